@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// errA11yIssues is returned by checkAccessibility in strict mode.
+var errA11yIssues = errors.New("accessibility issues found")
+
+// checkAccessibility lints the converted pages for missing image alt text,
+// skipped heading levels, and tables without headers, printing each issue
+// to w. In strict mode an error wrapping errA11yIssues is returned when any
+// are found.
+func checkAccessibility(pages []convertedPage, mode checkMode, w io.Writer) error {
+	found, affected := 0, 0
+	for _, page := range pages {
+		data, err := os.ReadFile(page.outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", page.outputPath, err)
+		}
+		issues := converter.CheckAccessibility(string(data))
+		for _, issue := range issues {
+			fmt.Fprintf(w, "Accessibility: %s:%d: %s\n", page.outputPath, issue.Line, issue.Message)
+		}
+		if len(issues) > 0 {
+			found += len(issues)
+			affected++
+		}
+	}
+
+	if found == 0 {
+		fmt.Fprintln(w, "Accessibility check: no issues")
+		return nil
+	}
+	fmt.Fprintf(w, "Accessibility check: %d issue(s) in %d page(s)\n", found, affected)
+	if mode == checkStrict {
+		return fmt.Errorf("%w: %d in %d page(s)", errA11yIssues, found, affected)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckAccessibility(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.md")
+	bad := filepath.Join(dir, "bad.md")
+	if err := os.WriteFile(good, []byte("# Title\n\n![Diagram](a.png)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bad, []byte("# Title\n\n### Skipped\n\n![](shot.png)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pages := []convertedPage{{outputPath: good}, {outputPath: bad}}
+
+	var out bytes.Buffer
+	if err := checkAccessibility(pages, checkReport, &out); err != nil {
+		t.Fatalf("report mode should not fail: %v", err)
+	}
+	for _, want := range []string{
+		"Accessibility: " + bad + ":3: heading level skips from H1 to H3: Skipped",
+		"Accessibility: " + bad + ":5: image without alt text: shot.png",
+		"Accessibility check: 2 issue(s) in 1 page(s)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := checkAccessibility(pages, checkStrict, &out); !errors.Is(err, errA11yIssues) {
+		t.Errorf("strict mode should return errA11yIssues, got %v", err)
+	}
+
+	out.Reset()
+	if err := checkAccessibility(pages[:1], checkStrict, &out); err != nil {
+		t.Errorf("no issues should pass strict mode, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Accessibility check: no issues") {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+
+	if err := checkAccessibility([]convertedPage{{outputPath: filepath.Join(dir, "missing.md")}}, checkReport, &out); err == nil {
+		t.Error("expected error for a missing output file")
+	}
+}
+
+func TestParseFlags_A11yCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    checkMode
+		wantErr bool
+	}{
+		{"default", []string{"input.doc"}, checkOff, false},
+		{"bare flag", []string{"--a11y-check", "input.doc"}, checkReport, false},
+		{"strict", []string{"--a11y-check=strict", "input.doc"}, checkStrict, false},
+		{"invalid mode", []string{"--a11y-check=loud", "input.doc"}, "", true},
+		{"org output", []string{"--a11y-check", "--to", "org", "input.doc"}, "", true},
+		{"chunked output", []string{"--a11y-check", "--chunk", "input.doc"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseFlags(tt.args, &bytes.Buffer{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlags(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if err == nil && cfg.a11yCheck != tt.want {
+				t.Errorf("a11yCheck = %q, want %q", cfg.a11yCheck, tt.want)
+			}
+		})
+	}
+}
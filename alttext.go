@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// altTextCommand runs a user-supplied command to describe images that have
+// no alt text. The command gets the image reference as its last argument,
+// and in C2MD_IMAGE_SRC; C2MD_IMAGE_PATH holds the local file when the
+// reference resolves to one. The first line of its standard output is the
+// alt text. Results are cached, since pages often share images.
+type altTextCommand struct {
+	args    []string
+	timeout time.Duration
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// newAltTextCommand parses a --alt-text-command value. Arguments are split
+// on whitespace; wrap commands that need quoting in a script.
+func newAltTextCommand(command string, timeout time.Duration) (*altTextCommand, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("--alt-text-command is empty")
+	}
+	return &altTextCommand{args: args, timeout: timeout, cache: make(map[string]string)}, nil
+}
+
+// describe returns the alt text for the image src referenced from a page
+// written into dir.
+func (c *altTextCommand) describe(src, dir string) (string, error) {
+	local := localImagePath(src, dir)
+	key := src
+	if local != "" {
+		key = local
+	}
+
+	c.mu.Lock()
+	alt, ok := c.cache[key]
+	c.mu.Unlock()
+	if ok {
+		return alt, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, c.args[0], append(c.args[1:], src)...)
+	cmd.Env = append(os.Environ(), "C2MD_IMAGE_SRC="+src, "C2MD_IMAGE_PATH="+local)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", fmt.Errorf("alt text command failed for %s: %w", src, err)
+	}
+
+	line, _, _ := bufio.NewReader(bytes.NewReader(out)).ReadLine()
+	alt = strings.TrimSpace(string(line))
+
+	c.mu.Lock()
+	c.cache[key] = alt
+	c.mu.Unlock()
+	return alt, nil
+}
+
+// localImagePath returns the local file an image reference points at,
+// resolving relative references against dir, or an empty string for
+// remote images and missing files.
+func localImagePath(src, dir string) string {
+	if u, err := url.Parse(src); err != nil || u.Scheme != "" || u.Host != "" {
+		return ""
+	}
+	p := src
+	if i := strings.IndexAny(p, "?#"); i != -1 {
+		p = p[:i]
+	}
+	if unescaped, err := url.PathUnescape(p); err == nil {
+		p = unescaped
+	}
+	p = filepath.FromSlash(p)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	if info, err := os.Stat(p); err != nil || info.IsDir() {
+		return ""
+	}
+	return p
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeAltTextScript writes an executable shell script and returns its path.
+func writeAltTextScript(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script commands are not available on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "describe.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAltTextCommand_Describe(t *testing.T) {
+	countFile := filepath.Join(t.TempDir(), "calls")
+	script := writeAltTextScript(t, `echo x >> "`+countFile+`"
+echo "Screenshot of $1 at $C2MD_IMAGE_PATH"
+echo "ignored second line"
+`)
+	cmd, err := newAltTextCommand(script, 10*time.Second)
+	if err != nil {
+		t.Fatalf("newAltTextCommand failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "img"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	local := filepath.Join(dir, "img", "my shot.png")
+	if err := os.WriteFile(local, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	alt, err := cmd.describe("img/my%20shot.png", dir)
+	if err != nil {
+		t.Fatalf("describe failed: %v", err)
+	}
+	if want := "Screenshot of img/my%20shot.png at " + local; alt != want {
+		t.Errorf("describe() = %q, want %q", alt, want)
+	}
+
+	// Same file again is answered from the cache
+	if _, err := cmd.describe("img/my%20shot.png", dir); err != nil {
+		t.Fatalf("describe failed: %v", err)
+	}
+	calls, _ := os.ReadFile(countFile)
+	if n := strings.Count(string(calls), "x"); n != 1 {
+		t.Errorf("command ran %d times, want 1", n)
+	}
+
+	remote, err := cmd.describe("https://example.com/a.png", dir)
+	if err != nil {
+		t.Fatalf("describe failed: %v", err)
+	}
+	if remote != "Screenshot of https://example.com/a.png at" {
+		t.Errorf("describe(remote) = %q", remote)
+	}
+}
+
+func TestAltTextCommand_Failure(t *testing.T) {
+	script := writeAltTextScript(t, "echo 'model unavailable' >&2\nexit 3\n")
+	cmd, err := newAltTextCommand(script, 10*time.Second)
+	if err != nil {
+		t.Fatalf("newAltTextCommand failed: %v", err)
+	}
+	_, err = cmd.describe("a.png", t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "model unavailable") {
+		t.Errorf("describe() error = %v, want the command's stderr", err)
+	}
+}
+
+func TestParseFlags_AltTextCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantCmd bool
+		wantErr bool
+	}{
+		{"default", []string{"input.doc"}, false, false},
+		{"command", []string{"--alt-text-command", "describe --model small", "input.doc"}, true, false},
+		{"blank command", []string{"--alt-text-command", " ", "input.doc"}, false, true},
+		{"org output", []string{"--alt-text-command", "describe", "--to", "org", "input.doc"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseFlags(tt.args, &bytes.Buffer{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlags(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if err == nil && (cfg.altText != nil) != tt.wantCmd {
+				t.Errorf("altText = %v, want set: %v", cfg.altText, tt.wantCmd)
+			}
+		})
+	}
+}
+
+func TestLocalImagePath(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.png")
+	if err := os.WriteFile(file, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"a.png", file},
+		{"a.png?version=2", file},
+		{"missing.png", ""},
+		{"https://example.com/a.png", ""},
+		{"//cdn.example.com/a.png", ""},
+		{".", ""},
+	}
+	for _, tt := range tests {
+		if got := localImagePath(tt.src, dir); got != tt.want {
+			t.Errorf("localImagePath(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
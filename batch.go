@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// convertBatch converts several exports like convertFile, but feeds their
+// pages through a single pandoc run (see converter.ConvertHTMLBatchToMarkdown)
+// to save the process start-up per page. It returns the error of each file.
+// The output format must not be binary.
+func convertBatch(inputPaths, outputPaths []string, cfg *config) []error {
+	errs := make([]error, len(inputPaths))
+	started := make([]time.Time, len(inputPaths))
+	jobs := make([]*fileJob, len(inputPaths))
+	var pages []converter.BatchPage
+	var batched []int
+	for i, inputPath := range inputPaths {
+		started[i] = time.Now()
+		cfg.progress.fileStarted(inputPath)
+		jobs[i], errs[i] = prepareFile(inputPath, outputPaths[i], cfg)
+		if errs[i] != nil || jobs[i] == nil {
+			fileDone(inputPath, outputPaths[i], errs[i], started[i], cfg)
+			continue
+		}
+		pages = append(pages, converter.BatchPage{HTML: jobs[i].html, Options: jobs[i].opts})
+		batched = append(batched, i)
+	}
+	if len(pages) == 0 {
+		return errs
+	}
+
+	if cfg.verbose {
+		fmt.Fprintf(cfg.out(), "Converting %d pages with one run of %s...\n", len(pages), cfg.options.Engine.Describe())
+	}
+	stageStarted := time.Now()
+	results := converter.ConvertHTMLBatchToMarkdown(pages)
+	for k, i := range batched {
+		job := jobs[i]
+		err := results[k].Err
+		if err != nil {
+			err = fmt.Errorf("failed to convert to Markdown: %w", err)
+		} else {
+			var content []byte
+			if content, err = job.finishMarkdown(results[k].Markdown, cfg); err == nil {
+				cfg.progress.stageCompleted(job.inputPath, stageConvert, stageStarted)
+				err = job.write(content, cfg)
+			}
+		}
+		errs[i] = err
+		fileDone(job.inputPath, job.outputPath, err, started[i], cfg)
+	}
+	return errs
+}
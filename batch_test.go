@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestConvertDirectory_BatchSize(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake pandoc scripts are not executable on Windows")
+	}
+
+	// A fake system pandoc that strips paragraph tags and the charset
+	// declaration and logs its runs
+	binDir := t.TempDir()
+	runLog := filepath.Join(binDir, "runs.log")
+	script := "#!/bin/sh\necho run >> " + runLog + "\nsed -e 's#</*p>##g' -e 's#<meta[^>]*>##g'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "pandoc"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tmpDir := t.TempDir()
+	for _, name := range []string{"Alpha", "Beta", "Gamma"} {
+		createTestConfluenceMIME(t, tmpDir, name+".doc", "<p>Page "+name+"</p>")
+	}
+
+	cfg := &config{batchSize: 2, sourceLink: sourceLinkNone, options: converter.Options{Engine: converter.EngineSystem}}
+	if err := convertDirectory(tmpDir, cfg); err != nil {
+		t.Fatalf("convertDirectory failed: %v", err)
+	}
+
+	for _, name := range []string{"Alpha", "Beta", "Gamma"} {
+		md, err := os.ReadFile(filepath.Join(tmpDir, name+".md"))
+		if err != nil {
+			t.Fatalf("output not written: %v", err)
+		}
+		if got := strings.TrimSpace(string(md)); got != "Page "+name {
+			t.Errorf("%s.md = %q, want %q", name, got, "Page "+name)
+		}
+	}
+	runs, _ := os.ReadFile(runLog)
+	if n := strings.Count(string(runs), "run\n"); n != 2 {
+		t.Errorf("pandoc ran %d times, want 2 (a batch of two and a single page)", n)
+	}
+}
+
+func TestParseFlags_BatchSize(t *testing.T) {
+	cfg, err := parseFlags([]string{"--dir", "exports"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.batchSize != 1 {
+		t.Errorf("batchSize = %d, want the default 1", cfg.batchSize)
+	}
+
+	cfg, err = parseFlags([]string{"--batch-size", "50", "--dir", "exports"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.batchSize != 50 {
+		t.Errorf("batchSize = %d, want 50", cfg.batchSize)
+	}
+
+	if _, err := parseFlags([]string{"--batch-size", "0", "--dir", "exports"}, &bytes.Buffer{}); err == nil {
+		t.Error("Expected error for --batch-size 0")
+	}
+}
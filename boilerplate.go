@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// boilerplateVariablePattern matches template variables such as {{title}}
+// in --prepend and --append files, capturing the variable name.
+var boilerplateVariablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_]+)\s*\}\}`)
+
+// boilerplateVariables lists the variables available in --prepend and
+// --append files.
+var boilerplateVariables = []string{"title", "date", "source", "page_id", "space", "version"}
+
+// loadBoilerplate reads the --prepend or --append file at path, rejecting
+// unknown template variables.
+func loadBoilerplate(flagName, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read --%s file: %w", flagName, err)
+	}
+	text := string(data)
+	for _, m := range boilerplateVariablePattern.FindAllStringSubmatch(text, -1) {
+		if !slices.Contains(boilerplateVariables, m[1]) {
+			return "", fmt.Errorf("unknown variable %s in --%s file %s (valid: %s)", m[0], flagName, path, strings.Join(boilerplateVariables, ", "))
+		}
+	}
+	return text, nil
+}
+
+// boilerplateValues returns the template variable values for a page: its
+// title, the conversion date, the source file name, the page ID and space
+// key from the export, and the tool version.
+func boilerplateValues(inputPath, html string, now time.Time) map[string]string {
+	meta, _ := converter.ReadExportMetadata(inputPath)
+	info := converter.ExtractPageInfo(html)
+	return map[string]string{
+		"title":   pageTitle(inputPath, meta),
+		"date":    now.Format("2006-01-02"),
+		"source":  filepath.Base(inputPath),
+		"page_id": info.PageID,
+		"space":   info.SpaceKey,
+		"version": version,
+	}
+}
+
+// renderBoilerplate substitutes the template variables in text.
+func renderBoilerplate(text string, values map[string]string) string {
+	return boilerplateVariablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		return values[boilerplateVariablePattern.FindStringSubmatch(match)[1]]
+	})
+}
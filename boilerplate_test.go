@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadBoilerplate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "header.md")
+	if err := os.WriteFile(valid, []byte("> Migrated from Confluence ({{ space }}) on {{date}}.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	unknown := filepath.Join(dir, "footer.md")
+	if err := os.WriteFile(unknown, []byte("By {{author}}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := loadBoilerplate("prepend", valid)
+	if err != nil {
+		t.Fatalf("loadBoilerplate failed: %v", err)
+	}
+	if !strings.Contains(text, "{{ space }}") {
+		t.Errorf("Expected the template text, got %q", text)
+	}
+
+	if _, err := loadBoilerplate("append", unknown); err == nil || !strings.Contains(err.Error(), "unknown variable {{author}} in --append file") {
+		t.Errorf("Expected unknown variable error, got: %v", err)
+	}
+	if _, err := loadBoilerplate("prepend", filepath.Join(dir, "missing.md")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestRenderBoilerplate(t *testing.T) {
+	values := map[string]string{"title": "Setup Guide", "date": "2026-03-01", "space": ""}
+	got := renderBoilerplate("# {{title}}\n\nConverted {{ date }}{{space}}.", values)
+	if want := "# Setup Guide\n\nConverted 2026-03-01."; got != want {
+		t.Errorf("renderBoilerplate() = %q, want %q", got, want)
+	}
+}
+
+func TestBoilerplateValues(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := createTestConfluenceMIME(t, tmpDir, "page.doc", "<p>Hello</p>")
+	html := `<meta name="ajs-page-id" content="12345"><meta name="ajs-space-key" content="ENG">`
+
+	values := boilerplateValues(inputPath, html, time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC))
+	for key, want := range map[string]string{"date": "2026-03-01", "source": "page.doc", "page_id": "12345", "space": "ENG", "version": version} {
+		if values[key] != want {
+			t.Errorf("%s = %q, want %q", key, values[key], want)
+		}
+	}
+	if values["title"] == "" {
+		t.Error("Expected a page title")
+	}
+}
+
+func TestParseFlags_Boilerplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "banner.md")
+	if err := os.WriteFile(path, []byte("Migrated {{date}}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	cfg, err := parseFlags([]string{"--prepend", path, "--append", path, "input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.prependText != "Migrated {{date}}\n" || cfg.appendText != "Migrated {{date}}\n" {
+		t.Errorf("Expected boilerplate templates, got %q and %q", cfg.prependText, cfg.appendText)
+	}
+
+	if _, err := parseFlags([]string{"--append", path, "--to", "org", "input.doc"}, &buf); err == nil || !strings.Contains(err.Error(), "--append requires --to markdown") {
+		t.Errorf("Expected markdown-only error, got: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// chunkExtension is the output file extension in --chunk mode.
+const chunkExtension = ".jsonl"
+
+// defaultMaxTokens is the default --max-tokens chunk size, which suits
+// common embedding models.
+const defaultMaxTokens = 512
+
+// chunkOverlapDivisor sets the overlap between consecutive chunks to a
+// tenth of the chunk size.
+const chunkOverlapDivisor = 10
+
+// chunkRecord is a line of --chunk output: a chunk of a page with the
+// metadata vector stores need to cite it.
+type chunkRecord struct {
+	ID       string   `json:"id"`
+	Source   string   `json:"source"`
+	Title    string   `json:"title"`
+	PageID   string   `json:"pageId,omitempty"`
+	Chunk    int      `json:"chunk"`
+	Headings []string `json:"headings"`
+	Text     string   `json:"text"`
+	Tokens   int      `json:"tokens"`
+}
+
+// renderChunks splits converted Markdown into chunks of at most maxTokens
+// estimated tokens and renders them as JSON lines. Chunk IDs combine the
+// source file name and the chunk number.
+func renderChunks(md, inputPath, title string, info converter.PageInfo, maxTokens int) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	source := filepath.Base(inputPath)
+	for i, c := range converter.ChunkMarkdown(md, maxTokens, maxTokens/chunkOverlapDivisor) {
+		record := chunkRecord{
+			ID:       fmt.Sprintf("%s#%d", source, i+1),
+			Source:   source,
+			Title:    title,
+			PageID:   info.PageID,
+			Chunk:    i + 1,
+			Headings: c.Headings,
+			Text:     c.Text,
+			Tokens:   c.Tokens,
+		}
+		if err := enc.Encode(record); err != nil {
+			return nil, fmt.Errorf("failed to encode chunk: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
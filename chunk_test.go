@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestRenderChunks(t *testing.T) {
+	md := "---\ntitle: Runbook\n---\n\n# Restart\n\nStop the <service> first.\n\n## Verify\n\nCheck the logs.\n"
+
+	data, err := renderChunks(md, "/exports/Runbook.doc", "Runbook", converter.PageInfo{PageID: "42"}, 100)
+	if err != nil {
+		t.Fatalf("renderChunks failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got %d:\n%s", len(lines), data)
+	}
+	var records []chunkRecord
+	for _, line := range lines {
+		var r chunkRecord
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		records = append(records, r)
+	}
+
+	first := records[0]
+	if first.ID != "Runbook.doc#1" || first.Source != "Runbook.doc" || first.Title != "Runbook" || first.PageID != "42" || first.Chunk != 1 {
+		t.Errorf("unexpected metadata: %+v", first)
+	}
+	if first.Text != "# Restart\n\nStop the <service> first." {
+		t.Errorf("unexpected text: %q", first.Text)
+	}
+	if got := strings.Join(records[1].Headings, " > "); got != "Restart > Verify" {
+		t.Errorf("heading path = %q, want %q", got, "Restart > Verify")
+	}
+	if !strings.Contains(lines[0], "<service>") {
+		t.Errorf("expected HTML characters to stay unescaped, got: %s", lines[0])
+	}
+}
+
+func TestParseFlags_Chunk(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantChunk bool
+		wantMax   int
+		wantErr   bool
+	}{
+		{"default", []string{"input.doc"}, false, defaultMaxTokens, false},
+		{"chunk", []string{"--chunk", "input.doc"}, true, defaultMaxTokens, false},
+		{"max tokens", []string{"--chunk", "--max-tokens", "256", "input.doc"}, true, 256, false},
+		{"zero max tokens", []string{"--chunk", "--max-tokens", "0", "input.doc"}, false, 0, true},
+		{"org output", []string{"--chunk", "--to", "org", "input.doc"}, false, 0, true},
+		{"link check", []string{"--chunk", "--check-links", "input.doc"}, false, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseFlags(tt.args, &bytes.Buffer{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlags(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if cfg.chunk != tt.wantChunk || cfg.maxTokens != tt.wantMax {
+				t.Errorf("chunk = %v, maxTokens = %d, want %v, %d", cfg.chunk, cfg.maxTokens, tt.wantChunk, tt.wantMax)
+			}
+			if tt.wantChunk {
+				if got := outputPathFor("Page.doc", cfg); got != "Page.jsonl" {
+					t.Errorf("outputPathFor() = %q, want Page.jsonl", got)
+				}
+			}
+		})
+	}
+}
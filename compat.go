@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// compatCommand is the subcommand scoring conversion fidelity on the
+// embedded corpus of Confluence constructs.
+const compatCommand = "compat"
+
+// compatCorpusJSON is the compatibility corpus: anonymized excerpts of
+// real-world Confluence exports, one per construct.
+//
+//go:embed assets/compat-corpus.json
+var compatCorpusJSON []byte
+
+// compatCase is a construct of the compatibility corpus: exported HTML and
+// what its Markdown should and should not contain.
+type compatCase struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	HTML     string `json:"html"`
+	// Expect lists text the converted Markdown should contain.
+	Expect []string `json:"expect"`
+	// Reject lists text, usually leftover Confluence markup, that the
+	// converted Markdown should not contain.
+	Reject []string `json:"reject"`
+}
+
+// compatResult is the score of one construct.
+type compatResult struct {
+	compatCase
+	passed, total int
+	// failures describe the checks that failed, or the conversion error.
+	failures []string
+}
+
+// status rates the construct as supported, partial, or unsupported.
+func (r compatResult) status() string {
+	switch {
+	case r.passed == r.total:
+		return "supported"
+	case r.passed > 0:
+		return "partial"
+	default:
+		return "unsupported"
+	}
+}
+
+// loadCompatCorpus parses and checks the embedded corpus.
+func loadCompatCorpus() ([]compatCase, error) {
+	var cases []compatCase
+	if err := json.Unmarshal(compatCorpusJSON, &cases); err != nil {
+		return nil, fmt.Errorf("failed to parse compatibility corpus: %w", err)
+	}
+	for i, c := range cases {
+		if c.Name == "" || c.Category == "" || c.HTML == "" {
+			return nil, fmt.Errorf("compatibility corpus entry %d needs a name, category, and html", i+1)
+		}
+		if len(c.Expect)+len(c.Reject) == 0 {
+			return nil, fmt.Errorf("compatibility corpus entry %q has no checks", c.Name)
+		}
+	}
+	return cases, nil
+}
+
+// scoreCompatCase checks the Markdown converted from c.
+func scoreCompatCase(c compatCase, md string) compatResult {
+	r := compatResult{compatCase: c, total: len(c.Expect) + len(c.Reject)}
+	for _, want := range c.Expect {
+		if strings.Contains(md, want) {
+			r.passed++
+		} else {
+			r.failures = append(r.failures, fmt.Sprintf("missing %q", want))
+		}
+	}
+	for _, unwanted := range c.Reject {
+		if !strings.Contains(md, unwanted) {
+			r.passed++
+		} else {
+			r.failures = append(r.failures, fmt.Sprintf("unexpected %q", unwanted))
+		}
+	}
+	return r
+}
+
+// scoreCompatCorpus converts every case with convert and scores the
+// output. A case that fails to convert passes none of its checks.
+func scoreCompatCorpus(cases []compatCase, convert func(html string) (string, error)) []compatResult {
+	results := make([]compatResult, len(cases))
+	for i, c := range cases {
+		md, err := convert(c.HTML)
+		if err != nil {
+			results[i] = compatResult{
+				compatCase: c,
+				total:      len(c.Expect) + len(c.Reject),
+				failures:   []string{fmt.Sprintf("conversion failed: %v", err)},
+			}
+			continue
+		}
+		results[i] = scoreCompatCase(c, md)
+	}
+	return results
+}
+
+// writeCompatScorecard writes the results grouped by category, in corpus
+// order, followed by totals. With verbose, the failed checks are listed
+// under each construct.
+func writeCompatScorecard(w io.Writer, results []compatResult, verbose bool) {
+	var categories []string
+	byCategory := make(map[string][]compatResult)
+	width := 0
+	for _, r := range results {
+		if _, ok := byCategory[r.Category]; !ok {
+			categories = append(categories, r.Category)
+		}
+		byCategory[r.Category] = append(byCategory[r.Category], r)
+		width = max(width, len(r.Name))
+	}
+
+	counts := make(map[string]int)
+	passed, total := 0, 0
+	for i, category := range categories {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, category)
+		for _, r := range byCategory[category] {
+			fmt.Fprintf(w, "  %-11s  %-*s  %d/%d\n", r.status(), width, r.Name, r.passed, r.total)
+			if verbose {
+				for _, failure := range r.failures {
+					fmt.Fprintf(w, "               %s\n", failure)
+				}
+			}
+			counts[r.status()]++
+			passed += r.passed
+			total += r.total
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d constructs: %d supported, %d partial, %d unsupported", len(results), counts["supported"], counts["partial"], counts["unsupported"])
+	if total > 0 {
+		fmt.Fprintf(w, " (%d%% of checks passed)", passed*100/total)
+	}
+	fmt.Fprintln(w)
+}
+
+// runCompat converts the embedded corpus with the default options and
+// prints a fidelity scorecard per construct. It returns the process exit
+// code, which is nonzero only when the corpus cannot be converted at all.
+func runCompat(args []string, w io.Writer) int {
+	fs := flag.NewFlagSet(compatCommand, flag.ContinueOnError)
+	fs.SetOutput(w)
+	verbose := fs.Bool("v", false, "List the failed checks of each construct")
+	verboseLong := fs.Bool("verbose", false, "List the failed checks of each construct")
+	fs.Usage = func() {
+		fmt.Fprintf(w, "Usage: confluence2md %s [-v]\n\n", compatCommand)
+		fmt.Fprintf(w, "Converts an embedded corpus of Confluence constructs and scores the output.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+
+	cases, err := loadCompatCorpus()
+	if err != nil {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return 1
+	}
+	if err := converter.CheckPandoc(); err != nil {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return 1
+	}
+
+	results := scoreCompatCorpus(cases, converter.ConvertHTMLToMarkdown)
+	writeCompatScorecard(w, results, *verbose || *verboseLong)
+	return 0
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestLoadCompatCorpus(t *testing.T) {
+	cases, err := loadCompatCorpus()
+	if err != nil {
+		t.Fatalf("loadCompatCorpus() error = %v", err)
+	}
+	if len(cases) == 0 {
+		t.Fatal("expected a non-empty corpus")
+	}
+	seen := make(map[string]bool)
+	for _, c := range cases {
+		if seen[c.Name] {
+			t.Errorf("construct %q appears twice", c.Name)
+		}
+		seen[c.Name] = true
+	}
+}
+
+func TestScoreCompatCase(t *testing.T) {
+	c := compatCase{Name: "Info macro", Expect: []string{"> **Info:**", "Deploys"}, Reject: []string{"<div"}}
+
+	tests := []struct {
+		name         string
+		md           string
+		wantPassed   int
+		wantStatus   string
+		wantFailures []string
+	}{
+		{"all checks pass", "> **Info:** Deploys are frozen.", 3, "supported", nil},
+		{"missing text", "Deploys are frozen.", 2, "partial", []string{`missing "> **Info:**"`}},
+		{"leftover markup", "<div>Frozen</div>", 0, "unsupported", []string{`missing "> **Info:**"`, `missing "Deploys"`, `unexpected "<div"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := scoreCompatCase(c, tt.md)
+			if r.passed != tt.wantPassed || r.total != 3 {
+				t.Errorf("scoreCompatCase() = %d/%d, want %d/3", r.passed, r.total, tt.wantPassed)
+			}
+			if got := r.status(); got != tt.wantStatus {
+				t.Errorf("status() = %q, want %q", got, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(r.failures, tt.wantFailures) {
+				t.Errorf("failures = %q, want %q", r.failures, tt.wantFailures)
+			}
+		})
+	}
+}
+
+func TestScoreCompatCorpus_ConversionError(t *testing.T) {
+	cases := []compatCase{{Name: "Panel", Category: "Macros", HTML: "<div>", Expect: []string{"x", "y"}}}
+	results := scoreCompatCorpus(cases, func(string) (string, error) { return "", errors.New("pandoc crashed") })
+	if len(results) != 1 || results[0].passed != 0 || results[0].total != 2 {
+		t.Fatalf("scoreCompatCorpus() = %+v, want 0/2", results)
+	}
+	if want := []string{"conversion failed: pandoc crashed"}; !reflect.DeepEqual(results[0].failures, want) {
+		t.Errorf("failures = %q, want %q", results[0].failures, want)
+	}
+}
+
+func TestWriteCompatScorecard(t *testing.T) {
+	results := []compatResult{
+		{compatCase: compatCase{Name: "Info macro", Category: "Macros"}, passed: 2, total: 2},
+		{compatCase: compatCase{Name: "Merged cells", Category: "Tables"}, passed: 1, total: 2, failures: []string{`unexpected "<table"`}},
+		{compatCase: compatCase{Name: "Task list", Category: "Macros"}, passed: 0, total: 2, failures: []string{`missing "- [x]"`}},
+	}
+
+	var buf bytes.Buffer
+	writeCompatScorecard(&buf, results, false)
+	want := `Macros
+  supported    Info macro    2/2
+  unsupported  Task list     0/2
+
+Tables
+  partial      Merged cells  1/2
+
+3 constructs: 1 supported, 1 partial, 1 unsupported (50% of checks passed)
+`
+	if got := buf.String(); got != want {
+		t.Errorf("writeCompatScorecard() =\n%s\nwant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	writeCompatScorecard(&buf, results, true)
+	if !strings.Contains(buf.String(), "Merged cells  1/2\n               unexpected \"<table\"\n") {
+		t.Errorf("expected failed checks in verbose output, got:\n%s", buf.String())
+	}
+}
+
+func TestRunCompat(t *testing.T) {
+	if err := converter.CheckPandoc(); err != nil {
+		t.Skipf("Pandoc not available, skipping test: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if code := runCompat([]string{"-v"}, &buf); code != 0 {
+		t.Fatalf("runCompat() = %d, output:\n%s", code, buf.String())
+	}
+	for _, want := range []string{"Macros", "Tables", "Layouts", "Info macro", "constructs:"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected scorecard to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRunCompat_BadFlag(t *testing.T) {
+	var buf bytes.Buffer
+	if code := runCompat([]string{"--nope"}, &buf); code != 1 {
+		t.Errorf("runCompat() = %d, want 1", code)
+	}
+}
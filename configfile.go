@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// fileConfig holds settings loaded from the JSON file given with --config.
+// It covers settings that are awkward to express as flags.
+type fileConfig struct {
+	// LinkMappings map spaces or URL prefixes to the base URL of the
+	// Confluence instance serving them.
+	LinkMappings []converter.LinkMapping `json:"linkMappings"`
+
+	// PanelColors maps panel background colors to admonition types
+	// (info, note, tip, success, warning, or none), overriding and
+	// extending the built-in color table.
+	PanelColors map[string]converter.AdmonitionType `json:"panelColors"`
+
+	// AdmonitionLabels overrides the labels of admonition types (info,
+	// note, tip, success, or warning) for the language chosen with --lang.
+	AdmonitionLabels map[converter.AdmonitionType]string `json:"admonitionLabels"`
+
+	// Emoticons maps emoticon alt texts like "(smile)" and emoji shortcodes
+	// like ":smile:" to emoji, overriding and extending the built-in table.
+	Emoticons map[string]string `json:"emoticons"`
+
+	// Replacements are ordered regex rewrites for organization-specific
+	// cleanups, applied to the HTML or the Markdown.
+	Replacements []converter.Replacement `json:"replacements"`
+
+	// Redactions are custom rules for --redact, applied after the built-in
+	// ones. A rule without a pattern sets the placeholder of the built-in
+	// rule it names.
+	Redactions []converter.RedactionRule `json:"redactions"`
+
+	// Routes place converted pages into subdirectories by label or space.
+	// The first matching route wins.
+	Routes []outputRoute `json:"routes"`
+
+	// Template is the path of a pandoc template, relative to the config file.
+	Template string `json:"template"`
+
+	// TemplateText is an inline pandoc template, so that an organization's
+	// standard template can be shipped inside the config file itself.
+	TemplateText string `json:"templateText"`
+
+	// ReferenceDoc is the path of a DOCX reference document, relative to
+	// the config file.
+	ReferenceDoc string `json:"referenceDoc"`
+
+	// Profiles defines named presets of flag values for --profile, keyed
+	// by flag name without dashes. They take precedence over built-in
+	// profiles of the same name.
+	Profiles map[string]profile `json:"profiles"`
+
+	// Confluence holds the credentials of --url and sync, for the ones not
+	// given with flags or in the environment.
+	Confluence credentials `json:"confluence"`
+
+	// Pipeline disables and reorders the steps of the conversion pipeline.
+	Pipeline converter.PipelineConfig `json:"pipeline"`
+
+	// pipeline is the conversion pipeline configured by Pipeline, or nil
+	// for the default one.
+	pipeline []converter.Transform
+}
+
+// loadConfigFile reads and validates a JSON configuration file.
+func loadConfigFile(path string) (*fileConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	var fc fileConfig
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	for _, mapping := range fc.LinkMappings {
+		if err := mapping.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+
+	if err := converter.ValidatePanelColors(fc.PanelColors); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	if err := converter.ValidateAdmonitionLabels(fc.AdmonitionLabels); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	if err := converter.ValidateEmoticons(fc.Emoticons); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	if len(fc.Pipeline.Disable) > 0 || len(fc.Pipeline.Order) > 0 {
+		if fc.pipeline, err = converter.ConfigurePipeline(converter.DefaultPipeline(), fc.Pipeline); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+
+	for _, r := range fc.Replacements {
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+
+	for _, r := range fc.Routes {
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+
+	for _, r := range fc.Redactions {
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+
+	if fc.Template != "" && fc.TemplateText != "" {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, errors.New("template and templateText are mutually exclusive"))
+	}
+	dir := filepath.Dir(path)
+	for _, p := range []*string{&fc.Template, &fc.ReferenceDoc, &fc.Confluence.CookieJar, &fc.Confluence.HeaderFile} {
+		if *p == "" {
+			continue
+		}
+		*p = resolveConfigPath(dir, *p)
+		if err := checkFileExists(*p); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+
+	return &fc, nil
+}
+
+// resolveConfigPath resolves a path given in a config file relative to the
+// directory holding the config file.
+func resolveConfigPath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// checkFileExists returns an error if path does not name a regular file.
+func checkFileExists(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return nil
+}
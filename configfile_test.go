@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// writeConfigFile writes a config file into a temp directory and returns its path
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "confluence2md.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `{
+  "linkMappings": [
+    {"space": "ENG", "baseURL": "https://eng.example.com"},
+    {"prefix": "/legacy/", "baseURL": "https://old.example.com"}
+  ]
+}`)
+
+	fc, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatalf("loadConfigFile failed: %v", err)
+	}
+	if len(fc.LinkMappings) != 2 {
+		t.Fatalf("Expected 2 link mappings, got %d", len(fc.LinkMappings))
+	}
+	if fc.LinkMappings[0].Space != "ENG" || fc.LinkMappings[1].Prefix != "/legacy/" {
+		t.Errorf("Unexpected link mappings: %+v", fc.LinkMappings)
+	}
+}
+
+func TestLoadConfigFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"invalid JSON", `{"linkMappings": [`, "failed to parse"},
+		{"unknown field", `{"linkMapping": []}`, "unknown field"},
+		{"invalid mapping", `{"linkMappings": [{"space": "ENG"}]}`, "missing baseURL"},
+		{"template and templateText", `{"template": "a.html", "templateText": "$body$"}`, "mutually exclusive"},
+		{"missing template", `{"template": "missing.html"}`, "missing.html"},
+		{"missing reference doc", `{"referenceDoc": "missing.docx"}`, "missing.docx"},
+		{"invalid panel color", `{"panelColors": {"blue": "info"}}`, "invalid color"},
+		{"unknown admonition type", `{"panelColors": {"#ffffff": "danger"}}`, "unknown admonition type"},
+		{"empty admonition label", `{"admonitionLabels": {"tip": ""}}`, "empty label"},
+		{"invalid replacement pattern", `{"replacements": [{"pattern": "(", "replacement": ""}]}`, "invalid replacement pattern"},
+		{"invalid redaction pattern", `{"redactions": [{"name": "host", "pattern": "("}]}`, "invalid pattern for redaction rule"},
+		{"redaction override of unknown rule", `{"redactions": [{"name": "phone", "placeholder": "x"}]}`, "not a built-in rule"},
+		{"unknown replacement stage", `{"replacements": [{"pattern": "x", "stage": "docx"}]}`, "unknown stage"},
+		{"unknown pipeline step", `{"pipeline": {"disable": ["smileys"]}}`, `unknown transform "smileys"`},
+		{"pipeline step ordered twice", `{"pipeline": {"order": ["toc", "toc"]}}`, "ordered twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfigFile(writeConfigFile(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseFlags_PanelColors(t *testing.T) {
+	path := writeConfigFile(t, `{"panelColors": {"#FFF": "tip"}}`)
+
+	var buf bytes.Buffer
+	cfg, err := parseFlags([]string{"--config", path, "input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := cfg.options.PanelColors["#FFF"]; got != "tip" {
+		t.Errorf("Expected panel colors from config file, got: %+v", cfg.options.PanelColors)
+	}
+}
+
+func TestParseFlags_AdmonitionLabels(t *testing.T) {
+	path := writeConfigFile(t, `{"admonitionLabels": {"note": "Achtung"}}`)
+
+	var buf bytes.Buffer
+	cfg, err := parseFlags([]string{"--config", path, "--lang", "de", "input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := cfg.options.AdmonitionLabels
+	if got[converter.AdmonitionNote] != "Achtung" || got[converter.AdmonitionTip] != "Tipp" {
+		t.Errorf("Expected German labels overridden by the config file, got: %+v", got)
+	}
+}
+
+func TestParseFlags_Replacements(t *testing.T) {
+	path := writeConfigFile(t, `{"replacements": [
+  {"pattern": "old\\.example\\.com", "replacement": "new.example.com"},
+  {"pattern": "<div class=\"banner\">.*?</div>", "replacement": "", "stage": "html"}
+]}`)
+
+	var buf bytes.Buffer
+	cfg, err := parseFlags([]string{"--config", path, "input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := cfg.options.Replacements
+	if len(got) != 2 || got[0].Pattern != `old\.example\.com` || got[1].Stage != converter.StageHTML {
+		t.Errorf("Expected replacements from config file, got: %+v", got)
+	}
+}
+
+func TestParseFlags_Pipeline(t *testing.T) {
+	path := writeConfigFile(t, `{"pipeline": {"disable": ["liquid-escape"], "order": ["toc", "heading-numbers"]}}`)
+
+	var buf bytes.Buffer
+	cfg, err := parseFlags([]string{"--config", path, "input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var names []string
+	for _, step := range cfg.options.Pipeline {
+		names = append(names, step.Name)
+	}
+	got := strings.Join(names, ",")
+	if strings.Contains(got, "liquid-escape") || !strings.Contains(got, "toc,heading-numbers") {
+		t.Errorf("Expected configured pipeline from config file, got: %s", got)
+	}
+
+	cfg, err = parseFlags([]string{"input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.options.Pipeline != nil {
+		t.Error("Expected the default pipeline without a config file")
+	}
+}
+
+func TestParseFlags_Emoticons(t *testing.T) {
+	path := writeConfigFile(t, `{"emoticons": {"(tick)": "✔️", ":party:": "🥳"}}`)
+
+	var buf bytes.Buffer
+	cfg, err := parseFlags([]string{"--config", path, "input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := cfg.options.Emoticons; got["(tick)"] != "✔️" || got[":party:"] != "🥳" {
+		t.Errorf("Expected emoticons from config file, got: %v", got)
+	}
+
+	path = writeConfigFile(t, `{"emoticons": {"party": "🥳"}}`)
+	if _, err := parseFlags([]string{"--config", path, "input.doc"}, &buf); err == nil {
+		t.Error("Expected error for emoticon name without parentheses or colons")
+	}
+}
+
+func TestParseFlags_Redactions(t *testing.T) {
+	path := writeConfigFile(t, `{"redactions": [
+  {"name": "email", "placeholder": "<email>"},
+  {"name": "hostname", "pattern": "[a-z]+\\.corp\\.example\\.com"}
+]}`)
+
+	var buf bytes.Buffer
+	cfg, err := parseFlags([]string{"--config", path, "--redact", "input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got, _, err := converter.Redact("Mail bob@example.com on build.corp.example.com", cfg.redaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Mail <email> on [REDACTED:hostname]"; got != want {
+		t.Errorf("Redact() with config rules = %q, want %q", got, want)
+	}
+
+	cfg, err = parseFlags([]string{"--config", path, "input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.redaction != nil {
+		t.Errorf("redaction rules should only be set with --redact, got %+v", cfg.redaction)
+	}
+
+	for _, format := range []string{"docx", "json"} {
+		if _, err := parseFlags([]string{"--redact", "--to", format, "input.doc"}, &buf); err == nil {
+			t.Errorf("Expected error for --redact with %s output", format)
+		}
+	}
+}
+
+func TestLoadConfigFile_Missing(t *testing.T) {
+	if _, err := loadConfigFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing config file")
+	}
+}
+
+func TestParseFlags_ConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `{"linkMappings": [{"space": "ENG", "baseURL": "https://eng.example.com"}]}`)
+
+	var buf bytes.Buffer
+	cfg, err := parseFlags([]string{"--config", path, "input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.options.LinkMappings) != 1 || cfg.options.LinkMappings[0].BaseURL != "https://eng.example.com" {
+		t.Errorf("Expected link mappings from config file, got: %+v", cfg.options.LinkMappings)
+	}
+}
+
+func TestLoadConfigFile_TemplatePaths(t *testing.T) {
+	path := writeConfigFile(t, `{"template": "templates/corp.html", "referenceDoc": "corp.docx", "confluence": {"cookieJar": "cookies.txt"}}`)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(filepath.Join(dir, "templates"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"templates/corp.html", "corp.docx", "cookies.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fc, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatalf("loadConfigFile failed: %v", err)
+	}
+	if want := filepath.Join(dir, "templates", "corp.html"); fc.Template != want {
+		t.Errorf("Template = %q, want path relative to the config file %q", fc.Template, want)
+	}
+	if want := filepath.Join(dir, "corp.docx"); fc.ReferenceDoc != want {
+		t.Errorf("ReferenceDoc = %q, want %q", fc.ReferenceDoc, want)
+	}
+	if want := filepath.Join(dir, "cookies.txt"); fc.Confluence.CookieJar != want {
+		t.Errorf("Confluence.CookieJar = %q, want %q", fc.Confluence.CookieJar, want)
+	}
+}
+
+func TestParseFlags_TemplateOptions(t *testing.T) {
+	dir := t.TempDir()
+	flagTemplate := filepath.Join(dir, "flag.md")
+	refDoc := filepath.Join(dir, "ref.docx")
+	for _, p := range []string{flagTemplate, refDoc} {
+		if err := os.WriteFile(p, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configPath := writeConfigFile(t, `{"templateText": "$title$\n$body$"}`)
+
+	tests := []struct {
+		name             string
+		args             []string
+		wantTemplate     string
+		wantTemplateText string
+		wantRefDoc       string
+	}{
+		{"inline template from config", []string{"--config", configPath, "input.doc"}, "", "$title$\n$body$", ""},
+		{"flag overrides config", []string{"--config", configPath, "--template", flagTemplate, "input.doc"}, flagTemplate, "", ""},
+		{"reference doc", []string{"--to", "docx", "--reference-doc", refDoc, "input.doc"}, "", "", refDoc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cfg, err := parseFlags(tt.args, &buf)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if cfg.options.Template != tt.wantTemplate || cfg.options.TemplateText != tt.wantTemplateText || cfg.options.ReferenceDoc != tt.wantRefDoc {
+				t.Errorf("options = %+v", cfg.options)
+			}
+		})
+	}
+}
+
+func TestParseFlags_TemplateErrors(t *testing.T) {
+	dir := t.TempDir()
+	refDoc := filepath.Join(dir, "ref.docx")
+	if err := os.WriteFile(refDoc, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing template", []string{"--template", filepath.Join(dir, "missing.md"), "input.doc"}},
+		{"reference doc without docx", []string{"--reference-doc", refDoc, "input.doc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if _, err := parseFlags(tt.args, &buf); err == nil {
+				t.Errorf("Expected error for %v", tt.args)
+			}
+		})
+	}
+}
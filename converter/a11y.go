@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// tableDelimiterRowPattern matches the delimiter row under the header
+	// row of a pipe table.
+	tableDelimiterRowPattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)+\|?\s*$`)
+
+	// htmlTableOpenPattern and htmlTableClosePattern match raw HTML table tags.
+	htmlTableOpenPattern  = regexp.MustCompile(`(?i)<table[\s>]`)
+	htmlTableClosePattern = regexp.MustCompile(`(?i)</table>`)
+
+	// htmlTableHeaderPattern matches header cells in raw HTML tables.
+	htmlTableHeaderPattern = regexp.MustCompile(`(?i)<th[\s>]`)
+)
+
+// AccessibilityIssue is a problem found by CheckAccessibility.
+type AccessibilityIssue struct {
+	// Line is the 1-based line of the problem in the document.
+	Line int
+	// Message describes the problem.
+	Message string
+}
+
+// CheckAccessibility lints converted Markdown for common accessibility
+// problems: images without alt text, headings that skip a level (H1
+// followed by H4), and tables without a header row. Code is ignored.
+func CheckAccessibility(md string) []AccessibilityIssue {
+	lines := strings.Split(md, "\n")
+	var issues []AccessibilityIssue
+
+	prevLevel := 0
+	for _, h := range findHeadings(lines) {
+		if prevLevel > 0 && h.level > prevLevel+1 {
+			issues = append(issues, AccessibilityIssue{
+				Line:    h.line + 1,
+				Message: fmt.Sprintf("heading level skips from H%d to H%d: %s", prevLevel, h.level, h.text),
+			})
+		}
+		prevLevel = h.level
+	}
+
+	inFence := false
+	tableStart, tableHasHeader := -1, false
+	for i, line := range lines {
+		if fencePattern.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		line = maskInlineCode(line, func(string) string { return "" })
+
+		for _, src := range imagesWithoutAlt(line) {
+			issues = append(issues, AccessibilityIssue{Line: i + 1, Message: "image without alt text: " + src})
+		}
+
+		if i > 0 && tableDelimiterRowPattern.MatchString(line) && strings.Trim(lines[i-1], " \t|") == "" && strings.Contains(lines[i-1], "|") {
+			issues = append(issues, AccessibilityIssue{Line: i, Message: "table without header row"})
+		}
+
+		if htmlTableOpenPattern.MatchString(line) {
+			tableStart, tableHasHeader = i, false
+		}
+		if tableStart != -1 && htmlTableHeaderPattern.MatchString(line) {
+			tableHasHeader = true
+		}
+		if tableStart != -1 && htmlTableClosePattern.MatchString(line) {
+			if !tableHasHeader {
+				issues = append(issues, AccessibilityIssue{Line: tableStart + 1, Message: "table without header row"})
+			}
+			tableStart = -1
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues
+}
+
+// imagesWithoutAlt returns the sources of the Markdown images and raw
+// <img> tags in a line that have no alt text.
+func imagesWithoutAlt(line string) []string {
+	var srcs []string
+	for _, m := range markdownLinkTargetPattern.FindAllStringSubmatch(line, -1) {
+		if m[1] == "!" && strings.TrimSpace(m[2]) == "" {
+			srcs = append(srcs, m[3])
+		}
+	}
+	for _, tag := range htmlImagePattern.FindAllString(line, -1) {
+		if alt := imageAltPattern.FindStringSubmatch(tag); alt != nil && strings.TrimSpace(alt[1]) != "" {
+			continue
+		}
+		src := ""
+		if m := imageSrcPattern.FindStringSubmatch(tag); m != nil {
+			src = m[1]
+		}
+		srcs = append(srcs, src)
+	}
+	return srcs
+}
@@ -0,0 +1,56 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckAccessibility(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []AccessibilityIssue
+	}{
+		{
+			name:  "clean document",
+			input: "# Title\n\n## Section\n\n![Diagram](a.png)\n\n| Name | Value |\n|------|-------|\n| a | 1 |\n",
+			want:  nil,
+		},
+		{
+			name:  "image without alt text",
+			input: "Intro\n\n![](shot.png) and <img src=\"b.png\" alt=\"\"> and <img src=\"c.png\" alt=\"C\">\n",
+			want: []AccessibilityIssue{
+				{Line: 3, Message: "image without alt text: shot.png"},
+				{Line: 3, Message: "image without alt text: b.png"},
+			},
+		},
+		{
+			name:  "skipped heading level",
+			input: "# Title\n\n#### Deep\n\n## Back\n\n### Fine\n",
+			want:  []AccessibilityIssue{{Line: 3, Message: "heading level skips from H1 to H4: Deep"}},
+		},
+		{
+			name:  "pipe table with empty header",
+			input: "Text\n\n|  |  |\n|--|--|\n| a | b |\n",
+			want:  []AccessibilityIssue{{Line: 3, Message: "table without header row"}},
+		},
+		{
+			name:  "html tables",
+			input: "<table>\n<tr><td>a</td></tr>\n</table>\n\n<table><tr><th>H</th></tr></table>\n",
+			want:  []AccessibilityIssue{{Line: 1, Message: "table without header row"}},
+		},
+		{
+			name:  "code ignored",
+			input: "```md\n# A\n#### B\n![](x.png)\n```\n\nUse `![](y.png)` syntax.\n",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckAccessibility(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckAccessibility() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
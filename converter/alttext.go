@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"html"
+	"strings"
+)
+
+// AltTextFunc returns alt text for the image at src, as it appears in the
+// converted Markdown, or an empty string to leave the image as it is.
+type AltTextFunc func(src string) string
+
+// altTextReplacer escapes characters that would end Markdown image text.
+var altTextReplacer = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`)
+
+// fillAltText asks altText to describe every image without alt text, both
+// Markdown images and raw <img> tags. Images inside code are left alone.
+func fillAltText(md string, altText AltTextFunc) string {
+	if altText == nil {
+		return md
+	}
+
+	describe := func(src string) string {
+		// Alt text is a single line of text
+		return strings.Join(strings.Fields(altText(src)), " ")
+	}
+	return protectCode(md, func(md string) string {
+		md = markdownLinkTargetPattern.ReplaceAllStringFunc(md, func(match string) string {
+			m := markdownLinkTargetPattern.FindStringSubmatch(match)
+			if m[1] != "!" || strings.TrimSpace(m[2]) != "" {
+				return match
+			}
+			alt := describe(m[3])
+			if alt == "" {
+				return match
+			}
+			return "![" + altTextReplacer.Replace(alt) + "](" + m[3] + m[4] + ")"
+		})
+		return htmlImagePattern.ReplaceAllStringFunc(md, func(tag string) string {
+			src := imageSrcPattern.FindStringSubmatch(tag)
+			if src == nil {
+				return tag
+			}
+			if existing := imageAltPattern.FindStringSubmatch(tag); existing != nil && strings.TrimSpace(existing[1]) != "" {
+				return tag
+			}
+			alt := describe(html.UnescapeString(src[1]))
+			if alt == "" {
+				return tag
+			}
+			attr := ` alt="` + html.EscapeString(alt) + `"`
+			if imageAltPattern.MatchString(tag) {
+				return imageAltPattern.ReplaceAllLiteralString(tag, attr)
+			}
+			return strings.Replace(tag, src[0], src[0]+attr, 1)
+		})
+	})
+}
@@ -0,0 +1,43 @@
+package converter
+
+import "testing"
+
+func TestFillAltText(t *testing.T) {
+	describe := func(src string) string {
+		switch src {
+		case "shot.png":
+			return "Login [screen]\nwith form"
+		case "a&b.png":
+			return `Chart "Q1"`
+		}
+		return ""
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty alt", "![](shot.png)", `![Login \[screen\] with form](shot.png)`},
+		{"title kept", `![](shot.png "Title")`, `![Login \[screen\] with form](shot.png "Title")`},
+		{"existing alt kept", "![Diagram](shot.png)", "![Diagram](shot.png)"},
+		{"no description", "![](other.png)", "![](other.png)"},
+		{"links untouched", "[](shot.png)", "[](shot.png)"},
+		{"code untouched", "`![](shot.png)`", "`![](shot.png)`"},
+		{"html empty alt", `<img src="a&amp;b.png" alt="" width="600">`, `<img src="a&amp;b.png" alt="Chart &#34;Q1&#34;" width="600">`},
+		{"html missing alt", `<img src="shot.png" width="600">`, `<img src="shot.png" alt="Login [screen] with form" width="600">`},
+		{"html existing alt", `<img src="shot.png" alt="Kept">`, `<img src="shot.png" alt="Kept">`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fillAltText(tt.input, describe); got != tt.want {
+				t.Errorf("fillAltText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	if got := fillAltText("![](shot.png)", nil); got != "![](shot.png)" {
+		t.Errorf("fillAltText(nil) = %q, want input unchanged", got)
+	}
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AttachmentsSectionStyle selects what happens to the "Attachments:"
+// appendix Confluence adds to the end of exported pages.
+type AttachmentsSectionStyle string
+
+const (
+	// AttachmentsKeep leaves the appendix as exported (the default).
+	AttachmentsKeep AttachmentsSectionStyle = "keep"
+	// AttachmentsRemove drops the appendix.
+	AttachmentsRemove AttachmentsSectionStyle = "remove"
+	// AttachmentsList rewrites the appendix as a plain list of links.
+	// Links to attachments with a local copy in Options.AttachmentPaths
+	// point at that copy.
+	AttachmentsList AttachmentsSectionStyle = "list"
+)
+
+// AttachmentsSectionStyles lists the supported appendix styles.
+var AttachmentsSectionStyles = []AttachmentsSectionStyle{AttachmentsKeep, AttachmentsRemove, AttachmentsList}
+
+var (
+	// attachmentsSectionPattern matches the start of the appendix: a
+	// pageSection div whose heading (optionally wrapped in a header div)
+	// reads "Attachments:".
+	attachmentsSectionPattern = regexp.MustCompile(`(?is)<div class="pageSection[^"]*"[^>]*>(?:\s*<div[^>]*>)?\s*<h[1-6][^>]*>\s*Attachments:?\s*</h[1-6]>`)
+
+	// anchorPattern matches links, capturing the target and the link text.
+	anchorPattern = regexp.MustCompile(`(?is)<a\s[^>]*?href="([^"]*)"[^>]*>(.*?)</a>`)
+)
+
+// applyAttachmentsSection removes or simplifies the attachments appendix.
+// The exported appendix lists each file with a bullet icon image and its
+// MIME type, which converts to noisy Markdown.
+func applyAttachmentsSection(htmlContent string, style AttachmentsSectionStyle) string {
+	if style != AttachmentsRemove && style != AttachmentsList {
+		return htmlContent
+	}
+	return replaceElements(htmlContent, attachmentsSectionPattern, "div", func(inner string, m []string) string {
+		if style == AttachmentsRemove {
+			return ""
+		}
+
+		var b strings.Builder
+		b.WriteString("<h2>Attachments</h2><ul>")
+		for _, link := range anchorPattern.FindAllStringSubmatch(inner, -1) {
+			text := strings.TrimSpace(tagPattern.ReplaceAllString(link[2], ""))
+			if text == "" {
+				continue
+			}
+			fmt.Fprintf(&b, `<li><a href="%s">%s</a></li>`, link[1], text)
+		}
+		b.WriteString("</ul>")
+		return b.String()
+	})
+}
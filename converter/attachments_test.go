@@ -0,0 +1,49 @@
+package converter
+
+import "testing"
+
+func TestApplyAttachmentsSection(t *testing.T) {
+	const body = `<p>Page body</p>`
+	const appendix = `<div class="pageSection group">
+<div class="pageSectionHeader">
+<h2 id="attachments" class="pageSectionTitle">Attachments:</h2>
+</div>
+<div class="greybox" align="left">
+<img src="images/icons/bullet_blue.gif" height="8" width="8" alt=""/>
+<a href="https://wiki.example.com/download/attachments/42/design.pdf?api=v2">design.pdf</a> (application/pdf)
+<br/>
+<img src="images/icons/bullet_blue.gif" height="8" width="8" alt=""/>
+<a href="/download/attachments/42/diagram%20v2.png"><span>diagram v2.png</span></a> (image/png)
+<br/>
+</div>
+</div>`
+	const other = `<div class="pageSection group"><h2>Comments:</h2><p>Nice page</p></div>`
+
+	tests := []struct {
+		name  string
+		html  string
+		style AttachmentsSectionStyle
+		want  string
+	}{
+		{"keep", body + appendix, AttachmentsKeep, body + appendix},
+		{"empty means keep", body + appendix, "", body + appendix},
+		{"remove", body + appendix, AttachmentsRemove, body},
+		{
+			"list",
+			body + appendix,
+			AttachmentsList,
+			body + `<h2>Attachments</h2><ul>` +
+				`<li><a href="https://wiki.example.com/download/attachments/42/design.pdf?api=v2">design.pdf</a></li>` +
+				`<li><a href="/download/attachments/42/diagram%20v2.png">diagram v2.png</a></li></ul>`,
+		},
+		{"other sections untouched", body + other, AttachmentsRemove, body + other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyAttachmentsSection(tt.html, tt.style); got != tt.want {
+				t.Errorf("applyAttachmentsSection() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// attributesExtension is the pandoc writer extension that writes element
+// classes and ids as attributes: "## Title {#id .class}".
+const attributesExtension = "+attributes"
+
+var (
+	// attributeSelectorPattern matches a KeepAttributes selector: "." or
+	// "#" and a class or id name, in which * matches any characters.
+	attributeSelectorPattern = regexp.MustCompile(`^[.#][A-Za-z0-9_*:-]+$`)
+
+	// attributeTagPattern matches start tags, capturing the element name
+	// and its attributes.
+	attributeTagPattern = regexp.MustCompile(`<([A-Za-z][A-Za-z0-9]*)(\s[^>]*)>`)
+
+	// idClassAttributePattern captures the id and class attributes of a
+	// tag with their values.
+	idClassAttributePattern = regexp.MustCompile(`(?i)\s(id|class)="([^"]*)"`)
+
+	// headingAttributesPattern matches the attribute block at the end of
+	// an ATX heading, capturing it.
+	headingAttributesPattern = regexp.MustCompile(`[ \t]+(\{[ \t]*[#.][^{}]*\})$`)
+
+	// attributeIDPattern captures the id of a pandoc attribute block.
+	attributeIDPattern = regexp.MustCompile(`[{\s]#([^\s{}]+)`)
+)
+
+// ParseAttributeSelectors parses a comma-separated list of selectors for
+// Options.KeepAttributes, such as ".warning,.code-*,#*". Each is a class
+// (".name") or id ("#name"); * matches any characters.
+func ParseAttributeSelectors(list string) ([]string, error) {
+	var selectors []string
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !attributeSelectorPattern.MatchString(s) {
+			return nil, fmt.Errorf("invalid attribute selector %q: want .class or #id, with * as a wildcard", s)
+		}
+		selectors = append(selectors, s)
+	}
+	return selectors, nil
+}
+
+// keepsAttributes reports whether opts writes classes or ids as pandoc
+// attributes: selected ones, or heading IDs.
+func (opts Options) keepsAttributes() bool {
+	return (len(opts.KeepAttributes) > 0 || opts.HeadingIDs == HeadingIDsAttributes) && writesMarkdown(opts)
+}
+
+// writer returns the pandoc writer for opts.To: that of the flavor for
+// Markdown output, with the attributes extension when opts keeps
+// attributes.
+func (opts Options) writer() string {
+	if !writesMarkdown(opts) {
+		return opts.To.pandocWriter()
+	}
+	if opts.keepsAttributes() {
+		return opts.Flavor.pandocWriter() + attributesExtension
+	}
+	return opts.Flavor.pandocWriter()
+}
+
+// keepAttributes removes the classes and ids that match none of selectors
+// from the HTML, so that pandoc writes only the selected ones as
+// attributes. Heading ids are kept too when headingIDs is set. Divs and
+// spans are left alone: the Markdown writer keeps them as raw HTML, which
+// later steps rely on.
+func keepAttributes(html string, selectors []string, headingIDs bool) string {
+	return attributeTagPattern.ReplaceAllStringFunc(html, func(tag string) string {
+		m := attributeTagPattern.FindStringSubmatch(tag)
+		name := strings.ToLower(m[1])
+		if name == "div" || name == "span" {
+			return tag
+		}
+		isHeading := len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6'
+		attrs := idClassAttributePattern.ReplaceAllStringFunc(m[2], func(attr string) string {
+			a := idClassAttributePattern.FindStringSubmatch(attr)
+			prefix := "."
+			if strings.EqualFold(a[1], "id") {
+				prefix = "#"
+			}
+			var kept []string
+			for _, value := range strings.Fields(a[2]) {
+				if (prefix == "#" && isHeading && headingIDs) || matchesSelector(prefix+value, selectors) {
+					kept = append(kept, value)
+				}
+			}
+			if len(kept) == 0 {
+				return ""
+			}
+			return fmt.Sprintf(` %s="%s"`, a[1], strings.Join(kept, " "))
+		})
+		return "<" + m[1] + attrs + ">"
+	})
+}
+
+// matchesSelector reports whether the class (".name") or id ("#name")
+// matches one of selectors.
+func matchesSelector(name string, selectors []string) bool {
+	for _, s := range selectors {
+		if ok, _ := path.Match(s, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// splitHeadingAttributes splits the attribute block pandoc writes at the
+// end of heading text, "Title {#id .class}", from the text. attrs is empty
+// for headings without one.
+func splitHeadingAttributes(text string) (title, attrs string) {
+	if loc := headingAttributesPattern.FindStringSubmatchIndex(text); loc != nil {
+		return text[:loc[0]], text[loc[2]:loc[3]]
+	}
+	return text, ""
+}
+
+// attributeID returns the id of a pandoc attribute block, or "" if it has
+// none.
+func attributeID(attrs string) string {
+	if m := attributeIDPattern.FindStringSubmatch(attrs); m != nil {
+		return m[1]
+	}
+	return ""
+}
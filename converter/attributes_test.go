@@ -0,0 +1,111 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAttributeSelectors(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []string
+		wantErr bool
+	}{
+		{list: "", want: nil},
+		{list: ".warning, .code-*,#*", want: []string{".warning", ".code-*", "#*"}},
+		{list: "warning", wantErr: true},
+		{list: ".a b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			got, err := ParseAttributeSelectors(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAttributeSelectors() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("ParseAttributeSelectors() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepAttributes(t *testing.T) {
+	tests := []struct {
+		name      string
+		html      string
+		selectors []string
+		want      string
+	}{
+		{
+			name:      "unselected classes and ids removed",
+			html:      `<h2 id="Page-Setup" class="heading important">Setup</h2>`,
+			selectors: []string{".important"},
+			want:      `<h2 class="important">Setup</h2>`,
+		},
+		{
+			name:      "id wildcard",
+			html:      `<h2 id="Page-Setup">Setup</h2><p class="x" id="p1">Text</p>`,
+			selectors: []string{"#Page-*"},
+			want:      `<h2 id="Page-Setup">Setup</h2><p>Text</p>`,
+		},
+		{
+			name:      "class wildcard",
+			html:      `<code class="code-java other">x</code>`,
+			selectors: []string{".code-*"},
+			want:      `<code class="code-java">x</code>`,
+		},
+		{
+			name:      "divs and spans left alone",
+			html:      `<div class="confluence-information-macro"><span class="status">OK</span></div>`,
+			selectors: []string{"#*"},
+			want:      `<div class="confluence-information-macro"><span class="status">OK</span></div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keepAttributes(tt.html, tt.selectors, false); got != tt.want {
+				t.Errorf("keepAttributes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitHeadingAttributes(t *testing.T) {
+	tests := []struct {
+		text      string
+		wantTitle string
+		wantAttrs string
+		wantID    string
+	}{
+		{"Setup {#Page-Setup .note}", "Setup", "{#Page-Setup .note}", "Page-Setup"},
+		{"Setup {.note}", "Setup", "{.note}", ""},
+		{"Use {braces}", "Use {braces}", "", ""},
+		{"Setup", "Setup", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			title, attrs := splitHeadingAttributes(tt.text)
+			if title != tt.wantTitle || attrs != tt.wantAttrs {
+				t.Errorf("splitHeadingAttributes() = %q, %q, want %q, %q", title, attrs, tt.wantTitle, tt.wantAttrs)
+			}
+			if id := attributeID(attrs); id != tt.wantID {
+				t.Errorf("attributeID() = %q, want %q", id, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestOptionsWriter(t *testing.T) {
+	if got := (Options{}).writer(); got != "gfm" {
+		t.Errorf("writer() = %q, want gfm", got)
+	}
+	if got := (Options{KeepAttributes: []string{"#*"}}).writer(); got != "gfm+attributes" {
+		t.Errorf("writer() with KeepAttributes = %q, want gfm+attributes", got)
+	}
+	if got := (Options{KeepAttributes: []string{"#*"}, To: FormatOrg}).writer(); got != "org" {
+		t.Errorf("writer() for org = %q, want org", got)
+	}
+}
@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// batchSeparatorPrefix starts the separator paragraphs placed between the
+// pages of a batch. A random nonce per batch follows it, so that page text
+// cannot be mistaken for a separator.
+const batchSeparatorPrefix = "C2MDBATCH"
+
+var (
+	// htmlBodyPattern captures the content of a document's body.
+	htmlBodyPattern = regexp.MustCompile(`(?is)<body\b[^>]*>(.*)</body>`)
+
+	// htmlHeadPattern matches a document's head.
+	htmlHeadPattern = regexp.MustCompile(`(?is)<head\b.*?</head>`)
+)
+
+// errBatchSeparators is returned when the separators between the pages of
+// a batch do not come back from pandoc intact, for example because a page
+// left an element open that swallowed the separator after it.
+var errBatchSeparators = errors.New("batch separators were not preserved")
+
+// BatchPage is a page of a batch conversion and its options.
+type BatchPage struct {
+	HTML    string
+	Options Options
+}
+
+// BatchResult is the converted Markdown of a batch page, or the error
+// converting it.
+type BatchResult struct {
+	Markdown string
+	Err      error
+}
+
+// ConvertHTMLBatchToMarkdown converts pages like
+// ConvertHTMLToMarkdownWithOptions, but feeds every Markdown page through a
+// single pandoc run, which saves the process start-up per page in large
+// batches. The prepared pages are joined with separator paragraphs, and
+// pandoc's output is split at them again; the pipeline steps still run per
+// page, with each page's options.
+//
+// Pages that cannot share the run (other output formats, templates, or
+// another engine or reader than the first Markdown page) are converted on
+// their own. When the shared run fails or its separators do not survive,
+// its pages are converted one pandoc run each, as are pages whose share of
+// the output looks empty, so the results always match per-page conversion.
+// Results are in page order.
+func ConvertHTMLBatchToMarkdown(pages []BatchPage) []BatchResult {
+	results := make([]BatchResult, len(pages))
+	var batch []int
+	prepared := make([]BatchPage, len(pages))
+	for i, page := range pages {
+		if !batchable(page.Options) || (len(batch) > 0 && !sameRun(page.Options, pages[batch[0]].Options)) {
+			results[i].Markdown, results[i].Err = ConvertHTMLToMarkdownWithOptions(page.HTML, page.Options)
+			continue
+		}
+		html, opts, err := prepareHTML(page.HTML, page.Options)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		prepared[i] = BatchPage{HTML: html, Options: opts}
+		batch = append(batch, i)
+	}
+	if len(batch) == 0 {
+		return results
+	}
+
+	htmls := make([]string, len(batch))
+	for k, i := range batch {
+		htmls[k] = prepared[i].HTML
+	}
+	outputs, err := runPandocBatch(htmls, prepared[batch[0]].Options)
+	for k, i := range batch {
+		opts := prepared[i].Options
+		if err != nil || outputLooksEmpty(outputs[k], prepared[i].HTML) {
+			results[i].Markdown, results[i].Err = convertPreparedHTML(prepared[i].HTML, opts)
+			continue
+		}
+		results[i].Markdown, results[i].Err = runPipeline(opts.Pipeline, StageMarkdown, outputs[k], opts)
+	}
+	return results
+}
+
+// batchable reports whether a page with opts can share a pandoc run: it
+// must be plain Markdown output without a template.
+func batchable(opts Options) bool {
+	return writesMarkdown(opts) && opts.Template == "" && opts.TemplateText == ""
+}
+
+// sameRun reports whether pages with opts a and b are read by the same
+// pandoc with the same reader and writer.
+func sameRun(a, b Options) bool {
+	return a.Engine == b.Engine && a.ReaderExtensions == b.ReaderExtensions && a.writer() == b.writer()
+}
+
+// convertPreparedHTML converts HTML that went through prepareHTML with a
+// pandoc run of its own and runs the Markdown steps.
+func convertPreparedHTML(html string, opts Options) (string, error) {
+	ctx, cancel := conversionContext(opts)
+	defer cancel()
+
+	md, err := runPandocText(ctx, opts, html, opts.writer())
+	if err != nil {
+		return "", err
+	}
+	return runPipeline(opts.Pipeline, StageMarkdown, md, opts)
+}
+
+// runPandocBatch converts prepared pages with one pandoc run and returns
+// pandoc's output per page. The run gets the per-file time limit of opts,
+// not one scaled by the page count, so a page that hangs pandoc stalls the
+// batch no longer than it would stall its own conversion; pandoc is killed
+// and the pages are converted one by one instead.
+func runPandocBatch(htmls []string, opts Options) ([]string, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to create batch separator: %w", err)
+	}
+	marker := batchSeparatorPrefix + hex.EncodeToString(nonce)
+
+	var b strings.Builder
+	for k, html := range htmls {
+		if k > 0 {
+			fmt.Fprintf(&b, "\n<p>%s%d</p>\n", marker, k)
+		}
+		b.WriteString(pageBody(html))
+	}
+
+	ctx, cancel := conversionContext(opts)
+	defer cancel()
+
+	md, err := runPandocFrom(ctx, opts.Engine, opts.reader(), b.String(), opts.writer())
+	if err != nil {
+		return nil, err
+	}
+	return splitBatchOutput(md, marker, len(htmls))
+}
+
+// pageBody returns the content of a page's body, so that pages can be
+// concatenated into one document. Documents without a body lose their head.
+func pageBody(html string) string {
+	if m := htmlBodyPattern.FindStringSubmatch(html); m != nil {
+		return m[1]
+	}
+	return htmlHeadPattern.ReplaceAllString(html, "")
+}
+
+// splitBatchOutput splits pandoc's output for a batch of n pages at the
+// separator lines, which must each stand alone on a line, in order.
+func splitBatchOutput(md, marker string, n int) ([]string, error) {
+	outputs := make([]string, 0, n)
+	var page []string
+	for _, line := range strings.Split(md, "\n") {
+		if !strings.Contains(line, marker) {
+			page = append(page, line)
+			continue
+		}
+		if line != fmt.Sprintf("%s%d", marker, len(outputs)+1) {
+			return nil, errBatchSeparators
+		}
+		outputs = append(outputs, batchPageOutput(page))
+		page = nil
+	}
+	outputs = append(outputs, batchPageOutput(page))
+	if len(outputs) != n {
+		return nil, errBatchSeparators
+	}
+	return outputs, nil
+}
+
+// batchPageOutput joins the lines pandoc wrote for one page, without the
+// blank lines around the separators, ending in a newline like the output
+// of a run of its own.
+func batchPageOutput(lines []string) string {
+	text := strings.Trim(strings.Join(lines, "\n"), "\n")
+	if text == "" {
+		return ""
+	}
+	return text + "\n"
+}
@@ -0,0 +1,214 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitBatchOutput(t *testing.T) {
+	const marker = "C2MDBATCHabc"
+	tests := []struct {
+		name    string
+		md      string
+		n       int
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "three pages",
+			md:   "# One\n\ntext\n\nC2MDBATCHabc1\n\nTwo\n\nC2MDBATCHabc2\n\n- three\n",
+			n:    3,
+			want: []string{"# One\n\ntext\n", "Two\n", "- three\n"},
+		},
+		{
+			name: "empty page",
+			md:   "One\n\nC2MDBATCHabc1\n\nC2MDBATCHabc2\n\nThree\n",
+			n:    3,
+			want: []string{"One\n", "", "Three\n"},
+		},
+		{
+			name: "single page",
+			md:   "Only\n",
+			n:    1,
+			want: []string{"Only\n"},
+		},
+		{name: "missing separator", md: "One\n\nC2MDBATCHabc1\n\nTwo\n", n: 3, wantErr: true},
+		{name: "separators out of order", md: "One\n\nC2MDBATCHabc2\n\nTwo\n", n: 2, wantErr: true},
+		{name: "separator inside a list", md: "- one\n\n  C2MDBATCHabc1\n\nTwo\n", n: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitBatchOutput(tt.md, marker, tt.n)
+			if tt.wantErr {
+				if !errors.Is(err, errBatchSeparators) {
+					t.Errorf("splitBatchOutput() error = %v, want errBatchSeparators", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitBatchOutput() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitBatchOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageBody(t *testing.T) {
+	tests := []struct {
+		html string
+		want string
+	}{
+		{`<html><head><title>T</title></head><body class="x"><p>Body</p></body></html>`, `<p>Body</p>`},
+		{`<head><title>T</title></head><p>Body</p>`, `<p>Body</p>`},
+		{`<p>Fragment</p>`, `<p>Fragment</p>`},
+	}
+	for _, tt := range tests {
+		if got := pageBody(tt.html); got != tt.want {
+			t.Errorf("pageBody(%q) = %q, want %q", tt.html, got, tt.want)
+		}
+	}
+}
+
+func TestBatchable(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want bool
+	}{
+		{Options{}, true},
+		{Options{To: FormatMarkdown, Flavor: FlavorGitLab}, true},
+		{Options{To: FormatOrg}, false},
+		{Options{To: FormatJSON}, false},
+		{Options{TemplateText: "$body$"}, false},
+	}
+	for _, tt := range tests {
+		if got := batchable(tt.opts); got != tt.want {
+			t.Errorf("batchable(%+v) = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+// Bodies of fake pandoc scripts for installFakePandoc.
+const (
+	// fakePandocStripParagraphs strips paragraph tags from its input.
+	fakePandocStripParagraphs = "sed -e 's#</*p>##g'\n"
+
+	// fakePandocFailOnBatch fails for input holding batch separators and
+	// strips paragraph tags otherwise.
+	fakePandocFailOnBatch = "input=$(cat)\ncase \"$input\" in *" + batchSeparatorPrefix + "*) exit 1;; esac\nprintf '%s\\n' \"$input\" | sed -e 's#</*p>##g'\n"
+)
+
+// installFakePandoc puts a pandoc script with the given body in front of
+// PATH and returns a function counting its runs since the last call.
+func installFakePandoc(t *testing.T, body string) func() int {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake pandoc scripts are not executable on Windows")
+	}
+
+	dir := t.TempDir()
+	log := filepath.Join(dir, "runs.log")
+	script := "#!/bin/sh\necho run >> " + log + "\n" + body
+	if err := os.WriteFile(filepath.Join(dir, "pandoc"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return func() int {
+		data, _ := os.ReadFile(log)
+		n := strings.Count(string(data), "run\n")
+		os.Remove(log)
+		return n
+	}
+}
+
+func TestConvertHTMLBatchToMarkdown(t *testing.T) {
+	for _, failOnBatch := range []bool{false, true} {
+		body := fakePandocStripParagraphs
+		if failOnBatch {
+			body = fakePandocFailOnBatch
+		}
+		runs := installFakePandoc(t, body)
+		opts := Options{Engine: EngineSystem}
+		pages := []BatchPage{
+			{HTML: "<p>First page</p>\n<ul><li>item</li></ul>", Options: opts},
+			{HTML: "<p>Second page</p>", Options: opts},
+			{HTML: "<p>Third page</p>", Options: Options{Engine: EngineSystem, FrontMatter: []FrontMatterField{{Key: "title", Value: "Third"}}}},
+		}
+
+		var want []BatchResult
+		for _, page := range pages {
+			md, err := ConvertHTMLToMarkdownWithOptions(page.HTML, page.Options)
+			want = append(want, BatchResult{Markdown: md, Err: err})
+		}
+		if n := runs(); n != 3 {
+			t.Fatalf("per-page conversion ran pandoc %d times, want 3", n)
+		}
+
+		got := ConvertHTMLBatchToMarkdown(pages)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("failOnBatch=%v: ConvertHTMLBatchToMarkdown() = %q, want %q", failOnBatch, got, want)
+		}
+		wantRuns := 1
+		if failOnBatch {
+			// The failed batch run, then one run per page
+			wantRuns = 4
+		}
+		if n := runs(); n != wantRuns {
+			t.Errorf("failOnBatch=%v: batch conversion ran pandoc %d times, want %d", failOnBatch, n, wantRuns)
+		}
+	}
+}
+
+func TestConvertHTMLBatchToMarkdown_Timeout(t *testing.T) {
+	// The batch run hangs; runs for one page do not
+	runs := installFakePandoc(t, "input=$(cat)\ncase \"$input\" in *"+batchSeparatorPrefix+"*) exec sleep 10;; esac\nprintf '%s\\n' \"$input\" | sed -e 's#</*p>##g'\n")
+	opts := Options{Engine: EngineSystem, Timeout: 500 * time.Millisecond}
+	var pages []BatchPage
+	for i := 0; i < 8; i++ {
+		pages = append(pages, BatchPage{HTML: fmt.Sprintf("<p>Page %d</p>", i), Options: opts})
+	}
+
+	start := time.Now()
+	results := ConvertHTMLBatchToMarkdown(pages)
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("batch took %v, want the per-file time limit of %v plus the per-page runs", elapsed, opts.Timeout)
+	}
+	for i, r := range results {
+		if r.Err != nil || !strings.Contains(r.Markdown, fmt.Sprintf("Page %d", i)) {
+			t.Errorf("page %d = %q, %v, want its per-page conversion", i, r.Markdown, r.Err)
+		}
+	}
+	if n := runs(); n != 1+len(pages) {
+		t.Errorf("ran pandoc %d times, want the timed-out batch run and one run per page", n)
+	}
+}
+
+func TestConvertHTMLBatchToMarkdown_Unbatchable(t *testing.T) {
+	runs := installFakePandoc(t, fakePandocStripParagraphs)
+	pages := []BatchPage{
+		{HTML: "<p>Org page</p>", Options: Options{Engine: EngineSystem, To: FormatOrg}},
+		{HTML: "<p>Markdown page</p>", Options: Options{Engine: EngineSystem}},
+	}
+	results := ConvertHTMLBatchToMarkdown(pages)
+	for i, r := range results {
+		if r.Err != nil {
+			t.Errorf("page %d: unexpected error: %v", i, r.Err)
+		}
+	}
+	if !strings.Contains(results[1].Markdown, "Markdown page") {
+		t.Errorf("unexpected Markdown: %q", results[1].Markdown)
+	}
+	if n := runs(); n != 2 {
+		t.Errorf("ran pandoc %d times, want 2 (the Org page on its own)", n)
+	}
+}
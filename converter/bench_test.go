@@ -0,0 +1,97 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchmarkPage builds a large Confluence-style page with tables, images,
+// admonitions, and code blocks repeated n times.
+func benchmarkPage(n int) string {
+	var b strings.Builder
+	b.WriteString(`<html><body><div class="Section1"><div class="contentLayout2"><div class="columnLayout single">`)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, `<h2 id="section-%d">Section %d</h2>`, i, i)
+		b.WriteString(`<p style="margin: 0">Some <span class="nolink">text</span> with <a href="/display/ENG/Page">a link</a> and &eacute;t&eacute;.</p>`)
+		b.WriteString(`<div class="confluence-information-macro confluence-information-macro-tip"><span class="aui-icon aui-icon-small"></span><div class="confluence-information-macro-body"><p>A tip.</p></div></div>`)
+		b.WriteString(`<div class="table-wrap"><table class="confluenceTable"><colgroup><col /><col /></colgroup><tbody>`)
+		b.WriteString(`<tr><th class="confluenceTh" scope="col">Name</th><th class="confluenceTh">Value</th></tr>`)
+		b.WriteString(`<tr><td class="confluenceTd"><p>alpha</p></td><td class="confluenceTd">one<br/>two</td></tr>`)
+		b.WriteString(`</tbody></table></div>`)
+		b.WriteString(`<p><img class="confluence-embedded-image" data-image-src="/download/attachments/1/a.png" src="/download/attachments/1/a.png" alt="diagram" width="600"></p>`)
+		b.WriteString(`<pre>func main() {\n\tfmt.Println("&lt;hello&gt;")\n}</pre>`)
+	}
+	b.WriteString(`</div></div></div></body></html>`)
+	return b.String()
+}
+
+// benchmarkMarkdown is representative pandoc output for benchmarkPage.
+func benchmarkMarkdown(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "## Section %d\n\n", i)
+		b.WriteString("Some text with <a href=\"/display/ENG/Page\">a link</a> and &amp; more.\n\n")
+		b.WriteString("<div class=\"confluence-information-macro confluence-information-macro-tip\">\n\nA tip.\n\n</div>\n\n")
+		b.WriteString("| Name | Value |\n|------|-------|\n| alpha | one two |\n\n")
+		b.WriteString("![diagram](/download/attachments/1/a.png) :check:\n\n")
+		b.WriteString("```\nfunc main() {\n\tfmt.Println(\"<hello>\")\n}\n```\n\n")
+	}
+	return b.String()
+}
+
+func BenchmarkPreProcessHTML(b *testing.B) {
+	html := benchmarkPage(200)
+	b.SetBytes(int64(len(html)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		preProcessHTML(html)
+	}
+}
+
+func BenchmarkPostProcessMarkdown(b *testing.B) {
+	md := benchmarkMarkdown(200)
+	b.SetBytes(int64(len(md)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		postProcessMarkdown(md)
+	}
+}
+
+func BenchmarkExtractHTMLFromMIME(b *testing.B) {
+	html := benchmarkPage(200)
+	path := filepath.Join(b.TempDir(), "page.doc")
+	mime := "Date: Wed, 7 Jan 2026 01:29:00 +0000 (UTC)\r\n" +
+		"Subject: Exported From Confluence\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/related; boundary=\"BOUNDARY\"\r\n\r\n" +
+		"--BOUNDARY\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n" +
+		strings.ReplaceAll(html, "<h2", "\r\n<h2") + "\r\n--BOUNDARY--\r\n"
+	if err := os.WriteFile(path, []byte(mime), 0644); err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(mime)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ExtractHTMLFromMIME(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConvertHTMLToMarkdown(b *testing.B) {
+	if err := CheckPandoc(); err != nil {
+		b.Skipf("Pandoc not available: %v", err)
+	}
+	html := benchmarkPage(200)
+	b.SetBytes(int64(len(html)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ConvertHTMLToMarkdown(html); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultMaxBlankLines is the number of consecutive blank lines kept in
+// converted Markdown unless BlankLines.Max says otherwise.
+const DefaultMaxBlankLines = 1
+
+// BlankLines controls how runs of blank lines in converted Markdown are
+// compacted. The zero value keeps at most one blank line in a row.
+type BlankLines struct {
+	// Max is the number of consecutive blank lines kept. Zero means
+	// DefaultMaxBlankLines.
+	Max int
+
+	// PreserveAroundBlocks keeps the blank lines before and after fenced
+	// code blocks and HTML blocks as pandoc wrote them.
+	PreserveAroundBlocks bool
+}
+
+// htmlBlockLinePattern matches a line that starts an HTML block, such as
+// <details> or a raw <table>, or closes one.
+var htmlBlockLinePattern = regexp.MustCompile(`^\s*</?[A-Za-z][A-Za-z0-9-]*(?:\s|/?>|$)`)
+
+// compactBlankLines limits runs of blank lines in md to blank.Max, leaving
+// the runs next to fenced code and HTML blocks alone when
+// blank.PreserveAroundBlocks is set. Lines holding only whitespace count
+// as blank.
+func compactBlankLines(md string, blank BlankLines) string {
+	limit := blank.Max
+	if limit <= 0 {
+		limit = DefaultMaxBlankLines
+	}
+
+	lines := strings.Split(md, "\n")
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); {
+		if strings.TrimSpace(lines[i]) != "" {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+		end := i
+		for end < len(lines) && strings.TrimSpace(lines[end]) == "" {
+			end++
+		}
+		keep := end - i
+		if !(blank.PreserveAroundBlocks && nextToBlock(lines, i, end)) {
+			keep = min(keep, limit)
+		}
+		out = append(out, lines[i:i+keep]...)
+		i = end
+	}
+	return strings.Join(out, "\n")
+}
+
+// nextToBlock reports whether the run of blank lines lines[start:end]
+// borders a code fence or HTML block line.
+func nextToBlock(lines []string, start, end int) bool {
+	isBlock := func(line string) bool {
+		return fencePattern.MatchString(line) || htmlBlockLinePattern.MatchString(line)
+	}
+	return (start > 0 && isBlock(lines[start-1])) || (end < len(lines) && isBlock(lines[end]))
+}
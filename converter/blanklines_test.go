@@ -0,0 +1,47 @@
+package converter
+
+import (
+	"testing"
+)
+
+func TestCompactBlankLines(t *testing.T) {
+	const doc = "Intro\n\n\n\nText\n\n\n<details>\n<summary>More</summary>\n\n\n\nHidden\n</details>\n\n\n```\ncode\n```\n"
+
+	tests := []struct {
+		name   string
+		input  string
+		blank  BlankLines
+		expect string
+	}{
+		{
+			name:   "default keeps one blank line",
+			input:  doc,
+			expect: "Intro\n\nText\n\n<details>\n<summary>More</summary>\n\nHidden\n</details>\n\n```\ncode\n```\n",
+		},
+		{
+			name:   "higher maximum",
+			input:  doc,
+			blank:  BlankLines{Max: 2},
+			expect: "Intro\n\n\nText\n\n\n<details>\n<summary>More</summary>\n\n\nHidden\n</details>\n\n\n```\ncode\n```\n",
+		},
+		{
+			name:   "preserve around blocks",
+			input:  doc,
+			blank:  BlankLines{PreserveAroundBlocks: true},
+			expect: "Intro\n\nText\n\n\n<details>\n<summary>More</summary>\n\n\n\nHidden\n</details>\n\n\n```\ncode\n```\n",
+		},
+		{
+			name:   "whitespace-only lines count as blank",
+			input:  "a\n \n\t\n\nb",
+			expect: "a\n \nb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compactBlankLines(tt.input, tt.blank); got != tt.expect {
+				t.Errorf("compactBlankLines() = %q, want %q", got, tt.expect)
+			}
+		})
+	}
+}
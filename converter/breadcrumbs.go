@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// breadcrumbsPattern matches the opening tag of the breadcrumb list of
+	// exported pages, capturing the tag name.
+	breadcrumbsPattern = regexp.MustCompile(`<(ol|ul|div|nav)\s[^>]*id="breadcrumbs"[^>]*>`)
+
+	// breadcrumbItemPattern captures the content of each breadcrumb.
+	breadcrumbItemPattern = regexp.MustCompile(`(?is)<li\b[^>]*>(.*?)</li>`)
+)
+
+// extractBreadcrumbs returns the titles of a page's breadcrumbs, starting
+// with the space. Confluence's own navigation crumbs (a leading "Dashboard"
+// and the "Pages" crumb following the space) are dropped.
+func extractBreadcrumbs(htmlContent string) []string {
+	loc := breadcrumbsPattern.FindStringSubmatchIndex(htmlContent)
+	if loc == nil {
+		return nil
+	}
+	tag := htmlContent[loc[2]:loc[3]]
+	end := findElementEnd(htmlContent, loc[0], tag)
+	if end == -1 {
+		return nil
+	}
+
+	var crumbs []string
+	for _, m := range breadcrumbItemPattern.FindAllStringSubmatch(elementInner(htmlContent, loc[0], end, tag), -1) {
+		crumb := plainText(m[1])
+		switch {
+		case crumb == "":
+			continue
+		case len(crumbs) == 0 && strings.EqualFold(crumb, "Dashboard"):
+			continue
+		case len(crumbs) == 1 && strings.EqualFold(crumb, "Pages"):
+			continue
+		}
+		crumbs = append(crumbs, crumb)
+	}
+	return crumbs
+}
@@ -0,0 +1,46 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractBreadcrumbs(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want []string
+	}{
+		{
+			name: "space and parents",
+			html: `<div id="main-header"><ol id="breadcrumbs"><li class="first"><span><a href="/display/ENG">Engineering</a></span></li><li><span><a href="/display/ENG/Runbooks">Runbooks</a></span></li></ol></div>`,
+			want: []string{"Engineering", "Runbooks"},
+		},
+		{
+			name: "navigation crumbs dropped",
+			html: `<ol id="breadcrumbs"><li><a href="/dashboard.action">Dashboard</a></li><li><a href="/display/OPS">Operations</a></li><li><a href="/pages/listpages.action">Pages</a></li><li><a href="#">On-call &amp; Escalation</a></li></ol>`,
+			want: []string{"Operations", "On-call & Escalation"},
+		},
+		{
+			name: "nested lists and whitespace",
+			html: "<nav class=\"crumbs\" id=\"breadcrumbs\"><ul>\n<li>\n  <a>Docs</a>\n</li>\n<li></li>\n<li><a>Guides</a></li></ul></nav><ul><li>Not a crumb</li></ul>",
+			want: []string{"Docs", "Guides"},
+		},
+		{
+			name: "no breadcrumbs",
+			html: `<ul><li>Item</li></ul>`,
+		},
+		{
+			name: "unclosed list",
+			html: `<ol id="breadcrumbs"><li>Space</li>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractBreadcrumbs(tt.html); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractBreadcrumbs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// HardBreakStyle selects how <br> line breaks are written in the Markdown output.
+type HardBreakStyle string
+
+const (
+	// HardBreakBackslash ends the line with a backslash (the default).
+	HardBreakBackslash HardBreakStyle = "backslash"
+	// HardBreakSpaces ends the line with two trailing spaces.
+	HardBreakSpaces HardBreakStyle = "spaces"
+	// HardBreakNewline starts a new source line, which most renderers
+	// display as a soft break (a space).
+	HardBreakNewline HardBreakStyle = "newline"
+	// HardBreakHTML keeps the break as an inline <br> tag.
+	HardBreakHTML HardBreakStyle = "html"
+)
+
+// HardBreakStyles lists the supported hard break styles.
+var HardBreakStyles = []HardBreakStyle{HardBreakBackslash, HardBreakSpaces, HardBreakNewline, HardBreakHTML}
+
+// hardBreakMarker stands in for <br> tags while pandoc converts the
+// document. Plain letters pass through pandoc unescaped.
+const hardBreakMarker = "CTWOMDHARDBREAK"
+
+var (
+	// brTagPattern matches <br> tags in any of their spellings.
+	brTagPattern = regexp.MustCompile(`(?i)<br\s*/?>`)
+
+	// hardBreakMarkerPattern matches a marker with the whitespace around it.
+	hardBreakMarkerPattern = regexp.MustCompile(`[ \t]*` + hardBreakMarker + `[ \t]*`)
+
+	// listItemPrefixPattern matches the marker of a list item line, whose
+	// continuation lines are indented to the item's content.
+	listItemPrefixPattern = regexp.MustCompile(`^[ \t]*(?:[-*+]|\d+[.)])[ \t]+`)
+
+	// blockquotePrefixPattern matches the ">" markers of a blockquote line.
+	blockquotePrefixPattern = regexp.MustCompile(`^[ \t]*(?:>[ \t]?)+`)
+
+	// leadingWhitespacePattern matches the indentation of a line.
+	leadingWhitespacePattern = regexp.MustCompile(`^[ \t]*`)
+)
+
+// markHardBreaks replaces <br> tags outside <pre> and <code> elements with
+// markers that renderHardBreaks turns into the chosen break syntax once
+// pandoc has produced the Markdown.
+func markHardBreaks(html string) string {
+	var b strings.Builder
+	for _, seg := range splitCodeRegions(html) {
+		if seg.region {
+			b.WriteString(seg.text)
+			continue
+		}
+		b.WriteString(brTagPattern.ReplaceAllString(seg.text, hardBreakMarker))
+	}
+	return b.String()
+}
+
+// renderHardBreaks replaces the markers left by markHardBreaks with breaks
+// in the given style. Continuation lines keep the indentation of list items
+// and the markers of blockquotes so the break stays inside its block. Table
+// rows always use <br>, and headings a space, since neither may span lines.
+// Breaks at the start or end of a line are dropped.
+func renderHardBreaks(md string, style HardBreakStyle) string {
+	if !strings.Contains(md, hardBreakMarker) {
+		return md
+	}
+
+	lines := strings.Split(md, "\n")
+	for i, line := range lines {
+		if !strings.Contains(line, hardBreakMarker) {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		parts := hardBreakMarkerPattern.Split(line, -1)
+		// Drop breaks at the start or end of the line
+		for len(parts) > 1 && strings.TrimSpace(parts[len(parts)-1]) == "" {
+			parts = parts[:len(parts)-1]
+		}
+		first := 0
+		for first < len(parts)-1 && strings.TrimSpace(parts[first]) == "" {
+			first++
+		}
+		if first > 0 {
+			parts = parts[first:]
+			parts[0] = leadingWhitespacePattern.FindString(line) + parts[0]
+		}
+
+		var sep string
+		switch {
+		case strings.HasPrefix(trimmed, "|"):
+			sep = "<br>"
+		case strings.HasPrefix(trimmed, "#"):
+			sep = " "
+		case style == HardBreakHTML:
+			sep = "<br>"
+		default:
+			sep = hardBreakSeparator(style) + continuationPrefix(line)
+		}
+		lines[i] = strings.Join(parts, sep)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hardBreakSeparator returns the text that ends a line at a hard break.
+func hardBreakSeparator(style HardBreakStyle) string {
+	switch style {
+	case HardBreakSpaces:
+		return "  \n"
+	case HardBreakNewline:
+		return "\n"
+	default:
+		return "\\\n"
+	}
+}
+
+// continuationPrefix returns the prefix that keeps a continuation line in
+// the same block as line.
+func continuationPrefix(line string) string {
+	if m := listItemPrefixPattern.FindString(line); m != "" {
+		return strings.Repeat(" ", len(m))
+	}
+	if m := blockquotePrefixPattern.FindString(line); m != "" {
+		return m
+	}
+	return leadingWhitespacePattern.FindString(line)
+}
@@ -0,0 +1,51 @@
+package converter
+
+import (
+	"testing"
+)
+
+func TestMarkHardBreaks(t *testing.T) {
+	input := `<p>a<br>b<BR/>c<br />d</p><pre>x<br>y</pre><code>p<br>q</code>`
+	want := `<p>a` + hardBreakMarker + `b` + hardBreakMarker + `c` + hardBreakMarker + `d</p><pre>x<br>y</pre><code>p<br>q</code>`
+	if got := markHardBreaks(input); got != want {
+		t.Errorf("markHardBreaks() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderHardBreaks(t *testing.T) {
+	br := hardBreakMarker
+
+	tests := []struct {
+		name   string
+		input  string
+		style  HardBreakStyle
+		expect string
+	}{
+		{"backslash default", "a" + br + "b", "", "a\\\nb"},
+		{"backslash", "a" + br + "b", HardBreakBackslash, "a\\\nb"},
+		{"spaces", "a" + br + "b", HardBreakSpaces, "a  \nb"},
+		{"newline", "a" + br + "b", HardBreakNewline, "a\nb"},
+		{"html", "a" + br + "b", HardBreakHTML, "a<br>b"},
+		{"surrounding whitespace", "a " + br + " b", HardBreakBackslash, "a\\\nb"},
+		{"bullet list item", "- one" + br + "two", HardBreakBackslash, "- one\\\n  two"},
+		{"nested ordered list item", "   10. one" + br + "two", HardBreakSpaces, "   10. one  \n       two"},
+		{"html in list item", "- one" + br + "two", HardBreakHTML, "- one<br>two"},
+		{"blockquote", "> one" + br + "two", HardBreakBackslash, "> one\\\n> two"},
+		{"indented paragraph", "    one" + br + "two", HardBreakBackslash, "    one\\\n    two"},
+		{"table row", "| a" + br + "b | c |", HardBreakBackslash, "| a<br>b | c |"},
+		{"heading", "## One" + br + "Two", HardBreakBackslash, "## One Two"},
+		{"trailing break dropped", "a" + br, HardBreakBackslash, "a"},
+		{"leading break dropped", br + "a", HardBreakBackslash, "a"},
+		{"leading break in list dropped", "  " + br + "a", HardBreakBackslash, "  a"},
+		{"multiple breaks", "a" + br + "b" + br + "c", HardBreakSpaces, "a  \nb  \nc"},
+		{"no markers", "plain\ntext", HardBreakSpaces, "plain\ntext"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderHardBreaks(tt.input, tt.style); got != tt.expect {
+				t.Errorf("renderHardBreaks() = %q, want %q", got, tt.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// calloutMarker and calloutMarkerEnd enclose the class of an information
+// macro in the paragraph that starts the blockquote markCallouts makes of
+// it, while pandoc converts the document.
+const (
+	calloutMarker    = "CTWOMDCALLOUT"
+	calloutMarkerEnd = "Z"
+)
+
+var (
+	// admonitionBodyPattern matches the opening tag of an information
+	// macro's body.
+	admonitionBodyPattern = regexp.MustCompile(`<div class="confluence-information-macro-body"[^>]*>`)
+
+	// admonitionIconPattern matches the icon of an information macro.
+	admonitionIconPattern = regexp.MustCompile(`<span class="aui-icon[^"]*"[^>]*></span>`)
+
+	// calloutMarkerLinePattern matches the marker line of a blockquote in
+	// pandoc's output, capturing its indentation and the macro class.
+	calloutMarkerLinePattern = regexp.MustCompile(`^([ \t]*)> ?` + calloutMarker + `([a-z]+)` + calloutMarkerEnd + `[ \t]*$`)
+)
+
+// writesCallouts reports whether the flavor has a syntax of its own for
+// admonitions: Obsidian callouts and MkDocs admonitions.
+func (f Flavor) writesCallouts() bool {
+	return f == FlavorObsidian || f == FlavorMkDocs
+}
+
+// markCallouts turns information macros into blockquotes that start with
+// a marker naming the macro class, so that pandoc converts their whole
+// body, code blocks included, for restoreCallouts.
+func markCallouts(html string) string {
+	return replaceElements(html, orgAdmonitionPattern, "div", func(inner string, m []string) string {
+		inner = replaceElements(inner, admonitionBodyPattern, "div", func(body string, _ []string) string {
+			return body
+		})
+		inner = admonitionIconPattern.ReplaceAllString(inner, "")
+		return fmt.Sprintf("<blockquote><p>%s%s%s</p>%s</blockquote>", calloutMarker, m[1], calloutMarkerEnd, inner)
+	})
+}
+
+// restoreCallouts turns the blockquotes of markCallouts into the
+// flavor's admonition syntax: "> [!tip]" callouts for Obsidian, "!!! tip"
+// blocks with their content indented by four spaces for MkDocs. They are
+// titled with labels (see Options.AdmonitionLabels) where it differs from
+// the title the renderer shows by default.
+func restoreCallouts(md string, flavor Flavor, labels map[AdmonitionType]string) string {
+	if !strings.Contains(md, calloutMarker) {
+		return md
+	}
+	lines := strings.Split(md, "\n")
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		m := calloutMarkerLinePattern.FindStringSubmatch(lines[i])
+		if m == nil {
+			out = append(out, lines[i])
+			continue
+		}
+		indent, class := m[1], m[2]
+		typ := admonitionTypeForClass(class)
+		title := ""
+		if label := admonitionLabel(class, labels); label != DefaultAdmonitionLabels[typ] {
+			title = label
+		}
+		quote := indent + ">"
+		// Skip the blank line between the marker and the content
+		if i+1 < len(lines) && lines[i+1] == quote {
+			i++
+		}
+
+		if flavor != FlavorMkDocs {
+			out = append(out, strings.TrimRight(fmt.Sprintf("%s> [!%s] %s", indent, typ, title), " "))
+			continue
+		}
+		if title != "" {
+			out = append(out, fmt.Sprintf(`%s!!! %s "%s"`, indent, typ, title))
+		} else {
+			out = append(out, fmt.Sprintf("%s!!! %s", indent, typ))
+		}
+		for ; i+1 < len(lines) && strings.HasPrefix(lines[i+1], quote); i++ {
+			content := strings.TrimPrefix(strings.TrimPrefix(lines[i+1], quote), " ")
+			if content == "" {
+				out = append(out, "")
+			} else {
+				out = append(out, indent+"    "+content)
+			}
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// admonitionTypeForClass returns the admonition type of the information
+// macro with the given class suffix.
+func admonitionTypeForClass(class string) AdmonitionType {
+	for typ, c := range admonitionMacroClasses {
+		if c == class {
+			return typ
+		}
+	}
+	return AdmonitionNone
+}
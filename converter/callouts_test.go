@@ -0,0 +1,63 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkCallouts(t *testing.T) {
+	html := `<div class="confluence-information-macro confluence-information-macro-tip"><span class="aui-icon aui-icon-small aui-iconfont-approve confluence-information-macro-icon"></span><div class="confluence-information-macro-body"><p>Save often</p></div></div>`
+	want := `<blockquote><p>CTWOMDCALLOUTtipZ</p><p>Save often</p></blockquote>`
+	if got := markCallouts(html); got != want {
+		t.Errorf("markCallouts() = %q, want %q", got, want)
+	}
+}
+
+func TestRestoreCallouts(t *testing.T) {
+	tests := []struct {
+		name   string
+		flavor Flavor
+		labels map[AdmonitionType]string
+		md     string
+		want   string
+	}{
+		{
+			name:   "Obsidian",
+			flavor: FlavorObsidian,
+			md:     "Intro\n\n> CTWOMDCALLOUTtipZ\n>\n> Save often\n>\n> ``` go\n> x := 1\n> ```\n\nAfter\n",
+			want:   "Intro\n\n> [!tip]\n> Save often\n>\n> ``` go\n> x := 1\n> ```\n\nAfter\n",
+		},
+		{
+			name:   "Obsidian with a localized title",
+			flavor: FlavorObsidian,
+			labels: map[AdmonitionType]string{AdmonitionNote: "Hinweis"},
+			md:     "> CTWOMDCALLOUTnoteZ\n>\n> Text\n",
+			want:   "> [!note] Hinweis\n> Text\n",
+		},
+		{
+			name:   "MkDocs",
+			flavor: FlavorMkDocs,
+			md:     "> CTWOMDCALLOUTinformationZ\n>\n> First\n>\n> > Quoted\n\nAfter\n",
+			want:   "!!! info\n    First\n\n    > Quoted\n\nAfter\n",
+		},
+		{
+			name:   "MkDocs in a list item with a localized title",
+			flavor: FlavorMkDocs,
+			labels: map[AdmonitionType]string{AdmonitionWarning: "Warnung"},
+			md:     "- Item\n\n    > CTWOMDCALLOUTwarningZ\n    >\n    > Hot\n",
+			want:   "- Item\n\n    !!! warning \"Warnung\"\n        Hot\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := restoreCallouts(tt.md, tt.flavor, tt.labels)
+			if got != tt.want {
+				t.Errorf("restoreCallouts() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if strings.Contains(got, calloutMarker) {
+				t.Errorf("restoreCallouts() left a marker: %q", got)
+			}
+		})
+	}
+}
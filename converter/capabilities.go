@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aqueeb/confluence2md/internal/pandoc"
+)
+
+// capabilityProbeTimeout bounds the pandoc --list-* calls.
+const capabilityProbeTimeout = 30 * time.Second
+
+// CheckPandocCapabilities verifies that the pandoc selected by opts.Engine
+// can read HTML and write the format that opts needs, so that an old or
+// limited pandoc is reported before any file is converted.
+func CheckPandocCapabilities(opts Options) error {
+	ctx, cancel := context.WithTimeout(context.Background(), capabilityProbeTimeout)
+	defer cancel()
+
+	path, err := pandocPath(opts.Engine)
+	if err != nil {
+		return err
+	}
+	features, err := pandoc.CapabilitiesOf(ctx, path)
+	if err != nil {
+		return fmt.Errorf("failed to query pandoc capabilities: %w", err)
+	}
+	return checkFeatures(features, opts)
+}
+
+// checkFeatures reports an error if features lacks the HTML reader or the
+// writer for the output format selected by opts.
+func checkFeatures(features pandoc.Features, opts Options) error {
+	version := features.Version
+	if version == "" {
+		version = "pandoc"
+	}
+	if !features.SupportsInput("html") {
+		return fmt.Errorf("%s cannot read HTML; install pandoc 2.0 or later: https://pandoc.org/installing.html", version)
+	}
+
+	writer, output := requiredWriter(opts)
+	if writer != "" && !features.SupportsOutput(writer) {
+		return fmt.Errorf("%s has no %q writer, which %s output needs; install pandoc 2.0 or later: https://pandoc.org/installing.html", version, writer, output)
+	}
+	return nil
+}
+
+// requiredWriter returns the pandoc writer the conversion needs and a
+// description of the output for error messages. The writer is empty when
+// it cannot be known yet (PDF output without an installed PDF engine).
+func requiredWriter(opts Options) (writer, output string) {
+	switch opts.To {
+	case FormatOrg, FormatPlain, FormatJSON:
+		return opts.To.pandocWriter(), string(opts.To)
+	case FormatDOCX:
+		return "docx", string(opts.To)
+	case FormatPDF:
+		engine, err := FindPDFEngine()
+		if err != nil {
+			return "", string(opts.To)
+		}
+		return pdfEngineWriter(engine), fmt.Sprintf("PDF (%s)", engine)
+	}
+	flavor := opts.Flavor
+	if flavor == "" {
+		flavor = FlavorGFM
+	}
+	return flavor.pandocWriter(), fmt.Sprintf("%s-flavored Markdown", flavor)
+}
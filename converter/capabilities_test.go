@@ -0,0 +1,57 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/internal/pandoc"
+)
+
+func TestCheckFeatures(t *testing.T) {
+	full := pandoc.Features{
+		Version:       "pandoc 3.1.11",
+		InputFormats:  []string{"html", "markdown"},
+		OutputFormats: []string{"docx", "gfm", "json", "org", "plain"},
+	}
+	old := pandoc.Features{
+		Version:       "pandoc 1.17.2",
+		InputFormats:  []string{"html"},
+		OutputFormats: []string{"markdown_github", "org"},
+	}
+
+	tests := []struct {
+		name     string
+		features pandoc.Features
+		opts     Options
+		wantErr  string
+	}{
+		{"markdown", full, Options{}, ""},
+		{"gitlab", full, Options{Flavor: FlavorGitLab}, ""},
+		{"org", full, Options{To: FormatOrg}, ""},
+		{"plain", full, Options{To: FormatPlain}, ""},
+		{"json", full, Options{To: FormatJSON}, ""},
+		{"docx", full, Options{To: FormatDOCX}, ""},
+		{"old pandoc gfm", old, Options{}, `pandoc 1.17.2 has no "gfm" writer, which gfm-flavored Markdown output needs`},
+		{"old pandoc gitlab", old, Options{Flavor: FlavorGitLab}, "gitlab-flavored Markdown"},
+		{"old pandoc commonmark", old, Options{Flavor: FlavorCommonMark}, `no "commonmark" writer, which commonmark-flavored Markdown output needs`},
+		{"old pandoc org", old, Options{To: FormatOrg}, ""},
+		{"old pandoc plain", old, Options{To: FormatPlain}, `no "plain" writer`},
+		{"old pandoc docx", old, Options{To: FormatDOCX}, `no "docx" writer`},
+		{"no html reader", pandoc.Features{OutputFormats: []string{"gfm"}}, Options{}, "pandoc cannot read HTML"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkFeatures(tt.features, tt.opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkFeatures() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkFeatures() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
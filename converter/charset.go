@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// utf8Declaration is the charset declaration put in the head of extracted
+// HTML, so that pandoc reads it the same way whatever the export declared.
+const utf8Declaration = `<meta charset="utf-8">`
+
+// charsetDeclaration is the declaration put in the head of HTML passed
+// through undecoded, naming the charset it is in.
+const charsetDeclaration = `<meta charset="%s">`
+
+var (
+	// metaCharsetPattern matches charset declarations, <meta charset="...">
+	// and <meta http-equiv="Content-Type" content="text/html; charset=...">,
+	// capturing the charset.
+	metaCharsetPattern = regexp.MustCompile(`(?is)<meta\s[^>]*?charset\s*=\s*["']?\s*([A-Za-z0-9._:-]+)[^>]*>`)
+
+	// charsetNameInvalidPattern matches the characters that cannot be part
+	// of a charset name in a declaration.
+	charsetNameInvalidPattern = regexp.MustCompile(`[^A-Za-z0-9._:-]+`)
+
+	// headStartPattern and htmlStartPattern match the start tags of the
+	// head and html elements.
+	headStartPattern = regexp.MustCompile(`(?i)<head\b[^>]*>`)
+	htmlStartPattern = regexp.MustCompile(`(?i)<html\b[^>]*>`)
+)
+
+// windows1252 maps the bytes 0x80 to 0x9F of Windows-1252 to Unicode. The
+// other bytes map to the code point of the same value, as in ISO-8859-1.
+// The five undefined bytes map to the C1 controls of the same value.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡',
+	'ˆ', '‰', 'Š', '‹', 'Œ', '\u008D', 'Ž', '\u008F',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—',
+	'˜', '™', 'š', '›', 'œ', '\u009D', 'ž', 'Ÿ',
+}
+
+// decodeHTMLPart transcodes the HTML part of an export to UTF-8 and
+// declares UTF-8 in its head. The charset comes from the part's
+// Content-Type, else from a declaration in the HTML. ISO-8859-1 and
+// Windows-1252 are decoded as Windows-1252, as browsers do. Bytes that are
+// not valid UTF-8 in UTF-8 HTML, or HTML without a charset, are taken for
+// Windows-1252 too. Content in other charsets is kept as UTF-8 when it is
+// valid UTF-8 anyway, and passed through undecoded otherwise, declared in
+// its charset (see UndecodedCharset).
+func decodeHTMLPart(data []byte, charset string) string {
+	if charset == "" {
+		if m := metaCharsetPattern.FindSubmatch(data); m != nil {
+			charset = string(m[1])
+		}
+	}
+
+	var html string
+	switch normalizeCharset(charset) {
+	case "windows-1252":
+		html = decodeWindows1252(data)
+	case "utf-8", "":
+		html = decodeUTF8(data)
+	default:
+		if !utf8.Valid(data) {
+			return declareCharset(string(data), fmt.Sprintf(charsetDeclaration, charsetName(charset)))
+		}
+		html = string(data)
+	}
+	return declareCharset(html, utf8Declaration)
+}
+
+// UndecodedCharset returns the charset of extracted HTML that was passed
+// through undecoded because its charset is not supported, or "" when the
+// HTML is UTF-8.
+func UndecodedCharset(html string) string {
+	m := metaCharsetPattern.FindStringSubmatch(html)
+	if m == nil || normalizeCharset(m[1]) == "utf-8" {
+		return ""
+	}
+	return m[1]
+}
+
+// charsetName returns charset without the characters metaCharsetPattern
+// does not accept in a name, or "unknown" when none are left.
+func charsetName(charset string) string {
+	if name := charsetNameInvalidPattern.ReplaceAllString(charset, ""); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// normalizeCharset returns the canonical name of the charsets
+// decodeHTMLPart decodes, and the lower-cased name of others.
+func normalizeCharset(charset string) string {
+	charset = strings.ToLower(strings.Trim(strings.TrimSpace(charset), `"'`))
+	switch charset {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return "utf-8"
+	case "iso-8859-1", "iso8859-1", "iso_8859-1", "latin1", "l1", "windows-1252", "cp1252", "x-cp1252":
+		return "windows-1252"
+	}
+	return charset
+}
+
+// decodeUTF8 decodes UTF-8 text, decoding the bytes that are not valid
+// UTF-8 as Windows-1252.
+func decodeUTF8(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	var b strings.Builder
+	b.Grow(len(data) + len(data)/8)
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 {
+			b.WriteString(decodeWindows1252(data[:1]))
+		} else {
+			b.Write(data[:size])
+		}
+		data = data[size:]
+	}
+	return b.String()
+}
+
+// decodeWindows1252 decodes Windows-1252 text.
+func decodeWindows1252(data []byte) string {
+	var b strings.Builder
+	b.Grow(len(data) + len(data)/8)
+	for _, c := range data {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case c < 0xA0:
+			b.WriteRune(windows1252[c-0x80])
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+// declareCharset replaces the charset declarations of html with the single
+// declaration given, at the start of its head, adding a head if it has
+// none.
+func declareCharset(html, declaration string) string {
+	html = metaCharsetPattern.ReplaceAllString(html, "")
+	if loc := headStartPattern.FindStringIndex(html); loc != nil {
+		return html[:loc[1]] + declaration + html[loc[1]:]
+	}
+	if loc := htmlStartPattern.FindStringIndex(html); loc != nil {
+		return html[:loc[1]] + "<head>" + declaration + "</head>" + html[loc[1]:]
+	}
+	return declaration + html
+}
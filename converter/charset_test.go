@@ -0,0 +1,110 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeHTMLPart(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		charset string
+		want    string
+	}{
+		{
+			name: "utf-8 without charset",
+			data: "<html><head><title>Café</title></head><body>Café</body></html>",
+			want: `<html><head><meta charset="utf-8"><title>Café</title></head><body>Café</body></html>`,
+		},
+		{
+			name:    "latin1 part",
+			data:    "<html><body>Caf\xe9 \x93quoted\x94</body></html>",
+			charset: "ISO-8859-1",
+			want:    `<html><head><meta charset="utf-8"></head><body>Café “quoted”</body></html>`,
+		},
+		{
+			name: "charset from the HTML replaced",
+			data: "<html><head><meta http-equiv=\"Content-Type\" content=\"text/html; charset=windows-1252\"></head><body>\x80 5</body></html>",
+			want: `<html><head><meta charset="utf-8"></head><body>€ 5</body></html>`,
+		},
+		{
+			name:    "stray bytes in utf-8",
+			data:    "<p>Ü and \xe9</p>",
+			charset: "utf-8",
+			want:    `<meta charset="utf-8"><p>Ü and é</p>`,
+		},
+		{
+			name:    "other charset with utf-8 content",
+			data:    "<p>plain</p>",
+			charset: "shift_jis",
+			want:    `<meta charset="utf-8"><p>plain</p>`,
+		},
+		{
+			name:    "other charset passed through",
+			data:    "<html><head><meta charset=\"Shift_JIS\"></head><body>\x82\xa0</body></html>",
+			charset: "shift_jis",
+			want:    "<html><head><meta charset=\"shift_jis\"></head><body>\x82\xa0</body></html>",
+		},
+		{
+			name:    "other charset with an odd name",
+			data:    "<p>\x82\xa0</p>",
+			charset: "x<y>",
+			want:    "<meta charset=\"xy\"><p>\x82\xa0</p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeHTMLPart([]byte(tt.data), tt.charset); got != tt.want {
+				t.Errorf("decodeHTMLPart() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractHTMLFromMIME_Charset(t *testing.T) {
+	mimeContent := "Date: Wed, 7 Jan 2026 01:29:00 +0000 (UTC)\n" +
+		"Subject: Exported From Confluence\n" +
+		"MIME-Version: 1.0\n" +
+		"Content-Type: multipart/related; boundary=\"b\"\n\n" +
+		"--b\n" +
+		"Content-Type: text/html; charset=iso-8859-1\n" +
+		"Content-Transfer-Encoding: quoted-printable\n\n" +
+		"<html><head><meta charset=3D\"iso-8859-1\"></head><body>Stra=DFe</body></html>\n" +
+		"--b--\n"
+	testFile := filepath.Join(t.TempDir(), "latin1.doc")
+	if err := os.WriteFile(testFile, []byte(mimeContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	html, err := ExtractHTMLFromMIME(testFile)
+	if err != nil {
+		t.Fatalf("ExtractHTMLFromMIME failed: %v", err)
+	}
+	want := `<html><head><meta charset="utf-8"></head><body>Straße</body></html>`
+	if html != want {
+		t.Errorf("ExtractHTMLFromMIME() = %q, want %q", html, want)
+	}
+}
+
+func TestUndecodedCharset(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"decoded", decodeHTMLPart([]byte("<p>Caf\xe9</p>"), "windows-1252"), ""},
+		{"passed through", decodeHTMLPart([]byte("<p>\x82\xa0</p>"), "Shift_JIS"), "Shift_JIS"},
+		{"no declaration", "<p>plain</p>", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UndecodedCharset(tt.html); got != tt.want {
+				t.Errorf("UndecodedCharset() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
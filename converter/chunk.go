@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Chunk is a piece of a converted page sized for embedding models.
+type Chunk struct {
+	// Headings is the path of headings enclosing the chunk, outermost first.
+	Headings []string
+	// Text is the chunk's Markdown.
+	Text string
+	// Tokens is the estimated token count of Text (see EstimateTokens).
+	Tokens int
+}
+
+// EstimateTokens approximates the number of tokens a text takes up in
+// common LLM tokenizers, at four characters per token.
+func EstimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
+
+// ChunkMarkdown splits a Markdown page into chunks of at most maxTokens
+// estimated tokens for vector-store ingestion. Chunks never span headings,
+// so each one belongs to a single section; long sections are split between
+// paragraphs, and paragraphs longer than maxTokens between words. Each
+// chunk after the first of a section starts with up to overlap tokens from
+// the end of the previous one. Front matter is skipped.
+func ChunkMarkdown(md string, maxTokens, overlap int) []Chunk {
+	if maxTokens <= 0 {
+		return nil
+	}
+	overlap = min(max(overlap, 0), maxTokens/2)
+
+	_, body := splitFrontMatter(md)
+	lines := strings.Split(body, "\n")
+
+	var chunks []Chunk
+	var path []heading
+	start := 0
+	emit := func(end int) {
+		headings := make([]string, len(path))
+		for i, h := range path {
+			headings[i] = tocLinkText(h.text)
+		}
+		chunks = append(chunks, chunkSection(lines[start:end], headings, maxTokens, overlap)...)
+	}
+	for _, h := range findHeadings(lines) {
+		emit(h.line)
+		for len(path) > 0 && path[len(path)-1].level >= h.level {
+			path = path[:len(path)-1]
+		}
+		path = append(path, h)
+		start = h.line
+	}
+	emit(len(lines))
+	return chunks
+}
+
+// chunkSection packs the blocks of one section into chunks.
+func chunkSection(lines []string, headings []string, maxTokens, overlap int) []Chunk {
+	blocks := splitBlocks(lines)
+	if len(blocks) == 0 || (len(blocks) == 1 && atxHeadingPattern.MatchString(blocks[0])) {
+		// Nothing but a heading: the next section carries its path
+		return nil
+	}
+
+	var chunks []Chunk
+	var current []string
+	flush := func() {
+		text := strings.Join(current, "\n\n")
+		chunks = append(chunks, Chunk{Headings: headings, Text: text, Tokens: EstimateTokens(text)})
+	}
+	for _, block := range blocks {
+		for _, piece := range splitOversized(block, maxTokens) {
+			if len(current) > 0 && EstimateTokens(strings.Join(current, "\n\n")+"\n\n"+piece) > maxTokens {
+				flush()
+				current = overlapTail(strings.Join(current, "\n\n"), overlap)
+				if len(current) > 0 && EstimateTokens(current[0]+"\n\n"+piece) > maxTokens {
+					current = nil
+				}
+			}
+			current = append(current, piece)
+		}
+	}
+	if len(current) > 0 {
+		flush()
+	}
+	return chunks
+}
+
+// splitBlocks splits Markdown lines into blank-line separated blocks,
+// keeping fenced code blocks whole.
+func splitBlocks(lines []string) []string {
+	var blocks []string
+	var current []string
+	inFence := false
+	for _, line := range lines {
+		if fencePattern.MatchString(line) {
+			inFence = !inFence
+		}
+		if !inFence && strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				blocks = append(blocks, strings.Join(current, "\n"))
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, strings.Join(current, "\n"))
+	}
+	return blocks
+}
+
+// splitOversized splits a block longer than maxTokens between words, and
+// words longer than maxTokens between characters.
+func splitOversized(block string, maxTokens int) []string {
+	if EstimateTokens(block) <= maxTokens {
+		return []string{block}
+	}
+
+	maxRunes := maxTokens * 4
+	var pieces []string
+	var current strings.Builder
+	for _, word := range strings.Fields(block) {
+		if current.Len() > 0 && utf8.RuneCountInString(current.String())+1+utf8.RuneCountInString(word) > maxRunes {
+			pieces = append(pieces, current.String())
+			current.Reset()
+		}
+		for utf8.RuneCountInString(word) > maxRunes {
+			runes := []rune(word)
+			pieces = append(pieces, string(runes[:maxRunes]))
+			word = string(runes[maxRunes:])
+		}
+		if current.Len() > 0 {
+			current.WriteByte(' ')
+		}
+		current.WriteString(word)
+	}
+	if current.Len() > 0 {
+		pieces = append(pieces, current.String())
+	}
+	return pieces
+}
+
+// overlapTail returns the last words of text that fit in overlap tokens,
+// as the start of the next chunk.
+func overlapTail(text string, overlap int) []string {
+	if overlap <= 0 {
+		return nil
+	}
+	words := strings.Fields(text)
+	n := 0
+	for n < len(words) && EstimateTokens(strings.Join(words[len(words)-n-1:], " ")) <= overlap {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	return []string{strings.Join(words[len(words)-n:], " ")}
+}
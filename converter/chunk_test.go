@@ -0,0 +1,84 @@
+package converter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"äöüß", 1},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.in); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestChunkMarkdown_HeadingBoundaries(t *testing.T) {
+	md := "---\ntitle: Page\n---\n\nIntro text.\n\n# Setup\n\n## Install\n\nRun the [installer](https://example.com).\n\n```sh\nmake\n\nmake install\n```\n\n## Configure\n\nEdit the file.\n\n# Usage\n\nStart it.\n"
+
+	got := ChunkMarkdown(md, 100, 0)
+	want := []Chunk{
+		{Headings: []string{}, Text: "Intro text.", Tokens: 3},
+		{Headings: []string{"Setup", "Install"}, Text: "## Install\n\nRun the [installer](https://example.com).\n\n```sh\nmake\n\nmake install\n```", Tokens: 21},
+		{Headings: []string{"Setup", "Configure"}, Text: "## Configure\n\nEdit the file.", Tokens: 7},
+		{Headings: []string{"Usage"}, Text: "# Usage\n\nStart it.", Tokens: 5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChunkMarkdown() =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestChunkMarkdown_SplitsLongSections(t *testing.T) {
+	paragraph := strings.TrimSpace(strings.Repeat("word ", 30)) // 149 chars, 38 tokens
+	md := "# Long\n\n" + paragraph + "\n\n" + paragraph + "\n\n" + paragraph + "\n"
+
+	chunks := ChunkMarkdown(md, 50, 5)
+	if len(chunks) < 3 {
+		t.Fatalf("expected the section to be split, got %d chunk(s)", len(chunks))
+	}
+	for i, c := range chunks {
+		if c.Tokens > 50 {
+			t.Errorf("chunk %d has %d tokens, want at most 50", i, c.Tokens)
+		}
+		if !reflect.DeepEqual(c.Headings, []string{"Long"}) {
+			t.Errorf("chunk %d headings = %v, want [Long]", i, c.Headings)
+		}
+		if i > 0 && !strings.HasPrefix(c.Text, "word word word word") {
+			t.Errorf("chunk %d should start with overlap from the previous chunk, got %q", i, c.Text)
+		}
+	}
+}
+
+func TestChunkMarkdown_OversizedParagraph(t *testing.T) {
+	md := strings.Repeat("lorem ", 100) + strings.Repeat("x", 50)
+	chunks := ChunkMarkdown(md, 10, 0)
+	for i, c := range chunks {
+		if c.Tokens > 10 {
+			t.Errorf("chunk %d has %d tokens, want at most 10: %q", i, c.Tokens, c.Text)
+		}
+	}
+	var words []string
+	for _, c := range chunks {
+		words = append(words, strings.Fields(c.Text)...)
+	}
+	if joined := strings.Join(words, ""); joined != strings.Repeat("lorem", 100)+strings.Repeat("x", 50) {
+		t.Errorf("chunks lost text: %q", joined)
+	}
+}
+
+func TestChunkMarkdown_InvalidMax(t *testing.T) {
+	if got := ChunkMarkdown("text", 0, 0); got != nil {
+		t.Errorf("ChunkMarkdown(max 0) = %v, want nil", got)
+	}
+}
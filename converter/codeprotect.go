@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// codePlaceholderPattern matches the placeholders protectCode substitutes
+// for code. NUL bytes never occur in pandoc output, so placeholders cannot
+// collide with document text.
+var codePlaceholderPattern = regexp.MustCompile("\x00(\\d+)\x00")
+
+// protectCode applies fn to md with the contents of fenced code blocks and
+// inline code spans masked, so that text replacements (emoji shortcodes,
+// entity cleanup, link rewriting, tag stripping) never alter code samples.
+// Fence lines stay visible so fn can still clean up their info strings.
+func protectCode(md string, fn func(string) string) string {
+	var saved []string
+	mask := func(code string) string {
+		saved = append(saved, code)
+		return fmt.Sprintf("\x00%d\x00", len(saved)-1)
+	}
+
+	lines := strings.Split(md, "\n")
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		if fencePattern.MatchString(lines[i]) {
+			end := i + 1
+			for end < len(lines) && !fencePattern.MatchString(lines[end]) {
+				end++
+			}
+			if end < len(lines) {
+				out = append(out, lines[i])
+				if end > i+1 {
+					out = append(out, mask(strings.Join(lines[i+1:end], "\n")))
+				}
+				out = append(out, lines[end])
+				i = end
+				continue
+			}
+		}
+		out = append(out, maskInlineCode(lines[i], mask))
+	}
+
+	result := fn(strings.Join(out, "\n"))
+	return codePlaceholderPattern.ReplaceAllStringFunc(result, func(placeholder string) string {
+		idx, err := strconv.Atoi(codePlaceholderPattern.FindStringSubmatch(placeholder)[1])
+		if err != nil || idx >= len(saved) {
+			return placeholder
+		}
+		return saved[idx]
+	})
+}
+
+// maskInlineCode replaces the inline code spans in a line, backticks
+// included, with the result of mask. A span opens with a run of backticks
+// and closes with the next run of the same length; escaped backticks and
+// runs without a closing partner are left alone.
+func maskInlineCode(line string, mask func(string) string) string {
+	if !strings.Contains(line, "`") {
+		return line
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case '\\':
+			end := i + 2
+			if end > len(line) {
+				end = len(line)
+			}
+			b.WriteString(line[i:end])
+			i = end
+			continue
+		case '`':
+			n := backtickRun(line, i)
+			if end := findClosingBackticks(line, i+n, n); end != -1 {
+				b.WriteString(mask(line[i:end]))
+				i = end
+				continue
+			}
+			b.WriteString(line[i : i+n])
+			i += n
+			continue
+		}
+		b.WriteByte(line[i])
+		i++
+	}
+	return b.String()
+}
+
+// backtickRun returns the length of the run of backticks starting at i.
+func backtickRun(s string, i int) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == '`' {
+		n++
+	}
+	return n
+}
+
+// findClosingBackticks returns the index just past the first run of exactly
+// n backticks at or after start, or -1 if there is none.
+func findClosingBackticks(s string, start, n int) int {
+	for i := start; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		run := backtickRun(s, i)
+		if run == n {
+			return i + run
+		}
+		i += run
+	}
+	return -1
+}
@@ -0,0 +1,91 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProtectCode(t *testing.T) {
+	upper := func(s string) string { return strings.ToUpper(s) }
+
+	tests := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			name:   "fenced block body protected",
+			input:  "text\n```yaml\nkey: value\n```\nmore",
+			expect: "TEXT\n```YAML\nkey: value\n```\nMORE",
+		},
+		{
+			name:   "inline code protected",
+			input:  "use `code` here",
+			expect: "USE `code` HERE",
+		},
+		{
+			name:   "double backtick span",
+			input:  "a ``x ` y`` b",
+			expect: "A ``x ` y`` B",
+		},
+		{
+			name:   "escaped backtick is not a span",
+			input:  "a \\`b` c",
+			expect: "A \\`B` C",
+		},
+		{
+			name:   "unclosed fence left alone",
+			input:  "```\ncode",
+			expect: "```\nCODE",
+		},
+		{
+			name:   "indented fence in list",
+			input:  "- item\n\n  ```\n  x\n  ```",
+			expect: "- ITEM\n\n  ```\n  x\n  ```",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := protectCode(tt.input, upper); got != tt.expect {
+				t.Errorf("protectCode() = %q, want %q", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestPostProcessMarkdown_ProtectsCode(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		keep  string
+	}{
+		{"emoji shortcode in fenced block", "```yaml\nicon: :fire:\n```\n", "icon: :fire:"},
+		{"emoji shortcode in inline code", "Set `:fire:` as the icon\n", "`:fire:`"},
+		{"entity in code", "```html\n<p>Tom &amp; Jerry</p>\n```\n", "Tom &amp; Jerry"},
+		{"br in code", "```html\nline<br/>break\n```\n", "line<br/>break"},
+		{"html link in code", "```html\n<a href=\"/x\">x</a>\n```\n", `<a href="/x">x</a>`},
+		{"blank lines in code", "```\na\n\n\n\nb\n```\n", "a\n\n\n\nb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := postProcessMarkdown(tt.input); !strings.Contains(got, tt.keep) {
+				t.Errorf("postProcessMarkdown() = %q, want code %q kept", got, tt.keep)
+			}
+		})
+	}
+
+	// Replacements still apply outside code
+	if got := postProcessMarkdown("Launch :rocket: with `:rocket:`\n"); got != "Launch 🚀 with `:rocket:`\n" {
+		t.Errorf("postProcessMarkdown() = %q", got)
+	}
+}
+
+func TestRewriteLinks_SkipsCode(t *testing.T) {
+	input := "[page](/display/ENG/Page)\n\n```markdown\n[page](/display/ENG/Page)\n```\n\n`[x](/y)`"
+	want := "[page](https://wiki.example.com/display/ENG/Page)\n\n```markdown\n[page](/display/ENG/Page)\n```\n\n`[x](/y)`"
+	if got := rewriteLinks(input, "https://wiki.example.com", nil, nil); got != want {
+		t.Errorf("rewriteLinks() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"io"
+)
+
+// Result is a converted Confluence export and what is known about its page.
+type Result struct {
+	// Output is the converted document in the format selected by
+	// Options.To: Markdown or other text, or the bytes of a DOCX or PDF
+	// file.
+	Output []byte
+	// Metadata holds the export's headers and the page title (see
+	// ExportMetadata.PageTitle).
+	Metadata ExportMetadata
+	// Page identifies the Confluence page the export was made from.
+	Page PageInfo
+	// Stats counts the words, headings, tables, images, and code blocks of
+	// the page.
+	Stats DocumentStats
+}
+
+// Convert converts a Confluence MIME export read from r, such as an export
+// downloaded through the Confluence API, without touching the disk for its
+// input. Output files of binary formats still go through pandoc's
+// temporary files (see Options.MinimizeTempFiles).
+func Convert(r io.Reader, opts Options) (Result, error) {
+	return ConvertWithLimits(r, opts, MIMELimits{})
+}
+
+// ConvertWithLimits is Convert with the given limits on header size, part
+// count, and part size. Exceeding one returns an error wrapping
+// ErrMIMELimit.
+func ConvertWithLimits(r io.Reader, opts Options, limits MIMELimits) (Result, error) {
+	limits = limits.withDefaults()
+	msg, err := readLimitedMessage(r, limits)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to parse MIME message: %w", err)
+	}
+	result := Result{Metadata: exportMetadata(msg.Header)}
+
+	html, err := extractHTMLFromMessage(msg, limits, 0)
+	if err != nil {
+		return result, fmt.Errorf("failed to extract HTML: %w", err)
+	}
+	if result.Metadata.PageTitle() == "" {
+		result.Metadata.HTMLTitle = ExtractHTMLTitle(html)
+	}
+	result.Page = ExtractPageInfo(html)
+	result.Stats = ComputeStats(html)
+
+	if opts.To.IsBinary() {
+		result.Output, err = ConvertHTMLToDocument(html, opts)
+		return result, err
+	}
+	text, err := ConvertHTMLToMarkdownWithOptions(html, opts)
+	if err != nil {
+		return result, err
+	}
+	result.Output = []byte(text)
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// convertExport is a Confluence export with a page ID, for Convert tests.
+const convertExport = "Date: Wed, 7 Jan 2026 01:29:00 +0000 (UTC)\n" +
+	"Subject: Exported From Confluence\n" +
+	"MIME-Version: 1.0\n" +
+	"Content-Type: multipart/related; boundary=\"b\"\n\n" +
+	"--b\n" +
+	"Content-Type: text/html; charset=UTF-8\n\n" +
+	"<html><head><title>Release Notes</title><meta name=\"ajs-page-id\" content=\"42\"></head>" +
+	"<body><h1>Notes</h1><p>First release.</p></body></html>\n" +
+	"--b--\n"
+
+func TestConvert(t *testing.T) {
+	installFakePandoc(t, fakePandocStripParagraphs)
+
+	result, err := Convert(strings.NewReader(convertExport), Options{Engine: EngineSystem})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if !strings.Contains(string(result.Output), "First release.") {
+		t.Errorf("Output = %q, want the page content", result.Output)
+	}
+	if got := result.Metadata.PageTitle(); got != "Release Notes" {
+		t.Errorf("PageTitle() = %q, want the HTML title", got)
+	}
+	if result.Metadata.Date.IsZero() {
+		t.Error("Metadata.Date not read")
+	}
+	if result.Page.PageID != "42" {
+		t.Errorf("Page.PageID = %q, want 42", result.Page.PageID)
+	}
+	if result.Stats.Headings != 1 || result.Stats.Words == 0 {
+		t.Errorf("Stats = %+v, want one heading and some words", result.Stats)
+	}
+}
+
+func TestConvert_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		limits MIMELimits
+		want   error
+	}{
+		{"not a MIME message", "just text", MIMELimits{}, nil},
+		{"no HTML part", strings.Replace(convertExport, "text/html", "text/plain", 1), MIMELimits{}, nil},
+		{"part too large", convertExport, MIMELimits{MaxPartBytes: 10}, ErrMIMELimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ConvertWithLimits(strings.NewReader(tt.input), Options{Engine: EngineSystem}, tt.limits)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
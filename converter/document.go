@@ -0,0 +1,309 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aqueeb/confluence2md/internal/pandoc"
+)
+
+// referenceDocxDir is the root of the embedded default reference document.
+const referenceDocxDir = "assets/reference-docx"
+
+// referenceDocxOnce guards the cached default reference document written
+// by cachedReferenceDocx.
+var (
+	referenceDocxOnce sync.Once
+	referenceDocxPath string
+	referenceDocxErr  error
+)
+
+// referenceDocxFS holds the parts of the default reference.docx, which gives
+// DOCX output neutral, Confluence-like styling. The parts are kept as plain
+// XML so that style changes are reviewable.
+//
+//go:embed all:assets/reference-docx
+var referenceDocxFS embed.FS
+
+// pdfEngines lists the PDF engines tried, in order of preference, with the
+// pandoc writer each one takes as input.
+var pdfEngines = []struct {
+	name   string
+	writer string
+}{
+	{"xelatex", "latex"},
+	{"lualatex", "latex"},
+	{"pdflatex", "latex"},
+	{"tectonic", "latex"},
+	{"typst", "typst"},
+	{"wkhtmltopdf", "html"},
+	{"weasyprint", "html"},
+}
+
+// ErrNoPDFEngine is returned when PDF output is requested but no PDF engine
+// is installed. Pandoc does not ship a LaTeX engine, so one must be installed
+// separately.
+var ErrNoPDFEngine = errors.New("PDF output needs a LaTeX engine (xelatex, lualatex, pdflatex, or tectonic) or typst, wkhtmltopdf, or weasyprint in PATH; install TeX Live (https://tug.org/texlive/) or MiKTeX (https://miktex.org/)")
+
+// FindPDFEngine returns the name of the first available PDF engine, or
+// ErrNoPDFEngine if none is installed.
+func FindPDFEngine() (string, error) {
+	for _, engine := range pdfEngines {
+		if _, err := exec.LookPath(engine.name); err == nil {
+			return engine.name, nil
+		}
+	}
+	return "", ErrNoPDFEngine
+}
+
+// pdfEngineWriter returns the pandoc writer used as input for a PDF engine.
+func pdfEngineWriter(name string) string {
+	for _, engine := range pdfEngines {
+		if engine.name == name {
+			return engine.writer
+		}
+	}
+	return "latex"
+}
+
+// ConvertHTMLToDocument converts HTML content to a binary document format
+// (FormatDOCX or FormatPDF, selected by opts.To) and returns the document.
+// Image caption and size, template, and reference document options are
+// honored; the Markdown-specific options are ignored.
+func ConvertHTMLToDocument(html string, opts Options) ([]byte, error) {
+	if !opts.To.IsBinary() {
+		return nil, fmt.Errorf("%q is not a document format", opts.To)
+	}
+
+	args, cleanup, err := templateArgs(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	var writer string
+	switch opts.To {
+	case FormatDOCX:
+		refPath := opts.ReferenceDoc
+		switch {
+		case refPath == "" && opts.MinimizeTempFiles:
+			refPath, err = cachedReferenceDocx()
+			if err != nil {
+				return nil, err
+			}
+		case refPath == "":
+			refPath, err = writeReferenceDocx()
+			if err != nil {
+				return nil, err
+			}
+			defer os.Remove(refPath)
+		}
+		writer = "docx"
+		args = append(args, "--reference-doc="+refPath)
+	case FormatPDF:
+		engine, err := FindPDFEngine()
+		if err != nil {
+			return nil, err
+		}
+		writer = pdfEngineWriter(engine)
+		args = append(args, "--pdf-engine="+engine)
+	}
+
+	ctx, cancel := conversionContext(opts)
+	defer cancel()
+
+	html, err = runPipeline(opts.Pipeline, StageHTML, html, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.MinimizeTempFiles && opts.To == FormatDOCX {
+		return runPandocToStdout(ctx, opts.Engine, opts.reader(), html, writer, args...)
+	}
+	return runPandocToFile(ctx, opts.Engine, opts.reader(), html, writer, opts.To.Extension(), args...)
+}
+
+// runPandocToStdout is like runPandocToFile but reads the document from
+// pandoc's standard output, which pandoc allows for binary formats other
+// than PDF.
+func runPandocToStdout(ctx context.Context, engine Engine, reader, html, to string, args ...string) ([]byte, error) {
+	path, err := pandocPath(engine)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	args = append([]string{"-o", "-"}, args...)
+	if err := pandoc.ConvertStreamWith(ctx, path, strings.NewReader(html), &out, reader, to, args...); err != nil {
+		return nil, pandocError(ctx, err, html)
+	}
+	return out.Bytes(), nil
+}
+
+// runPandocToFile converts HTML with pandoc's reader and writer to, using
+// the pandoc selected by engine, writing to a temporary output file (required for
+// binary formats) whose contents are returned. The HTML is streamed to
+// pandoc on stdin.
+func runPandocToFile(ctx context.Context, engine Engine, reader, html, to, ext string, args ...string) ([]byte, error) {
+	path, err := pandocPath(engine)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpOut, err := createTemp("confluence-*" + ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmpOut.Name())
+	tmpOut.Close()
+
+	args = append([]string{"-o", tmpOut.Name()}, args...)
+	if err := pandoc.ConvertStreamWith(ctx, path, strings.NewReader(html), io.Discard, reader, to, args...); err != nil {
+		return nil, pandocError(ctx, err, html)
+	}
+
+	doc, err := os.ReadFile(tmpOut.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read converted document: %w", err)
+	}
+	return doc, nil
+}
+
+// buildReferenceDocx zips the embedded reference document parts into a
+// .docx archive.
+func buildReferenceDocx() ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	err := fs.WalkDir(referenceDocxFS, referenceDocxDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := referenceDocxFS.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(strings.TrimPrefix(path, referenceDocxDir+"/"))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build reference document: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build reference document: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeReferenceDocx writes the default reference document to a temporary
+// file and returns its path. The caller removes the file.
+func writeReferenceDocx() (string, error) {
+	data, err := buildReferenceDocx()
+	if err != nil {
+		return "", err
+	}
+
+	f, err := createTemp("confluence-reference-*.docx")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write reference document: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write reference document: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// cachedReferenceDocx writes the default reference document to the cache
+// directory, once per process and only if it is not there yet, and returns
+// its path. The file is named after its content, so that builds with
+// different styles do not share it.
+func cachedReferenceDocx() (string, error) {
+	referenceDocxOnce.Do(func() {
+		referenceDocxPath, referenceDocxErr = writeCachedReferenceDocx()
+	})
+	return referenceDocxPath, referenceDocxErr
+}
+
+// writeCachedReferenceDocx does the work of cachedReferenceDocx. The file is
+// written under a temporary name and renamed, so concurrent runs never see
+// a partial document.
+func writeCachedReferenceDocx() (string, error) {
+	data, err := buildReferenceDocx()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	dir := pandoc.CacheDir()
+	path := filepath.Join(dir, fmt.Sprintf("reference-%x.docx", sum[:8]))
+	if info, err := os.Stat(path); err == nil && info.Size() == int64(len(data)) {
+		return path, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmpPath := fmt.Sprintf("%s.tmp.%d", path, os.Getpid())
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to write reference document: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to write reference document: %w", err)
+	}
+	return path, nil
+}
+
+// templateArgs returns the pandoc arguments selecting the template in opts.
+// An inline template is written to a temporary file, which the returned
+// cleanup function removes.
+func templateArgs(opts Options) ([]string, func(), error) {
+	noop := func() {}
+	switch {
+	case opts.Template != "":
+		return []string{"--standalone", "--template=" + opts.Template}, noop, nil
+	case opts.TemplateText != "":
+		// The extension stops pandoc from appending the writer's own
+		f, err := createTemp("confluence-template-*.tpl")
+		if err != nil {
+			return nil, noop, fmt.Errorf("failed to create temp file: %w", err)
+		}
+		cleanup := func() { os.Remove(f.Name()) }
+		if _, err := f.WriteString(opts.TemplateText); err != nil {
+			f.Close()
+			cleanup()
+			return nil, noop, fmt.Errorf("failed to write template: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			cleanup()
+			return nil, noop, fmt.Errorf("failed to write template: %w", err)
+		}
+		return []string{"--standalone", "--template=" + f.Name()}, cleanup, nil
+	default:
+		return nil, noop, nil
+	}
+}
@@ -0,0 +1,115 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBuildReferenceDocx(t *testing.T) {
+	data, err := buildReferenceDocx()
+	if err != nil {
+		t.Fatalf("buildReferenceDocx() error: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("reference document is not a zip archive: %v", err)
+	}
+
+	names := make(map[string]bool)
+	for _, f := range zr.File {
+		names[f.Name] = true
+	}
+	for _, want := range []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml", "word/_rels/document.xml.rels", "word/styles.xml"} {
+		if !names[want] {
+			t.Errorf("reference document is missing %s (has %v)", want, names)
+		}
+	}
+}
+
+func TestFindPDFEngine(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake engine scripts are not executable on Windows")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+
+	if _, err := FindPDFEngine(); !errors.Is(err, ErrNoPDFEngine) {
+		t.Errorf("FindPDFEngine() error = %v, want ErrNoPDFEngine", err)
+	}
+
+	for _, name := range []string{"pdflatex", "typst"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	engine, err := FindPDFEngine()
+	if err != nil {
+		t.Fatalf("FindPDFEngine() error: %v", err)
+	}
+	if engine != "pdflatex" {
+		t.Errorf("FindPDFEngine() = %q, want the preferred engine pdflatex", engine)
+	}
+}
+
+func TestPDFEngineWriter(t *testing.T) {
+	tests := map[string]string{
+		"xelatex":    "latex",
+		"tectonic":   "latex",
+		"typst":      "typst",
+		"weasyprint": "html",
+		"unknown":    "latex",
+	}
+	for engine, want := range tests {
+		if got := pdfEngineWriter(engine); got != want {
+			t.Errorf("pdfEngineWriter(%q) = %q, want %q", engine, got, want)
+		}
+	}
+}
+
+func TestConvertHTMLToDocument_RejectsTextFormats(t *testing.T) {
+	if _, err := ConvertHTMLToDocument("<p>x</p>", Options{To: FormatOrg}); err == nil {
+		t.Error("Expected error for a text output format")
+	}
+}
+
+func TestConvertHTMLToMarkdownWithOptions_RejectsBinaryFormats(t *testing.T) {
+	if _, err := ConvertHTMLToMarkdownWithOptions("<p>x</p>", Options{To: FormatDOCX}); err == nil {
+		t.Error("Expected error for a binary output format")
+	}
+}
+
+func TestTemplateArgs(t *testing.T) {
+	args, cleanup, err := templateArgs(Options{})
+	cleanup()
+	if err != nil || args != nil {
+		t.Errorf("templateArgs(no template) = %v, %v; want no arguments", args, err)
+	}
+
+	args, cleanup, err = templateArgs(Options{Template: "corp.html"})
+	cleanup()
+	if err != nil || len(args) != 2 || args[0] != "--standalone" || args[1] != "--template=corp.html" {
+		t.Errorf("templateArgs(path) = %v, %v", args, err)
+	}
+
+	args, cleanup, err = templateArgs(Options{TemplateText: "$body$"})
+	if err != nil {
+		t.Fatalf("templateArgs(inline) error: %v", err)
+	}
+	path := strings.TrimPrefix(args[1], "--template=")
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "$body$" {
+		t.Errorf("inline template file = %q, %v; want the template text", data, err)
+	}
+	cleanup()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected cleanup to remove %s", path)
+	}
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// emoticonsJSON is the default emoticon table. It maps the alt text of
+// Confluence emoticon images, such as "(tick)", and the emoji shortcodes
+// of Confluence Cloud, such as ":tada:", to Unicode emoji.
+//
+//go:embed assets/emoticons.json
+var emoticonsJSON []byte
+
+// defaultEmoticons is the parsed default emoticon table.
+var defaultEmoticons = mustParseEmoticons(emoticonsJSON)
+
+// mustParseEmoticons parses the embedded emoticon table.
+func mustParseEmoticons(data []byte) map[string]string {
+	var emoticons map[string]string
+	if err := json.Unmarshal(data, &emoticons); err != nil {
+		panic("converter: invalid embedded emoticon table: " + err.Error())
+	}
+	if err := ValidateEmoticons(emoticons); err != nil {
+		panic("converter: invalid embedded emoticon table: " + err.Error())
+	}
+	return emoticons
+}
+
+// EmoticonFallback selects how emoticon images without a Unicode emoji
+// mapping are converted.
+type EmoticonFallback string
+
+const (
+	// EmoticonShortname replaces the image with a :shortname: code derived
+	// from its alt text or file name (the default).
+	EmoticonShortname EmoticonFallback = "shortname"
+	// EmoticonDrop removes the image.
+	EmoticonDrop EmoticonFallback = "drop"
+	// EmoticonKeep converts the image like any other image, linking to the
+	// emoticon on the Confluence server.
+	EmoticonKeep EmoticonFallback = "keep"
+)
+
+// EmoticonFallbacks lists the supported emoticon fallbacks.
+var EmoticonFallbacks = []EmoticonFallback{EmoticonShortname, EmoticonDrop, EmoticonKeep}
+
+var (
+	// simplifiedImagePattern matches images simplified by preProcessHTML,
+	// capturing the src and alt attributes.
+	simplifiedImagePattern = regexp.MustCompile(`<img src="([^"]*)" alt="([^"]*)"[^>]*>`)
+
+	// emoticonAltPattern matches the alt text of emoticon images: the
+	// classic "(name)" form or a ":name:" emoji code.
+	emoticonAltPattern = regexp.MustCompile(`^(?:\(([^()]+)\)|:([A-Za-z0-9_+-]+):)$`)
+
+	// emojiCodePattern matches an emoji shortcode in text.
+	emojiCodePattern = regexp.MustCompile(`:[A-Za-z0-9_+-]+:`)
+
+	// shortnameUnsafePattern matches runs of characters not allowed in a
+	// :shortname: code.
+	shortnameUnsafePattern = regexp.MustCompile(`[^a-z0-9_+-]+`)
+)
+
+// ValidateEmoticons reports whether an emoticon table has valid keys, in
+// the "(name)" or ":name:" form, and an emoji for every key.
+func ValidateEmoticons(emoticons map[string]string) error {
+	for key, emoji := range emoticons {
+		if !emoticonAltPattern.MatchString(key) {
+			return fmt.Errorf("emoticon table: %q is not an emoticon name like (smile) or :smile:", key)
+		}
+		if strings.TrimSpace(emoji) == "" {
+			return fmt.Errorf("emoticon table: no emoji for %s", key)
+		}
+	}
+	return nil
+}
+
+// emoticonTable returns the default emoticon table with overrides applied.
+func emoticonTable(overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return defaultEmoticons
+	}
+	table := make(map[string]string, len(defaultEmoticons)+len(overrides))
+	for key, emoji := range defaultEmoticons {
+		table[key] = emoji
+	}
+	for key, emoji := range overrides {
+		table[key] = emoji
+	}
+	return table
+}
+
+// replaceEmojiCodes replaces the emoji shortcodes in a table, such as
+// ":tada:", with their emoji.
+func replaceEmojiCodes(s string, emoticons map[string]string) string {
+	if len(emoticons) == 0 {
+		return s
+	}
+	return emojiCodePattern.ReplaceAllStringFunc(s, func(code string) string {
+		if emoji, ok := emoticons[code]; ok {
+			return emoji
+		}
+		return code
+	})
+}
+
+// isEmoticonImage reports whether an image is a Confluence emoticon, by
+// its alt text or by the emoticon and emoji paths Confluence serves them
+// from.
+func isEmoticonImage(src, alt string) bool {
+	return emoticonAltPattern.MatchString(alt) ||
+		strings.Contains(src, "/emoticons/") || strings.Contains(src, "/emojis/")
+}
+
+// emoticonShortname returns the :shortname: code for an emoticon, taken
+// from its alt text or, failing that, its file name. It returns "" when
+// neither gives a name.
+func emoticonShortname(src, alt string) string {
+	name := alt
+	if m := emoticonAltPattern.FindStringSubmatch(alt); m != nil {
+		name = m[1] + m[2]
+	}
+	if strings.TrimSpace(name) == "" {
+		base := path.Base(strings.SplitN(src, "?", 2)[0])
+		name = strings.TrimSuffix(base, path.Ext(base))
+	}
+	name = strings.Trim(shortnameUnsafePattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return ""
+	}
+	return ":" + name + ":"
+}
+
+// applyEmoticons replaces emoticon images with their emoji from
+// emoticons, and rewrites those without one according to fallback, so
+// they never end up as raw <img> tags or links to the Confluence server.
+// It expects images simplified by preProcessHTML.
+func applyEmoticons(html string, emoticons map[string]string, fallback EmoticonFallback) string {
+	return simplifiedImagePattern.ReplaceAllStringFunc(html, func(match string) string {
+		m := simplifiedImagePattern.FindStringSubmatch(match)
+		src, alt := m[1], m[2]
+		if emoji, ok := emoticons[alt]; ok {
+			return emoji
+		}
+		if !isEmoticonImage(src, alt) {
+			return match
+		}
+		switch fallback {
+		case EmoticonKeep:
+			return match
+		case EmoticonDrop:
+			return ""
+		}
+		return emoticonShortname(src, alt)
+	})
+}
@@ -0,0 +1,117 @@
+package converter
+
+import (
+	"testing"
+)
+
+func TestApplyEmoticons(t *testing.T) {
+	const (
+		unknown = `<p><img src="/images/icons/emoticons/grimace.svg" alt="(grimace)"> Hi</p>`
+		known   = `<p><img src="/images/icons/emoticons/check.svg" alt="(tick)"> Done</p>`
+		cloud   = `<p><img src="https://example.atlassian.net/emojis/1f600.png" alt=":grinning face:"></p>`
+		noAlt   = `<p><img src="/images/icons/emoticons/thumbs-up.png?v=2" alt=""></p>`
+		picture = `<p><img src="/download/attachments/1/diagram.png" alt="diagram"></p>`
+	)
+
+	tests := []struct {
+		name     string
+		input    string
+		fallback EmoticonFallback
+		expect   string
+	}{
+		{
+			name:   "shortname by default",
+			input:  unknown,
+			expect: `<p>:grimace: Hi</p>`,
+		},
+		{
+			name:     "shortname from alt text with spaces",
+			input:    cloud,
+			fallback: EmoticonShortname,
+			expect:   `<p>:grinning_face:</p>`,
+		},
+		{
+			name:     "shortname from file name",
+			input:    noAlt,
+			fallback: EmoticonShortname,
+			expect:   `<p>:thumbs-up:</p>`,
+		},
+		{
+			name:     "drop",
+			input:    unknown,
+			fallback: EmoticonDrop,
+			expect:   `<p> Hi</p>`,
+		},
+		{
+			name:     "keep",
+			input:    unknown,
+			fallback: EmoticonKeep,
+			expect:   unknown,
+		},
+		{
+			name:     "mapped emoticons replaced",
+			input:    known,
+			fallback: EmoticonDrop,
+			expect:   `<p>✅ Done</p>`,
+		},
+		{
+			name:     "other images untouched",
+			input:    picture,
+			fallback: EmoticonDrop,
+			expect:   picture,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyEmoticons(tt.input, defaultEmoticons, tt.fallback); got != tt.expect {
+				t.Errorf("applyEmoticons() = %q, want %q", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestEmoticonTable(t *testing.T) {
+	if got := emoticonTable(nil)["(tick)"]; got != "✅" {
+		t.Errorf(`emoticonTable(nil)["(tick)"] = %q, want "✅"`, got)
+	}
+
+	table := emoticonTable(map[string]string{"(tick)": "✔️", ":party:": "🥳"})
+	if got := table["(tick)"]; got != "✔️" {
+		t.Errorf(`table["(tick)"] = %q, want the override`, got)
+	}
+	if got := table[":party:"]; got != "🥳" {
+		t.Errorf(`table[":party:"] = %q, want the added emoji`, got)
+	}
+	if got := defaultEmoticons["(tick)"]; got != "✅" {
+		t.Errorf("overrides changed the default table: (tick) = %q", got)
+	}
+}
+
+func TestValidateEmoticons(t *testing.T) {
+	tests := []struct {
+		name      string
+		emoticons map[string]string
+		wantErr   bool
+	}{
+		{"defaults", defaultEmoticons, false},
+		{"classic and cloud names", map[string]string{"(grimace)": "😬", ":party:": "🥳"}, false},
+		{"bare name", map[string]string{"smile": "🙂"}, true},
+		{"missing emoji", map[string]string{"(smile)": " "}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateEmoticons(tt.emoticons); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEmoticons() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReplaceEmojiCodes(t *testing.T) {
+	got := replaceEmojiCodes("Ship it :tada: at 10:30:00 :unknown:", defaultEmoticons)
+	if want := "Ship it 🎉 at 10:30:00 :unknown:"; got != want {
+		t.Errorf("replaceEmojiCodes() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrEmptyOutput is returned (wrapped) when pandoc reports success but
+// produces nothing, or next to nothing, for a page with text, even when
+// retried with the fallback reader.
+var ErrEmptyOutput = errors.New("pandoc produced empty output")
+
+const (
+	// fallbackExtension is added to the pandoc reader for the retry when
+	// the configured reader returns empty output.
+	fallbackExtension = "+raw_html"
+
+	// emptyCheckMinText is the page text, in bytes, below which short
+	// output is plausible; only blank output is taken for empty then.
+	emptyCheckMinText = 200
+
+	// emptyOutputRatio is how many times more text the page must hold than
+	// the output for the output to count as empty.
+	emptyOutputRatio = 20
+)
+
+// runPandocText is runPandoc for text output with opts.Engine and
+// opts.reader. When the output looks empty next to the text of html, the
+// conversion is retried with opts.fallbackReader, within a time limit of
+// its own, and opts.Warn is told; if the retry is empty too, ErrEmptyOutput
+// is returned instead of a blank document.
+func runPandocText(ctx context.Context, opts Options, html, to string, extraArgs ...string) (string, error) {
+	out, err := runPandocFrom(ctx, opts.Engine, opts.reader(), html, to, extraArgs...)
+	if err != nil || !outputLooksEmpty(out, html) {
+		return out, err
+	}
+
+	retryCtx, cancel := conversionContext(opts)
+	defer cancel()
+	fallback := opts.fallbackReader()
+	retry, err := runPandocFrom(retryCtx, opts.Engine, fallback, html, to, extraArgs...)
+	if err != nil {
+		return "", err
+	}
+	if outputLooksEmpty(retry, html) {
+		return "", fmt.Errorf("%w (%d bytes) for a page with %d bytes of text, also with the %s reader", ErrEmptyOutput, len(strings.TrimSpace(retry)), len(ExtractText(html)), fallback)
+	}
+	opts.warn(fmt.Sprintf("pandoc returned empty output (%d bytes) for a page with %d bytes of text; converted with the %s reader instead", len(strings.TrimSpace(out)), len(ExtractText(html)), fallback))
+	return retry, nil
+}
+
+// outputLooksEmpty reports whether pandoc's output is empty or nearly so
+// compared with the visible text of the HTML it was converted from. Blank
+// output is empty for any page with text; suspiciously short output only
+// for pages of at least emptyCheckMinText bytes of text.
+func outputLooksEmpty(out, html string) bool {
+	text := len(strings.TrimSpace(ExtractText(html)))
+	if text == 0 {
+		return false
+	}
+	visible := len(strings.Join(strings.Fields(out), ""))
+	if visible == 0 {
+		return true
+	}
+	return text >= emptyCheckMinText && visible*emptyOutputRatio < text
+}
@@ -0,0 +1,85 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputLooksEmpty(t *testing.T) {
+	longPage := "<p>" + strings.Repeat("Lorem ipsum dolor sit amet. ", 20) + "</p>"
+	tests := []struct {
+		name string
+		out  string
+		html string
+		want bool
+	}{
+		{"empty output for a long page", "", longPage, true},
+		{"whitespace output for a long page", "\n \n", longPage, true},
+		{"nearly empty output", "Lorem\n", longPage, true},
+		{"full output", strings.Repeat("Lorem ipsum dolor sit amet. ", 20), longPage, false},
+		{"empty output for a short page", "", "<p>Short page</p>", true},
+		{"whitespace output for a short page", "\n\n", "<p>Short page</p>", true},
+		{"short output for a short page", "Short\n", "<p>Short page with a few more words</p>", false},
+		{"empty output for markup only", "", "<div><img src=\"a.png\"></div>", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputLooksEmpty(tt.out, tt.html); got != tt.want {
+				t.Errorf("outputLooksEmpty() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertHTMLToMarkdownWithOptions_EmptyOutput(t *testing.T) {
+	longPage := "<p>" + strings.Repeat("Lorem ipsum dolor sit amet. ", 20) + "</p>"
+
+	// Empty output from the default reader only: the retry is used
+	runs := installFakePandoc(t, "case \"$2\" in html) cat >/dev/null;; *) sed -e 's#</*p>##g';; esac\n")
+	var warnings []string
+	opts := Options{Engine: EngineSystem, Warn: func(msg string) { warnings = append(warnings, msg) }}
+	md, err := ConvertHTMLToMarkdownWithOptions(longPage, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(md, "Lorem ipsum") {
+		t.Errorf("expected the retried conversion, got %q", md)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], Options{}.fallbackReader()) {
+		t.Errorf("warnings = %q, want one naming %s", warnings, Options{}.fallbackReader())
+	}
+	if n := runs(); n != 2 {
+		t.Errorf("ran pandoc %d times, want 2", n)
+	}
+
+	// Empty output from both readers
+	installFakePandoc(t, "cat >/dev/null\n")
+	if _, err := ConvertHTMLToMarkdownWithOptions(longPage, Options{Engine: EngineSystem}); !errors.Is(err, ErrEmptyOutput) {
+		t.Errorf("error = %v, want ErrEmptyOutput", err)
+	}
+
+	// The retry has a time limit of its own
+	installFakePandoc(t, "sleep 0.6\ncase \"$2\" in html) cat >/dev/null;; *) sed -e 's#</*p>##g';; esac\n")
+	if _, err := ConvertHTMLToMarkdownWithOptions(longPage, Options{Engine: EngineSystem, Timeout: time.Second}); err != nil {
+		t.Errorf("Unexpected error for a retry within its own time limit: %v", err)
+	}
+
+	// Short pages are retried only for blank output
+	runs = installFakePandoc(t, "cat >/dev/null\n")
+	if _, err := ConvertHTMLToMarkdownWithOptions("<p>Short</p>", Options{Engine: EngineSystem}); !errors.Is(err, ErrEmptyOutput) {
+		t.Errorf("error for a blank short page = %v, want ErrEmptyOutput", err)
+	}
+	if n := runs(); n != 2 {
+		t.Errorf("ran pandoc %d times for a blank short page, want 2", n)
+	}
+	runs = installFakePandoc(t, "cat >/dev/null\nprintf 'Short\\n'\n")
+	if _, err := ConvertHTMLToMarkdownWithOptions("<p>Short page with a few more words</p>", Options{Engine: EngineSystem}); err != nil {
+		t.Errorf("Unexpected error for a short page: %v", err)
+	}
+	if n := runs(); n != 1 {
+		t.Errorf("ran pandoc %d times for a short page, want 1", n)
+	}
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// doubleEncodingMinRatio is the minimum share of entity-encoded tags, among
+// encoded and real content tags outside code, for a document to be treated
+// as double-encoded. Pages that merely discuss HTML in prose contain far
+// more real tags than encoded ones.
+const doubleEncodingMinRatio = 0.5
+
+var (
+	// codeRegionPattern matches <pre> and <code> elements, whose entities are
+	// literal code and must never be decoded.
+	codeRegionPattern = regexp.MustCompile(`(?is)<pre\b.*?</pre>|<code\b.*?</code>`)
+
+	// encodedTagPattern matches an entity-encoded tag of a common HTML
+	// element, e.g. &lt;p&gt;, &lt;/td&gt;, or &#60;div class=&quot;x&quot;&#62;.
+	//
+	// Pattern breakdown:
+	// (?:&lt;|&#0*60;|&#x0*3c;)  - Encoded "<" in named, decimal, or hex form
+	// (/?)                       - Capture "/" for closing tags
+	// (p|div|...)\b              - Element name
+	// (?:[^&<>]|&[#\w]+;)*?      - Attributes, which may contain entities
+	// (?:&gt;|&#0*62;|&#x0*3e;)  - Encoded ">"
+	encodedTagPattern = regexp.MustCompile(`(?i)(?:&lt;|&#0*60;|&#x0*3c;)(/?)(p|div|span|a|b|i|em|strong|u|br|hr|h[1-6]|ul|ol|li|table|thead|tbody|tr|th|td|pre|code|img|blockquote)\b(?:[^&<>]|&[#\w]+;)*?(?:&gt;|&#0*62;|&#x0*3e;)`)
+
+	// realTagPattern matches a real HTML tag and captures its element name.
+	realTagPattern = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)\b[^>]*>`)
+)
+
+// contentTags are the real elements counted against encoded tags when
+// deciding whether a document is double-encoded. Wrapper elements such as
+// html, body, and div are left out: Confluence emits them even around
+// double-encoded content.
+var contentTags = map[string]bool{
+	"p": true, "li": true, "ul": true, "ol": true, "br": true, "blockquote": true,
+	"table": true, "tr": true, "th": true, "td": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// htmlSegment is a run of HTML that is either a matched region, such as a
+// code region, or content between regions.
+type htmlSegment struct {
+	text   string
+	region bool
+}
+
+// splitCodeRegions splits HTML into code regions (<pre> and <code>
+// elements) and the content between them.
+func splitCodeRegions(html string) []htmlSegment {
+	return splitRegions(html, codeRegionPattern)
+}
+
+// splitRegions splits HTML into the regions matching pattern and the
+// content between them.
+func splitRegions(html string, pattern *regexp.Regexp) []htmlSegment {
+	var segments []htmlSegment
+	last := 0
+	for _, loc := range pattern.FindAllStringIndex(html, -1) {
+		if loc[0] > last {
+			segments = append(segments, htmlSegment{text: html[last:loc[0]]})
+		}
+		segments = append(segments, htmlSegment{text: html[loc[0]:loc[1]], region: true})
+		last = loc[1]
+	}
+	if last < len(html) {
+		segments = append(segments, htmlSegment{text: html[last:]})
+	}
+	return segments
+}
+
+// isDoubleEncoded reports whether the content outside code regions is
+// double-encoded HTML: it must contain encoded closing tags, and encoded
+// tags must make up a large enough share of the document's content tags.
+func isDoubleEncoded(segments []htmlSegment) bool {
+	encoded, closing, real := 0, 0, 0
+	for _, seg := range segments {
+		if seg.region {
+			continue
+		}
+		for _, m := range encodedTagPattern.FindAllStringSubmatch(seg.text, -1) {
+			encoded++
+			if m[1] == "/" {
+				closing++
+			}
+		}
+		for _, m := range realTagPattern.FindAllStringSubmatch(seg.text, -1) {
+			if contentTags[strings.ToLower(m[2])] {
+				real++
+			}
+		}
+	}
+	if closing == 0 {
+		return false
+	}
+	return float64(encoded)/float64(encoded+real) >= doubleEncodingMinRatio
+}
+
+// fixDoubleEncoding decodes double-encoded HTML (&lt;p&gt; instead of <p>),
+// which Confluence sometimes exports. Detection is structural, so pages that
+// show HTML in code samples or mention a tag in prose are left alone, and
+// entities inside <pre> and <code> elements are never rewritten.
+func fixDoubleEncoding(html string) string {
+	if !strings.Contains(html, "&lt;") && !strings.Contains(html, "&#") {
+		return html
+	}
+
+	segments := splitCodeRegions(html)
+	if !isDoubleEncoded(segments) {
+		return html
+	}
+
+	var b strings.Builder
+	for _, seg := range segments {
+		if seg.region {
+			b.WriteString(seg.text)
+			continue
+		}
+		b.WriteString(decodeHTMLEntities(seg.text))
+	}
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package converter
+
+import (
+	"testing"
+)
+
+func TestFixDoubleEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			name:   "fully double-encoded",
+			input:  `&lt;p&gt;Hello &amp; welcome&lt;/p&gt;&lt;ul&gt;&lt;li&gt;Item&lt;/li&gt;&lt;/ul&gt;`,
+			expect: `<p>Hello & welcome</p><ul><li>Item</li></ul>`,
+		},
+		{
+			name:   "double-encoded inside real wrapper",
+			input:  `<html><body><div id="main-content">&lt;p&gt;Body&lt;/p&gt;</div></body></html>`,
+			expect: `<html><body><div id="main-content"><p>Body</p></div></body></html>`,
+		},
+		{
+			name:   "encoded attributes",
+			input:  `&lt;div class=&quot;note&quot;&gt;Text&lt;/div&gt;`,
+			expect: `<div class="note">Text</div>`,
+		},
+		{
+			name:   "numeric encoded tags",
+			input:  `&#60;p&#62;Text&#x3C;/p&#x3E;`,
+			expect: `<p>Text</p>`,
+		},
+		{
+			name:   "code sample left alone",
+			input:  `<p>Example:</p><pre>&lt;p&gt;Hello&lt;/p&gt;</pre>`,
+			expect: `<p>Example:</p><pre>&lt;p&gt;Hello&lt;/p&gt;</pre>`,
+		},
+		{
+			name:   "inline code left alone",
+			input:  `<p>Wrap text in <code>&lt;p&gt;...&lt;/p&gt;</code> tags.</p>`,
+			expect: `<p>Wrap text in <code>&lt;p&gt;...&lt;/p&gt;</code> tags.</p>`,
+		},
+		{
+			name:   "tag mentioned in prose",
+			input:  `<p>Use the &lt;div&gt; element.</p><p>Close it with &lt;/div&gt;.</p><ul><li>One</li><li>Two</li></ul>`,
+			expect: `<p>Use the &lt;div&gt; element.</p><p>Close it with &lt;/div&gt;.</p><ul><li>One</li><li>Two</li></ul>`,
+		},
+		{
+			name:   "comparison operators",
+			input:  `<p>Price &lt; $100 &amp;&amp; qty &gt; 5</p>`,
+			expect: `<p>Price &lt; $100 &amp;&amp; qty &gt; 5</p>`,
+		},
+		{
+			name:   "code kept while surrounding content is decoded",
+			input:  `&lt;p&gt;Intro&lt;/p&gt;<pre>if a &lt; b {}</pre>&lt;p&gt;Outro&lt;/p&gt;`,
+			expect: `<p>Intro</p><pre>if a &lt; b {}</pre><p>Outro</p>`,
+		},
+		{
+			name:   "no entities",
+			input:  `<p>Plain</p>`,
+			expect: `<p>Plain</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fixDoubleEncoding(tt.input); got != tt.expect {
+				t.Errorf("fixDoubleEncoding() = %q, want %q", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestSplitCodeRegions(t *testing.T) {
+	segments := splitCodeRegions(`a<pre>b</pre>c<code>d</code>`)
+	want := []htmlSegment{
+		{text: "a"},
+		{text: "<pre>b</pre>", region: true},
+		{text: "c"},
+		{text: "<code>d</code>", region: true},
+	}
+	if len(segments) != len(want) {
+		t.Fatalf("splitCodeRegions() = %+v, want %+v", segments, want)
+	}
+	for i := range want {
+		if segments[i] != want[i] {
+			t.Errorf("segment %d = %+v, want %+v", i, segments[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/aqueeb/confluence2md/internal/pandoc"
+)
+
+// Engine selects the pandoc that performs conversions.
+type Engine string
+
+const (
+	// EngineAuto tries the engines in engineChain order and uses the first
+	// one available (the default).
+	EngineAuto Engine = "auto"
+	// EngineEmbedded uses the pandoc binary embedded in this build.
+	EngineEmbedded Engine = "embedded"
+	// EngineSystem uses the pandoc found in PATH.
+	EngineSystem Engine = "system"
+)
+
+// Engines lists the supported engine choices.
+var Engines = []Engine{EngineAuto, EngineEmbedded, EngineSystem}
+
+// engineChain is the order in which EngineAuto tries the engines.
+var engineChain = []Engine{EngineEmbedded, EngineSystem}
+
+// ResolveEngine returns the engine that conversions with e use, checking
+// that it is available. EngineAuto resolves to the first available engine
+// in the fallback chain: the embedded pandoc, then the system pandoc.
+func ResolveEngine(e Engine) (Engine, error) {
+	if e != "" && e != EngineAuto {
+		if err := e.check(); err != nil {
+			return "", err
+		}
+		return e, nil
+	}
+
+	var errs []error
+	for _, engine := range engineChain {
+		// Builds without an embedded binary skip it silently
+		if engine == EngineEmbedded && !pandoc.IsEmbedded() {
+			continue
+		}
+		err := engine.check()
+		if err == nil {
+			return engine, nil
+		}
+		errs = append(errs, err)
+	}
+	return "", errors.Join(errs...)
+}
+
+// pandocPath resolves e and returns the path of its pandoc binary.
+func pandocPath(e Engine) (string, error) {
+	engine, err := ResolveEngine(e)
+	if err != nil {
+		return "", err
+	}
+	if engine == EngineEmbedded {
+		return pandoc.EnsureExtracted()
+	}
+	return exec.LookPath("pandoc")
+}
+
+// check returns an error if the engine cannot be used.
+func (e Engine) check() error {
+	switch e {
+	case EngineEmbedded:
+		if !pandoc.IsEmbedded() {
+			return fmt.Errorf("this build has no embedded pandoc")
+		}
+		if _, err := pandoc.EnsureExtracted(); err != nil {
+			return fmt.Errorf("failed to extract embedded pandoc: %w", err)
+		}
+		return nil
+	case EngineSystem:
+		if _, err := exec.LookPath("pandoc"); err != nil {
+			return fmt.Errorf("pandoc not found in PATH. Please install pandoc: https://pandoc.org/installing.html")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown engine %q", e)
+}
+
+// Describe returns a human-readable name for a resolved engine, including
+// the path of the system pandoc, for logging which engine converted a file.
+func (e Engine) Describe() string {
+	switch e {
+	case EngineEmbedded:
+		return "embedded pandoc"
+	case EngineSystem:
+		if path, err := exec.LookPath("pandoc"); err == nil {
+			return fmt.Sprintf("system pandoc (%s)", path)
+		}
+		return "system pandoc"
+	}
+	return string(e)
+}
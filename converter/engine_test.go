@@ -0,0 +1,108 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/internal/pandoc"
+)
+
+// stubPandoc is a stand-in system pandoc that prints its version.
+const stubPandoc = "#!/bin/sh\necho pandoc 3.1\n"
+
+// fakeSystemPandoc puts a pandoc shell script with the given contents first
+// in PATH, or empties PATH so that no pandoc is found when script is empty.
+func fakeSystemPandoc(t *testing.T, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	if script == "" {
+		t.Setenv("PATH", dir)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pandoc"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestResolveEngine(t *testing.T) {
+	if pandoc.IsEmbedded() {
+		t.Skip("fallback behavior is tested with builds that embed no pandoc")
+	}
+
+	tests := []struct {
+		name    string
+		engine  Engine
+		system  string
+		want    Engine
+		wantErr string
+	}{
+		{"auto falls back to system", EngineAuto, stubPandoc, EngineSystem, ""},
+		{"empty means auto", "", stubPandoc, EngineSystem, ""},
+		{"pinned system", EngineSystem, stubPandoc, EngineSystem, ""},
+		{"auto without any pandoc", EngineAuto, "", "", "pandoc not found in PATH"},
+		{"pinned system missing", EngineSystem, "", "", "pandoc not found in PATH"},
+		{"pinned embedded missing", EngineEmbedded, stubPandoc, "", "no embedded pandoc"},
+		{"unknown engine", Engine("native"), stubPandoc, "", `unknown engine "native"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeSystemPandoc(t, tt.system)
+			got, err := ResolveEngine(tt.engine)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ResolveEngine(%q) error = %v, want %q", tt.engine, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ResolveEngine(%q) = %q, %v; want %q", tt.engine, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineDescribe(t *testing.T) {
+	fakeSystemPandoc(t, stubPandoc)
+	if got := EngineEmbedded.Describe(); got != "embedded pandoc" {
+		t.Errorf("EngineEmbedded.Describe() = %q", got)
+	}
+	if got := EngineSystem.Describe(); !strings.HasPrefix(got, "system pandoc (") {
+		t.Errorf("EngineSystem.Describe() = %q, want the pandoc path", got)
+	}
+}
+
+func TestRunPandoc_SystemStreams(t *testing.T) {
+	// The stub echoes stdin to stdout, or to the -o file if given
+	fakeSystemPandoc(t, "#!/bin/sh\nout=/dev/stdout\nwhile [ $# -gt 0 ]; do [ \"$1\" = -o ] && out=$2; shift; done\ncat > \"$out\"\n")
+	ctx := context.Background()
+	html := "<p>streamed</p>"
+
+	got, err := runPandoc(ctx, EngineSystem, html, "gfm")
+	if err != nil || got != html {
+		t.Errorf("runPandoc() = %q, %v; want the stdin echoed", got, err)
+	}
+
+	doc, err := runPandocToFile(ctx, EngineSystem, "html", html, "docx", ".docx")
+	if err != nil || string(doc) != html {
+		t.Errorf("runPandocToFile() = %q, %v; want the stdin written to -o", doc, err)
+	}
+}
+
+func TestRunPandoc_SystemError(t *testing.T) {
+	fakeSystemPandoc(t, "#!/bin/sh\necho 'Error at \"source\" (line 1, column 4):' >&2\necho 'unexpected' >&2\nexit 64\n")
+
+	_, err := runPandoc(context.Background(), EngineSystem, "<p>x</p>", "gfm")
+	var pe *pandoc.PandocError
+	if !errors.As(err, &pe) {
+		t.Fatalf("runPandoc() error = %v, want a *pandoc.PandocError", err)
+	}
+	if pe.ExitCode != 64 || pe.Line != 1 || pe.Column != 4 || pe.Message != "unexpected" {
+		t.Errorf("PandocError = %+v", pe)
+	}
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// expanderPattern matches the opening tag of Confluence expand macros,
+	// capturing the expander ID.
+	expanderPattern = regexp.MustCompile(`<div id="expander-(\d+)"[^>]*>`)
+
+	// expandControlTextPattern captures the title text of an expander
+	// control, which also holds an icon.
+	expandControlTextPattern = regexp.MustCompile(`(?s)<span class="expand-control-text">(.*?)</span>`)
+
+	// expanderContentPattern matches the opening tag of an expand macro's body.
+	expanderContentPattern = regexp.MustCompile(`<div id="expander-content-\d+"[^>]*>`)
+)
+
+// splitExpander separates the title of an expand macro with the given ID
+// from its inner HTML, returning the plain-text title and the remaining
+// content.
+func splitExpander(inner, id string) (title, content string) {
+	controlPattern := regexp.MustCompile(`<div id="expander-control-` + id + `"[^>]*>`)
+	loc := controlPattern.FindStringIndex(inner)
+	if loc == nil {
+		return "", inner
+	}
+	end := findElementEnd(inner, loc[0], "div")
+	if end == -1 {
+		return "", inner
+	}
+	control := elementInner(inner, loc[0], end, "div")
+	if text := expandControlTextPattern.FindStringSubmatch(control); text != nil {
+		control = text[1]
+	}
+	title = strings.TrimSpace(tagPattern.ReplaceAllString(control, ""))
+	return title, inner[:loc[0]] + inner[end:]
+}
+
+// expandDetails renders expand macros inline: the title becomes a bold
+// paragraph followed by the content, instead of a collapsible
+// <details> element.
+func expandDetails(html string) string {
+	return replaceElements(html, expanderPattern, "div", func(inner string, m []string) string {
+		title, content := splitExpander(inner, m[1])
+		// Unwrap the body so Markdown post-processing does not treat it
+		// as the end of a summary
+		content = replaceElements(content, expanderContentPattern, "div", func(body string, _ []string) string {
+			return body
+		})
+		if title == "" {
+			return content
+		}
+		return "<p><strong>" + title + "</strong></p>" + content
+	})
+}
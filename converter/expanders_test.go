@@ -0,0 +1,61 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandDetails(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "title and content",
+			input: `<div id="expander-1" class="expand-container"><div id="expander-control-1" class="expand-control"><span class="expand-control-icon">+</span><span class="expand-control-text">Show more</span></div><div id="expander-content-1" class="expand-content"><p>Hidden</p></div></div>`,
+			want:  `<p><strong>Show more</strong></p><p>Hidden</p>`,
+		},
+		{
+			name:  "no title",
+			input: `<div id="expander-2"><div id="expander-content-2"><p>Body</p></div></div>`,
+			want:  `<p>Body</p>`,
+		},
+		{
+			name:  "nested expanders",
+			input: `<div id="expander-1"><div id="expander-control-1">Outer</div><div id="expander-content-1"><div id="expander-2"><div id="expander-control-2">Inner</div><div id="expander-content-2"><p>Deep</p></div></div></div></div>`,
+			want:  `<p><strong>Outer</strong></p><p><strong>Inner</strong></p><p>Deep</p>`,
+		},
+		{
+			name:  "no expanders",
+			input: `<p>Plain</p>`,
+			want:  `<p>Plain</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandDetails(tt.input); got != tt.want {
+				t.Errorf("expandDetails() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertHTMLToMarkdown_ExpandDetails(t *testing.T) {
+	if err := CheckPandoc(); err != nil {
+		t.Skipf("Pandoc not installed, skipping test: %v", err)
+	}
+
+	input := `<div id="expander-1"><div id="expander-control-1"><span class="expand-control-text">Details</span></div><div id="expander-content-1"><p>Hidden content</p></div></div>`
+	md, err := ConvertHTMLToMarkdownWithOptions(input, Options{ExpandDetails: true})
+	if err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+	if strings.Contains(md, "<details>") || strings.Contains(md, "<summary>") {
+		t.Errorf("expected no details/summary HTML, got: %s", md)
+	}
+	if !strings.Contains(md, "**Details**") || !strings.Contains(md, "Hidden content") {
+		t.Errorf("expected bold title and inline content, got: %s", md)
+	}
+}
@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Flavor selects the Markdown dialect the output is tailored for.
+type Flavor string
+
+const (
+	// FlavorGFM targets GitHub-flavored Markdown (the default).
+	FlavorGFM Flavor = "gfm"
+	// FlavorGitLab targets GitLab-flavored Markdown: GitLab anchor rules,
+	// >>> multiline blockquotes, mermaid fences, and [[_TOC_]].
+	FlavorGitLab Flavor = "gitlab"
+	// FlavorCommonMark targets strict CommonMark, written by pandoc's
+	// commonmark writer: tables and other GFM extensions become raw HTML.
+	FlavorCommonMark Flavor = "commonmark"
+	// FlavorObsidian targets Obsidian vaults: admonitions become callouts
+	// ("> [!tip]") and links to other Confluence pages [[wikilinks]].
+	FlavorObsidian Flavor = "obsidian"
+	// FlavorMkDocs targets MkDocs and Material for MkDocs (Python-Markdown):
+	// admonitions become "!!! tip" blocks and nested lists are indented by
+	// four spaces.
+	FlavorMkDocs Flavor = "mkdocs"
+)
+
+// Flavors lists the supported output flavors.
+var Flavors = []Flavor{FlavorGFM, FlavorGitLab, FlavorCommonMark, FlavorObsidian, FlavorMkDocs}
+
+var (
+	// repeatedHyphenPattern matches runs of hyphens, which GitLab and MkDocs
+	// collapse in anchors.
+	repeatedHyphenPattern = regexp.MustCompile(`-{2,}`)
+
+	// pageLinkTitlePattern captures the title part of Confluence page URLs
+	// (/display/KEY/Title, /spaces/KEY/pages/ID/Title).
+	pageLinkTitlePattern = regexp.MustCompile(`/(?:display/[A-Za-z0-9_~-]+|spaces/[A-Za-z0-9_~-]+/pages/\d+)/([^/?#]+)(?:[?#].*)?$`)
+
+	// mermaidStartPattern matches the first line of a mermaid diagram definition.
+	mermaidStartPattern = regexp.MustCompile(`^\s*(?:graph|flowchart|sequenceDiagram|classDiagram|stateDiagram(?:-v2)?|erDiagram|gantt|pie|journey|gitGraph|mindmap|timeline|quadrantChart)\b`)
+)
+
+// pandocWriter returns the pandoc writer for Markdown output in the flavor.
+func (f Flavor) pandocWriter() string {
+	if f == FlavorCommonMark {
+		return "commonmark"
+	}
+	return FormatMarkdown.pandocWriter()
+}
+
+// headingSlugFunc returns the anchor generation rule for the flavor.
+func (f Flavor) headingSlugFunc() func(string) string {
+	if f == FlavorGitLab || f == FlavorMkDocs {
+		return gitlabHeadingSlug
+	}
+	return headingSlug
+}
+
+// duplicateSlugSeparator returns what separates the numeric suffix of a
+// duplicate heading's anchor from the anchor: "usage-1" for GitHub and
+// GitLab, "usage_1" for the MkDocs toc extension.
+func (f Flavor) duplicateSlugSeparator() string {
+	if f == FlavorMkDocs {
+		return "_"
+	}
+	return "-"
+}
+
+// gitlabHeadingSlug converts heading text to the anchor GitLab (and the
+// MkDocs toc extension) generates for it. It follows the GitHub rules, but
+// collapses repeated hyphens.
+func gitlabHeadingSlug(text string) string {
+	return repeatedHyphenPattern.ReplaceAllString(headingSlug(text), "-")
+}
+
+// applyGitLabFlavor rewrites GFM output into GitLab-specific syntax:
+// multi-line blockquotes use the >>> fence and mermaid diagrams in untagged
+// code fences are tagged as mermaid.
+func applyGitLabFlavor(md string) string {
+	lines := strings.Split(md, "\n")
+	lines = tagMermaidFences(lines)
+	lines = fenceMultilineBlockquotes(lines)
+	return strings.Join(lines, "\n")
+}
+
+// tagMermaidFences adds the mermaid language to untagged code fences whose
+// content starts with a mermaid diagram declaration.
+func tagMermaidFences(lines []string) []string {
+	for i := 0; i < len(lines); i++ {
+		marker := strings.TrimSpace(lines[i])
+		if marker != "```" && marker != "~~~" {
+			if fencePattern.MatchString(lines[i]) {
+				// Tagged fence: skip to its closing line
+				for i++; i < len(lines) && !fencePattern.MatchString(lines[i]); i++ {
+				}
+			}
+			continue
+		}
+
+		start := i
+		for i++; i < len(lines) && !fencePattern.MatchString(lines[i]); i++ {
+		}
+		if start+1 < len(lines) && mermaidStartPattern.MatchString(lines[start+1]) {
+			lines[start] = strings.Replace(lines[start], marker, marker+"mermaid", 1)
+		}
+	}
+	return lines
+}
+
+// fenceMultilineBlockquotes converts top-level blockquotes spanning several
+// lines into GitLab's >>> multiline blockquote syntax. Nested blockquotes are
+// left unchanged.
+func fenceMultilineBlockquotes(lines []string) []string {
+	var out []string
+	for i := 0; i < len(lines); {
+		if !strings.HasPrefix(lines[i], ">") {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+
+		end := i
+		nested := false
+		for end < len(lines) && strings.HasPrefix(lines[end], ">") {
+			if strings.HasPrefix(strings.TrimPrefix(strings.TrimPrefix(lines[end], ">"), " "), ">") {
+				nested = true
+			}
+			end++
+		}
+
+		if end-i < 2 || nested {
+			out = append(out, lines[i:end]...)
+		} else {
+			out = append(out, ">>>")
+			for _, line := range lines[i:end] {
+				line = strings.TrimPrefix(line, ">")
+				out = append(out, strings.TrimPrefix(line, " "))
+			}
+			out = append(out, ">>>")
+		}
+		i = end
+	}
+	return out
+}
+
+// obsidianWikilinks turns links to Confluence pages into Obsidian
+// [[wikilinks]] to the converted page, named like converted exports: the
+// page title with spaces as "-" (Release+Notes.doc becomes Release-Notes.md).
+// Section anchors are dropped; Obsidian links to headings by their text.
+func obsidianWikilinks(md string) string {
+	return protectCode(md, func(md string) string {
+		return markdownLinkTargetPattern.ReplaceAllStringFunc(md, func(link string) string {
+			m := markdownLinkTargetPattern.FindStringSubmatch(link)
+			if m[1] != "" {
+				return link
+			}
+			t := pageLinkTitlePattern.FindStringSubmatch(m[3])
+			if t == nil {
+				return link
+			}
+			title, err := url.QueryUnescape(t[1])
+			if err != nil || strings.ContainsAny(title, "[]|#^") {
+				return link
+			}
+			name := strings.ReplaceAll(strings.TrimSpace(title), " ", "-")
+			if m[2] == "" || m[2] == name {
+				return "[[" + name + "]]"
+			}
+			return "[[" + name + "|" + m[2] + "]]"
+		})
+	})
+}
@@ -0,0 +1,131 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGitLabHeadingSlug(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Getting Started", "getting-started"},
+		{"Build - Test - Deploy", "build-test-deploy"},
+		{"1.2 What's New?", "12-whats-new"},
+	}
+
+	for _, tt := range tests {
+		if got := gitlabHeadingSlug(tt.input); got != tt.expected {
+			t.Errorf("gitlabHeadingSlug(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestApplyGitLabFlavor_Blockquotes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "multi-line blockquote fenced",
+			input:    "Intro\n\n> **Note:** first line\n>\n> second line\n\nAfter\n",
+			expected: "Intro\n\n>>>\n**Note:** first line\n\nsecond line\n>>>\n\nAfter\n",
+		},
+		{
+			name:     "single-line blockquote unchanged",
+			input:    "> **Tip:** short\n",
+			expected: "> **Tip:** short\n",
+		},
+		{
+			name:     "nested blockquote unchanged",
+			input:    "> outer\n> > inner\n",
+			expected: "> outer\n> > inner\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyGitLabFlavor(tt.input); got != tt.expected {
+				t.Errorf("applyGitLabFlavor() =\n%q\nwant\n%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyGitLabFlavor_Mermaid(t *testing.T) {
+	input := "```\ngraph TD\n  A --> B\n```\n\n```\nplain code\n```\n\n```yaml\npie: true\n```\n"
+	got := applyGitLabFlavor(input)
+
+	if !strings.Contains(got, "```mermaid\ngraph TD") {
+		t.Errorf("Expected mermaid fence, got: %s", got)
+	}
+	if !strings.Contains(got, "```\nplain code") {
+		t.Errorf("Expected plain fence untouched, got: %s", got)
+	}
+	if !strings.Contains(got, "```yaml\npie: true") {
+		t.Errorf("Expected tagged fence untouched, got: %s", got)
+	}
+}
+
+func TestInsertTOC_GitLab(t *testing.T) {
+	got := insertTOC("# Intro\n", 3, FlavorGitLab, defaultListIndent)
+
+	if got != "[[_TOC_]]\n\n# Intro\n" {
+		t.Errorf("Expected GitLab TOC tag, got: %q", got)
+	}
+}
+
+func TestNumberHeadings_GitLabAnchors(t *testing.T) {
+	got := numberHeadings("[Go](#build-deploy)\n\n# Build - Deploy\n", FlavorGitLab)
+
+	if !strings.Contains(got, "[Go](#1-build-deploy)") {
+		t.Errorf("Expected GitLab anchor rewritten, got: %s", got)
+	}
+}
+
+func TestObsidianWikilinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"display link", "See [Release Notes](/display/ENG/Release+Notes).", "See [[Release-Notes|Release Notes]]."},
+		{"same text as the file name", "[Setup](https://wiki.example.com/display/ENG/Setup#Setup-Linux)", "[[Setup]]"},
+		{"Cloud page link", "[notes](/wiki/spaces/ENG/pages/123/Release+Notes)", "[[Release-Notes|notes]]"},
+		{"external link", "[Go](https://go.dev/doc)", "[Go](https://go.dev/doc)"},
+		{"image", "![Diagram](/display/ENG/Diagram)", "![Diagram](/display/ENG/Diagram)"},
+		{"title Obsidian cannot link", "[A](/display/ENG/A%7CB)", "[A](/display/ENG/A%7CB)"},
+		{"code", "`[A](/display/ENG/A)`", "`[A](/display/ENG/A)`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := obsidianWikilinks(tt.input); got != tt.want {
+				t.Errorf("obsidianWikilinks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlavorWriter(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, "gfm"},
+		{Options{Flavor: FlavorObsidian}, "gfm"},
+		{Options{Flavor: FlavorCommonMark}, "commonmark"},
+		{Options{Flavor: FlavorCommonMark, KeepAttributes: []string{".x"}}, "commonmark+attributes"},
+		{Options{Flavor: FlavorCommonMark, To: FormatOrg}, "org"},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.writer(); got != tt.want {
+			t.Errorf("writer() with flavor %q and format %q = %q, want %q", tt.opts.Flavor, tt.opts.To, got, tt.want)
+		}
+	}
+	if got := FlavorMkDocs.listIndentStep(); got != 4 {
+		t.Errorf("MkDocs list indentation = %d, want 4", got)
+	}
+}
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// footnoteRefPattern matches a superscript link to a footnote, as produced
+	// by the Confluence footnote macros: <sup><a href="#fn1">1</a></sup>.
+	//
+	// Pattern breakdown:
+	// <sup[^>]*>\s*           - Opening superscript tag
+	// <a[^>]*href="#([^"]+)"  - Capture the in-page anchor the link points at
+	// [^>]*>\s*\[?([\w-]+)\]? - Capture the footnote label, optionally bracketed
+	// \s*</a>\s*</sup>        - Closing link and superscript tags
+	footnoteRefPattern = regexp.MustCompile(`<sup[^>]*>\s*<a[^>]*href="#([^"]+)"[^>]*>\s*\[?([\w-]+)\]?\s*</a>\s*</sup>`)
+
+	// footnoteBackrefPattern matches "return to text" links inside footnote bodies.
+	footnoteBackrefPattern = regexp.MustCompile(`\s*<a[^>]*href="#[^"]*"[^>]*>\s*(?:↩|&#8617;|&#x21a9;|\^|back)\s*</a>`)
+
+	// escapedFootnotePattern matches footnote markers escaped by pandoc (\[^1\]).
+	escapedFootnotePattern = regexp.MustCompile(`\\\[\\?\^([\w-]+)\\\]`)
+
+	// emptyListPattern matches ordered/unordered lists left empty once their
+	// footnote items have been moved.
+	emptyListPattern = regexp.MustCompile(`<(ol|ul)[^>]*>\s*</(?:ol|ul)>`)
+
+	// paragraphTagPattern matches opening and closing paragraph tags.
+	paragraphTagPattern = regexp.MustCompile(`</?p[^>]*>`)
+)
+
+// writesFootnotes reports whether the flavor has footnotes ("[^1]"), an
+// extension that CommonMark lacks; there pandoc's own rendering of the
+// superscript links and notes is kept.
+func (f Flavor) writesFootnotes() bool {
+	return f != FlavorCommonMark
+}
+
+// convertFootnotes rewrites footnote references and their notes section into
+// Markdown footnote markers ([^1]) and definitions ([^1]: text). References are
+// only converted when a matching note can be found, so ordinary superscript
+// links are left alone. The definitions are moved to the end of the document.
+func convertFootnotes(html string) string {
+	refs := footnoteRefPattern.FindAllStringSubmatch(html, -1)
+	if len(refs) == 0 {
+		return html
+	}
+
+	labels := make(map[string]string)
+	var order []string
+	var definitions []string
+	for _, ref := range refs {
+		anchor, label := ref[1], ref[2]
+		if _, seen := labels[anchor]; seen {
+			continue
+		}
+
+		body, remaining, ok := extractFootnoteBody(html, anchor)
+		if !ok {
+			continue
+		}
+		html = remaining
+		labels[anchor] = label
+		order = append(order, anchor)
+		definitions = append(definitions, fmt.Sprintf("<p>[^%s]: %s</p>", label, body))
+	}
+	if len(order) == 0 {
+		return html
+	}
+
+	html = footnoteRefPattern.ReplaceAllStringFunc(html, func(match string) string {
+		anchor := footnoteRefPattern.FindStringSubmatch(match)[1]
+		if label, ok := labels[anchor]; ok {
+			return "[^" + label + "]"
+		}
+		return match
+	})
+	html = emptyListPattern.ReplaceAllString(html, "")
+
+	notes := strings.Join(definitions, "\n")
+	if idx := strings.LastIndex(strings.ToLower(html), "</body>"); idx != -1 {
+		return html[:idx] + notes + "\n" + html[idx:]
+	}
+	return html + "\n" + notes
+}
+
+// extractFootnoteBody finds the note with the given anchor, removes it from
+// the document, and returns its inner HTML. Notes are either list items
+// carrying the id (<li id="fn1">) or paragraphs starting with a named anchor
+// (<p><a name="fn1"></a>1. Text</p>).
+func extractFootnoteBody(html, anchor string) (string, string, bool) {
+	id := regexp.QuoteMeta(anchor)
+	patterns := []*regexp.Regexp{
+		regexp.MustCompile(`<li[^>]*\sid="` + id + `"[^>]*>([\s\S]*?)</li>`),
+		regexp.MustCompile(`<p[^>]*>\s*(?:<sup>)?<a[^>]*\s(?:id|name)="` + id + `"[^>]*>(?:[^<]*)</a>(?:</sup>)?\s*(?:\d+[.)]?\s*)?([\s\S]*?)</p>`),
+	}
+
+	for _, pattern := range patterns {
+		loc := pattern.FindStringSubmatchIndex(html)
+		if loc == nil {
+			continue
+		}
+		body := html[loc[2]:loc[3]]
+		body = footnoteBackrefPattern.ReplaceAllString(body, "")
+		body = paragraphTagPattern.ReplaceAllString(body, " ")
+		body = strings.TrimSpace(body)
+		return body, html[:loc[0]] + html[loc[1]:], true
+	}
+	return "", html, false
+}
+
+// restoreFootnoteMarkers unescapes footnote markers that pandoc escaped while
+// converting the rewritten HTML.
+func restoreFootnoteMarkers(md string) string {
+	return escapedFootnotePattern.ReplaceAllString(md, "[^$1]")
+}
@@ -0,0 +1,67 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertFootnotes_ListNotes(t *testing.T) {
+	html := `<html><body><p>Claim<sup><a href="#fn1" id="fnref1">1</a></sup> and another<sup><a href="#fn2">[2]</a></sup>.</p>` +
+		`<ol class="footnotes"><li id="fn1"><p>First source. <a href="#fnref1">↩</a></p></li><li id="fn2">Second source.</li></ol></body></html>`
+
+	got := convertFootnotes(html)
+
+	for _, want := range []string{
+		"Claim[^1] and another[^2].",
+		"<p>[^1]: First source.</p>",
+		"<p>[^2]: Second source.</p>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in output, got: %s", want, got)
+		}
+	}
+	if strings.Contains(got, "<ol") || strings.Contains(got, "↩") {
+		t.Errorf("Expected notes list and back-references removed, got: %s", got)
+	}
+	if !strings.HasSuffix(got, "</body></html>") {
+		t.Errorf("Expected definitions inside body, got: %s", got)
+	}
+}
+
+func TestConvertFootnotes_AnchorParagraphNotes(t *testing.T) {
+	html := `<p>Text<sup><a href="#note-a">1</a></sup></p><p><a name="note-a"></a>1. The note.</p>`
+
+	got := convertFootnotes(html)
+
+	if !strings.Contains(got, "Text[^1]") {
+		t.Errorf("Expected footnote reference, got: %s", got)
+	}
+	if !strings.Contains(got, "[^1]: The note.") {
+		t.Errorf("Expected footnote definition, got: %s", got)
+	}
+}
+
+func TestConvertFootnotes_UnmatchedSuperscriptUntouched(t *testing.T) {
+	html := `<p>See<sup><a href="#missing">3</a></sup></p>`
+
+	if got := convertFootnotes(html); got != html {
+		t.Errorf("Expected unmatched reference to be unchanged, got: %s", got)
+	}
+}
+
+func TestRestoreFootnoteMarkers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Claim\[^1\] here.`, "Claim[^1] here."},
+		{`\[\^note\]: The note.`, "[^note]: The note."},
+		{"Plain [link](#x).", "Plain [link](#x)."},
+	}
+
+	for _, tt := range tests {
+		if got := restoreFootnoteMarkers(tt.input); got != tt.expected {
+			t.Errorf("restoreFootnoteMarkers(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+// OutputFormat selects the document format produced by the conversion.
+type OutputFormat string
+
+const (
+	// FormatMarkdown produces Markdown in the selected flavor (the default).
+	FormatMarkdown OutputFormat = "markdown"
+	// FormatOrg produces Emacs Org mode documents.
+	FormatOrg OutputFormat = "org"
+	// FormatPlain produces plain text without markup, for search and
+	// text-processing pipelines.
+	FormatPlain OutputFormat = "plain"
+	// FormatJSON produces pandoc's JSON AST of the pre-processed page, for
+	// custom analysis and transformations. Templates do not apply to it.
+	FormatJSON OutputFormat = "json"
+	// FormatDOCX produces Word documents (see ConvertHTMLToDocument).
+	FormatDOCX OutputFormat = "docx"
+	// FormatPDF produces PDF documents (see ConvertHTMLToDocument). It needs
+	// a PDF engine such as a LaTeX installation.
+	FormatPDF OutputFormat = "pdf"
+)
+
+// OutputFormats lists the supported output formats.
+var OutputFormats = []OutputFormat{FormatMarkdown, FormatOrg, FormatPlain, FormatJSON, FormatDOCX, FormatPDF}
+
+// Extension returns the file extension, including the dot, for the format.
+func (f OutputFormat) Extension() string {
+	switch f {
+	case FormatOrg:
+		return ".org"
+	case FormatPlain:
+		return ".txt"
+	case FormatJSON:
+		return ".json"
+	case FormatDOCX:
+		return ".docx"
+	case FormatPDF:
+		return ".pdf"
+	default:
+		return ".md"
+	}
+}
+
+// IsBinary reports whether the format is a binary document format, produced
+// with ConvertHTMLToDocument rather than ConvertHTMLToMarkdownWithOptions.
+func (f OutputFormat) IsBinary() bool {
+	return f == FormatDOCX || f == FormatPDF
+}
+
+// IsData reports whether the format is a data format rather than a
+// document, so that no text (such as comments or footers) can be appended
+// to the output.
+func (f OutputFormat) IsData() bool {
+	return f == FormatJSON
+}
+
+// pandocWriter returns the pandoc output format name for the format.
+func (f OutputFormat) pandocWriter() string {
+	switch f {
+	case FormatOrg:
+		return "org"
+	case FormatPlain:
+		return "plain"
+	case FormatJSON:
+		return "json"
+	default:
+		return "gfm"
+	}
+}
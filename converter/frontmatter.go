@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FrontMatterField is a single YAML front matter entry. Fields are emitted in
+// the order given, so the output is deterministic.
+type FrontMatterField struct {
+	Key   string
+	Value any
+}
+
+// renderFrontMatter renders fields as a YAML front matter block, including
+// the --- delimiters and a trailing blank line. It returns an empty string
+// when there are no fields.
+func renderFrontMatter(fields []FrontMatterField) string {
+	if len(fields) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	for _, field := range fields {
+		fmt.Fprintf(&b, "%s: %s\n", field.Key, yamlValue(field.Value))
+	}
+	b.WriteString("---\n\n")
+	return b.String()
+}
+
+// yamlValue formats a Go value as a YAML scalar or flow sequence.
+func yamlValue(v any) string {
+	switch val := v.(type) {
+	case string:
+		return strconv.Quote(val)
+	case time.Time:
+		return val.Format("2006-01-02 15:04:05 -0700")
+	case []string:
+		items := make([]string, len(val))
+		for i, item := range val {
+			items[i] = strconv.Quote(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// SplitFrontMatter splits converted Markdown into its leading YAML front
+// matter block, with its delimiters, and the body. The front matter is
+// empty for documents without one.
+func SplitFrontMatter(md string) (frontMatter, body string) {
+	return splitFrontMatter(md)
+}
+
+// prependFrontMatter adds the rendered fields to the top of the document.
+func prependFrontMatter(md string, fields []FrontMatterField) string {
+	return renderFrontMatter(fields) + md
+}
+
+// addBoilerplate inserts header at the top of the document, after any
+// front matter, and footer at its end, each separated by a blank line.
+func addBoilerplate(md, header, footer string) string {
+	if header = strings.Trim(header, "\n"); header != "" {
+		frontMatter, body := splitFrontMatter(md)
+		md = frontMatter + header + "\n\n" + body
+	}
+	if footer = strings.Trim(footer, "\n"); footer != "" {
+		md = strings.TrimRight(md, "\n") + "\n\n" + footer + "\n"
+	}
+	return md
+}
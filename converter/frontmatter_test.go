@@ -0,0 +1,69 @@
+package converter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRenderFrontMatter(t *testing.T) {
+	fields := []FrontMatterField{
+		{Key: "layout", Value: "post"},
+		{Key: "title", Value: `Say "hello"`},
+		{Key: "date", Value: time.Date(2026, 1, 7, 1, 29, 0, 0, time.UTC)},
+		{Key: "tags", Value: []string{"ops", "runbook"}},
+		{Key: "draft", Value: false},
+	}
+
+	want := "---\n" +
+		"layout: \"post\"\n" +
+		"title: \"Say \\\"hello\\\"\"\n" +
+		"date: 2026-01-07 01:29:00 +0000\n" +
+		"tags: [\"ops\", \"runbook\"]\n" +
+		"draft: false\n" +
+		"---\n\n"
+
+	if got := renderFrontMatter(fields); got != want {
+		t.Errorf("renderFrontMatter() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderFrontMatter_Empty(t *testing.T) {
+	if got := renderFrontMatter(nil); got != "" {
+		t.Errorf("Expected empty front matter, got %q", got)
+	}
+}
+
+func TestApplyOptions_FrontMatterBeforeTOC(t *testing.T) {
+	opts := Options{
+		TOCDepth:    2,
+		FrontMatter: []FrontMatterField{{Key: "title", Value: "Page"}},
+	}
+	got := runMarkdownSteps(t, "# Intro\n", opts)
+
+	want := "---\ntitle: \"Page\"\n---\n\n- [Intro](#intro)\n\n# Intro\n"
+	if got != want {
+		t.Errorf("runMarkdownSteps() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestAddBoilerplate(t *testing.T) {
+	tests := []struct {
+		name   string
+		md     string
+		header string
+		footer string
+		want   string
+	}{
+		{"header and footer", "# Title\n\nBody\n", "> Banner\n", "\nLicense\n", "> Banner\n\n# Title\n\nBody\n\nLicense\n"},
+		{"after front matter", "---\ntitle: \"x\"\n---\n\nBody\n", "Banner", "", "---\ntitle: \"x\"\n---\n\nBanner\n\nBody\n"},
+		{"nothing to add", "Body\n", "", "\n", "Body\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addBoilerplate(tt.md, tt.header, tt.footer); got != tt.want {
+				t.Errorf("addBoilerplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
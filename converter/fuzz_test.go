@@ -564,7 +564,7 @@ func FuzzIsConfluenceMIME(f *testing.F) {
 		// Binary
 		"\x00\x01\x02",
 
-		// Headers after limit (10 lines)
+		// Required headers past line 10
 		strings.Repeat("X-Header: value\n", 15) + "Date: x\nMIME-Version: 1.0\nSubject: Exported From Confluence\n",
 
 		// Very long lines
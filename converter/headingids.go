@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// HeadingIDStyle selects how the IDs Confluence gives headings, which its
+// deep links (page#Page-Section) point at, are kept in Markdown output.
+type HeadingIDStyle string
+
+const (
+	// HeadingIDsNone drops heading IDs; renderers generate their own
+	// anchors from the heading text (the default).
+	HeadingIDsNone HeadingIDStyle = "none"
+	// HeadingIDsAttributes writes them as pandoc attributes:
+	// "## Setup {#Page-Setup}".
+	HeadingIDsAttributes HeadingIDStyle = "attributes"
+	// HeadingIDsAnchors puts an invisible HTML anchor, <a id="Page-Setup"></a>,
+	// before each heading, which renderers without attribute syntax honor.
+	HeadingIDsAnchors HeadingIDStyle = "anchors"
+)
+
+// HeadingIDStyles lists the supported heading ID styles.
+var HeadingIDStyles = []HeadingIDStyle{HeadingIDsNone, HeadingIDsAttributes, HeadingIDsAnchors}
+
+// headingIDMarker and headingIDMarkerEnd enclose the hex-encoded ID of a
+// heading while pandoc converts the document. Letters and digits pass
+// through pandoc unescaped, whatever characters the ID holds.
+const (
+	headingIDMarker    = "CTWOMDHEADINGID"
+	headingIDMarkerEnd = "Z"
+)
+
+var (
+	// headingIDTagPattern matches heading start tags with an id attribute,
+	// capturing the tag and the id.
+	headingIDTagPattern = regexp.MustCompile(`(?i)<h[1-6]\b[^>]*\sid="([^"]+)"[^>]*>`)
+
+	// headingIDMarkerPattern matches a marker and the space pandoc leaves
+	// after it, capturing the encoded ID.
+	headingIDMarkerPattern = regexp.MustCompile(headingIDMarker + `([0-9a-f]+)` + headingIDMarkerEnd + ` ?`)
+)
+
+// markHeadingIDs puts a marker carrying the heading's ID at the start of
+// each heading with one, for restoreHeadingAnchors.
+func markHeadingIDs(html string) string {
+	return headingIDTagPattern.ReplaceAllStringFunc(html, func(tag string) string {
+		id := headingIDTagPattern.FindStringSubmatch(tag)[1]
+		return tag + headingIDMarker + hex.EncodeToString([]byte(id)) + headingIDMarkerEnd + " "
+	})
+}
+
+// restoreHeadingAnchors replaces the markers of markHeadingIDs with HTML
+// anchors: on a line of their own before headings, and inline where the
+// heading did not become a Markdown heading, such as in a table cell.
+func restoreHeadingAnchors(md string) string {
+	if !strings.Contains(md, headingIDMarker) {
+		return md
+	}
+	lines := strings.Split(md, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if atxHeadingPattern.MatchString(line) {
+			var anchors []string
+			line = headingIDMarkerPattern.ReplaceAllStringFunc(line, func(marker string) string {
+				anchors = append(anchors, headingAnchor(marker))
+				return ""
+			})
+			if len(anchors) > 0 {
+				out = append(out, strings.Join(anchors, ""), "")
+			}
+			out = append(out, line)
+			continue
+		}
+		out = append(out, headingIDMarkerPattern.ReplaceAllStringFunc(line, headingAnchor))
+	}
+	return strings.Join(out, "\n")
+}
+
+// headingAnchor returns the HTML anchor for a heading ID marker.
+func headingAnchor(marker string) string {
+	id, err := hex.DecodeString(headingIDMarkerPattern.FindStringSubmatch(marker)[1])
+	if err != nil {
+		return ""
+	}
+	return `<a id="` + escapeAttribute(string(id)) + `"></a>`
+}
@@ -0,0 +1,61 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeadingAnchors(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		// md is what pandoc makes of the marked HTML
+		md   string
+		want string
+	}{
+		{
+			name: "heading",
+			html: `<h2 id="Page-Setup&amp;Use">Setup</h2>`,
+			md:   "Intro\n\n## %s Setup\n",
+			want: "Intro\n\n<a id=\"Page-Setup&amp;Use\"></a>\n\n## Setup\n",
+		},
+		{
+			name: "heading that is not a Markdown heading",
+			html: `<h3 id="Cell">Cell</h3>`,
+			md:   "| %s Cell |\n",
+			want: "| <a id=\"Cell\"></a>Cell |\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marked := markHeadingIDs(tt.html)
+			marker := headingIDMarkerPattern.FindString(marked)
+			if marker == "" {
+				t.Fatalf("markHeadingIDs() = %q, want a marker", marked)
+			}
+			md := strings.Replace(tt.md, "%s ", marker, 1)
+			if got := restoreHeadingAnchors(md); got != tt.want {
+				t.Errorf("restoreHeadingAnchors() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkHeadingIDs_WithoutID(t *testing.T) {
+	html := `<h2>Setup</h2><p id="x">Text</p>`
+	if got := markHeadingIDs(html); got != html {
+		t.Errorf("markHeadingIDs() = %q, want the HTML unchanged", got)
+	}
+}
+
+func TestKeepAttributes_HeadingIDs(t *testing.T) {
+	html := `<h2 id="Page-Setup" class="x">Setup</h2><p id="p1">Text</p>`
+	want := `<h2 id="Page-Setup">Setup</h2><p>Text</p>`
+	if got := keepAttributes(html, nil, true); got != want {
+		t.Errorf("keepAttributes() = %q, want %q", got, want)
+	}
+	if got := (Options{HeadingIDs: HeadingIDsAttributes}).writer(); got != "gfm+attributes" {
+		t.Errorf("writer() = %q, want gfm+attributes", got)
+	}
+}
@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// atxHeadingPattern matches ATX-style Markdown headings ("## Title").
+//
+// Pattern breakdown:
+// ^(#{1,6})  - Capture the heading marker (level 1-6)
+// [ \t]+     - Require whitespace after the marker
+// (.*?)      - Capture the heading text (non-greedy)
+// [ \t]*$    - Ignore trailing whitespace
+var atxHeadingPattern = regexp.MustCompile(`^(#{1,6})[ \t]+(.*?)[ \t]*$`)
+
+// fencePattern matches the opening or closing line of a fenced code block.
+var fencePattern = regexp.MustCompile("^\\s*(```|~~~)")
+
+// anchorLinkPattern matches in-document Markdown links such as [text](#anchor).
+var anchorLinkPattern = regexp.MustCompile(`\]\(#([^)\s]+)\)`)
+
+// markdownLinkPattern matches inline Markdown links, capturing the link text.
+var markdownLinkPattern = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+
+// heading describes an ATX heading found in a Markdown document.
+type heading struct {
+	line  int    // index of the line in the document
+	level int    // heading level (1-6)
+	text  string // heading text without the # marker and attributes
+	attrs string // pandoc attribute block, such as {#id .class}, or ""
+}
+
+// findHeadings returns all ATX headings in md, skipping fenced code blocks.
+func findHeadings(lines []string) []heading {
+	var headings []heading
+	inFence := false
+	for i, line := range lines {
+		if fencePattern.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if m := atxHeadingPattern.FindStringSubmatch(line); m != nil {
+			text, attrs := splitHeadingAttributes(m[2])
+			headings = append(headings, heading{line: i, level: len(m[1]), text: text, attrs: attrs})
+		}
+	}
+	return headings
+}
+
+// format returns the Markdown line of the heading at level with text,
+// keeping its attributes.
+func (h heading) format(level int, text string) string {
+	line := strings.Repeat("#", level) + " " + text
+	if h.attrs != "" {
+		line += " " + h.attrs
+	}
+	return line
+}
+
+// anchor returns the heading's anchor: the id of its attributes, or the
+// next slug of its text.
+func (h heading) anchor(slugs *slugger) string {
+	if id := attributeID(h.attrs); id != "" {
+		return id
+	}
+	return slugs.slug(h.text)
+}
+
+// numberHeadings prefixes every heading with its hierarchical number
+// (1., 1.1, 1.1.1). The shallowest heading level in the document is treated
+// as the top level, so documents starting at "##" still number from "1.".
+// In-document anchor links are rewritten so they keep pointing at the
+// renumbered headings, using the anchor rules of the given flavor.
+func numberHeadings(md string, flavor Flavor) string {
+	lines := strings.Split(md, "\n")
+	headings := findHeadings(lines)
+	if len(headings) == 0 {
+		return md
+	}
+
+	topLevel := 6
+	for _, h := range headings {
+		if h.level < topLevel {
+			topLevel = h.level
+		}
+	}
+
+	counters := make([]int, 6)
+	oldSlugs := newSlugger(flavor)
+	newSlugs := newSlugger(flavor)
+	anchors := make(map[string]string)
+
+	for _, h := range headings {
+		depth := h.level - topLevel
+		counters[depth]++
+		for i := depth + 1; i < len(counters); i++ {
+			counters[i] = 0
+		}
+
+		parts := make([]string, depth+1)
+		for i := 0; i <= depth; i++ {
+			parts[i] = strconv.Itoa(counters[i])
+		}
+		number := strings.Join(parts, ".")
+		if depth == 0 {
+			number += "."
+		}
+
+		text := fmt.Sprintf("%s %s", number, h.text)
+		lines[h.line] = h.format(h.level, text)
+		if attributeID(h.attrs) == "" {
+			anchors[oldSlugs.slug(h.text)] = newSlugs.slug(text)
+		}
+	}
+
+	md = strings.Join(lines, "\n")
+	return rewriteAnchors(md, anchors)
+}
+
+// normalizeHeadingLevels compresses heading levels so that no level is
+// skipped: a heading is placed one level below the nearest preceding
+// heading with a lower original level, so "# A / #### B / ##### C" becomes
+// "# A / ## B / ### C". Headings without such a parent keep the shallowest
+// level in the document. Heading text, and therefore anchors, is unchanged.
+func normalizeHeadingLevels(md string) string {
+	lines := strings.Split(md, "\n")
+	headings := findHeadings(lines)
+	if len(headings) == 0 {
+		return md
+	}
+
+	topLevel := 6
+	for _, h := range headings {
+		topLevel = min(topLevel, h.level)
+	}
+
+	// parents holds the original and normalized levels of the open headings
+	type level struct{ original, normalized int }
+	var parents []level
+	for _, h := range headings {
+		for len(parents) > 0 && parents[len(parents)-1].original >= h.level {
+			parents = parents[:len(parents)-1]
+		}
+		normalized := topLevel
+		if len(parents) > 0 {
+			normalized = parents[len(parents)-1].normalized + 1
+		}
+		parents = append(parents, level{h.level, normalized})
+		if normalized != h.level {
+			lines[h.line] = h.format(normalized, h.text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rewriteAnchors replaces in-document anchor links according to the given
+// old → new anchor mapping. Links to unknown anchors and links inside code
+// are left untouched.
+func rewriteAnchors(md string, anchors map[string]string) string {
+	return protectCode(md, func(md string) string {
+		return anchorLinkPattern.ReplaceAllStringFunc(md, func(match string) string {
+			anchor := anchorLinkPattern.FindStringSubmatch(match)[1]
+			if replacement, ok := anchors[anchor]; ok {
+				return "](#" + replacement + ")"
+			}
+			return match
+		})
+	})
+}
+
+// slugger generates heading anchors for a flavor, disambiguating duplicates
+// with numeric suffixes ("usage", "usage-1", "usage-2"; "usage_1" for
+// MkDocs).
+type slugger struct {
+	seen      map[string]int
+	slugFunc  func(string) string
+	separator string
+}
+
+// newSlugger returns a slugger with no anchors seen yet.
+func newSlugger(flavor Flavor) *slugger {
+	return &slugger{seen: make(map[string]int), slugFunc: flavor.headingSlugFunc(), separator: flavor.duplicateSlugSeparator()}
+}
+
+// slug returns the unique anchor for a heading with the given text.
+func (s *slugger) slug(text string) string {
+	base := s.slugFunc(text)
+	count, ok := s.seen[base]
+	s.seen[base] = count + 1
+	if !ok {
+		return base
+	}
+	return fmt.Sprintf("%s%s%d", base, s.separator, count)
+}
+
+// headingSlug converts heading text to the anchor GitHub generates for it:
+// lowercased, punctuation removed, and spaces replaced with hyphens.
+// Link syntax is reduced to its visible text first.
+func headingSlug(text string) string {
+	text = markdownLinkPattern.ReplaceAllString(text, "$1")
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
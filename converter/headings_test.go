@@ -0,0 +1,147 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNumberHeadings(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "hierarchical numbering",
+			input:    "# Intro\n\n## Scope\n\n### Details\n\n## Goals\n\n# Usage\n",
+			expected: "# 1. Intro\n\n## 1.1 Scope\n\n### 1.1.1 Details\n\n## 1.2 Goals\n\n# 2. Usage\n",
+		},
+		{
+			name:     "document starting at level 2",
+			input:    "## First\n\n### Sub\n\n## Second\n",
+			expected: "## 1. First\n\n### 1.1 Sub\n\n## 2. Second\n",
+		},
+		{
+			name:     "headings inside code fences are ignored",
+			input:    "# Title\n\n```\n# not a heading\n```\n\n## Next\n",
+			expected: "# 1. Title\n\n```\n# not a heading\n```\n\n## 1.1 Next\n",
+		},
+		{
+			name:     "no headings",
+			input:    "Just a paragraph.\n",
+			expected: "Just a paragraph.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := numberHeadings(tt.input, FlavorGFM)
+			if got != tt.expected {
+				t.Errorf("numberHeadings() =\n%q\nwant\n%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNumberHeadings_RewritesAnchors(t *testing.T) {
+	input := "- [Getting Started](#getting-started)\n- [External](https://example.com/#getting-started)\n\n# Getting Started\n"
+	got := numberHeadings(input, FlavorGFM)
+
+	if !strings.Contains(got, "[Getting Started](#1-getting-started)") {
+		t.Errorf("Expected anchor link to be rewritten, got: %s", got)
+	}
+	if !strings.Contains(got, "(https://example.com/#getting-started)") {
+		t.Errorf("Expected external link to be untouched, got: %s", got)
+	}
+}
+
+func TestNumberHeadings_Attributes(t *testing.T) {
+	input := "- [Setup](#Page-Setup)\n\n# Setup {#Page-Setup}\n\n## Linux {.os}\n"
+	want := "- [Setup](#Page-Setup)\n\n# 1. Setup {#Page-Setup}\n\n## 1.1 Linux {.os}\n"
+	if got := numberHeadings(input, FlavorGFM); got != want {
+		t.Errorf("numberHeadings() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestNormalizeHeadingLevels(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "skipped levels are compressed",
+			input:    "# Title\n\n#### Deep\n\n###### Deeper\n\n#### Sibling\n",
+			expected: "# Title\n\n## Deep\n\n### Deeper\n\n## Sibling\n",
+		},
+		{
+			name:     "relative structure is preserved",
+			input:    "# A\n\n### B\n\n## C\n\n### D\n\n# E\n\n### F\n",
+			expected: "# A\n\n## B\n\n## C\n\n### D\n\n# E\n\n## F\n",
+		},
+		{
+			name:     "document starting at level 2 keeps its top level",
+			input:    "## First\n\n#### Sub\n",
+			expected: "## First\n\n### Sub\n",
+		},
+		{
+			name:     "headings inside code fences are ignored",
+			input:    "# Title\n\n```\n### not a heading\n```\n\n### Next\n",
+			expected: "# Title\n\n```\n### not a heading\n```\n\n## Next\n",
+		},
+		{
+			name:     "no skipped levels",
+			input:    "# A\n\n## B\n\n### C\n",
+			expected: "# A\n\n## B\n\n### C\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeHeadingLevels(tt.input)
+			if got != tt.expected {
+				t.Errorf("normalizeHeadingLevels() =\n%q\nwant\n%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHeadingSlug(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Getting Started", "getting-started"},
+		{"1.2 What's New?", "12-whats-new"},
+		{"API_v2 Reference", "api_v2-reference"},
+		{"Über uns", "über-uns"},
+	}
+
+	for _, tt := range tests {
+		if got := headingSlug(tt.input); got != tt.expected {
+			t.Errorf("headingSlug(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestSlugger_Duplicates(t *testing.T) {
+	tests := []struct {
+		flavor Flavor
+		want   []string
+	}{
+		{FlavorGFM, []string{"usage", "usage-1", "usage-2"}},
+		{FlavorGitLab, []string{"usage", "usage-1", "usage-2"}},
+		{FlavorMkDocs, []string{"usage", "usage_1", "usage_2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.flavor), func(t *testing.T) {
+			s := newSlugger(tt.flavor)
+			for i, want := range tt.want {
+				if got := s.slug("Usage"); got != want {
+					t.Errorf("slug #%d = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// emoticonImagePattern matches simplified emoticon images by alt text.
+var emoticonImagePattern = regexp.MustCompile(`<img src="[^"]*" alt="(\([^"]*\))"[^>]*>`)
+
+// findElementEnd returns the index just past the closing tag matching the
+// element that opens at start, counting nested elements with the same tag
+// name. It returns -1 if the element is never closed.
+func findElementEnd(html string, start int, tag string) int {
+	open := "<" + tag
+	closing := "</" + tag + ">"
+
+	depth := 0
+	for i := start; i < len(html); {
+		nextOpen := indexOpenTag(html[i:], open)
+		nextClose := strings.Index(html[i:], closing)
+		if nextClose == -1 {
+			return -1
+		}
+		if nextOpen != -1 && nextOpen < nextClose {
+			depth++
+			i += nextOpen + len(open)
+			continue
+		}
+		depth--
+		i += nextClose + len(closing)
+		if depth == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexOpenTag returns the index of the first opening tag in s, making sure
+// the match is the whole tag name (so "<div" does not match "<divider").
+func indexOpenTag(s, open string) int {
+	offset := 0
+	for {
+		idx := strings.Index(s[offset:], open)
+		if idx == -1 {
+			return -1
+		}
+		end := offset + idx + len(open)
+		if end >= len(s) || s[end] == '>' || s[end] == ' ' || s[end] == '\t' || s[end] == '\n' || s[end] == '/' {
+			return offset + idx
+		}
+		offset = end
+	}
+}
+
+// elementInner returns the content between the opening tag of the element
+// spanning html[start:end] and its closing tag.
+func elementInner(html string, start, end int, tag string) string {
+	openEnd := strings.Index(html[start:end], ">")
+	if openEnd == -1 {
+		return ""
+	}
+	return html[start+openEnd+1 : end-len("</"+tag+">")]
+}
+
+// replaceElements replaces every element whose opening tag matches pattern
+// with the result of fn, which receives the element's inner HTML and the
+// pattern submatches of its opening tag. Elements are processed outermost
+// first; content returned by fn is scanned again, so nested elements are
+// handled too.
+func replaceElements(html string, pattern *regexp.Regexp, tag string, fn func(inner string, match []string) string) string {
+	searchFrom := 0
+	for {
+		loc := pattern.FindStringSubmatchIndex(html[searchFrom:])
+		if loc == nil {
+			return html
+		}
+		start := searchFrom + loc[0]
+		end := findElementEnd(html, start, tag)
+		if end == -1 {
+			// Unclosed element: skip past its opening tag
+			searchFrom = searchFrom + loc[1]
+			continue
+		}
+
+		match := make([]string, len(loc)/2)
+		for i := range match {
+			if loc[2*i] >= 0 {
+				match[i] = html[searchFrom+loc[2*i] : searchFrom+loc[2*i+1]]
+			}
+		}
+		replacement := fn(elementInner(html, start, end, tag), match)
+		html = html[:start] + replacement + html[end:]
+		searchFrom = start
+	}
+}
+
+// replaceEmoticonImages replaces simplified Confluence emoticon images with
+// Unicode emoji, for output formats that skip Markdown post-processing.
+func replaceEmoticonImages(html string) string {
+	return emoticonImagePattern.ReplaceAllStringFunc(html, func(match string) string {
+		alt := emoticonImagePattern.FindStringSubmatch(match)[1]
+		if emoji, ok := defaultEmoticons[alt]; ok {
+			return emoji
+		}
+		return match
+	})
+}
@@ -0,0 +1,42 @@
+package converter
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFindElementEnd(t *testing.T) {
+	tests := []struct {
+		name  string
+		html  string
+		start int
+		want  int
+	}{
+		{"simple", `<div>a</div>b`, 0, 12},
+		{"nested", `<div><div>a</div></div>b`, 0, 23},
+		{"similar tag name", `<div><divider></divider></div>`, 0, 30},
+		{"unclosed", `<div><div>a</div>`, 0, -1},
+		{"inner element", `<div><div>a</div></div>`, 5, 17},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findElementEnd(tt.html, tt.start, "div"); got != tt.want {
+				t.Errorf("findElementEnd() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceElements(t *testing.T) {
+	pattern := regexp.MustCompile(`<div class="(box)">`)
+	input := `<p>x</p><div class="box"><div>inner</div></div><div class="box">open`
+	want := `<p>x</p>[box:<div>inner</div>]<div class="box">open`
+
+	got := replaceElements(input, pattern, "div", func(inner string, m []string) string {
+		return "[" + m[1] + ":" + inner + "]"
+	})
+	if got != want {
+		t.Errorf("replaceElements() = %q, want %q", got, want)
+	}
+}
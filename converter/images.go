@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CaptionStyle selects how image captions are rendered in the Markdown output.
+type CaptionStyle string
+
+const (
+	// CaptionItalic emits the caption as an italic line below the image.
+	CaptionItalic CaptionStyle = "italic"
+	// CaptionAlt uses the caption as the image alt text.
+	CaptionAlt CaptionStyle = "alt"
+	// CaptionTitle uses the caption as the image title.
+	CaptionTitle CaptionStyle = "title"
+)
+
+// CaptionStyles lists the supported caption styles.
+var CaptionStyles = []CaptionStyle{CaptionItalic, CaptionAlt, CaptionTitle}
+
+// ImageSizeStyle selects how image width/height hints are preserved.
+type ImageSizeStyle string
+
+const (
+	// ImageSizeNone drops size hints, producing plain ![alt](src) images.
+	ImageSizeNone ImageSizeStyle = "none"
+	// ImageSizeHTML keeps sized images as HTML <img width=... height=...> tags.
+	ImageSizeHTML ImageSizeStyle = "html"
+	// ImageSizeSuffix appends the size to the image target: ![alt](src =600x400).
+	ImageSizeSuffix ImageSizeStyle = "suffix"
+)
+
+// ImageSizeStyles lists the supported image size styles.
+var ImageSizeStyles = []ImageSizeStyle{ImageSizeNone, ImageSizeHTML, ImageSizeSuffix}
+
+var (
+	// captionElementPatterns match the wrappers Confluence uses for image
+	// captions. Go's regexp has no backreferences, so each tag gets a pattern.
+	captionElementPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`<div[^>]*class="[^"]*caption[^"]*"[^>]*>([\s\S]*?)</div>`),
+		regexp.MustCompile(`<p[^>]*class="[^"]*caption[^"]*"[^>]*>([\s\S]*?)</p>`),
+		regexp.MustCompile(`<span[^>]*class="[^"]*caption[^"]*"[^>]*>([\s\S]*?)</span>`),
+		regexp.MustCompile(`<figcaption[^>]*>([\s\S]*?)</figcaption>`),
+	}
+
+	// captionedImagePattern matches a simplified image followed by its
+	// caption, allowing paragraph, break, and wrapper tags in between.
+	//
+	// Pattern breakdown:
+	// <img src="([^"]*)" alt="([^"]*)"    - Capture src and alt of the simplified image
+	// ([^>]*)>                             - Capture remaining attributes (size hints)
+	// ((?:\s|</?p>|<br\s*/?>|...)*?)       - Capture the markup separating image and caption
+	// <figcaption>([\s\S]*?)</figcaption>  - Capture the normalized caption text
+	captionedImagePattern = regexp.MustCompile(`<img src="([^"]*)" alt="([^"]*)"([^>]*)>((?:\s|</?p>|<br\s*/?>|</?figure[^>]*>|</?div[^>]*>)*?)<figcaption>([\s\S]*?)</figcaption>`)
+
+	// imageWidthPattern and imageHeightPattern capture numeric size attributes.
+	imageWidthPattern  = regexp.MustCompile(`\swidth="(\d+)(?:px)?"`)
+	imageHeightPattern = regexp.MustCompile(`\sheight="(\d+)(?:px)?"`)
+
+	// simplifiedImageSizePattern matches the size attributes kept on simplified images.
+	simplifiedImageSizePattern = regexp.MustCompile(`(<img src="[^"]*" alt="[^"]*"[^>]*?)\s(?:width|height)="\d+"`)
+
+	// htmlImagePattern matches raw <img> tags that pandoc leaves in the Markdown
+	// for images it cannot express natively, such as sized images.
+	htmlImagePattern = regexp.MustCompile(`<img\s[^>]*>`)
+
+	// tagPattern matches any HTML tag.
+	tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+	// imageSrcPattern and imageAltPattern capture the src and alt attributes of an <img> tag.
+	imageSrcPattern = regexp.MustCompile(`\ssrc="([^"]*)"`)
+	imageAltPattern = regexp.MustCompile(`\salt="([^"]*)"`)
+
+	// figcaptionPattern matches a normalized caption element.
+	figcaptionPattern = regexp.MustCompile(`<figcaption>([\s\S]*?)</figcaption>`)
+)
+
+// normalizeImageCaptions rewrites the various Confluence caption wrappers into
+// <figcaption> elements. It runs before preProcessHTML, which strips the
+// class attributes needed to recognize captions.
+func normalizeImageCaptions(html string) string {
+	for _, pattern := range captionElementPatterns {
+		html = pattern.ReplaceAllString(html, "<figcaption>$1</figcaption>")
+	}
+	return html
+}
+
+// applyImageCaptions attaches normalized captions to the image preceding them
+// using the given style. Captions without a preceding image are kept as plain
+// paragraphs.
+func applyImageCaptions(html string, style CaptionStyle) string {
+	html = captionedImagePattern.ReplaceAllStringFunc(html, func(match string) string {
+		m := captionedImagePattern.FindStringSubmatch(match)
+		src, alt, attrs, between := m[1], m[2], m[3], m[4]
+		caption := strings.TrimSpace(tagPattern.ReplaceAllString(m[5], ""))
+		if caption == "" {
+			return fmt.Sprintf(`<img src="%s" alt="%s"%s>%s`, src, alt, attrs, between)
+		}
+
+		switch style {
+		case CaptionAlt:
+			return fmt.Sprintf(`<img src="%s" alt="%s"%s>%s`, src, escapeAttribute(caption), attrs, between)
+		case CaptionTitle:
+			return fmt.Sprintf(`<img src="%s" alt="%s"%s title="%s">%s`, src, alt, attrs, escapeAttribute(caption), between)
+		default:
+			return fmt.Sprintf(`<img src="%s" alt="%s"%s>%s<p><em>%s</em></p>`, src, alt, attrs, between, caption)
+		}
+	})
+
+	// Orphaned captions become ordinary paragraphs
+	return figcaptionPattern.ReplaceAllString(html, "<p>$1</p>")
+}
+
+// escapeAttribute escapes double quotes so text can be used as an attribute value.
+func escapeAttribute(s string) string {
+	return strings.ReplaceAll(s, `"`, "&quot;")
+}
+
+// imageSizeAttributes returns the width/height attributes of an <img> tag,
+// normalized to ` width="N" height="N"`, or an empty string if it has none.
+func imageSizeAttributes(tag string) string {
+	var attrs string
+	if m := imageWidthPattern.FindStringSubmatch(tag); m != nil {
+		attrs += fmt.Sprintf(` width="%s"`, m[1])
+	}
+	if m := imageHeightPattern.FindStringSubmatch(tag); m != nil {
+		attrs += fmt.Sprintf(` height="%s"`, m[1])
+	}
+	return attrs
+}
+
+// applyImageSizes removes the size hints kept on simplified images unless the
+// style preserves them. Sized images are emitted by pandoc as raw HTML.
+func applyImageSizes(html string, style ImageSizeStyle) string {
+	if style == ImageSizeHTML || style == ImageSizeSuffix {
+		return html
+	}
+	for simplifiedImageSizePattern.MatchString(html) {
+		html = simplifiedImageSizePattern.ReplaceAllString(html, "$1")
+	}
+	return html
+}
+
+// sizedImagesToSuffix converts raw HTML images carrying size hints into
+// Markdown images with a size suffix: ![alt](src =600x400). The height is
+// omitted when unknown (=600x).
+func sizedImagesToSuffix(md string) string {
+	return htmlImagePattern.ReplaceAllStringFunc(md, func(tag string) string {
+		width := imageWidthPattern.FindStringSubmatch(tag)
+		height := imageHeightPattern.FindStringSubmatch(tag)
+		src := imageSrcPattern.FindStringSubmatch(tag)
+		if src == nil || (width == nil && height == nil) {
+			return tag
+		}
+
+		alt := ""
+		if m := imageAltPattern.FindStringSubmatch(tag); m != nil {
+			alt = m[1]
+		}
+		size := "="
+		if width != nil {
+			size += width[1]
+		}
+		size += "x"
+		if height != nil {
+			size += height[1]
+		}
+		return fmt.Sprintf("![%s](%s %s)", alt, src[1], size)
+	})
+}
@@ -0,0 +1,126 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImageCaptions(t *testing.T) {
+	input := `<p><span class="confluence-embedded-file-wrapper"><img class="confluence-embedded-image" src="diagram.png" alt="" width="600"></span></p>` +
+		`<div class="confluence-image-caption">System <b>overview</b></div>`
+
+	tests := []struct {
+		name     string
+		style    CaptionStyle
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "italic below image by default",
+			style:    "",
+			contains: []string{`<img src="diagram.png" alt="">`, "<p><em>System overview</em></p>"},
+			excludes: []string{"figcaption"},
+		},
+		{
+			name:     "caption as alt text",
+			style:    CaptionAlt,
+			contains: []string{`<img src="diagram.png" alt="System overview">`},
+			excludes: []string{"<em>", "figcaption"},
+		},
+		{
+			name:     "caption as title",
+			style:    CaptionTitle,
+			contains: []string{`<img src="diagram.png" alt="" title="System overview">`},
+			excludes: []string{"<em>", "figcaption"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html := preProcessHTML(normalizeImageCaptions(input))
+			got := applyImageSizes(applyImageCaptions(html, tt.style), ImageSizeNone)
+
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("Expected %q in output, got: %s", want, got)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("Expected %q to be absent, got: %s", unwanted, got)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyImageCaptions_OrphanedCaption(t *testing.T) {
+	got := applyImageCaptions("<figcaption>Lonely caption</figcaption>", CaptionItalic)
+
+	if got != "<p>Lonely caption</p>" {
+		t.Errorf("Expected orphaned caption to become a paragraph, got: %s", got)
+	}
+}
+
+func TestApplyImageCaptions_QuotesEscaped(t *testing.T) {
+	got := applyImageCaptions(`<img src="a.png" alt=""><figcaption>The "main" view</figcaption>`, CaptionAlt)
+
+	if !strings.Contains(got, `alt="The &quot;main&quot; view"`) {
+		t.Errorf("Expected quotes escaped in alt text, got: %s", got)
+	}
+}
+
+func TestApplyImageSizes(t *testing.T) {
+	input := preProcessHTML(`<img class="confluence-embedded-image" width="600" height="400px" src="shot.png" alt="Shot">`)
+
+	tests := []struct {
+		name     string
+		style    ImageSizeStyle
+		expected string
+	}{
+		{"dropped by default", "", `<img src="shot.png" alt="Shot">`},
+		{"dropped explicitly", ImageSizeNone, `<img src="shot.png" alt="Shot">`},
+		{"kept for html", ImageSizeHTML, `<img src="shot.png" alt="Shot" width="600" height="400">`},
+		{"kept for suffix", ImageSizeSuffix, `<img src="shot.png" alt="Shot" width="600" height="400">`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyImageSizes(input, tt.style); got != tt.expected {
+				t.Errorf("applyImageSizes() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSizedImagesToSuffix(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "width and height",
+			input:    `See <img src="shot.png" width="600" height="400" alt="Shot" /> here`,
+			expected: "See ![Shot](shot.png =600x400) here",
+		},
+		{
+			name:     "width only",
+			input:    `<img src="shot.png" alt="" width="600" />`,
+			expected: "![](shot.png =600x)",
+		},
+		{
+			name:     "unsized image untouched",
+			input:    `<img src="icon.png" alt="(tick)" />`,
+			expected: `<img src="icon.png" alt="(tick)" />`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sizedImagesToSuffix(tt.input); got != tt.expected {
+				t.Errorf("sizedImagesToSuffix() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultAdmonitionLabels are the English labels that introduce the
+// content of info, note, tip, success, and warning macros in Markdown and
+// plain text output: "> **Tip:** ...".
+var DefaultAdmonitionLabels = map[AdmonitionType]string{
+	AdmonitionInfo:    "Info",
+	AdmonitionNote:    "Note",
+	AdmonitionTip:     "Tip",
+	AdmonitionSuccess: "Success",
+	AdmonitionWarning: "Warning",
+}
+
+// LabelLanguages lists the languages with built-in admonition labels, by
+// ISO 639-1 code: the languages DetectLanguage recognizes.
+var LabelLanguages = []string{"en", "de", "fr", "es", "it", "nl", "pt"}
+
+// translatedAdmonitionLabels holds the built-in labels of the languages
+// other than English.
+var translatedAdmonitionLabels = map[string]map[AdmonitionType]string{
+	"de": {AdmonitionInfo: "Info", AdmonitionNote: "Hinweis", AdmonitionTip: "Tipp", AdmonitionSuccess: "Erfolg", AdmonitionWarning: "Warnung"},
+	"fr": {AdmonitionInfo: "Info", AdmonitionNote: "Remarque", AdmonitionTip: "Astuce", AdmonitionSuccess: "Succès", AdmonitionWarning: "Avertissement"},
+	"es": {AdmonitionInfo: "Información", AdmonitionNote: "Nota", AdmonitionTip: "Consejo", AdmonitionSuccess: "Éxito", AdmonitionWarning: "Advertencia"},
+	"it": {AdmonitionInfo: "Info", AdmonitionNote: "Nota", AdmonitionTip: "Suggerimento", AdmonitionSuccess: "Successo", AdmonitionWarning: "Avviso"},
+	"nl": {AdmonitionInfo: "Info", AdmonitionNote: "Opmerking", AdmonitionTip: "Tip", AdmonitionSuccess: "Gelukt", AdmonitionWarning: "Waarschuwing"},
+	"pt": {AdmonitionInfo: "Informação", AdmonitionNote: "Nota", AdmonitionTip: "Dica", AdmonitionSuccess: "Sucesso", AdmonitionWarning: "Aviso"},
+}
+
+// admonitionMacroPattern matches the opening tag of the information
+// macros, capturing the class suffix (see admonitionMacroClasses).
+var admonitionMacroPattern = regexp.MustCompile(`<div class="confluence-information-macro confluence-information-macro-(tip|note|warning|information|success)"[^>]*>\s*`)
+
+// LocalizedAdmonitionLabels returns the built-in admonition labels for a
+// language of LabelLanguages, for Options.AdmonitionLabels.
+func LocalizedAdmonitionLabels(lang string) (map[AdmonitionType]string, error) {
+	labels := translatedAdmonitionLabels[lang]
+	if labels == nil {
+		if lang != "en" {
+			return nil, fmt.Errorf("no admonition labels for language %q (valid: %s)", lang, strings.Join(LabelLanguages, ", "))
+		}
+		labels = DefaultAdmonitionLabels
+	}
+	copied := make(map[AdmonitionType]string, len(labels))
+	for typ, label := range labels {
+		copied[typ] = label
+	}
+	return copied, nil
+}
+
+// ValidateAdmonitionLabels reports whether a label table names known
+// admonition types and has no empty labels.
+func ValidateAdmonitionLabels(labels map[AdmonitionType]string) error {
+	for typ, label := range labels {
+		if admonitionMacroClasses[typ] == "" {
+			return fmt.Errorf("admonition labels: unknown admonition type %q", typ)
+		}
+		if strings.TrimSpace(label) == "" {
+			return fmt.Errorf("admonition labels: empty label for %s", typ)
+		}
+	}
+	return nil
+}
+
+// admonitionLabel returns the label for the information macro with the
+// given class suffix: the one in labels, or the English default.
+func admonitionLabel(class string, labels map[AdmonitionType]string) string {
+	typ := admonitionTypeForClass(class)
+	if label := labels[typ]; label != "" {
+		return label
+	}
+	return DefaultAdmonitionLabels[typ]
+}
+
+// replaceAdmonitionMacros turns the opening tags of information macros
+// left by pandoc into a blockquote starting with the macro's label.
+func replaceAdmonitionMacros(md string, labels map[AdmonitionType]string) string {
+	return admonitionMacroPattern.ReplaceAllStringFunc(md, func(tag string) string {
+		class := admonitionMacroPattern.FindStringSubmatch(tag)[1]
+		return "\n> **" + admonitionLabel(class, labels) + ":** "
+	})
+}
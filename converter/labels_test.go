@@ -0,0 +1,104 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReplaceAdmonitionMacros(t *testing.T) {
+	german, err := LocalizedAdmonitionLabels("de")
+	if err != nil {
+		t.Fatalf("LocalizedAdmonitionLabels() error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		labels map[AdmonitionType]string
+		md     string
+		want   string
+	}{
+		{
+			name: "default labels",
+			md:   `<div class="confluence-information-macro confluence-information-macro-tip">Save often`,
+			want: "\n> **Tip:** Save often",
+		},
+		{
+			name:   "German labels",
+			labels: german,
+			md:     `<div class="confluence-information-macro confluence-information-macro-note">Text`,
+			want:   "\n> **Hinweis:** Text",
+		},
+		{
+			name:   "missing label falls back to English",
+			labels: map[AdmonitionType]string{AdmonitionTip: "Astuce"},
+			md:     `<div class="confluence-information-macro confluence-information-macro-warning">Text`,
+			want:   "\n> **Warning:** Text",
+		},
+		{
+			name:   "info macro",
+			labels: map[AdmonitionType]string{AdmonitionInfo: "Información"},
+			md:     `<div class="confluence-information-macro confluence-information-macro-information">Text`,
+			want:   "\n> **Información:** Text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replaceAdmonitionMacros(tt.md, tt.labels); got != tt.want {
+				t.Errorf("replaceAdmonitionMacros() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalizedAdmonitionLabels(t *testing.T) {
+	for _, lang := range LabelLanguages {
+		labels, err := LocalizedAdmonitionLabels(lang)
+		if err != nil {
+			t.Errorf("LocalizedAdmonitionLabels(%q) error = %v", lang, err)
+			continue
+		}
+		if len(labels) != len(DefaultAdmonitionLabels) {
+			t.Errorf("LocalizedAdmonitionLabels(%q) has %d labels, want %d", lang, len(labels), len(DefaultAdmonitionLabels))
+		}
+		if err := ValidateAdmonitionLabels(labels); err != nil {
+			t.Errorf("LocalizedAdmonitionLabels(%q) are invalid: %v", lang, err)
+		}
+	}
+
+	labels, _ := LocalizedAdmonitionLabels("en")
+	labels[AdmonitionTip] = "Hint"
+	if DefaultAdmonitionLabels[AdmonitionTip] != "Tip" {
+		t.Error("changing the returned labels changed DefaultAdmonitionLabels")
+	}
+	if _, err := LocalizedAdmonitionLabels("xx"); err == nil {
+		t.Error("LocalizedAdmonitionLabels(\"xx\") succeeded")
+	}
+}
+
+func TestValidateAdmonitionLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[AdmonitionType]string
+		wantErr bool
+	}{
+		{"valid", map[AdmonitionType]string{AdmonitionNote: "Hinweis"}, false},
+		{"unknown type", map[AdmonitionType]string{"danger": "Gefahr"}, true},
+		{"none", map[AdmonitionType]string{AdmonitionNone: "Nothing"}, true},
+		{"empty label", map[AdmonitionType]string{AdmonitionTip: " "}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateAdmonitionLabels(tt.labels); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAdmonitionLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPreparePlainHTML_Labels(t *testing.T) {
+	html := `<div class="confluence-information-macro confluence-information-macro-warning"><p>Hot</p></div>`
+	got := preparePlainHTML(html, map[AdmonitionType]string{AdmonitionWarning: "Avertissement"})
+	if !strings.Contains(got, "<p>Avertissement:</p>") {
+		t.Errorf("preparePlainHTML() = %q, want the French label", got)
+	}
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// minLanguageHits is the number of stop words a text must contain before
+// its language is reported. Shorter texts are too ambiguous to classify.
+const minLanguageHits = 5
+
+// languageStopWords lists frequent function words for each language that
+// DetectLanguage recognizes, keyed by ISO 639-1 code. Words shared between
+// languages count for each of them; the distinctive ones decide.
+var languageStopWords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "as", "was", "on", "are", "this", "be", "by", "not", "or", "from", "have", "you", "which", "can"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "den", "ein", "eine", "zu", "von", "sich", "auf", "für", "dem", "des", "wird", "werden", "auch", "im", "sind", "oder", "kann"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "du", "que", "dans", "pour", "pas", "sur", "qui", "au", "avec", "ce", "sont", "il", "de", "être", "peut"},
+	"es": {"el", "la", "los", "las", "y", "que", "de", "en", "es", "un", "una", "por", "con", "para", "del", "se", "no", "al", "como", "más", "está", "puede"},
+	"it": {"il", "di", "che", "e", "la", "per", "un", "una", "non", "sono", "del", "della", "con", "è", "gli", "le", "si", "da", "al", "anche", "questo", "può"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "met", "voor", "zijn", "er", "aan", "ook", "wordt", "naar", "bij", "deze", "kan"},
+	"pt": {"o", "os", "a", "as", "de", "que", "e", "do", "da", "em", "um", "uma", "para", "com", "não", "por", "mais", "dos", "das", "é", "se", "são", "pode"},
+}
+
+// languageLookup maps each stop word to the languages it belongs to.
+var languageLookup = buildLanguageLookup()
+
+func buildLanguageLookup() map[string][]string {
+	lookup := make(map[string][]string)
+	for lang, words := range languageStopWords {
+		for _, word := range words {
+			lookup[word] = append(lookup[word], lang)
+		}
+	}
+	return lookup
+}
+
+var (
+	// fencedCodePattern matches fenced code blocks, which are excluded
+	// from language detection.
+	fencedCodePattern = regexp.MustCompile("(?ms)^[ \t]*(```|~~~).*?^[ \t]*(```|~~~)[ \t]*$")
+
+	// inlineCodePattern matches inline code spans.
+	inlineCodePattern = regexp.MustCompile("`[^`\n]+`")
+
+	// urlPattern matches URLs, whose path segments are not prose.
+	urlPattern = regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://\S+`)
+)
+
+// DetectLanguage guesses the natural language of Markdown text from the
+// frequency of common function words, skipping code and URLs. It returns
+// an ISO 639-1 code (en, de, fr, es, it, nl, or pt), or an empty string
+// when the text is too short or too mixed to tell.
+func DetectLanguage(md string) string {
+	text := fencedCodePattern.ReplaceAllString(md, "")
+	text = inlineCodePattern.ReplaceAllString(text, "")
+	text = urlPattern.ReplaceAllString(text, "")
+
+	scores := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		for _, lang := range languageLookup[word] {
+			scores[lang]++
+		}
+	}
+
+	best, bestScore, secondScore := "", 0, 0
+	for lang, score := range scores {
+		if score > bestScore {
+			best, bestScore, secondScore = lang, score, bestScore
+		} else if score > secondScore {
+			secondScore = score
+		}
+	}
+	if bestScore < minLanguageHits || bestScore == secondScore {
+		return ""
+	}
+	return best
+}
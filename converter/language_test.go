@@ -0,0 +1,91 @@
+package converter
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{
+			"english",
+			"# Deployment\n\nThe service is deployed by the pipeline. It runs the tests and publishes the image to the registry, which is used for all environments.",
+			"en",
+		},
+		{
+			"german",
+			"# Bereitstellung\n\nDer Dienst wird von der Pipeline bereitgestellt. Sie führt die Tests aus und veröffentlicht das Image in der Registry, die für alle Umgebungen verwendet wird.",
+			"de",
+		},
+		{
+			"french",
+			"# Déploiement\n\nLe service est déployé par le pipeline. Il exécute les tests et publie l'image dans le registre, qui est utilisé pour tous les environnements.",
+			"fr",
+		},
+		{
+			"spanish",
+			"# Despliegue\n\nEl servicio se despliega con el pipeline. Ejecuta las pruebas y publica la imagen en el registro, que se usa para todos los entornos del equipo.",
+			"es",
+		},
+		{
+			"italian",
+			"# Distribuzione\n\nIl servizio è distribuito dalla pipeline. Esegue i test e pubblica l'immagine nel registro, che è usato per tutti gli ambienti della squadra e anche per questo progetto.",
+			"it",
+		},
+		{
+			"dutch",
+			"# Uitrol\n\nDe dienst wordt door de pipeline uitgerold. Het voert de tests uit en publiceert het image naar het register, dat voor alle omgevingen wordt gebruikt.",
+			"nl",
+		},
+		{
+			"portuguese",
+			"# Implantação\n\nO serviço é implantado pelo pipeline. Ele executa os testes e publica a imagem no registro, que é usado para todos os ambientes da equipe e não para os outros.",
+			"pt",
+		},
+		{
+			"too short",
+			"The end.",
+			"",
+		},
+		{
+			"code is ignored",
+			"Der Dienst wird gestartet.\n\n```\nthe and of to is in that it for with as was on are this\n```\n\nDie Konfiguration ist in der Datei und wird von dem Dienst gelesen.",
+			"de",
+		},
+		{
+			"empty",
+			"",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectLanguage(tt.md); got != tt.want {
+				t.Errorf("DetectLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyOptions_DetectLanguage(t *testing.T) {
+	md := "Der Dienst wird von der Pipeline gestartet und ist für alle Umgebungen mit dem Image verfügbar.\n"
+	fields := make([]FrontMatterField, 1, 4)
+	fields[0] = FrontMatterField{Key: "title", Value: "Seite"}
+	opts := Options{DetectLanguage: true, FrontMatter: fields}
+
+	got := runMarkdownSteps(t, md, opts)
+	want := "---\ntitle: \"Seite\"\nlang: \"de\"\n---\n\n" + md
+	if got != want {
+		t.Errorf("runMarkdownSteps() =\n%q\nwant\n%q", got, want)
+	}
+	if extra := fields[:2][1]; extra.Key != "" {
+		t.Errorf("runMarkdownSteps wrote into the caller's FrontMatter array: %+v", extra)
+	}
+
+	opts.DetectLanguage = false
+	if got := runMarkdownSteps(t, md, opts); got != "---\ntitle: \"Seite\"\n---\n\n"+md {
+		t.Errorf("runMarkdownSteps() without detection = %q", got)
+	}
+}
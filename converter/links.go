@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	// markdownLinkTargetPattern matches inline Markdown links and images,
+	// capturing the optional image marker, link text, target, and title.
+	//
+	// Pattern breakdown:
+	// (!?)                 - Capture "!" for images
+	// \[([^\]]*)\]         - Capture link text
+	// \(([^)\s]+)          - Capture link target
+	// ((?:\s+"[^"]*")?)\)  - Capture optional title
+	markdownLinkTargetPattern = regexp.MustCompile(`(!?)\[([^\]]*)\]\(([^)\s]+)((?:\s+"[^"]*")?)\)`)
+
+	// attachmentPathPattern matches Confluence attachment download paths,
+	// capturing the page ID and file name.
+	attachmentPathPattern = regexp.MustCompile(`/download/attachments/(\d+)/([^?#/]+)`)
+
+	// spaceKeyPattern captures the space key from Confluence page URLs
+	// (/display/KEY/..., /spaces/KEY/..., /wiki/spaces/KEY/...).
+	spaceKeyPattern = regexp.MustCompile(`/(?:display|spaces)/([A-Za-z0-9_~-]+)(?:/|$)`)
+)
+
+// LinkMapping maps server-relative Confluence links to the base URL of the
+// instance serving them. A mapping matches links to pages in Space, or links
+// whose target starts with Prefix.
+type LinkMapping struct {
+	Space   string `json:"space,omitempty"`
+	Prefix  string `json:"prefix,omitempty"`
+	BaseURL string `json:"baseURL"`
+}
+
+// Validate reports whether the mapping is usable.
+func (m LinkMapping) Validate() error {
+	if m.BaseURL == "" {
+		return fmt.Errorf("link mapping is missing baseURL")
+	}
+	if (m.Space == "") == (m.Prefix == "") {
+		return fmt.Errorf("link mapping for %s must set exactly one of space or prefix", m.BaseURL)
+	}
+	return nil
+}
+
+// matches reports whether the mapping applies to a link target.
+func (m LinkMapping) matches(target string) bool {
+	if m.Prefix != "" {
+		return strings.HasPrefix(target, m.Prefix)
+	}
+	key := spaceKeyPattern.FindStringSubmatch(target)
+	return key != nil && strings.EqualFold(key[1], m.Space)
+}
+
+// rewriteLinks rewrites non-image links that cannot be resolved outside
+// Confluence. Links to attachments with a local copy in attachmentPaths (keyed
+// by file name) point at that copy. Remaining server-relative links are
+// prefixed with the base URL of the first matching mapping, falling back to
+// baseURL when one is given.
+func rewriteLinks(md string, baseURL string, mappings []LinkMapping, attachmentPaths map[string]string) string {
+	if baseURL == "" && len(mappings) == 0 && len(attachmentPaths) == 0 {
+		return md
+	}
+
+	return protectCode(md, func(md string) string {
+		return rewriteLinkTargets(md, baseURL, mappings, attachmentPaths)
+	})
+}
+
+// rewriteLinkTargets performs the rewriteLinks replacements on Markdown
+// whose code has been masked.
+func rewriteLinkTargets(md string, baseURL string, mappings []LinkMapping, attachmentPaths map[string]string) string {
+	return markdownLinkTargetPattern.ReplaceAllStringFunc(md, func(match string) string {
+		m := markdownLinkTargetPattern.FindStringSubmatch(match)
+		isImage, text, target, title := m[1] == "!", m[2], m[3], m[4]
+		if isImage {
+			return match
+		}
+
+		if attachment := attachmentPathPattern.FindStringSubmatch(target); attachment != nil {
+			name := attachment[2]
+			if decoded, err := url.PathUnescape(name); err == nil {
+				name = decoded
+			}
+			if local, ok := attachmentPaths[name]; ok {
+				return "[" + text + "](" + escapeLinkTarget(local) + title + ")"
+			}
+		}
+
+		// Only server-relative links need a base URL; protocol-relative
+		// links (//host/path) already name their host.
+		if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") {
+			return match
+		}
+		base := baseURL
+		for _, mapping := range mappings {
+			if mapping.matches(target) {
+				base = mapping.BaseURL
+				break
+			}
+		}
+		if base == "" {
+			return match
+		}
+		return "[" + text + "](" + strings.TrimRight(base, "/") + target + title + ")"
+	})
+}
+
+// rewriteImagePaths points Markdown images and raw <img> tags whose source
+// has a local copy in imagePaths at that copy. Images inside code are left
+// alone.
+func rewriteImagePaths(md string, imagePaths map[string]string) string {
+	if len(imagePaths) == 0 {
+		return md
+	}
+
+	return protectCode(md, func(md string) string {
+		md = markdownLinkTargetPattern.ReplaceAllStringFunc(md, func(match string) string {
+			m := markdownLinkTargetPattern.FindStringSubmatch(match)
+			local, ok := localImage(m[3], imagePaths)
+			if m[1] != "!" || !ok {
+				return match
+			}
+			return "![" + m[2] + "](" + escapeLinkTarget(local) + m[4] + ")"
+		})
+		return htmlImagePattern.ReplaceAllStringFunc(md, func(tag string) string {
+			src := imageSrcPattern.FindStringSubmatch(tag)
+			if src == nil {
+				return tag
+			}
+			local, ok := localImage(html.UnescapeString(src[1]), imagePaths)
+			if !ok {
+				return tag
+			}
+			return strings.Replace(tag, src[0], ` src="`+html.EscapeString(escapeLinkTarget(local))+`"`, 1)
+		})
+	})
+}
+
+// localImage looks up the local copy of the image at src, which pandoc may
+// have percent-encoded.
+func localImage(src string, imagePaths map[string]string) (string, bool) {
+	if local, ok := imagePaths[src]; ok {
+		return local, true
+	}
+	if unescaped, err := url.PathUnescape(src); err == nil {
+		if local, ok := imagePaths[unescaped]; ok {
+			return local, true
+		}
+	}
+	return "", false
+}
+
+// Link is an inline link or image found in converted Markdown.
+type Link struct {
+	Target string
+	Image  bool
+}
+
+// IsAttachment reports whether the link points at a Confluence attachment
+// download path.
+func (l Link) IsAttachment() bool {
+	return attachmentPathPattern.MatchString(l.Target)
+}
+
+// MarkdownLinks returns the inline links and images in md, including raw
+// <img> tags. Links inside code are ignored.
+func MarkdownLinks(md string) []Link {
+	var links []Link
+	protectCode(md, func(masked string) string {
+		for _, m := range markdownLinkTargetPattern.FindAllStringSubmatch(masked, -1) {
+			links = append(links, Link{Target: m[3], Image: m[1] == "!"})
+		}
+		for _, tag := range htmlImagePattern.FindAllString(masked, -1) {
+			if src := imageSrcPattern.FindStringSubmatch(tag); src != nil {
+				links = append(links, Link{Target: src[1], Image: true})
+			}
+		}
+		return masked
+	})
+	return links
+}
+
+// escapeLinkTarget makes a local file path safe to use as a Markdown link
+// target by percent-encoding spaces and other special characters.
+func escapeLinkTarget(p string) string {
+	segments := strings.Split(path.Clean(strings.ReplaceAll(p, "\\", "/")), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
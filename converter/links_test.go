@@ -0,0 +1,213 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRewriteLinks_Attachments(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		baseURL     string
+		attachments map[string]string
+		expected    string
+	}{
+		{
+			name:     "no base URL or attachments",
+			input:    "[spec.pdf](/download/attachments/123/spec.pdf?version=1)",
+			expected: "[spec.pdf](/download/attachments/123/spec.pdf?version=1)",
+		},
+		{
+			name:     "relative link gets base URL",
+			input:    "See [spec.pdf](/download/attachments/123/spec.pdf?version=1&api=v2).",
+			baseURL:  "https://wiki.example.com/",
+			expected: "See [spec.pdf](https://wiki.example.com/download/attachments/123/spec.pdf?version=1&api=v2).",
+		},
+		{
+			name:     "absolute link left alone without local copy",
+			input:    "[data](https://wiki.example.com/download/attachments/123/data.xlsx)",
+			baseURL:  "https://other.example.com",
+			expected: "[data](https://wiki.example.com/download/attachments/123/data.xlsx)",
+		},
+		{
+			name:        "local copy preferred",
+			input:       "[Q3 data](https://wiki.example.com/download/attachments/123/Q3%20data.xlsx?version=2)",
+			baseURL:     "https://wiki.example.com",
+			attachments: map[string]string{"Q3 data.xlsx": "assets/Q3 data.xlsx"},
+			expected:    "[Q3 data](assets/Q3%20data.xlsx)",
+		},
+		{
+			name:     "images untouched",
+			input:    "![diagram](/download/attachments/123/diagram.png)",
+			baseURL:  "https://wiki.example.com",
+			expected: "![diagram](/download/attachments/123/diagram.png)",
+		},
+		{
+			name:     "anchor links untouched",
+			input:    "[section](#setup)",
+			baseURL:  "https://wiki.example.com",
+			expected: "[section](#setup)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rewriteLinks(tt.input, tt.baseURL, nil, tt.attachments)
+			if got != tt.expected {
+				t.Errorf("rewriteLinks() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRewriteImagePaths(t *testing.T) {
+	paths := map[string]string{
+		"https://wiki.example.com/download/attachments/1/diagram v2.png?version=1&api=v2": "assets/diagram-v2.png",
+		"cid:photo@example": "assets/my photo.jpg",
+	}
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "markdown image",
+			input:    "![diagram](https://wiki.example.com/download/attachments/1/diagram%20v2.png?version=1&api=v2)",
+			expected: "![diagram](assets/diagram-v2.png)",
+		},
+		{
+			name:     "content ID",
+			input:    `![](cid:photo@example "Team")`,
+			expected: `![](assets/my%20photo.jpg "Team")`,
+		},
+		{
+			name:     "raw img tag",
+			input:    `<img src="https://wiki.example.com/download/attachments/1/diagram v2.png?version=1&amp;api=v2" width="200" />`,
+			expected: `<img src="assets/diagram-v2.png" width="200" />`,
+		},
+		{
+			name:     "links untouched",
+			input:    "[photo](cid:photo@example)",
+			expected: "[photo](cid:photo@example)",
+		},
+		{
+			name:     "images without a copy untouched",
+			input:    "![logo](https://wiki.example.com/logo.png)",
+			expected: "![logo](https://wiki.example.com/logo.png)",
+		},
+		{
+			name:     "code untouched",
+			input:    "`![](cid:photo@example)`",
+			expected: "`![](cid:photo@example)`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewriteImagePaths(tt.input, paths); got != tt.expected {
+				t.Errorf("rewriteImagePaths() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRewriteLinks_Mappings(t *testing.T) {
+	mappings := []LinkMapping{
+		{Space: "ENG", BaseURL: "https://eng.example.com"},
+		{Prefix: "/legacy/", BaseURL: "https://old.example.com"},
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		baseURL  string
+		expected string
+	}{
+		{
+			name:     "space key from display URL",
+			input:    "[Design](/display/ENG/Design+Notes)",
+			expected: "[Design](https://eng.example.com/display/ENG/Design+Notes)",
+		},
+		{
+			name:     "space key from spaces URL",
+			input:    "[Design](/wiki/spaces/eng/pages/42/Design)",
+			expected: "[Design](https://eng.example.com/wiki/spaces/eng/pages/42/Design)",
+		},
+		{
+			name:     "URL prefix",
+			input:    "[Old](/legacy/pages/viewpage.action?pageId=7)",
+			expected: "[Old](https://old.example.com/legacy/pages/viewpage.action?pageId=7)",
+		},
+		{
+			name:     "unmapped link falls back to base URL",
+			input:    "[Home](/display/DOCS/Home)",
+			baseURL:  "https://docs.example.com",
+			expected: "[Home](https://docs.example.com/display/DOCS/Home)",
+		},
+		{
+			name:     "unmapped link without base URL untouched",
+			input:    "[Home](/display/DOCS/Home)",
+			expected: "[Home](/display/DOCS/Home)",
+		},
+		{
+			name:     "protocol-relative link untouched",
+			input:    "[CDN](//cdn.example.com/file.js)",
+			baseURL:  "https://docs.example.com",
+			expected: "[CDN](//cdn.example.com/file.js)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rewriteLinks(tt.input, tt.baseURL, mappings, nil)
+			if got != tt.expected {
+				t.Errorf("rewriteLinks() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLinkMapping_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping LinkMapping
+		wantErr bool
+	}{
+		{"space", LinkMapping{Space: "DOCS", BaseURL: "https://a"}, false},
+		{"prefix", LinkMapping{Prefix: "/wiki/", BaseURL: "https://a"}, false},
+		{"missing base URL", LinkMapping{Space: "DOCS"}, true},
+		{"neither space nor prefix", LinkMapping{BaseURL: "https://a"}, true},
+		{"both space and prefix", LinkMapping{Space: "DOCS", Prefix: "/wiki/", BaseURL: "https://a"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.mapping.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMarkdownLinks(t *testing.T) {
+	md := "See [the guide](guide.md#setup) and ![diagram](images/a.png).\n\n" +
+		"`[not](a-link.md)`\n\n" +
+		"```\n[also not](code.md)\n```\n\n" +
+		"[file](/download/attachments/123/spec.pdf) <img src=\"big.png\" alt=\"\" width=\"600\">\n"
+
+	want := []Link{
+		{Target: "guide.md#setup"},
+		{Target: "images/a.png", Image: true},
+		{Target: "/download/attachments/123/spec.pdf"},
+		{Target: "big.png", Image: true},
+	}
+	got := MarkdownLinks(md)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MarkdownLinks() = %+v, want %+v", got, want)
+	}
+
+	if !got[2].IsAttachment() || got[0].IsAttachment() {
+		t.Error("IsAttachment should only match Confluence attachment paths")
+	}
+}
@@ -0,0 +1,285 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultListIndent is the nested list indentation step used when none is
+// configured. CommonMark-based flavors (GFM, GitLab) accept two spaces.
+const defaultListIndent = 2
+
+// mkdocsListIndent is the nested list indentation step of Python-Markdown,
+// which MkDocs uses: it only nests items indented by four spaces.
+const mkdocsListIndent = 4
+
+// listItemPattern matches a list item line, capturing its indentation and
+// its marker including the spaces that follow it.
+var listItemPattern = regexp.MustCompile(`^([ \t]*)((?:[-*+]|\d{1,9}[.)])[ \t]+)\S`)
+
+// listItem records the original and normalized layout of an open list item.
+type listItem struct {
+	origIndent  int // column of the marker in the input
+	origContent int // column of the item's content in the input
+	newIndent   int // column of the marker in the output
+	newContent  int // column of the item's content in the output
+}
+
+// listIndentStep returns the nested list indentation step for the flavor.
+func (f Flavor) listIndentStep() int {
+	if f == FlavorMkDocs {
+		return mkdocsListIndent
+	}
+	return defaultListIndent
+}
+
+// listIndent returns the nested list indentation step of opts: ListIndent,
+// or the flavor's step when it is not set.
+func (opts Options) listIndent() int {
+	if opts.ListIndent > 0 {
+		return opts.ListIndent
+	}
+	return opts.Flavor.listIndentStep()
+}
+
+// normalizeListIndentation re-indents nested list items so that each level
+// is indented by step spaces relative to its parent, and shifts the
+// paragraphs and code blocks belonging to an item along with it. Children of
+// items with wider markers ("10. ") are indented to the parent's content
+// column instead, because CommonMark would not nest them otherwise.
+func normalizeListIndentation(md string, step int) string {
+	if step <= 0 {
+		step = defaultListIndent
+	}
+
+	lines := strings.Split(md, "\n")
+	var stack []listItem
+	inFence := false
+	fenceShift := 0
+
+	for i, line := range lines {
+		if inFence {
+			if fencePattern.MatchString(line) {
+				inFence = false
+			}
+			lines[i] = shiftLine(line, fenceShift)
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		indent := indentWidth(line)
+		if m := listItemPattern.FindStringSubmatch(line); m != nil && !fencePattern.MatchString(line) {
+			for len(stack) > 0 && stack[len(stack)-1].origIndent >= indent {
+				stack = stack[:len(stack)-1]
+			}
+			newIndent := 0
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				newIndent = parent.newIndent + max(step, parent.newContent-parent.newIndent)
+			}
+			marker := m[2]
+			stack = append(stack, listItem{
+				origIndent:  indent,
+				origContent: indent + len(marker),
+				newIndent:   newIndent,
+				newContent:  newIndent + len(marker),
+			})
+			lines[i] = strings.Repeat(" ", newIndent) + strings.TrimLeft(line, " \t")
+			continue
+		}
+
+		// Unindented text ends any open list
+		if indent == 0 {
+			stack = stack[:0]
+		}
+		for len(stack) > 0 && stack[len(stack)-1].origContent > indent && stack[len(stack)-1].origIndent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		shift := 0
+		if len(stack) > 0 {
+			item := stack[len(stack)-1]
+			shift = item.newContent - item.origContent
+			if indent < item.origContent {
+				// Under-indented continuation: align it with the content
+				shift = item.newContent - indent
+			}
+		}
+		lines[i] = shiftLine(line, shift)
+		if fencePattern.MatchString(line) {
+			inFence = true
+			fenceShift = shift
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indentWidth returns the width of a line's leading whitespace, counting a
+// tab as four columns.
+func indentWidth(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// shiftLine indents a line by shift columns, or removes up to -shift
+// columns of leading whitespace when shift is negative.
+func shiftLine(line string, shift int) string {
+	if shift == 0 || strings.TrimSpace(line) == "" {
+		return line
+	}
+	width := indentWidth(line)
+	newWidth := width + shift
+	if newWidth < 0 {
+		newWidth = 0
+	}
+	return strings.Repeat(" ", newWidth) + strings.TrimLeft(line, " \t")
+}
+
+// ListNumberingStyle selects how ordered list items are numbered.
+type ListNumberingStyle string
+
+const (
+	// ListNumberingSequential numbers items 1, 2, 3 (the default).
+	ListNumberingSequential ListNumberingStyle = "sequential"
+	// ListNumberingLazy numbers every item "1." and lets the renderer count.
+	// Lists continued after an interruption keep their start number.
+	ListNumberingLazy ListNumberingStyle = "lazy"
+)
+
+// ListNumberingStyles lists the supported list numbering styles.
+var ListNumberingStyles = []ListNumberingStyle{ListNumberingSequential, ListNumberingLazy}
+
+var (
+	// orderedItemPattern matches an ordered list item line, capturing its
+	// indentation, number, delimiter, and the rest of the line.
+	orderedItemPattern = regexp.MustCompile(`^([ \t]*)(\d{1,9})([.)])([ \t]+\S.*)$`)
+
+	// listGapPattern matches lines that may sit between two parts of an
+	// ordered list without ending it: images and their italic captions.
+	listGapPattern = regexp.MustCompile(`^[ \t]*(?:!\[[^\]]*\]\([^)]*\)|<img[^>]*>|\*[^*\s][^*]*\*|_[^_\s][^_]*_)[ \t]*$`)
+)
+
+// orderedList tracks an ordered list at one indentation level.
+type orderedList struct {
+	last        int  // number of the last item
+	open        bool // whether the list is uninterrupted so far
+	continuable bool // whether a list restarting at 1 continues this one
+	shift       int  // columns the current item's marker grew or shrank by
+}
+
+// repairListNumbering restores ordered list numbering that restarts at 1
+// after an interleaved code block or image, a side effect of stripping the
+// Confluence markup that kept the list together. Items are renumbered
+// sequentially, or all numbered "1." with ListNumberingLazy. Content lines
+// of an item are shifted when its marker changes width, so they stay inside
+// the item.
+func repairListNumbering(md string, style ListNumberingStyle) string {
+	lines := strings.Split(md, "\n")
+	lists := make(map[int]*orderedList)
+	inFence := false
+	fenceShift := 0
+
+	// contentShift returns the accumulated marker shift of the items whose
+	// content starts before the given column.
+	contentShift := func(indent int) int {
+		shift := 0
+		for listIndent, list := range lists {
+			if listIndent < indent {
+				shift += list.shift
+			}
+		}
+		return shift
+	}
+	// interrupt ends or suspends the lists at or beyond the given column.
+	interrupt := func(indent int, gap bool) {
+		for listIndent, list := range lists {
+			if listIndent < indent {
+				continue
+			}
+			if gap {
+				list.open = false
+				list.shift = 0
+			} else {
+				delete(lists, listIndent)
+			}
+		}
+	}
+
+	for i, line := range lines {
+		if inFence {
+			if fencePattern.MatchString(line) {
+				inFence = false
+			}
+			lines[i] = shiftLine(line, fenceShift)
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		indent := indentWidth(line)
+		if m := orderedItemPattern.FindStringSubmatch(line); m != nil {
+			// A new item restarts any lists nested in the previous one
+			for listIndent := range lists {
+				if listIndent > indent {
+					delete(lists, listIndent)
+				}
+			}
+
+			shift := contentShift(indent)
+			number, _ := strconv.Atoi(m[2])
+			list, ok := lists[indent]
+			display := number
+			switch {
+			case ok && list.open:
+				number = list.last + 1
+				display = number
+				if style == ListNumberingLazy {
+					display = 1
+				}
+			case ok && list.continuable && number == 1:
+				number = list.last + 1
+				display = number
+			default:
+				list = &orderedList{}
+				lists[indent] = list
+				if style == ListNumberingLazy && number == 1 {
+					display = 1
+				}
+			}
+
+			marker := strconv.Itoa(display) + m[3]
+			list.last = number
+			list.open = true
+			list.continuable = true
+			list.shift = len(marker) - len(m[2]+m[3])
+			lines[i] = shiftLine(m[1]+marker+m[4], shift)
+			continue
+		}
+
+		shift := contentShift(indent)
+		isFence := fencePattern.MatchString(line)
+		interrupt(indent, isFence || listGapPattern.MatchString(line))
+		lines[i] = shiftLine(line, shift)
+		if isFence {
+			inFence = true
+			fenceShift = shift
+		}
+	}
+	return strings.Join(lines, "\n")
+}
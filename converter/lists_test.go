@@ -0,0 +1,146 @@
+package converter
+
+import (
+	"testing"
+)
+
+func TestNormalizeListIndentation(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		step   int
+		expect string
+	}{
+		{
+			name:   "well-formed list unchanged",
+			input:  "- a\n  - b\n    - c\n- d",
+			step:   2,
+			expect: "- a\n  - b\n    - c\n- d",
+		},
+		{
+			name:   "inconsistent indentation",
+			input:  "- a\n     - b\n         - c\n     - d",
+			step:   2,
+			expect: "- a\n  - b\n    - c\n  - d",
+		},
+		{
+			name:   "four-space step",
+			input:  "- a\n  - b\n    - c",
+			step:   4,
+			expect: "- a\n    - b\n        - c",
+		},
+		{
+			name:   "ordered parent keeps content column",
+			input:  "1. a\n  - b\n10. c\n - d",
+			step:   2,
+			expect: "1. a\n   - b\n10. c\n    - d",
+		},
+		{
+			name:   "continuation paragraph follows item",
+			input:  "- a\n      - b\n\n        more b\n\n- c",
+			step:   2,
+			expect: "- a\n  - b\n\n    more b\n\n- c",
+		},
+		{
+			name:   "code block in item shifted with it",
+			input:  "- a\n      - b\n\n        ```\n        code\n          indented\n        ```",
+			step:   2,
+			expect: "- a\n  - b\n\n    ```\n    code\n      indented\n    ```",
+		},
+		{
+			name:   "text after list unchanged",
+			input:  "- a\n     - b\n\nParagraph\n\n    indented code",
+			step:   2,
+			expect: "- a\n  - b\n\nParagraph\n\n    indented code",
+		},
+		{
+			name:   "list-like lines in fence untouched",
+			input:  "```\n     - not a list\n```",
+			step:   2,
+			expect: "```\n     - not a list\n```",
+		},
+		{
+			name:   "zero step uses default",
+			input:  "- a\n    - b",
+			step:   0,
+			expect: "- a\n  - b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeListIndentation(tt.input, tt.step); got != tt.expect {
+				t.Errorf("normalizeListIndentation() =\n%q\nwant:\n%q", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestRepairListNumbering(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		style  ListNumberingStyle
+		expect string
+	}{
+		{
+			name:   "continues after code block",
+			input:  "1. a\n2. b\n\n```\ncode\n```\n\n1. c\n2. d",
+			expect: "1. a\n2. b\n\n```\ncode\n```\n\n3. c\n4. d",
+		},
+		{
+			name:   "continues after image and caption",
+			input:  "1. a\n\n![diagram](d.png)\n\n*Figure 1*\n\n1. b",
+			expect: "1. a\n\n![diagram](d.png)\n\n*Figure 1*\n\n2. b",
+		},
+		{
+			name:   "paragraph starts a new list",
+			input:  "1. a\n2. b\n\nSome text.\n\n1. c",
+			expect: "1. a\n2. b\n\nSome text.\n\n1. c",
+		},
+		{
+			name:   "heading starts a new list",
+			input:  "1. a\n\n## Next\n\n1. b",
+			expect: "1. a\n\n## Next\n\n1. b",
+		},
+		{
+			name:   "explicit start number kept",
+			input:  "1. a\n\n```\nx\n```\n\n5. b",
+			expect: "1. a\n\n```\nx\n```\n\n5. b",
+		},
+		{
+			name:   "nested list restarts under each parent",
+			input:  "1. a\n   1. x\n   2. y\n2. b\n   1. z",
+			expect: "1. a\n   1. x\n   2. y\n2. b\n   1. z",
+		},
+		{
+			name:   "content shifted when marker widens",
+			input:  "1. a\n2. b\n3. c\n4. d\n5. e\n6. f\n7. g\n8. h\n\n```\nx\n```\n\n1. i\n   - nested\n2. j",
+			expect: "1. a\n2. b\n3. c\n4. d\n5. e\n6. f\n7. g\n8. h\n\n```\nx\n```\n\n9. i\n   - nested\n10. j",
+		},
+		{
+			name:   "nested content after widened marker",
+			input:  "1. a\n\n![i](i.png)\n\n1. b\n2. c\n3. d\n4. e\n5. f\n6. g\n7. h\n8. i\n9. j\n   - nested",
+			expect: "1. a\n\n![i](i.png)\n\n2. b\n3. c\n4. d\n5. e\n6. f\n7. g\n8. h\n9. i\n10. j\n    - nested",
+		},
+		{
+			name:   "lazy numbering",
+			input:  "1. a\n2. b\n\n```\ncode\n```\n\n1. c\n2. d",
+			style:  ListNumberingLazy,
+			expect: "1. a\n1. b\n\n```\ncode\n```\n\n3. c\n1. d",
+		},
+		{
+			name:   "parenthesis delimiter",
+			input:  "1) a\n\n![i](i.png)\n\n1) b",
+			expect: "1) a\n\n![i](i.png)\n\n2) b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repairListNumbering(tt.input, tt.style); got != tt.expect {
+				t.Errorf("repairListNumbering() =\n%q\nwant:\n%q", got, tt.expect)
+			}
+		})
+	}
+}
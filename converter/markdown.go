@@ -4,13 +4,14 @@ package converter
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"os/exec"
+	"html"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/aqueeb/confluence2md/internal/pandoc"
 )
@@ -19,11 +20,13 @@ const (
 	// pandocTimeout is the maximum time allowed for pandoc conversion.
 	pandocTimeout = 2 * time.Minute
 
-	// maxASCIICodePoint is the maximum code point for ASCII characters.
-	// Used when decoding numeric HTML entities to only decode printable ASCII.
-	maxASCIICodePoint = 127
+	// DefaultTimeout is the conversion time limit used when Options.Timeout is zero.
+	DefaultTimeout = pandocTimeout
 )
 
+// ErrTimeout is returned (wrapped) when a conversion exceeds its time limit.
+var ErrTimeout = errors.New("conversion timed out")
+
 // htmlEntityMap maps HTML entities to their decoded characters.
 // Used for decoding double-encoded HTML from Confluence exports.
 var htmlEntityMap = map[string]string{
@@ -43,197 +46,550 @@ var htmlEntityMap = map[string]string{
 
 // CheckPandoc verifies that pandoc is available (embedded or in PATH).
 func CheckPandoc() error {
-	// First try to use embedded pandoc
-	if pandoc.IsEmbedded() {
-		_, err := pandoc.EnsureExtracted()
-		if err != nil {
-			return fmt.Errorf("failed to extract embedded pandoc: %w", err)
+	_, err := ResolveEngine(EngineAuto)
+	return err
+}
+
+// Options controls optional conversion behavior.
+// The zero value produces the default GitHub-flavored Markdown output.
+type Options struct {
+	// Flavor selects the Markdown dialect. The empty value means FlavorGFM.
+	Flavor Flavor
+
+	// NormalizeHeadingLevels compresses heading levels so none is skipped
+	// (H1 followed by H4 becomes H1 followed by H2).
+	NormalizeHeadingLevels bool
+
+	// NumberHeadings prefixes headings with hierarchical numbers (1., 1.1, 1.1.1).
+	NumberHeadings bool
+
+	// TOCDepth inserts a generated table of contents listing headings up to
+	// this many levels deep. Zero disables the table of contents.
+	TOCDepth int
+
+	// ImageCaptions selects how image captions are rendered.
+	// The empty value renders captions in italics below the image.
+	ImageCaptions CaptionStyle
+
+	// ImageSizes selects how image width/height hints are preserved.
+	// The empty value drops them.
+	ImageSizes ImageSizeStyle
+
+	// HardBreaks selects how <br> line breaks are written.
+	// The empty value means HardBreakBackslash.
+	HardBreaks HardBreakStyle
+
+	// ListIndent is the number of spaces each nested list level is indented
+	// by, in converted lists and the table of contents. Zero means the
+	// flavor's default (2, or 4 for FlavorMkDocs).
+	ListIndent int
+
+	// ListNumbering selects how ordered list items are numbered.
+	// The empty value means ListNumberingSequential.
+	ListNumbering ListNumberingStyle
+
+	// TableHeaders selects how tables without a header row are converted.
+	// The empty value means TableHeaderInfer.
+	TableHeaders TableHeaderStyle
+
+	// TableSort sorts table body rows by a column. The zero value keeps
+	// the export order.
+	TableSort TableSort
+
+	// Tables, when set, receives the text of every table and may replace
+	// a table with a link, e.g. to export tables as CSV files.
+	Tables TableFunc
+
+	// SingleCellTables selects how single-cell and empty layout tables are
+	// converted. The empty value means SingleCellUnwrap.
+	SingleCellTables SingleCellTableStyle
+
+	// TableCells selects how table cells holding lists or several
+	// paragraphs are converted. The empty value means TableCellFlatten.
+	TableCells TableCellStyle
+
+	// Emoticons overrides and extends the default emoticon table, mapping
+	// emoticon alt texts like "(smile)" and shortcodes like ":smile:" to
+	// emoji. Keys are checked with ValidateEmoticons.
+	Emoticons map[string]string
+
+	// Pipeline replaces the conversion steps, e.g. with DefaultPipeline
+	// adjusted by ConfigurePipeline. Nil means the default pipeline.
+	Pipeline []Transform
+
+	// Trace, when set, is told about every pipeline step that changed the
+	// document, to find the step responsible for mangled output.
+	Trace TraceFunc
+
+	// BlankLines controls how runs of blank lines are compacted.
+	BlankLines BlankLines
+
+	// NBSP selects how non-breaking spaces are handled. The empty value
+	// means NBSPKeep.
+	NBSP NBSPStyle
+
+	// EmoticonFallback selects how emoticons without a Unicode emoji are
+	// converted. The empty value means EmoticonShortname.
+	EmoticonFallback EmoticonFallback
+
+	// BaseURL is the Confluence server URL used to absolutize server-relative
+	// links, e.g. https://confluence.example.com.
+	BaseURL string
+
+	// LinkMappings override BaseURL for links to specific spaces or URL
+	// prefixes, for migrations spanning several Confluence instances.
+	LinkMappings []LinkMapping
+
+	// Target prepares the output for a static site generator.
+	// The empty value means TargetNone.
+	Target Target
+
+	// FrontMatter fields are emitted as a YAML front matter block at the
+	// top of the document.
+	FrontMatter []FrontMatterField
+
+	// PageIDs adds confluence_page_id and confluence_space front matter
+	// fields when the export names them (see ExtractPageInfo).
+	PageIDs bool
+
+	// DetectLanguage adds a lang front matter field with the detected
+	// content language (see DetectLanguage), when it can be told.
+	DetectLanguage bool
+
+	// AttachmentPaths maps attachment file names to local copies. Links to
+	// these attachments are rewritten to point at the local files.
+	AttachmentPaths map[string]string
+
+	// ImagePaths maps image sources, as the page's HTML refers to them, to
+	// local copies such as the files written by ExtractAttachments (see
+	// ImageLinks). Images with a local copy point at that copy.
+	ImagePaths map[string]string
+
+	// AttachmentsSection selects what happens to the "Attachments:"
+	// appendix of exported pages. The empty value means AttachmentsKeep.
+	AttachmentsSection AttachmentsSectionStyle
+
+	// PanelColors maps panel background colors to admonition types. Its
+	// entries take precedence over DefaultPanelColors.
+	PanelColors map[string]AdmonitionType
+
+	// AdmonitionLabels overrides the labels that introduce info, note, tip,
+	// success, and warning macros in Markdown and plain text, such as
+	// "Hinweis" for notes in German wikis (see LocalizedAdmonitionLabels).
+	// Types it leaves out keep DefaultAdmonitionLabels.
+	AdmonitionLabels map[AdmonitionType]string
+
+	// Prepend and Append are Markdown inserted at the start of the page,
+	// after any front matter, and at its end, such as a license header or
+	// a "migrated from Confluence" banner.
+	Prepend string
+	Append  string
+
+	// Replacements are user-defined regular expression rewrites applied
+	// in order at their stage. Markdown-stage replacements only apply to
+	// Markdown output.
+	Replacements []Replacement
+
+	// AltText, when set, is asked for alt text for every image that has
+	// none, such as most Confluence screenshots.
+	AltText AltTextFunc
+
+	// ExpandDetails renders expand macros inline under a bold title
+	// instead of as collapsible <details> elements.
+	ExpandDetails bool
+
+	// To selects the output format. The empty value means FormatMarkdown.
+	// The Markdown-specific options above are ignored for other formats.
+	To OutputFormat
+
+	// Template is the path of a pandoc template for the output format.
+	// Setting it (or TemplateText) produces a standalone document.
+	Template string
+
+	// TemplateText is an inline pandoc template, used when Template is empty.
+	TemplateText string
+
+	// ReferenceDoc is the path of a reference document whose styles are
+	// used for DOCX output instead of the built-in default.
+	ReferenceDoc string
+
+	// MinimizeTempFiles avoids per-conversion temporary files where pandoc
+	// allows: DOCX output is read from pandoc's standard output, and the
+	// default reference document is cached instead of written for every
+	// conversion. PDF output always goes through a temporary file.
+	MinimizeTempFiles bool
+
+	// Timeout limits how long pandoc may run for one conversion. Zero
+	// means the default of two minutes.
+	Timeout time.Duration
+
+	// Engine selects the pandoc used for conversion. The empty value means
+	// EngineAuto.
+	Engine Engine
+
+	// ReaderExtensions toggles extensions of pandoc's HTML reader, in
+	// pandoc's syntax: "-native_divs-native_spans" reads divs and spans as
+	// their content, "-raw_tex" leaves TeX-like text alone. Checked with
+	// ValidateReaderExtensions. The empty value keeps pandoc's defaults.
+	ReaderExtensions string
+
+	// KeepAttributes selects the classes and ids kept in Markdown output
+	// as pandoc attributes, "## Title {#id .class}", for renderers that
+	// read them. Selectors are ".class" or "#id", where * matches any
+	// characters (see ParseAttributeSelectors). Empty drops all classes
+	// and ids, as GitHub-flavored Markdown has no syntax for them.
+	KeepAttributes []string
+
+	// HeadingIDs selects how Confluence's heading IDs are kept in Markdown
+	// output, so that deep links to page sections keep working. The empty
+	// value means HeadingIDsNone.
+	HeadingIDs HeadingIDStyle
+
+	// Warn, when set, receives warnings about the conversion that do not
+	// stop it, such as a retry after pandoc returned empty output.
+	Warn func(message string)
+}
+
+// warn passes message to opts.Warn, if set.
+func (opts Options) warn(message string) {
+	if opts.Warn != nil {
+		opts.Warn(message)
+	}
+}
+
+// conversionContext returns a context bounded by the conversion timeout.
+func conversionContext(opts Options) (context.Context, context.CancelFunc) {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = pandocTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// pandocError wraps a pandoc failure, reporting ErrTimeout when the
+// context deadline was the cause. When pandoc reports the position of a
+// parse error, the offending lines of html are appended to the message.
+func pandocError(ctx context.Context, err error, html string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("pandoc: %w", ErrTimeout)
+	}
+	var pe *pandoc.PandocError
+	if errors.As(err, &pe) && pe.Line > 0 {
+		if snippet := sourceSnippet(html, pe.Line, pe.Column); snippet != "" {
+			return fmt.Errorf("pandoc conversion failed: %w\n%s", err, snippet)
 		}
-		return nil
 	}
+	return fmt.Errorf("pandoc conversion failed: %w", err)
+}
 
-	// Fallback to system pandoc
-	_, err := exec.LookPath("pandoc")
-	if err != nil {
-		return fmt.Errorf("pandoc not found in PATH. Please install pandoc: https://pandoc.org/installing.html")
+const (
+	// snippetContextLines is the number of lines shown before and after
+	// the line of a parse error.
+	snippetContextLines = 2
+
+	// snippetWidth is the number of characters shown of each line. Exports
+	// often put a whole page on one line, so long lines are cut to a window
+	// around the error column.
+	snippetWidth = 100
+)
+
+// sourceSnippet returns the lines of src around line, numbered, with the
+// error line marked and a caret under column. Line and column are 1-based,
+// as pandoc reports them; column 0 omits the caret. It returns an empty
+// string if line is out of range.
+func sourceSnippet(src string, line, column int) string {
+	lines := strings.Split(src, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	first := max(line-snippetContextLines, 1)
+	last := min(line+snippetContextLines, len(lines))
+	width := len(strconv.Itoa(last))
+
+	// All lines share the window so that they stay aligned
+	offset := 0
+	if column > snippetWidth {
+		offset = column - snippetWidth/2
+	}
+	prefix := ""
+	if offset > 0 {
+		prefix = "…"
+	}
+
+	var b strings.Builder
+	for n := first; n <= last; n++ {
+		text := []rune(lines[n-1])
+		if offset < len(text) {
+			text = text[offset:]
+		} else {
+			text = nil
+		}
+		suffix := ""
+		if len(text) > snippetWidth {
+			text, suffix = text[:snippetWidth], "…"
+		}
+
+		marker := " "
+		if n == line {
+			marker = ">"
+		}
+		fmt.Fprintf(&b, "%s %*d | %s%s%s\n", marker, width, n, prefix, string(text), suffix)
+		if n == line && column > 0 {
+			pad := column - 1 - offset + utf8.RuneCountInString(prefix)
+			fmt.Fprintf(&b, "  %*s | %s^\n", width, "", strings.Repeat(" ", pad))
+		}
 	}
-	return nil
+	return strings.TrimRight(b.String(), "\n")
 }
 
 // ConvertHTMLToMarkdown converts HTML content to Markdown using pandoc and applies post-processing.
 func ConvertHTMLToMarkdown(html string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), pandocTimeout)
+	return ConvertHTMLToMarkdownWithOptions(html, Options{})
+}
+
+// ConvertHTMLToMarkdownWithOptions converts HTML content to Markdown like
+// ConvertHTMLToMarkdown, applying the optional transformations in opts.
+// When opts.To selects another output format, the result is in that format.
+func ConvertHTMLToMarkdownWithOptions(html string, opts Options) (string, error) {
+	if opts.To.IsBinary() {
+		return "", fmt.Errorf("%s output is a binary format; use ConvertHTMLToDocument", opts.To)
+	}
+
+	ctx, cancel := conversionContext(opts)
 	defer cancel()
 
-	// Pre-process HTML to remove Confluence layout markup
-	html = preProcessHTML(html)
+	html, opts, err := prepareHTML(html, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.To == FormatJSON {
+		return runPandocFrom(ctx, opts.Engine, opts.reader(), html, opts.To.pandocWriter())
+	}
+
+	args, cleanup, err := templateArgs(opts)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
 
-	// Try embedded pandoc first
-	if pandoc.IsEmbedded() {
-		mdBytes, err := pandoc.Convert(ctx, []byte(html), "html", "gfm", "--wrap=none")
+	switch opts.To {
+	case FormatOrg:
+		org, err := runPandocText(ctx, opts, prepareOrgHTML(html), opts.To.pandocWriter(), args...)
 		if err != nil {
-			return "", fmt.Errorf("pandoc conversion failed: %w", err)
+			return "", err
 		}
-
-		markdown := postProcessMarkdown(string(mdBytes))
-		return markdown, nil
+		return restoreOrgMarkers(org), nil
+	case FormatPlain:
+		text, err := runPandocText(ctx, opts, preparePlainHTML(html, opts.AdmonitionLabels), opts.To.pandocWriter(), args...)
+		if err != nil {
+			return "", err
+		}
+		return cleanPlainText(text), nil
 	}
 
-	// Fallback to system pandoc using temp files
-	tmpHTML, err := os.CreateTemp("", "confluence-*.html")
+	md, err := runPandocText(ctx, opts, html, opts.writer(), args...)
 	if err != nil {
-		return "", fmt.Errorf("failed to create temp file: %w", err)
+		return "", err
 	}
-	defer os.Remove(tmpHTML.Name())
+	return runPipeline(opts.Pipeline, StageMarkdown, md, opts)
+}
 
-	if _, err := tmpHTML.WriteString(html); err != nil {
-		return "", fmt.Errorf("failed to write HTML to temp file: %w", err)
+// prepareHTML adds the page ID front matter when opts asks for it and runs
+// the HTML steps of the pipeline. It returns the HTML for pandoc and the
+// options for the Markdown steps.
+func prepareHTML(html string, opts Options) (string, Options, error) {
+	if opts.PageIDs {
+		// Cap the capacity so the caller's backing array is never written
+		fields := opts.FrontMatter
+		opts.FrontMatter = append(fields[:len(fields):len(fields)], ExtractPageInfo(html).FrontMatter()...)
 	}
-	tmpHTML.Close()
 
-	// Create temp file for Markdown output
-	tmpMD, err := os.CreateTemp("", "confluence-*.md")
+	html, err := runPipeline(opts.Pipeline, StageHTML, html, opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to create temp file: %w", err)
-	}
-	defer os.Remove(tmpMD.Name())
-	tmpMD.Close()
-
-	// Run system pandoc
-	cmd := exec.Command("pandoc",
-		"-f", "html",
-		"-t", "gfm",
-		"--wrap=none",
-		tmpHTML.Name(),
-		"-o", tmpMD.Name(),
-	)
-
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("pandoc failed: %w\nOutput: %s", err, string(output))
+		return "", opts, err
 	}
+	return html, opts, nil
+}
 
-	// Read the converted markdown
-	mdBytes, err := os.ReadFile(tmpMD.Name())
+// runPandoc converts pre-processed HTML to the given pandoc output format
+// with the pandoc selected by engine, streaming the HTML on stdin and
+// reading the result from stdout. Extra arguments are passed on to pandoc.
+func runPandoc(ctx context.Context, engine Engine, html, to string, extraArgs ...string) (string, error) {
+	return runPandocFrom(ctx, engine, pandocReader, html, to, extraArgs...)
+}
+
+// runPandocFrom is runPandoc with the given pandoc reader, which may carry
+// extensions such as "html+raw_html".
+func runPandocFrom(ctx context.Context, engine Engine, reader, html, to string, extraArgs ...string) (string, error) {
+	path, err := pandocPath(engine)
 	if err != nil {
-		return "", fmt.Errorf("failed to read converted markdown: %w", err)
+		return "", err
 	}
 
-	markdown := postProcessMarkdown(string(mdBytes))
-	return markdown, nil
+	var out strings.Builder
+	out.Grow(len(html))
+	args := append([]string{"--wrap=none"}, extraArgs...)
+	if err := pandoc.ConvertStreamWith(ctx, path, strings.NewReader(html), &out, reader, to, args...); err != nil {
+		return "", pandocError(ctx, err, html)
+	}
+	return out.String(), nil
 }
 
 // decodeHTMLEntities decodes HTML entities that represent actual HTML tags.
 // Confluence exports sometimes double-encode HTML, resulting in &lt;p&gt; instead of <p>.
-func decodeHTMLEntities(html string) string {
+// Decoding covers every named entity and numeric character reference, so
+// text such as &eacute;, &mdash;, or &#x1F389; comes out as the intended
+// Unicode characters.
+func decodeHTMLEntities(content string) string {
 	// Check if content appears to be double-encoded (contains &lt; which represents <)
-	if !strings.Contains(html, "&lt;") && !strings.Contains(html, "&#") {
-		return html
+	if !strings.Contains(content, "&lt;") && !strings.Contains(content, "&#") {
+		return content
 	}
 
-	// Decode common HTML entities using the shared map
-	for entity, char := range htmlEntityMap {
-		html = strings.ReplaceAll(html, entity, char)
+	// Non-breaking spaces become plain spaces so they don't leak into the
+	// Markdown as invisible U+00A0 characters
+	content = strings.ReplaceAll(content, "&nbsp;", " ")
+
+	return html.UnescapeString(content)
+}
+
+// regexReplacement is a pattern and the template replacing its matches
+// ($1-style references allowed).
+type regexReplacement struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// applyReplacements applies each replacement to s in order.
+func applyReplacements(s string, replacements []regexReplacement) string {
+	for _, r := range replacements {
+		s = r.pattern.ReplaceAllString(s, r.replacement)
 	}
+	return s
+}
+
+// htmlRemovalPatterns match Confluence markup that preProcessHTML drops
+// before pandoc conversion. They are applied in order.
+var htmlRemovalPatterns = []*regexp.Regexp{
+	// Confluence page layout containers (these wrap content in columns)
+	regexp.MustCompile(`<div class="contentLayout2"[^>]*>`),
+	regexp.MustCompile(`<div class="columnLayout[^"]*"[^>]*>`),
+	regexp.MustCompile(`<div class="cell[^"]*"[^>]*>`),
+	regexp.MustCompile(`<div class="innerCell"[^>]*>`),
+	regexp.MustCompile(`<div class="sectionColumnWrapper"[^>]*>`),
+	regexp.MustCompile(`<div class="sectionMacro"[^>]*>`),
+	regexp.MustCompile(`<div class="sectionMacroRow"[^>]*>`),
+	regexp.MustCompile(`<div class="plugin_pagetree[^"]*"[^>]*>`),
+	regexp.MustCompile(`<div class="plugin_pagetree_children[^"]*"[^>]*>`),
+	regexp.MustCompile(`<div class="plugin-tabmeta-details"[^>]*>`),
+
+	// Confluence plugin elements (page tree, hidden fieldsets, etc.)
+	regexp.MustCompile(`<fieldset class="hidden"[^>]*>[\s\S]*?</fieldset>`),
+	regexp.MustCompile(`<input type="hidden"[^>]*>`),
+	regexp.MustCompile(`<ul[^>]*class="[^"]*plugin_pagetree[^"]*"[^>]*>[\s\S]*?</ul>`),
+
+	// Empty paragraphs and excessive breaks
+	regexp.MustCompile(`<p>\s*</p>`),
+	regexp.MustCompile(`<p>\s*<br\s*/?>\s*</p>`),
+	regexp.MustCompile(`<p[^>]*>\s*\\?<br\s*/?>\\?\s*</p>`),
+
+	// Style, data-*, tabindex, and draggable attributes that can cause issues
+	regexp.MustCompile(`\s+style="[^"]*"`),
+	regexp.MustCompile(`\s+data-[a-z-]+="[^"]*"`),
+	regexp.MustCompile(`\s+tabindex="[^"]*"`),
+	regexp.MustCompile(`\s+draggable="[^"]*"`),
+}
 
-	// Handle numeric HTML entities for common characters.
-	// These patterns decode character references like &#x3C; (hex) and &#60; (decimal).
-	// Only ASCII characters (< 127) are decoded to avoid issues with extended characters.
+var (
+	// confluenceImagePattern matches Confluence image tags so they can be
+	// reduced to simple img tags pandoc handles better.
 	//
-	// Hex format: &#xNN; where NN is a hexadecimal number
-	// Pattern breakdown: &#x captures literal prefix, ([0-9a-fA-F]+) captures hex digits, ; captures literal suffix
-	hexEntityPattern := regexp.MustCompile(`&#x([0-9a-fA-F]+);`)
-	html = hexEntityPattern.ReplaceAllStringFunc(html, func(match string) string {
-		submatches := hexEntityPattern.FindStringSubmatch(match)
-		if len(submatches) > 1 {
-			val, err := strconv.ParseInt(submatches[1], 16, 32)
-			if err == nil && val > 0 && val < maxASCIICodePoint {
-				return string(rune(val))
-			}
-		}
-		return match
-	})
+	// Pattern breakdown:
+	// <img[^>]*           - Match <img tag with any attributes before src
+	// \ssrc="([^"]*)"     - Capture src attribute value (required)
+	// [^>]*               - Match any attributes between src and alt
+	// (?:\salt="([^"]*)"|) - Optionally capture alt attribute value (non-capturing group with alternation)
+	// [^>]*>              - Match remaining attributes and closing >
+	confluenceImagePattern = regexp.MustCompile(`<img[^>]*\ssrc="([^"]*)"[^>]*(?:\salt="([^"]*)"|)[^>]*>`)
+
+	// srcAttributePattern and altAttributePattern capture the first src and
+	// alt attribute values of a tag.
+	srcAttributePattern = regexp.MustCompile(`src="([^"]*)"`)
+	altAttributePattern = regexp.MustCompile(`alt="([^"]*)"`)
+
+	// tableCellPattern matches a whole table cell; cellTagPattern and
+	// paragraphOpenPattern match the tags unwrapped from its content.
+	tableCellPattern     = regexp.MustCompile(`(<t[dh]>)([\s\S]*?)(</t[dh]>)`)
+	cellTagPattern       = regexp.MustCompile(`</?t[dh]>`)
+	paragraphOpenPattern = regexp.MustCompile(`<p[^>]*>`)
+)
 
-	// Decimal format: &#NNN; where NNN is a decimal number
-	// Pattern breakdown: &# captures literal prefix, (\d+) captures decimal digits, ; captures literal suffix
-	decEntityPattern := regexp.MustCompile(`&#(\d+);`)
-	html = decEntityPattern.ReplaceAllStringFunc(html, func(match string) string {
-		submatches := decEntityPattern.FindStringSubmatch(match)
-		if len(submatches) > 1 {
-			val, err := strconv.ParseInt(submatches[1], 10, 32)
-			if err == nil && val > 0 && val < maxASCIICodePoint {
-				return string(rune(val))
-			}
-		}
-		return match
-	})
+// tableCleanupReplacements simplify table markup so pandoc can convert it
+// to Markdown tables. They are applied in order.
+var tableCleanupReplacements = []regexReplacement{
+	// Remove colgroup/col elements (pandoc doesn't need them)
+	{regexp.MustCompile(`(?i)<colgroup[^>]*>[\s\S]*?</colgroup>`), ""},
+	{regexp.MustCompile(`(?i)<col[^>]*/?\s*>`), ""},
 
-	return html
+	// Remove class and scope attributes from table elements
+	{regexp.MustCompile(`(<(?:table|thead|tbody|tr|th|td)[^>]*)\s+class="[^"]*"`), "$1"},
+	{regexp.MustCompile(`(<(?:th|td)[^>]*)\s+scope="[^"]*"`), "$1"},
+
+	// Remove table-wrap divs
+	{regexp.MustCompile(`<div class="table-wrap"[^>]*>`), ""},
+
+	// Simplify any remaining attributes on table elements
+	{regexp.MustCompile(`<table[^>]*>`), "<table>"},
+	{regexp.MustCompile(`<thead[^>]*>`), "<thead>"},
+	{regexp.MustCompile(`<tbody[^>]*>`), "<tbody>"},
+	{regexp.MustCompile(`<tr[^>]*>`), "<tr>"},
+	{regexp.MustCompile(`<th[^>]*>`), "<th>"},
+	{regexp.MustCompile(`<td[^>]*>`), "<td>"},
+}
+
+// wrapperCleanupReplacements unwrap spans and content-wrapper divs, keeping
+// their content. They are applied in order.
+var wrapperCleanupReplacements = []regexReplacement{
+	// Remove span tags inside table cells (especially nolink spans)
+	{regexp.MustCompile(`<span[^>]*class="[^"]*nolink[^"]*"[^>]*>([\s\S]*?)</span>`), "$1"},
+	// Remove status-macro and aui-message spans (keep content)
+	{regexp.MustCompile(`<span[^>]*class="[^"]*(?:status-macro|aui-message|aui-lozenge)[^"]*"[^>]*>([\s\S]*?)</span>`), "$1"},
+	// Remove empty icon spans
+	{regexp.MustCompile(`<span[^>]*class="[^"]*icon[^"]*"[^>]*>\s*</span>`), ""},
+	// Remove remaining spans
+	{regexp.MustCompile(`<span[^>]*>([\s\S]*?)</span>`), "$1"},
+
+	// Remove content-wrapper divs (keep content)
+	{regexp.MustCompile(`<div[^>]*class="[^"]*content-wrapper[^"]*"[^>]*>([\s\S]*?)</div>`), "$1"},
 }
 
 // preProcessHTML removes Confluence layout markup before Pandoc conversion.
 // This ensures layout divs don't get escaped and pollute the output.
 func preProcessHTML(html string) string {
+	return preProcessHTMLCells(html, TableCellFlatten)
+}
+
+// preProcessHTMLCells is preProcessHTML with table cells holding block
+// content converted according to cells (see formatTableCells).
+func preProcessHTMLCells(html string, cells TableCellStyle) string {
 	// First, decode HTML entities that represent actual HTML tags
 	// Confluence sometimes double-encodes HTML, resulting in &lt;p&gt; instead of <p>
-	html = decodeHTMLEntities(html)
-
-	// Remove Confluence page layout containers (these wrap content in columns)
-	layoutPatterns := []string{
-		`<div class="contentLayout2"[^>]*>`,
-		`<div class="columnLayout[^"]*"[^>]*>`,
-		`<div class="cell[^"]*"[^>]*>`,
-		`<div class="innerCell"[^>]*>`,
-		`<div class="sectionColumnWrapper"[^>]*>`,
-		`<div class="sectionMacro"[^>]*>`,
-		`<div class="sectionMacroRow"[^>]*>`,
-		`<div class="plugin_pagetree[^"]*"[^>]*>`,
-		`<div class="plugin_pagetree_children[^"]*"[^>]*>`,
-		`<div class="plugin-tabmeta-details"[^>]*>`,
-	}
-	for _, pattern := range layoutPatterns {
-		html = regexp.MustCompile(pattern).ReplaceAllString(html, "")
-	}
+	html = fixDoubleEncoding(html)
 
-	// Remove Confluence plugin elements (page tree, hidden fieldsets, etc.)
-	pluginPatterns := []string{
-		`<fieldset class="hidden"[^>]*>[\s\S]*?</fieldset>`,
-		`<input type="hidden"[^>]*>`,
-		`<ul[^>]*class="[^"]*plugin_pagetree[^"]*"[^>]*>[\s\S]*?</ul>`,
-	}
-	for _, pattern := range pluginPatterns {
-		html = regexp.MustCompile(pattern).ReplaceAllString(html, "")
+	// Remove layout containers, plugin elements, empty paragraphs, and
+	// noisy attributes
+	for _, pattern := range htmlRemovalPatterns {
+		html = pattern.ReplaceAllString(html, "")
 	}
 
-	// Remove empty paragraphs and excessive breaks
-	html = regexp.MustCompile(`<p>\s*</p>`).ReplaceAllString(html, "")
-	html = regexp.MustCompile(`<p>\s*<br\s*/?>\s*</p>`).ReplaceAllString(html, "")
-	html = regexp.MustCompile(`<p[^>]*>\s*\\?<br\s*/?>\\?\s*</p>`).ReplaceAllString(html, "")
-
-	// Remove style attributes that can cause issues
-	html = regexp.MustCompile(`\s+style="[^"]*"`).ReplaceAllString(html, "")
-
-	// Remove data-* attributes
-	html = regexp.MustCompile(`\s+data-[a-z-]+="[^"]*"`).ReplaceAllString(html, "")
-
-	// Remove tabindex attributes
-	html = regexp.MustCompile(`\s+tabindex="[^"]*"`).ReplaceAllString(html, "")
-
-	// Remove draggable attributes
-	html = regexp.MustCompile(`\s+draggable="[^"]*"`).ReplaceAllString(html, "")
-
 	// Convert Confluence image tags to simple img tags pandoc can handle better.
-	// Extract src and alt attributes, discard all other attributes (data-*, class, etc.).
-	//
-	// Pattern breakdown:
-	// <img[^>]*           - Match <img tag with any attributes before src
-	// \ssrc="([^"]*)"     - Capture src attribute value (required)
-	// [^>]*               - Match any attributes between src and alt
-	// (?:\salt="([^"]*)"|) - Optionally capture alt attribute value (non-capturing group with alternation)
-	// [^>]*>              - Match remaining attributes and closing >
-	imgPattern := regexp.MustCompile(`<img[^>]*\ssrc="([^"]*)"[^>]*(?:\salt="([^"]*)"|)[^>]*>`)
-	html = imgPattern.ReplaceAllStringFunc(html, func(match string) string {
-		srcMatch := regexp.MustCompile(`src="([^"]*)"`).FindStringSubmatch(match)
-		altMatch := regexp.MustCompile(`alt="([^"]*)"`).FindStringSubmatch(match)
+	// Extract src and alt attributes plus any size hints, discard all other
+	// attributes (data-*, class, etc.). Size hints are removed later unless
+	// the caller asked to keep them (see applyImageSizes).
+	html = confluenceImagePattern.ReplaceAllStringFunc(html, func(match string) string {
+		srcMatch := srcAttributePattern.FindStringSubmatch(match)
+		altMatch := altAttributePattern.FindStringSubmatch(match)
 		src := ""
 		alt := ""
 		if len(srcMatch) > 1 {
@@ -245,222 +601,183 @@ func preProcessHTML(html string) string {
 		if src == "" {
 			return ""
 		}
-		return fmt.Sprintf(`<img src="%s" alt="%s">`, src, alt)
+		return fmt.Sprintf(`<img src="%s" alt="%s"%s>`, src, alt, imageSizeAttributes(match))
 	})
 
 	// Clean up table markup so pandoc can convert to markdown tables
-	// Remove colgroup/col elements (pandoc doesn't need them)
-	html = regexp.MustCompile(`(?i)<colgroup[^>]*>[\s\S]*?</colgroup>`).ReplaceAllString(html, "")
-	html = regexp.MustCompile(`(?i)<col[^>]*/?\s*>`).ReplaceAllString(html, "")
+	html = applyReplacements(html, tableCleanupReplacements)
 
-	// Remove class and scope attributes from table elements
-	html = regexp.MustCompile(`(<(?:table|thead|tbody|tr|th|td)[^>]*)\s+class="[^"]*"`).ReplaceAllString(html, "$1")
-	html = regexp.MustCompile(`(<(?:th|td)[^>]*)\s+scope="[^"]*"`).ReplaceAllString(html, "$1")
-
-	// Remove table-wrap divs
-	html = regexp.MustCompile(`<div class="table-wrap"[^>]*>`).ReplaceAllString(html, "")
+	html = formatTableCells(html, cells)
 
-	// Simplify any remaining attributes on table elements
-	html = regexp.MustCompile(`<table[^>]*>`).ReplaceAllString(html, "<table>")
-	html = regexp.MustCompile(`<thead[^>]*>`).ReplaceAllString(html, "<thead>")
-	html = regexp.MustCompile(`<tbody[^>]*>`).ReplaceAllString(html, "<tbody>")
-	html = regexp.MustCompile(`<tr[^>]*>`).ReplaceAllString(html, "<tr>")
-	html = regexp.MustCompile(`<th[^>]*>`).ReplaceAllString(html, "<th>")
-	html = regexp.MustCompile(`<td[^>]*>`).ReplaceAllString(html, "<td>")
-
-	// Remove <br> tags inside table cells (pandoc can't handle them and falls back to HTML)
-	// Match <td>...<br>...</td> and <th>...<br>...</th> and remove the br
-	html = regexp.MustCompile(`(<t[dh]>)([^<]*)<br\s*/?>([^<]*)(</t[dh]>)`).ReplaceAllString(html, "$1$2 $3$4")
-	// Handle cells that are just <br>
-	html = regexp.MustCompile(`<td>\s*<br\s*/?>\s*</td>`).ReplaceAllString(html, "<td></td>")
-	html = regexp.MustCompile(`<th>\s*<br\s*/?>\s*</th>`).ReplaceAllString(html, "<th></th>")
-
-	// Remove <p> tags inside table cells (unwrap content)
-	// First handle simple single-p cells
-	html = regexp.MustCompile(`(<t[dh]>)\s*<p>([^<]*)</p>\s*(</t[dh]>)`).ReplaceAllString(html, "$1$2$3")
-	// Handle multiple <p> tags in cells - convert to text with spaces
-	html = regexp.MustCompile(`(<t[dh]>)([\s\S]*?)(</t[dh]>)`).ReplaceAllStringFunc(html, func(match string) string {
-		// Remove <p> and </p> tags inside cells, replace with space
-		inner := regexp.MustCompile(`<t[dh]>`).ReplaceAllString(match, "")
-		inner = regexp.MustCompile(`</t[dh]>`).ReplaceAllString(inner, "")
-		inner = regexp.MustCompile(`<p[^>]*>`).ReplaceAllString(inner, "")
-		inner = regexp.MustCompile(`</p>`).ReplaceAllString(inner, " ")
-		inner = strings.TrimSpace(inner)
-		// Detect if it was th or td
-		if strings.HasPrefix(match, "<th") {
-			return "<th>" + inner + "</th>"
-		}
-		return "<td>" + inner + "</td>"
-	})
-
-	// Remove span tags inside table cells (especially nolink spans)
-	html = regexp.MustCompile(`<span[^>]*class="[^"]*nolink[^"]*"[^>]*>([\s\S]*?)</span>`).ReplaceAllString(html, "$1")
-	// Remove status-macro and aui-message spans (keep content)
-	html = regexp.MustCompile(`<span[^>]*class="[^"]*(?:status-macro|aui-message|aui-lozenge)[^"]*"[^>]*>([\s\S]*?)</span>`).ReplaceAllString(html, "$1")
-	// Remove empty icon spans
-	html = regexp.MustCompile(`<span[^>]*class="[^"]*icon[^"]*"[^>]*>\s*</span>`).ReplaceAllString(html, "")
-	// Remove remaining spans
-	html = regexp.MustCompile(`<span[^>]*>([\s\S]*?)</span>`).ReplaceAllString(html, "$1")
-
-	// Remove content-wrapper divs (keep content)
-	html = regexp.MustCompile(`<div[^>]*class="[^"]*content-wrapper[^"]*"[^>]*>([\s\S]*?)</div>`).ReplaceAllString(html, "$1")
+	html = applyReplacements(html, wrapperCleanupReplacements)
 
 	// Remove closing divs that match the layout containers we removed
 	// Count opens vs closes and balance
 	openCount := strings.Count(html, "<div")
 	closeCount := strings.Count(html, "</div>")
-	for closeCount > openCount {
-		html = strings.Replace(html, "</div>", "", 1)
-		closeCount--
+	if closeCount > openCount {
+		html = strings.Replace(html, "</div>", "", closeCount-openCount)
 	}
 
 	return html
 }
 
 // postProcessMarkdown cleans up Confluence-specific HTML artifacts from the converted Markdown.
+// Code blocks and inline code are left untouched.
 func postProcessMarkdown(md string) string {
-	// Replace emoji images with Unicode characters
-	emojiReplacements := map[string]string{
-		`(tick)`:        "✅ ",
-		`(error)`:       "❌ ",
-		`(blue star)`:   "🚧",
-		`(warning)`:     "⚠️ ",
-		`(info)`:        "ℹ️ ",
-		`(question)`:    "❓ ",
-		`(plus)`:        "➕ ",
-		`(minus)`:       "➖ ",
-		`(on)`:          "💡 ",
-		`(off)`:         "⭕ ",
-		`(star)`:        "⭐ ",
-		`(thumbs up)`:   "👍 ",
-		`(thumbs down)`: "👎 ",
-	}
+	return postProcessMarkdownSpacing(md, BlankLines{}, nil)
+}
 
-	// Match <img> tags with alt attributes containing emoticon names
-	imgPattern := regexp.MustCompile(`<img[^>]*alt="([^"]*)"[^>]*/?>`)
-	md = imgPattern.ReplaceAllStringFunc(md, func(match string) string {
-		submatches := imgPattern.FindStringSubmatch(match)
-		if len(submatches) > 1 {
-			alt := submatches[1]
-			if replacement, ok := emojiReplacements[alt]; ok {
-				return replacement
-			}
-		}
-		// Remove other img tags (like expand-control-image)
-		if strings.Contains(match, "expand-control-image") {
-			return ""
-		}
-		return match
+// postProcessMarkdownSpacing is postProcessMarkdown with runs of blank
+// lines compacted according to blank and admonitions introduced by labels
+// (see Options.AdmonitionLabels).
+func postProcessMarkdownSpacing(md string, blank BlankLines, labels map[AdmonitionType]string) string {
+	return protectCode(md, func(md string) string {
+		return cleanUpMarkdown(md, blank, labels)
 	})
+}
 
+// markdownImagePattern matches raw <img> tags left in converted Markdown,
+// capturing the alt text used to recognize emoticons.
+var markdownImagePattern = regexp.MustCompile(`<img[^>]*alt="([^"]*)"[^>]*/?>`)
+
+// macroCleanupReplacements turn Confluence macro wrappers (panels,
+// expanders, code panels) left by pandoc into Markdown. They are applied in
+// order, after replaceAdmonitionMacros.
+var macroCleanupReplacements = []regexReplacement{
 	// Clean up Section1 div wrapper
-	md = regexp.MustCompile(`<div class="Section1">\s*`).ReplaceAllString(md, "")
+	{regexp.MustCompile(`<div class="Section1">\s*`), ""},
 
 	// Remove Confluence table of contents wrapper but keep the content
-	md = regexp.MustCompile(`<div class="toc-macro[^"]*"[^>]*>\s*`).ReplaceAllString(md, "")
-
-	// Convert Confluence info/tip/warning/note macros to blockquotes
-	macroPatterns := []struct {
-		pattern     *regexp.Regexp
-		replacement string
-	}{
-		{
-			regexp.MustCompile(`<div class="confluence-information-macro confluence-information-macro-tip"[^>]*>\s*`),
-			"\n> **Tip:** ",
-		},
-		{
-			regexp.MustCompile(`<div class="confluence-information-macro confluence-information-macro-note"[^>]*>\s*`),
-			"\n> **Note:** ",
-		},
-		{
-			regexp.MustCompile(`<div class="confluence-information-macro confluence-information-macro-warning"[^>]*>\s*`),
-			"\n> **Warning:** ",
-		},
-		{
-			regexp.MustCompile(`<div class="confluence-information-macro confluence-information-macro-information"[^>]*>\s*`),
-			"\n> **Info:** ",
-		},
-	}
-
-	for _, mp := range macroPatterns {
-		md = mp.pattern.ReplaceAllString(md, mp.replacement)
-	}
+	{regexp.MustCompile(`<div class="toc-macro[^"]*"[^>]*>\s*`), ""},
 
 	// Remove aui-icon spans
-	md = regexp.MustCompile(`<span class="aui-icon[^"]*"[^>]*></span>\s*`).ReplaceAllString(md, "")
+	{regexp.MustCompile(`<span class="aui-icon[^"]*"[^>]*></span>\s*`), ""},
 
 	// Clean up confluence-information-macro-body divs
-	md = regexp.MustCompile(`<div class="confluence-information-macro-body">\s*`).ReplaceAllString(md, "")
+	{regexp.MustCompile(`<div class="confluence-information-macro-body">\s*`), ""},
 
 	// Convert panel divs to blockquotes
-	md = regexp.MustCompile(`<div class="panel"[^>]*>\s*`).ReplaceAllString(md, "\n> ")
-	md = regexp.MustCompile(`<div class="panelContent"[^>]*>\s*`).ReplaceAllString(md, "")
+	{regexp.MustCompile(`<div class="panel"[^>]*>\s*`), "\n> "},
+	{regexp.MustCompile(`<div class="panelContent"[^>]*>\s*`), ""},
 
 	// Handle expander/collapsible sections
-	md = regexp.MustCompile(`<div id="expander-\d+"[^>]*>\s*`).ReplaceAllString(md, "\n<details>\n")
-	md = regexp.MustCompile(`<div id="expander-control-\d+"[^>]*>\s*`).ReplaceAllString(md, "<summary>")
-	md = regexp.MustCompile(`<span class="expand-control-icon">[^<]*</span><span class="expand-control-text">([^<]*)</span>\s*`).ReplaceAllString(md, "$1")
-	md = regexp.MustCompile(`<span class="expand-control-text">([^<]*)</span>\s*`).ReplaceAllString(md, "$1")
-	md = regexp.MustCompile(`<span class="expand-control-icon">[^<]*</span>\s*`).ReplaceAllString(md, "")
-	md = regexp.MustCompile(`<div id="expander-content-\d+"[^>]*>\s*`).ReplaceAllString(md, "</summary>\n")
+	{regexp.MustCompile(`<div id="expander-\d+"[^>]*>\s*`), "\n<details>\n"},
+	{regexp.MustCompile(`<div id="expander-control-\d+"[^>]*>\s*`), "<summary>"},
+	{regexp.MustCompile(`<span class="expand-control-icon">[^<]*</span><span class="expand-control-text">([^<]*)</span>\s*`), "$1"},
+	{regexp.MustCompile(`<span class="expand-control-text">([^<]*)</span>\s*`), "$1"},
+	{regexp.MustCompile(`<span class="expand-control-icon">[^<]*</span>\s*`), ""},
+	{regexp.MustCompile(`<div id="expander-content-\d+"[^>]*>\s*`), "</summary>\n"},
 
 	// Fix nested details tags
-	md = regexp.MustCompile(`</summary>\s*\n\s*<details>\s*\n`).ReplaceAllString(md, "</summary>\n\n")
-	md = regexp.MustCompile(`<details>\s*\n\x60\x60\x60`).ReplaceAllString(md, "\n```")
+	{regexp.MustCompile(`</summary>\s*\n\s*<details>\s*\n`), "</summary>\n\n"},
+	{regexp.MustCompile(`<details>\s*\n\x60\x60\x60`), "\n```"},
 
 	// Clean up code panel divs and code headers
-	md = regexp.MustCompile(`<div class="code panel[^"]*"[^>]*>\s*`).ReplaceAllString(md, "")
-	md = regexp.MustCompile(`<div class="codeContent[^"]*"[^>]*>\s*`).ReplaceAllString(md, "")
-	md = regexp.MustCompile(`<div class="codeHeader[^"]*"[^>]*>\s*`).ReplaceAllString(md, "")
+	{regexp.MustCompile(`<div class="code panel[^"]*"[^>]*>\s*`), ""},
+	{regexp.MustCompile(`<div class="codeContent[^"]*"[^>]*>\s*`), ""},
+	{regexp.MustCompile(`<div class="codeHeader[^"]*"[^>]*>\s*`), ""},
+}
 
-	// Fix code block language hints
-	md = strings.ReplaceAll(md, "``` syntaxhighlighter-pre", "```")
-	md = regexp.MustCompile("```\\s*\\{[^}]*\\}").ReplaceAllString(md, "```")
+// tagCleanupReplacements convert or remove the HTML tags pandoc passed
+// through. They are applied in order.
+var tagCleanupReplacements = []regexReplacement{
+	// Drop attribute blocks on code fences ("``` {.java}")
+	{regexp.MustCompile("```\\s*\\{[^}]*\\}"), "```"},
 
 	// Convert remaining HTML links to Markdown
-	linkPattern := regexp.MustCompile(`<a\s+href="([^"]*)"[^>]*>([^<]*)</a>`)
-	md = linkPattern.ReplaceAllString(md, "[$2]($1)")
+	{regexp.MustCompile(`<a\s+href="([^"]*)"[^>]*>([^<]*)</a>`), "[$2]($1)"},
 
 	// Handle links with underline tags
-	linkUnderlinePattern := regexp.MustCompile(`<a\s+href="([^"]*)"[^>]*><u>([^<]*)</u></a>`)
-	md = linkUnderlinePattern.ReplaceAllString(md, "[$2]($1)")
+	{regexp.MustCompile(`<a\s+href="([^"]*)"[^>]*><u>([^<]*)</u></a>`), "[$2]($1)"},
 
 	// Remove underline tags
-	md = regexp.MustCompile(`</?u>`).ReplaceAllString(md, "")
+	{regexp.MustCompile(`</?u>`), ""},
 
 	// Clean up closing divs - try to match groups first
-	md = regexp.MustCompile(`</div>\s*</div>\s*</div>\s*`).ReplaceAllString(md, "\n</details>\n\n")
-	md = regexp.MustCompile(`</div>\s*</div>\s*`).ReplaceAllString(md, "\n\n")
-	md = regexp.MustCompile(`</div>`).ReplaceAllString(md, "")
+	{regexp.MustCompile(`</div>\s*</div>\s*</div>\s*`), "\n</details>\n\n"},
+	{regexp.MustCompile(`</div>\s*</div>\s*`), "\n\n"},
+	{regexp.MustCompile(`</div>`), ""},
 
 	// Remove any remaining span tags
-	md = regexp.MustCompile(`</?span[^>]*>`).ReplaceAllString(md, "")
+	{regexp.MustCompile(`</?span[^>]*>`), ""},
+}
+
+// escapedTagReplacements remove escaped HTML that pandoc didn't convert.
+// These appear as \<tag\> or \</tag\>.
+var escapedTagReplacements = []regexReplacement{
+	{regexp.MustCompile(`\\<br\\?/?>`), "\n"},
+	{regexp.MustCompile(`\\</?p\\?>`), "\n"},
+	{regexp.MustCompile(`\\</?div[^>]*\\?>`), ""},
+	{regexp.MustCompile(`\\</?span[^>]*\\?>`), ""},
+}
+
+// escapedImagePattern matches <img> tags pandoc escaped as \<img ...\>.
+//
+// Pattern breakdown:
+// \\<img           - Match escaped opening: \<img
+// [^>]*src="..."   - Capture src attribute
+// (?:alt="..."|)   - Optionally capture alt attribute
+// \\?>             - Match optional escaped closing: \> or just >
+var escapedImagePattern = regexp.MustCompile(`\\<img[^>]*src="([^"]*)"[^>]*(?:alt="([^"]*)"|)[^>]*\\?>`)
+
+// strayMarkupReplacements clean up the remaining escaped and raw HTML.
+// They are applied in order.
+var strayMarkupReplacements = []regexReplacement{
+	// Clean any remaining escaped tags
+	{regexp.MustCompile(`\\<[^>]*\\?>`), ""},
+
+	// Fix double-dash in nested lists (pandoc sometimes produces "- - item")
+	{regexp.MustCompile(`^(\s*)- - `), "$1  - "},
+	{regexp.MustCompile(`\n(\s*)- - `), "\n$1  - "},
+
+	// Remove any stray <br> tags
+	{regexp.MustCompile(`<br\s*/?>`), "\n"},
+	// Remove empty <div> tags
+	{regexp.MustCompile(`<div[^>]*>\s*</div>`), ""},
+	// Remove standalone closing </div> tags
+	{regexp.MustCompile(`</div>`), ""},
+}
+
+// cleanUpMarkdown performs the postProcessMarkdown replacements on Markdown
+// whose code has been masked.
+func cleanUpMarkdown(md string, blank BlankLines, labels map[AdmonitionType]string) string {
+	// Replace emoji images with Unicode characters
+	// Match <img> tags with alt attributes containing emoticon names
+	md = markdownImagePattern.ReplaceAllStringFunc(md, func(match string) string {
+		submatches := markdownImagePattern.FindStringSubmatch(match)
+		if len(submatches) > 1 {
+			alt := submatches[1]
+			if emoji, ok := defaultEmoticons[alt]; ok {
+				return emoji
+			}
+		}
+		// Remove other img tags (like expand-control-image)
+		if strings.Contains(match, "expand-control-image") {
+			return ""
+		}
+		return match
+	})
+
+	// Convert Confluence info/tip/warning/note macros to blockquotes
+	md = replaceAdmonitionMacros(md, labels)
+	md = applyReplacements(md, macroCleanupReplacements)
+
+	// Fix code block language hints, then convert or remove leftover tags
+	md = strings.ReplaceAll(md, "``` syntaxhighlighter-pre", "```")
+	md = applyReplacements(md, tagCleanupReplacements)
 
 	// Clean up HTML entities using the shared map
 	for entity, char := range htmlEntityMap {
 		md = strings.ReplaceAll(md, entity, char)
 	}
 
-	// Remove escaped HTML that pandoc didn't convert
-	// These appear as \<tag\> or \</tag\>
-	md = regexp.MustCompile(`\\<br\\?/?>`).ReplaceAllString(md, "\n")
-	md = regexp.MustCompile(`\\</?p\\?>`).ReplaceAllString(md, "\n")
-	md = regexp.MustCompile(`\\</?div[^>]*\\?>`).ReplaceAllString(md, "")
-	md = regexp.MustCompile(`\\</?span[^>]*\\?>`).ReplaceAllString(md, "")
+	md = applyReplacements(md, escapedTagReplacements)
 
-	// Handle escaped img tags - convert to markdown images.
-	// Pandoc sometimes escapes HTML tags as \<tag\>. This pattern matches escaped <img> tags
-	// and converts them to proper Markdown image syntax: ![alt](src)
-	//
-	// Pattern breakdown:
-	// \\<img           - Match escaped opening: \<img
-	// [^>]*src="..."   - Capture src attribute
-	// (?:alt="..."|)   - Optionally capture alt attribute
-	// \\?>             - Match optional escaped closing: \> or just >
-	escapedImgPattern := regexp.MustCompile(`\\<img[^>]*src="([^"]*)"[^>]*(?:alt="([^"]*)"|)[^>]*\\?>`)
-	md = escapedImgPattern.ReplaceAllStringFunc(md, func(match string) string {
-		srcMatch := regexp.MustCompile(`src="([^"]*)"`).FindStringSubmatch(match)
-		altMatch := regexp.MustCompile(`alt="([^"]*)"`).FindStringSubmatch(match)
+	// Handle escaped img tags - convert to markdown images: ![alt](src)
+	md = escapedImagePattern.ReplaceAllStringFunc(md, func(match string) string {
+		srcMatch := srcAttributePattern.FindStringSubmatch(match)
+		altMatch := altAttributePattern.FindStringSubmatch(match)
 		src := ""
 		alt := "image"
 		if len(srcMatch) > 1 {
@@ -475,23 +792,7 @@ func postProcessMarkdown(md string) string {
 		return fmt.Sprintf("![%s](%s)", alt, src)
 	})
 
-	// Clean any remaining escaped tags
-	md = regexp.MustCompile(`\\<[^>]*\\?>`).ReplaceAllString(md, "")
-
-	// Fix double-dash in nested lists (pandoc sometimes produces "- - item")
-	md = regexp.MustCompile(`^(\s*)- - `).ReplaceAllString(md, "$1  - ")
-	md = regexp.MustCompile(`\n(\s*)- - `).ReplaceAllString(md, "\n$1  - ")
-
-	// Clean up remaining HTML tags in output
-	// Remove any stray <br> tags
-	md = regexp.MustCompile(`<br\s*/?>`).ReplaceAllString(md, "\n")
-	// Remove empty <div> tags
-	md = regexp.MustCompile(`<div[^>]*>\s*</div>`).ReplaceAllString(md, "")
-	// Remove standalone closing </div> tags
-	md = regexp.MustCompile(`</div>`).ReplaceAllString(md, "")
-
-	// Normalize multiple blank lines to max 2
-	md = regexp.MustCompile(`\n{3,}`).ReplaceAllString(md, "\n\n")
+	md = applyReplacements(md, strayMarkupReplacements)
 
 	// Trim trailing whitespace from lines
 	lines := strings.Split(md, "\n")
@@ -499,6 +800,7 @@ func postProcessMarkdown(md string) string {
 		lines[i] = strings.TrimRight(line, " \t")
 	}
 	md = strings.Join(lines, "\n")
+	md = compactBlankLines(md, blank)
 
 	// Trim leading/trailing whitespace from document
 	md = strings.TrimSpace(md) + "\n"
@@ -507,23 +809,7 @@ func postProcessMarkdown(md string) string {
 	md = balanceDetailsTags(md)
 
 	// Convert text emoji shortcodes like :celebration:
-	textEmojis := map[string]string{
-		":celebration:": "🎉",
-		":thumbsup:":    "👍",
-		":thumbsdown:":  "👎",
-		":check:":       "✅",
-		":cross:":       "❌",
-		":warning:":     "⚠️",
-		":info:":        "ℹ️",
-		":question:":    "❓",
-		":star:":        "⭐",
-		":fire:":        "🔥",
-		":rocket:":      "🚀",
-		":sparkles:":    "✨",
-	}
-	for code, emoji := range textEmojis {
-		md = strings.ReplaceAll(md, code, emoji)
-	}
+	md = replaceEmojiCodes(md, defaultEmoticons)
 
 	return md
 }
@@ -1,8 +1,14 @@
 package converter
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/aqueeb/confluence2md/internal/pandoc"
 )
 
 func TestCheckPandoc(t *testing.T) {
@@ -446,14 +452,29 @@ func TestDecodeHTMLEntities(t *testing.T) {
 			expect: "<word word>",
 		},
 		{
-			name:   "high codepoint unchanged",
+			name:   "high codepoint decoded",
 			input:  "&#200;", // È - above ASCII range
-			expect: "&#200;",
+			expect: "È",
 		},
 		{
-			name:   "hex high codepoint unchanged",
+			name:   "hex high codepoint decoded",
 			input:  "&#xC8;", // È - above ASCII range
-			expect: "&#xC8;",
+			expect: "È",
+		},
+		{
+			name:   "named non-ASCII entities",
+			input:  "&lt;p&gt;caf&eacute; &mdash; na&iuml;ve &euro;5&lt;/p&gt;",
+			expect: "<p>café — naïve €5</p>",
+		},
+		{
+			name:   "emoji references",
+			input:  "&lt;p&gt;&#x1F389; &#128640;&lt;/p&gt;",
+			expect: "<p>🎉 🚀</p>",
+		},
+		{
+			name:   "CJK references",
+			input:  "&#26085;&#26412;&#x8A9E;",
+			expect: "日本語",
 		},
 	}
 
@@ -997,8 +1018,8 @@ func TestDecodeHTMLEntities_EdgeCases(t *testing.T) {
 		},
 		{
 			name:   "numeric entity at boundary",
-			input:  "&#126; &#127; &#128;", // 126 is ~ (decoded), 127+ not decoded (val < 127 check)
-			expect: "~ &#127; &#128;",
+			input:  "&#126; &#127; &#128;", // 128 maps to € as in browsers (windows-1252 range)
+			expect: "~ \x7f €",
 		},
 		{
 			name:   "low ascii numeric entities",
@@ -1016,3 +1037,118 @@ func TestDecodeHTMLEntities_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestConversionContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		want    time.Duration
+	}{
+		{"default", 0, DefaultTimeout},
+		{"custom", 5 * time.Second, 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			ctx, cancel := conversionContext(Options{Timeout: tt.timeout})
+			defer cancel()
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				t.Fatal("expected a deadline")
+			}
+			if got := deadline.Sub(start); got < tt.want || got > tt.want+time.Second {
+				t.Errorf("deadline in %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPandocError_Timeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	err := pandocError(ctx, errors.New("signal: killed"), "")
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+
+	err = pandocError(context.Background(), pandoc.ParseError(errors.New("exit status 64"), []byte("unknown option")), "")
+	if errors.Is(err, ErrTimeout) {
+		t.Errorf("unexpected ErrTimeout for a plain failure: %v", err)
+	}
+	if !strings.Contains(err.Error(), "unknown option") {
+		t.Errorf("expected pandoc output in error, got %v", err)
+	}
+}
+
+func TestPandocError_Snippet(t *testing.T) {
+	html := "<html>\n<body>\n<p>one</p>\n<tabl\n<p>two</p>\n</body>\n</html>"
+	stderr := []byte("Error at \"source\" (line 4, column 5):\nunexpected end of tag")
+
+	err := pandocError(context.Background(), pandoc.ParseError(errors.New("exit status 64"), stderr), html)
+	var pe *pandoc.PandocError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected a *pandoc.PandocError, got %v", err)
+	}
+	if pe.Line != 4 || pe.Column != 5 {
+		t.Errorf("position = %d:%d, want 4:5", pe.Line, pe.Column)
+	}
+	want := "  2 | <body>\n  3 | <p>one</p>\n> 4 | <tabl\n    |     ^\n  5 | <p>two</p>\n  6 | </body>"
+	if !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("error doesn't end with the snippet:\n%v\nwant suffix:\n%s", err, want)
+	}
+}
+
+func TestSourceSnippet(t *testing.T) {
+	long := strings.Repeat("a", 150) + "<x" + strings.Repeat("b", 150)
+
+	tests := []struct {
+		name         string
+		src          string
+		line, column int
+		want         string
+	}{
+		{"first line", "<a>\n<b>", 1, 2, "> 1 | <a>\n    |  ^\n  2 | <b>"},
+		{"no column", "<a>\n<b>", 2, 0, "  1 | <a>\n> 2 | <b>"},
+		{"out of range", "<a>", 3, 1, ""},
+		{
+			"long line",
+			long, 1, 151,
+			"> 1 | …" + strings.Repeat("a", 49) + "<x" + strings.Repeat("b", 49) + "…\n    | " + strings.Repeat(" ", 50) + "^",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sourceSnippet(tt.src, tt.line, tt.column); got != tt.want {
+				t.Errorf("sourceSnippet() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertHTMLToMarkdown_JSON(t *testing.T) {
+	if err := CheckPandoc(); err != nil {
+		t.Skipf("Pandoc not installed, skipping test: %v", err)
+	}
+
+	out, err := ConvertHTMLToMarkdownWithOptions(`<div class="panel"><p>Hello</p></div>`, Options{To: FormatJSON})
+	if err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+	var ast struct {
+		APIVersion []int             `json:"pandoc-api-version"`
+		Blocks     []json.RawMessage `json:"blocks"`
+	}
+	if err := json.Unmarshal([]byte(out), &ast); err != nil {
+		t.Fatalf("output is not pandoc JSON: %v\n%s", err, out)
+	}
+	if len(ast.APIVersion) == 0 || len(ast.Blocks) == 0 {
+		t.Errorf("expected an API version and blocks, got: %s", out)
+	}
+	if !strings.Contains(out, `"panel"`) {
+		t.Errorf("expected the panel class to be kept in the AST, got: %s", out)
+	}
+}
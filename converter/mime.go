@@ -4,25 +4,108 @@ package converter
 
 import (
 	"bufio"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
 	"mime/multipart"
 	"mime/quotedprintable"
 	"net/mail"
+	"net/textproto"
 	"os"
 	"strings"
+	"time"
 )
 
 const (
-	// mimeHeaderScanLimit is the maximum number of lines to scan when
-	// checking if a file is a Confluence MIME export. The required headers
-	// (Date, MIME-Version, Subject) typically appear in the first few lines.
-	mimeHeaderScanLimit = 10
+	// maxDetectionHeaderLines bounds the header lines read when checking if
+	// a file is a Confluence MIME export, for files that are not MIME
+	// messages and so never end their headers with a blank line.
+	maxDetectionHeaderLines = 1000
+
+	// maxDetectionLineBytes is how much of each line the detection checks
+	// look at. Header lines of real exports are far shorter.
+	maxDetectionLineBytes = 4096
+
+	// genericExportSubject is the Subject header Confluence puts on every
+	// export. It carries no information about the page itself.
+	genericExportSubject = "Exported From Confluence"
 )
 
-// ExtractHTMLFromMIME reads a MIME-encoded Confluence export file and extracts the HTML content.
+// ExportMetadata holds information from the top-level headers of a
+// Confluence MIME export.
+type ExportMetadata struct {
+	// Subject is the decoded Subject header.
+	Subject string
+	// Date is the export date, or the zero time if the header is missing or invalid.
+	Date time.Time
+	// HTMLTitle is the text of the page's <title> element (see
+	// ExtractHTMLTitle). It is only read when the Subject carries no title.
+	HTMLTitle string
+}
+
+// PageTitle returns the page title carried in the Subject header, falling
+// back to the HTML title when the subject is empty or the generic
+// Confluence export subject. It is empty if neither names the page.
+func (m ExportMetadata) PageTitle() string {
+	subject := strings.TrimSpace(m.Subject)
+	if subject == "" || strings.EqualFold(subject, genericExportSubject) {
+		return m.HTMLTitle
+	}
+	return subject
+}
+
+// ReadExportMetadata reads the Subject and Date headers of a MIME export,
+// and the HTML title when the subject carries no title.
+func ReadExportMetadata(filepath string) (ExportMetadata, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return ExportMetadata{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	msg, err := readLimitedMessage(file, MIMELimits{})
+	if err != nil {
+		return ExportMetadata{}, fmt.Errorf("failed to parse MIME message: %w", err)
+	}
+
+	meta := exportMetadata(msg.Header)
+	if meta.PageTitle() == "" {
+		// An export without an HTML part still has usable headers
+		if html, err := extractHTMLFromMessage(msg, MIMELimits{}.withDefaults(), 0); err == nil {
+			meta.HTMLTitle = ExtractHTMLTitle(html)
+		}
+	}
+	return meta, nil
+}
+
+// exportMetadata reads the Subject and Date headers of an export.
+func exportMetadata(header mail.Header) ExportMetadata {
+	var meta ExportMetadata
+	subject := header.Get("Subject")
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(subject); err == nil {
+		subject = decoded
+	}
+	meta.Subject = subject
+	if date, err := header.Date(); err == nil {
+		meta.Date = date
+	}
+	return meta
+}
+
+// ExtractHTMLFromMIME reads a MIME-encoded Confluence export file and extracts the HTML content,
+// transcoded to UTF-8 and declared as such with a <meta charset="utf-8"> element. HTML in a
+// charset that is not supported is passed through undecoded (see UndecodedCharset).
 func ExtractHTMLFromMIME(filepath string) (string, error) {
+	return ExtractHTMLFromMIMEWithLimits(filepath, MIMELimits{})
+}
+
+// ExtractHTMLFromMIMEWithLimits is ExtractHTMLFromMIME with the given
+// limits on header size, part count, and part size. Exceeding one returns
+// an error wrapping ErrMIMELimit.
+func ExtractHTMLFromMIMEWithLimits(filepath string, limits MIMELimits) (string, error) {
+	limits = limits.withDefaults()
 	file, err := os.Open(filepath)
 	if err != nil {
 		return "", fmt.Errorf("failed to open file: %w", err)
@@ -30,17 +113,32 @@ func ExtractHTMLFromMIME(filepath string) (string, error) {
 	defer file.Close()
 
 	// Parse as email/MIME message
-	msg, err := mail.ReadMessage(bufio.NewReader(file))
+	msg, err := readLimitedMessage(file, limits)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse MIME message: %w", err)
 	}
+	return extractHTMLFromMessage(msg, limits, 0)
+}
+
+// maxMessageNesting is how deep message/rfc822 messages are unwrapped.
+// Forwarding an export once or twice nests it one or two levels.
+const maxMessageNesting = 4
 
+// extractHTMLFromMessage returns the text/html part of a multipart message.
+// Exports forwarded by mail clients arrive wrapped in a message/rfc822
+// message or part; these are unwrapped, and HTML found inside one wins over
+// HTML next to it, which belongs to the forwarding mail. depth counts the
+// enclosing messages.
+func extractHTMLFromMessage(msg *mail.Message, limits MIMELimits, depth int) (string, error) {
 	contentType := msg.Header.Get("Content-Type")
 	mediaType, params, err := mime.ParseMediaType(contentType)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse Content-Type: %w", err)
 	}
 
+	if mediaType == "message/rfc822" {
+		return extractEmbeddedMessage(msg.Body, msg.Header.Get("Content-Transfer-Encoding"), limits, depth)
+	}
 	if !strings.HasPrefix(mediaType, "multipart/") {
 		return "", fmt.Errorf("expected multipart message, got: %s", mediaType)
 	}
@@ -53,74 +151,254 @@ func ExtractHTMLFromMIME(filepath string) (string, error) {
 	// Parse multipart body
 	mr := multipart.NewReader(msg.Body, boundary)
 
-	for {
+	var html string
+	found := false
+	for n := 1; ; n++ {
 		part, err := mr.NextPart()
 		if err == io.EOF {
 			break
 		}
+		if err == nil {
+			err = checkPartHeader(n, part.Header, limits)
+		}
 		if err != nil {
+			if found {
+				// Keep the HTML rather than failing on the parts after it
+				break
+			}
+			if errors.Is(err, ErrMIMELimit) {
+				return "", err
+			}
 			return "", fmt.Errorf("failed to read MIME part: %w", err)
 		}
 
-		partContentType := part.Header.Get("Content-Type")
-		partMediaType, _, _ := mime.ParseMediaType(partContentType)
-
-		// We're looking for the text/html part
-		if partMediaType == "text/html" {
-			encoding := part.Header.Get("Content-Transfer-Encoding")
-
-			var reader io.Reader = part
-			if strings.ToLower(encoding) == "quoted-printable" {
-				reader = quotedprintable.NewReader(part)
-			}
-
-			htmlBytes, err := io.ReadAll(reader)
+		encoding := part.Header.Get("Content-Transfer-Encoding")
+		partMediaType, partParams, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		switch {
+		case partMediaType == "text/html" && !found:
+			htmlBytes, err := readPart(transferDecoder(part, encoding), limits.MaxPartBytes)
 			if err != nil {
 				return "", fmt.Errorf("failed to read HTML content: %w", err)
 			}
-
-			return string(htmlBytes), nil
+			html, found = decodeHTMLPart(htmlBytes, partParams["charset"]), true
+		case partMediaType == "message/rfc822":
+			embedded, err := extractEmbeddedMessage(part, encoding, limits, depth)
+			if err == nil {
+				return embedded, nil
+			}
+			if errors.Is(err, ErrMIMELimit) {
+				return "", err
+			}
 		}
 	}
 
-	return "", fmt.Errorf("no text/html part found in MIME message")
+	if !found {
+		return "", fmt.Errorf("no text/html part found in MIME message")
+	}
+	return html, nil
 }
 
-// IsConfluenceMIME checks if a file appears to be a MIME-encoded Confluence export.
-// Returns (true, nil) if the file is a valid Confluence MIME export,
-// (false, nil) if the file can be read but is not a Confluence export,
-// and (false, error) if there was an error reading the file.
-func IsConfluenceMIME(filepath string) (bool, error) {
+// extractEmbeddedMessage parses the message/rfc822 content r, encoded with
+// the given Content-Transfer-Encoding, and returns its HTML part.
+func extractEmbeddedMessage(r io.Reader, encoding string, limits MIMELimits, depth int) (string, error) {
+	if depth >= maxMessageNesting {
+		return "", fmt.Errorf("%w: messages nested more than %d deep", ErrMIMELimit, maxMessageNesting)
+	}
+	msg, err := readLimitedMessage(transferDecoder(r, encoding), limits)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse embedded message: %w", err)
+	}
+	return extractHTMLFromMessage(msg, limits, depth+1)
+}
+
+// transferDecoder decodes r according to its Content-Transfer-Encoding.
+// 7bit, 8bit, and binary content is returned unchanged.
+func transferDecoder(r io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	}
+	return r
+}
+
+// DetectionCheck is one of the header checks IsConfluenceMIME performs.
+type DetectionCheck struct {
+	// Name describes what the check looks for.
+	Name string
+	// Line is the 1-based line number where the matching header starts,
+	// or 0.
+	Line int
+	// Text is the matching header, with folded lines joined.
+	Text string
+}
+
+// Found reports whether the check matched.
+func (c DetectionCheck) Found() bool {
+	return c.Line > 0
+}
+
+// Detection explains how a file was classified as a Confluence export or not.
+type Detection struct {
+	// Checks lists the header checks in order: Date, MIME-Version, and the
+	// Confluence export subject.
+	Checks []DetectionCheck
+	// LinesScanned is the number of lines examined for the checks.
+	LinesScanned int
+	// ContentType is the top-level Content-Type header, if the file parses
+	// as a MIME message.
+	ContentType string
+	// ParseError explains why the file does not parse as a MIME message.
+	ParseError string
+}
+
+// IsConfluence reports whether every check matched.
+func (d Detection) IsConfluence() bool {
+	for _, check := range d.Checks {
+		if !check.Found() {
+			return false
+		}
+	}
+	return true
+}
+
+// DetectConfluenceMIME runs the IsConfluenceMIME checks on a file and
+// reports which matched, for explaining the classification to users.
+func DetectConfluenceMIME(filepath string) (Detection, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
-		return false, fmt.Errorf("failed to open file: %w", err)
+		return Detection{}, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	lineCount := 0
-	hasDateHeader := false
-	hasMIMEVersion := false
-	hasConfluenceSubject := false
+	date := DetectionCheck{Name: "Date header"}
+	mimeVersion := DetectionCheck{Name: "MIME-Version header"}
+	subject := DetectionCheck{Name: fmt.Sprintf("%q subject", genericExportSubject)}
+	match := func(check *DetectionCheck, ok bool, line int, text string) {
+		if ok && !check.Found() {
+			check.Line, check.Text = line, text
+		}
+	}
 
-	for scanner.Scan() && lineCount < mimeHeaderScanLimit {
-		line := scanner.Text()
+	// Read the headers up to the blank line ending them, skipping blank
+	// lines and other preamble before the first header
+	br := bufio.NewReader(file)
+	lineCount := 0
+	sawHeader := false
+	for lineCount < maxDetectionHeaderLines {
+		line, err := readDetectionLine(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Detection{}, fmt.Errorf("failed to read file: %w", err)
+		}
 		lineCount++
+		start := lineCount
+		if strings.TrimSpace(line) == "" {
+			if sawHeader {
+				break
+			}
+			continue
+		}
 
-		if strings.HasPrefix(line, "Date:") {
-			hasDateHeader = true
+		// Unfold the continuation lines of a folded header
+		for lineCount < maxDetectionHeaderLines && startsContinuation(br) {
+			next, err := readDetectionLine(br)
+			if err != nil {
+				break
+			}
+			lineCount++
+			line = unfoldHeaderLine(line, next)
 		}
-		if strings.HasPrefix(line, "MIME-Version:") {
-			hasMIMEVersion = true
+
+		if !isHeaderField(line) {
+			continue
 		}
-		if strings.Contains(line, "Exported From Confluence") {
-			hasConfluenceSubject = true
+		sawHeader = true
+		key, value, _ := strings.Cut(line, ":")
+		switch textproto.CanonicalMIMEHeaderKey(key) {
+		case "Date":
+			match(&date, true, start, line)
+		case "Mime-Version":
+			match(&mimeVersion, true, start, line)
+		case "Subject":
+			if decoded, err := new(mime.WordDecoder).DecodeHeader(value); err == nil {
+				value = decoded
+			}
+			match(&subject, strings.Contains(value, genericExportSubject), start, line)
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return false, fmt.Errorf("failed to read file: %w", err)
+	d := Detection{
+		Checks:       []DetectionCheck{date, mimeVersion, subject},
+		LinesScanned: lineCount,
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return Detection{}, fmt.Errorf("failed to read file: %w", err)
 	}
+	if msg, err := readLimitedMessage(file, MIMELimits{}); err != nil {
+		d.ParseError = err.Error()
+	} else {
+		d.ContentType = msg.Header.Get("Content-Type")
+	}
+	return d, nil
+}
 
-	return hasDateHeader && hasMIMEVersion && hasConfluenceSubject, nil
+// startsContinuation reports whether the next line in br continues a folded
+// header, that is, starts with a space or tab.
+func startsContinuation(br *bufio.Reader) bool {
+	next, err := br.Peek(1)
+	return err == nil && (next[0] == ' ' || next[0] == '\t')
+}
+
+// unfoldHeaderLine joins a folded header line and its continuation with a
+// single space, as net/textproto does, keeping at most
+// maxDetectionLineBytes.
+func unfoldHeaderLine(line, continuation string) string {
+	line = strings.TrimRight(line, " \t") + " " + strings.TrimLeft(continuation, " \t")
+	if len(line) > maxDetectionLineBytes {
+		line = line[:maxDetectionLineBytes]
+	}
+	return line
+}
+
+// readDetectionLine reads the next line from br without its line ending.
+// Only the first maxDetectionLineBytes of a longer line are kept; the rest
+// is skipped without buffering it, so that huge lines cannot exhaust
+// memory. It returns io.EOF when no line is left.
+func readDetectionLine(br *bufio.Reader) (string, error) {
+	var line []byte
+	empty := true
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if len(chunk) > 0 {
+			empty = false
+		}
+		if room := maxDetectionLineBytes - len(line); room > 0 {
+			line = append(line, chunk[:min(len(chunk), room)]...)
+		}
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && !empty:
+		case err != nil:
+			return "", err
+		}
+		return strings.TrimRight(string(line), "\r\n"), nil
+	}
+}
+
+// IsConfluenceMIME checks if a file appears to be a MIME-encoded Confluence export.
+// Returns (true, nil) if the file is a valid Confluence MIME export,
+// (false, nil) if the file can be read but is not a Confluence export,
+// and (false, error) if there was an error reading the file.
+// DetectConfluenceMIME explains the result.
+func IsConfluenceMIME(filepath string) (bool, error) {
+	d, err := DetectConfluenceMIME(filepath)
+	if err != nil {
+		return false, err
+	}
+	return d.IsConfluence(), nil
 }
@@ -1,6 +1,10 @@
 package converter
 
 import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -363,3 +367,404 @@ Some content
 	}
 }
 
+
+func TestReadExportMetadata(t *testing.T) {
+	tests := []struct {
+		name      string
+		headers   string
+		wantTitle string
+		wantDate  string
+	}{
+		{
+			name:      "generic subject",
+			headers:   "Date: Wed, 7 Jan 2026 01:29:00 +0000 (UTC)\nSubject: Exported From Confluence\n",
+			wantTitle: "",
+			wantDate:  "2026-01-07",
+		},
+		{
+			name:      "encoded page subject",
+			headers:   "Date: Wed, 7 Jan 2026 01:29:00 +0000 (UTC)\nSubject: =?UTF-8?Q?Caf=C3=A9_Menu?=\n",
+			wantTitle: "Café Menu",
+			wantDate:  "2026-01-07",
+		},
+		{
+			name:      "missing date",
+			headers:   "Subject: Runbook\n",
+			wantTitle: "Runbook",
+			wantDate:  "0001-01-01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "page.doc")
+			content := tt.headers + "MIME-Version: 1.0\nContent-Type: text/html\n\n<html></html>\n"
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+
+			meta, err := ReadExportMetadata(path)
+			if err != nil {
+				t.Fatalf("ReadExportMetadata failed: %v", err)
+			}
+			if got := meta.PageTitle(); got != tt.wantTitle {
+				t.Errorf("PageTitle() = %q, want %q", got, tt.wantTitle)
+			}
+			if got := meta.Date.Format("2006-01-02"); got != tt.wantDate {
+				t.Errorf("Date = %s, want %s", got, tt.wantDate)
+			}
+		})
+	}
+}
+
+func TestReadExportMetadata_MissingFile(t *testing.T) {
+	if _, err := ReadExportMetadata("/nonexistent/file.doc"); err == nil {
+		t.Error("Expected error for non-existent file")
+	}
+}
+
+func TestDetectConfluenceMIME(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := "Date: Wed, 7 Jan 2026 01:29:00 +0000 (UTC)\n" +
+		"Subject: Quarterly plan\n" +
+		"MIME-Version: 1.0\n" +
+		"Content-Type: multipart/related; boundary=\"b\"\n" +
+		"\n" +
+		"--b--\n"
+	path := filepath.Join(tmpDir, "page.doc")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	d, err := DetectConfluenceMIME(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if d.IsConfluence() {
+		t.Error("Expected a file without the export subject to be rejected")
+	}
+	if len(d.Checks) != 3 {
+		t.Fatalf("Expected 3 checks, got %d", len(d.Checks))
+	}
+	if d.Checks[0].Line != 1 || d.Checks[1].Line != 3 || d.Checks[2].Found() {
+		t.Errorf("Unexpected check results: %+v", d.Checks)
+	}
+	if d.Checks[1].Text != "MIME-Version: 1.0" {
+		t.Errorf("Expected matching line text, got %q", d.Checks[1].Text)
+	}
+	if d.LinesScanned != 5 {
+		t.Errorf("Expected 5 lines scanned, up to the blank line, got %d", d.LinesScanned)
+	}
+	if d.ContentType != `multipart/related; boundary="b"` {
+		t.Errorf("Unexpected content type %q", d.ContentType)
+	}
+
+	plain := filepath.Join(tmpDir, "plain.doc")
+	if err := os.WriteFile(plain, []byte("just text\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	d, err = DetectConfluenceMIME(plain)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if d.ParseError == "" || d.ContentType != "" {
+		t.Errorf("Expected a parse error for plain text, got %+v", d)
+	}
+}
+
+// confluenceExport is a minimal Confluence export with the given HTML.
+const confluenceExport = `Date: Wed, 7 Jan 2026 01:29:00 +0000 (UTC)
+Subject: Exported From Confluence
+MIME-Version: 1.0
+Content-Type: multipart/related; boundary="export"
+
+--export
+Content-Type: text/html; charset=UTF-8
+
+<html><body><h1>Exported Page</h1></body></html>
+--export--
+`
+
+func TestExtractHTMLFromMIME_EmbeddedMessage(t *testing.T) {
+	forwardHeader := "Date: Thu, 8 Jan 2026 09:00:00 +0000\nSubject: Fwd: Exported From Confluence\nMIME-Version: 1.0\n"
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "top-level message/rfc822",
+			content: forwardHeader + "Content-Type: message/rfc822\n\n" + confluenceExport,
+			want:    "Exported Page",
+		},
+		{
+			name: "forwarded as an attachment",
+			content: forwardHeader + `Content-Type: multipart/mixed; boundary="fwd"
+
+--fwd
+Content-Type: multipart/alternative; boundary="alt"
+
+--alt
+Content-Type: text/plain
+
+See the attached export.
+--alt--
+--fwd
+Content-Type: message/rfc822
+Content-Disposition: attachment
+
+` + confluenceExport + `
+--fwd--
+`,
+			want: "Exported Page",
+		},
+		{
+			name: "embedded export wins over the forwarding mail's HTML",
+			content: forwardHeader + `Content-Type: multipart/mixed; boundary="fwd"
+
+--fwd
+Content-Type: text/html
+
+<p>See the attached export.</p>
+--fwd
+Content-Type: message/rfc822
+
+` + confluenceExport + `
+--fwd--
+`,
+			want: "Exported Page",
+		},
+		{
+			name: "base64-encoded embedded message",
+			content: forwardHeader + `Content-Type: multipart/mixed; boundary="fwd"
+
+--fwd
+Content-Type: message/rfc822
+Content-Transfer-Encoding: base64
+
+` + base64.StdEncoding.EncodeToString([]byte(confluenceExport)) + `
+--fwd--
+`,
+			want: "Exported Page",
+		},
+		{
+			name:    "nested too deep",
+			content: strings.Repeat(forwardHeader+"Content-Type: message/rfc822\n\n", maxMessageNesting+1) + confluenceExport,
+			wantErr: "nested more than",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "forwarded.doc")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			html, err := ExtractHTMLFromMIME(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractHTMLFromMIME failed: %v", err)
+			}
+			if !strings.Contains(html, tt.want) {
+				t.Errorf("expected HTML containing %q, got: %s", tt.want, html)
+			}
+		})
+	}
+}
+
+func TestDetectConfluenceMIME_LongLines(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		confluence  bool
+		extractable bool
+	}{
+		{
+			name:        "long header line before the checks",
+			content:     "X-Trace: " + strings.Repeat("a", 30000) + "\n" + confluenceExport,
+			confluence:  true,
+			extractable: true,
+		},
+		{
+			name:       "line larger than the header limit",
+			content:    "X-Trace: " + strings.Repeat("a", 200000) + "\n" + confluenceExport,
+			confluence: true,
+		},
+		{
+			name:    "single huge line",
+			content: strings.Repeat("x", 1<<20),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "long.doc")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			d, err := DetectConfluenceMIME(path)
+			if err != nil {
+				t.Fatalf("DetectConfluenceMIME failed: %v", err)
+			}
+			if d.IsConfluence() != tt.confluence {
+				t.Errorf("IsConfluence() = %v, want %v (checks %+v)", d.IsConfluence(), tt.confluence, d.Checks)
+			}
+			for _, check := range d.Checks {
+				if len(check.Text) > maxDetectionLineBytes {
+					t.Errorf("check %q kept %d bytes of its line", check.Name, len(check.Text))
+				}
+			}
+			_, err = ExtractHTMLFromMIME(path)
+			if tt.extractable && err != nil {
+				t.Errorf("ExtractHTMLFromMIME failed: %v", err)
+			}
+			if !tt.extractable && err == nil {
+				t.Error("expected ExtractHTMLFromMIME to fail")
+			}
+		})
+	}
+}
+
+func TestReadDetectionLine(t *testing.T) {
+	long := strings.Repeat("a", maxDetectionLineBytes+100)
+	br := bufio.NewReaderSize(strings.NewReader("Date: now\r\n"+long+"\nlast"), 16)
+	want := []string{"Date: now", long[:maxDetectionLineBytes], "last"}
+	for _, w := range want {
+		line, err := readDetectionLine(br)
+		if err != nil {
+			t.Fatalf("readDetectionLine() error = %v", err)
+		}
+		if line != w {
+			t.Errorf("readDetectionLine() = %q, want %q", line, w)
+		}
+	}
+	if _, err := readDetectionLine(br); err != io.EOF {
+		t.Errorf("readDetectionLine() at end error = %v, want io.EOF", err)
+	}
+}
+
+func TestDetectConfluenceMIME_HeaderParsing(t *testing.T) {
+	tests := []struct {
+		name        string
+		headers     string
+		confluence  bool
+		subjectLine int
+		subjectText string
+	}{
+		{
+			name:        "folded subject",
+			headers:     "Date: Wed, 7 Jan 2026 01:29:00 +0000\nSubject: Exported\n\tFrom Confluence\nMIME-Version: 1.0\n",
+			confluence:  true,
+			subjectLine: 2,
+			subjectText: "Subject: Exported From Confluence",
+		},
+		{
+			name:        "folded date before the subject",
+			headers:     "Date: Wed,\n 7 Jan 2026 01:29:00 +0000\nMIME-Version: 1.0\nSubject: Exported From Confluence\n",
+			confluence:  true,
+			subjectLine: 4,
+			subjectText: "Subject: Exported From Confluence",
+		},
+		{
+			name:        "encoded subject",
+			headers:     "Date: Wed, 7 Jan 2026 01:29:00 +0000\nSubject: =?UTF-8?Q?Exported_From_Confluence?=\nMIME-Version: 1.0\n",
+			confluence:  true,
+			subjectLine: 2,
+			subjectText: "Subject: =?UTF-8?Q?Exported_From_Confluence?=",
+		},
+		{
+			name:        "header names in other case",
+			headers:     "DATE: Wed, 7 Jan 2026 01:29:00 +0000\nsubject: Exported From Confluence\nmime-version: 1.0\n",
+			confluence:  true,
+			subjectLine: 2,
+			subjectText: "subject: Exported From Confluence",
+		},
+		{
+			name:    "export subject outside the Subject header",
+			headers: "Date: Wed, 7 Jan 2026 01:29:00 +0000\nSubject: Notes\nX-Note: Exported From Confluence\nMIME-Version: 1.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "page.doc")
+			content := tt.headers + "Content-Type: multipart/related; boundary=\"b\"\n\n--b--\n"
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			d, err := DetectConfluenceMIME(path)
+			if err != nil {
+				t.Fatalf("DetectConfluenceMIME failed: %v", err)
+			}
+			if d.IsConfluence() != tt.confluence {
+				t.Fatalf("IsConfluence() = %v, want %v (checks %+v)", d.IsConfluence(), tt.confluence, d.Checks)
+			}
+			if subject := d.Checks[2]; tt.confluence && (subject.Line != tt.subjectLine || subject.Text != tt.subjectText) {
+				t.Errorf("subject check = line %d %q, want line %d %q", subject.Line, subject.Text, tt.subjectLine, tt.subjectText)
+			}
+		})
+	}
+}
+
+func TestDetectConfluenceMIME_HeaderBlock(t *testing.T) {
+	var received strings.Builder
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&received, "Received: from relay%d.example.com\n\tby mx.example.com; Wed, 7 Jan 2026 01:29:00 +0000\n", i)
+	}
+
+	tests := []struct {
+		name        string
+		content     string
+		confluence  bool
+		extractable bool
+	}{
+		{
+			name:        "required headers past line 10",
+			content:     received.String() + confluenceExport,
+			confluence:  true,
+			extractable: true,
+		},
+		{
+			name:       "reordered headers",
+			content:    "MIME-Version: 1.0\nContent-Type: multipart/related; boundary=\"b\"\n" + received.String() + "Subject: Exported From Confluence\nDate: Wed, 7 Jan 2026 01:29:00 +0000\n\n--b--\n",
+			confluence: true,
+		},
+		{
+			name:        "preamble before the headers",
+			content:     "\nFrom confluence@example.com Wed Jan  7 01:29:00 2026\n" + confluenceExport,
+			confluence:  true,
+			extractable: true,
+		},
+		{
+			name:    "required header only in the body",
+			content: "Date: Wed, 7 Jan 2026 01:29:00 +0000\nSubject: Exported From Confluence\n\nMIME-Version: 1.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "page.doc")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			d, err := DetectConfluenceMIME(path)
+			if err != nil {
+				t.Fatalf("DetectConfluenceMIME failed: %v", err)
+			}
+			if d.IsConfluence() != tt.confluence {
+				t.Errorf("IsConfluence() = %v, want %v (checks %+v)", d.IsConfluence(), tt.confluence, d.Checks)
+			}
+			if tt.extractable {
+				if html, err := ExtractHTMLFromMIME(path); err != nil || !strings.Contains(html, "Exported Page") {
+					t.Errorf("ExtractHTMLFromMIME() = %q, %v; want the export's HTML", html, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// attachmentExtensions are the file extensions given to extracted images
+// whose name has none, for the image types Confluence exports embed.
+var attachmentExtensions = map[string]string{
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/gif":     ".gif",
+	"image/svg+xml": ".svg",
+	"image/webp":    ".webp",
+	"image/bmp":     ".bmp",
+}
+
+// Attachment is an image part of a MIME export written to disk by
+// ExtractAttachments.
+type Attachment struct {
+	// Path is the file the image was written to.
+	Path string
+	// MediaType is the part's media type, such as image/png.
+	MediaType string
+	// Location is the part's Content-Location, the URL the page's HTML
+	// refers to the image by.
+	Location string
+	// ContentID is the part's Content-ID without angle brackets. The HTML
+	// may refer to the image as cid:ContentID instead.
+	ContentID string
+}
+
+// Sources returns the image sources the page's HTML may use for the
+// attachment.
+func (a Attachment) Sources() []string {
+	var sources []string
+	if a.Location != "" {
+		sources = append(sources, a.Location)
+	}
+	if a.ContentID != "" {
+		sources = append(sources, "cid:"+a.ContentID)
+	}
+	return sources
+}
+
+// ImageLinks maps the sources of attachments to links to the extracted
+// files relative to dir, the directory of the converted page, for use as
+// Options.ImagePaths.
+func ImageLinks(attachments []Attachment, dir string) map[string]string {
+	links := make(map[string]string)
+	for _, a := range attachments {
+		rel, err := filepath.Rel(dir, a.Path)
+		if err != nil {
+			rel = a.Path
+		}
+		for _, source := range a.Sources() {
+			links[source] = filepath.ToSlash(rel)
+		}
+	}
+	return links
+}
+
+// ExtractAttachments decodes the image parts (image/png, image/jpeg, and
+// other image types) of a MIME export and writes them to destDir, named
+// after the file name the part carries. A file of the same name with other
+// content is kept and the image gets a numbered name; a file with the same
+// content is reused, so that repeated runs do not duplicate images.
+func ExtractAttachments(path, destDir string) ([]Attachment, error) {
+	return ExtractAttachmentsWithLimits(path, destDir, MIMELimits{})
+}
+
+// ExtractAttachmentsWithLimits is ExtractAttachments with the given limits
+// on header size, part count, and part size. Exceeding one returns an
+// error wrapping ErrMIMELimit. The attachments written before an error are
+// returned with it.
+func ExtractAttachmentsWithLimits(path, destDir string, limits MIMELimits) ([]Attachment, error) {
+	limits = limits.withDefaults()
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	msg, err := readLimitedMessage(file, limits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse MIME message: %w", err)
+	}
+	x := &attachmentExtractor{destDir: destDir, limits: limits}
+	err = x.entity(textproto.MIMEHeader(msg.Header), msg.Body, 0)
+	return x.attachments, err
+}
+
+// attachmentExtractor walks the parts of a MIME export, writing its images.
+type attachmentExtractor struct {
+	destDir     string
+	limits      MIMELimits
+	parts       int
+	attachments []Attachment
+}
+
+// entity handles a message or part with the given header and body: images
+// are written, multipart bodies and embedded messages are walked, and
+// anything else is skipped. depth counts the enclosing messages.
+func (x *attachmentExtractor) entity(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		if depth == 0 && x.parts == 0 {
+			return fmt.Errorf("failed to parse Content-Type: %w", err)
+		}
+		return nil
+	}
+	encoding := header.Get("Content-Transfer-Encoding")
+
+	switch {
+	case strings.HasPrefix(mediaType, "image/"):
+		data, err := readPart(transferDecoder(body, encoding), x.limits.MaxPartBytes)
+		if err != nil {
+			if errors.Is(err, ErrMIMELimit) {
+				return err
+			}
+			return fmt.Errorf("failed to read image part: %w", err)
+		}
+		return x.write(header, mediaType, params, data)
+	case mediaType == "message/rfc822":
+		if depth >= maxMessageNesting {
+			return fmt.Errorf("%w: messages nested more than %d deep", ErrMIMELimit, maxMessageNesting)
+		}
+		msg, err := readLimitedMessage(transferDecoder(body, encoding), x.limits)
+		if err != nil {
+			return fmt.Errorf("failed to parse embedded message: %w", err)
+		}
+		return x.entity(textproto.MIMEHeader(msg.Header), msg.Body, depth+1)
+	case strings.HasPrefix(mediaType, "multipart/"):
+		if params["boundary"] == "" {
+			return fmt.Errorf("no boundary found in Content-Type")
+		}
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err == nil {
+				x.parts++
+				err = checkPartHeader(x.parts, part.Header, x.limits)
+			}
+			if err != nil {
+				if errors.Is(err, ErrMIMELimit) {
+					return err
+				}
+				return fmt.Errorf("failed to read MIME part: %w", err)
+			}
+			if err := x.entity(part.Header, part, depth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// write saves the decoded image of a part to destDir.
+func (x *attachmentExtractor) write(header textproto.MIMEHeader, mediaType string, params map[string]string, data []byte) error {
+	if err := os.MkdirAll(x.destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create attachment directory: %w", err)
+	}
+	name := attachmentFileName(header, mediaType, params, len(x.attachments)+1)
+	path, err := writeAttachment(x.destDir, name, data)
+	if err != nil {
+		return err
+	}
+
+	x.attachments = append(x.attachments, Attachment{
+		Path:      path,
+		MediaType: mediaType,
+		Location:  strings.TrimSpace(header.Get("Content-Location")),
+		ContentID: strings.Trim(strings.TrimSpace(header.Get("Content-Id")), "<>"),
+	})
+	return nil
+}
+
+// attachmentFileName returns the file name for the nth image part: the
+// Content-Disposition file name, the Content-Type name, or the last
+// segment of the Content-Location, made safe for the file system.
+func attachmentFileName(header textproto.MIMEHeader, mediaType string, params map[string]string, n int) string {
+	var name string
+	if _, disposition, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil {
+		name = disposition["filename"]
+	}
+	if name == "" {
+		name = params["name"]
+	}
+	if name == "" {
+		if u, err := url.Parse(strings.TrimSpace(header.Get("Content-Location"))); err == nil {
+			name = u.Path
+		}
+	}
+	// Content-Location may hold a Windows path
+	name = name[strings.LastIndexAny(name, `/\`)+1:]
+
+	name = safeFileName(name)
+	if name == "" {
+		name = fmt.Sprintf("image-%d", n)
+	}
+	if path.Ext(name) == "" {
+		if ext, ok := attachmentExtensions[mediaType]; ok {
+			name += ext
+		} else if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	return name
+}
+
+// safeFileName replaces white space, control characters, and characters
+// not allowed in file names on common file systems with hyphens.
+func safeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(` /\:*?"<>|`, r) {
+			return '-'
+		}
+		return r
+	}, name)
+	return strings.Trim(name, "-.")
+}
+
+// writeAttachment writes data to dir under name and returns the file's
+// path. Names taken by other content get a number: image.png, image-2.png,
+// image-3.png; a file that already holds data is reused. Files are created
+// exclusively, so that concurrent conversions sharing dir never overwrite
+// each other's images.
+func writeAttachment(dir, name string, data []byte) (string, error) {
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d%s", stem, i, ext)
+		}
+		p := filepath.Join(dir, candidate)
+		f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(data)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return "", fmt.Errorf("failed to write attachment: %w", err)
+			}
+			return p, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", fmt.Errorf("failed to write attachment: %w", err)
+		}
+
+		existing, err := os.ReadFile(p)
+		if err != nil {
+			return "", fmt.Errorf("failed to check attachment %s: %w", p, err)
+		}
+		if bytes.Equal(existing, data) {
+			return p, nil
+		}
+	}
+}
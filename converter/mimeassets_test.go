@@ -0,0 +1,143 @@
+package converter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pngPixel is a base64-encoded 1x1 PNG.
+const pngPixel = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg=="
+
+const assetsExport = `Date: Wed, 7 Jan 2026 01:29:00 +0000 (UTC)
+Subject: Exported From Confluence
+MIME-Version: 1.0
+Content-Type: multipart/related;
+	boundary="----=_Part_1"
+
+------=_Part_1
+Content-Type: text/html; charset=UTF-8
+Content-Transfer-Encoding: quoted-printable
+Content-Location: file:///C:/exported.html
+
+<html><body><img src="https://wiki.example.com/download/attachments/1/diagram%20v2.png?version=1"></body></html>
+------=_Part_1
+Content-Type: image/png
+Content-Transfer-Encoding: base64
+Content-Location: https://wiki.example.com/download/attachments/1/diagram%20v2.png?version=1
+
+` + pngPixel + `
+------=_Part_1
+Content-Type: image/jpeg
+Content-Transfer-Encoding: base64
+Content-ID: <photo@example>
+
+/9j/4AAQSkZJRg==
+------=_Part_1
+Content-Type: image/png
+Content-Transfer-Encoding: base64
+Content-Location: file:///C:\export\diagram v2.png
+
+iVBORw0KGgo=
+------=_Part_1--
+`
+
+func TestExtractAttachments(t *testing.T) {
+	dir := t.TempDir()
+	export := filepath.Join(dir, "page.doc")
+	if err := os.WriteFile(export, []byte(assetsExport), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(dir, "assets")
+
+	attachments, err := ExtractAttachments(export, dest)
+	if err != nil {
+		t.Fatalf("ExtractAttachments failed: %v", err)
+	}
+	want := []struct {
+		name      string
+		mediaType string
+		sources   []string
+	}{
+		{"diagram-v2.png", "image/png", []string{"https://wiki.example.com/download/attachments/1/diagram%20v2.png?version=1"}},
+		{"image-2.jpg", "image/jpeg", []string{"cid:photo@example"}},
+		{"diagram-v2-2.png", "image/png", []string{`file:///C:\export\diagram v2.png`}},
+	}
+	if len(attachments) != len(want) {
+		t.Fatalf("got %d attachments, want %d: %+v", len(attachments), len(want), attachments)
+	}
+	for i, w := range want {
+		a := attachments[i]
+		if a.Path != filepath.Join(dest, w.name) || a.MediaType != w.mediaType {
+			t.Errorf("attachment %d = %s (%s), want %s (%s)", i, a.Path, a.MediaType, w.name, w.mediaType)
+		}
+		if got := a.Sources(); strings.Join(got, " ") != strings.Join(w.sources, " ") {
+			t.Errorf("attachment %d sources = %q, want %q", i, got, w.sources)
+		}
+		if _, err := os.Stat(a.Path); err != nil {
+			t.Errorf("attachment %d not written: %v", i, err)
+		}
+	}
+
+	// A second run reuses the files instead of numbering new copies
+	again, err := ExtractAttachments(export, dest)
+	if err != nil {
+		t.Fatalf("second ExtractAttachments failed: %v", err)
+	}
+	for i := range again {
+		if again[i].Path != attachments[i].Path {
+			t.Errorf("second run attachment %d = %s, want %s", i, again[i].Path, attachments[i].Path)
+		}
+	}
+	entries, _ := os.ReadDir(dest)
+	if len(entries) != len(want) {
+		t.Errorf("assets directory holds %d files after two runs, want %d", len(entries), len(want))
+	}
+
+	links := ImageLinks(attachments, dir)
+	if got := links["cid:photo@example"]; got != "assets/image-2.jpg" {
+		t.Errorf("ImageLinks()[cid] = %q, want assets/image-2.jpg", got)
+	}
+}
+
+func TestExtractAttachments_Limits(t *testing.T) {
+	dir := t.TempDir()
+	export := filepath.Join(dir, "page.doc")
+	if err := os.WriteFile(export, []byte(assetsExport), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ExtractAttachmentsWithLimits(export, filepath.Join(dir, "assets"), MIMELimits{MaxParts: 2})
+	if !errors.Is(err, ErrMIMELimit) {
+		t.Errorf("error = %v, want ErrMIMELimit", err)
+	}
+}
+
+func TestAttachmentFileName(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    map[string]string
+		mediaType string
+		want      string
+	}{
+		{"disposition", map[string]string{"Content-Disposition": `inline; filename="shot.png"`}, "image/png", "shot.png"},
+		{"location", map[string]string{"Content-Location": "https://x/download/attachments/1/a%3Ab.gif?v=1"}, "image/gif", "a-b.gif"},
+		{"windows location", map[string]string{"Content-Location": `file:///C:\tmp\pic.jpeg`}, "image/jpeg", "pic.jpeg"},
+		{"no name", map[string]string{}, "image/png", "image-3.png"},
+		{"no extension", map[string]string{"Content-Location": "https://x/thumbnail"}, "image/jpeg", "thumbnail.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := make(map[string][]string)
+			for k, v := range tt.header {
+				header[k] = []string{v}
+			}
+			if got := attachmentFileName(header, tt.mediaType, nil, 3); got != tt.want {
+				t.Errorf("attachmentFileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// ErrMIMELimit is returned (wrapped) when an export exceeds one of the
+// MIMELimits.
+var ErrMIMELimit = errors.New("MIME limit exceeded")
+
+const (
+	// DefaultMaxHeaderBytes bounds the header block of the message and of
+	// each part. Confluence headers take well under a kilobyte.
+	DefaultMaxHeaderBytes = 64 << 10
+
+	// DefaultMaxParts bounds the number of parts of a multipart export.
+	// Exports carry one part per embedded image besides the HTML.
+	DefaultMaxParts = 10000
+
+	// DefaultMaxPartBytes bounds the decoded size of the HTML part.
+	DefaultMaxPartBytes = 256 << 20
+)
+
+// MIMELimits guard the MIME parser against adversarial exports, such as
+// absurdly many headers, gigantic header lines, or endless parts. Zero
+// fields use the defaults.
+type MIMELimits struct {
+	// MaxHeaderBytes is the largest header block, in bytes, of the message
+	// or of a part.
+	MaxHeaderBytes int
+	// MaxParts is the most parts read from a multipart body.
+	MaxParts int
+	// MaxPartBytes is the largest decoded part, in bytes, that is read.
+	MaxPartBytes int64
+}
+
+// withDefaults returns l with zero fields set to the defaults.
+func (l MIMELimits) withDefaults() MIMELimits {
+	if l.MaxHeaderBytes <= 0 {
+		l.MaxHeaderBytes = DefaultMaxHeaderBytes
+	}
+	if l.MaxParts <= 0 {
+		l.MaxParts = DefaultMaxParts
+	}
+	if l.MaxPartBytes <= 0 {
+		l.MaxPartBytes = DefaultMaxPartBytes
+	}
+	return l
+}
+
+// readLimitedMessage parses a MIME message from r like mail.ReadMessage,
+// but fails with ErrMIMELimit when the header block exceeds the limit
+// instead of buffering it whole.
+func readLimitedMessage(r io.Reader, limits MIMELimits) (*mail.Message, error) {
+	br := bufio.NewReader(r)
+	header, err := readHeaderBlock(br, limits.withDefaults().MaxHeaderBytes)
+	if err != nil {
+		return nil, err
+	}
+	return mail.ReadMessage(io.MultiReader(bytes.NewReader(header), br))
+}
+
+// readHeaderBlock reads the lines of a header block up to and including
+// the blank line ending it, failing once they exceed limit bytes. A single
+// oversized line is caught without reading it to its end. Blank lines and
+// other lines that are no header field before the first header, such as
+// an mbox "From " line, are skipped.
+func readHeaderBlock(br *bufio.Reader, limit int) ([]byte, error) {
+	var header []byte
+	lineStart, read := 0, 0
+	sawHeader := false
+	for {
+		chunk, err := br.ReadSlice('\n')
+		header = append(header, chunk...)
+		read += len(chunk)
+		if read > limit {
+			return nil, fmt.Errorf("%w: header is larger than %d bytes", ErrMIMELimit, limit)
+		}
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF:
+			return header, nil
+		case err != nil:
+			return nil, err
+		}
+		line := header[lineStart:]
+		switch {
+		case sawHeader && len(bytes.TrimRight(line, "\r\n")) == 0:
+			return header, nil
+		case !sawHeader && !isHeaderField(string(line)):
+			header = header[:lineStart]
+			continue
+		}
+		sawHeader = true
+		lineStart = len(header)
+	}
+}
+
+// isHeaderField reports whether line starts a header field: a name without
+// spaces, followed by a colon.
+func isHeaderField(line string) bool {
+	key, _, ok := strings.Cut(line, ":")
+	return ok && key != "" && !strings.ContainsAny(key, " \t")
+}
+
+// checkPartHeader returns an ErrMIMELimit error if the header of part n
+// (counting from 1) exceeds the limits, or if n exceeds the part count.
+func checkPartHeader(n int, header textproto.MIMEHeader, limits MIMELimits) error {
+	if n > limits.MaxParts {
+		return fmt.Errorf("%w: more than %d parts", ErrMIMELimit, limits.MaxParts)
+	}
+	size := 0
+	for key, values := range header {
+		for _, value := range values {
+			// "Key: value\r\n"
+			size += len(key) + len(value) + 4
+		}
+	}
+	if size > limits.MaxHeaderBytes {
+		return fmt.Errorf("%w: header of part %d is larger than %d bytes", ErrMIMELimit, n, limits.MaxHeaderBytes)
+	}
+	return nil
+}
+
+// readPart reads a decoded part, failing with ErrMIMELimit once it exceeds
+// limit bytes.
+func readPart(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: part is larger than %d bytes", ErrMIMELimit, limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,110 @@
+package converter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildMIMEExport returns a Confluence-style export with extra top-level
+// headers, the given number of image parts before the HTML part, and the
+// given HTML.
+func buildMIMEExport(extraHeaders string, images int, html string) string {
+	var b strings.Builder
+	b.WriteString("Date: Wed, 7 Jan 2026 01:29:00 +0000 (UTC)\n")
+	b.WriteString("Subject: Exported From Confluence\n")
+	b.WriteString("MIME-Version: 1.0\n")
+	b.WriteString(extraHeaders)
+	b.WriteString("Content-Type: multipart/related; boundary=\"b\"\n\n")
+	for i := 0; i < images; i++ {
+		fmt.Fprintf(&b, "--b\nContent-Type: image/png\nContent-Location: image%d.png\n\nPNG\n", i)
+	}
+	b.WriteString("--b\nContent-Type: text/html; charset=UTF-8\n\n")
+	b.WriteString(html)
+	b.WriteString("\n--b--\n")
+	return b.String()
+}
+
+func TestExtractHTMLFromMIMEWithLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		limits  MIMELimits
+		wantErr string
+	}{
+		{
+			name:    "within defaults",
+			content: buildMIMEExport("", 3, "<p>Page</p>"),
+		},
+		{
+			name:    "gigantic header line",
+			content: buildMIMEExport("X-Junk: "+strings.Repeat("a", 200000)+"\n", 0, "<p>Page</p>"),
+			wantErr: "header is larger than 65536 bytes",
+		},
+		{
+			name:    "too many headers",
+			content: buildMIMEExport(strings.Repeat("X-Junk: a\n", 100), 0, "<p>Page</p>"),
+			limits:  MIMELimits{MaxHeaderBytes: 512},
+			wantErr: "header is larger than 512 bytes",
+		},
+		{
+			name:    "too many parts",
+			content: buildMIMEExport("", 5, "<p>Page</p>"),
+			limits:  MIMELimits{MaxParts: 3},
+			wantErr: "more than 3 parts",
+		},
+		{
+			name:    "large part header",
+			content: strings.Replace(buildMIMEExport("", 1, "<p>Page</p>"), "Content-Location: image0.png", "Content-Location: "+strings.Repeat("x", 300), 1),
+			limits:  MIMELimits{MaxHeaderBytes: 256},
+			wantErr: "header of part 1 is larger than 256 bytes",
+		},
+		{
+			name:    "large HTML part",
+			content: buildMIMEExport("", 0, "<p>"+strings.Repeat("text ", 100)+"</p>"),
+			limits:  MIMELimits{MaxPartBytes: 100},
+			wantErr: "part is larger than 100 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "export.doc")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			html, err := ExtractHTMLFromMIMEWithLimits(path, tt.limits)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !strings.Contains(html, "<p>Page</p>") {
+					t.Errorf("expected the HTML part, got: %q", html)
+				}
+				return
+			}
+			if !errors.Is(err, ErrMIMELimit) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected ErrMIMELimit containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestReadHeaderBlock(t *testing.T) {
+	input := "Subject: Test\r\nX-Long: " + strings.Repeat("a", 100) + "\r\n\r\nbody"
+	br := bufio.NewReaderSize(strings.NewReader(input), 16)
+	header, err := readHeaderBlock(br, 1024)
+	if err != nil {
+		t.Fatalf("readHeaderBlock() error = %v", err)
+	}
+	if want := strings.TrimSuffix(input, "body"); string(header) != want {
+		t.Errorf("readHeaderBlock() = %q, want %q", header, want)
+	}
+	if rest, _ := br.ReadString(0); rest != "body" {
+		t.Errorf("expected the body to remain unread, got %q", rest)
+	}
+}
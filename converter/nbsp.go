@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NBSPStyle selects how non-breaking spaces in converted Markdown are
+// handled. Confluence authors use runs of them for indentation, and the
+// literal U+00A0 characters pandoc writes look like spaces but break code
+// blocks and table alignment.
+type NBSPStyle string
+
+const (
+	// NBSPKeep leaves non-breaking spaces alone (the default).
+	NBSPKeep NBSPStyle = "keep"
+	// NBSPSpace turns every non-breaking space into a regular space.
+	NBSPSpace NBSPStyle = "space"
+	// NBSPSmart keeps a single non-breaking space between two words, as in
+	// "10 km", and turns all others into regular spaces.
+	NBSPSmart NBSPStyle = "smart"
+)
+
+// NBSPStyles lists the supported non-breaking space styles.
+var NBSPStyles = []NBSPStyle{NBSPKeep, NBSPSpace, NBSPSmart}
+
+// nbspChars are the non-breaking spaces normalizeNBSP handles: U+00A0 and
+// the narrow U+202F.
+const nbspChars = "\u00a0\u202f"
+
+// zeroWidthSpace is an invisible space that only serves as a line break
+// opportunity; it is removed along with non-breaking spaces.
+const zeroWidthSpace = "\u200b"
+
+var (
+	// nbspToSpace and nbspRemover replace or remove non-breaking spaces.
+	nbspToSpace = strings.NewReplacer("\u00a0", " ", "\u202f", " ")
+	nbspRemover = strings.NewReplacer("\u00a0", "", "\u202f", "")
+)
+
+// nbspRunPattern matches a run of non-breaking spaces, with any regular
+// spaces around it.
+var nbspRunPattern = regexp.MustCompile(`[ \t]*[\x{a0}\x{202f}]+[ \t]*`)
+
+// normalizeNBSP replaces non-breaking spaces in md according to style and
+// removes zero-width spaces. In fenced code every non-breaking space
+// becomes a space, so indentation is kept. Elsewhere, non-breaking spaces
+// are dropped from line indentation, where spaces could turn a paragraph
+// into a code block, and from line ends, where they could become a hard
+// break; runs inside a line become a single space.
+func normalizeNBSP(md string, style NBSPStyle) string {
+	if style == "" || style == NBSPKeep || !strings.ContainsAny(md, nbspChars+zeroWidthSpace) {
+		return md
+	}
+
+	lines := strings.Split(md, "\n")
+	inFence := false
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, zeroWidthSpace, "")
+		switch {
+		case fencePattern.MatchString(line):
+			inFence = !inFence
+		case inFence:
+			line = nbspToSpace.Replace(line)
+		case strings.ContainsAny(line, nbspChars):
+			line = normalizeLineNBSP(line, style)
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normalizeLineNBSP applies normalizeNBSP to a line outside fenced code.
+func normalizeLineNBSP(line string, style NBSPStyle) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range nbspRunPattern.FindAllStringIndex(line, -1) {
+		run := line[loc[0]:loc[1]]
+		b.WriteString(line[last:loc[0]])
+		last = loc[1]
+		switch {
+		case strings.TrimLeft(line[:loc[0]], " \t") == "":
+			// Indentation: keep the regular spaces only
+			b.WriteString(nbspRemover.Replace(run))
+		case loc[1] == len(line):
+			// Trailing: drop it
+		case style == NBSPSmart && utf8.RuneCountInString(run) == 1 && isWordBoundary(line, loc[0], loc[1]):
+			b.WriteString(run)
+		default:
+			b.WriteByte(' ')
+		}
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+// isWordBoundary reports whether the text before start and after end
+// are letters, digits, or punctuation rather than spaces.
+func isWordBoundary(line string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(line[:start])
+	after, _ := utf8.DecodeRuneInString(line[end:])
+	return !unicode.IsSpace(before) && !unicode.IsSpace(after)
+}
@@ -0,0 +1,70 @@
+package converter
+
+import (
+	"testing"
+)
+
+func TestNormalizeNBSP(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		style  NBSPStyle
+		expect string
+	}{
+		{
+			name:   "keep by default",
+			input:  "a  b\n",
+			expect: "a  b\n",
+		},
+		{
+			name:   "space collapses runs",
+			input:  "Total:    10 km\n",
+			style:  NBSPSpace,
+			expect: "Total: 10 km\n",
+		},
+		{
+			name:   "smart keeps single nbsp between words",
+			input:  "Total:    10 km and 5 %\n",
+			style:  NBSPSmart,
+			expect: "Total: 10 km and 5 %\n",
+		},
+		{
+			name:   "indentation and trailing nbsp dropped",
+			input:  "    Indented text \n-  item\n",
+			style:  NBSPSmart,
+			expect: "Indented text\n- item\n",
+		},
+		{
+			name:   "list continuation keeps regular indentation",
+			input:  "- item\n   more\n",
+			style:  NBSPSpace,
+			expect: "- item\n  more\n",
+		},
+		{
+			name:   "code keeps alignment",
+			input:  "```\nif x {\n    return  y\n}\n```\n",
+			style:  NBSPSmart,
+			expect: "```\nif x {\n    return  y\n}\n```\n",
+		},
+		{
+			name:   "table cells",
+			input:  "| a | b |\n|---|---|\n|  x | y  |\n",
+			style:  NBSPSpace,
+			expect: "| a | b |\n|---|---|\n| x | y |\n",
+		},
+		{
+			name:   "zero-width spaces removed",
+			input:  "long​word\n",
+			style:  NBSPSpace,
+			expect: "longword\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeNBSP(tt.input, tt.style); got != tt.expect {
+				t.Errorf("normalizeNBSP() = %q, want %q", got, tt.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// nonContentElementPattern matches elements whose text is not page
+	// content but leaks into the output as text: scripts, style sheets,
+	// and the page title.
+	nonContentElementPattern = regexp.MustCompile(`(?is)<script\b.*?</script\s*>|<style\b.*?</style\s*>|<title\b.*?</title\s*>`)
+
+	// headOnlyTagPattern matches the void elements that only describe the
+	// document: meta tags, links to style sheets and the like, and the
+	// document base.
+	headOnlyTagPattern = regexp.MustCompile(`(?i)<(?:meta|link|base)\b[^>]*>`)
+)
+
+// removeNonContent drops the parts of an export that are not page content:
+// <script>, <style>, and <title> elements wherever they are, and <meta>,
+// <link>, and <base> tags. The charset declaration is kept for pandoc, and
+// inline SVG images, whose <title> and <style> elements belong to the
+// image, are left alone. Page information in meta tags is read before
+// this step (see ExtractPageInfo).
+func removeNonContent(htmlContent string) string {
+	var b strings.Builder
+	for _, seg := range splitRegions(htmlContent, svgElementPattern) {
+		if seg.region {
+			b.WriteString(seg.text)
+			continue
+		}
+		text := nonContentElementPattern.ReplaceAllString(seg.text, "")
+		text = headOnlyTagPattern.ReplaceAllStringFunc(text, func(tag string) string {
+			if metaCharsetPattern.MatchString(tag) {
+				return tag
+			}
+			return ""
+		})
+		b.WriteString(text)
+	}
+	return b.String()
+}
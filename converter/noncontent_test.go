@@ -0,0 +1,55 @@
+package converter
+
+import "testing"
+
+func TestRemoveNonContent(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "head elements",
+			html: `<html><head><meta charset="utf-8"><title>Page</title><meta name="ajs-page-id" content="1"><link rel="stylesheet" href="a.css"><base href="https://wiki/"><style>body { color: red }</style><script>var x = 1;</script></head><body><p>Text</p></body></html>`,
+			want: `<html><head><meta charset="utf-8"></head><body><p>Text</p></body></html>`,
+		},
+		{
+			name: "inline style block in the body",
+			html: "<body><style type=\"text/css\">\n.confluenceTable { border: 1px }\n</style><p>Text</p></body>",
+			want: "<body><p>Text</p></body>",
+		},
+		{
+			name: "scripts in the body",
+			html: `<p>A</p><script type="text/javascript">AJS.toInit(function() {});</script><p>B</p><SCRIPT src="x.js"></SCRIPT>`,
+			want: `<p>A</p><p>B</p>`,
+		},
+		{
+			name: "stray title in the body",
+			html: "<body><title>Page</title><p>Text</p></body>",
+			want: "<body><p>Text</p></body>",
+		},
+		{
+			name: "svg title and style kept",
+			html: "<p>A</p><svg><title>Arrow</title><style>path { fill: red }</style></svg>",
+			want: "<p>A</p><svg><title>Arrow</title><style>path { fill: red }</style></svg>",
+		},
+		{
+			name: "style attributes kept",
+			html: `<p style="color: red">Text</p>`,
+			want: `<p style="color: red">Text</p>`,
+		},
+		{
+			name: "code about scripts kept",
+			html: `<pre>&lt;script&gt;alert(1)&lt;/script&gt;</pre>`,
+			want: `<pre>&lt;script&gt;alert(1)&lt;/script&gt;</pre>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := removeNonContent(tt.html); got != tt.want {
+				t.Errorf("removeNonContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
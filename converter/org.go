@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Marker paragraphs survive pandoc's org writer as plain text lines and are
+// turned into org block syntax afterwards. Pandoc would otherwise drop the
+// Confluence macro divs or render them without any block structure.
+const (
+	orgBeginMarker       = "c2md-begin-"
+	orgEndMarker         = "c2md-end-"
+	orgDrawerBeginMarker = "c2md-drawer-begin"
+	orgDrawerEndMarker   = "c2md-drawer-end"
+)
+
+var (
+	// orgAdmonitionPattern matches the opening tag of Confluence info/tip/note/warning
+	// macros, and of success callouts produced from colored panels.
+	orgAdmonitionPattern = regexp.MustCompile(`<div class="confluence-information-macro confluence-information-macro-(tip|note|warning|information|success)[^"]*"[^>]*>`)
+
+	// orgPanelPattern matches the opening tag of Confluence panel macros.
+	orgPanelPattern = regexp.MustCompile(`<div class="panel"[^>]*>`)
+
+	// orgMarkerLinePattern matches marker lines in pandoc's org output.
+	orgMarkerLinePattern = regexp.MustCompile(`(?m)^([ \t]*)c2md-(begin-\w+|end-\w+|drawer-begin|drawer-end)[ \t]*$`)
+
+	// blankLinesPattern matches runs of two or more blank lines.
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// orgAdmonitionNames maps Confluence macro types to org special block names.
+var orgAdmonitionNames = map[string]string{
+	"tip":         "tip",
+	"note":        "note",
+	"warning":     "warning",
+	"information": "info",
+	"success":     "success",
+}
+
+// prepareOrgHTML rewrites Confluence macros into marker paragraphs: info-style
+// macros become org special blocks, panels become quote blocks, and expanders
+// become a bold summary followed by a DETAILS drawer. Emoticon images are
+// replaced with Unicode emoji, as for Markdown output.
+func prepareOrgHTML(html string) string {
+	html = replaceElements(html, orgAdmonitionPattern, "div", func(inner string, m []string) string {
+		name := orgAdmonitionNames[m[1]]
+		return fmt.Sprintf("<p>%s%s</p>%s<p>%s%s</p>", orgBeginMarker, name, inner, orgEndMarker, name)
+	})
+
+	html = replaceElements(html, orgPanelPattern, "div", func(inner string, m []string) string {
+		return fmt.Sprintf("<p>%squote</p>%s<p>%squote</p>", orgBeginMarker, inner, orgEndMarker)
+	})
+
+	html = replaceElements(html, expanderPattern, "div", func(inner string, m []string) string {
+		title, content := splitExpander(inner, m[1])
+
+		var b strings.Builder
+		if title != "" {
+			fmt.Fprintf(&b, "<p><strong>%s</strong></p>", title)
+		}
+		fmt.Fprintf(&b, "<p>%s</p>%s<p>%s</p>", orgDrawerBeginMarker, content, orgDrawerEndMarker)
+		return b.String()
+	})
+
+	return replaceEmoticonImages(html)
+}
+
+// restoreOrgMarkers turns marker lines in pandoc's org output into org
+// special blocks and drawers, keeping their indentation.
+func restoreOrgMarkers(org string) string {
+	org = orgMarkerLinePattern.ReplaceAllStringFunc(org, func(line string) string {
+		m := orgMarkerLinePattern.FindStringSubmatch(line)
+		indent, marker := m[1], m[2]
+		switch {
+		case marker == "drawer-begin":
+			return indent + ":DETAILS:"
+		case marker == "drawer-end":
+			return indent + ":END:"
+		case strings.HasPrefix(marker, "begin-"):
+			return indent + "#+begin_" + strings.TrimPrefix(marker, "begin-")
+		default:
+			return indent + "#+end_" + strings.TrimPrefix(marker, "end-")
+		}
+	})
+
+	// Collapse the blank lines pandoc puts between paragraphs and markers
+	org = blankLinesPattern.ReplaceAllString(org, "\n\n")
+	return strings.TrimSpace(org) + "\n"
+}
@@ -0,0 +1,102 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrepareOrgHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "info macro becomes special block markers",
+			input:    `<div class="confluence-information-macro confluence-information-macro-information conf-macro"><div class="confluence-information-macro-body"><p>Read this</p></div></div>`,
+			contains: []string{"<p>c2md-begin-info</p>", "<p>Read this</p>", "<p>c2md-end-info</p>"},
+			excludes: []string{"confluence-information-macro-information"},
+		},
+		{
+			name:     "warning macro",
+			input:    `<div class="confluence-information-macro confluence-information-macro-warning"><p>Careful</p></div>`,
+			contains: []string{"<p>c2md-begin-warning</p><p>Careful</p><p>c2md-end-warning</p>"},
+		},
+		{
+			name:     "panel becomes quote block",
+			input:    `<div class="panel"><div class="panelContent"><p>Panel text</p></div></div><p>After</p>`,
+			contains: []string{"<p>c2md-begin-quote</p>", "<p>c2md-end-quote</p><p>After</p>"},
+		},
+		{
+			name:     "expander becomes summary and drawer",
+			input:    `<div id="expander-123" class="expand-container"><div id="expander-control-123" class="expand-control"><span class="expand-control-text">Show more</span></div><div id="expander-content-123" class="expand-content"><p>Hidden</p></div></div>`,
+			contains: []string{"<p><strong>Show more</strong></p><p>c2md-drawer-begin</p>", "<p>Hidden</p>", "<p>c2md-drawer-end</p>"},
+			excludes: []string{"expander-control-123"},
+		},
+		{
+			name:     "nested macros",
+			input:    `<div class="panel"><div class="confluence-information-macro confluence-information-macro-tip"><p>Tip</p></div></div>`,
+			contains: []string{"<p>c2md-begin-quote</p><p>c2md-begin-tip</p><p>Tip</p><p>c2md-end-tip</p><p>c2md-end-quote</p>"},
+		},
+		{
+			name:     "emoticon replaced",
+			input:    `<p><img src="images/icons/emoticons/check.svg" alt="(tick)"> Done</p>`,
+			contains: []string{"<p>✅ Done</p>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := prepareOrgHTML(tt.input)
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("prepareOrgHTML() = %q, want it to contain %q", got, want)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("prepareOrgHTML() = %q, should not contain %q", got, unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreOrgMarkers(t *testing.T) {
+	input := "* Heading\n\nc2md-begin-warning\n\nCareful\n\nc2md-end-warning\n\n*Show more*\n\nc2md-drawer-begin\n\nHidden\n\nc2md-drawer-end\n\n- item\n\n  c2md-begin-note\n\n  Nested\n\n  c2md-end-note\n"
+	want := "* Heading\n\n#+begin_warning\n\nCareful\n\n#+end_warning\n\n*Show more*\n\n:DETAILS:\n\nHidden\n\n:END:\n\n- item\n\n  #+begin_note\n\n  Nested\n\n  #+end_note\n"
+
+	if got := restoreOrgMarkers(input); got != want {
+		t.Errorf("restoreOrgMarkers() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestOutputFormatExtension(t *testing.T) {
+	tests := []struct {
+		format OutputFormat
+		want   string
+	}{
+		{"", ".md"},
+		{FormatMarkdown, ".md"},
+		{FormatOrg, ".org"},
+		{FormatPlain, ".txt"},
+		{FormatJSON, ".json"},
+		{FormatDOCX, ".docx"},
+		{FormatPDF, ".pdf"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.format.Extension(); got != tt.want {
+			t.Errorf("OutputFormat(%q).Extension() = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestOutputFormatIsData(t *testing.T) {
+	for _, format := range OutputFormats {
+		if got, want := format.IsData(), format == FormatJSON; got != want {
+			t.Errorf("OutputFormat(%q).IsData() = %v, want %v", format, got, want)
+		}
+	}
+}
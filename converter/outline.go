@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// htmlHeadingPattern captures the level and content of HTML headings.
+	htmlHeadingPattern = regexp.MustCompile(`(?is)<h([1-6])\b[^>]*>(.*?)</h[1-6]>`)
+
+	// htmlParagraphPattern captures the content of HTML paragraphs.
+	htmlParagraphPattern = regexp.MustCompile(`(?is)<p\b[^>]*>(.*?)</p>`)
+
+	// nonContentPattern matches elements whose text is never page content.
+	nonContentPattern = regexp.MustCompile(`(?is)<(script|style|title)\b[^>]*>.*?</(?:script|style|title)>`)
+
+	// wordBoundaryTagPattern matches the tags of block-level elements, whose
+	// boundaries separate words.
+	wordBoundaryTagPattern = regexp.MustCompile(`(?i)</?(?:address|article|aside|blockquote|body|dd|div|dl|dt|figcaption|figure|footer|h[1-6]|header|hr|li|main|nav|ol|p|pre|section|table|tbody|td|tfoot|th|thead|tr|ul)\b[^>]*>`)
+
+	// whitespacePattern matches runs of whitespace.
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// OutlineHeading is a heading in a page outline.
+type OutlineHeading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+}
+
+// PageOutline summarizes a page without converting it: its first paragraph
+// and its headings, as plain text.
+type PageOutline struct {
+	// Lead is the text of the first non-empty paragraph.
+	Lead string
+	// Headings lists the page's headings in document order.
+	Headings []OutlineHeading
+}
+
+// ExtractOutline reads the first paragraph and the headings from export
+// HTML. It is much cheaper than a conversion, for cataloging many pages.
+func ExtractOutline(htmlContent string) PageOutline {
+	htmlContent = nonContentPattern.ReplaceAllString(fixDoubleEncoding(htmlContent), "")
+
+	var outline PageOutline
+	for _, m := range htmlHeadingPattern.FindAllStringSubmatch(htmlContent, -1) {
+		if text := plainText(m[2]); text != "" {
+			outline.Headings = append(outline.Headings, OutlineHeading{Level: int(m[1][0] - '0'), Text: text})
+		}
+	}
+	for _, m := range htmlParagraphPattern.FindAllStringSubmatch(htmlContent, -1) {
+		if text := plainText(m[1]); text != "" {
+			outline.Lead = text
+			break
+		}
+	}
+	return outline
+}
+
+// ExtractText returns the visible text of export HTML as a single line,
+// for full-text search indexes.
+func ExtractText(htmlContent string) string {
+	htmlContent = nonContentPattern.ReplaceAllString(fixDoubleEncoding(htmlContent), "")
+	return plainText(wordBoundaryTagPattern.ReplaceAllString(htmlContent, " "))
+}
+
+// plainText strips tags from an HTML fragment, decodes entities, and
+// collapses whitespace. Line breaks become spaces.
+func plainText(fragment string) string {
+	text := brTagPattern.ReplaceAllString(fragment, " ")
+	text = html.UnescapeString(tagPattern.ReplaceAllString(text, ""))
+	text = strings.ReplaceAll(text, "\u00a0", " ")
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+}
@@ -0,0 +1,59 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractOutline(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  PageOutline
+	}{
+		{
+			name: "lead and headings",
+			input: `<html><head><title>Ignored</title><style>p { color: red }</style></head><body>
+<h1>Runbook</h1>
+<p>&nbsp;</p>
+<p>How to <strong>restart</strong> the&nbsp;service.<br/>Read first.</p>
+<h2 id="x">Steps</h2><p>Second</p>
+<h3><span>Rollback</span></h3>
+</body></html>`,
+			want: PageOutline{
+				Lead: "How to restart the service. Read first.",
+				Headings: []OutlineHeading{
+					{Level: 1, Text: "Runbook"},
+					{Level: 2, Text: "Steps"},
+					{Level: 3, Text: "Rollback"},
+				},
+			},
+		},
+		{
+			name:  "double-encoded markup",
+			input: `&lt;h2&gt;Intro&lt;/h2&gt;&lt;p&gt;Hello&lt;/p&gt;`,
+			want:  PageOutline{Lead: "Hello", Headings: []OutlineHeading{{Level: 2, Text: "Intro"}}},
+		},
+		{
+			name:  "empty page",
+			input: `<html><body><h2> </h2></body></html>`,
+			want:  PageOutline{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractOutline(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractOutline() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractText(t *testing.T) {
+	input := `<html><head><title>T</title><script>var x = 1;</script></head><body><h1>Guide</h1><p>First <em>step</em>.</p><ul><li>One</li><li>Two</li></ul><table><tr><td>A</td><td>B</td></tr></table></body></html>`
+	want := "Guide First step. One Two A B"
+	if got := ExtractText(input); got != want {
+		t.Errorf("ExtractText() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,244 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// metaTagPattern matches <meta name="..." content="..."> tags with the
+	// attributes in either order.
+	metaTagPattern = regexp.MustCompile(`(?i)<meta\s[^>]*>`)
+
+	// metaNamePattern and metaContentPattern capture a meta tag's name (or
+	// property) and content.
+	metaNamePattern    = regexp.MustCompile(`(?i)\b(?:name|property)="([^"]*)"`)
+	metaContentPattern = regexp.MustCompile(`(?i)\bcontent="([^"]*)"`)
+
+	// pageURLPattern matches the tags that carry a page's own URL: the
+	// canonical link and the document base.
+	pageURLPattern = regexp.MustCompile(`(?i)<(?:link\s[^>]*rel="canonical"|base\s)[^>]*href="([^"]*)"`)
+
+	// pageIDPattern captures the page ID from Confluence page URLs
+	// (viewpage.action?pageId=N or /spaces/KEY/pages/N/...).
+	pageIDPattern = regexp.MustCompile(`[?&]pageId=(\d+)|/pages/(\d+)(?:/|$)`)
+
+	// spaceKeyQueryPattern captures the space key from ?spaceKey=KEY URLs.
+	spaceKeyQueryPattern = regexp.MustCompile(`[?&]spaceKey=([A-Za-z0-9_~-]+)`)
+)
+
+// pageIDMetaNames and spaceKeyMetaNames are the meta tags Confluence uses
+// for the page ID and space key.
+var (
+	pageIDMetaNames   = []string{"ajs-page-id", "confluence-page-id"}
+	spaceKeyMetaNames = []string{"ajs-space-key", "confluence-space-key"}
+)
+
+// labelMetaNames, statusMetaNames, and contentTypeMetaNames are the meta
+// tags carrying a page's labels, content status, and content type.
+var (
+	labelMetaNames       = []string{"ajs-labels", "confluence-labels", "keywords"}
+	statusMetaNames      = []string{"ajs-content-status", "confluence-content-status"}
+	contentTypeMetaNames = []string{"ajs-content-type", "confluence-content-type"}
+)
+
+// restrictedMetaNames, readUserMetaNames, and readGroupMetaNames are the
+// meta tags the exports of fetched pages record view restrictions in.
+var (
+	restrictedMetaNames = []string{"confluence-restricted"}
+	readUserMetaNames   = []string{"confluence-read-users"}
+	readGroupMetaNames  = []string{"confluence-read-groups"}
+)
+
+// publishedMetaNames are the meta tags the exports of fetched blog posts
+// record their publication date in, as RFC 3339.
+var publishedMetaNames = []string{"confluence-published"}
+
+// labelSeparatorPattern splits label lists on commas and whitespace.
+var labelSeparatorPattern = regexp.MustCompile(`[,\s]+`)
+
+// PageInfo identifies the Confluence page an export was made from.
+type PageInfo struct {
+	// PageID is the numeric Confluence page ID, or empty if unknown.
+	PageID string
+	// SpaceKey is the key of the page's space, or empty if unknown.
+	SpaceKey string
+	// Labels are the page's labels, lowercased.
+	Labels []string
+	// Status is the content status, such as "current" or "draft", or
+	// empty if unknown.
+	Status string
+	// ContentType is the content type, such as "page", "blogpost", or
+	// "template", or empty if unknown.
+	ContentType string
+	// Published is when a blog post was published, or zero if unknown.
+	Published time.Time
+	// Restricted reports whether viewing the page is restricted, and
+	// ReadUsers and ReadGroups name who its view restrictions allow.
+	Restricted bool
+	ReadUsers  []string
+	ReadGroups []string
+	// Breadcrumbs are the titles of the page's breadcrumbs, starting with
+	// the space, or empty if the export has none.
+	Breadcrumbs []string
+}
+
+// ExtractPageInfo finds the page ID and space key in export HTML. Meta tags
+// are preferred, then the page's canonical or base URL. As a last resort
+// the page ID is taken from the attachment download paths of the page's own
+// images, which carry the ID of the page they are attached to. Breadcrumbs
+// are read from the export's breadcrumb list.
+func ExtractPageInfo(htmlContent string) PageInfo {
+	var info PageInfo
+	meta := metaValues(htmlContent)
+	info.PageID = firstValue(meta, pageIDMetaNames)
+	info.SpaceKey = firstValue(meta, spaceKeyMetaNames)
+	info.Status = strings.ToLower(firstValue(meta, statusMetaNames))
+	info.ContentType = strings.ToLower(firstValue(meta, contentTypeMetaNames))
+	for _, label := range labelSeparatorPattern.Split(firstValue(meta, labelMetaNames), -1) {
+		if label != "" {
+			info.Labels = append(info.Labels, strings.ToLower(label))
+		}
+	}
+
+	info.Published, _ = time.Parse(time.RFC3339, firstValue(meta, publishedMetaNames))
+	info.ReadUsers = splitList(firstValue(meta, readUserMetaNames))
+	info.ReadGroups = splitList(firstValue(meta, readGroupMetaNames))
+	info.Restricted = strings.EqualFold(firstValue(meta, restrictedMetaNames), "true") ||
+		len(info.ReadUsers) > 0 || len(info.ReadGroups) > 0
+
+	if m := pageURLPattern.FindStringSubmatch(htmlContent); m != nil {
+		pageURL := html.UnescapeString(m[1])
+		if info.PageID == "" {
+			if id := pageIDPattern.FindStringSubmatch(pageURL); id != nil {
+				info.PageID = id[1] + id[2]
+			}
+		}
+		if info.SpaceKey == "" {
+			if key := spaceKeyPattern.FindStringSubmatch(pageURL); key != nil {
+				info.SpaceKey = key[1]
+			} else if key := spaceKeyQueryPattern.FindStringSubmatch(pageURL); key != nil {
+				info.SpaceKey = key[1]
+			}
+		}
+	}
+
+	if info.PageID == "" {
+		info.PageID = mostFrequentAttachmentPage(htmlContent)
+	}
+	info.Breadcrumbs = extractBreadcrumbs(htmlContent)
+	return info
+}
+
+// IsDraft reports whether the page is an unpublished draft.
+func (p PageInfo) IsDraft() bool {
+	return p.Status == "draft"
+}
+
+// IsTemplate reports whether the export is a page template.
+func (p PageInfo) IsTemplate() bool {
+	return p.ContentType == "template"
+}
+
+// IsBlogPost reports whether the export is a blog post.
+func (p PageInfo) IsBlogPost() bool {
+	return p.ContentType == "blogpost"
+}
+
+// HasLabel reports whether the page carries label, ignoring case.
+func (p PageInfo) HasLabel(label string) bool {
+	return slices.Contains(p.Labels, strings.ToLower(label))
+}
+
+// FrontMatter returns the confluence_page_id and confluence_space front
+// matter fields for the known values.
+func (p PageInfo) FrontMatter() []FrontMatterField {
+	var fields []FrontMatterField
+	if p.PageID != "" {
+		fields = append(fields, FrontMatterField{Key: "confluence_page_id", Value: p.PageID})
+	}
+	if p.SpaceKey != "" {
+		fields = append(fields, FrontMatterField{Key: "confluence_space", Value: p.SpaceKey})
+	}
+	return fields
+}
+
+// BlogFrontMatter returns the date front matter field of a blog post with a
+// known publication date, or nil for other exports.
+func (p PageInfo) BlogFrontMatter() []FrontMatterField {
+	if !p.IsBlogPost() || p.Published.IsZero() {
+		return nil
+	}
+	return []FrontMatterField{{Key: "date", Value: p.Published}}
+}
+
+// RestrictionFrontMatter returns the restricted, restricted_users, and
+// restricted_groups front matter fields of a restricted page, or nil for a
+// page anyone in its space can view.
+func (p PageInfo) RestrictionFrontMatter() []FrontMatterField {
+	if !p.Restricted {
+		return nil
+	}
+	fields := []FrontMatterField{{Key: "restricted", Value: true}}
+	if len(p.ReadUsers) > 0 {
+		fields = append(fields, FrontMatterField{Key: "restricted_users", Value: p.ReadUsers})
+	}
+	if len(p.ReadGroups) > 0 {
+		fields = append(fields, FrontMatterField{Key: "restricted_groups", Value: p.ReadGroups})
+	}
+	return fields
+}
+
+// splitList splits a comma-separated meta tag value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// metaValues returns the content of each named meta tag, keyed by the
+// lowercased name.
+func metaValues(htmlContent string) map[string]string {
+	values := make(map[string]string)
+	for _, tag := range metaTagPattern.FindAllString(htmlContent, -1) {
+		name := metaNamePattern.FindStringSubmatch(tag)
+		content := metaContentPattern.FindStringSubmatch(tag)
+		if name != nil && content != nil {
+			values[strings.ToLower(name[1])] = strings.TrimSpace(html.UnescapeString(content[1]))
+		}
+	}
+	return values
+}
+
+// firstValue returns the first non-empty value among names.
+func firstValue(values map[string]string, names []string) string {
+	for _, name := range names {
+		if v := values[name]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// mostFrequentAttachmentPage returns the page ID that most attachment
+// download paths belong to. On ties, the ID that reached the count first wins.
+func mostFrequentAttachmentPage(htmlContent string) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, m := range attachmentPathPattern.FindAllStringSubmatch(htmlContent, -1) {
+		counts[m[1]]++
+		if counts[m[1]] > counts[best] {
+			best = m[1]
+		}
+	}
+	return best
+}
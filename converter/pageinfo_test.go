@@ -0,0 +1,126 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExtractPageInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want PageInfo
+	}{
+		{
+			name: "meta tags",
+			html: `<head><meta name="ajs-page-id" content="123456"><meta content="DOCS" name="ajs-space-key"></head>`,
+			want: PageInfo{PageID: "123456", SpaceKey: "DOCS"},
+		},
+		{
+			name: "canonical viewpage link",
+			html: `<link rel="canonical" href="https://wiki.example.com/pages/viewpage.action?spaceKey=ENG&amp;pageId=42">`,
+			want: PageInfo{PageID: "42", SpaceKey: "ENG"},
+		},
+		{
+			name: "cloud base URL",
+			html: `<base href="https://example.atlassian.net/wiki/spaces/OPS/pages/98765/Runbook">`,
+			want: PageInfo{PageID: "98765", SpaceKey: "OPS"},
+		},
+		{
+			name: "meta wins over links",
+			html: `<meta name="ajs-page-id" content="1"><link rel="canonical" href="/spaces/X/pages/2/T">`,
+			want: PageInfo{PageID: "1", SpaceKey: "X"},
+		},
+		{
+			name: "page ID from attachments",
+			html: `<img src="/download/attachments/555/a.png"><img src="/download/attachments/777/b.png"><img src="/download/attachments/555/c.png">`,
+			want: PageInfo{PageID: "555"},
+		},
+		{
+			name: "labels, status, and content type",
+			html: `<meta name="ajs-labels" content="Runbook, ops  oncall"><meta name="ajs-content-status" content="Draft"><meta name="ajs-content-type" content="page">`,
+			want: PageInfo{Labels: []string{"runbook", "ops", "oncall"}, Status: "draft", ContentType: "page"},
+		},
+		{
+			name: "view restrictions",
+			html: `<meta name="confluence-restricted" content="true"><meta name="confluence-read-users" content="jdoe"><meta name="confluence-read-groups" content="hr, finance">`,
+			want: PageInfo{Restricted: true, ReadUsers: []string{"jdoe"}, ReadGroups: []string{"hr", "finance"}},
+		},
+		{
+			name: "blog post",
+			html: `<meta name="ajs-content-type" content="blogpost"><meta name="confluence-published" content="2026-02-03T09:00:00Z">`,
+			want: PageInfo{ContentType: "blogpost", Published: time.Date(2026, 2, 3, 9, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "breadcrumbs",
+			html: `<meta name="ajs-space-key" content="ENG"><ol id="breadcrumbs"><li><a href="/display/ENG">Engineering</a></li></ol>`,
+			want: PageInfo{SpaceKey: "ENG", Breadcrumbs: []string{"Engineering"}},
+		},
+		{
+			name: "links to other pages are ignored",
+			html: `<a href="/pages/viewpage.action?pageId=999">Other</a><a href="/display/OTHER/Page">Page</a>`,
+			want: PageInfo{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractPageInfo(tt.html); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractPageInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageInfo_Predicates(t *testing.T) {
+	info := PageInfo{Labels: []string{"runbook"}, Status: "draft", ContentType: "template"}
+	if !info.IsDraft() || !info.IsTemplate() {
+		t.Errorf("Expected a draft template: %+v", info)
+	}
+	if !info.HasLabel("RunBook") || info.HasLabel("ops") {
+		t.Errorf("HasLabel() mismatch for %+v", info)
+	}
+	if (PageInfo{Status: "current", ContentType: "page"}).IsDraft() {
+		t.Error("current page reported as draft")
+	}
+}
+
+func TestPageInfo_FrontMatter(t *testing.T) {
+	got := PageInfo{PageID: "42", SpaceKey: "ENG"}.FrontMatter()
+	want := []FrontMatterField{
+		{Key: "confluence_page_id", Value: "42"},
+		{Key: "confluence_space", Value: "ENG"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FrontMatter() = %v, want %v", got, want)
+	}
+	if got := (PageInfo{}).FrontMatter(); got != nil {
+		t.Errorf("FrontMatter() of empty info = %v, want nil", got)
+	}
+}
+
+func TestPageInfo_BlogFrontMatter(t *testing.T) {
+	published := time.Date(2026, 2, 3, 9, 0, 0, 0, time.UTC)
+	got := PageInfo{ContentType: "blogpost", Published: published}.BlogFrontMatter()
+	if want := []FrontMatterField{{Key: "date", Value: published}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("BlogFrontMatter() = %v, want %v", got, want)
+	}
+	if got := (PageInfo{ContentType: "page", Published: published}).BlogFrontMatter(); got != nil {
+		t.Errorf("BlogFrontMatter() of a page = %v, want nil", got)
+	}
+}
+
+func TestPageInfo_RestrictionFrontMatter(t *testing.T) {
+	got := PageInfo{Restricted: true, ReadGroups: []string{"hr"}}.RestrictionFrontMatter()
+	want := []FrontMatterField{
+		{Key: "restricted", Value: true},
+		{Key: "restricted_groups", Value: []string{"hr"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RestrictionFrontMatter() = %v, want %v", got, want)
+	}
+	if got := (PageInfo{PageID: "42"}).RestrictionFrontMatter(); got != nil {
+		t.Errorf("RestrictionFrontMatter() of an unrestricted page = %v, want nil", got)
+	}
+}
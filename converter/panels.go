@@ -0,0 +1,211 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AdmonitionType names the callout a Confluence panel is converted to.
+type AdmonitionType string
+
+const (
+	// AdmonitionNone keeps the panel as a plain blockquote.
+	AdmonitionNone AdmonitionType = "none"
+	// AdmonitionInfo renders the panel like an info macro.
+	AdmonitionInfo AdmonitionType = "info"
+	// AdmonitionNote renders the panel like a note macro.
+	AdmonitionNote AdmonitionType = "note"
+	// AdmonitionTip renders the panel like a tip macro.
+	AdmonitionTip AdmonitionType = "tip"
+	// AdmonitionSuccess renders the panel as a success callout.
+	AdmonitionSuccess AdmonitionType = "success"
+	// AdmonitionWarning renders the panel like a warning macro.
+	AdmonitionWarning AdmonitionType = "warning"
+)
+
+// AdmonitionTypes lists the supported panel mappings.
+var AdmonitionTypes = []AdmonitionType{AdmonitionNone, AdmonitionInfo, AdmonitionNote, AdmonitionTip, AdmonitionSuccess, AdmonitionWarning}
+
+// DefaultPanelColors maps the background colors of Confluence's built-in
+// panel palette to admonition types. Keys are normalized with NormalizeColor.
+var DefaultPanelColors = map[string]AdmonitionType{
+	"#deebff": AdmonitionInfo,    // blue (info panel)
+	"#eae6ff": AdmonitionNote,    // purple (note panel)
+	"#e3fcef": AdmonitionSuccess, // green (success panel)
+	"#fffae6": AdmonitionWarning, // yellow (warning panel)
+	"#ffebe6": AdmonitionWarning, // red (error panel)
+}
+
+// panelTitleTypes maps the first word of a panel title to an admonition
+// type, for panels whose color is not in the color table.
+var panelTitleTypes = map[string]AdmonitionType{
+	"info":        AdmonitionInfo,
+	"information": AdmonitionInfo,
+	"note":        AdmonitionNote,
+	"tip":         AdmonitionTip,
+	"hint":        AdmonitionTip,
+	"success":     AdmonitionSuccess,
+	"done":        AdmonitionSuccess,
+	"warning":     AdmonitionWarning,
+	"caution":     AdmonitionWarning,
+	"important":   AdmonitionWarning,
+	"danger":      AdmonitionWarning,
+	"error":       AdmonitionWarning,
+}
+
+// admonitionMacroClasses maps admonition types to the information macro
+// class suffix that the Markdown and Org post-processing already handle.
+var admonitionMacroClasses = map[AdmonitionType]string{
+	AdmonitionInfo:    "information",
+	AdmonitionNote:    "note",
+	AdmonitionTip:     "tip",
+	AdmonitionSuccess: "success",
+	AdmonitionWarning: "warning",
+}
+
+var (
+	// panelPattern matches the opening tag of Confluence panel macros.
+	panelPattern = regexp.MustCompile(`<div class="panel"[^>]*>`)
+
+	// panelHeaderPattern matches the opening tag of a panel's title bar.
+	panelHeaderPattern = regexp.MustCompile(`<div class="panelHeader"[^>]*>`)
+
+	// panelContentPattern matches the opening tag of a panel's body.
+	panelContentPattern = regexp.MustCompile(`<div class="panelContent"[^>]*>`)
+
+	// backgroundColorPattern captures the background color from a style attribute.
+	backgroundColorPattern = regexp.MustCompile(`(?i)background(?:-color)?\s*:\s*([^;"]+)`)
+
+	// rgbColorPattern captures the components of an rgb() or rgba() color.
+	rgbColorPattern = regexp.MustCompile(`(?i)^rgba?\(\s*(\d{1,3})\s*,\s*(\d{1,3})\s*,\s*(\d{1,3})\s*(?:,[^)]*)?\)$`)
+
+	// hexColorPattern matches #rgb and #rrggbb colors.
+	hexColorPattern = regexp.MustCompile(`^#(?:[0-9a-f]{3}|[0-9a-f]{6})$`)
+)
+
+// NormalizeColor returns a CSS color in #rrggbb form, accepting #rgb,
+// #rrggbb, and rgb()/rgba() notation in any case.
+func NormalizeColor(color string) (string, error) {
+	c := strings.ToLower(strings.TrimSpace(color))
+	if m := rgbColorPattern.FindStringSubmatch(c); m != nil {
+		var parts [3]int
+		for i := range parts {
+			n, err := strconv.Atoi(m[i+1])
+			if err != nil || n > 255 {
+				return "", fmt.Errorf("invalid color %q", color)
+			}
+			parts[i] = n
+		}
+		return fmt.Sprintf("#%02x%02x%02x", parts[0], parts[1], parts[2]), nil
+	}
+	if !hexColorPattern.MatchString(c) {
+		return "", fmt.Errorf("invalid color %q", color)
+	}
+	if len(c) == 4 {
+		c = string([]byte{'#', c[1], c[1], c[2], c[2], c[3], c[3]})
+	}
+	return c, nil
+}
+
+// ValidatePanelColors reports whether a panel color table has valid colors
+// and admonition types.
+func ValidatePanelColors(colors map[string]AdmonitionType) error {
+	for color, typ := range colors {
+		if _, err := NormalizeColor(color); err != nil {
+			return fmt.Errorf("panel color table: %w", err)
+		}
+		if typ != AdmonitionNone && admonitionMacroClasses[typ] == "" {
+			return fmt.Errorf("panel color table: unknown admonition type %q for %s", typ, color)
+		}
+	}
+	return nil
+}
+
+// panelType picks the admonition type for a panel from its background
+// color, looking in custom before DefaultPanelColors, and then from the
+// first word of its title. It returns "" when nothing matches.
+func panelType(color, title string, custom map[string]AdmonitionType) AdmonitionType {
+	if c, err := NormalizeColor(color); err == nil {
+		for key, typ := range custom {
+			if k, err := NormalizeColor(key); err == nil && k == c {
+				return typ
+			}
+		}
+		if typ, ok := DefaultPanelColors[c]; ok {
+			return typ
+		}
+	}
+	if fields := strings.Fields(strings.ToLower(title)); len(fields) > 0 {
+		return panelTitleTypes[strings.TrimRight(fields[0], ":!.")]
+	}
+	return ""
+}
+
+// applyPanelColors rewrites Confluence panels. A panel whose background
+// color or title maps to an admonition type becomes the matching
+// information macro, so it is rendered like the built-in info, note, tip,
+// and warning macros. Panel titles are kept as a bold lead-in paragraph.
+// Other panels keep their markup and are rendered as plain blockquotes.
+func applyPanelColors(html string, custom map[string]AdmonitionType) string {
+	searchFrom := 0
+	for {
+		loc := panelPattern.FindStringIndex(html[searchFrom:])
+		if loc == nil {
+			return html
+		}
+		start := searchFrom + loc[0]
+		openEnd := searchFrom + loc[1]
+		end := findElementEnd(html, start, "div")
+		if end == -1 {
+			searchFrom = openEnd
+			continue
+		}
+		open := html[start:openEnd]
+		inner := elementInner(html, start, end, "div")
+
+		title := ""
+		if h := panelHeaderPattern.FindStringIndex(inner); h != nil {
+			if hEnd := findElementEnd(inner, h[0], "div"); hEnd != -1 {
+				title = strings.TrimSpace(tagPattern.ReplaceAllString(elementInner(inner, h[0], hEnd, "div"), ""))
+				inner = inner[:h[0]] + inner[hEnd:]
+			}
+		}
+		lead := ""
+		if title != "" {
+			lead = "<p><strong>" + title + "</strong></p>"
+		}
+
+		color := ""
+		if m := backgroundColorPattern.FindStringSubmatch(open); m != nil {
+			color = m[1]
+		} else if c := panelContentPattern.FindString(inner); c != "" {
+			if m := backgroundColorPattern.FindStringSubmatch(c); m != nil {
+				color = m[1]
+			}
+		}
+
+		typ := panelType(color, title, custom)
+		class := admonitionMacroClasses[typ]
+		if class == "" {
+			// Keep the panel and continue inside it, so nested panels
+			// are handled too.
+			html = html[:start] + open + lead + inner + "</div>" + html[end:]
+			searchFrom = openEnd
+			continue
+		}
+
+		body := inner
+		if c := panelContentPattern.FindStringIndex(inner); c != nil {
+			if cEnd := findElementEnd(inner, c[0], "div"); cEnd != -1 {
+				body = elementInner(inner, c[0], cEnd, "div")
+			}
+		}
+		replacement := fmt.Sprintf(`<div class="confluence-information-macro confluence-information-macro-%s"><div class="confluence-information-macro-body">%s%s</div></div>`, class, lead, body)
+		html = html[:start] + replacement + html[end:]
+		searchFrom = start
+	}
+}
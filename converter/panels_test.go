@@ -0,0 +1,129 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeColor(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"#DEEBFF", "#deebff", false},
+		{" #abc ", "#aabbcc", false},
+		{"rgb(222, 235, 255)", "#deebff", false},
+		{"RGBA(0,0,0,0.5)", "#000000", false},
+		{"rgb(256, 0, 0)", "", true},
+		{"blue", "", true},
+		{"#abcd", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := NormalizeColor(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeColor(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeColor(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePanelColors(t *testing.T) {
+	valid := map[string]AdmonitionType{"#fff": AdmonitionTip, "rgb(1,2,3)": AdmonitionNone}
+	if err := ValidatePanelColors(valid); err != nil {
+		t.Errorf("ValidatePanelColors(%v) = %v, want nil", valid, err)
+	}
+	for _, invalid := range []map[string]AdmonitionType{
+		{"white": AdmonitionTip},
+		{"#fff": "danger"},
+		{"#fff": ""},
+	} {
+		if err := ValidatePanelColors(invalid); err == nil {
+			t.Errorf("ValidatePanelColors(%v) = nil, want error", invalid)
+		}
+	}
+}
+
+func TestApplyPanelColors(t *testing.T) {
+	panel := func(style, title, body string) string {
+		header := ""
+		if title != "" {
+			header = `<div class="panelHeader" style="border-bottom-width: 1px;background-color: #0052CC;"><b>` + title + `</b></div>`
+		}
+		return `<div class="panel" style="` + style + `">` + header + `<div class="panelContent" style="` + style + `">` + body + `</div></div>`
+	}
+
+	tests := []struct {
+		name   string
+		html   string
+		custom map[string]AdmonitionType
+		want   string
+	}{
+		{
+			name: "default palette color",
+			html: panel("background-color: #E3FCEF;border-color: #ABF5D1;", "", "<p>Deployed</p>"),
+			want: `<div class="confluence-information-macro confluence-information-macro-success"><div class="confluence-information-macro-body"><p>Deployed</p></div></div>`,
+		},
+		{
+			name: "title kept as bold lead-in",
+			html: panel("background-color: #FFEBE6;", "Deploy freeze", "<p>No releases</p>"),
+			want: `<div class="confluence-information-macro confluence-information-macro-warning"><div class="confluence-information-macro-body"><p><strong>Deploy freeze</strong></p><p>No releases</p></div></div>`,
+		},
+		{
+			name: "color only on panel content",
+			html: `<div class="panel"><div class="panelContent" style="background-color: rgb(222, 235, 255);"><p>FYI</p></div></div>`,
+			want: `<div class="confluence-information-macro confluence-information-macro-information"><div class="confluence-information-macro-body"><p>FYI</p></div></div>`,
+		},
+		{
+			name:   "custom color",
+			html:   panel("background-color: #FFFFCE;", "", "<p>Hint</p>"),
+			custom: map[string]AdmonitionType{"#ffffce": AdmonitionTip},
+			want:   `<div class="confluence-information-macro confluence-information-macro-tip"><div class="confluence-information-macro-body"><p>Hint</p></div></div>`,
+		},
+		{
+			name:   "custom none overrides default",
+			html:   panel("background-color: #DEEBFF;", "Details", "<p>Text</p>"),
+			custom: map[string]AdmonitionType{"#DEEBFF": AdmonitionNone},
+			want:   `<div class="panel" style="background-color: #DEEBFF;"><p><strong>Details</strong></p><div class="panelContent" style="background-color: #DEEBFF;"><p>Text</p></div></div>`,
+		},
+		{
+			name: "title word",
+			html: panel("background-color: #FFFFFF;", "Caution: hot", "<p>Text</p>"),
+			want: `<div class="confluence-information-macro confluence-information-macro-warning"><div class="confluence-information-macro-body"><p><strong>Caution: hot</strong></p><p>Text</p></div></div>`,
+		},
+		{
+			name: "unmapped panel",
+			html: panel("background-color: #FFFFFF;", "", "<p>Text</p>"),
+			want: panel("background-color: #FFFFFF;", "", "<p>Text</p>"),
+		},
+		{
+			name: "nested panel",
+			html: panel("background-color: #FFFFFF;", "", panel("background-color: #DEEBFF;", "", "<p>Inner</p>")),
+			want: panel("background-color: #FFFFFF;", "", `<div class="confluence-information-macro confluence-information-macro-information"><div class="confluence-information-macro-body"><p>Inner</p></div></div>`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyPanelColors(tt.html, tt.custom); got != tt.want {
+				t.Errorf("applyPanelColors() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostProcessMarkdown_SuccessMacro(t *testing.T) {
+	input := `<div class="confluence-information-macro confluence-information-macro-success">
+<div class="confluence-information-macro-body">
+Shipped.
+</div>
+</div>`
+	if got := postProcessMarkdown(input); !strings.Contains(got, "> **Success:** Shipped.") {
+		t.Errorf("expected success callout, got: %q", got)
+	}
+}
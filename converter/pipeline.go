@@ -0,0 +1,297 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Transform is a named step of the conversion pipeline. HTML steps
+// (StageHTML) prepare the exported page for pandoc; Markdown steps
+// (StageMarkdown) clean up and extend pandoc's output.
+type Transform struct {
+	// Name identifies the step in PipelineConfig.
+	Name string
+	// Stage selects whether the step runs on the HTML before pandoc or on
+	// the Markdown after it.
+	Stage ReplacementStage
+	// Enabled reports whether the step applies to a conversion. Nil means
+	// it always does.
+	Enabled func(opts Options) bool
+	// Apply performs the step.
+	Apply func(s string, opts Options) (string, error)
+}
+
+// PipelineConfig disables and reorders the steps of the default pipeline.
+type PipelineConfig struct {
+	// Disable names the steps to skip.
+	Disable []string `json:"disable,omitempty"`
+	// Order names steps of the same stage to run in the given order,
+	// taking the places the named steps have in the pipeline.
+	Order []string `json:"order,omitempty"`
+}
+
+// TraceFunc receives each pipeline step that changed the document, with
+// the document's size in bytes before and after the step.
+type TraceFunc func(step Transform, before, after int)
+
+// infallible adapts a transformation that cannot fail to Transform.Apply.
+func infallible(fn func(s string, opts Options) string) func(string, Options) (string, error) {
+	return func(s string, opts Options) (string, error) {
+		return fn(s, opts), nil
+	}
+}
+
+// writesTables reports whether the output format gets the table steps:
+// Markdown and pandoc's JSON AST.
+func writesTables(opts Options) bool {
+	return writesMarkdown(opts) || opts.To == FormatJSON
+}
+
+// writesMarkdown reports whether the output format is Markdown.
+func writesMarkdown(opts Options) bool {
+	return opts.To == "" || opts.To == FormatMarkdown
+}
+
+// defaultPipeline is the conversion pipeline, in order.
+var defaultPipeline = []Transform{
+	{Name: "sanitize", Stage: StageHTML, Apply: infallible(func(s string, _ Options) string {
+		s, _ = SanitizeControlChars(s)
+		return s
+	})},
+	{Name: "storage-format", Stage: StageHTML, Apply: infallible(func(s string, _ Options) string {
+		if !IsStorageFormat(s) {
+			return s
+		}
+		info := ExtractPageInfo(s)
+		return StorageToHTML(s, info.SpaceKey, info.PageID)
+	})},
+	{Name: "non-content", Stage: StageHTML, Apply: infallible(func(s string, _ Options) string {
+		return removeNonContent(s)
+	})},
+	{Name: "html-replacements", Stage: StageHTML, Apply: func(s string, opts Options) (string, error) {
+		return applyUserReplacements(s, opts.Replacements, StageHTML)
+	}},
+	{Name: "attachments-section", Stage: StageHTML, Apply: infallible(func(s string, opts Options) string {
+		return applyAttachmentsSection(s, opts.AttachmentsSection)
+	})},
+	{Name: "panel-colors", Stage: StageHTML, Apply: infallible(func(s string, opts Options) string {
+		return applyPanelColors(s, opts.PanelColors)
+	})},
+	{Name: "callout-markers", Stage: StageHTML,
+		Enabled: func(opts Options) bool { return writesMarkdown(opts) && opts.Flavor.writesCallouts() },
+		Apply:   infallible(func(s string, _ Options) string { return markCallouts(s) })},
+	{Name: "expand-details", Stage: StageHTML,
+		Enabled: func(opts Options) bool { return opts.ExpandDetails },
+		Apply:   infallible(func(s string, _ Options) string { return expandDetails(s) })},
+	{Name: "caption-markup", Stage: StageHTML, Apply: infallible(func(s string, _ Options) string {
+		return normalizeImageCaptions(s)
+	})},
+	{Name: "confluence-markup", Stage: StageHTML, Apply: infallible(func(s string, opts Options) string {
+		return preProcessHTMLCells(s, opts.TableCells)
+	})},
+	{Name: "image-captions", Stage: StageHTML, Apply: infallible(func(s string, opts Options) string {
+		return applyImageCaptions(s, opts.ImageCaptions)
+	})},
+	{Name: "image-sizes", Stage: StageHTML, Apply: infallible(func(s string, opts Options) string {
+		return applyImageSizes(s, opts.ImageSizes)
+	})},
+	{Name: "emoticons", Stage: StageHTML, Apply: infallible(func(s string, opts Options) string {
+		return applyEmoticons(s, emoticonTable(opts.Emoticons), opts.EmoticonFallback)
+	})},
+	{Name: "task-list-markers", Stage: StageHTML,
+		Enabled: func(opts Options) bool { return writesMarkdown(opts) && opts.Flavor.writesTaskLists() },
+		Apply:   infallible(func(s string, _ Options) string { return markTaskLists(s) })},
+	{Name: "layout-tables", Stage: StageHTML,
+		Enabled: func(opts Options) bool { return opts.To != FormatOrg && !opts.To.IsBinary() },
+		Apply: infallible(func(s string, opts Options) string {
+			return simplifyLayoutTables(s, opts.SingleCellTables)
+		})},
+	{Name: "table-headers", Stage: StageHTML, Enabled: writesTables, Apply: infallible(func(s string, opts Options) string {
+		return applyTableHeaders(s, opts.TableHeaders)
+	})},
+	{Name: "sort-tables", Stage: StageHTML, Enabled: writesTables, Apply: infallible(func(s string, opts Options) string {
+		return sortTables(s, opts.TableSort)
+	})},
+	{Name: "export-tables", Stage: StageHTML, Enabled: writesMarkdown, Apply: infallible(func(s string, opts Options) string {
+		return exportTables(s, opts.Tables)
+	})},
+	{Name: "hard-break-markers", Stage: StageHTML, Enabled: writesMarkdown, Apply: infallible(func(s string, _ Options) string {
+		return markHardBreaks(s)
+	})},
+	{Name: "footnote-markers", Stage: StageHTML,
+		Enabled: func(opts Options) bool { return writesMarkdown(opts) && opts.Flavor.writesFootnotes() },
+		Apply:   infallible(func(s string, _ Options) string { return convertFootnotes(s) })},
+	{Name: "heading-id-markers", Stage: StageHTML,
+		Enabled: func(opts Options) bool { return opts.HeadingIDs == HeadingIDsAnchors && writesMarkdown(opts) },
+		Apply:   infallible(func(s string, _ Options) string { return markHeadingIDs(s) })},
+	{Name: "attributes", Stage: StageHTML, Enabled: Options.keepsAttributes, Apply: infallible(func(s string, opts Options) string {
+		return keepAttributes(s, opts.KeepAttributes, opts.HeadingIDs == HeadingIDsAttributes)
+	})},
+
+	{Name: "emoji-overrides", Stage: StageMarkdown,
+		// Before cleanup, so overrides win over the defaults
+		Enabled: func(opts Options) bool { return len(opts.Emoticons) > 0 },
+		Apply: infallible(func(s string, opts Options) string {
+			return protectCode(s, func(md string) string { return replaceEmojiCodes(md, opts.Emoticons) })
+		})},
+	{Name: "footnotes", Stage: StageMarkdown,
+		Enabled: func(opts Options) bool { return opts.Flavor.writesFootnotes() },
+		Apply:   infallible(func(s string, _ Options) string { return restoreFootnoteMarkers(s) })},
+	{Name: "heading-anchors", Stage: StageMarkdown,
+		Enabled: func(opts Options) bool { return opts.HeadingIDs == HeadingIDsAnchors },
+		Apply:   infallible(func(s string, _ Options) string { return restoreHeadingAnchors(s) })},
+	{Name: "task-lists", Stage: StageMarkdown, Apply: infallible(func(s string, _ Options) string {
+		return restoreTaskLists(s)
+	})},
+	{Name: "cleanup", Stage: StageMarkdown, Apply: infallible(func(s string, opts Options) string {
+		return postProcessMarkdownSpacing(s, opts.BlankLines, opts.AdmonitionLabels)
+	})},
+	{Name: "hard-breaks", Stage: StageMarkdown, Apply: infallible(func(s string, opts Options) string {
+		return renderHardBreaks(s, opts.HardBreaks)
+	})},
+	{Name: "image-size-suffix", Stage: StageMarkdown,
+		Enabled: func(opts Options) bool { return opts.ImageSizes == ImageSizeSuffix },
+		Apply:   infallible(func(s string, _ Options) string { return sizedImagesToSuffix(s) })},
+	{Name: "markdown-replacements", Stage: StageMarkdown, Apply: func(s string, opts Options) (string, error) {
+		return applyUserReplacements(s, opts.Replacements, StageMarkdown)
+	}},
+	{Name: "nbsp", Stage: StageMarkdown, Apply: infallible(func(s string, opts Options) string {
+		return normalizeNBSP(s, opts.NBSP)
+	})},
+	{Name: "list-indentation", Stage: StageMarkdown, Apply: infallible(func(s string, opts Options) string {
+		return normalizeListIndentation(s, opts.listIndent())
+	})},
+	{Name: "list-numbering", Stage: StageMarkdown, Apply: infallible(func(s string, opts Options) string {
+		return repairListNumbering(s, opts.ListNumbering)
+	})},
+	{Name: "gitlab", Stage: StageMarkdown,
+		Enabled: func(opts Options) bool { return opts.Flavor == FlavorGitLab },
+		Apply:   infallible(func(s string, _ Options) string { return applyGitLabFlavor(s) })},
+	{Name: "callouts", Stage: StageMarkdown,
+		Enabled: func(opts Options) bool { return opts.Flavor.writesCallouts() },
+		Apply: infallible(func(s string, opts Options) string {
+			return restoreCallouts(s, opts.Flavor, opts.AdmonitionLabels)
+		})},
+	{Name: "wikilinks", Stage: StageMarkdown,
+		Enabled: func(opts Options) bool { return opts.Flavor == FlavorObsidian },
+		Apply:   infallible(func(s string, _ Options) string { return obsidianWikilinks(s) })},
+	{Name: "heading-levels", Stage: StageMarkdown,
+		Enabled: func(opts Options) bool { return opts.NormalizeHeadingLevels },
+		Apply:   infallible(func(s string, _ Options) string { return normalizeHeadingLevels(s) })},
+	{Name: "heading-numbers", Stage: StageMarkdown,
+		Enabled: func(opts Options) bool { return opts.NumberHeadings },
+		Apply:   infallible(func(s string, opts Options) string { return numberHeadings(s, opts.Flavor) })},
+	{Name: "toc", Stage: StageMarkdown,
+		Enabled: func(opts Options) bool { return opts.TOCDepth > 0 },
+		Apply: infallible(func(s string, opts Options) string {
+			return insertTOC(s, opts.TOCDepth, opts.Flavor, opts.listIndent())
+		})},
+	{Name: "image-paths", Stage: StageMarkdown, Apply: infallible(func(s string, opts Options) string {
+		return rewriteImagePaths(s, opts.ImagePaths)
+	})},
+	{Name: "alt-text", Stage: StageMarkdown, Apply: infallible(func(s string, opts Options) string {
+		return fillAltText(s, opts.AltText)
+	})},
+	{Name: "links", Stage: StageMarkdown, Apply: infallible(func(s string, opts Options) string {
+		return rewriteLinks(s, opts.BaseURL, opts.LinkMappings, opts.AttachmentPaths)
+	})},
+	{Name: "boilerplate", Stage: StageMarkdown, Apply: infallible(func(s string, opts Options) string {
+		return addBoilerplate(s, opts.Prepend, opts.Append)
+	})},
+	{Name: "liquid-escape", Stage: StageMarkdown,
+		Enabled: func(opts Options) bool { return opts.Target == TargetJekyll },
+		Apply:   infallible(func(s string, _ Options) string { return escapeLiquid(s) })},
+	{Name: "front-matter", Stage: StageMarkdown, Apply: infallible(func(s string, opts Options) string {
+		fields := opts.FrontMatter
+		if opts.DetectLanguage {
+			if lang := DetectLanguage(s); lang != "" {
+				// Cap the capacity so the caller's backing array is never written
+				fields = append(fields[:len(fields):len(fields)], FrontMatterField{Key: "lang", Value: lang})
+			}
+		}
+		return prependFrontMatter(s, fields)
+	})},
+}
+
+// DefaultPipeline returns the steps of the default conversion pipeline, in
+// order. The result may be modified and passed as Options.Pipeline.
+func DefaultPipeline() []Transform {
+	return slices.Clone(defaultPipeline)
+}
+
+// ConfigurePipeline returns pipeline with the steps named in cfg.Disable
+// removed and the steps named in cfg.Order rearranged. Each stage's
+// ordered steps run in the listed order, in the places they had before.
+func ConfigurePipeline(pipeline []Transform, cfg PipelineConfig) ([]Transform, error) {
+	index := make(map[string]int, len(pipeline))
+	for i, t := range pipeline {
+		index[t.Name] = i
+	}
+	lookup := func(name string) (int, error) {
+		i, ok := index[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown transform %q", name)
+		}
+		return i, nil
+	}
+
+	result := slices.Clone(pipeline)
+	seen := make(map[string]bool, len(cfg.Order))
+	for _, stage := range []ReplacementStage{StageHTML, StageMarkdown} {
+		var positions []int
+		var steps []Transform
+		for _, name := range cfg.Order {
+			i, err := lookup(name)
+			if err != nil {
+				return nil, err
+			}
+			if pipeline[i].Stage != stage {
+				continue
+			}
+			if seen[name] {
+				return nil, fmt.Errorf("transform %q is ordered twice", name)
+			}
+			seen[name] = true
+			positions = append(positions, i)
+			steps = append(steps, pipeline[i])
+		}
+		slices.Sort(positions)
+		for j, pos := range positions {
+			result[pos] = steps[j]
+		}
+	}
+
+	disabled := make(map[string]bool, len(cfg.Disable))
+	for _, name := range cfg.Disable {
+		if _, err := lookup(name); err != nil {
+			return nil, err
+		}
+		disabled[name] = true
+	}
+	return slices.DeleteFunc(result, func(t Transform) bool { return disabled[t.Name] }), nil
+}
+
+// runPipeline runs the enabled steps of stage in pipeline on s, falling
+// back to the default pipeline when pipeline is nil. Steps that change s
+// are reported to opts.Trace.
+func runPipeline(pipeline []Transform, stage ReplacementStage, s string, opts Options) (string, error) {
+	if pipeline == nil {
+		pipeline = defaultPipeline
+	}
+	for _, t := range pipeline {
+		if t.Stage != stage || (t.Enabled != nil && !t.Enabled(opts)) {
+			continue
+		}
+		out, err := t.Apply(s, opts)
+		if err != nil {
+			return "", err
+		}
+		if opts.Trace != nil && out != s {
+			opts.Trace(t, len(s), len(out))
+		}
+		s = out
+	}
+	return s, nil
+}
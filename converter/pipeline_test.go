@@ -0,0 +1,231 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// runMarkdownSteps runs the Markdown steps of the default pipeline on md.
+func runMarkdownSteps(t *testing.T, md string, opts Options) string {
+	t.Helper()
+	got, err := runPipeline(nil, StageMarkdown, md, opts)
+	if err != nil {
+		t.Fatalf("runPipeline() error = %v", err)
+	}
+	return got
+}
+
+// transformNames returns the names of the steps of a pipeline.
+func transformNames(pipeline []Transform) []string {
+	names := make([]string, len(pipeline))
+	for i, t := range pipeline {
+		names[i] = t.Name
+	}
+	return names
+}
+
+func TestDefaultPipeline(t *testing.T) {
+	pipeline := DefaultPipeline()
+	seen := make(map[string]bool)
+	markdown := false
+	for _, step := range pipeline {
+		if step.Name == "" || step.Apply == nil {
+			t.Errorf("step %+v has no name or function", step)
+		}
+		if seen[step.Name] {
+			t.Errorf("step %q appears twice", step.Name)
+		}
+		seen[step.Name] = true
+		switch step.Stage {
+		case StageHTML:
+			if markdown {
+				t.Errorf("HTML step %q follows Markdown steps", step.Name)
+			}
+		case StageMarkdown:
+			markdown = true
+		default:
+			t.Errorf("step %q has unknown stage %q", step.Name, step.Stage)
+		}
+	}
+
+	pipeline[0].Name = "changed"
+	if defaultPipeline[0].Name == "changed" {
+		t.Error("DefaultPipeline() shares its array with the default pipeline")
+	}
+}
+
+func TestConfigurePipeline(t *testing.T) {
+	step := func(name string, stage ReplacementStage) Transform {
+		return Transform{Name: name, Stage: stage, Apply: infallible(func(s string, _ Options) string { return s + name })}
+	}
+	pipeline := []Transform{
+		step("a", StageHTML), step("b", StageHTML), step("c", StageHTML),
+		step("x", StageMarkdown), step("y", StageMarkdown), step("z", StageMarkdown),
+	}
+
+	tests := []struct {
+		name    string
+		cfg     PipelineConfig
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "unchanged",
+			want: []string{"a", "b", "c", "x", "y", "z"},
+		},
+		{
+			name: "disable",
+			cfg:  PipelineConfig{Disable: []string{"b", "z"}},
+			want: []string{"a", "c", "x", "y"},
+		},
+		{
+			name: "reorder within the places of the named steps",
+			cfg:  PipelineConfig{Order: []string{"c", "a", "z", "x"}},
+			want: []string{"c", "b", "a", "z", "y", "x"},
+		},
+		{
+			name: "reorder and disable",
+			cfg:  PipelineConfig{Order: []string{"c", "a"}, Disable: []string{"c"}},
+			want: []string{"b", "a", "x", "y", "z"},
+		},
+		{
+			name:    "unknown disabled step",
+			cfg:     PipelineConfig{Disable: []string{"nope"}},
+			wantErr: `unknown transform "nope"`,
+		},
+		{
+			name:    "unknown ordered step",
+			cfg:     PipelineConfig{Order: []string{"a", "nope"}},
+			wantErr: `unknown transform "nope"`,
+		},
+		{
+			name:    "step ordered twice",
+			cfg:     PipelineConfig{Order: []string{"a", "b", "a"}},
+			wantErr: `transform "a" is ordered twice`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConfigurePipeline(pipeline, tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ConfigurePipeline() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConfigurePipeline() error = %v", err)
+			}
+			if names := transformNames(got); !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("ConfigurePipeline() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+
+	if names := transformNames(pipeline); !reflect.DeepEqual(names, []string{"a", "b", "c", "x", "y", "z"}) {
+		t.Errorf("ConfigurePipeline() modified its input: %v", names)
+	}
+}
+
+func TestRunPipeline(t *testing.T) {
+	errFailed := errors.New("failed")
+	pipeline := []Transform{
+		{Name: "html", Stage: StageHTML, Apply: infallible(func(s string, _ Options) string { return s + "<html>" })},
+		{Name: "one", Stage: StageMarkdown, Apply: infallible(func(s string, _ Options) string { return s + "1" })},
+		{Name: "toc only", Stage: StageMarkdown,
+			Enabled: func(opts Options) bool { return opts.TOCDepth > 0 },
+			Apply:   infallible(func(s string, _ Options) string { return s + "T" })},
+		{Name: "two", Stage: StageMarkdown, Apply: infallible(func(s string, _ Options) string { return s + "2" })},
+	}
+
+	if got, _ := runPipeline(pipeline, StageMarkdown, "", Options{}); got != "12" {
+		t.Errorf("runPipeline() = %q, want %q", got, "12")
+	}
+	if got, _ := runPipeline(pipeline, StageMarkdown, "", Options{TOCDepth: 2}); got != "1T2" {
+		t.Errorf("runPipeline() with enabled step = %q, want %q", got, "1T2")
+	}
+
+	pipeline = append(pipeline, Transform{Name: "fail", Stage: StageMarkdown, Apply: func(string, Options) (string, error) {
+		return "", errFailed
+	}})
+	if _, err := runPipeline(pipeline, StageMarkdown, "", Options{}); !errors.Is(err, errFailed) {
+		t.Errorf("runPipeline() error = %v, want %v", err, errFailed)
+	}
+}
+
+func TestRunPipeline_DisabledStep(t *testing.T) {
+	pipeline, err := ConfigurePipeline(DefaultPipeline(), PipelineConfig{Disable: []string{"front-matter"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{FrontMatter: []FrontMatterField{{Key: "title", Value: "Intro"}}}
+	got, err := runPipeline(pipeline, StageMarkdown, "# Intro\n", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "title:") {
+		t.Errorf("runPipeline() without front-matter step = %q, want no front matter", got)
+	}
+	if got := runMarkdownSteps(t, "# Intro\n", opts); !strings.HasPrefix(got, "---\n") {
+		t.Errorf("runPipeline() with front-matter step = %q, want front matter", got)
+	}
+}
+
+func TestRunPipeline_GFMExtensions(t *testing.T) {
+	html := `<ul class="inline-task-list"><li class="checked">Write</li></ul>` +
+		`<p>Claim<sup><a href="#fn1">1</a></sup></p><ol class="footnotes"><li id="fn1">Source.</li></ol>`
+	tests := []struct {
+		flavor Flavor
+		want   bool
+	}{
+		{FlavorGFM, true},
+		{FlavorObsidian, true},
+		{FlavorCommonMark, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.flavor), func(t *testing.T) {
+			opts := Options{Flavor: tt.flavor}
+			got, err := runPipeline(nil, StageHTML, html, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, marker := range []string{"CTWOMDTASKDONE", "[^1]"} {
+				if strings.Contains(got, marker) != tt.want {
+					t.Errorf("HTML steps for %s = %s, has %q = %v, want %v", tt.flavor, got, marker, !tt.want, tt.want)
+				}
+			}
+			if md := runMarkdownSteps(t, `Claim\[^1\]`, opts); strings.Contains(md, "Claim[^1]") != tt.want {
+				t.Errorf("Markdown steps for %s = %q, footnote restored = %v, want %v", tt.flavor, md, !tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunPipeline_Trace(t *testing.T) {
+	pipeline := []Transform{
+		{Name: "grow", Stage: StageMarkdown, Apply: infallible(func(s string, _ Options) string { return s + "abc" })},
+		{Name: "same", Stage: StageMarkdown, Apply: infallible(func(s string, _ Options) string { return s })},
+		{Name: "shrink", Stage: StageMarkdown, Apply: infallible(func(s string, _ Options) string { return s[1:] })},
+	}
+
+	var traced []string
+	opts := Options{Trace: func(step Transform, before, after int) {
+		traced = append(traced, fmt.Sprintf("%s %d->%d", step.Name, before, after))
+	}}
+	got, err := runPipeline(pipeline, StageMarkdown, "x", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "abc" {
+		t.Errorf("runPipeline() = %q, want %q", got, "abc")
+	}
+	want := []string{"grow 1->4", "shrink 4->3"}
+	if !reflect.DeepEqual(traced, want) {
+		t.Errorf("traced steps = %v, want %v", traced, want)
+	}
+}
@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// trailingSpacePattern matches whitespace at the end of lines.
+var trailingSpacePattern = regexp.MustCompile(`(?m)[ \t]+$`)
+
+// preparePlainHTML rewrites Confluence macros for pandoc's plain writer,
+// which drops all markup: info-style macros get a label paragraph from
+// labels (see Options.AdmonitionLabels) so their kind is not lost, expanders are rendered inline, and emoticon images
+// become Unicode emoji.
+func preparePlainHTML(html string, labels map[AdmonitionType]string) string {
+	html = replaceElements(html, orgAdmonitionPattern, "div", func(inner string, m []string) string {
+		return fmt.Sprintf("<p>%s:</p>%s", admonitionLabel(m[1], labels), inner)
+	})
+	html = expandDetails(html)
+	return replaceEmoticonImages(html)
+}
+
+// cleanPlainText tidies pandoc's plain output for downstream text
+// processing: non-breaking spaces become plain spaces, trailing whitespace
+// is removed, runs of blank lines are collapsed, and the text ends with a
+// single newline.
+func cleanPlainText(text string) string {
+	text = strings.ReplaceAll(text, "\u00a0", " ")
+	text = trailingSpacePattern.ReplaceAllString(text, "")
+	text = blankLinesPattern.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text) + "\n"
+}
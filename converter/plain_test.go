@@ -0,0 +1,43 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreparePlainHTML(t *testing.T) {
+	input := `<div class="confluence-information-macro confluence-information-macro-warning"><div class="confluence-information-macro-body"><p>Careful</p></div></div>` +
+		`<div id="expander-1"><div id="expander-control-1"><span class="expand-control-text">More</span></div><div id="expander-content-1"><p>Hidden</p></div></div>`
+	want := `<p>Warning:</p><div class="confluence-information-macro-body"><p>Careful</p></div><p><strong>More</strong></p><p>Hidden</p>`
+	if got := preparePlainHTML(input, nil); got != want {
+		t.Errorf("preparePlainHTML() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCleanPlainText(t *testing.T) {
+	input := "\n\nTitle  \n\n\n\nBody text\t\n\n\n"
+	want := "Title\n\nBody text\n"
+	if got := cleanPlainText(input); got != want {
+		t.Errorf("cleanPlainText() = %q, want %q", got, want)
+	}
+}
+
+func TestConvertHTMLToMarkdown_Plain(t *testing.T) {
+	if err := CheckPandoc(); err != nil {
+		t.Skipf("Pandoc not installed, skipping test: %v", err)
+	}
+
+	input := `<h1>Guide</h1><p>Some <strong>bold</strong> and <a href="https://example.com">linked</a> text.</p>`
+	text, err := ConvertHTMLToMarkdownWithOptions(input, Options{To: FormatPlain})
+	if err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+	for _, markup := range []string{"**", "#", "<", "]("} {
+		if strings.Contains(text, markup) {
+			t.Errorf("expected no %q markup in plain text, got: %s", markup, text)
+		}
+	}
+	if !strings.Contains(text, "Guide") || !strings.Contains(text, "linked") {
+		t.Errorf("expected text content, got: %s", text)
+	}
+}
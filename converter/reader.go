@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// pandocReader is the pandoc reader Confluence exports are read with.
+const pandocReader = "html"
+
+// readerExtensionsPattern matches pandoc extension toggles such as
+// "-native_divs-native_spans+raw_html".
+var readerExtensionsPattern = regexp.MustCompile(`^(?:[+-][a-z0-9_]+)+$`)
+
+// ValidateReaderExtensions reports whether extensions is a list of pandoc
+// extension toggles for Options.ReaderExtensions, each a "+" or "-"
+// followed by an extension name. The empty string is valid.
+func ValidateReaderExtensions(extensions string) error {
+	if extensions != "" && !readerExtensionsPattern.MatchString(extensions) {
+		return fmt.Errorf("invalid reader extensions %q: want toggles like -native_divs+raw_html", extensions)
+	}
+	return nil
+}
+
+// reader returns the pandoc reader with opts.ReaderExtensions applied.
+func (opts Options) reader() string {
+	return pandocReader + opts.ReaderExtensions
+}
+
+// fallbackReader returns the reader tried when opts.reader returns empty
+// output: the same reader with raw HTML kept.
+func (opts Options) fallbackReader() string {
+	return opts.reader() + fallbackExtension
+}
@@ -0,0 +1,99 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateReaderExtensions(t *testing.T) {
+	tests := []struct {
+		extensions string
+		wantErr    bool
+	}{
+		{"", false},
+		{"-native_divs", false},
+		{"-native_divs-native_spans", false},
+		{"-raw_tex+raw_html", false},
+		{"native_divs", true},
+		{"html-native_divs", true},
+		{"-native divs", true},
+		{"+", true},
+		{"-Native_Divs", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.extensions, func(t *testing.T) {
+			err := ValidateReaderExtensions(tt.extensions)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateReaderExtensions(%q) error = %v, wantErr %v", tt.extensions, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOptionsReader(t *testing.T) {
+	tests := []struct {
+		extensions   string
+		wantReader   string
+		wantFallback string
+	}{
+		{"", "html", "html+raw_html"},
+		{"-native_divs-native_spans", "html-native_divs-native_spans", "html-native_divs-native_spans+raw_html"},
+		{"-raw_html", "html-raw_html", "html-raw_html+raw_html"},
+	}
+
+	for _, tt := range tests {
+		opts := Options{ReaderExtensions: tt.extensions}
+		if got := opts.reader(); got != tt.wantReader {
+			t.Errorf("reader() with %q = %q, want %q", tt.extensions, got, tt.wantReader)
+		}
+		if got := opts.fallbackReader(); got != tt.wantFallback {
+			t.Errorf("fallbackReader() with %q = %q, want %q", tt.extensions, got, tt.wantFallback)
+		}
+	}
+}
+
+func TestConvertHTMLToMarkdownWithOptions_ReaderExtensions(t *testing.T) {
+	// The fake pandoc writes the reader it was given
+	installFakePandoc(t, "cat >/dev/null\necho \"$2\"\n")
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"default", Options{Engine: EngineSystem}, "html"},
+		{"extensions", Options{Engine: EngineSystem, ReaderExtensions: "-native_divs-native_spans"}, "html-native_divs-native_spans"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md, err := ConvertHTMLToMarkdownWithOptions("<p>Text</p>", tt.opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if strings.TrimSpace(md) != tt.want {
+				t.Errorf("pandoc reader = %q, want %q", strings.TrimSpace(md), tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertHTMLBatchToMarkdown_ReaderExtensions(t *testing.T) {
+	runs := installFakePandoc(t, fakePandocStripParagraphs)
+	pages := []BatchPage{
+		{HTML: "<p>One</p>", Options: Options{Engine: EngineSystem}},
+		{HTML: "<p>Two</p>", Options: Options{Engine: EngineSystem, ReaderExtensions: "-native_divs"}},
+		{HTML: "<p>Three</p>", Options: Options{Engine: EngineSystem}},
+	}
+
+	results := ConvertHTMLBatchToMarkdown(pages)
+	for i, want := range []string{"One", "Two", "Three"} {
+		if results[i].Err != nil || !strings.Contains(results[i].Markdown, want) {
+			t.Errorf("page %d = %q, %v; want %q", i, results[i].Markdown, results[i].Err, want)
+		}
+	}
+	// The page with other extensions needs a run of its own
+	if n := runs(); n != 2 {
+		t.Errorf("ran pandoc %d times, want 2", n)
+	}
+}
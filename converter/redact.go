@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// RedactionRule replaces text matching Pattern with Placeholder, to scrub
+// e-mail addresses, internal hostnames, or credentials from converted pages
+// before they are published. An empty Placeholder means "[REDACTED:name]".
+type RedactionRule struct {
+	Name        string `json:"name"`
+	Pattern     string `json:"pattern,omitempty"`
+	Placeholder string `json:"placeholder,omitempty"`
+}
+
+// BuiltinRedactionRules are the built-in detectors, in the order they are
+// applied: private keys and tokens come first so their contents are not
+// partially matched by the broader rules.
+var BuiltinRedactionRules = []RedactionRule{
+	{Name: "private-key", Pattern: `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`},
+	{Name: "aws-access-key", Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{Name: "github-token", Pattern: `\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`},
+	{Name: "slack-token", Pattern: `\bxox[abprs]-[A-Za-z0-9-]{10,}\b`},
+	{Name: "jwt", Pattern: `\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\b`},
+	{Name: "email", Pattern: `\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`},
+	{Name: "ipv4", Pattern: `\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b`},
+}
+
+// Redaction counts the matches a rule replaced in a page.
+type Redaction struct {
+	Rule  string `json:"rule"`
+	Count int    `json:"count"`
+}
+
+// Validate reports whether the rule is usable as a custom rule. A rule
+// without a pattern must name a built-in rule, whose placeholder it sets.
+func (r RedactionRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("redaction rule %q is missing name", r.Pattern)
+	}
+	if r.Pattern == "" {
+		if builtinRedactionRule(r.Name) == nil {
+			return fmt.Errorf("redaction rule %q has no pattern and is not a built-in rule", r.Name)
+		}
+		return nil
+	}
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern for redaction rule %q: %w", r.Name, err)
+	}
+	return nil
+}
+
+// placeholder returns the text replacing the rule's matches.
+func (r RedactionRule) placeholder() string {
+	if r.Placeholder != "" {
+		return r.Placeholder
+	}
+	return "[REDACTED:" + r.Name + "]"
+}
+
+// builtinRedactionRule returns the built-in rule with the given name, or nil.
+func builtinRedactionRule(name string) *RedactionRule {
+	for i := range BuiltinRedactionRules {
+		if BuiltinRedactionRules[i].Name == name {
+			return &BuiltinRedactionRules[i]
+		}
+	}
+	return nil
+}
+
+// RedactionRules returns the built-in rules followed by the custom rules
+// with a pattern. Custom rules without a pattern override the placeholder
+// of the built-in rule they name.
+func RedactionRules(custom []RedactionRule) ([]RedactionRule, error) {
+	rules := append([]RedactionRule{}, BuiltinRedactionRules...)
+	for _, r := range custom {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+		if r.Pattern != "" {
+			rules = append(rules, r)
+			continue
+		}
+		for i := range rules {
+			if rules[i].Name == r.Name {
+				rules[i].Placeholder = r.Placeholder
+			}
+		}
+	}
+	return rules, nil
+}
+
+// Redact applies the rules to text in order and returns the redacted text
+// with the number of matches each rule replaced. Rules without matches are
+// left out of the counts, and rules without a pattern are skipped.
+func Redact(text string, rules []RedactionRule) (string, []Redaction, error) {
+	var redactions []Redaction
+	for _, r := range rules {
+		if r.Pattern == "" {
+			continue
+		}
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid pattern for redaction rule %q: %w", r.Name, err)
+		}
+		count := 0
+		placeholder := r.placeholder()
+		text = pattern.ReplaceAllStringFunc(text, func(string) string {
+			count++
+			return placeholder
+		})
+		if count > 0 {
+			redactions = append(redactions, Redaction{Rule: r.Name, Count: count})
+		}
+	}
+	return text, redactions, nil
+}
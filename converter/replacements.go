@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ReplacementStage selects where in the conversion a Replacement applies.
+type ReplacementStage string
+
+const (
+	// StageHTML applies the replacement to the exported HTML before any
+	// other processing.
+	StageHTML ReplacementStage = "html"
+	// StageMarkdown applies the replacement to the Markdown after the
+	// built-in cleanup, before options such as the table of contents and
+	// front matter are applied. The empty stage means StageMarkdown.
+	StageMarkdown ReplacementStage = "markdown"
+)
+
+// Replacement is a user-defined regular expression rewrite, such as
+// replacing an old hostname or stripping a banner macro. Pattern uses Go
+// regexp syntax and Replacement may refer to groups as $1 or ${name}.
+type Replacement struct {
+	Pattern     string           `json:"pattern"`
+	Replacement string           `json:"replacement"`
+	Stage       ReplacementStage `json:"stage,omitempty"`
+}
+
+// Validate reports whether the replacement has a valid pattern and stage.
+func (r Replacement) Validate() error {
+	if r.Stage != "" && r.Stage != StageHTML && r.Stage != StageMarkdown {
+		return fmt.Errorf("replacement %q has unknown stage %q (want %s or %s)", r.Pattern, r.Stage, StageHTML, StageMarkdown)
+	}
+	if r.Pattern == "" {
+		return fmt.Errorf("replacement is missing pattern")
+	}
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return fmt.Errorf("invalid replacement pattern: %w", err)
+	}
+	return nil
+}
+
+// stage returns the stage the replacement applies at.
+func (r Replacement) stage() ReplacementStage {
+	if r.Stage == "" {
+		return StageMarkdown
+	}
+	return r.Stage
+}
+
+// applyUserReplacements applies the replacements for stage to s, in order.
+func applyUserReplacements(s string, replacements []Replacement, stage ReplacementStage) (string, error) {
+	var compiled []regexReplacement
+	for _, r := range replacements {
+		if r.stage() != stage {
+			continue
+		}
+		if err := r.Validate(); err != nil {
+			return "", err
+		}
+		compiled = append(compiled, regexReplacement{regexp.MustCompile(r.Pattern), r.Replacement})
+	}
+	return applyReplacements(s, compiled), nil
+}
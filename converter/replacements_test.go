@@ -0,0 +1,66 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReplacementValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       Replacement
+		wantErr string
+	}{
+		{"markdown stage", Replacement{Pattern: `old\.example\.com`, Replacement: "new.example.com", Stage: StageMarkdown}, ""},
+		{"default stage", Replacement{Pattern: `foo`}, ""},
+		{"html stage", Replacement{Pattern: `<div class="banner">.*?</div>`, Stage: StageHTML}, ""},
+		{"missing pattern", Replacement{Replacement: "x"}, "missing pattern"},
+		{"invalid pattern", Replacement{Pattern: `(unclosed`}, "invalid replacement pattern"},
+		{"unknown stage", Replacement{Pattern: `foo`, Stage: "pdf"}, "unknown stage"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.r.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyUserReplacements(t *testing.T) {
+	replacements := []Replacement{
+		{Pattern: `https://old\.example\.com/(\w+)`, Replacement: "https://new.example.com/$1"},
+		{Pattern: `new\.example\.com/wiki`, Replacement: "docs.example.com"},
+		{Pattern: `<div class="banner">.*?</div>`, Stage: StageHTML},
+	}
+
+	got, err := applyUserReplacements("See https://old.example.com/wiki and <div class=\"banner\">x</div>", replacements, StageMarkdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Rules apply in order, so the second sees the output of the first;
+	// the HTML-stage rule is not applied
+	if want := "See https://docs.example.com and <div class=\"banner\">x</div>"; got != want {
+		t.Errorf("markdown stage = %q, want %q", got, want)
+	}
+
+	got, err = applyUserReplacements("<p>Hi</p><div class=\"banner\">Draft</div>", replacements, StageHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<p>Hi</p>"; got != want {
+		t.Errorf("html stage = %q, want %q", got, want)
+	}
+
+	if _, err := applyUserReplacements("x", []Replacement{{Pattern: `[`}}, StageMarkdown); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
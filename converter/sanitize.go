@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RemovedChars counts the occurrences of a control character that
+// SanitizeControlChars removed or normalized.
+type RemovedChars struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// controlCharNames names the control characters commonly found in exports.
+// Others are named by their code point.
+var controlCharNames = map[rune]string{
+	0x00:   "NUL",
+	0x0B:   "vertical tab",
+	0x0C:   "form feed",
+	0x7F:   "DEL",
+	0xFEFF: "byte-order mark",
+	0xFFFE: "noncharacter U+FFFE",
+}
+
+// SanitizeControlChars strips byte-order marks and control characters,
+// such as NULs from pasted content, which survive conversion and break
+// downstream parsers. Vertical tabs and form feeds, which word processors
+// use as line and page breaks, become newlines. Tabs, newlines, and
+// carriage returns are kept. It returns the cleaned text and what was
+// changed, in order of first occurrence.
+func SanitizeControlChars(s string) (string, []RemovedChars) {
+	if strings.IndexFunc(s, isStrayControlChar) == -1 {
+		return s, nil
+	}
+
+	var removed []RemovedChars
+	index := make(map[rune]int)
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if !isStrayControlChar(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if r == '\v' || r == '\f' {
+			b.WriteByte('\n')
+		}
+		i, ok := index[r]
+		if !ok {
+			name, named := controlCharNames[r]
+			if !named {
+				name = fmt.Sprintf("U+%04X", r)
+			}
+			i = len(removed)
+			index[r] = i
+			removed = append(removed, RemovedChars{Name: name})
+		}
+		removed[i].Count++
+	}
+	return b.String(), removed
+}
+
+// isStrayControlChar reports whether r is a control character that
+// SanitizeControlChars removes or normalizes: C0 and C1 controls other than
+// tab, newline, and carriage return, DEL, the byte-order mark, and U+FFFE.
+func isStrayControlChar(r rune) bool {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return false
+	case r < 0x20 || (r >= 0x7F && r <= 0x9F):
+		return true
+	}
+	return r == 0xFEFF || r == 0xFFFE
+}
@@ -0,0 +1,51 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSanitizeControlChars(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expect      string
+		wantRemoved []RemovedChars
+	}{
+		{
+			name:   "clean text untouched",
+			input:  "<p>Tabs\tand\r\nnewlines stay</p>",
+			expect: "<p>Tabs\tand\r\nnewlines stay</p>",
+		},
+		{
+			name:        "byte-order marks",
+			input:       "\ufeff<p>Page</p>\ufeff",
+			expect:      "<p>Page</p>",
+			wantRemoved: []RemovedChars{{Name: "byte-order mark", Count: 2}},
+		},
+		{
+			name:        "NULs and other controls",
+			input:       "<p>pa\x00st\x00ed\x01 \u0085text\x7f</p>",
+			expect:      "<p>pasted text</p>",
+			wantRemoved: []RemovedChars{{Name: "NUL", Count: 2}, {Name: "U+0001", Count: 1}, {Name: "U+0085", Count: 1}, {Name: "DEL", Count: 1}},
+		},
+		{
+			name:        "vertical tabs and form feeds become newlines",
+			input:       "line one\vline two\fpage two",
+			expect:      "line one\nline two\npage two",
+			wantRemoved: []RemovedChars{{Name: "vertical tab", Count: 1}, {Name: "form feed", Count: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, removed := SanitizeControlChars(tt.input)
+			if got != tt.expect {
+				t.Errorf("SanitizeControlChars() = %q, want %q", got, tt.expect)
+			}
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("SanitizeControlChars() removed = %+v, want %+v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// htmlHeadingTagPattern, htmlTableTagPattern, and htmlPreTagPattern
+	// match the opening tags of headings, tables, and preformatted blocks.
+	htmlHeadingTagPattern = regexp.MustCompile(`(?i)<h[1-6]\b`)
+	htmlTableTagPattern   = regexp.MustCompile(`(?i)<table\b`)
+	htmlPreTagPattern     = regexp.MustCompile(`(?i)<pre\b`)
+
+	// htmlImageTagPattern matches image tags.
+	htmlImageTagPattern = regexp.MustCompile(`(?i)<img\b[^>]*>`)
+
+	// emoticonClassPattern matches the class Confluence gives emoticons.
+	emoticonClassPattern = regexp.MustCompile(`(?i)\bclass="[^"]*\bemoticon\b`)
+)
+
+// DocumentStats sizes up a page, to help scope review effort.
+type DocumentStats struct {
+	Words      int `json:"words"`
+	Headings   int `json:"headings"`
+	Tables     int `json:"tables"`
+	Images     int `json:"images"`
+	CodeBlocks int `json:"codeBlocks"`
+}
+
+// ComputeStats counts the words, headings, tables, images, and code blocks
+// of export HTML. Emoticons are not counted as images, and each
+// preformatted block, including code macros, counts as a code block. It
+// needs no conversion, so the counts do not depend on the output format.
+func ComputeStats(htmlContent string) DocumentStats {
+	words := len(strings.Fields(ExtractText(htmlContent)))
+	htmlContent = nonContentPattern.ReplaceAllString(htmlContent, "")
+
+	stats := DocumentStats{
+		Words:      words,
+		Headings:   len(htmlHeadingTagPattern.FindAllStringIndex(htmlContent, -1)),
+		Tables:     len(htmlTableTagPattern.FindAllStringIndex(htmlContent, -1)),
+		CodeBlocks: len(htmlPreTagPattern.FindAllStringIndex(htmlContent, -1)),
+	}
+	for _, tag := range htmlImageTagPattern.FindAllString(htmlContent, -1) {
+		if !emoticonClassPattern.MatchString(tag) {
+			stats.Images++
+		}
+	}
+	return stats
+}
+
+// String summarizes the stats as "120 words, 3 headings, 1 table, 0 images,
+// 2 code blocks".
+func (s DocumentStats) String() string {
+	return strings.Join([]string{
+		plural(s.Words, "word"),
+		plural(s.Headings, "heading"),
+		plural(s.Tables, "table"),
+		plural(s.Images, "image"),
+		plural(s.CodeBlocks, "code block"),
+	}, ", ")
+}
+
+// plural formats a count with the noun, adding "s" unless the count is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
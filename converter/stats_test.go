@@ -0,0 +1,53 @@
+package converter
+
+import "testing"
+
+func TestComputeStats(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want DocumentStats
+	}{
+		{
+			name: "empty",
+			html: ``,
+			want: DocumentStats{},
+		},
+		{
+			name: "mixed content",
+			html: `<html><head><title>Ignored title words</title><style>p { color: red }</style></head><body>` +
+				`<h1>Install guide</h1><p>Run the <b>installer</b>&nbsp;now.</p>` +
+				`<h2 id="x">Options</h2><table><tr><td>a</td><td>b</td></tr></table>` +
+				`<div class="code panel"><pre class="syntaxhighlighter-pre">make install</pre></div><pre>ls</pre>` +
+				`<img src="a.png"><img class="emoticon emoticon-tick" src="tick.png" alt="(tick)"><IMG SRC="b.png"/>` +
+				`</body></html>`,
+			want: DocumentStats{Words: 12, Headings: 2, Tables: 1, Images: 2, CodeBlocks: 2},
+		},
+		{
+			name: "nested tables",
+			html: `<table><tr><td><table><tr><td>inner</td></tr></table></td></tr></table>`,
+			want: DocumentStats{Words: 1, Tables: 2},
+		},
+		{
+			name: "words split at block boundaries",
+			html: `<ul><li>one</li><li>two</li></ul><p>three<br>four</p>`,
+			want: DocumentStats{Words: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeStats(tt.html); got != tt.want {
+				t.Errorf("ComputeStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentStats_String(t *testing.T) {
+	got := DocumentStats{Words: 120, Headings: 3, Tables: 1, Images: 0, CodeBlocks: 2}.String()
+	want := "120 words, 3 headings, 1 table, 0 images, 2 code blocks"
+	if got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
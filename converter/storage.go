@@ -0,0 +1,422 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// StorageExtension is the file extension of storage-format pages saved
+// from the REST API (body.storage), which are converted even when they use
+// no ac: or ri: elements.
+const StorageExtension = ".xhtml"
+
+// maxStorageSniffBytes is how much of a file IsStorageFile reads to look
+// for storage-format elements.
+const maxStorageSniffBytes = 64 << 10
+
+const (
+	macroStartTag = "<ac:structured-macro"
+	macroEndTag   = "</ac:structured-macro>"
+
+	taskListStartTag = "<ac:task-list>"
+	taskListEndTag   = "</ac:task-list>"
+)
+
+var (
+	// storageElementPattern matches the ac: and ri: elements of
+	// Confluence's storage format.
+	storageElementPattern = regexp.MustCompile(`<(?:ac|ri):[a-z-]+[\s/>]`)
+
+	// cdataPattern captures the content of CDATA sections.
+	cdataPattern = regexp.MustCompile(`(?s)<!\[CDATA\[(.*?)\]\]>`)
+
+	// storageAttributePattern captures the name and value of the ac: and ri:
+	// attributes of a tag.
+	storageAttributePattern = regexp.MustCompile(`\b((?:ac|ri):[a-z-]+)="([^"]*)"`)
+
+	// macroParameterPattern captures the name and value of a macro
+	// parameter.
+	macroParameterPattern = regexp.MustCompile(`(?s)<ac:parameter\b[^>]*\bac:name="([^"]*)"[^>]*>(.*?)</ac:parameter>`)
+
+	// richTextBodyPattern and plainTextBodyPattern capture macro bodies.
+	richTextBodyPattern  = regexp.MustCompile(`(?s)<ac:rich-text-body>(.*?)</ac:rich-text-body>`)
+	plainTextBodyPattern = regexp.MustCompile(`(?s)<ac:plain-text-body>(.*?)</ac:plain-text-body>`)
+
+	// storageLinkPattern matches links, capturing the link's attributes and
+	// content.
+	storageLinkPattern = regexp.MustCompile(`(?s)<ac:link\b([^>]*)>(.*?)</ac:link>`)
+
+	// storageImagePattern matches images, capturing the image's
+	// attributes and content.
+	storageImagePattern = regexp.MustCompile(`(?s)<ac:image\b([^>]*)>(.*?)</ac:image>`)
+
+	// resourcePattern matches the ri: resource identifier in a link or
+	// image, capturing its type and attributes.
+	resourcePattern = regexp.MustCompile(`<ri:([a-z-]+)\b([^>]*?)\s*/?>`)
+
+	// linkBodyPattern captures the text of a link, plain or rich.
+	linkBodyPattern = regexp.MustCompile(`(?s)<ac:(?:plain-text-link-body|link-body)>(.*?)</ac:(?:plain-text-link-body|link-body)>`)
+
+	// emoticonElementPattern matches emoticons, capturing their attributes.
+	emoticonElementPattern = regexp.MustCompile(`<ac:emoticon\b([^>]*?)\s*/?>`)
+
+	// Tasks and their parts.
+	taskPattern       = regexp.MustCompile(`(?s)<ac:task>(.*?)</ac:task>`)
+	taskStatusPattern = regexp.MustCompile(`<ac:task-status>\s*([a-z]+)\s*</ac:task-status>`)
+	taskBodyPattern   = regexp.MustCompile(`(?s)<ac:task-body>(.*?)</ac:task-body>`)
+
+	// placeholderPattern matches instructional placeholder text, which
+	// is not page content.
+	placeholderPattern = regexp.MustCompile(`(?s)<ac:placeholder\b[^>]*>.*?</ac:placeholder>`)
+
+	// storageTagPattern matches any remaining ac: or ri: tag.
+	storageTagPattern = regexp.MustCompile(`</?(?:ac|ri):[a-z-]+\b[^>]*>`)
+)
+
+// storageAdmonitions maps the admonition macros to the class suffix of
+// the information macros of exports.
+var storageAdmonitions = map[string]string{
+	"info":    "information",
+	"tip":     "tip",
+	"note":    "note",
+	"warning": "warning",
+}
+
+// IsStorageFormat reports whether content is Confluence storage-format
+// XHTML: whether it uses ac: or ri: elements.
+func IsStorageFormat(content string) bool {
+	return storageElementPattern.MatchString(content)
+}
+
+// IsStorageFile reports whether the file at path is a storage-format
+// page: a file with the StorageExtension, or one using ac: or ri: elements
+// near its start.
+func IsStorageFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+	if strings.EqualFold(filepath.Ext(path), StorageExtension) {
+		return true, nil
+	}
+	head, err := io.ReadAll(io.LimitReader(file, maxStorageSniffBytes))
+	if err != nil {
+		return false, fmt.Errorf("failed to read file: %w", err)
+	}
+	return IsStorageFormat(string(head)), nil
+}
+
+// ReadStorageFile reads a storage-format page, transcoded to UTF-8 like
+// the HTML of exports. The ac: and ri: elements are mapped by the
+// conversion (see StorageToHTML).
+func ReadStorageFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return decodeHTMLPart(data, ""), nil
+}
+
+// ReadPageHTML returns the HTML of the page at path, a MIME export or a
+// storage-format page. limits applies to MIME exports.
+func ReadPageHTML(path string, limits MIMELimits) (string, error) {
+	isMIME, err := IsConfluenceMIME(path)
+	if err != nil {
+		return "", err
+	}
+	if isMIME {
+		return ExtractHTMLFromMIMEWithLimits(path, limits)
+	}
+	isStorage, err := IsStorageFile(path)
+	if err != nil {
+		return "", err
+	}
+	if !isStorage {
+		return "", fmt.Errorf("%s is neither a Confluence MIME export nor a storage-format page", path)
+	}
+	return ReadStorageFile(path)
+}
+
+// StorageToHTML maps the ac: and ri: elements of storage-format XHTML to
+// the HTML Confluence exports use for them, so that the rest of the
+// conversion treats both alike: code and noformat macros become code
+// blocks, info, tip, note, and warning macros panels, expand macros
+// expanders, page and attachment links and images links and images,
+// emoticons emoticon images, and task lists checklists. Other macros are
+// replaced by their body. spaceKey and pageID identify the page, for links
+// to pages of its space and to its attachments; links to pages whose space
+// is not known are kept as text.
+func StorageToHTML(storage, spaceKey, pageID string) string {
+	s := placeholderPattern.ReplaceAllString(storage, "")
+	s = replaceMacros(s)
+	s = storageImagePattern.ReplaceAllStringFunc(s, func(match string) string {
+		m := storageImagePattern.FindStringSubmatch(match)
+		return storageImage(storageAttributes(m[1]), m[2], pageID)
+	})
+	s = storageLinkPattern.ReplaceAllStringFunc(s, func(match string) string {
+		m := storageLinkPattern.FindStringSubmatch(match)
+		return storageLink(storageAttributes(m[1]), m[2], spaceKey, pageID)
+	})
+	s = emoticonElementPattern.ReplaceAllStringFunc(s, func(match string) string {
+		return storageEmoticon(storageAttributes(emoticonElementPattern.FindStringSubmatch(match)[1]))
+	})
+	s = replaceTaskLists(s)
+	s = storageTagPattern.ReplaceAllString(s, "")
+	return cdataPattern.ReplaceAllStringFunc(s, func(match string) string {
+		return html.EscapeString(cdataPattern.FindStringSubmatch(match)[1])
+	})
+}
+
+// replaceMacros replaces the structured macros of storage XHTML, innermost
+// first, so that macros nested in the rich-text body of others are mapped
+// before their parents.
+func replaceMacros(s string) string {
+	expanders := 0
+	for {
+		end := strings.Index(s, macroEndTag)
+		if end == -1 {
+			return s
+		}
+		start := strings.LastIndex(s[:end], macroStartTag)
+		if start == -1 {
+			// A stray end tag
+			s = s[:end] + s[end+len(macroEndTag):]
+			continue
+		}
+		openEnd := strings.IndexByte(s[start:end], '>')
+		if openEnd == -1 {
+			s = s[:start] + s[end+len(macroEndTag):]
+			continue
+		}
+		attrs := storageAttributes(s[start : start+openEnd])
+		inner := s[start+openEnd+1 : end]
+		if attrs["ac:name"] == "expand" {
+			expanders++
+		}
+		s = s[:start] + storageMacro(attrs["ac:name"], inner, expanders) + s[end+len(macroEndTag):]
+	}
+}
+
+// storageMacro returns the HTML for a macro with the given name and inner
+// XHTML. n numbers expand macros.
+func storageMacro(name, inner string, n int) string {
+	params := make(map[string]string)
+	for _, m := range macroParameterPattern.FindAllStringSubmatch(inner, -1) {
+		params[m[1]] = html.UnescapeString(strings.TrimSpace(m[2]))
+	}
+	var body, text string
+	if m := richTextBodyPattern.FindStringSubmatch(inner); m != nil {
+		body = m[1]
+	}
+	if m := plainTextBodyPattern.FindStringSubmatch(inner); m != nil {
+		text = m[1]
+		if c := cdataPattern.FindStringSubmatch(text); c != nil {
+			text = c[1]
+		} else {
+			text = html.UnescapeString(text)
+		}
+	}
+
+	switch name {
+	case "code", "noformat":
+		class := ""
+		if lang := params["language"]; lang != "" && name == "code" {
+			class = fmt.Sprintf(` class="%s"`, html.EscapeString(lang))
+		}
+		return fmt.Sprintf("<pre%s><code>%s</code></pre>", class, html.EscapeString(text))
+	case "info", "tip", "note", "warning":
+		return fmt.Sprintf(`<div class="confluence-information-macro confluence-information-macro-%s"><div class="confluence-information-macro-body">%s</div></div>`,
+			storageAdmonitions[name], body)
+	case "expand":
+		title := params["title"]
+		if title == "" {
+			title = "Click here to expand..."
+		}
+		return fmt.Sprintf(`<div id="expander-%d" class="expand-container"><div id="expander-control-%d" class="expand-control"><span class="expand-control-text">%s</span></div><div id="expander-content-%d" class="expand-content">%s</div></div>`,
+			n, n, html.EscapeString(title), n, body)
+	case "status":
+		return fmt.Sprintf(`<span class="status-macro aui-lozenge">%s</span>`, html.EscapeString(params["title"]))
+	case "anchor":
+		return fmt.Sprintf(`<span id="%s"></span>`, html.EscapeString(params[""]))
+	}
+	return body
+}
+
+// storageAttributes returns the ac: and ri: attributes of a tag, with
+// entities decoded.
+func storageAttributes(tag string) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range storageAttributePattern.FindAllStringSubmatch(tag, -1) {
+		attrs[m[1]] = html.UnescapeString(m[2])
+	}
+	return attrs
+}
+
+// storageLink returns the HTML link for an ac:link with the given
+// attributes and inner XHTML.
+func storageLink(attrs map[string]string, inner, spaceKey, pageID string) string {
+	text := ""
+	if m := linkBodyPattern.FindStringSubmatch(inner); m != nil {
+		text = m[1]
+		if c := cdataPattern.FindStringSubmatch(text); c != nil {
+			text = html.EscapeString(c[1])
+		}
+	}
+
+	var href, fallback string
+	if m := resourcePattern.FindStringSubmatch(inner); m != nil {
+		res := storageAttributes(m[2])
+		switch m[1] {
+		case "page", "blog-post":
+			fallback = res["ri:content-title"]
+			key := res["ri:space-key"]
+			if key == "" {
+				key = spaceKey
+			}
+			if key != "" && fallback != "" {
+				href = "/display/" + url.PathEscape(key) + "/" + strings.ReplaceAll(url.QueryEscape(fallback), "%2F", "/")
+			}
+		case "attachment":
+			fallback = res["ri:filename"]
+			href = attachmentHref(fallback, pageID)
+		case "url":
+			href = res["ri:value"]
+			fallback = href
+		case "user":
+			fallback = "@" + firstNonEmpty(res["ri:username"], res["ri:userkey"], res["ri:account-id"])
+		case "space":
+			fallback = res["ri:space-key"]
+			if fallback != "" {
+				href = "/display/" + url.PathEscape(fallback)
+			}
+		}
+	}
+	if anchor := attrs["ac:anchor"]; anchor != "" {
+		href += "#" + anchor
+		if fallback == "" {
+			fallback = anchor
+		}
+	}
+
+	if text == "" {
+		text = html.EscapeString(fallback)
+	}
+	if href == "" {
+		return text
+	}
+	return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(href), text)
+}
+
+// storageImage returns the HTML image for an ac:image with the given
+// attributes and inner XHTML.
+func storageImage(attrs map[string]string, inner, pageID string) string {
+	m := resourcePattern.FindStringSubmatch(inner)
+	if m == nil {
+		return ""
+	}
+	res := storageAttributes(m[2])
+	var src string
+	switch m[1] {
+	case "attachment":
+		src = attachmentHref(res["ri:filename"], pageID)
+	case "url":
+		src = res["ri:value"]
+	}
+	if src == "" {
+		return ""
+	}
+
+	alt := firstNonEmpty(attrs["ac:alt"], attrs["ac:title"])
+	size := ""
+	if w := attrs["ac:width"]; w != "" {
+		size += fmt.Sprintf(` width="%s"`, html.EscapeString(w))
+	}
+	if h := attrs["ac:height"]; h != "" {
+		size += fmt.Sprintf(` height="%s"`, html.EscapeString(h))
+	}
+	return fmt.Sprintf(`<img src="%s" alt="%s"%s>`, html.EscapeString(src), html.EscapeString(alt), size)
+}
+
+// attachmentHref returns the link to an attachment of the page: its
+// download path when the page ID is known, else the file name.
+func attachmentHref(filename, pageID string) string {
+	if filename == "" {
+		return ""
+	}
+	if pageID == "" {
+		return url.PathEscape(filename)
+	}
+	return "/download/attachments/" + pageID + "/" + url.PathEscape(filename)
+}
+
+// storageEmoticon returns the emoticon image for an ac:emoticon with the
+// given attributes, with the alt text exports give it: the emoji
+// shortname, or the emoticon name in parentheses.
+func storageEmoticon(attrs map[string]string) string {
+	name := attrs["ac:name"]
+	alt := attrs["ac:emoji-shortname"]
+	if alt == "" {
+		alt = "(" + strings.ReplaceAll(name, "-", " ") + ")"
+	}
+	return fmt.Sprintf(`<img class="emoticon emoticon-%s" src="/images/icons/emoticons/%s.svg" alt="%s">`,
+		html.EscapeString(name), url.PathEscape(name), html.EscapeString(alt))
+}
+
+// replaceTaskLists replaces the task lists of storage XHTML, innermost
+// first, so that a list nested in the body of a task becomes part of that
+// task rather than ending its parent list.
+func replaceTaskLists(s string) string {
+	for {
+		end := strings.Index(s, taskListEndTag)
+		if end == -1 {
+			return s
+		}
+		start := strings.LastIndex(s[:end], taskListStartTag)
+		if start == -1 {
+			// A stray end tag
+			s = s[:end] + s[end+len(taskListEndTag):]
+			continue
+		}
+		inner := s[start+len(taskListStartTag) : end]
+		s = s[:start] + storageTaskList(inner) + s[end+len(taskListEndTag):]
+	}
+}
+
+// storageTaskList returns the inline task list of exports for the inner
+// XHTML of an ac:task-list.
+func storageTaskList(inner string) string {
+	var b strings.Builder
+	b.WriteString(`<ul class="inline-task-list">`)
+	for _, task := range taskPattern.FindAllStringSubmatch(inner, -1) {
+		class := ""
+		if m := taskStatusPattern.FindStringSubmatch(task[1]); m != nil && m[1] == "complete" {
+			class = ` class="checked"`
+		}
+		body := ""
+		if m := taskBodyPattern.FindStringSubmatch(task[1]); m != nil {
+			body = strings.TrimSpace(m[1])
+		}
+		fmt.Fprintf(&b, "<li%s>%s</li>", class, body)
+	}
+	b.WriteString("</ul>")
+	return b.String()
+}
+
+// firstNonEmpty returns the first of values that is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStorageToHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		storage string
+		want    string
+	}{
+		{
+			name:    "code macro",
+			storage: `<ac:structured-macro ac:name="code" ac:schema-version="1"><ac:parameter ac:name="language">go</ac:parameter><ac:plain-text-body><![CDATA[if a < b && ok {}]]></ac:plain-text-body></ac:structured-macro>`,
+			want:    `<pre class="go"><code>if a &lt; b &amp;&amp; ok {}</code></pre>`,
+		},
+		{
+			name:    "noformat macro",
+			storage: `<ac:structured-macro ac:name="noformat"><ac:plain-text-body><![CDATA[raw]]></ac:plain-text-body></ac:structured-macro>`,
+			want:    `<pre><code>raw</code></pre>`,
+		},
+		{
+			name:    "panel with nested code",
+			storage: `<ac:structured-macro ac:name="info"><ac:rich-text-body><p>Run:</p><ac:structured-macro ac:name="code"><ac:plain-text-body><![CDATA[make]]></ac:plain-text-body></ac:structured-macro></ac:rich-text-body></ac:structured-macro>`,
+			want:    `<div class="confluence-information-macro confluence-information-macro-information"><div class="confluence-information-macro-body"><p>Run:</p><pre><code>make</code></pre></div></div>`,
+		},
+		{
+			name:    "expand macro",
+			storage: `<ac:structured-macro ac:name="expand"><ac:parameter ac:name="title">Details</ac:parameter><ac:rich-text-body><p>Hidden</p></ac:rich-text-body></ac:structured-macro>`,
+			want:    `<div id="expander-1" class="expand-container"><div id="expander-control-1" class="expand-control"><span class="expand-control-text">Details</span></div><div id="expander-content-1" class="expand-content"><p>Hidden</p></div></div>`,
+		},
+		{
+			name:    "unknown macro keeps its body",
+			storage: `<ac:structured-macro ac:name="section"><ac:parameter ac:name="border">true</ac:parameter><ac:rich-text-body><p>Body</p></ac:rich-text-body></ac:structured-macro>`,
+			want:    `<p>Body</p>`,
+		},
+		{
+			name:    "page link",
+			storage: `<ac:link><ri:page ri:content-title="Release Notes" /></ac:link>`,
+			want:    `<a href="/display/ENG/Release+Notes">Release Notes</a>`,
+		},
+		{
+			name:    "page link to another space with anchor and text",
+			storage: `<ac:link ac:anchor="Setup"><ri:page ri:space-key="OPS" ri:content-title="Runbook" /><ac:plain-text-link-body><![CDATA[the <runbook>]]></ac:plain-text-link-body></ac:link>`,
+			want:    `<a href="/display/OPS/Runbook#Setup">the &lt;runbook&gt;</a>`,
+		},
+		{
+			name:    "attachment link",
+			storage: `<ac:link><ri:attachment ri:filename="report v2.pdf" /><ac:link-body><b>Report</b></ac:link-body></ac:link>`,
+			want:    `<a href="/download/attachments/42/report%20v2.pdf"><b>Report</b></a>`,
+		},
+		{
+			name:    "user link",
+			storage: `<ac:link><ri:user ri:username="jdoe" /></ac:link>`,
+			want:    `@jdoe`,
+		},
+		{
+			name:    "attached image",
+			storage: `<ac:image ac:alt="Diagram" ac:width="300"><ri:attachment ri:filename="arch.png" /></ac:image>`,
+			want:    `<img src="/download/attachments/42/arch.png" alt="Diagram" width="300">`,
+		},
+		{
+			name:    "external image",
+			storage: `<ac:image><ri:url ri:value="https://example.com/a.png" /></ac:image>`,
+			want:    `<img src="https://example.com/a.png" alt="">`,
+		},
+		{
+			name:    "emoticon",
+			storage: `<p>Done <ac:emoticon ac:name="thumbs-up" /></p>`,
+			want:    `<p>Done <img class="emoticon emoticon-thumbs-up" src="/images/icons/emoticons/thumbs-up.svg" alt="(thumbs up)"></p>`,
+		},
+		{
+			name:    "task list",
+			storage: `<ac:task-list><ac:task><ac:task-id>1</ac:task-id><ac:task-status>complete</ac:task-status><ac:task-body>Write</ac:task-body></ac:task><ac:task><ac:task-id>2</ac:task-id><ac:task-status>incomplete</ac:task-status><ac:task-body>Ship</ac:task-body></ac:task></ac:task-list>`,
+			want:    `<ul class="inline-task-list"><li class="checked">Write</li><li>Ship</li></ul>`,
+		},
+		{
+			name:    "nested task list",
+			storage: `<ac:task-list><ac:task><ac:task-status>incomplete</ac:task-status><ac:task-body>Release<ac:task-list><ac:task><ac:task-status>complete</ac:task-status><ac:task-body>Tag</ac:task-body></ac:task><ac:task><ac:task-status>incomplete</ac:task-status><ac:task-body>Publish</ac:task-body></ac:task></ac:task-list></ac:task-body></ac:task><ac:task><ac:task-status>complete</ac:task-status><ac:task-body>Announce</ac:task-body></ac:task></ac:task-list>`,
+			want:    `<ul class="inline-task-list"><li>Release<ul class="inline-task-list"><li class="checked">Tag</li><li>Publish</li></ul></li><li class="checked">Announce</li></ul>`,
+		},
+		{
+			name:    "layout and placeholder",
+			storage: `<ac:layout><ac:layout-section ac:type="single"><ac:layout-cell><p>Text<ac:placeholder>Type here</ac:placeholder></p></ac:layout-cell></ac:layout-section></ac:layout>`,
+			want:    `<p>Text</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StorageToHTML(tt.storage, "ENG", "42"); got != tt.want {
+				t.Errorf("StorageToHTML() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStorageToHTML_UnknownSpace(t *testing.T) {
+	storage := `<ac:link><ri:page ri:content-title="Runbook" /></ac:link> <ac:image><ri:attachment ri:filename="a.png" /></ac:image>`
+	want := `Runbook <img src="a.png" alt="">`
+	if got := StorageToHTML(storage, "", ""); got != want {
+		t.Errorf("StorageToHTML() = %q, want %q", got, want)
+	}
+}
+
+func TestIsStorageFormat(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{`<p>Hi <ac:emoticon ac:name="smile" /></p>`, true},
+		{`<ac:structured-macro ac:name="toc"></ac:structured-macro>`, true},
+		{`<p>Plain <b>HTML</b></p>`, false},
+		{`<p>Mail me at ac:foo</p>`, false},
+	}
+	for _, tt := range tests {
+		if got := IsStorageFormat(tt.content); got != tt.want {
+			t.Errorf("IsStorageFormat(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestReadPageHTML_Storage(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"page.xhtml": `<p>Plain</p>`,
+		"page.html":  `<p><ac:link><ri:page ri:content-title="Home" /></ac:link></p>`,
+		"other.html": `<p>Plain</p>`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"page.xhtml", "page.html"} {
+		path := filepath.Join(dir, name)
+		if ok, err := IsStorageFile(path); err != nil || !ok {
+			t.Errorf("IsStorageFile(%s) = %v, %v, want true", name, ok, err)
+		}
+		if got, err := ReadPageHTML(path, MIMELimits{}); err != nil || !strings.HasSuffix(got, files[name]) {
+			t.Errorf("ReadPageHTML(%s) = %q, %v, want the file content", name, got, err)
+		}
+	}
+	if _, err := ReadPageHTML(filepath.Join(dir, "other.html"), MIMELimits{}); err == nil {
+		t.Error("ReadPageHTML() of plain HTML succeeded")
+	}
+}
@@ -0,0 +1,275 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TableCellStyle selects how table cells holding block content (lists or
+// several paragraphs) are converted. Markdown pipe table cells hold a
+// single line, so the structure has to be flattened, kept as HTML, or moved
+// out of the table.
+type TableCellStyle string
+
+const (
+	// TableCellFlatten joins the paragraphs and list items of a cell with
+	// spaces (the default).
+	TableCellFlatten TableCellStyle = "flatten"
+	// TableCellBreaks puts each paragraph and list item of a cell on its own
+	// line, separated by <br>, with list items keeping a "- " or "1. "
+	// marker.
+	TableCellBreaks TableCellStyle = "br"
+	// TableCellHTML keeps tables with block content as HTML tables.
+	TableCellHTML TableCellStyle = "html"
+	// TableCellExtract moves block content below the table as numbered
+	// notes, leaving a reference to the note in the cell.
+	TableCellExtract TableCellStyle = "extract"
+)
+
+// TableCellStyles lists the supported table cell styles.
+var TableCellStyles = []TableCellStyle{TableCellFlatten, TableCellBreaks, TableCellHTML, TableCellExtract}
+
+var (
+	// cellBlockPattern matches the opening tag of an element that makes a
+	// cell's content block content.
+	cellBlockPattern = regexp.MustCompile(`(?i)<(?:ul|ol|pre|blockquote|h[1-6])\b`)
+
+	// cellParagraphPattern matches the opening tag of a paragraph.
+	cellParagraphPattern = regexp.MustCompile(`(?i)<p\b`)
+
+	// cellStructurePattern matches the tags that delimit the lines of a
+	// cell converted with TableCellBreaks.
+	cellStructurePattern = regexp.MustCompile(`(?i)<(/?)(p|div|ul|ol|li|br|h[1-6]|blockquote|pre)\b[^>]*>`)
+)
+
+// cellFlattenReplacements unwrap the paragraphs and line breaks of simple
+// table cells. They are applied in order.
+var cellFlattenReplacements = []regexReplacement{
+	// Remove <br> tags inside table cells (pandoc can't handle them and falls back to HTML)
+	// Match <td>...<br>...</td> and <th>...<br>...</th> and remove the br
+	{regexp.MustCompile(`(<t[dh]>)([^<]*)<br\s*/?>([^<]*)(</t[dh]>)`), "$1$2 $3$4"},
+	// Handle cells that are just <br>
+	{regexp.MustCompile(`<td>\s*<br\s*/?>\s*</td>`), "<td></td>"},
+	{regexp.MustCompile(`<th>\s*<br\s*/?>\s*</th>`), "<th></th>"},
+
+	// Remove <p> tags inside table cells (unwrap content), starting with
+	// simple single-p cells
+	{regexp.MustCompile(`(<t[dh]>)\s*<p>([^<]*)</p>\s*(</t[dh]>)`), "$1$2$3"},
+}
+
+// formatTableCells converts the block content of table cells according to
+// style and flattens what is left, so pandoc can write pipe tables. It
+// expects tables simplified by tableCleanupReplacements. Single-cell layout
+// tables are always flattened; simplifyLayoutTables unwraps them.
+func formatTableCells(html string, style TableCellStyle) string {
+	switch style {
+	case TableCellBreaks:
+		html = rewriteTables(html, func(inner string) string {
+			if isSingleCellTable(inner) {
+				return inner
+			}
+			return rewriteBlockCells(inner, cellLines)
+		})
+	case TableCellHTML:
+		return keepBlockTables(html)
+	case TableCellExtract:
+		html = extractBlockCells(html)
+	}
+	return flattenTableCells(html)
+}
+
+// flattenTableCells unwraps the paragraphs of table cells, joining them
+// with spaces.
+func flattenTableCells(html string) string {
+	html = applyReplacements(html, cellFlattenReplacements)
+
+	// Handle multiple <p> tags in cells - convert to text with spaces
+	return tableCellPattern.ReplaceAllStringFunc(html, func(match string) string {
+		// Remove <p> and </p> tags inside cells, replace with space
+		inner := cellTagPattern.ReplaceAllString(match, "")
+		inner = paragraphOpenPattern.ReplaceAllString(inner, "")
+		inner = strings.ReplaceAll(inner, "</p>", " ")
+		inner = strings.TrimSpace(inner)
+		// Detect if it was th or td
+		if strings.HasPrefix(match, "<th") {
+			return "<th>" + inner + "</th>"
+		}
+		return "<td>" + inner + "</td>"
+	})
+}
+
+// isBlockCell reports whether cell content holds lists, several
+// paragraphs, or other block content that flattening would lose.
+func isBlockCell(content string) bool {
+	return cellBlockPattern.MatchString(content) || len(cellParagraphPattern.FindAllStringIndex(content, 2)) > 1
+}
+
+// hasBlockCells reports whether any cell of a table, outside the tables
+// nested in it, holds block content.
+func hasBlockCells(inner string) bool {
+	for _, row := range tableRows(inner) {
+		for _, cell := range rowCells(row) {
+			if isBlockCell(cell.content) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rewriteBlockCells replaces the content of the cells of a table that hold
+// block content with the result of fn. Tables nested in the cells are left
+// alone.
+func rewriteBlockCells(inner string, fn func(content string) string) string {
+	var b strings.Builder
+	offset := 0
+	for {
+		idx := indexOpenTag(inner[offset:], "<tr")
+		if idx == -1 {
+			break
+		}
+		rowStart := offset + idx
+		rowEnd := findElementEnd(inner, rowStart, "tr")
+		if rowEnd == -1 {
+			break
+		}
+		b.WriteString(inner[offset:rowStart])
+		for pos := rowStart; pos < rowEnd; {
+			loc := cellOpenPattern.FindStringSubmatchIndex(inner[pos:rowEnd])
+			if loc == nil {
+				b.WriteString(inner[pos:rowEnd])
+				break
+			}
+			start := pos + loc[0]
+			openEnd := pos + loc[1]
+			tag := inner[pos+loc[2] : pos+loc[3]]
+			end := findElementEnd(inner, start, tag)
+			if end == -1 || end > rowEnd {
+				b.WriteString(inner[pos:rowEnd])
+				break
+			}
+			content := elementInner(inner, start, end, tag)
+			b.WriteString(inner[pos:openEnd])
+			if isBlockCell(content) {
+				content = fn(content)
+			}
+			b.WriteString(content)
+			b.WriteString("</" + tag + ">")
+			pos = end
+		}
+		offset = rowEnd
+	}
+	b.WriteString(inner[offset:])
+	return b.String()
+}
+
+// cellLines converts block cell content to lines separated by <br>.
+// Paragraphs, headings, and list items each start a line; list items are
+// prefixed with "- " or their number. Table cells cannot indent lines, so
+// nested list items are not indented.
+func cellLines(content string) string {
+	type list struct {
+		ordered bool
+		n       int
+	}
+	var lists []list
+	var lines []string
+	var line strings.Builder
+	endLine := func() {
+		if text := strings.TrimSpace(line.String()); text != "" {
+			lines = append(lines, text)
+		}
+		line.Reset()
+	}
+
+	offset := 0
+	for _, m := range cellStructurePattern.FindAllStringSubmatchIndex(content, -1) {
+		line.WriteString(content[offset:m[0]])
+		offset = m[1]
+		closing := m[3] > m[2]
+		tag := strings.ToLower(content[m[4]:m[5]])
+		endLine()
+		switch {
+		case (tag == "ul" || tag == "ol") && !closing:
+			lists = append(lists, list{ordered: tag == "ol"})
+		case (tag == "ul" || tag == "ol") && len(lists) > 0:
+			lists = lists[:len(lists)-1]
+		case tag == "li" && !closing && len(lists) > 0:
+			l := &lists[len(lists)-1]
+			l.n++
+			if l.ordered {
+				line.WriteString(strconv.Itoa(l.n) + ". ")
+			} else {
+				line.WriteString("- ")
+			}
+		}
+	}
+	line.WriteString(content[offset:])
+	endLine()
+	return strings.Join(lines, "<br>")
+}
+
+// keepBlockTables flattens the cells of tables without block content and
+// leaves the others untouched, so pandoc writes them as HTML tables.
+func keepBlockTables(html string) string {
+	var b strings.Builder
+	const open = "<table>"
+	for {
+		idx := strings.Index(html, open)
+		if idx == -1 {
+			break
+		}
+		end := findElementEnd(html, idx, "table")
+		if end == -1 {
+			break
+		}
+		b.WriteString(flattenTableCells(html[:idx]))
+		table := html[idx:end]
+		if inner := elementInner(html, idx, end, "table"); isSingleCellTable(inner) || !hasBlockCells(inner) {
+			table = flattenTableCells(table)
+		}
+		b.WriteString(table)
+		html = html[end:]
+	}
+	b.WriteString(flattenTableCells(html))
+	return b.String()
+}
+
+// extractBlockCells moves the block content of table cells below their
+// table as numbered notes, replacing it with a reference to the note.
+// Notes are numbered across the page.
+func extractBlockCells(html string) string {
+	const open = "<table>"
+	n := 0
+	for searchFrom := 0; ; {
+		idx := strings.Index(html[searchFrom:], open)
+		if idx == -1 {
+			return html
+		}
+		start := searchFrom + idx
+		end := findElementEnd(html, start, "table")
+		if end == -1 {
+			return html
+		}
+		inner := elementInner(html, start, end, "table")
+		if isSingleCellTable(inner) {
+			searchFrom = start + len(open)
+			continue
+		}
+
+		var notes strings.Builder
+		inner = rewriteBlockCells(inner, func(content string) string {
+			n++
+			fmt.Fprintf(&notes, "<p><strong>Note %d</strong></p>%s", n, strings.TrimSpace(content))
+			return fmt.Sprintf("<em>See note %d</em>", n)
+		})
+		table := open + inner + "</table>"
+		html = html[:start] + table + notes.String() + html[end:]
+		// Nested tables stay in the table; notes may hold tables of their own
+		searchFrom = start + len(open)
+	}
+}
@@ -0,0 +1,93 @@
+package converter
+
+import (
+	"testing"
+)
+
+func TestFormatTableCells(t *testing.T) {
+	const (
+		list     = `<table><tbody><tr><td>Steps</td><td><p>Do this:</p><ul><li>one</li><li>two</li></ul></td></tr></tbody></table>`
+		paras    = `<table><tbody><tr><td>a</td><td><p>first</p><p>second</p></td></tr></tbody></table>`
+		simple   = `<table><tbody><tr><td><p>a</p></td><td>b<br/>c</td></tr></tbody></table>`
+		single   = `<table><tbody><tr><td><p>first</p><p>second</p></td></tr></tbody></table>`
+		numbered = `<table><tbody><tr><td>x</td><td><ol><li>one<ul><li>sub</li></ul></li><li>two</li></ol></td></tr></tbody></table>`
+	)
+
+	tests := []struct {
+		name   string
+		input  string
+		style  TableCellStyle
+		expect string
+	}{
+		{
+			name:   "flatten joins paragraphs",
+			input:  paras,
+			expect: `<table><tbody><tr><td>a</td><td>first second</td></tr></tbody></table>`,
+		},
+		{
+			name:   "flatten unwraps simple cells",
+			input:  simple,
+			style:  TableCellFlatten,
+			expect: `<table><tbody><tr><td>a</td><td>b c</td></tr></tbody></table>`,
+		},
+		{
+			name:   "br splits paragraphs and list items",
+			input:  list,
+			style:  TableCellBreaks,
+			expect: `<table><tbody><tr><td>Steps</td><td>Do this:<br>- one<br>- two</td></tr></tbody></table>`,
+		},
+		{
+			name:   "br numbers ordered items",
+			input:  numbered,
+			style:  TableCellBreaks,
+			expect: `<table><tbody><tr><td>x</td><td>1. one<br>- sub<br>2. two</td></tr></tbody></table>`,
+		},
+		{
+			name:   "br leaves simple cells flattened",
+			input:  simple,
+			style:  TableCellBreaks,
+			expect: `<table><tbody><tr><td>a</td><td>b c</td></tr></tbody></table>`,
+		},
+		{
+			name:   "br skips layout tables",
+			input:  single,
+			style:  TableCellBreaks,
+			expect: `<table><tbody><tr><td>first second</td></tr></tbody></table>`,
+		},
+		{
+			name:   "html keeps tables with block content",
+			input:  list,
+			style:  TableCellHTML,
+			expect: list,
+		},
+		{
+			name:   "html flattens other tables",
+			input:  simple + paras,
+			style:  TableCellHTML,
+			expect: `<table><tbody><tr><td>a</td><td>b c</td></tr></tbody></table>` + paras,
+		},
+		{
+			name:  "extract moves block content below the table",
+			input: list + paras,
+			style: TableCellExtract,
+			expect: `<table><tbody><tr><td>Steps</td><td><em>See note 1</em></td></tr></tbody></table>` +
+				`<p><strong>Note 1</strong></p><p>Do this:</p><ul><li>one</li><li>two</li></ul>` +
+				`<table><tbody><tr><td>a</td><td><em>See note 2</em></td></tr></tbody></table>` +
+				`<p><strong>Note 2</strong></p><p>first</p><p>second</p>`,
+		},
+		{
+			name:   "extract skips layout tables",
+			input:  single,
+			style:  TableCellExtract,
+			expect: `<table><tbody><tr><td>first second</td></tr></tbody></table>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatTableCells(tt.input, tt.style); got != tt.expect {
+				t.Errorf("formatTableCells() =\n%s\nwant\n%s", got, tt.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// TableFunc receives each table of a page as rows of cell text, header row
+// first, with index counting tables from 1 in document order. When it
+// returns a link target, the table is replaced with a link to it, such as
+// a CSV copy of a table too large to read in Markdown.
+type TableFunc func(index int, rows [][]string) string
+
+// exportTables passes every table to fn, replacing those for which fn
+// returns a link target. Nested tables are passed on their own after the
+// table containing them. It expects tables with headers added by
+// applyTableHeaders.
+func exportTables(htmlContent string, fn TableFunc) string {
+	if fn == nil {
+		return htmlContent
+	}
+	const open = "<table>"
+	index := 0
+	for searchFrom := 0; ; {
+		idx := strings.Index(htmlContent[searchFrom:], open)
+		if idx == -1 {
+			return htmlContent
+		}
+		start := searchFrom + idx
+		end := findElementEnd(htmlContent, start, "table")
+		if end == -1 {
+			return htmlContent
+		}
+		index++
+		link := fn(index, tableText(elementInner(htmlContent, start, end, "table")))
+		if link == "" {
+			searchFrom = start + len(open)
+			continue
+		}
+		replacement := fmt.Sprintf(`<p><a href="%s">Table %d (CSV)</a></p>`, html.EscapeString(link), index)
+		htmlContent = htmlContent[:start] + replacement + htmlContent[end:]
+		searchFrom = start + len(replacement)
+	}
+}
+
+// tableText returns the cell text of a table's rows. A header row without
+// any text is left out.
+func tableText(inner string) [][]string {
+	var rows [][]string
+	for i, row := range tableRows(inner) {
+		cells := rowCells(row)
+		texts := make([]string, len(cells))
+		empty := true
+		for j, cell := range cells {
+			texts[j] = cellText(brTagPattern.ReplaceAllString(cell.content, "\n"))
+			if texts[j] != "" {
+				empty = false
+			}
+		}
+		if i == 0 && empty && strings.HasPrefix(inner, "<thead>") {
+			continue
+		}
+		rows = append(rows, texts)
+	}
+	return rows
+}
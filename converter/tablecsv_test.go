@@ -0,0 +1,43 @@
+package converter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportTables(t *testing.T) {
+	input := `<table><thead><tr><th>Name</th><th>Role</th></tr></thead><tr><td><strong>Ana</strong></td><td>Dev &amp; Ops</td></tr></table>` +
+		`<p>Between</p>` +
+		`<table><thead><tr><th></th><th></th></tr></thead><tr><td>a<br>b</td><td>c</td></tr></table>`
+
+	var got [][][]string
+	out := exportTables(input, func(index int, rows [][]string) string {
+		got = append(got, rows)
+		if index == 2 {
+			return "tables/page-table-2.csv"
+		}
+		return ""
+	})
+
+	want := [][][]string{
+		{{"Name", "Role"}, {"Ana", "Dev & Ops"}},
+		{{"a\nb", "c"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("exportTables() passed %q, want %q", got, want)
+	}
+	if !strings.Contains(out, `<td>Dev &amp; Ops</td>`) {
+		t.Errorf("first table should be kept:\n%s", out)
+	}
+	if !strings.HasSuffix(out, `<p>Between</p><p><a href="tables/page-table-2.csv">Table 2 (CSV)</a></p>`) {
+		t.Errorf("second table should be replaced by a link:\n%s", out)
+	}
+}
+
+func TestExportTables_Disabled(t *testing.T) {
+	input := `<table><tr><td>x</td></tr></table>`
+	if got := exportTables(input, nil); got != input {
+		t.Errorf("exportTables(nil) = %q, want unchanged", got)
+	}
+}
@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TableHeaderStyle selects how tables without a header row are converted.
+// Markdown pipe tables always have a header row, so a headerless table
+// either gains an empty one or has its first row promoted.
+type TableHeaderStyle string
+
+const (
+	// TableHeaderInfer promotes the first row when it looks like a header
+	// (all <th> cells, or all cells bold) and adds an empty header row
+	// otherwise (the default).
+	TableHeaderInfer TableHeaderStyle = "infer"
+	// TableHeaderFirstRow always promotes the first row to the header.
+	TableHeaderFirstRow TableHeaderStyle = "first-row"
+	// TableHeaderEmpty adds an empty header row unless the first row
+	// consists of <th> cells.
+	TableHeaderEmpty TableHeaderStyle = "empty"
+)
+
+// TableHeaderStyles lists the supported table header styles.
+var TableHeaderStyles = []TableHeaderStyle{TableHeaderInfer, TableHeaderFirstRow, TableHeaderEmpty}
+
+var (
+	// cellOpenPattern matches the opening tag of a table cell.
+	cellOpenPattern = regexp.MustCompile(`<(t[dh])\b[^>]*>`)
+
+	// boldCellPattern matches cell content that is entirely bold.
+	boldCellPattern = regexp.MustCompile(`^\s*<(strong|b)>[\s\S]*</(strong|b)>\s*$`)
+)
+
+// tableCell is a cell of a table row.
+type tableCell struct {
+	tag     string // "td" or "th"
+	content string // inner HTML
+}
+
+// applyTableHeaders gives every table without a <thead> an explicit header
+// row according to style, so pandoc never silently promotes a data row.
+// It expects tables simplified by preProcessHTML.
+func applyTableHeaders(html string, style TableHeaderStyle) string {
+	return rewriteTables(html, func(inner string) string {
+		return addTableHeader(inner, style)
+	})
+}
+
+// rewriteTables replaces the inner HTML of every table with the result of
+// fn. Outer tables are rewritten before the tables nested in their cells.
+func rewriteTables(html string, fn func(inner string) string) string {
+	const open = "<table>"
+	for searchFrom := 0; ; {
+		idx := strings.Index(html[searchFrom:], open)
+		if idx == -1 {
+			return html
+		}
+		start := searchFrom + idx
+		end := findElementEnd(html, start, "table")
+		if end == -1 {
+			return html
+		}
+		inner := elementInner(html, start, end, "table")
+		html = html[:start] + open + fn(inner) + "</table>" + html[end:]
+		searchFrom = start + len(open)
+	}
+}
+
+// addTableHeader returns the inner HTML of a table with a header row added
+// or promoted according to style.
+func addTableHeader(inner string, style TableHeaderStyle) string {
+	rowStart := strings.Index(inner, "<tr>")
+	if rowStart == -1 {
+		return inner
+	}
+	if theadStart := strings.Index(inner, "<thead>"); theadStart != -1 && theadStart < rowStart {
+		return inner
+	}
+	rowEnd := findElementEnd(inner, rowStart, "tr")
+	if rowEnd == -1 {
+		return inner
+	}
+
+	cells := rowCells(elementInner(inner, rowStart, rowEnd, "tr"))
+	if len(cells) == 0 {
+		return inner
+	}
+
+	allTH, allBold := true, true
+	for _, cell := range cells {
+		if cell.tag != "th" {
+			allTH = false
+		}
+		if !boldCellPattern.MatchString(cell.content) {
+			allBold = false
+		}
+	}
+
+	promote := allTH
+	switch style {
+	case TableHeaderFirstRow:
+		promote = true
+	case TableHeaderEmpty:
+	default:
+		promote = allTH || allBold
+	}
+
+	var b strings.Builder
+	b.WriteString("<thead><tr>")
+	for _, cell := range cells {
+		b.WriteString("<th>")
+		if promote {
+			b.WriteString(cell.content)
+		}
+		b.WriteString("</th>")
+	}
+	b.WriteString("</tr></thead>")
+
+	if !promote {
+		return b.String() + inner
+	}
+	return b.String() + inner[:rowStart] + inner[rowEnd:]
+}
+
+// rowCells returns the cells of a table row, skipping over tables nested
+// inside the cells.
+func rowCells(row string) []tableCell {
+	var cells []tableCell
+	for offset := 0; offset < len(row); {
+		loc := cellOpenPattern.FindStringSubmatchIndex(row[offset:])
+		if loc == nil {
+			break
+		}
+		start := offset + loc[0]
+		tag := row[offset+loc[2] : offset+loc[3]]
+		end := findElementEnd(row, start, tag)
+		if end == -1 {
+			break
+		}
+		cells = append(cells, tableCell{tag: tag, content: elementInner(row, start, end, tag)})
+		offset = end
+	}
+	return cells
+}
+
+// SingleCellTableStyle selects how layout tables (a single cell, or no
+// content at all) are converted.
+type SingleCellTableStyle string
+
+const (
+	// SingleCellUnwrap replaces single-cell tables with their content and
+	// drops empty tables (the default).
+	SingleCellUnwrap SingleCellTableStyle = "unwrap"
+	// SingleCellKeep converts layout tables like any other table.
+	SingleCellKeep SingleCellTableStyle = "keep"
+)
+
+// SingleCellTableStyles lists the supported layout table styles.
+var SingleCellTableStyles = []SingleCellTableStyle{SingleCellUnwrap, SingleCellKeep}
+
+// blockTagPattern matches the opening tag of a block-level element.
+var blockTagPattern = regexp.MustCompile(`(?i)<(?:p|div|ul|ol|table|pre|blockquote|h[1-6]|figure)\b`)
+
+// simplifyLayoutTables unwraps single-cell tables and drops tables without
+// any content, which Confluence authors use for layout rather than data.
+// It expects tables simplified by preProcessHTML.
+func simplifyLayoutTables(html string, style SingleCellTableStyle) string {
+	if style == SingleCellKeep {
+		return html
+	}
+
+	const open = "<table>"
+	for searchFrom := 0; ; {
+		idx := strings.Index(html[searchFrom:], open)
+		if idx == -1 {
+			return html
+		}
+		start := searchFrom + idx
+		end := findElementEnd(html, start, "table")
+		if end == -1 {
+			return html
+		}
+
+		inner := elementInner(html, start, end, "table")
+		switch {
+		case isEmptyTable(inner):
+			html = html[:start] + html[end:]
+		case isSingleCellTable(inner):
+			content := strings.TrimSpace(rowCells(tableRows(inner)[0])[0].content)
+			if !blockTagPattern.MatchString(content) {
+				content = "<p>" + content + "</p>"
+			}
+			// Rescan from the same position: the content may hold tables
+			html = html[:start] + content + html[end:]
+		default:
+			searchFrom = start + len(open)
+		}
+	}
+}
+
+// tableRows returns the inner HTML of the rows of a table, skipping over
+// the rows of tables nested inside its cells.
+func tableRows(inner string) []string {
+	var rows []string
+	for offset := 0; offset < len(inner); {
+		idx := indexOpenTag(inner[offset:], "<tr")
+		if idx == -1 {
+			break
+		}
+		start := offset + idx
+		end := findElementEnd(inner, start, "tr")
+		if end == -1 {
+			break
+		}
+		rows = append(rows, elementInner(inner, start, end, "tr"))
+		offset = end
+	}
+	return rows
+}
+
+// isSingleCellTable reports whether a table has exactly one row with one cell.
+func isSingleCellTable(inner string) bool {
+	rows := tableRows(inner)
+	return len(rows) == 1 && len(rowCells(rows[0])) == 1
+}
+
+// isEmptyTable reports whether a table has no text or images.
+func isEmptyTable(inner string) bool {
+	if strings.Contains(inner, "<img") {
+		return false
+	}
+	text := tagPattern.ReplaceAllString(inner, "")
+	text = strings.ReplaceAll(text, "&nbsp;", "")
+	return strings.TrimSpace(text) == ""
+}
@@ -0,0 +1,137 @@
+package converter
+
+import (
+	"testing"
+)
+
+func TestApplyTableHeaders(t *testing.T) {
+	const (
+		plain  = `<table><tbody><tr><td>a</td><td>b</td></tr><tr><td>c</td><td>d</td></tr></tbody></table>`
+		thRow  = `<table><tbody><tr><th>H1</th><th>H2</th></tr><tr><td>c</td><td>d</td></tr></tbody></table>`
+		bold   = `<table><tbody><tr><td><strong>H1</strong></td><td><b>H2</b></td></tr><tr><td>c</td><td>d</td></tr></tbody></table>`
+		thead  = `<table><thead><tr><th>H</th></tr></thead><tbody><tr><td>x</td></tr></tbody></table>`
+		nested = `<table><tbody><tr><td>outer<table><tbody><tr><td>in</td></tr></tbody></table></td></tr></tbody></table>`
+	)
+
+	tests := []struct {
+		name   string
+		input  string
+		style  TableHeaderStyle
+		expect string
+	}{
+		{
+			name:   "infer adds empty header to data table",
+			input:  plain,
+			expect: `<table><thead><tr><th></th><th></th></tr></thead><tbody><tr><td>a</td><td>b</td></tr><tr><td>c</td><td>d</td></tr></tbody></table>`,
+		},
+		{
+			name:   "infer promotes th row",
+			input:  thRow,
+			style:  TableHeaderInfer,
+			expect: `<table><thead><tr><th>H1</th><th>H2</th></tr></thead><tbody><tr><td>c</td><td>d</td></tr></tbody></table>`,
+		},
+		{
+			name:   "infer promotes bold row",
+			input:  bold,
+			style:  TableHeaderInfer,
+			expect: `<table><thead><tr><th><strong>H1</strong></th><th><b>H2</b></th></tr></thead><tbody><tr><td>c</td><td>d</td></tr></tbody></table>`,
+		},
+		{
+			name:   "first-row promotes data",
+			input:  plain,
+			style:  TableHeaderFirstRow,
+			expect: `<table><thead><tr><th>a</th><th>b</th></tr></thead><tbody><tr><td>c</td><td>d</td></tr></tbody></table>`,
+		},
+		{
+			name:   "empty does not promote bold row",
+			input:  bold,
+			style:  TableHeaderEmpty,
+			expect: `<table><thead><tr><th></th><th></th></tr></thead><tbody><tr><td><strong>H1</strong></td><td><b>H2</b></td></tr><tr><td>c</td><td>d</td></tr></tbody></table>`,
+		},
+		{
+			name:   "empty keeps th row as header",
+			input:  thRow,
+			style:  TableHeaderEmpty,
+			expect: `<table><thead><tr><th>H1</th><th>H2</th></tr></thead><tbody><tr><td>c</td><td>d</td></tr></tbody></table>`,
+		},
+		{
+			name:   "existing thead untouched",
+			input:  thead,
+			style:  TableHeaderFirstRow,
+			expect: thead,
+		},
+		{
+			name:   "nested tables each get a header",
+			input:  nested,
+			style:  TableHeaderEmpty,
+			expect: `<table><thead><tr><th></th></tr></thead><tbody><tr><td>outer<table><thead><tr><th></th></tr></thead><tbody><tr><td>in</td></tr></tbody></table></td></tr></tbody></table>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyTableHeaders(tt.input, tt.style); got != tt.expect {
+				t.Errorf("applyTableHeaders() =\n%s\nwant:\n%s", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestSimplifyLayoutTables(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		style  SingleCellTableStyle
+		expect string
+	}{
+		{
+			name:   "single cell with text",
+			input:  `<p>a</p><table><tbody><tr><td>Note text</td></tr></tbody></table><p>b</p>`,
+			expect: `<p>a</p><p>Note text</p><p>b</p>`,
+		},
+		{
+			name:   "single cell with blocks",
+			input:  `<table><tr><td><p>One</p><ul><li>x</li></ul></td></tr></table>`,
+			expect: `<p>One</p><ul><li>x</li></ul>`,
+		},
+		{
+			name:   "empty table dropped",
+			input:  `<p>a</p><table><tbody><tr><td> </td><td>&nbsp;</td></tr><tr><td><br></td><td></td></tr></tbody></table>`,
+			expect: `<p>a</p>`,
+		},
+		{
+			name:   "image-only table kept",
+			input:  `<table><tr><td><img src="a.png" alt=""></td><td></td></tr></table>`,
+			expect: `<table><tr><td><img src="a.png" alt=""></td><td></td></tr></table>`,
+		},
+		{
+			name:   "data table kept",
+			input:  `<table><tr><td>a</td><td>b</td></tr></table>`,
+			expect: `<table><tr><td>a</td><td>b</td></tr></table>`,
+		},
+		{
+			name:   "single cell wrapping a data table",
+			input:  `<table><tr><td><table><tr><td>a</td><td>b</td></tr></table></td></tr></table>`,
+			expect: `<table><tr><td>a</td><td>b</td></tr></table>`,
+		},
+		{
+			name:   "single-cell table nested in data table",
+			input:  `<table><tr><td><table><tr><td>x</td></tr></table></td><td>b</td></tr></table>`,
+			expect: `<table><tr><td><p>x</p></td><td>b</td></tr></table>`,
+		},
+		{
+			name:   "keep",
+			input:  `<table><tr><td>Note</td></tr></table>`,
+			style:  SingleCellKeep,
+			expect: `<table><tr><td>Note</td></tr></table>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := simplifyLayoutTables(tt.input, tt.style); got != tt.expect {
+				t.Errorf("simplifyLayoutTables() =\n%s\nwant:\n%s", got, tt.expect)
+			}
+		})
+	}
+}
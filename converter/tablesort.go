@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TableSort sorts the body rows of tables by one column, for tables whose
+// export order is arbitrary because Confluence sorts them in the browser.
+type TableSort struct {
+	// Column is a header name, matched case-insensitively, or a 1-based
+	// column number. The zero value disables sorting.
+	Column string
+	// Descending reverses the order.
+	Descending bool
+}
+
+// ParseTableSort parses a --sort-tables value: a column name or number,
+// optionally followed by ":asc" or ":desc".
+func ParseTableSort(s string) (TableSort, error) {
+	column, order, hasOrder := strings.Cut(s, ":")
+	column = strings.TrimSpace(column)
+	if column == "" {
+		return TableSort{}, fmt.Errorf("invalid table sort %q: missing column", s)
+	}
+	ts := TableSort{Column: column}
+	if hasOrder {
+		switch strings.ToLower(strings.TrimSpace(order)) {
+		case "asc":
+		case "desc":
+			ts.Descending = true
+		default:
+			return TableSort{}, fmt.Errorf("invalid table sort order %q (want asc or desc)", order)
+		}
+	}
+	return ts, nil
+}
+
+// sortTables sorts the body rows of every table that has the sort column.
+// Header rows stay in place. Tables with merged cells or a footer are left
+// alone, as are tables without the column. Rows compare numerically when
+// both cells are numbers and as case-insensitive text otherwise; rows with
+// an empty cell sort last. It expects tables with headers added by
+// applyTableHeaders.
+func sortTables(htmlContent string, ts TableSort) string {
+	if ts.Column == "" {
+		return htmlContent
+	}
+	return rewriteTables(htmlContent, func(inner string) string {
+		return sortTableRows(inner, ts)
+	})
+}
+
+// sortTableRows returns the inner HTML of a table with its body rows sorted.
+func sortTableRows(inner string, ts TableSort) string {
+	if strings.Contains(inner, "<tfoot") || strings.Contains(inner, "rowspan") || strings.Contains(inner, "colspan") {
+		return inner
+	}
+	headStart := strings.Index(inner, "<thead>")
+	if headStart == -1 {
+		return inner
+	}
+	headEnd := findElementEnd(inner, headStart, "thead")
+	if headEnd == -1 {
+		return inner
+	}
+	head := inner[:headEnd]
+	headRows := tableRows(elementInner(inner, headStart, headEnd, "thead"))
+	if len(headRows) != 1 {
+		return inner
+	}
+	column := sortColumn(rowCells(headRows[0]), ts.Column)
+	if column == -1 {
+		return inner
+	}
+
+	rows := tableRows(inner[headEnd:])
+	keys := make([]string, len(rows))
+	for i, row := range rows {
+		cells := rowCells(row)
+		if column < len(cells) {
+			keys[i] = cellText(cells[column].content)
+		}
+	}
+	order := make([]int, len(rows))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ka, kb := keys[order[a]], keys[order[b]]
+		if ka == "" || kb == "" {
+			return kb == "" && ka != ""
+		}
+		if ts.Descending {
+			return lessCell(kb, ka)
+		}
+		return lessCell(ka, kb)
+	})
+
+	var b strings.Builder
+	b.WriteString(head)
+	b.WriteString("<tbody>")
+	for _, i := range order {
+		b.WriteString("<tr>" + rows[i] + "</tr>")
+	}
+	b.WriteString("</tbody>")
+	return b.String()
+}
+
+// sortColumn returns the index of the header cell named column, or of the
+// 1-based column number, or -1.
+func sortColumn(header []tableCell, column string) int {
+	for i, cell := range header {
+		if strings.EqualFold(cellText(cell.content), column) {
+			return i
+		}
+	}
+	if n, err := strconv.Atoi(column); err == nil && n >= 1 && n <= len(header) {
+		return n - 1
+	}
+	return -1
+}
+
+// cellText returns the text of a table cell's HTML.
+func cellText(content string) string {
+	return strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(content, "")))
+}
+
+// lessCell orders two non-empty cell texts.
+func lessCell(a, b string) bool {
+	na, errA := strconv.ParseFloat(strings.ReplaceAll(a, ",", ""), 64)
+	nb, errB := strconv.ParseFloat(strings.ReplaceAll(b, ",", ""), 64)
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return strings.ToLower(a) < strings.ToLower(b)
+}
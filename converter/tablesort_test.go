@@ -0,0 +1,98 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTableSort(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    TableSort
+		wantErr bool
+	}{
+		{"Status", TableSort{Column: "Status"}, false},
+		{"2:desc", TableSort{Column: "2", Descending: true}, false},
+		{"Due date:ASC", TableSort{Column: "Due date"}, false},
+		{":desc", TableSort{}, true},
+		{"Status:up", TableSort{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTableSort(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTableSort(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTableSort(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSortTables(t *testing.T) {
+	const table = `<table><thead><tr><th>Task</th><th>Points</th></tr></thead><tbody>` +
+		`<tr><td>Deploy</td><td>8</td></tr>` +
+		`<tr><td><strong>audit</strong></td><td>10</td></tr>` +
+		`<tr><td></td><td>1</td></tr>` +
+		`<tr><td>Build</td><td>2</td></tr>` +
+		`</tbody></table>`
+	order := func(html string, names ...string) []int {
+		idx := make([]int, len(names))
+		for i, name := range names {
+			idx[i] = strings.Index(html, name)
+		}
+		return idx
+	}
+	ascending := func(idx []int) bool {
+		for i := 1; i < len(idx); i++ {
+			if idx[i-1] == -1 || idx[i-1] > idx[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	tests := []struct {
+		name  string
+		sort  TableSort
+		order []string
+	}{
+		{"by name, empty cells last", TableSort{Column: "task"}, []string{"audit", "Build", "Deploy", "<td>1</td>"}},
+		{"by name descending", TableSort{Column: "Task", Descending: true}, []string{"Deploy", "Build", "audit", "<td>1</td>"}},
+		{"numerically by column number", TableSort{Column: "2"}, []string{"<td>1</td>", "<td>2</td>", "<td>8</td>", "<td>10</td>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sortTables(table, tt.sort)
+			if !ascending(order(got, tt.order...)) {
+				t.Errorf("sortTables() rows out of order:\n%s", got)
+			}
+			if !strings.HasPrefix(got, `<table><thead><tr><th>Task</th><th>Points</th></tr></thead><tbody><tr>`) {
+				t.Errorf("header row should stay first:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestSortTables_Unchanged(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		sort TableSort
+	}{
+		{"sorting disabled", `<table><thead><tr><th>A</th></tr></thead><tr><td>b</td></tr><tr><td>a</td></tr></table>`, TableSort{}},
+		{"unknown column", `<table><thead><tr><th>A</th></tr></thead><tr><td>b</td></tr><tr><td>a</td></tr></table>`, TableSort{Column: "Owner"}},
+		{"merged cells", `<table><thead><tr><th>A</th></tr></thead><tr><td rowspan="2">b</td></tr><tr><td>a</td></tr></table>`, TableSort{Column: "A"}},
+		{"no header", `<table><tr><td>b</td></tr><tr><td>a</td></tr></table>`, TableSort{Column: "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sortTables(tt.html, tt.sort); got != tt.html {
+				t.Errorf("sortTables() = %s, want unchanged", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import "strings"
+
+// Target selects a static site generator the output is prepared for.
+type Target string
+
+const (
+	// TargetNone produces plain Markdown files (the default).
+	TargetNone Target = "none"
+	// TargetJekyll produces Jekyll/GitHub Pages posts: Liquid-safe content,
+	// with front matter and date-prefixed file names supplied by the caller.
+	TargetJekyll Target = "jekyll"
+)
+
+// Targets lists the supported site generator targets.
+var Targets = []Target{TargetNone, TargetJekyll}
+
+// escapeLiquid protects {{ and {% sequences from Jekyll's Liquid template
+// engine. Fenced code blocks containing them are wrapped in a raw block as a
+// whole; other lines containing them are wrapped individually.
+func escapeLiquid(md string) string {
+	if !strings.Contains(md, "{{") && !strings.Contains(md, "{%") {
+		return md
+	}
+
+	lines := strings.Split(md, "\n")
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		if !fencePattern.MatchString(lines[i]) {
+			if hasLiquidMarkup(lines[i]) {
+				out = append(out, "{% raw %}"+lines[i]+"{% endraw %}")
+			} else {
+				out = append(out, lines[i])
+			}
+			continue
+		}
+
+		end := i + 1
+		for end < len(lines) && !fencePattern.MatchString(lines[end]) {
+			end++
+		}
+		if end >= len(lines) {
+			end = len(lines) - 1
+		}
+
+		block := lines[i : end+1]
+		if hasLiquidMarkup(strings.Join(block, "\n")) {
+			out = append(out, "{% raw %}")
+			out = append(out, block...)
+			out = append(out, "{% endraw %}")
+		} else {
+			out = append(out, block...)
+		}
+		i = end
+	}
+	return strings.Join(out, "\n")
+}
+
+// hasLiquidMarkup reports whether s contains Liquid tag or output delimiters.
+func hasLiquidMarkup(s string) bool {
+	return strings.Contains(s, "{{") || strings.Contains(s, "{%")
+}
@@ -0,0 +1,40 @@
+package converter
+
+import "testing"
+
+func TestEscapeLiquid(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "no liquid markup",
+			input:    "Plain text\n",
+			expected: "Plain text\n",
+		},
+		{
+			name:     "code block wrapped as a whole",
+			input:    "Example:\n\n```yaml\nname: {{ .Name }}\n```\n",
+			expected: "Example:\n\n{% raw %}\n```yaml\nname: {{ .Name }}\n```\n{% endraw %}\n",
+		},
+		{
+			name:     "code block without markup untouched",
+			input:    "```\nplain\n```\n",
+			expected: "```\nplain\n```\n",
+		},
+		{
+			name:     "inline occurrence wrapped per line",
+			input:    "Use `{% include x %}` here.\nNext line.\n",
+			expected: "{% raw %}Use `{% include x %}` here.{% endraw %}\nNext line.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeLiquid(tt.input); got != tt.expected {
+				t.Errorf("escapeLiquid() =\n%q\nwant\n%q", got, tt.expected)
+			}
+		})
+	}
+}
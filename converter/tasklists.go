@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// taskDoneMarker and taskTodoMarker start the items of inline task lists
+// while pandoc converts the document, recording whether they are checked.
+const (
+	taskDoneMarker = "CTWOMDTASKDONE"
+	taskTodoMarker = "CTWOMDTASKTODO"
+)
+
+var (
+	// inlineTaskListPattern matches the opening tag of Confluence task
+	// lists.
+	inlineTaskListPattern = regexp.MustCompile(`<ul\b[^>]*\bclass="[^"]*\binline-task-list\b[^"]*"[^>]*>`)
+
+	// taskListTokenPattern matches list tags and list item start tags,
+	// with the opening <p> of the item's content.
+	taskListTokenPattern = regexp.MustCompile(`(?i)</?(?:ul|ol)\b[^>]*>|<li\b[^>]*>(?:\s*<p\b[^>]*>)?`)
+
+	// checkedTaskPattern matches the class of checked task items.
+	checkedTaskPattern = regexp.MustCompile(`\bclass="[^"]*\bchecked\b`)
+
+	// taskItemMarkerPattern matches a marker at the start of a list item,
+	// capturing the list marker and the marker.
+	taskItemMarkerPattern = regexp.MustCompile(`(?m)^([ \t]*(?:[-*+]|\d{1,9}[.)])[ \t]+)(` + taskDoneMarker + `|` + taskTodoMarker + `)[ \t]*`)
+
+	// taskMarkerPattern matches the markers left elsewhere, such as in
+	// lists pandoc wrote as raw HTML.
+	taskMarkerPattern = regexp.MustCompile(`(` + taskDoneMarker + `|` + taskTodoMarker + `)[ \t]*`)
+)
+
+// writesTaskLists reports whether the flavor has task list items
+// ("- [ ]"), a GFM extension that CommonMark lacks; there pandoc's own
+// rendering of the list is kept.
+func (f Flavor) writesTaskLists() bool {
+	return f != FlavorCommonMark
+}
+
+// markTaskLists starts the items of Confluence inline task lists with a
+// marker of their checked state, for restoreTaskLists. Exports mark
+// checked items with the "checked" class.
+func markTaskLists(html string) string {
+	return replaceElements(html, inlineTaskListPattern, "ul", func(inner string, _ []string) string {
+		depth := 0
+		inner = taskListTokenPattern.ReplaceAllStringFunc(inner, func(tag string) string {
+			switch {
+			case strings.HasPrefix(tag, "</"):
+				depth--
+				return tag
+			case !strings.HasPrefix(strings.ToLower(tag), "<li"):
+				depth++
+				return tag
+			case depth != 0:
+				return tag
+			case checkedTaskPattern.MatchString(tag):
+				return tag + taskDoneMarker + " "
+			default:
+				return tag + taskTodoMarker + " "
+			}
+		})
+		// Nested task lists are marked when the search reaches them
+		return "<ul>" + inner + "</ul>"
+	})
+}
+
+// restoreTaskLists turns the markers of markTaskLists into GFM task list
+// checkboxes: "- [x]" for checked items and "- [ ]" for the others.
+func restoreTaskLists(md string) string {
+	if !strings.Contains(md, taskDoneMarker) && !strings.Contains(md, taskTodoMarker) {
+		return md
+	}
+	md = taskItemMarkerPattern.ReplaceAllStringFunc(md, func(match string) string {
+		m := taskItemMarkerPattern.FindStringSubmatch(match)
+		return m[1] + taskCheckbox(m[2]) + " "
+	})
+	return taskMarkerPattern.ReplaceAllStringFunc(md, func(match string) string {
+		return taskCheckbox(taskMarkerPattern.FindStringSubmatch(match)[1]) + " "
+	})
+}
+
+// taskCheckbox returns the checkbox for a task marker.
+func taskCheckbox(marker string) string {
+	if marker == taskDoneMarker {
+		return "[x]"
+	}
+	return "[ ]"
+}
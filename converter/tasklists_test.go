@@ -0,0 +1,55 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkTaskLists(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "checked and unchecked items",
+			html: `<ul class="inline-task-list" data-inline-tasks-content-id="7"><li data-inline-task-id="1" class="checked">Write</li><li data-inline-task-id="2">Ship</li></ul>`,
+			want: `<ul><li data-inline-task-id="1" class="checked">CTWOMDTASKDONE Write</li><li data-inline-task-id="2">CTWOMDTASKTODO Ship</li></ul>`,
+		},
+		{
+			name: "item with a paragraph",
+			html: `<ul class="inline-task-list"><li><p>Review</p></li></ul>`,
+			want: `<ul><li><p>CTWOMDTASKTODO Review</p></li></ul>`,
+		},
+		{
+			name: "nested task list and plain list",
+			html: `<ul class="inline-task-list"><li class="checked">Parent<ul class="inline-task-list"><li>Child</li></ul><ul><li>Note</li></ul></li></ul>`,
+			want: `<ul><li class="checked">CTWOMDTASKDONE Parent<ul><li>CTWOMDTASKTODO Child</li></ul><ul><li>Note</li></ul></li></ul>`,
+		},
+		{
+			name: "plain list",
+			html: `<ul><li class="checked">Item</li></ul>`,
+			want: `<ul><li class="checked">Item</li></ul>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markTaskLists(tt.html); got != tt.want {
+				t.Errorf("markTaskLists() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestoreTaskLists(t *testing.T) {
+	md := "- CTWOMDTASKDONE Write\n- CTWOMDTASKTODO Ship\n  - CTWOMDTASKTODO Child\n1. CTWOMDTASKDONE First\n\n<li>CTWOMDTASKTODO Raw</li>\n"
+	want := "- [x] Write\n- [ ] Ship\n  - [ ] Child\n1. [x] First\n\n<li>[ ] Raw</li>\n"
+	got := restoreTaskLists(md)
+	if got != want {
+		t.Errorf("restoreTaskLists() =\n%s\nwant\n%s", got, want)
+	}
+	if strings.Contains(got, "CTWOMDTASK") {
+		t.Errorf("restoreTaskLists() left a marker: %q", got)
+	}
+}
@@ -0,0 +1,27 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"os"
+
+	"github.com/aqueeb/confluence2md/internal/pandoc"
+)
+
+// tempDir is the directory temporary files are created in, or "" for the
+// system temp directory.
+var tempDir string
+
+// SetTempDir directs temporary files, the extracted embedded pandoc, and
+// pandoc's own temporary files to dir instead of the system temp and user
+// cache directories, for example to keep them on a local disk when the
+// exports live on a network share. Call it before the first conversion.
+func SetTempDir(dir string) {
+	tempDir = dir
+	pandoc.SetWorkDir(dir)
+}
+
+// createTemp creates a temporary file in the configured temp directory.
+func createTemp(pattern string) (*os.File, error) {
+	return os.CreateTemp(tempDir, pattern)
+}
@@ -0,0 +1,64 @@
+package converter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetTempDir(t *testing.T) {
+	dir := t.TempDir()
+	SetTempDir(dir)
+	defer SetTempDir("")
+
+	f, err := createTemp("confluence-*.tpl")
+	if err != nil {
+		t.Fatalf("createTemp() error: %v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	if filepath.Dir(f.Name()) != dir {
+		t.Errorf("createTemp() created %s, want a file in %s", f.Name(), dir)
+	}
+}
+
+func TestCachedReferenceDocx(t *testing.T) {
+	SetTempDir(t.TempDir())
+	defer SetTempDir("")
+
+	path, err := cachedReferenceDocx()
+	if err != nil {
+		t.Fatalf("cachedReferenceDocx() error: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cached reference document not written: %v", err)
+	}
+	want, err := buildReferenceDocx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("cached reference document differs from the built one")
+	}
+
+	again, err := writeCachedReferenceDocx()
+	if err != nil || again != path {
+		t.Errorf("writeCachedReferenceDocx() = %s, %v; want the cached %s", again, err, path)
+	}
+}
+
+func TestConvertHTMLToDocument_MinimizeTempFiles(t *testing.T) {
+	if err := CheckPandoc(); err != nil {
+		t.Skipf("Pandoc not installed, skipping test: %v", err)
+	}
+
+	doc, err := ConvertHTMLToDocument("<h1>Title</h1><p>Body</p>", Options{To: FormatDOCX, MinimizeTempFiles: true})
+	if err != nil {
+		t.Fatalf("ConvertHTMLToDocument() error: %v", err)
+	}
+	if !bytes.HasPrefix(doc, []byte("PK")) {
+		t.Errorf("expected a DOCX (zip) document, got %q...", doc[:min(len(doc), 16)])
+	}
+}
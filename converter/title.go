@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// titleElementPattern captures the content of a <title> element.
+	titleElementPattern = regexp.MustCompile(`(?is)<title\b[^>]*>(.*?)</title\s*>`)
+
+	// svgElementPattern matches inline SVG images, whose <title> and
+	// <style> elements belong to the image rather than the page.
+	svgElementPattern = regexp.MustCompile(`(?is)<svg\b.*?</svg\s*>`)
+)
+
+// ExtractHTMLTitle returns the text of the page's <title> element, or an
+// empty string if it has none or only the generic Confluence export
+// subject. Titles of inline SVG images are ignored.
+func ExtractHTMLTitle(htmlContent string) string {
+	for _, seg := range splitRegions(htmlContent, svgElementPattern) {
+		if seg.region {
+			continue
+		}
+		if m := titleElementPattern.FindStringSubmatch(seg.text); m != nil {
+			title := plainText(m[1])
+			if strings.EqualFold(title, genericExportSubject) {
+				return ""
+			}
+			return title
+		}
+	}
+	return ""
+}
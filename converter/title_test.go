@@ -0,0 +1,66 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractHTMLTitle(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"head title", "<html><head><title>Release Notes</title></head><body></body></html>", "Release Notes"},
+		{"entities and whitespace", "<title>\n  Q3 &amp; Q4\n  Plans </title>", "Q3 & Q4 Plans"},
+		{"generic export subject", "<title>Exported From Confluence</title>", ""},
+		{"no title", "<html><body><p>Text</p></body></html>", ""},
+		{"svg title skipped", `<body><svg><title>Arrow</title></svg><p>Text</p></body>`, ""},
+		{"page title after svg", `<svg><title>Arrow</title></svg><title>Page</title>`, "Page"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractHTMLTitle(tt.html); got != tt.want {
+				t.Errorf("ExtractHTMLTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadExportMetadata_HTMLTitle(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		want    string
+	}{
+		{"generic subject", "Exported From Confluence", "Release Notes"},
+		{"page subject wins", "Team Page", "Team Page"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mimeContent := "Date: Wed, 7 Jan 2026 01:29:00 +0000 (UTC)\n" +
+				"Subject: " + tt.subject + "\n" +
+				"MIME-Version: 1.0\n" +
+				"Content-Type: multipart/related; boundary=\"b\"\n\n" +
+				"--b\n" +
+				"Content-Type: text/html; charset=UTF-8\n\n" +
+				"<html><head><title>Release Notes</title></head><body></body></html>\n" +
+				"--b--\n"
+			testFile := filepath.Join(t.TempDir(), "page.doc")
+			if err := os.WriteFile(testFile, []byte(mimeContent), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			meta, err := ReadExportMetadata(testFile)
+			if err != nil {
+				t.Fatalf("ReadExportMetadata failed: %v", err)
+			}
+			if got := meta.PageTitle(); got != tt.want {
+				t.Errorf("PageTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
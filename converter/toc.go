@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultTOCDepth is the heading depth used when a table of contents is
+// requested without an explicit depth.
+const DefaultTOCDepth = 3
+
+// insertTOC generates a Markdown table of contents from the document's
+// headings and inserts it at the top of the document, after any front matter.
+// Only headings up to depth levels below the shallowest heading are listed,
+// each level indented by indent spaces. GitLab renders its own table of contents, so for that flavor the [[_TOC_]]
+// tag is inserted instead.
+func insertTOC(md string, depth int, flavor Flavor, indent int) string {
+	lines := strings.Split(md, "\n")
+	headings := findHeadings(lines)
+	if len(headings) == 0 || depth <= 0 {
+		return md
+	}
+
+	if flavor == FlavorGitLab {
+		frontMatter, body := splitFrontMatter(md)
+		return frontMatter + "[[_TOC_]]\n\n" + body
+	}
+
+	topLevel := 6
+	for _, h := range headings {
+		if h.level < topLevel {
+			topLevel = h.level
+		}
+	}
+
+	// Every heading gets a slug, even when it is too deep to be listed,
+	// so that duplicate suffixes match the ones the renderer generates.
+	slugs := newSlugger(flavor)
+	var toc strings.Builder
+	for _, h := range headings {
+		anchor := h.anchor(slugs)
+		nesting := h.level - topLevel
+		if nesting >= depth {
+			continue
+		}
+		fmt.Fprintf(&toc, "%s- [%s](#%s)\n", strings.Repeat(" ", nesting*indent), tocLinkText(h.text), anchor)
+	}
+
+	frontMatter, body := splitFrontMatter(md)
+	return frontMatter + toc.String() + "\n" + body
+}
+
+// tocLinkText strips Markdown link syntax from heading text so it can be
+// nested inside a TOC link.
+func tocLinkText(text string) string {
+	return markdownLinkPattern.ReplaceAllString(text, "$1")
+}
+
+// splitFrontMatter splits a document into its leading YAML front matter block
+// (including the closing delimiter line) and the remaining body. Documents
+// without front matter return an empty front matter string.
+func splitFrontMatter(md string) (string, string) {
+	if !strings.HasPrefix(md, "---\n") {
+		return "", md
+	}
+	end := strings.Index(md[4:], "\n---\n")
+	if end == -1 {
+		return "", md
+	}
+	split := 4 + end + len("\n---\n")
+	return md[:split] + "\n", strings.TrimLeft(md[split:], "\n")
+}
@@ -0,0 +1,124 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInsertTOC(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		depth    int
+		expected string
+	}{
+		{
+			name:  "nested headings",
+			input: "# Intro\n\nText.\n\n## Setup\n\n### Linux\n",
+			depth: 3,
+			expected: "- [Intro](#intro)\n  - [Setup](#setup)\n    - [Linux](#linux)\n\n" +
+				"# Intro\n\nText.\n\n## Setup\n\n### Linux\n",
+		},
+		{
+			name:     "depth limits listed headings",
+			input:    "# Intro\n\n## Setup\n\n### Linux\n",
+			depth:    1,
+			expected: "- [Intro](#intro)\n\n# Intro\n\n## Setup\n\n### Linux\n",
+		},
+		{
+			name:     "duplicate headings get suffixed anchors",
+			input:    "## Usage\n\n## Usage\n",
+			depth:    2,
+			expected: "- [Usage](#usage)\n- [Usage](#usage-1)\n\n## Usage\n\n## Usage\n",
+		},
+		{
+			name:     "heading ids from attributes",
+			input:    "## Usage {#Page-Usage .note}\n\n## Setup\n",
+			depth:    2,
+			expected: "- [Usage](#Page-Usage)\n- [Setup](#setup)\n\n## Usage {#Page-Usage .note}\n\n## Setup\n",
+		},
+		{
+			name:     "no headings",
+			input:    "Just text.\n",
+			depth:    3,
+			expected: "Just text.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := insertTOC(tt.input, tt.depth, FlavorGFM, defaultListIndent)
+			if got != tt.expected {
+				t.Errorf("insertTOC() =\n%q\nwant\n%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInsertTOC_AfterFrontMatter(t *testing.T) {
+	input := "---\ntitle: Page\n---\n\n# Intro\n"
+	got := insertTOC(input, 3, FlavorGFM, defaultListIndent)
+
+	if !strings.HasPrefix(got, "---\ntitle: Page\n---\n\n- [Intro](#intro)\n") {
+		t.Errorf("Expected TOC after front matter, got: %q", got)
+	}
+}
+
+func TestInsertTOC_LinkHeadings(t *testing.T) {
+	got := insertTOC("# See [Docs](https://example.com)\n", 3, FlavorGFM, defaultListIndent)
+
+	if !strings.Contains(got, "- [See Docs](#see-docs)") {
+		t.Errorf("Expected link syntax stripped from TOC entry, got: %q", got)
+	}
+}
+
+func TestApplyOptions_NumberedTOC(t *testing.T) {
+	got := runMarkdownSteps(t, "# Intro\n\n## Setup\n", Options{NumberHeadings: true, TOCDepth: 2})
+
+	if !strings.Contains(got, "- [1. Intro](#1-intro)") {
+		t.Errorf("Expected numbered TOC entry, got: %q", got)
+	}
+	if !strings.Contains(got, "  - [1.1 Setup](#11-setup)") {
+		t.Errorf("Expected numbered nested TOC entry, got: %q", got)
+	}
+}
+
+func TestApplyOptions_TOCListIndent(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"gfm", Options{TOCDepth: 3}, "- [Intro](#intro)\n  - [Setup](#setup)\n    - [Linux](#linux)\n"},
+		{"mkdocs", Options{TOCDepth: 3, Flavor: FlavorMkDocs}, "- [Intro](#intro)\n    - [Setup](#setup)\n        - [Linux](#linux)\n"},
+		{"list indent", Options{TOCDepth: 3, ListIndent: 3}, "- [Intro](#intro)\n   - [Setup](#setup)\n      - [Linux](#linux)\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := runMarkdownSteps(t, "# Intro\n\n## Setup\n\n### Linux\n", tt.opts)
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("TOC =\n%s\nwant it to start with\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyOptions_TOCDuplicateHeadings(t *testing.T) {
+	tests := []struct {
+		flavor Flavor
+		want   string
+	}{
+		{FlavorGFM, "- [Usage](#usage)\n- [Usage](#usage-1)\n"},
+		{FlavorMkDocs, "- [Usage](#usage)\n- [Usage](#usage_1)\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.flavor), func(t *testing.T) {
+			got := runMarkdownSteps(t, "## Usage\n\n## Usage\n", Options{TOCDepth: 2, Flavor: tt.flavor})
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("TOC =\n%s\nwant it to start with\n%s", got, tt.want)
+			}
+		})
+	}
+}
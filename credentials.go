@@ -0,0 +1,195 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/aqueeb/confluence2md/internal/confluence"
+)
+
+// The environment variables holding the Confluence credentials for --url.
+// Without a user the token is sent as a Data Center personal access token;
+// with an OAuth client ID the client credentials are used instead.
+const (
+	confluenceUserEnv         = "CONFLUENCE_USER"
+	confluenceTokenEnv        = "CONFLUENCE_TOKEN"
+	confluenceClientIDEnv     = "CONFLUENCE_CLIENT_ID"
+	confluenceClientSecretEnv = "CONFLUENCE_CLIENT_SECRET"
+)
+
+// errNoTerminal is returned by promptSecret when there is no terminal to
+// ask on.
+var errNoTerminal = errors.New("no terminal")
+
+// credentials authenticate the requests of --url and sync: an account
+// email and API token (Confluence Cloud), a personal access token alone
+// (Data Center), or OAuth 2.0 client credentials. A cookie jar and a
+// header file add an SSO session for instances behind single sign-on. They
+// are read from the "confluence" section of the config file as well.
+type credentials struct {
+	User         string `json:"user,omitempty"`
+	Token        string `json:"token,omitempty"`
+	ClientID     string `json:"clientId,omitempty"`
+	ClientSecret string `json:"clientSecret,omitempty"`
+	TokenURL     string `json:"tokenUrl,omitempty"`
+	// CookieJar is a Netscape cookies.txt file and HeaderFile a file of
+	// "Name: value" lines, sent with every request; in the config file
+	// they are relative to it
+	CookieJar  string `json:"cookieJar,omitempty"`
+	HeaderFile string `json:"headerFile,omitempty"`
+}
+
+// envCredentials returns the credentials set in the environment.
+func envCredentials() credentials {
+	return credentials{
+		User:         os.Getenv(confluenceUserEnv),
+		Token:        os.Getenv(confluenceTokenEnv),
+		ClientID:     os.Getenv(confluenceClientIDEnv),
+		ClientSecret: os.Getenv(confluenceClientSecretEnv),
+	}
+}
+
+// or returns c with its empty fields taken from fallback.
+func (c credentials) or(fallback credentials) credentials {
+	for _, f := range []struct{ field, fallback *string }{
+		{&c.User, &fallback.User},
+		{&c.Token, &fallback.Token},
+		{&c.ClientID, &fallback.ClientID},
+		{&c.ClientSecret, &fallback.ClientSecret},
+		{&c.TokenURL, &fallback.TokenURL},
+		{&c.CookieJar, &fallback.CookieJar},
+		{&c.HeaderFile, &fallback.HeaderFile},
+	} {
+		if *f.field == "" {
+			*f.field = *f.fallback
+		}
+	}
+	return c
+}
+
+// validate reports whether the credentials are consistent.
+func (c credentials) validate() error {
+	if c.ClientID == "" && (c.ClientSecret != "" || c.TokenURL != "") {
+		return errors.New("an OAuth client secret or token URL needs a client ID")
+	}
+	return nil
+}
+
+// apply sets up client to authenticate with the credentials. A missing
+// token or client secret is asked for on the terminal, without echoing it,
+// unless a cookie jar or header file carries the session instead.
+func (c credentials) apply(client *confluence.Client) error {
+	if err := c.applySession(client); err != nil {
+		return err
+	}
+	if c.ClientID != "" {
+		if c.ClientSecret == "" {
+			secret, err := promptSecret(fmt.Sprintf("OAuth client secret for %s: ", c.ClientID))
+			if err != nil {
+				return missingCredentials(err)
+			}
+			c.ClientSecret = secret
+		}
+		client.OAuth = &confluence.OAuth{TokenURL: c.TokenURL, ClientID: c.ClientID, ClientSecret: c.ClientSecret}
+		return nil
+	}
+	if c.Token == "" && c.User == "" && (c.CookieJar != "" || c.HeaderFile != "") {
+		return nil
+	}
+	if c.Token == "" {
+		prompt := "Confluence personal access token: "
+		if c.User != "" {
+			prompt = fmt.Sprintf("Confluence API token for %s: ", c.User)
+		}
+		token, err := promptSecret(prompt)
+		if err != nil {
+			return missingCredentials(err)
+		}
+		c.Token = token
+	}
+	client.User, client.Token = c.User, c.Token
+	return nil
+}
+
+// applySession loads the cookie jar and header file into client.
+func (c credentials) applySession(client *confluence.Client) error {
+	if c.CookieJar != "" {
+		jar, err := readSessionFile(c.CookieJar, confluence.ReadCookieJar)
+		if err != nil {
+			return fmt.Errorf("invalid cookie jar %s: %w", c.CookieJar, err)
+		}
+		if client.HTTPClient == nil {
+			client.HTTPClient = &http.Client{}
+		}
+		client.HTTPClient.Jar = jar
+	}
+	if c.HeaderFile != "" {
+		header, err := readSessionFile(c.HeaderFile, confluence.ReadHeaders)
+		if err != nil {
+			return fmt.Errorf("invalid header file %s: %w", c.HeaderFile, err)
+		}
+		client.Header = header
+	}
+	return nil
+}
+
+// readSessionFile reads the file at path with read.
+func readSessionFile[T any](path string, read func(io.Reader) (T, error)) (T, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer f.Close()
+	return read(f)
+}
+
+// missingCredentials explains where credentials can be given when they
+// could not be asked for.
+func missingCredentials(err error) error {
+	if errors.Is(err, errNoTerminal) {
+		return fmt.Errorf("--url requires Confluence credentials: an API token in %s (with the account email in %s) or --confluence-token, OAuth client credentials in %s and %s, or an SSO session with --cookie-jar or --header-file", confluenceTokenEnv, confluenceUserEnv, confluenceClientIDEnv, confluenceClientSecretEnv)
+	}
+	return fmt.Errorf("failed to read credentials: %w", err)
+}
+
+// promptSecret asks for a secret on the terminal, with echo turned off
+// while it is typed. It returns errNoTerminal when the process has no
+// terminal, such as in CI.
+var promptSecret = func(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", errNoTerminal
+	}
+	defer tty.Close()
+
+	stty := func(arg string) error {
+		cmd := exec.Command("stty", arg)
+		cmd.Stdin = tty
+		return cmd.Run()
+	}
+	if err := stty("-echo"); err != nil {
+		return "", fmt.Errorf("failed to turn off echo: %w", err)
+	}
+	defer stty("echo")
+
+	fmt.Fprint(tty, prompt)
+	line, err := bufio.NewReader(tty).ReadString('\n')
+	fmt.Fprintln(tty)
+	secret := strings.TrimSpace(line)
+	if secret == "" {
+		if err == nil {
+			err = errors.New("nothing entered")
+		}
+		return "", err
+	}
+	return secret, nil
+}
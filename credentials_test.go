@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/internal/confluence"
+)
+
+func TestParseFlags_Credentials(t *testing.T) {
+	path := writeConfigFile(t, `{"confluence": {"user": "config@example.com", "token": "config-token", "clientSecret": "config-secret"}}`)
+	t.Setenv(confluenceUserEnv, "")
+	t.Setenv(confluenceTokenEnv, "env-token")
+	t.Setenv(confluenceClientIDEnv, "env-app")
+	t.Setenv(confluenceClientSecretEnv, "")
+
+	cfg, err := parseFlags([]string{"--config", path, "--url", "https://example.atlassian.net/wiki", "--space", "ENG", "--confluence-token", "flag-token"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	want := credentials{User: "config@example.com", Token: "flag-token", ClientID: "env-app", ClientSecret: "config-secret"}
+	if cfg.credentials != want {
+		t.Errorf("credentials = %+v, want %+v", cfg.credentials, want)
+	}
+
+	t.Setenv(confluenceClientIDEnv, "")
+	for _, args := range [][]string{
+		{"--confluence-token", "flag-token", "input.doc"},
+		{"--url", "https://example.atlassian.net/wiki", "--space", "ENG", "--oauth-client-secret", "s3cret"},
+	} {
+		if _, err := parseFlags(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("parseFlags(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestCredentials_Apply(t *testing.T) {
+	tests := []struct {
+		name      string
+		creds     credentials
+		prompted  string
+		want      credentials
+		wantOAuth *confluence.OAuth
+		wantErr   bool
+	}{
+		{name: "API token", creds: credentials{User: "me@example.com", Token: "t0ken"}, want: credentials{User: "me@example.com", Token: "t0ken"}},
+		{name: "prompted token", creds: credentials{User: "me@example.com"}, prompted: "typed", want: credentials{User: "me@example.com", Token: "typed"}},
+		{name: "prompted client secret", creds: credentials{ClientID: "app"}, prompted: "typed", wantOAuth: &confluence.OAuth{ClientID: "app", ClientSecret: "typed"}},
+		{name: "no terminal", creds: credentials{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(prompt func(string) (string, error)) { promptSecret = prompt }(promptSecret)
+			promptSecret = func(string) (string, error) {
+				if tt.prompted == "" {
+					return "", errNoTerminal
+				}
+				return tt.prompted, nil
+			}
+
+			var client confluence.Client
+			err := tt.creds.apply(&client)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if errors.Is(err, errNoTerminal) {
+					t.Errorf("apply() error = %v, want a hint at the credentials instead", err)
+				}
+				return
+			}
+			if client.User != tt.want.User || client.Token != tt.want.Token {
+				t.Errorf("apply() = user %q, token %q, want %q, %q", client.User, client.Token, tt.want.User, tt.want.Token)
+			}
+			if (client.OAuth == nil) != (tt.wantOAuth == nil) || client.OAuth != nil && *client.OAuth != *tt.wantOAuth {
+				t.Errorf("apply() OAuth = %+v, want %+v", client.OAuth, tt.wantOAuth)
+			}
+		})
+	}
+}
+
+func TestCredentials_ApplySession(t *testing.T) {
+	dir := t.TempDir()
+	cookieJar := filepath.Join(dir, "cookies.txt")
+	headerFile := filepath.Join(dir, "headers.txt")
+	if err := os.WriteFile(cookieJar, []byte("wiki.example.com\tFALSE\t/\tTRUE\t0\tsession\tabc\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(headerFile, []byte("X-Proxy-Auth: s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var client confluence.Client
+	if err := (credentials{CookieJar: cookieJar, HeaderFile: headerFile}).apply(&client); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	u, _ := url.Parse("https://wiki.example.com/rest/api/content")
+	if client.HTTPClient == nil || client.HTTPClient.Jar == nil || len(client.HTTPClient.Jar.Cookies(u)) != 1 {
+		t.Errorf("apply() did not load the cookie jar: %+v", client.HTTPClient)
+	}
+	if client.Header.Get("X-Proxy-Auth") != "s3cret" || client.Token != "" {
+		t.Errorf("apply() = header %v, token %q, want the header file and no token", client.Header, client.Token)
+	}
+
+	for _, creds := range []credentials{
+		{CookieJar: filepath.Join(dir, "missing.txt")},
+		{HeaderFile: cookieJar},
+	} {
+		if err := creds.apply(&confluence.Client{}); err == nil {
+			t.Errorf("apply(%+v) succeeded, want an error", creds)
+		}
+	}
+}
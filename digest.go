@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// pageDigest is the catalog entry --summary records for a page: its
+// metadata, first paragraph, and heading outline.
+type pageDigest struct {
+	Title    string                     `json:"title"`
+	Source   string                     `json:"source"`
+	Exported *time.Time                 `json:"exported,omitempty"`
+	PageID   string                     `json:"pageId,omitempty"`
+	Space    string                     `json:"space,omitempty"`
+	Summary  string                     `json:"summary,omitempty"`
+	Headings []converter.OutlineHeading `json:"headings,omitempty"`
+}
+
+// readPageDigest builds the digest of an export without converting it.
+func readPageDigest(inputPath string) (pageDigest, error) {
+	html, err := converter.ExtractHTMLFromMIME(inputPath)
+	if err != nil {
+		return pageDigest{}, err
+	}
+	meta, _ := converter.ReadExportMetadata(inputPath)
+	info := converter.ExtractPageInfo(html)
+	outline := converter.ExtractOutline(html)
+
+	d := pageDigest{
+		Title:    pageTitle(inputPath, meta),
+		Source:   inputPath,
+		PageID:   info.PageID,
+		Space:    info.SpaceKey,
+		Summary:  outline.Lead,
+		Headings: outline.Headings,
+	}
+	if !meta.Date.IsZero() {
+		d.Exported = &meta.Date
+	}
+	return d, nil
+}
+
+// summaryInputs returns the exports to summarize: the Confluence exports in
+// --dir, or the files named on the command line.
+func summaryInputs(cfg *config) ([]string, error) {
+	if cfg.dirMode == "" {
+		if len(cfg.args) == 0 {
+			return nil, errors.New("--summary needs input files or --dir")
+		}
+		return cfg.args, nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(cfg.dirMode, "*.doc"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to glob directory: %w", err)
+	}
+	var inputs []string
+	for _, match := range matches {
+		if ok, err := converter.IsConfluenceMIME(match); err == nil && ok {
+			inputs = append(inputs, match)
+		} else if cfg.verbose {
+			fmt.Printf("Skipping (not Confluence MIME): %s\n", match)
+		}
+	}
+	return inputs, nil
+}
+
+// runSummary writes the --summary digest of the input pages instead of
+// converting them. The digest is JSON when the output file name ends in
+// .json and Markdown otherwise.
+func runSummary(cfg *config) error {
+	inputs, err := summaryInputs(cfg)
+	if err != nil {
+		return err
+	}
+
+	digests := make([]pageDigest, 0, len(inputs))
+	for _, inputPath := range inputs {
+		d, err := readPageDigest(inputPath)
+		if err != nil {
+			fmt.Fprintf(cfg.messages(), "Warning: skipped %s: %v\n", inputPath, err)
+			continue
+		}
+		digests = append(digests, d)
+	}
+
+	if cfg.dryRun {
+		fmt.Printf("[dry-run] Would write summary of %d page(s) to %s\n", len(digests), cfg.summaryPath)
+		return nil
+	}
+
+	var data []byte
+	if strings.EqualFold(filepath.Ext(cfg.summaryPath), ".json") {
+		data, err = json.MarshalIndent(digests, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode summary: %w", err)
+		}
+		data = append(data, '\n')
+	} else {
+		data = []byte(renderDigestMarkdown(digests))
+	}
+	if err := os.WriteFile(cfg.summaryPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	fmt.Printf("Wrote summary of %d page(s) to %s\n", len(digests), cfg.summaryPath)
+	return nil
+}
+
+// renderDigestMarkdown renders digests as a Markdown catalog with one
+// section per page. Heading outlines are nested lists, indented relative to
+// the page's top heading level.
+func renderDigestMarkdown(digests []pageDigest) string {
+	var b strings.Builder
+	b.WriteString("# Page summary\n")
+	for _, d := range digests {
+		fmt.Fprintf(&b, "\n## %s\n\n", d.Title)
+		fmt.Fprintf(&b, "- Source: `%s`\n", d.Source)
+		if d.PageID != "" {
+			fmt.Fprintf(&b, "- Page ID: %s\n", d.PageID)
+		}
+		if d.Space != "" {
+			fmt.Fprintf(&b, "- Space: %s\n", d.Space)
+		}
+		if d.Exported != nil {
+			fmt.Fprintf(&b, "- Exported: %s\n", d.Exported.Format("2006-01-02"))
+		}
+		if d.Summary != "" {
+			fmt.Fprintf(&b, "\n%s\n", d.Summary)
+		}
+		if len(d.Headings) == 0 {
+			continue
+		}
+
+		top := d.Headings[0].Level
+		for _, h := range d.Headings {
+			top = min(top, h.Level)
+		}
+		fmt.Fprintf(&b, "\nOutline:\n\n")
+		for _, h := range d.Headings {
+			fmt.Fprintf(&b, "%s- %s\n", strings.Repeat("  ", h.Level-top), h.Text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestRenderDigestMarkdown(t *testing.T) {
+	exported := time.Date(2026, 1, 7, 1, 29, 0, 0, time.UTC)
+	digests := []pageDigest{
+		{
+			Title:    "Runbook",
+			Source:   "docs/Runbook.doc",
+			Exported: &exported,
+			PageID:   "42",
+			Space:    "OPS",
+			Summary:  "How to restart the service.",
+			Headings: []converter.OutlineHeading{{Level: 2, Text: "Steps"}, {Level: 3, Text: "Rollback"}, {Level: 2, Text: "Contacts"}},
+		},
+		{Title: "Empty", Source: "docs/Empty.doc"},
+	}
+
+	want := "# Page summary\n" +
+		"\n## Runbook\n\n" +
+		"- Source: `docs/Runbook.doc`\n- Page ID: 42\n- Space: OPS\n- Exported: 2026-01-07\n" +
+		"\nHow to restart the service.\n" +
+		"\nOutline:\n\n- Steps\n  - Rollback\n- Contacts\n" +
+		"\n## Empty\n\n" +
+		"- Source: `docs/Empty.doc`\n"
+	if got := renderDigestMarkdown(digests); got != want {
+		t.Errorf("renderDigestMarkdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRunSummary(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTestConfluenceMIME(t, tmpDir, "Runbook.doc",
+		`<html><head><meta name="ajs-page-id" content="4242"></head><body><h1>Runbook</h1><p>Restart steps.</p></body></html>`)
+	createPlainTextFile(t, tmpDir, "notes.doc", "not an export")
+
+	summaryPath := filepath.Join(t.TempDir(), "catalog.json")
+	cfg, err := parseFlags([]string{"--dir", tmpDir, "--summary", summaryPath}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("parseFlags failed: %v", err)
+	}
+	if code := run(cfg); code != 0 {
+		t.Fatalf("run() = %d, want 0", code)
+	}
+
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("expected summary to be written: %v", err)
+	}
+	var digests []pageDigest
+	if err := json.Unmarshal(data, &digests); err != nil {
+		t.Fatalf("summary is not valid JSON: %v", err)
+	}
+	if len(digests) != 1 {
+		t.Fatalf("expected 1 page in summary, got %d", len(digests))
+	}
+	d := digests[0]
+	if d.Title != "Runbook" || d.PageID != "4242" || d.Summary != "Restart steps." {
+		t.Errorf("unexpected digest: %+v", d)
+	}
+	if want := []converter.OutlineHeading{{Level: 1, Text: "Runbook"}}; !reflect.DeepEqual(d.Headings, want) {
+		t.Errorf("Headings = %+v, want %+v", d.Headings, want)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Runbook.md")); !os.IsNotExist(err) {
+		t.Error("--summary should not convert pages")
+	}
+}
+
+func TestRunSummary_Markdown(t *testing.T) {
+	tmpDir := t.TempDir()
+	input := createTestConfluenceMIME(t, tmpDir, "Page.doc", `<html><body><p>Lead text.</p></body></html>`)
+	summaryPath := filepath.Join(tmpDir, "catalog.md")
+
+	if err := runSummary(&config{args: []string{input}, summaryPath: summaryPath}); err != nil {
+		t.Fatalf("runSummary failed: %v", err)
+	}
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("expected summary to be written: %v", err)
+	}
+	if !strings.Contains(string(data), "## Page\n") || !strings.Contains(string(data), "Lead text.") {
+		t.Errorf("unexpected Markdown summary:\n%s", data)
+	}
+
+	if err := runSummary(&config{summaryPath: summaryPath}); err == nil {
+		t.Error("expected error without inputs")
+	}
+}
@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aqueeb/confluence2md/internal/confluence"
+)
+
+// fetchTimeout bounds each Confluence API request.
+const fetchTimeout = 60 * time.Second
+
+// fetchBlogDir is the subdirectory of the output directory the blog posts
+// of a space are fetched into.
+const fetchBlogDir = "blog"
+
+// fetchSource is what --url and --space fetch: one page, or every page of
+// a space.
+type fetchSource struct {
+	baseURL  string
+	pageID   string
+	spaceKey string
+	// sync fetches and converts only what changed since the last sync
+	sync bool
+}
+
+// newFetchSource parses the --url and --space flags. Without a space the
+// URL must be a page URL carrying the page ID.
+func newFetchSource(rawURL, spaceKey string) (*fetchSource, error) {
+	if spaceKey != "" {
+		baseURL, err := confluence.SiteURL(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		return &fetchSource{baseURL: baseURL, spaceKey: spaceKey}, nil
+	}
+	baseURL, pageID, err := confluence.ParsePageURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return &fetchSource{baseURL: baseURL, pageID: pageID}, nil
+}
+
+// fetchExports downloads the pages of src through the Confluence REST API
+// and saves each to dir as a MIME export, named after the page title, for
+// directory mode to convert. The blog posts of a space go to its
+// fetchBlogDir. The pages of a space are recorded in the sync
+// state of dir, so that a fetch that was interrupted resumes where it
+// stopped: pages whose export is already there at their current version
+// are not downloaded again. In a dry run the pages are listed instead.
+func fetchExports(src *fetchSource, dir string, cfg *config) error {
+	client, err := newFetchClient(src, cfg.credentials)
+	if err != nil {
+		return err
+	}
+	if !cfg.dryRun {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	ctx := context.Background()
+	if src.spaceKey != "" {
+		state, err := loadSyncState(dir, src)
+		if err != nil {
+			return err
+		}
+		current, err := listSpace(ctx, client, src.spaceKey)
+		if err != nil {
+			return err
+		}
+		current = withoutRestricted(current, cfg)
+		if _, err := fetchChanged(ctx, client, current, dir, state, cfg); err != nil || cfg.dryRun {
+			return err
+		}
+		return state.save(dir)
+	}
+
+	page, err := fetchPage(ctx, client, src.pageID, cfg)
+	if err != nil {
+		return fetchError(err)
+	}
+	if cfg.filter.skipRestricted && page.Restrictions.Restricted() {
+		fmt.Printf("Skipping (restricted): %s\n", page.Title)
+		return nil
+	}
+	path := filepath.Join(dir, fetchFileName(page, make(map[string]bool)))
+	if cfg.dryRun {
+		fmt.Printf("[dry-run] Would fetch: %s -> %s\n", page.Title, path)
+		return nil
+	}
+	if err := writeFetchedExport(path, page); err != nil {
+		return err
+	}
+	if cfg.verbose {
+		fmt.Printf("Fetched: %s -> %s\n", page.Title, path)
+	}
+	return nil
+}
+
+// fetchPage fetches the page with the given ID, with its comments and
+// version history when --comments and --version-history are set.
+func fetchPage(ctx context.Context, client *confluence.Client, id string, cfg *config) (*confluence.Page, error) {
+	page, err := client.Page(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.fetchComments {
+		if page.Comments, err = client.Comments(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.fetchVersions {
+		if page.Versions, err = client.Versions(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// withoutRestricted drops the pages with view restrictions when
+// --skip-restricted is set, so that they are never downloaded.
+func withoutRestricted(pages []*confluence.Page, cfg *config) []*confluence.Page {
+	if !cfg.filter.skipRestricted {
+		return pages
+	}
+	var kept []*confluence.Page
+	for _, page := range pages {
+		if !page.Restrictions.Restricted() {
+			kept = append(kept, page)
+		} else if cfg.verbose {
+			fmt.Printf("Skipping (restricted): %s\n", page.Title)
+		}
+	}
+	return kept
+}
+
+// newFetchClient returns a client for the site of src, authenticated with
+// creds.
+func newFetchClient(src *fetchSource, creds credentials) (*confluence.Client, error) {
+	client := &confluence.Client{
+		BaseURL:    src.baseURL,
+		HTTPClient: &http.Client{Timeout: fetchTimeout},
+	}
+	if err := creds.apply(client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// fetchError adds a hint at the credentials to authentication failures.
+func fetchError(err error) error {
+	var apiErr *confluence.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%w (check the credentials, such as %s and %s, or the OAuth client)", err, confluenceUserEnv, confluenceTokenEnv)
+	}
+	return err
+}
+
+// fetchFileName returns the export file name for a page: its title made
+// safe for the file system, with the page ID added when another page of
+// the run already took the name. Blog posts go to fetchBlogDir, named
+// after their publication date and title.
+func fetchFileName(page *confluence.Page, used map[string]bool) string {
+	name := treeDirName(page.Title)
+	if name == "" {
+		name = "page-" + page.ID
+	}
+	if page.Type == confluence.TypeBlogPost {
+		date := page.Created
+		if date.IsZero() {
+			date = page.Modified
+		}
+		name = fetchBlogDir + "/" + date.Format("2006-01-02") + "-" + name
+	}
+	if used[strings.ToLower(name)] {
+		name += "-" + page.ID
+	}
+	used[strings.ToLower(name)] = true
+	return name + ".doc"
+}
+
+// writeFetchedExport writes page to path as a MIME export.
+func writeFetchedExport(path string, page *confluence.Page) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	err = confluence.WriteExport(f, page)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write export: %w", closeErr)
+	}
+	return err
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aqueeb/confluence2md/converter"
+	"github.com/aqueeb/confluence2md/internal/confluence"
+)
+
+func TestParseFlags_URL(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantErr   bool
+		wantDir   string
+		wantFetch fetchSource
+	}{
+		{
+			name:      "page URL",
+			args:      []string{"--url", "https://example.atlassian.net/wiki/spaces/ENG/pages/123/Title"},
+			wantDir:   ".",
+			wantFetch: fetchSource{baseURL: "https://example.atlassian.net/wiki", pageID: "123"},
+		},
+		{
+			name:      "space into output directory",
+			args:      []string{"--url", "https://example.atlassian.net/wiki", "--space", "ENG", "-o", "docs"},
+			wantDir:   "docs",
+			wantFetch: fetchSource{baseURL: "https://example.atlassian.net/wiki", spaceKey: "ENG"},
+		},
+		{name: "page URL without ID", args: []string{"--url", "https://example.atlassian.net/wiki/display/ENG/Title"}, wantErr: true},
+		{name: "space without URL", args: []string{"--space", "ENG"}, wantErr: true},
+		{name: "with dir", args: []string{"--url", "https://example.atlassian.net/wiki", "--space", "ENG", "--dir", "exports"}, wantErr: true},
+		{name: "with input file", args: []string{"--url", "https://example.atlassian.net/wiki", "--space", "ENG", "page.doc"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseFlags(tt.args, &bytes.Buffer{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if cfg.fetch == nil || *cfg.fetch != tt.wantFetch {
+				t.Errorf("fetch = %+v, want %+v", cfg.fetch, tt.wantFetch)
+			}
+			if cfg.dirMode != tt.wantDir || cfg.outputPath != "" {
+				t.Errorf("dirMode = %q, outputPath = %q, want %q and none", cfg.dirMode, cfg.outputPath, tt.wantDir)
+			}
+		})
+	}
+}
+
+func TestFetchExports(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, _ := r.BasicAuth(); user != "me@example.com" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		result := `{"id": "%s", "title": "Release Notes", "space": {"key": "ENG"}, "version": {"number": 1}, "body": {"export_view": {"value": "<p>Page %s</p>"}}}`
+		post := `{"id": "3", "type": "blogpost", "title": "Release Notes", "space": {"key": "ENG"}, "version": {"number": 1},
+			"history": {"createdDate": "2026-02-03T09:00:00.000Z"}, "body": {"export_view": {"value": "<p>Post</p>"}}}`
+		switch id := strings.TrimPrefix(r.URL.Path, "/rest/api/content/"); {
+		case id == "3":
+			fmt.Fprint(w, post)
+		case id != r.URL.Path:
+			fmt.Fprintf(w, result, id, id)
+		case r.URL.Query().Get("type") == confluence.TypeBlogPost:
+			fmt.Fprintf(w, `{"results": [%s]}`, post)
+		default:
+			fmt.Fprintf(w, `{"results": [`+result+`, `+result+`]}`, "1", "1", "2", "2")
+		}
+	}))
+	defer server.Close()
+	cfg := &config{credentials: credentials{User: "me@example.com", Token: "secret"}}
+
+	dir := filepath.Join(t.TempDir(), "docs")
+	src := &fetchSource{baseURL: server.URL, spaceKey: "ENG"}
+	if err := fetchExports(src, dir, cfg); err != nil {
+		t.Fatalf("fetchExports() error = %v", err)
+	}
+	for _, name := range []string{"Release-Notes.doc", "Release-Notes-2.doc", "blog/2026-02-03-Release-Notes.doc"} {
+		path := filepath.Join(dir, name)
+		if ok, err := converter.IsConfluenceMIME(path); err != nil || !ok {
+			t.Errorf("%s: IsConfluenceMIME() = %v, %v, want a Confluence export", name, ok, err)
+		}
+	}
+	html, err := converter.ExtractHTMLFromMIME(filepath.Join(dir, "blog", "2026-02-03-Release-Notes.doc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := converter.ExtractPageInfo(html); !info.IsBlogPost() || len(info.BlogFrontMatter()) != 1 {
+		t.Errorf("blog post export info = %+v, want a blog post with its publication date", info)
+	}
+
+	cfg.credentials.Token = "wrong"
+	err = fetchExports(src, dir, cfg)
+	if err == nil || !strings.Contains(err.Error(), confluenceTokenEnv) {
+		t.Errorf("fetchExports() with a bad token error = %v, want a hint at %s", err, confluenceTokenEnv)
+	}
+
+	// Without a token one is asked for on the terminal
+	cfg.credentials.Token = ""
+	defer func(prompt func(string) (string, error)) { promptSecret = prompt }(promptSecret)
+	promptSecret = func(string) (string, error) { return "secret", nil }
+	if err := fetchExports(src, dir, cfg); err != nil {
+		t.Errorf("fetchExports() with a prompted token error = %v", err)
+	}
+}
+
+func TestFetchExports_Resume(t *testing.T) {
+	var mu sync.Mutex
+	fetched := make(map[string]int)
+	failing := "3"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		id := strings.TrimPrefix(r.URL.Path, "/rest/api/content/")
+		if id == r.URL.Path {
+			var results []string
+			for i := 1; i <= 4 && r.URL.Query().Get("type") == confluence.TypePage; i++ {
+				results = append(results, fmt.Sprintf(`{"id": "%d", "title": "Page %d", "version": {"number": 1}}`, i, i))
+			}
+			fmt.Fprintf(w, `{"results": [%s]}`, strings.Join(results, ", "))
+			return
+		}
+		if id == failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fetched[id]++
+		fmt.Fprintf(w, `{"id": %q, "title": "Page %s", "version": {"number": 1}, "body": {"export_view": {"value": "<p>Page %s</p>"}}}`, id, id, id)
+	}))
+	defer server.Close()
+	creds := credentials{Token: "secret"}
+
+	dir := t.TempDir()
+	src := &fetchSource{baseURL: server.URL, spaceKey: "ENG"}
+	if err := fetchExports(src, dir, &config{fetchJobs: 1, credentials: creds}); err == nil {
+		t.Fatal("fetchExports() succeeded despite a failing page")
+	}
+	if fetched["1"] != 1 || fetched["2"] != 1 {
+		t.Fatalf("pages fetched before the failure: %v, want 1 and 2", fetched)
+	}
+
+	// The rerun fetches only the pages the first run did not save
+	failing = ""
+	if err := fetchExports(src, dir, &config{fetchJobs: 3, credentials: creds}); err != nil {
+		t.Fatalf("fetchExports() rerun error = %v", err)
+	}
+	if want := map[string]int{"1": 1, "2": 1, "3": 1, "4": 1}; !maps.Equal(fetched, want) {
+		t.Errorf("pages fetched = %v, want %v", fetched, want)
+	}
+	for i := 1; i <= 4; i++ {
+		if _, err := os.Stat(filepath.Join(dir, fmt.Sprintf("Page-%d.doc", i))); err != nil {
+			t.Errorf("export of page %d missing: %v", i, err)
+		}
+	}
+}
+
+func TestParseFlags_FetchJobs(t *testing.T) {
+	cfg, err := parseFlags([]string{"--url", "https://example.atlassian.net/wiki", "--space", "ENG", "--fetch-jobs", "4"}, &bytes.Buffer{})
+	if err != nil || cfg.fetchJobs != 4 {
+		t.Fatalf("parseFlags() = %v, want --fetch-jobs 4", err)
+	}
+	for _, args := range [][]string{
+		{"--url", "https://example.atlassian.net/wiki", "--space", "ENG", "--fetch-jobs", "0"},
+		{"--fetch-jobs", "4", "page.doc"},
+		{"--comments", "page.doc"},
+		{"--version-history", "page.doc"},
+	} {
+		if _, err := parseFlags(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("parseFlags(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestFetchExports_DryRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "7", "title": "Runbook"}`)
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "docs")
+	src := &fetchSource{baseURL: server.URL, pageID: "7"}
+	if err := fetchExports(src, dir, &config{dryRun: true, credentials: credentials{Token: "secret"}}); err != nil {
+		t.Fatalf("fetchExports() error = %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("dry run created %s", dir)
+	}
+}
+
+func TestFetchExports_Session(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("JSESSIONID")
+		if err != nil || cookie.Value != "abc" || r.Header.Get("X-Proxy-Auth") != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"id": "7", "title": "Runbook", "version": {"number": 1}, "body": {"export_view": {"value": "<p>Steps</p>"}}}`)
+	}))
+	defer server.Close()
+	defer func(prompt func(string) (string, error)) { promptSecret = prompt }(promptSecret)
+	promptSecret = func(string) (string, error) {
+		t.Error("asked for a token despite the session")
+		return "", errNoTerminal
+	}
+
+	tmpDir := t.TempDir()
+	cookieJar := filepath.Join(tmpDir, "cookies.txt")
+	headerFile := filepath.Join(tmpDir, "headers.txt")
+	u, _ := url.Parse(server.URL)
+	if err := os.WriteFile(cookieJar, []byte("# Netscape HTTP Cookie File\n"+u.Hostname()+"\tFALSE\t/\tFALSE\t0\tJSESSIONID\tabc\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(headerFile, []byte("X-Proxy-Auth: s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(tmpDir, "docs")
+	src := &fetchSource{baseURL: server.URL, pageID: "7"}
+	if err := fetchExports(src, dir, &config{credentials: credentials{CookieJar: cookieJar, HeaderFile: headerFile}}); err != nil {
+		t.Fatalf("fetchExports() error = %v", err)
+	}
+	if ok, err := converter.IsConfluenceMIME(filepath.Join(dir, "Runbook.doc")); err != nil || !ok {
+		t.Errorf("IsConfluenceMIME() = %v, %v, want a Confluence export", ok, err)
+	}
+
+	if err := fetchExports(src, dir, &config{credentials: credentials{HeaderFile: headerFile}}); err == nil {
+		t.Error("fetchExports() without the session cookie succeeded, want an error")
+	}
+}
+
+func TestFetchFileName(t *testing.T) {
+	used := make(map[string]bool)
+	published := time.Date(2026, 2, 3, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		id, title   string
+		contentType string
+		want        string
+	}{
+		{"1", "Setup: Linux / macOS", confluence.TypePage, "Setup--Linux---macOS.doc"},
+		{"2", "setup: linux / macos", confluence.TypePage, "setup--linux---macos-2.doc"},
+		{"3", "..", confluence.TypePage, "page-3.doc"},
+		{"4", "Setup: Linux / macOS", confluence.TypeBlogPost, "blog/2026-02-03-Setup--Linux---macOS.doc"},
+		{"5", "Setup: Linux / macOS", confluence.TypeBlogPost, "blog/2026-02-03-Setup--Linux---macOS-5.doc"},
+	}
+	for _, tt := range tests {
+		page := &confluence.Page{ID: tt.id, Title: tt.title, Type: tt.contentType, Created: published}
+		if got := fetchFileName(page, used); got != tt.want {
+			t.Errorf("fetchFileName(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestFetchExports_Restricted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages := map[string]string{
+			"1": `{"id": "1", "title": "Salaries", "version": {"number": 1}, "body": {"export_view": {"value": "<p>Salaries</p>"}},
+				"ancestors": [{"restrictions": {"read": {"restrictions": {"group": {"results": [{"name": "hr"}]}}}}}]}`,
+			"2": `{"id": "2", "title": "Handbook", "version": {"number": 1}, "body": {"export_view": {"value": "<p>Handbook</p>"}}}`,
+		}
+		if id := strings.TrimPrefix(r.URL.Path, "/rest/api/content/"); id != r.URL.Path {
+			fmt.Fprint(w, pages[id])
+			return
+		}
+		if r.URL.Query().Get("type") == confluence.TypeBlogPost {
+			fmt.Fprint(w, `{"results": []}`)
+			return
+		}
+		fmt.Fprintf(w, `{"results": [%s, %s]}`, pages["1"], pages["2"])
+	}))
+	defer server.Close()
+	src := &fetchSource{baseURL: server.URL, spaceKey: "HR"}
+
+	dir := t.TempDir()
+	cfg := &config{credentials: credentials{Token: "secret"}, filter: pageFilter{skipRestricted: true}}
+	if err := fetchExports(src, dir, cfg); err != nil {
+		t.Fatalf("fetchExports() error = %v", err)
+	}
+	if fileExists(filepath.Join(dir, "Salaries.doc")) || !fileExists(filepath.Join(dir, "Handbook.doc")) {
+		t.Errorf("fetchExports() with --skip-restricted did not skip only the restricted page")
+	}
+
+	dir = t.TempDir()
+	cfg.filter.skipRestricted = false
+	if err := fetchExports(src, dir, cfg); err != nil {
+		t.Fatalf("fetchExports() error = %v", err)
+	}
+	cfg.options = converter.Options{To: converter.FormatMarkdown}
+	cfg.sourceLink = sourceLinkNone
+	job, err := prepareFile(filepath.Join(dir, "Salaries.doc"), filepath.Join(dir, "Salaries.md"), cfg)
+	if err != nil {
+		t.Fatalf("prepareFile() error = %v", err)
+	}
+	want := []converter.FrontMatterField{{Key: "restricted", Value: true}, {Key: "restricted_groups", Value: []string{"hr"}}}
+	if !reflect.DeepEqual(job.opts.FrontMatter, want) {
+		t.Errorf("front matter = %v, want %v", job.opts.FrontMatter, want)
+	}
+}
+
+func TestFetchExports_CommentsAndVersions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/content/7/child/comment":
+			fmt.Fprint(w, `{"results": [{"id": "70", "history": {"createdBy": {"displayName": "Jane Doe"}}, "body": {"export_view": {"value": "<p>Needs a rollback step</p>"}}}]}`)
+		case "/rest/api/content/7/version":
+			fmt.Fprint(w, `{"results": [{"number": 1, "by": {"displayName": "Jane Doe"}, "message": "Initial draft"}]}`)
+		default:
+			fmt.Fprint(w, `{"id": "7", "title": "Runbook", "body": {"export_view": {"value": "<p>Deploy</p>"}}}`)
+		}
+	}))
+	defer server.Close()
+	src := &fetchSource{baseURL: server.URL, pageID: "7"}
+
+	for _, fetchAll := range []bool{false, true} {
+		dir := t.TempDir()
+		cfg := &config{fetchComments: fetchAll, fetchVersions: fetchAll, credentials: credentials{Token: "secret"}}
+		if err := fetchExports(src, dir, cfg); err != nil {
+			t.Fatalf("fetchExports() error = %v", err)
+		}
+		html, err := converter.ExtractHTMLFromMIME(filepath.Join(dir, "Runbook.doc"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(html, "<strong>Jane Doe</strong></p><p>Needs a rollback step</p>"); got != fetchAll {
+			t.Errorf("export with --comments %v contains the comment = %v: %s", fetchAll, got, html)
+		}
+		if got := strings.Contains(html, "<td>Jane Doe</td><td>Initial draft</td>"); got != fetchAll {
+			t.Errorf("export with --version-history %v contains the version = %v: %s", fetchAll, got, html)
+		}
+	}
+}
+
+func TestConvertDirectory_FetchedBlogPosts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake pandoc scripts are not executable on Windows")
+	}
+	binDir := t.TempDir()
+	script := "#!/bin/sh\nsed -e 's#<[^>]*>##g'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "pandoc"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	post := &confluence.Page{ID: "3", Type: confluence.TypeBlogPost, Title: "Launch", Created: time.Date(2026, 2, 3, 9, 0, 0, 0, time.UTC), HTML: "<p>We launched</p>"}
+	if err := writeFetchedExport(filepath.Join(dir, fetchFileName(post, make(map[string]bool))), post); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config{
+		fetch:      &fetchSource{baseURL: "https://example.atlassian.net/wiki", spaceKey: "ENG"},
+		jobs:       1,
+		sourceLink: sourceLinkNone,
+		redactions: newRedactionLog(),
+		options:    converter.Options{Engine: converter.EngineSystem, To: converter.FormatMarkdown},
+	}
+	if err := convertDirectory(dir, cfg); err != nil {
+		t.Fatalf("convertDirectory() error = %v", err)
+	}
+	md, err := os.ReadFile(filepath.Join(dir, "blog", "2026-02-03-Launch.md"))
+	if err != nil {
+		t.Fatalf("blog post not converted: %v", err)
+	}
+	if !strings.HasPrefix(string(md), "---\ndate: 2026-02-03 09:00:00 +0000\n---\n") || !strings.Contains(string(md), "We launched") {
+		t.Errorf("blog post output = %q, want its date in front matter", md)
+	}
+}
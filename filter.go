@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// pageFilter selects the exports a directory conversion converts, so that
+// drafts, templates, restricted pages, and unrelated pages of a space
+// export can be left out.
+type pageFilter struct {
+	skipDrafts    bool
+	skipTemplates bool
+	// skipRestricted leaves out pages with view restrictions, which only
+	// exports fetched with --url record
+	skipRestricted bool
+	// labels, when set, keeps only pages carrying at least one of them
+	labels []string
+	// title, when set, keeps only pages whose title matches
+	title *regexp.Regexp
+}
+
+// newPageFilter builds a filter from the --label-filter and --title-filter
+// flag values.
+func newPageFilter(skipDrafts, skipTemplates bool, labels, title string) (pageFilter, error) {
+	f := pageFilter{skipDrafts: skipDrafts, skipTemplates: skipTemplates}
+	for _, label := range strings.Split(labels, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			f.labels = append(f.labels, label)
+		}
+	}
+	if title != "" {
+		re, err := regexp.Compile(title)
+		if err != nil {
+			return pageFilter{}, fmt.Errorf("invalid --title-filter: %w", err)
+		}
+		f.title = re
+	}
+	return f, nil
+}
+
+// active reports whether the filter can skip any page.
+func (f pageFilter) active() bool {
+	return f.skipDrafts || f.skipTemplates || f.skipRestricted || len(f.labels) > 0 || f.title != nil
+}
+
+// skipReason returns why the page with the given title and info is
+// filtered out, or "" if it should be converted.
+func (f pageFilter) skipReason(title string, info converter.PageInfo) string {
+	if f.skipDrafts && info.IsDraft() {
+		return "draft"
+	}
+	if f.skipTemplates && info.IsTemplate() {
+		return "template"
+	}
+	if f.skipRestricted && info.Restricted {
+		return "restricted"
+	}
+	if len(f.labels) > 0 && !f.hasLabel(info) {
+		return "no label matching " + strings.Join(f.labels, ", ")
+	}
+	if f.title != nil && !f.title.MatchString(title) {
+		return fmt.Sprintf("title %q does not match %s", title, f.title)
+	}
+	return ""
+}
+
+// hasLabel reports whether the page carries one of the filter's labels.
+func (f pageFilter) hasLabel(info converter.PageInfo) bool {
+	for _, label := range f.labels {
+		if info.HasLabel(label) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterExports drops the exports the filter skips, recording each one in
+// the report. Exports whose HTML cannot be read are kept, so that their
+// conversion reports the error.
+func filterExports(files []string, cfg *config, report *migrationReport) []string {
+	if !cfg.filter.active() {
+		return files
+	}
+	var kept []string
+	for _, file := range files {
+		html, err := converter.ReadPageHTML(file, cfg.mimeLimits)
+		if err != nil {
+			kept = append(kept, file)
+			continue
+		}
+		meta, _ := converter.ReadExportMetadata(file)
+		reason := cfg.filter.skipReason(pageTitle(file, meta), converter.ExtractPageInfo(html))
+		if reason == "" {
+			kept = append(kept, file)
+			continue
+		}
+		if cfg.verbose {
+			fmt.Printf("Skipping (%s): %s\n", reason, file)
+		}
+		report.addIssue(file, issueFiltered, fmt.Errorf("filtered out: %s", reason))
+		cfg.progress.warning(file, "skipped: "+reason)
+	}
+	return kept
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestPageFilter_SkipReason(t *testing.T) {
+	tests := []struct {
+		name          string
+		skipDrafts    bool
+		skipTemplates bool
+		restricted    bool
+		labels        string
+		title         string
+		pageTitle     string
+		info          converter.PageInfo
+		want          string
+	}{
+		{name: "no filters", pageTitle: "Draft", info: converter.PageInfo{Status: "draft"}, want: ""},
+		{name: "draft", skipDrafts: true, info: converter.PageInfo{Status: "draft"}, want: "draft"},
+		{name: "current page", skipDrafts: true, skipTemplates: true, info: converter.PageInfo{Status: "current", ContentType: "page"}, want: ""},
+		{name: "template", skipTemplates: true, info: converter.PageInfo{ContentType: "template"}, want: "template"},
+		{name: "restricted", restricted: true, info: converter.PageInfo{Restricted: true, ReadGroups: []string{"hr"}}, want: "restricted"},
+		{name: "unrestricted", restricted: true, info: converter.PageInfo{PageID: "42"}, want: ""},
+		{name: "matching label", labels: "runbook, howto", info: converter.PageInfo{Labels: []string{"howto"}}, want: ""},
+		{name: "missing label", labels: "runbook,howto", info: converter.PageInfo{Labels: []string{"meeting"}}, want: "no label matching runbook, howto"},
+		{name: "matching title", title: `^Runbook:`, pageTitle: "Runbook: Deploys", want: ""},
+		{name: "title mismatch", title: `^Runbook:`, pageTitle: "Meeting notes", want: `title "Meeting notes" does not match ^Runbook:`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newPageFilter(tt.skipDrafts, tt.skipTemplates, tt.labels, tt.title)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.skipRestricted = tt.restricted
+			if got := f.skipReason(tt.pageTitle, tt.info); got != tt.want {
+				t.Errorf("skipReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterExports(t *testing.T) {
+	tmpDir := t.TempDir()
+	page := createTestConfluenceMIME(t, tmpDir, "page.doc", `<meta name=3D"ajs-content-status" content=3D"current"><p>Page</p>`)
+	draft := createTestConfluenceMIME(t, tmpDir, "draft.doc", `<meta name=3D"ajs-content-status" content=3D"draft"><p>Draft</p>`)
+
+	filter, err := newPageFilter(true, false, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := newMigrationReport(tmpDir)
+	kept := filterExports([]string{page, draft}, &config{filter: filter}, report)
+
+	if want := []string{page}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("filterExports() = %v, want %v", kept, want)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].File != draft || report.Issues[0].Category != issueFiltered {
+		t.Errorf("Expected the draft to be reported as filtered, got %+v", report.Issues)
+	}
+}
+
+func TestParseFlags_PageFilters(t *testing.T) {
+	cfg, err := parseFlags([]string{"--skip-drafts", "--label-filter", "runbook", "--dir", "exports"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !cfg.filter.skipDrafts || !reflect.DeepEqual(cfg.filter.labels, []string{"runbook"}) {
+		t.Errorf("Unexpected filter: %+v", cfg.filter)
+	}
+
+	for _, args := range [][]string{
+		{"--skip-templates", "input.doc"},
+		{"--title-filter", "(", "--dir", "exports"},
+	} {
+		if _, err := parseFlags(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("parseFlags(%v) succeeded, want error", args)
+		} else if !strings.Contains(err.Error(), "--dir") && !strings.Contains(err.Error(), "--title-filter") {
+			t.Errorf("parseFlags(%v) error = %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultGitMessage is the --git-message used when none is given.
+const defaultGitMessage = "Convert Confluence exports in {{source}} with confluence2md {{version}}"
+
+// gitMessageVariables lists the variables available in --git-message.
+var gitMessageVariables = []string{"source", "version", "count", "date"}
+
+// validateGitMessage rejects --git-message templates that are empty or use
+// unknown variables.
+func validateGitMessage(message string) error {
+	if strings.TrimSpace(message) == "" {
+		return errors.New("--git-message is empty")
+	}
+	for _, m := range boilerplateVariablePattern.FindAllStringSubmatch(message, -1) {
+		if !slices.Contains(gitMessageVariables, m[1]) {
+			return fmt.Errorf("unknown variable %s in --git-message (valid: %s)", m[0], strings.Join(gitMessageVariables, ", "))
+		}
+	}
+	return nil
+}
+
+// renderGitMessage fills in the --git-message template for a batch
+// converted from dir.
+func renderGitMessage(message, dir string, count int, now time.Time) string {
+	return renderBoilerplate(message, map[string]string{
+		"source":  dir,
+		"version": version,
+		"count":   strconv.Itoa(count),
+		"date":    now.Format("2006-01-02"),
+	})
+}
+
+// gitCommitFiles stages the given files in the git repository containing
+// dir and commits them, leaving anything else already staged out of the
+// commit. It reports whether a commit was made: unchanged files produce
+// none.
+func gitCommitFiles(dir string, files []string, message string) (bool, error) {
+	if _, err := git(dir, "rev-parse", "--show-toplevel"); err != nil {
+		return false, fmt.Errorf("--git-commit: %s is not inside a git repository: %w", dir, err)
+	}
+
+	paths := make([]string, len(files))
+	for i, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return false, err
+		}
+		paths[i] = abs
+	}
+
+	if _, err := git(dir, append([]string{"add", "--"}, paths...)...); err != nil {
+		return false, fmt.Errorf("--git-commit: failed to stage files: %w", err)
+	}
+	changed, err := git(dir, append([]string{"diff", "--cached", "--name-only", "--"}, paths...)...)
+	if err != nil {
+		return false, fmt.Errorf("--git-commit: %w", err)
+	}
+	if strings.TrimSpace(changed) == "" {
+		return false, nil
+	}
+	if _, err := git(dir, append([]string{"commit", "--quiet", "-m", message, "--"}, paths...)...); err != nil {
+		return false, fmt.Errorf("--git-commit: failed to commit: %w", err)
+	}
+	return true, nil
+}
+
+// git runs a git command in dir and returns its standard output. Errors
+// include git's standard error.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// initGitRepo creates a git repository with a commit identity in a temp
+// directory, skipping the test when git is not installed.
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		if _, err := git(dir, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	return dir
+}
+
+func TestGitCommitFiles(t *testing.T) {
+	dir := initGitRepo(t)
+	page := filepath.Join(dir, "page.md")
+	other := filepath.Join(dir, "other.md")
+	for _, path := range []string{page, other} {
+		if err := os.WriteFile(path, []byte("# Page\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A file staged by the user must stay out of the commit
+	if _, err := git(dir, "add", other); err != nil {
+		t.Fatal(err)
+	}
+
+	committed, err := gitCommitFiles(dir, []string{page}, "Convert exports")
+	if err != nil {
+		t.Fatalf("gitCommitFiles failed: %v", err)
+	}
+	if !committed {
+		t.Fatal("Expected a commit")
+	}
+	files, err := git(dir, "show", "--name-only", "--format=%s", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != "Convert exports\n\npage.md\n" {
+		t.Errorf("Unexpected commit contents: %q", files)
+	}
+
+	committed, err = gitCommitFiles(dir, []string{page}, "Convert exports")
+	if err != nil {
+		t.Fatalf("gitCommitFiles failed: %v", err)
+	}
+	if committed {
+		t.Error("Expected no commit for unchanged files")
+	}
+}
+
+func TestGitCommitFiles_NotARepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+	if _, err := gitCommitFiles(dir, []string{filepath.Join(dir, "page.md")}, "msg"); err == nil || !strings.Contains(err.Error(), "not inside a git repository") {
+		t.Errorf("Expected not-a-repository error, got: %v", err)
+	}
+}
+
+func TestRenderGitMessage(t *testing.T) {
+	got := renderGitMessage("Sync {{count}} pages from {{source}} on {{date}} ({{version}})", "exports", 3, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	if want := "Sync 3 pages from exports on 2026-03-01 (" + version + ")"; got != want {
+		t.Errorf("renderGitMessage() = %q, want %q", got, want)
+	}
+}
+
+func TestParseFlags_GitCommit(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"directory mode", []string{"--git-commit", "--dir", "exports"}, ""},
+		{"custom message", []string{"--git-commit", "--git-message", "Sync {{source}}", "--dir", "exports"}, ""},
+		{"single file", []string{"--git-commit", "input.doc"}, "--git-commit requires --dir"},
+		{"unknown variable", []string{"--git-commit", "--git-message", "Sync {{branch}}", "--dir", "exports"}, "unknown variable {{branch}}"},
+		{"empty message", []string{"--git-commit", "--git-message", " ", "--dir", "exports"}, "--git-message is empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseFlags(tt.args, &bytes.Buffer{})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !cfg.gitCommit {
+					t.Error("Expected gitCommit to be set")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
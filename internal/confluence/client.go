@@ -0,0 +1,524 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package confluence fetches pages from the Confluence REST API.
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// restrictionsExpand expands the users and groups named by the view
+// restrictions of a page and of its ancestors, which the page inherits.
+const restrictionsExpand = "restrictions.read.restrictions.user,restrictions.read.restrictions.group," +
+	"ancestors.restrictions.read.restrictions.user,ancestors.restrictions.read.restrictions.group"
+
+// pageExpand is the expand parameter of page requests: the export view of
+// the body, with the space, version, creation, labels, and restrictions the
+// export metadata needs.
+const pageExpand = "body.export_view,space,version,history,metadata.labels," + restrictionsExpand
+
+// versionExpand is the expand parameter of page listings that only need to
+// know which version of each page is current, when blog posts were
+// published, and whether a page is restricted.
+const versionExpand = "space,version,history," + restrictionsExpand
+
+// The content types of a space that are listed: pages, then blog posts.
+const (
+	TypePage     = "page"
+	TypeBlogPost = "blogpost"
+)
+
+// Defaults for the Client fields left zero.
+const (
+	defaultMinInterval = 100 * time.Millisecond
+	defaultMaxRetries  = 3
+	defaultRetryDelay  = time.Second
+	defaultPageLimit   = 25
+)
+
+var (
+	// pageIDPattern captures the page ID from page URLs
+	// (viewpage.action?pageId=N or /spaces/KEY/pages/N/...).
+	pageIDPattern = regexp.MustCompile(`[?&]pageId=(\d+)|/pages/(\d+)(?:/|$)`)
+
+	// sitePathPattern matches the start of the page part of a URL path, the
+	// part after the site's context path.
+	sitePathPattern = regexp.MustCompile(`/(?:spaces|pages|display|rest|x)(?:/|$)`)
+)
+
+// Client is a Confluence REST API client. Requests are spaced at least
+// MinInterval apart, and requests the server rate limits (429) or finds
+// itself unavailable for (503) are retried after the delay the server asks
+// for, or with exponential backoff. A Client is safe for concurrent use;
+// the delay of a retry holds back the other requests too.
+type Client struct {
+	// BaseURL is the site URL, such as https://example.atlassian.net/wiki.
+	BaseURL string
+	// User is the account email for Confluence Cloud API tokens. When it
+	// is empty, Token is sent as a bearer token (a Data Center personal
+	// access token).
+	User string
+	// Token is the API token or personal access token.
+	Token string
+	// OAuth, when set, authenticates with OAuth 2.0 access tokens instead
+	// of User and Token.
+	OAuth *OAuth
+	// HTTPClient sends the requests, http.DefaultClient if nil. Its Jar
+	// can hold a session cookie (see ReadCookieJar).
+	HTTPClient *http.Client
+	// Header holds headers sent with every request, such as the ones an
+	// authenticating proxy requires (see ReadHeaders). The Accept and
+	// authentication headers of the Client take precedence.
+	Header http.Header
+	// MinInterval is the least time between requests, 100ms if zero.
+	MinInterval time.Duration
+	// MaxRetries is how often a rate-limited request is retried, 3 if
+	// zero. Negative values disable retries.
+	MaxRetries int
+	// RetryDelay is the first backoff delay when the server gives no
+	// Retry-After, 1s if zero. It doubles with each retry.
+	RetryDelay time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+
+	// token is the current OAuth access token, valid until tokenExpiry
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// Page is a Confluence page or blog post with its body in export view
+// HTML.
+type Page struct {
+	ID    string
+	Title string
+	// Type is TypePage or TypeBlogPost.
+	Type     string
+	SpaceKey string
+	// Created is when the page was created, or the blog post published.
+	Created time.Time
+	// Version is the page's version number and Modified when it was made.
+	Version  int
+	Modified time.Time
+	Labels   []string
+	// Restrictions limit who can view the page.
+	Restrictions Restrictions
+	// HTML is the body rendered for export.
+	HTML string
+	// Comments are the page's comments, when they were fetched.
+	Comments []Comment
+	// Versions are the page's version history, when it was fetched.
+	Versions []Version
+	// WebURL is the page's URL in the browser.
+	WebURL string
+}
+
+// Restrictions are the users and groups named by the view restrictions of
+// a page, its own and those it inherits from its ancestors.
+type Restrictions struct {
+	Users  []string
+	Groups []string
+}
+
+// Restricted reports whether viewing the page is restricted.
+func (r Restrictions) Restricted() bool {
+	return len(r.Users) > 0 || len(r.Groups) > 0
+}
+
+// APIError is an unsuccessful response from the REST API.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("confluence API: %s", http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("confluence API: %s: %s", http.StatusText(e.StatusCode), e.Message)
+}
+
+// content is a page as the REST API returns it.
+type content struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	Space struct {
+		Key string `json:"key"`
+	} `json:"space"`
+	Version struct {
+		Number int       `json:"number"`
+		When   time.Time `json:"when"`
+	} `json:"version"`
+	Metadata struct {
+		Labels struct {
+			Results []struct {
+				Name string `json:"name"`
+			} `json:"results"`
+		} `json:"labels"`
+	} `json:"metadata"`
+	Restrictions restrictions `json:"restrictions"`
+	Ancestors    []struct {
+		ID           string       `json:"id"`
+		Restrictions restrictions `json:"restrictions"`
+	} `json:"ancestors"`
+	History struct {
+		CreatedBy   user      `json:"createdBy"`
+		CreatedDate time.Time `json:"createdDate"`
+	} `json:"history"`
+	Extensions struct {
+		Location   string `json:"location"`
+		Resolution struct {
+			Status string `json:"status"`
+		} `json:"resolution"`
+	} `json:"extensions"`
+	Body struct {
+		ExportView struct {
+			Value string `json:"value"`
+		} `json:"export_view"`
+	} `json:"body"`
+	Links struct {
+		WebUI string `json:"webui"`
+		Base  string `json:"base"`
+	} `json:"_links"`
+}
+
+// restrictions are the restrictions of a content as the REST API returns
+// them.
+type restrictions struct {
+	Read struct {
+		Restrictions struct {
+			User struct {
+				Results []user `json:"results"`
+			} `json:"user"`
+			Group struct {
+				Results []struct {
+					Name string `json:"name"`
+				} `json:"results"`
+			} `json:"group"`
+		} `json:"restrictions"`
+	} `json:"read"`
+}
+
+// user is a user as the REST API returns it.
+type user struct {
+	Username    string `json:"username"`
+	PublicName  string `json:"publicName"`
+	DisplayName string `json:"displayName"`
+	AccountID   string `json:"accountId"`
+}
+
+// resultList is a page of results of a listing, such as the content
+// search.
+type resultList[T any] struct {
+	Results []T `json:"results"`
+	Links   struct {
+		Next string `json:"next"`
+	} `json:"_links"`
+}
+
+// Page fetches the page with the given ID.
+func (c *Client) Page(ctx context.Context, id string) (*Page, error) {
+	query := url.Values{"expand": {pageExpand}}
+	var result content
+	if err := c.get(ctx, "/rest/api/content/"+url.PathEscape(id)+"?"+query.Encode(), &result); err != nil {
+		return nil, fmt.Errorf("failed to fetch page %s: %w", id, err)
+	}
+	return c.page(result), nil
+}
+
+// SpacePages fetches the pages and then the blog posts of the space with
+// the given key, calling fn for each in the order the API lists them.
+// Fetching stops at the first error fn returns.
+func (c *Client) SpacePages(ctx context.Context, spaceKey string, fn func(*Page) error) error {
+	return c.listSpace(ctx, spaceKey, pageExpand, fn)
+}
+
+// SpaceVersions lists the pages of the space with the given key like
+// SpacePages, but without their bodies and labels: enough to tell which
+// pages changed since they were last fetched.
+func (c *Client) SpaceVersions(ctx context.Context, spaceKey string, fn func(*Page) error) error {
+	return c.listSpace(ctx, spaceKey, versionExpand, fn)
+}
+
+// listSpace lists the pages and blog posts of a space with the given
+// expand parameter.
+func (c *Client) listSpace(ctx context.Context, spaceKey, expand string, fn func(*Page) error) error {
+	for _, listing := range []struct{ contentType, what string }{
+		{TypePage, "pages"},
+		{TypeBlogPost, "blog posts"},
+	} {
+		query := url.Values{
+			"spaceKey": {spaceKey},
+			"type":     {listing.contentType},
+			"expand":   {expand},
+			"limit":    {strconv.Itoa(defaultPageLimit)},
+		}
+		err := list(ctx, c, "/rest/api/content?"+query.Encode(), listing.what+" of space "+spaceKey, func(result content) error {
+			if result.Type == "" {
+				result.Type = listing.contentType
+			}
+			return fn(c.page(result))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// list requests path with c and the pages of results that follow it,
+// calling fn for each result. what names the results in errors. Listing
+// stops at the first error fn returns.
+func list[T any](ctx context.Context, c *Client, path, what string, fn func(T) error) error {
+	for next := path; next != ""; {
+		var page resultList[T]
+		if err := c.get(ctx, next, &page); err != nil {
+			return fmt.Errorf("failed to list %s: %w", what, err)
+		}
+		for _, result := range page.Results {
+			if err := fn(result); err != nil {
+				return err
+			}
+		}
+		next = page.Links.Next
+	}
+	return nil
+}
+
+// page converts an API result to a Page.
+func (c *Client) page(result content) *Page {
+	p := &Page{
+		ID:       result.ID,
+		Title:    result.Title,
+		Type:     result.Type,
+		SpaceKey: result.Space.Key,
+		Created:  result.History.CreatedDate,
+		Version:  result.Version.Number,
+		Modified: result.Version.When,
+		HTML:     result.Body.ExportView.Value,
+	}
+	for _, label := range result.Metadata.Labels.Results {
+		p.Labels = append(p.Labels, label.Name)
+	}
+	p.Restrictions.add(result.Restrictions)
+	for _, ancestor := range result.Ancestors {
+		p.Restrictions.add(ancestor.Restrictions)
+	}
+	if result.Links.WebUI != "" {
+		base := result.Links.Base
+		if base == "" {
+			base = strings.TrimSuffix(c.BaseURL, "/")
+		}
+		p.WebURL = base + result.Links.WebUI
+	}
+	return p
+}
+
+// add adds the users and groups of the view restrictions of a page or
+// ancestor that are not listed yet.
+func (r *Restrictions) add(result restrictions) {
+	read := result.Read.Restrictions
+	for _, user := range read.User.Results {
+		if name := user.name(); name != "" && !slices.Contains(r.Users, name) {
+			r.Users = append(r.Users, name)
+		}
+	}
+	for _, group := range read.Group.Results {
+		if group.Name != "" && !slices.Contains(r.Groups, group.Name) {
+			r.Groups = append(r.Groups, group.Name)
+		}
+	}
+}
+
+// name returns the user's username (Data Center) or public name (Cloud),
+// whichever the API returns, falling back to the display name and account
+// ID.
+func (u user) name() string {
+	return firstNonEmpty(u.Username, u.PublicName, u.DisplayName, u.AccountID)
+}
+
+// get requests path, relative to the base URL, and decodes the JSON
+// response into v.
+func (c *Client) get(ctx context.Context, path string, v any) error {
+	target := strings.TrimSuffix(c.BaseURL, "/") + path
+	delay := c.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	retries := c.MaxRetries
+	if retries == 0 {
+		retries = defaultMaxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
+		resp, err := c.do(ctx, target)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusOK {
+			err := json.NewDecoder(resp.Body).Decode(v)
+			resp.Body.Close()
+			if err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+			return nil
+		}
+
+		apiErr := readAPIError(resp)
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+		if !retryable || attempt >= retries {
+			return apiErr
+		}
+		wait := delay << attempt
+		if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			wait = after
+		}
+		c.pause(wait)
+	}
+}
+
+// do sends an authenticated GET request.
+func (c *Client) do(ctx context.Context, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request URL: %w", err)
+	}
+	if c.Header != nil {
+		req.Header = c.Header.Clone()
+	}
+	req.Header.Set("Accept", "application/json")
+	switch {
+	case c.OAuth != nil:
+		token, err := c.accessToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case c.User != "":
+		req.SetBasicAuth(c.User, c.Token)
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return c.httpClient().Do(req)
+}
+
+// httpClient returns the client sending the requests.
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// wait blocks until MinInterval has passed since the previous request.
+func (c *Client) wait(ctx context.Context) error {
+	interval := c.MinInterval
+	if interval <= 0 {
+		interval = defaultMinInterval
+	}
+	c.mu.Lock()
+	now := time.Now()
+	next := c.last.Add(interval)
+	if next.Before(now) {
+		next = now
+	}
+	c.last = next
+	c.mu.Unlock()
+	return sleep(ctx, time.Until(next))
+}
+
+// pause holds back the requests not yet sent until d from now.
+func (c *Client) pause(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if until := time.Now().Add(d); until.After(c.last) {
+		c.last = until
+	}
+}
+
+// readAPIError reads the message of an error response and closes its body.
+func readAPIError(resp *http.Response) *APIError {
+	defer resp.Body.Close()
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	var body struct {
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) == nil {
+		apiErr.Message = body.Message
+	}
+	return apiErr
+}
+
+// retryAfter parses a Retry-After header given in seconds or as a date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ParsePageURL splits a Confluence page URL into the site URL and the page
+// ID. Page URLs without an ID, such as /display/KEY/Title, are rejected.
+func ParsePageURL(raw string) (baseURL, pageID string, err error) {
+	baseURL, err = SiteURL(raw)
+	if err != nil {
+		return "", "", err
+	}
+	m := pageIDPattern.FindStringSubmatch(raw)
+	if m == nil {
+		return "", "", fmt.Errorf("no page ID in URL %q: want a URL like %s/spaces/KEY/pages/123", raw, baseURL)
+	}
+	return baseURL, m[1] + m[2], nil
+}
+
+// SiteURL returns the site URL of a Confluence URL: the scheme, host, and
+// context path (such as /wiki), without the page part.
+func SiteURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("invalid Confluence URL %q", raw)
+	}
+	path := u.Path
+	if loc := sitePathPattern.FindStringIndex(path); loc != nil {
+		path = path[:loc[0]]
+	}
+	return u.Scheme + "://" + u.Host + strings.TrimSuffix(path, "/"), nil
+}
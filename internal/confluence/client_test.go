@@ -0,0 +1,313 @@
+package confluence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const pageJSON = `{
+	"id": "%s",
+	"title": "Page %s",
+	"space": {"key": "ENG"},
+	"version": {"number": 3, "when": "2026-01-07T01:29:00.000Z"},
+	"metadata": {"labels": {"results": [{"name": "howto"}]}},
+	"body": {"export_view": {"value": "<p>Body %s</p>"}},
+	"_links": {"webui": "/spaces/ENG/pages/%s/Page"}
+}`
+
+func newTestClient(handler http.HandlerFunc) (*Client, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	client := &Client{
+		BaseURL:     server.URL + "/wiki",
+		User:        "me@example.com",
+		Token:       "secret",
+		HTTPClient:  server.Client(),
+		MinInterval: time.Millisecond,
+		RetryDelay:  time.Millisecond,
+	}
+	return client, server
+}
+
+func TestClient_Page(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, ok := r.BasicAuth(); !ok || user != "me@example.com" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/wiki/rest/api/content/42" || r.URL.Query().Get("expand") != pageExpand {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, pageJSON, "42", "42", "42", "42")
+	})
+	defer server.Close()
+
+	page, err := client.Page(context.Background(), "42")
+	if err != nil {
+		t.Fatalf("Page() error = %v", err)
+	}
+	if page.ID != "42" || page.Title != "Page 42" || page.SpaceKey != "ENG" || page.Version != 3 {
+		t.Errorf("Page() = %+v", page)
+	}
+	if page.HTML != "<p>Body 42</p>" {
+		t.Errorf("HTML = %q", page.HTML)
+	}
+	if want := server.URL + "/wiki/spaces/ENG/pages/42/Page"; page.WebURL != want {
+		t.Errorf("WebURL = %q, want %q", page.WebURL, want)
+	}
+	if len(page.Labels) != 1 || page.Labels[0] != "howto" {
+		t.Errorf("Labels = %q", page.Labels)
+	}
+	if !page.Modified.Equal(time.Date(2026, 1, 7, 1, 29, 0, 0, time.UTC)) {
+		t.Errorf("Modified = %v", page.Modified)
+	}
+}
+
+func TestClient_PageRestrictions(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+	"id": "7",
+	"title": "Salaries",
+	"restrictions": {"read": {"restrictions": {
+		"user": {"results": [{"accountId": "5b10", "publicName": "jdoe"}]},
+		"group": {"results": [{"name": "hr"}]}
+	}}},
+	"ancestors": [
+		{"restrictions": {"read": {"restrictions": {"user": {"results": []}, "group": {"results": [{"name": "hr"}, {"name": "finance"}]}}}}},
+		{"restrictions": {"read": {"restrictions": {"user": {"results": [{"username": "admin"}]}, "group": {"results": []}}}}}
+	]
+}`)
+	})
+	defer server.Close()
+
+	page, err := client.Page(context.Background(), "7")
+	if err != nil {
+		t.Fatalf("Page() error = %v", err)
+	}
+	want := Restrictions{Users: []string{"jdoe", "admin"}, Groups: []string{"hr", "finance"}}
+	if !reflect.DeepEqual(page.Restrictions, want) || !page.Restrictions.Restricted() {
+		t.Errorf("Restrictions = %+v, want %+v", page.Restrictions, want)
+	}
+	if (Restrictions{}).Restricted() {
+		t.Error("Restricted() of no restrictions = true")
+	}
+}
+
+func TestClient_BearerToken(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, pageJSON, "1", "1", "1", "1")
+	})
+	defer server.Close()
+	client.User = ""
+
+	if _, err := client.Page(context.Background(), "1"); err != nil {
+		t.Errorf("Page() error = %v", err)
+	}
+}
+
+func TestClient_SpacePages(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("spaceKey") != "ENG" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch {
+		case r.URL.Query().Get("type") == TypeBlogPost:
+			fmt.Fprint(w, `{"results": [{"id": "4", "type": "blogpost", "title": "Launch", "version": {"number": 1},
+				"history": {"createdDate": "2026-02-03T09:00:00.000Z"}}]}`)
+			return
+		case r.URL.Query().Get("type") != TypePage:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		case r.URL.Query().Get("start") == "":
+			fmt.Fprintf(w, `{"results": [`+pageJSON+`, `+pageJSON+`], "_links": {"next": "/rest/api/content?spaceKey=ENG&type=page&start=2"}}`,
+				"1", "1", "1", "1", "2", "2", "2", "2")
+			return
+		}
+		fmt.Fprintf(w, `{"results": [`+pageJSON+`], "_links": {}}`, "3", "3", "3", "3")
+	})
+	defer server.Close()
+
+	var ids []string
+	var post *Page
+	err := client.SpacePages(context.Background(), "ENG", func(p *Page) error {
+		ids = append(ids, p.ID)
+		post = p
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SpacePages() error = %v", err)
+	}
+	if got := strings.Join(ids, ","); got != "1,2,3,4" {
+		t.Errorf("SpacePages() pages = %s, want 1,2,3 and blog post 4", got)
+	}
+	if post.Type != TypeBlogPost || !post.Created.Equal(time.Date(2026, 2, 3, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("blog post = %+v, want its type and publication date", post)
+	}
+
+	ids = nil
+	err = client.SpaceVersions(context.Background(), "ENG", func(p *Page) error {
+		ids = append(ids, fmt.Sprintf("%s@%d", p.ID, p.Version))
+		return nil
+	})
+	if got := strings.Join(ids, ","); err != nil || got != "1@3,2@3,3@3,4@1" {
+		t.Errorf("SpaceVersions() = %s, %v, want 1@3,2@3,3@3,4@1", got, err)
+	}
+
+	stop := errors.New("stop")
+	err = client.SpacePages(context.Background(), "ENG", func(*Page) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Errorf("SpacePages() error = %v, want the callback's error", err)
+	}
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		maxRetries int
+		wantErr    bool
+		wantCalls  int
+	}{
+		{name: "retried until success", failures: 2, wantCalls: 3},
+		{name: "retries exhausted", failures: 5, maxRetries: 2, wantErr: true, wantCalls: 3},
+		{name: "retries disabled", failures: 1, maxRetries: -1, wantErr: true, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls <= tt.failures {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusTooManyRequests)
+					fmt.Fprint(w, `{"message": "Rate limit exceeded"}`)
+					return
+				}
+				fmt.Fprintf(w, pageJSON, "1", "1", "1", "1")
+			})
+			defer server.Close()
+			client.MaxRetries = tt.maxRetries
+
+			_, err := client.Page(context.Background(), "1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Page() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var apiErr *APIError
+			if tt.wantErr && (!errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Message != "Rate limit exceeded") {
+				t.Errorf("Page() error = %#v, want the 429 APIError", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("server called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestClient_MinInterval(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, pageJSON, "1", "1", "1", "1")
+	})
+	defer server.Close()
+	client.MinInterval = 20 * time.Millisecond
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := client.Page(context.Background(), "1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("3 requests took %v, want at least 40ms", elapsed)
+	}
+}
+
+func TestClient_PauseHoldsBackRequests(t *testing.T) {
+	client := &Client{MinInterval: time.Millisecond}
+	client.pause(30 * time.Millisecond)
+
+	start := time.Now()
+	if err := client.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("request after a 30ms pause waited %v", elapsed)
+	}
+}
+
+func TestClient_NotFound(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "No content found with id: 7"}`)
+	})
+	defer server.Close()
+
+	_, err := client.Page(context.Background(), "7")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Page() error = %v, want a 404 APIError", err)
+	}
+	if !strings.Contains(err.Error(), "No content found with id: 7") {
+		t.Errorf("error %q lacks the server's message", err)
+	}
+}
+
+func TestParsePageURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		wantBase string
+		wantID   string
+		wantErr  bool
+	}{
+		{"https://example.atlassian.net/wiki/spaces/ENG/pages/123/Some+Page", "https://example.atlassian.net/wiki", "123", false},
+		{"https://wiki.example.com/pages/viewpage.action?pageId=456", "https://wiki.example.com", "456", false},
+		{"https://wiki.example.com/confluence/pages/viewpage.action?pageId=789", "https://wiki.example.com/confluence", "789", false},
+		{"https://wiki.example.com/display/ENG/Some+Page", "", "", true},
+		{"example.atlassian.net/wiki/spaces/ENG/pages/123", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			base, id, err := ParsePageURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePageURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if base != tt.wantBase || id != tt.wantID {
+				t.Errorf("ParsePageURL() = %q, %q, want %q, %q", base, id, tt.wantBase, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestSiteURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.atlassian.net/wiki", "https://example.atlassian.net/wiki"},
+		{"https://example.atlassian.net/wiki/", "https://example.atlassian.net/wiki"},
+		{"https://example.atlassian.net/wiki/spaces/ENG/overview", "https://example.atlassian.net/wiki"},
+		{"https://wiki.example.com/display/ENG", "https://wiki.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := SiteURL(tt.url)
+			if err != nil || got != tt.want {
+				t.Errorf("SiteURL() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
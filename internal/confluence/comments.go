@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commentExpand is the expand parameter of comment requests: the export view
+// of the body, the author and date, where the comment is and whether it was
+// resolved, and the comments it replies to.
+const commentExpand = "body.export_view,history,extensions.location,extensions.resolution,ancestors"
+
+// Comment is a comment on a page.
+type Comment struct {
+	ID string
+	// ParentID is the ID of the comment this one replies to, or empty.
+	ParentID string
+	Author   string
+	Created  time.Time
+	// Inline reports whether the comment is on a selection of the page's
+	// text rather than at its foot, and Resolved whether it was resolved.
+	Inline   bool
+	Resolved bool
+	// HTML is the body rendered for export.
+	HTML string
+}
+
+// Comments fetches the comments on the page with the given ID, replies
+// included, in the order the API lists them.
+func (c *Client) Comments(ctx context.Context, pageID string) ([]Comment, error) {
+	query := url.Values{
+		"expand": {commentExpand},
+		"depth":  {"all"},
+		"limit":  {strconv.Itoa(defaultPageLimit)},
+	}
+	path := "/rest/api/content/" + url.PathEscape(pageID) + "/child/comment?" + query.Encode()
+	var comments []Comment
+	err := list(ctx, c, path, "comments of page "+pageID, func(result content) error {
+		author := result.History.CreatedBy
+		comment := Comment{
+			ID:       result.ID,
+			Author:   firstNonEmpty(author.DisplayName, author.name()),
+			Created:  result.History.CreatedDate,
+			Inline:   result.Extensions.Location == "inline",
+			Resolved: result.Extensions.Resolution.Status == "resolved",
+			HTML:     result.Body.ExportView.Value,
+		}
+		if n := len(result.Ancestors); n > 0 {
+			comment.ParentID = result.Ancestors[n-1].ID
+		}
+		comments = append(comments, comment)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// commentsHTML renders comments as a "Comments" section for the end of a
+// page's export: each comment after a line with its author and date, and
+// its replies quoted below it. It returns "" when there are no comments.
+func commentsHTML(comments []Comment) string {
+	if len(comments) == 0 {
+		return ""
+	}
+	listed := make(map[string]bool, len(comments))
+	for _, comment := range comments {
+		listed[comment.ID] = true
+	}
+	replies := make(map[string][]Comment)
+	var threads []Comment
+	for _, comment := range comments {
+		if listed[comment.ParentID] {
+			replies[comment.ParentID] = append(replies[comment.ParentID], comment)
+		} else {
+			threads = append(threads, comment)
+		}
+	}
+
+	var b strings.Builder
+	var write func(Comment)
+	write = func(comment Comment) {
+		author := comment.Author
+		if author == "" {
+			author = "Anonymous"
+		}
+		fmt.Fprintf(&b, "<p><strong>%s</strong>", html.EscapeString(author))
+		if !comment.Created.IsZero() {
+			fmt.Fprintf(&b, ", %s", comment.Created.UTC().Format("2006-01-02 15:04 MST"))
+		}
+		var notes []string
+		if comment.Inline {
+			notes = append(notes, "inline comment")
+		}
+		if comment.Resolved {
+			notes = append(notes, "resolved")
+		}
+		if len(notes) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(notes, ", "))
+		}
+		b.WriteString("</p>")
+		b.WriteString(comment.HTML)
+		if thread := replies[comment.ID]; len(thread) > 0 {
+			b.WriteString("<blockquote>")
+			for _, reply := range thread {
+				write(reply)
+			}
+			b.WriteString("</blockquote>")
+		}
+	}
+	b.WriteString("<h2>Comments</h2>")
+	for _, comment := range threads {
+		write(comment)
+	}
+	return b.String()
+}
+
+// firstNonEmpty returns the first of values that is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClient_Comments(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wiki/rest/api/content/42/child/comment" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("start") == "" {
+			if r.URL.Query().Get("expand") != commentExpand || r.URL.Query().Get("depth") != "all" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{
+	"results": [{
+		"id": "100",
+		"history": {"createdBy": {"displayName": "Jane Doe", "publicName": "jdoe"}, "createdDate": "2026-01-07T01:29:00.000Z"},
+		"extensions": {"location": "inline", "resolution": {"status": "resolved"}},
+		"body": {"export_view": {"value": "<p>Typo here</p>"}}
+	}],
+	"_links": {"next": "/rest/api/content/42/child/comment?start=1"}
+}`)
+			return
+		}
+		fmt.Fprint(w, `{"results": [{
+	"id": "101",
+	"history": {"createdBy": {"username": "admin"}},
+	"extensions": {"location": "footer"},
+	"ancestors": [{"id": "100"}],
+	"body": {"export_view": {"value": "<p>Fixed</p>"}}
+}]}`)
+	})
+	defer server.Close()
+
+	comments, err := client.Comments(context.Background(), "42")
+	if err != nil {
+		t.Fatalf("Comments() error = %v", err)
+	}
+	want := []Comment{
+		{ID: "100", Author: "Jane Doe", Created: time.Date(2026, 1, 7, 1, 29, 0, 0, time.UTC), Inline: true, Resolved: true, HTML: "<p>Typo here</p>"},
+		{ID: "101", ParentID: "100", Author: "admin", HTML: "<p>Fixed</p>"},
+	}
+	if !reflect.DeepEqual(comments, want) {
+		t.Errorf("Comments() = %+v, want %+v", comments, want)
+	}
+}
+
+func TestCommentsHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments []Comment
+		want     string
+	}{
+		{name: "no comments", want: ""},
+		{
+			name: "reply quoted below its comment",
+			comments: []Comment{
+				{ID: "1", Author: "Jane <QA>", Created: time.Date(2026, 1, 7, 1, 29, 0, 0, time.UTC), Inline: true, Resolved: true, HTML: "<p>Typo</p>"},
+				{ID: "2", HTML: "<p>Looks good</p>"},
+				{ID: "3", ParentID: "1", Author: "admin", HTML: "<p>Fixed</p>"},
+			},
+			want: "<h2>Comments</h2>" +
+				"<p><strong>Jane &lt;QA&gt;</strong>, 2026-01-07 01:29 UTC (inline comment, resolved)</p><p>Typo</p>" +
+				"<blockquote><p><strong>admin</strong></p><p>Fixed</p></blockquote>" +
+				"<p><strong>Anonymous</strong></p><p>Looks good</p>",
+		},
+		{
+			name:     "reply to an unlisted comment",
+			comments: []Comment{{ID: "2", ParentID: "1", Author: "admin", HTML: "<p>Fixed</p>"}},
+			want:     "<h2>Comments</h2><p><strong>admin</strong></p><p>Fixed</p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commentsHTML(tt.comments); got != tt.want {
+				t.Errorf("commentsHTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
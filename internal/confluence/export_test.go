@@ -0,0 +1,56 @@
+package confluence
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestWriteExport(t *testing.T) {
+	page := &Page{
+		ID:           "42",
+		Title:        "Café & Co",
+		SpaceKey:     "ENG",
+		Modified:     time.Date(2026, 1, 7, 1, 29, 0, 0, time.UTC),
+		Labels:       []string{"howto", "draft-notes"},
+		Restrictions: Restrictions{Groups: []string{"eng-leads"}},
+		HTML:         `<h1>Café</h1><p>A long line that quoted-printable encoding has to wrap, since it is well over seventy-six characters.</p>`,
+		WebURL:       "https://example.atlassian.net/wiki/spaces/ENG/pages/42/Cafe",
+	}
+	path := filepath.Join(t.TempDir(), "page.doc")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteExport(f, page); err != nil {
+		t.Fatalf("WriteExport() error = %v", err)
+	}
+	f.Close()
+
+	if ok, err := converter.IsConfluenceMIME(path); err != nil || !ok {
+		t.Fatalf("IsConfluenceMIME() = %v, %v, want true", ok, err)
+	}
+	meta, err := converter.ReadExportMetadata(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.PageTitle() != "Café & Co" || !meta.Date.Equal(page.Modified) {
+		t.Errorf("metadata = %+v, want the page's title and date", meta)
+	}
+
+	html, err := converter.ExtractHTMLFromMIME(path)
+	if err != nil {
+		t.Fatalf("ExtractHTMLFromMIME() error = %v", err)
+	}
+	if !strings.Contains(html, page.HTML) {
+		t.Errorf("extracted HTML lacks the page body: %s", html)
+	}
+	info := converter.ExtractPageInfo(html)
+	if info.PageID != "42" || info.SpaceKey != "ENG" || !info.HasLabel("draft-notes") || !info.Restricted || len(info.ReadGroups) != 1 {
+		t.Errorf("ExtractPageInfo() = %+v", info)
+	}
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTokenURL is the token endpoint of Atlassian's OAuth 2.0
+// authorization server.
+const DefaultTokenURL = "https://auth.atlassian.com/oauth/token"
+
+// tokenExpiryMargin is how long before its expiry an access token is
+// replaced, so that it does not expire while a request is under way.
+const tokenExpiryMargin = time.Minute
+
+// OAuth holds the OAuth 2.0 client credentials a Client obtains access
+// tokens with, using the client credentials grant (Atlassian service
+// accounts). Requests then go to the API gateway URL of the site, such as
+// https://api.atlassian.com/ex/confluence/<cloud-id>/wiki.
+type OAuth struct {
+	// TokenURL is the token endpoint, DefaultTokenURL if empty.
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+}
+
+// tokenResponse is the token endpoint's answer, successful or not.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// accessToken returns the current OAuth access token, requesting a new one
+// when there is none yet or it is about to expire.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	tokenURL := c.OAuth.TokenURL
+	if tokenURL == "" {
+		tokenURL = DefaultTokenURL
+	}
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.OAuth.ClientID},
+		"client_secret": {c.OAuth.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("invalid OAuth token URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain OAuth access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result tokenResponse
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	decodeErr := json.Unmarshal(data, &result)
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: result.ErrorDescription}
+		if apiErr.Message == "" {
+			apiErr.Message = result.Error
+		}
+		return "", fmt.Errorf("failed to obtain OAuth access token: %w", apiErr)
+	}
+	if decodeErr != nil || result.AccessToken == "" {
+		return "", fmt.Errorf("failed to obtain OAuth access token: no access token in response")
+	}
+
+	c.token = result.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - tokenExpiryMargin)
+	return c.token, nil
+}
@@ -0,0 +1,65 @@
+package confluence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClient_OAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		expiresIn  int
+		secret     string
+		wantTokens int
+		wantErr    bool
+	}{
+		{name: "token reused", expiresIn: 3600, secret: "s3cret", wantTokens: 1},
+		{name: "expiring token replaced", expiresIn: 30, secret: "s3cret", wantTokens: 2},
+		{name: "wrong secret", expiresIn: 3600, secret: "wrong", wantTokens: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := 0
+			client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/oauth/token" {
+					tokens++
+					if r.PostFormValue("grant_type") != "client_credentials" || r.PostFormValue("client_id") != "app" || r.PostFormValue("client_secret") != "s3cret" {
+						w.WriteHeader(http.StatusUnauthorized)
+						fmt.Fprint(w, `{"error": "access_denied", "error_description": "Unauthorized"}`)
+						return
+					}
+					fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": %d}`, tokens, tt.expiresIn)
+					return
+				}
+				if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer token-") {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				fmt.Fprintf(w, pageJSON, "1", "1", "1", "1")
+			})
+			defer server.Close()
+			client.User, client.Token = "", ""
+			client.OAuth = &OAuth{TokenURL: server.URL + "/oauth/token", ClientID: "app", ClientSecret: tt.secret}
+
+			var err error
+			for i := 0; i < 2 && err == nil; i++ {
+				_, err = client.Page(context.Background(), "1")
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Page() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var apiErr *APIError
+			if tt.wantErr && (!errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Message != "Unauthorized") {
+				t.Errorf("Page() error = %v, want the token endpoint's 401", err)
+			}
+			if tokens != tt.wantTokens {
+				t.Errorf("token requested %d times, want %d", tokens, tt.wantTokens)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package confluence
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpOnlyPrefix marks the HttpOnly cookies of a cookies.txt file, which
+// would otherwise read as comments.
+const httpOnlyPrefix = "#HttpOnly_"
+
+// ReadCookieJar reads cookies in the Netscape cookies.txt format, as
+// written by curl and browser extensions, into a jar for the HTTPClient of
+// a Client. It lets the requests through instances behind single sign-on
+// that only accept a browser session, such as a SAML session cookie.
+// Expired cookies are left out.
+func ReadCookieJar(r io.Reader) (http.CookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(line, httpOnlyPrefix)
+		line = strings.TrimPrefix(line, httpOnlyPrefix)
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// domain, subdomains, path, secure, expiry, name, and value; the
+		// value may be missing when it is empty
+		fields := strings.Split(line, "\t")
+		if len(fields) == 6 {
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: want 7 tab-separated fields, got %d", n, len(fields))
+		}
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry %q", n, fields[4])
+		}
+		host := strings.TrimPrefix(fields[0], ".")
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if strings.EqualFold(fields[1], "TRUE") {
+			cookie.Domain = host
+		}
+		if expiry > 0 {
+			cookie.Expires = time.Unix(expiry, 0)
+			if cookie.Expires.Before(now) {
+				continue
+			}
+		}
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: cookie.Path}, []*http.Cookie{cookie})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return jar, nil
+}
+
+// ReadHeaders reads "Name: value" header lines, as for curl's -H @file,
+// for the Header of a Client. Blank lines and lines starting with # are
+// skipped.
+func ReadHeaders(r io.Reader) (http.Header, error) {
+	header := make(http.Header)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("line %d: want a \"Name: value\" header", n)
+		}
+		header.Add(name, strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
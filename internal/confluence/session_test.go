@@ -0,0 +1,102 @@
+package confluence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCookieJar(t *testing.T) {
+	future := time.Now().Add(time.Hour).Unix()
+	cookies := "# Netscape HTTP Cookie File\n\n" +
+		fmt.Sprintf("wiki.example.com\tFALSE\t/\tTRUE\t%d\tsession\tabc\n", future) +
+		"#HttpOnly_.example.com\tTRUE\t/\tFALSE\t0\tsso\txyz\n" +
+		"wiki.example.com\tFALSE\t/\tFALSE\t1\texpired\told\n" +
+		"wiki.example.com\tFALSE\t/wiki\tFALSE\t0\tempty\n"
+	jar, err := ReadCookieJar(strings.NewReader(cookies))
+	if err != nil {
+		t.Fatalf("ReadCookieJar() error = %v", err)
+	}
+
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{"https://wiki.example.com/wiki/rest/api", []string{"empty=", "session=abc", "sso=xyz"}},
+		{"http://wiki.example.com/", []string{"sso=xyz"}},
+		{"https://other.example.com/", []string{"sso=xyz"}},
+		{"https://example.org/", nil},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		var got []string
+		for _, c := range jar.Cookies(u) {
+			got = append(got, c.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("cookies for %s = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"wiki.example.com\tFALSE\t/\n", "wiki.example.com\tFALSE\t/\tFALSE\tnever\tname\tvalue\n"} {
+		if _, err := ReadCookieJar(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadCookieJar(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestReadHeaders(t *testing.T) {
+	header, err := ReadHeaders(strings.NewReader("# proxy\nX-Proxy-Auth: s3cret\n\ncookie: a=1; b=2\nX-Empty:\n"))
+	if err != nil {
+		t.Fatalf("ReadHeaders() error = %v", err)
+	}
+	want := http.Header{"X-Proxy-Auth": {"s3cret"}, "Cookie": {"a=1; b=2"}, "X-Empty": {""}}
+	if !reflect.DeepEqual(header, want) {
+		t.Errorf("ReadHeaders() = %v, want %v", header, want)
+	}
+
+	for _, bad := range []string{"no colon\n", "Bad Name: value\n", ": value\n"} {
+		if _, err := ReadHeaders(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadHeaders(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestClient_Session(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("JSESSIONID")
+		if err != nil || cookie.Value != "abc" || r.Header.Get("X-Proxy-Auth") != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Accept") != "application/json" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		fmt.Fprintf(w, pageJSON, "1", "1", "1", "1")
+	})
+	defer server.Close()
+	client.User, client.Token = "", ""
+	client.Header = http.Header{"X-Proxy-Auth": {"s3cret"}, "Accept": {"text/html"}}
+
+	var apiErr *APIError
+	if _, err := client.Page(context.Background(), "1"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Page() without the session cookie error = %v, want a 401", err)
+	}
+
+	u, _ := url.Parse(server.URL)
+	jar, err := ReadCookieJar(strings.NewReader(u.Hostname() + "\tFALSE\t/\tFALSE\t0\tJSESSIONID\tabc\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient.Jar = jar
+	if _, err := client.Page(context.Background(), "1"); err != nil {
+		t.Errorf("Page() with the session cookie error = %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is one version of a page's history.
+type Version struct {
+	Number  int
+	Author  string
+	When    time.Time
+	Message string
+	// MinorEdit reports whether the version was saved without notifying
+	// the page's watchers.
+	MinorEdit bool
+}
+
+// version is a version as the REST API returns it.
+type version struct {
+	Number    int       `json:"number"`
+	By        user      `json:"by"`
+	When      time.Time `json:"when"`
+	Message   string    `json:"message"`
+	MinorEdit bool      `json:"minorEdit"`
+}
+
+// Versions fetches the version history of the page with the given ID,
+// newest version first.
+func (c *Client) Versions(ctx context.Context, pageID string) ([]Version, error) {
+	query := url.Values{"limit": {strconv.Itoa(defaultPageLimit)}}
+	path := "/rest/api/content/" + url.PathEscape(pageID) + "/version?" + query.Encode()
+	var versions []Version
+	err := list(ctx, c, path, "versions of page "+pageID, func(result version) error {
+		versions = append(versions, Version{
+			Number:    result.Number,
+			Author:    firstNonEmpty(result.By.DisplayName, result.By.name()),
+			When:      result.When,
+			Message:   result.Message,
+			MinorEdit: result.MinorEdit,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Number > versions[j].Number
+	})
+	return versions, nil
+}
+
+// versionsHTML renders a page's version history as a "Version history"
+// section for the end of its export: a table of the versions, newest first,
+// with their date, author, and version comment. It returns "" when there
+// are no versions.
+func versionsHTML(versions []Version) string {
+	if len(versions) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<h2>Version history</h2><table><thead><tr><th>Version</th><th>Date</th><th>Author</th><th>Comment</th></tr></thead><tbody>")
+	for _, v := range versions {
+		number := strconv.Itoa(v.Number)
+		if v.MinorEdit {
+			number += " (minor edit)"
+		}
+		date := ""
+		if !v.When.IsZero() {
+			date = v.When.UTC().Format("2006-01-02 15:04 MST")
+		}
+		fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>",
+			number, date, html.EscapeString(v.Author), html.EscapeString(v.Message))
+	}
+	b.WriteString("</tbody></table>")
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClient_Versions(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wiki/rest/api/content/42/version" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("start") == "" {
+			fmt.Fprint(w, `{
+	"results": [
+		{"number": 1, "by": {"displayName": "Jane Doe"}, "when": "2026-01-07T01:29:00.000Z", "message": "Initial draft"},
+		{"number": 3, "by": {"username": "admin"}, "minorEdit": true}
+	],
+	"_links": {"next": "/rest/api/content/42/version?start=2"}
+}`)
+			return
+		}
+		fmt.Fprint(w, `{"results": [{"number": 2, "by": {"publicName": "jdoe"}, "message": "Add rollback"}]}`)
+	})
+	defer server.Close()
+
+	versions, err := client.Versions(context.Background(), "42")
+	if err != nil {
+		t.Fatalf("Versions() error = %v", err)
+	}
+	want := []Version{
+		{Number: 3, Author: "admin", MinorEdit: true},
+		{Number: 2, Author: "jdoe", Message: "Add rollback"},
+		{Number: 1, Author: "Jane Doe", When: time.Date(2026, 1, 7, 1, 29, 0, 0, time.UTC), Message: "Initial draft"},
+	}
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("Versions() = %+v, want %+v", versions, want)
+	}
+}
+
+func TestVersionsHTML(t *testing.T) {
+	if got := versionsHTML(nil); got != "" {
+		t.Errorf("versionsHTML(nil) = %q, want empty", got)
+	}
+	got := versionsHTML([]Version{
+		{Number: 2, Author: "admin", MinorEdit: true},
+		{Number: 1, Author: "Jane Doe", When: time.Date(2026, 1, 7, 1, 29, 0, 0, time.UTC), Message: "Fix <script> typo"},
+	})
+	want := "<h2>Version history</h2><table><thead><tr><th>Version</th><th>Date</th><th>Author</th><th>Comment</th></tr></thead><tbody>" +
+		"<tr><td>2 (minor edit)</td><td></td><td>admin</td><td></td></tr>" +
+		"<tr><td>1</td><td>2026-01-07 01:29 UTC</td><td>Jane Doe</td><td>Fix &lt;script&gt; typo</td></tr>" +
+		"</tbody></table>"
+	if got != want {
+		t.Errorf("versionsHTML() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package pandoc
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	"runtime"
+)
+
+// cacheDirName returns the name of the cache directory for the embedded
+// binary. It includes the platform so that home directories shared between
+// architectures (NFS homes, macOS universal setups) never reuse a binary
+// built for another machine.
+func cacheDirName() string {
+	return fmt.Sprintf("pandoc-%s-%s-%s", Version, runtime.GOOS, runtime.GOARCH)
+}
+
+// verifyArchitecture returns an error unless the executable at path is built
+// for the running GOARCH. ELF, Mach-O (including universal binaries), and PE
+// executables are recognized.
+func verifyArchitecture(path string) error {
+	arches, err := binaryArchitectures(path)
+	if err != nil {
+		return err
+	}
+	for _, arch := range arches {
+		if arch == runtime.GOARCH {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is built for %v, not %s", path, arches, runtime.GOARCH)
+}
+
+// binaryArchitectures returns the GOARCH names an executable is built for.
+// Unknown machine types are reported by their numeric value.
+func binaryArchitectures(path string) ([]string, error) {
+	if f, err := elf.Open(path); err == nil {
+		defer f.Close()
+		return []string{elfArch(f.Machine)}, nil
+	}
+	if f, err := macho.Open(path); err == nil {
+		defer f.Close()
+		return []string{machoArch(f.Cpu)}, nil
+	}
+	if f, err := macho.OpenFat(path); err == nil {
+		defer f.Close()
+		arches := make([]string, len(f.Arches))
+		for i, arch := range f.Arches {
+			arches[i] = machoArch(arch.Cpu)
+		}
+		return arches, nil
+	}
+	if f, err := pe.Open(path); err == nil {
+		defer f.Close()
+		return []string{peArch(f.Machine)}, nil
+	}
+	return nil, fmt.Errorf("%s is not a recognized executable", path)
+}
+
+func elfArch(m elf.Machine) string {
+	switch m {
+	case elf.EM_X86_64:
+		return "amd64"
+	case elf.EM_AARCH64:
+		return "arm64"
+	case elf.EM_386:
+		return "386"
+	case elf.EM_ARM:
+		return "arm"
+	}
+	return m.String()
+}
+
+func machoArch(c macho.Cpu) string {
+	switch c {
+	case macho.CpuAmd64:
+		return "amd64"
+	case macho.CpuArm64:
+		return "arm64"
+	case macho.Cpu386:
+		return "386"
+	case macho.CpuArm:
+		return "arm"
+	}
+	return c.String()
+}
+
+func peArch(m uint16) string {
+	switch m {
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		return "amd64"
+	case pe.IMAGE_FILE_MACHINE_ARM64:
+		return "arm64"
+	case pe.IMAGE_FILE_MACHINE_I386:
+		return "386"
+	}
+	return fmt.Sprintf("machine 0x%x", m)
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package pandoc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// Features describes the formats and extensions a pandoc binary supports.
+type Features struct {
+	// Version is the first line of pandoc --version, e.g. "pandoc 3.1.11".
+	Version string
+	// InputFormats lists the reader names from --list-input-formats.
+	InputFormats []string
+	// OutputFormats lists the writer names from --list-output-formats.
+	OutputFormats []string
+	// Extensions maps each extension from --list-extensions to whether it
+	// is enabled by default for pandoc's Markdown.
+	Extensions map[string]bool
+}
+
+// SupportsInput reports whether pandoc can read the named format.
+func (f Features) SupportsInput(format string) bool {
+	return slices.Contains(f.InputFormats, format)
+}
+
+// SupportsOutput reports whether pandoc can write the named format.
+func (f Features) SupportsOutput(format string) bool {
+	return slices.Contains(f.OutputFormats, format)
+}
+
+// HasExtension reports whether pandoc knows the named extension.
+func (f Features) HasExtension(name string) bool {
+	_, ok := f.Extensions[name]
+	return ok
+}
+
+// Capabilities probes the embedded pandoc for its supported formats and
+// extensions.
+func Capabilities(ctx context.Context) (Features, error) {
+	pandocPath, err := EnsureExtracted()
+	if err != nil {
+		return Features{}, fmt.Errorf("failed to extract pandoc: %w", err)
+	}
+	return CapabilitiesOf(ctx, pandocPath)
+}
+
+// CapabilitiesOf probes the pandoc binary at path for its supported formats
+// and extensions.
+func CapabilitiesOf(ctx context.Context, path string) (Features, error) {
+	list := func(arg string) ([]byte, error) {
+		output, err := exec.CommandContext(ctx, path, arg).Output()
+		if err != nil {
+			return nil, fmt.Errorf("pandoc %s failed: %w", arg, err)
+		}
+		return output, nil
+	}
+
+	var f Features
+	output, err := list("--version")
+	if err != nil {
+		return Features{}, err
+	}
+	if lines := parseList(output); len(lines) > 0 {
+		f.Version = lines[0]
+	}
+
+	if output, err = list("--list-input-formats"); err != nil {
+		return Features{}, err
+	}
+	f.InputFormats = parseList(output)
+
+	if output, err = list("--list-output-formats"); err != nil {
+		return Features{}, err
+	}
+	f.OutputFormats = parseList(output)
+
+	if output, err = list("--list-extensions"); err != nil {
+		return Features{}, err
+	}
+	f.Extensions = parseExtensions(output)
+
+	return f, nil
+}
+
+// parseList returns the non-empty, trimmed lines of output.
+func parseList(output []byte) []string {
+	var items []string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if item := strings.TrimSpace(scanner.Text()); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseExtensions parses --list-extensions output, where each line is an
+// extension name prefixed with "+" (enabled by default) or "-" (disabled).
+func parseExtensions(output []byte) map[string]bool {
+	extensions := make(map[string]bool)
+	for _, line := range parseList(output) {
+		switch line[0] {
+		case '+':
+			extensions[line[1:]] = true
+		case '-':
+			extensions[line[1:]] = false
+		default:
+			extensions[line] = false
+		}
+	}
+	return extensions
+}
@@ -0,0 +1,74 @@
+package pandoc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseList(t *testing.T) {
+	got := parseList([]byte("commonmark\ngfm\r\n\n  html  \n"))
+	want := []string{"commonmark", "gfm", "html"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseList() = %v, want %v", got, want)
+	}
+}
+
+func TestParseExtensions(t *testing.T) {
+	got := parseExtensions([]byte("+footnotes\n-hard_line_breaks\n+pipe_tables\n"))
+	want := map[string]bool{
+		"footnotes":        true,
+		"hard_line_breaks": false,
+		"pipe_tables":      true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseExtensions() = %v, want %v", got, want)
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	f := Features{
+		InputFormats:  []string{"html", "markdown"},
+		OutputFormats: []string{"gfm", "org"},
+		Extensions:    map[string]bool{"smart": false},
+	}
+
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"reads html", f.SupportsInput("html"), true},
+		{"reads docx", f.SupportsInput("docx"), false},
+		{"writes gfm", f.SupportsOutput("gfm"), true},
+		{"writes html", f.SupportsOutput("html"), false},
+		{"disabled extension", f.HasExtension("smart"), true},
+		{"unknown extension", f.HasExtension("wikilinks"), false},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	if !IsEmbedded() {
+		t.Skip("pandoc binary not embedded (run scripts/download-pandoc.sh first)")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	f, err := Capabilities(ctx)
+	if err != nil {
+		t.Fatalf("Capabilities failed: %v", err)
+	}
+	if !f.SupportsInput("html") || !f.SupportsOutput("gfm") {
+		t.Errorf("embedded pandoc lacks html reader or gfm writer: %+v", f)
+	}
+	if !f.HasExtension("pipe_tables") {
+		t.Error("embedded pandoc lacks the pipe_tables extension")
+	}
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package pandoc
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// errorPositionPattern matches the input position pandoc reports for
+	// parse errors, e.g. `Error at "source" (line 12, column 5):`.
+	errorPositionPattern = regexp.MustCompile(`line (\d+),? column (\d+)`)
+
+	// errorHeaderPattern matches the line that introduces a positioned
+	// parse error, which carries no information beyond the position.
+	errorHeaderPattern = regexp.MustCompile(`^Error at .*\(line \d+,? column \d+\):?$`)
+)
+
+// PandocError is a failed pandoc run, with the details parsed from its
+// standard error.
+type PandocError struct {
+	// ExitCode is pandoc's exit status, or -1 if it did not exit normally.
+	ExitCode int
+	// Line and Column locate the error in the input. They are 0 when pandoc
+	// did not report a position.
+	Line, Column int
+	// Message is pandoc's error output without the position header and
+	// warnings.
+	Message string
+
+	err error
+}
+
+func (e *PandocError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "pandoc error: %v", e.err)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, " at line %d, column %d", e.Line, e.Column)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Message)
+	}
+	return b.String()
+}
+
+// Unwrap returns the error from running pandoc.
+func (e *PandocError) Unwrap() error {
+	return e.err
+}
+
+// ParseError builds a PandocError from the error of a pandoc run and its
+// standard error output.
+func ParseError(err error, stderr []byte) *PandocError {
+	e := &PandocError{ExitCode: -1, err: err}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		e.ExitCode = exitErr.ExitCode()
+	}
+
+	output := strings.TrimSpace(string(stderr))
+	if m := errorPositionPattern.FindStringSubmatch(output); m != nil {
+		e.Line, _ = strconv.Atoi(m[1])
+		e.Column, _ = strconv.Atoi(m[2])
+	}
+
+	var message, warnings []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || errorHeaderPattern.MatchString(line):
+		case strings.HasPrefix(line, "[WARNING]"):
+			warnings = append(warnings, line)
+		default:
+			message = append(message, line)
+		}
+	}
+	// Warnings are the only explanation when pandoc printed nothing else
+	if len(message) == 0 {
+		message = warnings
+	}
+	e.Message = strings.Join(message, "\n")
+	return e
+}
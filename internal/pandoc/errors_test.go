@@ -0,0 +1,74 @@
+package pandoc
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	runErr := errors.New("exit status 64")
+
+	tests := []struct {
+		name         string
+		stderr       string
+		line, column int
+		message      string
+	}{
+		{
+			name:    "positioned parse error",
+			stderr:  "Error at \"source\" (line 12, column 5):\nunexpected '<'\nexpecting end of input\n",
+			line:    12,
+			column:  5,
+			message: "unexpected '<'\nexpecting end of input",
+		},
+		{
+			name:    "unpositioned error",
+			stderr:  "Unknown output format nope\n",
+			message: "Unknown output format nope",
+		},
+		{
+			name:    "warnings are dropped",
+			stderr:  "[WARNING] Could not fetch resource x.png\nUnknown option --bogus\n",
+			message: "Unknown option --bogus",
+		},
+		{
+			name:    "warnings alone are kept",
+			stderr:  "[WARNING] Could not fetch resource x.png\n",
+			message: "[WARNING] Could not fetch resource x.png",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := ParseError(runErr, []byte(tt.stderr))
+			if e.Line != tt.line || e.Column != tt.column {
+				t.Errorf("position = %d:%d, want %d:%d", e.Line, e.Column, tt.line, tt.column)
+			}
+			if e.Message != tt.message {
+				t.Errorf("Message = %q, want %q", e.Message, tt.message)
+			}
+			if e.ExitCode != -1 {
+				t.Errorf("ExitCode = %d, want -1 for a non-exit error", e.ExitCode)
+			}
+			if !errors.Is(e, runErr) {
+				t.Error("PandocError doesn't unwrap to the run error")
+			}
+		})
+	}
+}
+
+func TestParseError_ExitCode(t *testing.T) {
+	err := exec.Command("sh", "-c", "exit 64").Run()
+	if err == nil {
+		t.Skip("sh not available")
+	}
+	e := ParseError(err, []byte("Error at \"source\" (line 3, column 1):\nbad"))
+	if e.ExitCode != 64 {
+		t.Errorf("ExitCode = %d, want 64", e.ExitCode)
+	}
+	if !strings.Contains(e.Error(), "at line 3, column 1: bad") {
+		t.Errorf("Error() = %q, want the position and message", e.Error())
+	}
+}
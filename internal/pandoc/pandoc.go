@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -23,8 +24,44 @@ var (
 	extractOnce   sync.Once
 	extractedPath string
 	extractErr    error
+
+	// workDir, when set, replaces the user cache and system temp
+	// directories.
+	workDir string
 )
 
+// SetWorkDir directs the extracted binary, and the temporary files pandoc
+// creates while converting, to dir instead of the user cache and system
+// temp directories. The binary location only changes when SetWorkDir is
+// called before the first extraction.
+func SetWorkDir(dir string) {
+	workDir = dir
+}
+
+// CacheDir returns the directory files are cached in across runs, such as
+// the extracted binary.
+func CacheDir() string {
+	if workDir != "" {
+		return filepath.Join(workDir, "confluence2md")
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		// Fallback to temp directory
+		cacheDir = os.TempDir()
+	}
+	return filepath.Join(cacheDir, "confluence2md")
+}
+
+// commandEnv returns the environment of pandoc runs: the process
+// environment, with the temp directory variables pointing at the work
+// directory when one is set.
+func commandEnv() []string {
+	if workDir == "" {
+		return nil
+	}
+	return append(os.Environ(), "TMPDIR="+workDir, "TMP="+workDir, "TEMP="+workDir)
+}
+
 // EnsureExtracted extracts the embedded Pandoc binary to a cache location
 // and returns the path. Safe for concurrent use. Subsequent calls return
 // the cached path without re-extraction.
@@ -37,15 +74,8 @@ func EnsureExtracted() (string, error) {
 
 // extractBinary extracts the embedded binary to a persistent cache location.
 func extractBinary() (string, error) {
-	// Get user cache directory
-	cacheDir, err := os.UserCacheDir()
-	if err != nil {
-		// Fallback to temp directory
-		cacheDir = os.TempDir()
-	}
-
-	// Create versioned cache directory
-	pandocDir := filepath.Join(cacheDir, "confluence2md", fmt.Sprintf("pandoc-%s", Version))
+	// Create versioned, per-platform cache directory
+	pandocDir := filepath.Join(CacheDir(), cacheDirName())
 	if err := os.MkdirAll(pandocDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create cache directory: %w", err)
 	}
@@ -54,10 +84,11 @@ func extractBinary() (string, error) {
 	binaryName := getBinaryName()
 	binaryPath := filepath.Join(pandocDir, binaryName)
 
-	// Check if binary already exists and has correct size
+	// Check if binary already exists, has correct size, and is built for
+	// this architecture
 	if info, err := os.Stat(binaryPath); err == nil {
 		expectedSize := int64(len(embeddedBinary))
-		if info.Size() == expectedSize {
+		if info.Size() == expectedSize && verifyArchitecture(binaryPath) == nil {
 			// Binary exists and matches expected size, verify it's executable
 			if err := verifyExecutable(binaryPath); err == nil {
 				return binaryPath, nil
@@ -86,7 +117,7 @@ func extractBinary() (string, error) {
 	if err != nil {
 		// Another process might have already extracted, check if target exists
 		if info, statErr := os.Stat(binaryPath); statErr == nil {
-			if info.Size() == int64(len(embeddedBinary)) {
+			if info.Size() == int64(len(embeddedBinary)) && verifyArchitecture(binaryPath) == nil {
 				if verifyErr := verifyExecutable(binaryPath); verifyErr == nil {
 					return binaryPath, nil
 				}
@@ -118,7 +149,7 @@ func extractBinary() (string, error) {
 		os.Remove(tmpPath)
 		// Check if target was created by another process
 		if info, statErr := os.Stat(binaryPath); statErr == nil {
-			if info.Size() == int64(len(embeddedBinary)) {
+			if info.Size() == int64(len(embeddedBinary)) && verifyArchitecture(binaryPath) == nil {
 				if verifyErr := verifyExecutable(binaryPath); verifyErr == nil {
 					return binaryPath, nil
 				}
@@ -128,6 +159,10 @@ func extractBinary() (string, error) {
 	}
 
 	// Verify extraction
+	if err := verifyArchitecture(binaryPath); err != nil {
+		os.Remove(binaryPath)
+		return "", fmt.Errorf("extracted binary verification failed: %w", err)
+	}
 	if err := verifyExecutable(binaryPath); err != nil {
 		os.Remove(binaryPath)
 		return "", fmt.Errorf("extracted binary verification failed: %w", err)
@@ -170,31 +205,49 @@ func Run(ctx context.Context, args ...string) ([]byte, error) {
 	}
 
 	cmd := exec.CommandContext(ctx, pandocPath, args...)
+	cmd.Env = commandEnv()
 	return cmd.CombinedOutput()
 }
 
 // Convert performs a pandoc conversion with input from stdin.
 func Convert(ctx context.Context, input []byte, from, to string, extraArgs ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	if err := ConvertStream(ctx, bytes.NewReader(input), &stdout, from, to, extraArgs...); err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// ConvertStream performs a pandoc conversion reading input from r and
+// writing the result to w as it is produced, without buffering either side
+// in full. A failed run returns a *PandocError.
+func ConvertStream(ctx context.Context, r io.Reader, w io.Writer, from, to string, extraArgs ...string) error {
 	pandocPath, err := EnsureExtracted()
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract pandoc: %w", err)
+		return fmt.Errorf("failed to extract pandoc: %w", err)
 	}
+	return ConvertStreamWith(ctx, pandocPath, r, w, from, to, extraArgs...)
+}
 
+// ConvertStreamWith is like ConvertStream but runs the pandoc binary at
+// pandocPath, such as a system pandoc, instead of the embedded one.
+func ConvertStreamWith(ctx context.Context, pandocPath string, r io.Reader, w io.Writer, from, to string, extraArgs ...string) error {
 	args := []string{"-f", from, "-t", to}
 	args = append(args, extraArgs...)
 
 	cmd := exec.CommandContext(ctx, pandocPath, args...)
-	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = commandEnv()
+	cmd.Stdin = r
+	cmd.Stdout = w
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
+	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("pandoc error: %w: %s", err, stderr.String())
+		return ParseError(err, stderr.Bytes())
 	}
 
-	return stdout.Bytes(), nil
+	return nil
 }
 
 // GetVersion returns the version string from the embedded pandoc.
@@ -2,9 +2,11 @@ package pandoc
 
 import (
 	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -115,6 +117,30 @@ func TestConvertHTMLToGFM(t *testing.T) {
 	t.Logf("Converted output:\n%s", result)
 }
 
+func TestConvertStream(t *testing.T) {
+	if !IsEmbedded() {
+		t.Skip("pandoc binary not embedded (run scripts/download-pandoc.sh first)")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var out strings.Builder
+	input := strings.NewReader("<h2>Streamed</h2><p>Body</p>")
+	if err := ConvertStream(ctx, input, &out, "html", "gfm", "--wrap=none"); err != nil {
+		t.Fatalf("ConvertStream failed: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "## Streamed") {
+		t.Errorf("output doesn't contain markdown heading: %s", out.String())
+	}
+
+	err := ConvertStream(ctx, strings.NewReader("<p>x</p>"), io.Discard, "html", "no-such-format")
+	if err == nil || !strings.Contains(err.Error(), "no-such-format") {
+		t.Errorf("ConvertStream(unknown format) error = %v, want pandoc's stderr", err)
+	}
+}
+
 func TestConcurrentAccess(t *testing.T) {
 	if !IsEmbedded() {
 		t.Skip("pandoc binary not embedded (run scripts/download-pandoc.sh first)")
@@ -314,3 +340,52 @@ func TestVersion(t *testing.T) {
 		t.Errorf("Version should have at least 2 parts: %s", Version)
 	}
 }
+
+func TestCacheDirName(t *testing.T) {
+	name := cacheDirName()
+	for _, part := range []string{Version, runtime.GOOS, runtime.GOARCH} {
+		if !strings.Contains(name, part) {
+			t.Errorf("cacheDirName() = %q, want it to contain %q", name, part)
+		}
+	}
+}
+
+func TestVerifyArchitecture(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("cannot locate test executable: %v", err)
+	}
+	if err := verifyArchitecture(exe); err != nil {
+		t.Errorf("verifyArchitecture(test binary) = %v, want nil", err)
+	}
+
+	notBinary := filepath.Join(t.TempDir(), "pandoc")
+	if err := os.WriteFile(notBinary, []byte("#!/bin/sh\necho pandoc\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyArchitecture(notBinary); err == nil {
+		t.Error("verifyArchitecture(script) = nil, want error")
+	}
+}
+
+func TestSetWorkDir(t *testing.T) {
+	defer SetWorkDir("")
+
+	SetWorkDir("")
+	if env := commandEnv(); env != nil {
+		t.Errorf("commandEnv() without work dir = %d entries, want nil", len(env))
+	}
+
+	dir := t.TempDir()
+	SetWorkDir(dir)
+	if got, want := CacheDir(), filepath.Join(dir, "confluence2md"); got != want {
+		t.Errorf("CacheDir() = %s, want %s", got, want)
+	}
+	env := commandEnv()
+	for _, name := range []string{"TMPDIR", "TMP", "TEMP"} {
+		want := name + "=" + dir
+		if len(env) == 0 || !slices.Contains(env, want) {
+			t.Errorf("commandEnv() is missing %s", want)
+		}
+	}
+}
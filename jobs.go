@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"sync"
+)
+
+// runJobs calls fn with every index below n, running up to jobs calls at
+// once. It returns when all calls have returned.
+func runJobs(n, jobs int, fn func(i int)) {
+	jobs = max(1, min(jobs, n))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// orderedOutput collects the output of concurrent jobs and writes it in job
+// order: the output of a job is written once it and every job before it
+// are done.
+type orderedOutput struct {
+	cfg  *config
+	mu   sync.Mutex
+	jobs []jobOutput
+	next int
+}
+
+// jobOutput is the output of a job, and whether the job is done.
+type jobOutput struct {
+	out, messages bytes.Buffer
+	done          bool
+}
+
+// newOrderedOutput returns an orderedOutput for n jobs writing to the
+// output of cfg.
+func newOrderedOutput(n int, cfg *config) *orderedOutput {
+	return &orderedOutput{cfg: cfg, jobs: make([]jobOutput, n)}
+}
+
+// config returns a copy of cfg whose output is collected for job i.
+func (o *orderedOutput) config(i int) *config {
+	cfg := *o.cfg
+	cfg.stdout, cfg.stderr = &o.jobs[i].out, &o.jobs[i].messages
+	return &cfg
+}
+
+// done marks job i done and writes the output of the jobs done so far that
+// no unfinished job precedes.
+func (o *orderedOutput) done(i int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.jobs[i].done = true
+	for ; o.next < len(o.jobs) && o.jobs[o.next].done; o.next++ {
+		job := &o.jobs[o.next]
+		o.cfg.out().Write(job.out.Bytes())
+		o.cfg.messages().Write(job.messages.Bytes())
+		job.out, job.messages = bytes.Buffer{}, bytes.Buffer{}
+	}
+}
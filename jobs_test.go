@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestRunJobs(t *testing.T) {
+	tests := []struct {
+		n, jobs int
+	}{
+		{0, 4},
+		{1, 4},
+		{10, 1},
+		{10, 3},
+		{3, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d items, %d jobs", tt.n, tt.jobs), func(t *testing.T) {
+			var mu sync.Mutex
+			running, peak := 0, 0
+			done := make([]int, tt.n)
+			runJobs(tt.n, tt.jobs, func(i int) {
+				mu.Lock()
+				running++
+				peak = max(peak, running)
+				mu.Unlock()
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				running--
+				done[i]++
+				mu.Unlock()
+			})
+
+			for i, count := range done {
+				if count != 1 {
+					t.Errorf("item %d ran %d times, want 1", i, count)
+				}
+			}
+			if peak > tt.jobs {
+				t.Errorf("%d calls ran at once, want at most %d", peak, tt.jobs)
+			}
+		})
+	}
+}
+
+func TestOrderedOutput(t *testing.T) {
+	var out, messages bytes.Buffer
+	output := newOrderedOutput(3, &config{stdout: &out, stderr: &messages})
+	for _, i := range []int{2, 0, 1} {
+		cfg := output.config(i)
+		fmt.Fprintf(cfg.out(), "out %d\n", i)
+		fmt.Fprintf(cfg.messages(), "warning %d\n", i)
+		output.done(i)
+		if i == 2 && out.Len() > 0 {
+			t.Errorf("output of job 2 written before the jobs preceding it: %q", out.String())
+		}
+	}
+	if want := "out 0\nout 1\nout 2\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if want := "warning 0\nwarning 1\nwarning 2\n"; messages.String() != want {
+		t.Errorf("messages = %q, want %q", messages.String(), want)
+	}
+}
+
+func TestParseFlags_Jobs(t *testing.T) {
+	cfg, err := parseFlags([]string{"--dir", "exports"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.jobs != runtime.NumCPU() {
+		t.Errorf("jobs = %d, want the default %d (the number of CPUs)", cfg.jobs, runtime.NumCPU())
+	}
+}
+
+func TestConvertDirectory_Jobs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake pandoc scripts are not executable on Windows")
+	}
+
+	// A fake system pandoc that strips paragraph tags and the charset
+	// declaration
+	binDir := t.TempDir()
+	script := "#!/bin/sh\nsed -e 's#</*p>##g' -e 's#<meta[^>]*>##g'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "pandoc"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tmpDir := t.TempDir()
+	var names []string
+	for i := 0; i < 12; i++ {
+		name := fmt.Sprintf("Page%02d", i)
+		names = append(names, name)
+		createTestConfluenceMIME(t, tmpDir, name+".doc", "<p>Content of "+name+"</p>")
+	}
+
+	var out bytes.Buffer
+	cfg := &config{jobs: 4, report: true, sourceLink: sourceLinkNone, redactions: newRedactionLog(), options: converter.Options{Engine: converter.EngineSystem}, stdout: &out}
+	if err := convertDirectory(tmpDir, cfg); err != nil {
+		t.Fatalf("convertDirectory failed: %v", err)
+	}
+
+	for _, name := range names {
+		md, err := os.ReadFile(filepath.Join(tmpDir, name+".md"))
+		if err != nil {
+			t.Fatalf("output not written: %v", err)
+		}
+		if got := strings.TrimSpace(string(md)); got != "Content of "+name {
+			t.Errorf("%s.md = %q, want %q", name, got, "Content of "+name)
+		}
+	}
+
+	// The output and the report list the pages in order, whatever order
+	// they finished in
+	var converted []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.HasPrefix(line, "Converted: ") {
+			converted = append(converted, strings.TrimSuffix(strings.Fields(line)[1], ".doc"))
+		}
+	}
+	if strings.Join(converted, ",") != strings.Join(names, ",") {
+		t.Errorf("converted files printed as %v, want %v", converted, names)
+	}
+	report, err := os.ReadFile(filepath.Join(tmpDir, migrationReportJSONFile))
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	last := -1
+	for _, name := range names {
+		at := bytes.Index(report, []byte(name+".doc"))
+		if at == -1 {
+			t.Errorf("report does not list %s", name)
+		} else if at < last {
+			t.Errorf("report lists %s out of order", name)
+		}
+		last = at
+	}
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// errLimitExceeded marks files skipped because they exceed a per-file limit.
+var errLimitExceeded = errors.New("per-file limit exceeded")
+
+// byteSizeUnits maps size suffixes to their multipliers (binary units).
+var byteSizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseByteSize parses a size such as "512", "64KB", or "80MB". Units are
+// case-insensitive and binary (1KB = 1024 bytes). Zero means no limit.
+func parseByteSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use bytes or a KB/MB/GB suffix)", value)
+	}
+	return n * multiplier, nil
+}
+
+// parsePositiveByteSize parses the value of a size flag that must be
+// positive, such as a parser limit that cannot be disabled.
+func parsePositiveByteSize(flagName, value string) (int64, error) {
+	n, err := parseByteSize(value)
+	if err == nil && n == 0 {
+		err = errors.New("must be positive")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", flagName, err)
+	}
+	return n, nil
+}
+
+// formatByteSize renders a byte count with the largest whole unit.
+func formatByteSize(n int64) string {
+	switch {
+	case n >= 1<<30 && n%(1<<30) == 0:
+		return fmt.Sprintf("%dGB", n>>30)
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", n>>10)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+// checkSizeLimit returns an errLimitExceeded error if size is over limit.
+// A limit of zero disables the check.
+func checkSizeLimit(what string, size, limit int64) error {
+	if limit > 0 && size > limit {
+		return fmt.Errorf("%w: %s is %s, limit is %s", errLimitExceeded, what, formatByteSize(size), formatByteSize(limit))
+	}
+	return nil
+}
+
+// isSkippable reports whether a conversion error came from a per-file limit,
+// so batch mode reports the file as skipped rather than failed.
+func isSkippable(err error) bool {
+	return errors.Is(err, errLimitExceeded) || errors.Is(err, converter.ErrMIMELimit) || errors.Is(err, converter.ErrTimeout)
+}
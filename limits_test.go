@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"512", 512, false},
+		{"64KB", 64 << 10, false},
+		{"64k", 64 << 10, false},
+		{"80MB", 80 << 20, false},
+		{"80 mb", 80 << 20, false},
+		{"2G", 2 << 30, false},
+		{"100B", 100, false},
+		{"", 0, true},
+		{"MB", 0, true},
+		{"-5MB", 0, true},
+		{"1.5MB", 0, true},
+		{"ten", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseByteSize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseByteSize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseByteSize(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSizeLimit(t *testing.T) {
+	if err := checkSizeLimit("input", 100, 0); err != nil {
+		t.Errorf("zero limit should disable the check, got %v", err)
+	}
+	if err := checkSizeLimit("input", 100, 100); err != nil {
+		t.Errorf("size equal to the limit should pass, got %v", err)
+	}
+	err := checkSizeLimit("input", 80<<20, 50<<20)
+	if !errors.Is(err, errLimitExceeded) {
+		t.Fatalf("expected errLimitExceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "input is 80MB, limit is 50MB") {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
+
+func TestIsSkippable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"size limit", fmt.Errorf("%w: too big", errLimitExceeded), true},
+		{"timeout", fmt.Errorf("failed to convert to Markdown: %w", converter.ErrTimeout), true},
+		{"MIME limit", fmt.Errorf("failed to extract HTML: %w", converter.ErrMIMELimit), true},
+		{"other failure", errors.New("pandoc failed"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSkippable(tt.err); got != tt.want {
+				t.Errorf("isSkippable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertFile_SizeLimits(t *testing.T) {
+	tmpDir := t.TempDir()
+	body := "<html><body>" + strings.Repeat("<p>Lorem ipsum dolor sit amet.</p>\n", 100) + "</body></html>"
+	inputPath := createTestConfluenceMIME(t, tmpDir, "big.doc", body)
+	outputPath := filepath.Join(tmpDir, "big.md")
+
+	tests := []struct {
+		name string
+		cfg  *config
+	}{
+		{"input size", &config{maxInputSize: 1024}},
+		{"html size", &config{maxHTMLSize: 1024}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := convertFile(inputPath, outputPath, tt.cfg)
+			if !errors.Is(err, errLimitExceeded) {
+				t.Fatalf("expected errLimitExceeded, got %v", err)
+			}
+			if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+				t.Error("expected no output for a skipped file")
+			}
+		})
+	}
+}
+
+func TestParseFlags_SizeLimits(t *testing.T) {
+	var buf bytes.Buffer
+	cfg, err := parseFlags([]string{"--max-input-size", "80MB", "--max-html-size", "512KB", "input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("parseFlags failed: %v", err)
+	}
+	if cfg.maxInputSize != 80<<20 {
+		t.Errorf("maxInputSize = %d, want %d", cfg.maxInputSize, 80<<20)
+	}
+	if cfg.maxHTMLSize != 512<<10 {
+		t.Errorf("maxHTMLSize = %d, want %d", cfg.maxHTMLSize, 512<<10)
+	}
+}
+
+func TestParseFlags_MIMELimits(t *testing.T) {
+	var buf bytes.Buffer
+	cfg, err := parseFlags([]string{"input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("parseFlags failed: %v", err)
+	}
+	want := converter.MIMELimits{MaxHeaderBytes: converter.DefaultMaxHeaderBytes, MaxParts: converter.DefaultMaxParts, MaxPartBytes: converter.DefaultMaxPartBytes}
+	if cfg.mimeLimits != want {
+		t.Errorf("mimeLimits = %+v, want defaults %+v", cfg.mimeLimits, want)
+	}
+
+	cfg, err = parseFlags([]string{"--max-header-size", "8KB", "--max-parts", "50", "--max-part-size", "20MB", "input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("parseFlags failed: %v", err)
+	}
+	want = converter.MIMELimits{MaxHeaderBytes: 8 << 10, MaxParts: 50, MaxPartBytes: 20 << 20}
+	if cfg.mimeLimits != want {
+		t.Errorf("mimeLimits = %+v, want %+v", cfg.mimeLimits, want)
+	}
+
+	for _, args := range [][]string{
+		{"--max-header-size", "0", "input.doc"},
+		{"--max-part-size", "lots", "input.doc"},
+		{"--max-parts", "0", "input.doc"},
+	} {
+		if _, err := parseFlags(args, &buf); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
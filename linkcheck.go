@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// checkMode selects whether converted Markdown is checked after conversion,
+// for broken relative links (--check-links) or accessibility problems
+// (--a11y-check).
+type checkMode string
+
+const (
+	// checkOff disables the check.
+	checkOff checkMode = ""
+	// checkReport prints the problems found as warnings.
+	checkReport checkMode = "report"
+	// checkStrict prints the problems found and fails the run.
+	checkStrict checkMode = "strict"
+)
+
+// errBrokenLinks is returned by checkLinks in strict mode.
+var errBrokenLinks = errors.New("broken links found")
+
+// checkModeFlag implements flag.Value for --check-links and --a11y-check,
+// which accept an optional mode. A bare --check-links reports broken links,
+// while --check-links=strict also fails the run.
+type checkModeFlag struct {
+	mode checkMode
+}
+
+func (f *checkModeFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return string(f.mode)
+}
+
+func (f *checkModeFlag) Set(value string) error {
+	switch value {
+	case "true", string(checkReport):
+		f.mode = checkReport
+	case "false":
+		f.mode = checkOff
+	case string(checkStrict):
+		f.mode = checkStrict
+	default:
+		return fmt.Errorf("mode must be report or strict")
+	}
+	return nil
+}
+
+func (f *checkModeFlag) IsBoolFlag() bool {
+	return true
+}
+
+// checkLinks checks the converted pages for relative links and images whose
+// target does not exist in the output tree, printing each one to w. In
+// strict mode an error wrapping errBrokenLinks is returned when any are found.
+func checkLinks(pages []convertedPage, mode checkMode, w io.Writer) error {
+	broken, affected := 0, 0
+	for _, page := range pages {
+		stats, err := checkPageLinks(page.outputPath)
+		if err != nil {
+			return err
+		}
+		for _, target := range stats.broken {
+			fmt.Fprintf(w, "Broken link in %s: %s\n", page.outputPath, target)
+		}
+		if len(stats.broken) > 0 {
+			broken += len(stats.broken)
+			affected++
+		}
+	}
+
+	if broken == 0 {
+		fmt.Fprintln(w, "Link check: no broken links")
+		return nil
+	}
+	fmt.Fprintf(w, "Link check: %d broken link(s) in %d page(s)\n", broken, affected)
+	if mode == checkStrict {
+		return fmt.Errorf("%w: %d in %d page(s)", errBrokenLinks, broken, affected)
+	}
+	return nil
+}
+
+// linkStats summarizes the links of one converted page.
+type linkStats struct {
+	attachments int      // images and attachment links
+	broken      []string // relative link targets with no file in the output tree
+}
+
+// checkPageLinks scans a converted Markdown file for attachments and for
+// relative links whose target file does not exist.
+func checkPageLinks(outputPath string) (linkStats, error) {
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		return linkStats{}, fmt.Errorf("failed to read %s: %w", outputPath, err)
+	}
+
+	var stats linkStats
+	dir := filepath.Dir(outputPath)
+	for _, link := range converter.MarkdownLinks(string(data)) {
+		if link.Image || link.IsAttachment() {
+			stats.attachments++
+		}
+		local := localLinkPath(link.Target)
+		if local == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(local))); err != nil {
+			stats.broken = append(stats.broken, link.Target)
+		}
+	}
+	return stats, nil
+}
+
+// localLinkPath returns the relative file path a link target refers to, or
+// an empty string for targets outside the output tree: URLs, in-page
+// anchors, and server-relative paths.
+func localLinkPath(target string) string {
+	if strings.HasPrefix(target, "#") || strings.HasPrefix(target, "/") {
+		return ""
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return ""
+	}
+	return u.Path
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLocalLinkPath(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"other.md", "other.md"},
+		{"sub/page.md#section", "sub/page.md"},
+		{"images/My%20Diagram.png", "images/My Diagram.png"},
+		{"../up.md?x=1", "../up.md"},
+		{"#anchor", ""},
+		{"/display/ENG/Page", ""},
+		{"https://example.com/page", ""},
+		{"mailto:team@example.com", ""},
+		{"//cdn.example.com/a.png", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			if got := localLinkPath(tt.target); got != tt.want {
+				t.Errorf("localLinkPath(%q) = %q, want %q", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPageLinks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "images"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"other.md", "images/a.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page := filepath.Join(dir, "page.md")
+	md := "[ok](other.md#top) [missing](gone.md) ![img](images/a.png) ![lost](images/b.png)\n" +
+		"[file](/download/attachments/1/spec.pdf) [web](https://example.com) [anchor](#x)\n" +
+		"`[code](ignored.md)`\n"
+	if err := os.WriteFile(page, []byte(md), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := checkPageLinks(page)
+	if err != nil {
+		t.Fatalf("checkPageLinks failed: %v", err)
+	}
+	if stats.attachments != 3 {
+		t.Errorf("attachments = %d, want 3", stats.attachments)
+	}
+	if want := []string{"gone.md", "images/b.png"}; !reflect.DeepEqual(stats.broken, want) {
+		t.Errorf("broken = %v, want %v", stats.broken, want)
+	}
+
+	if _, err := checkPageLinks(filepath.Join(dir, "nope.md")); err == nil {
+		t.Error("expected error for a missing output file")
+	}
+}
+
+func TestCheckLinks(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.md")
+	bad := filepath.Join(dir, "bad.md")
+	if err := os.WriteFile(good, []byte("[ok](bad.md)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bad, []byte("[a](gone.md) ![b](missing.png)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pages := []convertedPage{{outputPath: good}, {outputPath: bad}}
+
+	var out bytes.Buffer
+	if err := checkLinks(pages, checkReport, &out); err != nil {
+		t.Fatalf("report mode should not fail: %v", err)
+	}
+	for _, want := range []string{
+		"Broken link in " + bad + ": gone.md",
+		"Broken link in " + bad + ": missing.png",
+		"Link check: 2 broken link(s) in 1 page(s)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := checkLinks(pages, checkStrict, &out); !errors.Is(err, errBrokenLinks) {
+		t.Errorf("strict mode should return errBrokenLinks, got %v", err)
+	}
+
+	out.Reset()
+	if err := checkLinks(pages[:1], checkStrict, &out); err != nil {
+		t.Errorf("no broken links should pass strict mode, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Link check: no broken links") {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+}
+
+func TestParseFlags_CheckLinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    checkMode
+		wantErr bool
+	}{
+		{"off by default", []string{"input.doc"}, checkOff, false},
+		{"bare flag reports", []string{"--check-links", "input.doc"}, checkReport, false},
+		{"strict", []string{"--check-links=strict", "input.doc"}, checkStrict, false},
+		{"unknown mode", []string{"--check-links=loud", "input.doc"}, "", true},
+		{"non-markdown output", []string{"--check-links", "--to", "org", "input.doc"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cfg, err := parseFlags(tt.args, &buf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlags(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if err == nil && cfg.checkLinks != tt.want {
+				t.Errorf("checkLinks = %q, want %q", cfg.checkLinks, tt.want)
+			}
+		})
+	}
+}
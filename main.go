@@ -3,14 +3,21 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/aqueeb/confluence2md/converter"
+	"github.com/aqueeb/confluence2md/internal/confluence"
 )
 
 var (
@@ -20,6 +27,10 @@ var (
 	repoURL = "https://github.com/aqueeb/confluence2md"
 )
 
+// assetsDir is the directory next to each output that --extract-attachments
+// writes images to.
+const assetsDir = "assets"
+
 // config holds the parsed command-line configuration
 type config struct {
 	outputPath  string
@@ -28,6 +39,159 @@ type config struct {
 	dryRun      bool
 	showVersion bool
 	args        []string
+
+	// fetch is the Confluence page or space --url downloads into dirMode
+	fetch *fetchSource
+	// fetchJobs is the number of pages downloaded at once
+	fetchJobs int
+	// fetchComments and fetchVersions append the comments and version
+	// history of fetched pages to their exports
+	fetchComments bool
+	fetchVersions bool
+	// credentials authenticate the requests of fetch
+	credentials credentials
+
+	// inputs, when set, are the exports of dirMode to convert instead of
+	// all of them; onConverted is called with each converted successfully
+	inputs      []string
+	onConverted func(inputPath string)
+
+	// gitbookSummary writes a GitBook SUMMARY.md in directory mode
+	gitbookSummary bool
+	// sitemap writes a sitemap.json page tree in directory mode
+	sitemap bool
+
+	// searchIndex, when set, writes a search index of the converted pages
+	// to this file
+	searchIndex string
+
+	// chunk writes converted Markdown as JSON lines of chunks of at most
+	// maxTokens estimated tokens, for vector-store ingestion
+	chunk     bool
+	maxTokens int
+
+	// altText describes images without alt text (nil when disabled)
+	altText *altTextCommand
+
+	// redaction holds the redaction rules applied to each output (nil
+	// when disabled); redactions records what they replaced per output
+	redaction  []converter.RedactionRule
+	redactions *redactionLog
+
+	// prependText and appendText are the --prepend and --append boilerplate
+	// templates added to each output
+	prependText string
+	appendText  string
+	// pageTemplate, when set, renders each converted page (--page-template)
+	pageTemplate *template.Template
+
+	// tableCSV writes the tables of each page to CSV files (nil when
+	// disabled)
+	tableCSV *tableCSVWriter
+	// extractAssets writes the images embedded in each export to
+	// assetsDir next to the output and links them from the Markdown
+	extractAssets bool
+
+	// routes place pages into subdirectories by label or space
+	routes []outputRoute
+	// tree places pages into directories named after their breadcrumbs
+	tree bool
+
+	// filter skips drafts, templates, and pages not matching the label or
+	// title filters in directory mode
+	filter pageFilter
+
+	// gitCommit commits the files produced in directory mode with a
+	// message rendered from the gitMessage template
+	gitCommit  bool
+	gitMessage string
+
+	// summaryPath, when set, writes a digest of the input pages to this
+	// file instead of converting them
+	summaryPath string
+
+	// checkLinks checks converted Markdown for broken relative links
+	checkLinks checkMode
+	// a11yCheck lints converted Markdown for accessibility problems
+	a11yCheck checkMode
+
+	// report writes MIGRATION_REPORT.md and migration-report.json in directory mode
+	report bool
+
+	// jekyllLayout is the layout named in front matter for --target jekyll
+	jekyllLayout string
+
+	// stamp records the source file, tool version, and source hash in each output
+	stamp stampStyle
+	// sourceLink adds a link back to the original Confluence page to each output
+	sourceLink sourceLinkStyle
+
+	// maxInputSize and maxHTMLSize skip files whose MIME export or
+	// extracted HTML exceeds the given number of bytes (0 means no limit)
+	maxInputSize int64
+	maxHTMLSize  int64
+
+	// mimeLimits bound the MIME parsing of each export
+	mimeLimits converter.MIMELimits
+
+	// traceTransforms reports each pipeline step that changed a document
+	traceTransforms bool
+
+	// tempDir, when set, holds temporary files and the extracted pandoc
+	tempDir string
+
+	// batchSize is the number of pages converted per pandoc run in
+	// directory mode
+	batchSize int
+	// jobs is the number of files, or batches, converted at once in
+	// directory mode
+	jobs int
+	// stdout and stderr, when set, take the output of a file's conversion
+	// instead of the process's, so that concurrently converted files can
+	// be reported in order
+	stdout, stderr io.Writer
+
+	// progress emits JSON lines progress events on stderr (nil when disabled)
+	progress *progressEmitter
+
+	// options controls optional conversion behavior passed to the converter
+	options converter.Options
+}
+
+// tocFlag implements flag.Value for --toc, which accepts an optional depth.
+// A bare --toc enables the table of contents with the default depth, while
+// --toc=N sets the depth explicitly.
+type tocFlag struct {
+	depth int
+}
+
+func (f *tocFlag) String() string {
+	if f == nil || f.depth == 0 {
+		return ""
+	}
+	return strconv.Itoa(f.depth)
+}
+
+func (f *tocFlag) Set(value string) error {
+	switch value {
+	case "true":
+		f.depth = converter.DefaultTOCDepth
+		return nil
+	case "false":
+		f.depth = 0
+		return nil
+	}
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 1 || depth > 6 {
+		return fmt.Errorf("depth must be a number between 1 and 6")
+	}
+	f.depth = depth
+	return nil
+}
+
+// IsBoolFlag allows --toc to be given without a value.
+func (f *tocFlag) IsBoolFlag() bool {
+	return true
 }
 
 // parseFlags parses command-line flags and returns a config.
@@ -39,16 +203,108 @@ func parseFlags(args []string, output io.Writer) (*config, error) {
 	outputPath := fs.String("o", "", "Output file path (default: input with .md extension)")
 	outputLong := fs.String("output", "", "Output file path (default: input with .md extension)")
 	dirMode := fs.String("dir", "", "Convert all .doc files in directory")
+	fetchURL := fs.String("url", "", "Fetch from Confluence and convert: a page URL, or the site URL with --space")
+	space := fs.String("space", "", "Fetch every page and blog post of the space with this key (with --url)")
+	fetchJobs := fs.Int("fetch-jobs", 1, "With --url, download up to this many pages at once")
+	fetchComments := fs.Bool("comments", false, "With --url, append each page's comments, with their replies and resolved state, as a Comments section")
+	fetchVersions := fs.Bool("version-history", false, "With --url, append each page's version history, with the author, date, and comment of each version, as a table")
+	flagCredentials := credentials{}
+	fs.StringVar(&flagCredentials.User, "confluence-user", "", "With --url, the account email the API token belongs to (default $"+confluenceUserEnv+"); without one the token is sent as a personal access token")
+	fs.StringVar(&flagCredentials.Token, "confluence-token", "", "With --url, the API token or personal access token (default $"+confluenceTokenEnv+"; asked for on the terminal when not set anywhere)")
+	fs.StringVar(&flagCredentials.ClientID, "oauth-client-id", "", "With --url, authenticate with this OAuth 2.0 client ID and the client credentials grant (default $"+confluenceClientIDEnv+")")
+	fs.StringVar(&flagCredentials.ClientSecret, "oauth-client-secret", "", "With --oauth-client-id, the client secret (default $"+confluenceClientSecretEnv+"; asked for on the terminal when not set anywhere)")
+	fs.StringVar(&flagCredentials.TokenURL, "oauth-token-url", "", "With --oauth-client-id, the token endpoint (default "+confluence.DefaultTokenURL+")")
+	fs.StringVar(&flagCredentials.CookieJar, "cookie-jar", "", "With --url, send the cookies of this Netscape cookies.txt file, such as an SSO session cookie")
+	fs.StringVar(&flagCredentials.HeaderFile, "header-file", "", "With --url, send the \"Name: value\" headers of this file with every request")
 	verbose := fs.Bool("v", false, "Verbose output")
 	verboseLong := fs.Bool("verbose", false, "Verbose output")
 	dryRun := fs.Bool("dry-run", false, "Show what would be converted without writing")
 	showVersion := fs.Bool("version", false, "Show version")
+	gitbookSummary := fs.Bool("gitbook-summary", false, "Write a GitBook/HonKit SUMMARY.md listing converted pages (with --dir)")
+	altTextCmd := fs.String("alt-text-command", "", "Command run for each image without alt text; it gets the image reference as its last argument (and the local file in $C2MD_IMAGE_PATH) and prints the alt text")
+	chunk := fs.Bool("chunk", false, "Write each page as JSON lines of heading-bounded, overlapping chunks (.jsonl) for vector-store ingestion")
+	maxTokens := fs.Int("max-tokens", defaultMaxTokens, "Maximum estimated tokens per chunk with --chunk")
+	searchIndex := fs.String("search-index", "", "Write a JSON search index of the converted pages (id, title, headings, body, path) for lunr.js or Meilisearch to this file")
+	summaryPath := fs.String("summary", "", "Write a digest of each page's title, first paragraph, headings, and metadata to this file (.json or Markdown) instead of converting")
+	tree := fs.Bool("tree", false, "Place each output in a directory hierarchy named after the page's breadcrumbs (Space/Parent/Page.md) instead of next to its export")
+	sitemapJSON := fs.Bool("sitemap", false, "Write sitemap.json with the converted page tree, titles, paths, and page IDs (with --dir)")
+	progress := fs.String("progress-format", string(progressText), "Progress output: text, or jsonl (one JSON event per line on stderr)")
+	report := fs.Bool("report", false, "Write MIGRATION_REPORT.md and migration-report.json summarizing the batch (with --dir)")
+	pageIDs := fs.Bool("page-ids", false, "Record the Confluence page ID and space key as confluence_page_id and confluence_space in front matter")
+	detectLanguage := fs.Bool("detect-language", false, "Detect the page language (en, de, fr, es, it, nl, pt) and record it as lang in front matter")
+	lang := fs.String("lang", "en", "Language of the labels introducing info, note, tip, success, and warning macros: en, de, fr, es, it, nl, or pt")
+	normalizeHeadingLevels := fs.Bool("normalize-heading-levels", false, "Compress heading levels so none is skipped (H1 then H4 becomes H1 then H2)")
+	numberHeadings := fs.Bool("number-headings", false, "Prefix headings with hierarchical numbers (1., 1.1, 1.1.1)")
+	to := fs.String("to", string(converter.FormatMarkdown), "Output format: markdown, org, plain, json (pandoc AST), docx, or pdf (pdf needs a LaTeX engine)")
+	flavor := fs.String("flavor", string(converter.FlavorGFM), "Markdown flavor: gfm, gitlab, commonmark, obsidian (callouts and [[wikilinks]]), or mkdocs (!!! admonitions, 4-space lists)")
+	target := fs.String("target", string(converter.TargetNone), "Static site generator target: none or jekyll")
+	jekyllLayout := fs.String("jekyll-layout", "post", "Layout named in front matter for --target jekyll")
+	imageCaptions := fs.String("image-captions", string(converter.CaptionItalic), "Image caption style: italic, alt, or title")
+	imageSizes := fs.String("image-sizes", string(converter.ImageSizeNone), "Image size hints: none, html (<img width=...>), or suffix (![alt](src =600x))")
+	hardBreaks := fs.String("hard-breaks", string(converter.HardBreakBackslash), "Line break style for <br>: backslash, spaces, newline, or html")
+	listIndent := fs.Int("list-indent", 0, "Spaces per nested list level: 2 or 4 (default: the flavor's, 2)")
+	listNumbering := fs.String("list-numbering", string(converter.ListNumberingSequential), "Ordered list numbering: sequential or lazy (every item \"1.\")")
+	tablesToCSV := fs.String("tables-to-csv", "", "Also write each table to a CSV file in this directory, named after the page and the table's number")
+	extractAttachments := fs.Bool("extract-attachments", false, "Write the images embedded in each export to an "+assetsDir+"/ directory next to the output and link them from the Markdown")
+	csvMaxRows := fs.Int("csv-max-rows", 0, "With --tables-to-csv, replace tables with more than this many rows by a link to their CSV file (0 = keep all tables)")
+	sortTables := fs.String("sort-tables", "", "Sort table rows by a column, given by header name or 1-based number, with an optional :asc or :desc suffix (e.g. \"Status:desc\")")
+	tableHeader := fs.String("table-header", string(converter.TableHeaderInfer), "Header row for tables without one: infer, first-row, or empty")
+	tableCells := fs.String("table-cells", string(converter.TableCellFlatten), "Table cells with lists or several paragraphs: flatten (join with spaces), br (one line per paragraph or list item), html (keep the table as HTML), or extract (move the content below the table as a note)")
+	singleCellTables := fs.String("single-cell-tables", string(converter.SingleCellUnwrap), "Layout tables: unwrap (single-cell tables become their content, empty tables are dropped) or keep")
+	expandDetails := fs.Bool("expand-details", false, "Render expand macros inline under a bold title instead of as <details> elements")
+	maxBlankLines := fs.Int("max-blank-lines", converter.DefaultMaxBlankLines, "Most consecutive blank lines kept in the Markdown")
+	preserveBlockSpacing := fs.Bool("preserve-block-spacing", false, "Keep the blank lines around code and HTML blocks as converted, ignoring --max-blank-lines")
+	nbsp := fs.String("nbsp", string(converter.NBSPKeep), "Non-breaking spaces: keep, space (all become regular spaces), or smart (keep single ones between words, such as \"10 km\")")
+	emoticonFallback := fs.String("emoticon-fallback", string(converter.EmoticonShortname), "Emoticons without a Unicode emoji: shortname (a :name: code), drop, or keep (an image linking to the Confluence server)")
+	attachmentsSection := fs.String("attachments-section", string(converter.AttachmentsKeep), "The \"Attachments:\" appendix of exported pages: keep, remove, or list (plain links, to local copies where available)")
+	baseURL := fs.String("base-url", "", "Confluence base URL used to absolutize server-relative links (e.g. https://confluence.example.com)")
+	template := fs.String("template", "", "Pandoc template for the output format (produces a standalone document)")
+	referenceDoc := fs.String("reference-doc", "", "Reference DOCX whose styles are used for --to docx")
+	maxInputSize := fs.String("max-input-size", "0", "Skip exports larger than this size, e.g. 50MB (0 = no limit)")
+	maxHTMLSize := fs.String("max-html-size", "0", "Skip exports whose extracted HTML is larger than this size, e.g. 20MB (0 = no limit)")
+	maxHeaderSize := fs.String("max-header-size", formatByteSize(converter.DefaultMaxHeaderBytes), "Skip exports whose MIME header block, of the message or of a part, is larger than this size")
+	maxParts := fs.Int("max-parts", converter.DefaultMaxParts, "Skip exports with more MIME parts than this")
+	maxPartSize := fs.String("max-part-size", formatByteSize(converter.DefaultMaxPartBytes), "Skip exports whose decoded HTML part is larger than this size")
+	engine := fs.String("engine", string(converter.EngineAuto), "Pandoc to convert with: auto (embedded, then system), embedded, or system")
+	headingIDs := fs.String("heading-ids", string(converter.HeadingIDsNone), "Keep Confluence heading IDs for deep links: none, attributes ({#id}), or anchors (<a id>)")
+	keepAttributesFlag := fs.String("keep-attributes", "", "Keep these classes and ids as pandoc attributes ({#id .class}): comma-separated .class and #id selectors, * as a wildcard")
+	readerExtensions := fs.String("reader-extensions", "", "Pandoc HTML reader extension toggles, e.g. -native_divs-native_spans or -raw_tex")
+	batchSize := fs.Int("batch-size", 1, "With --dir, convert up to this many pages per pandoc run to save process start-up time in large batches")
+	jobs := fs.Int("jobs", runtime.NumCPU(), "With --dir, convert up to this many files (or --batch-size batches) at once")
+	tempDir := fs.String("temp-dir", "", "Directory for temporary files and the extracted pandoc, e.g. a local disk when exports are on a network share")
+	minimizeTempFiles := fs.Bool("minimize-temp-files", false, "Avoid per-file temporary files where pandoc allows (DOCX is read from pandoc's standard output)")
+	timeout := fs.Duration("timeout", converter.DefaultTimeout, "Per-file conversion time limit, e.g. 30s or 5m")
+	stamp := fs.String("stamp", string(stampNone), "Record source file, tool version, and source SHA-256 in each output: none, comment, or front-matter")
+	prependPath := fs.String("prepend", "", "Markdown file inserted at the top of each output, after front matter; may use {{title}}, {{date}}, {{source}}, {{page_id}}, {{space}}, and {{version}}")
+	appendPath := fs.String("append", "", "Markdown file added to the end of each output; takes the same variables as --prepend")
+	pageTemplatePath := fs.String("page-template", "", "Go template file rendering each output around the converted page, with .Title, .Body, .FrontMatter, .Metadata, .Labels, .SourcePath, and .OutputPath")
+	skipDrafts := fs.Bool("skip-drafts", false, "With --dir, skip exports of draft pages")
+	skipTemplates := fs.Bool("skip-templates", false, "With --dir, skip exports of page templates")
+	skipRestricted := fs.Bool("skip-restricted", false, "Skip pages with view restrictions: with --url they are not downloaded, with --dir exports fetched with --url are not converted")
+	labelFilter := fs.String("label-filter", "", "With --dir, convert only pages with one of these comma-separated labels")
+	titleFilter := fs.String("title-filter", "", "With --dir, convert only pages whose title matches this regular expression")
+	gitCommitFlag := fs.Bool("git-commit", false, "After converting --dir inside a git repository, stage and commit the produced files")
+	gitMessage := fs.String("git-message", defaultGitMessage, "Commit message for --git-commit; may use {{source}}, {{version}}, {{count}}, and {{date}}")
+	redact := fs.Bool("redact", false, "Replace e-mail addresses, IP addresses, and secrets such as API tokens and private keys with placeholders, and apply the redactions rules from --config")
+	sourceLink := fs.String("source-link", string(sourceLinkNone), "Link each output back to its Confluence page (needs --base-url or config link mappings): none, footer, or front-matter")
+	profileName := fs.String("profile", "", "Preset of conversion flags: github, mkdocs-material, minimal-html, or a profile from --config")
+	traceTransforms := fs.Bool("trace-transforms", false, "Log each named conversion step that changed a document, with the size change in bytes, to find the step that mangled a page")
+	configPath := fs.String("config", "", "Path to a JSON config file (link mappings and other advanced settings)")
+	toc := &tocFlag{}
+	checkLinksOpt := &checkModeFlag{}
+	a11yCheckOpt := &checkModeFlag{}
+	fs.Var(a11yCheckOpt, "a11y-check", "Report images without alt text, skipped heading levels, and tables without headers after conversion; --a11y-check=strict also fails the run")
+	fs.Var(checkLinksOpt, "check-links", "Report relative links and images pointing at missing files after conversion; --check-links=strict also fails the run")
+	fs.Var(toc, "toc", "Insert a table of contents; optionally set the heading depth with --toc=N (default 3)")
 
 	fs.Usage = func() {
 		fmt.Fprintf(output, "confluence2md - Convert Confluence MIME exports to Markdown\n\n")
 		fmt.Fprintf(output, "Usage:\n")
 		fmt.Fprintf(output, "  confluence2md [flags] <input.doc>\n")
-		fmt.Fprintf(output, "  confluence2md --dir <directory>\n\n")
+		fmt.Fprintf(output, "  confluence2md --dir <directory>\n")
+		fmt.Fprintf(output, "  confluence2md sync --url <site-url> --space <KEY> --out <directory>\n")
+		fmt.Fprintf(output, "  confluence2md why <file.doc>...\n")
+		fmt.Fprintf(output, "  confluence2md compat [-v]\n\n")
 		fmt.Fprintf(output, "Flags:\n")
 		fs.PrintDefaults()
 		fmt.Fprintf(output, "\nExamples:\n")
@@ -56,12 +312,471 @@ func parseFlags(args []string, output io.Writer) (*config, error) {
 		fmt.Fprintf(output, "  confluence2md document.doc -o output.md       Convert with custom output\n")
 		fmt.Fprintf(output, "  confluence2md --dir ./docs                    Convert all .doc files in directory\n")
 		fmt.Fprintf(output, "  confluence2md --dir ./docs --dry-run          Preview conversions\n")
+		fmt.Fprintf(output, "  confluence2md why document.doc                Explain why a file is or isn't converted\n")
+		fmt.Fprintf(output, "  confluence2md compat                          Score how well Confluence constructs convert\n")
 	}
 
 	if err := fs.Parse(args); err != nil {
 		return nil, err
 	}
 
+	fc := &fileConfig{}
+	if *configPath != "" {
+		loaded, err := loadConfigFile(*configPath)
+		if err != nil {
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+		fc = loaded
+	}
+
+	// Profiles fill in flags not given on the command line
+	if *profileName != "" {
+		p, err := lookupProfile(*profileName, fc.Profiles)
+		if err == nil {
+			err = applyProfile(fs, *profileName, p)
+		}
+		if err != nil {
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+	}
+
+	// Fetch mode saves the fetched pages as exports in the output directory
+	// and converts that as directory mode
+	var fetch *fetchSource
+	if *space != "" && *fetchURL == "" {
+		err := fmt.Errorf("--space requires --url")
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *fetchJobs < 1 {
+		err := fmt.Errorf("invalid value %d for --fetch-jobs (must be at least 1)", *fetchJobs)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if (*fetchJobs != 1 || *fetchComments || *fetchVersions) && *fetchURL == "" {
+		err := fmt.Errorf("--fetch-jobs, --comments, and --version-history require --url")
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if flagCredentials != (credentials{}) && *fetchURL == "" {
+		err := fmt.Errorf("--confluence-user, --confluence-token, --cookie-jar, --header-file, and the --oauth flags require --url")
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	creds := flagCredentials.or(envCredentials()).or(fc.Confluence)
+	if err := creds.validate(); err != nil {
+		err = fmt.Errorf("invalid Confluence credentials: %w", err)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *fetchURL != "" {
+		if *dirMode != "" || fs.NArg() > 0 {
+			err := fmt.Errorf("--url cannot be combined with --dir or an input file")
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+		src, err := newFetchSource(*fetchURL, *space)
+		if err != nil {
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+		fetch = src
+		*dirMode = "."
+		for _, o := range []*string{outputPath, outputLong} {
+			if *o != "" {
+				*dirMode, *o = *o, ""
+			}
+		}
+	}
+
+	if err := validateChoice("to", *to, converter.OutputFormats); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *to != string(converter.FormatMarkdown) && *target != string(converter.TargetNone) {
+		err := fmt.Errorf("--target %s requires --to %s", *target, converter.FormatMarkdown)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *to != string(converter.FormatMarkdown) && *extractAttachments {
+		err := fmt.Errorf("--extract-attachments requires --to %s", converter.FormatMarkdown)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("flavor", *flavor, converter.Flavors); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("target", *target, converter.Targets); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("image-captions", *imageCaptions, converter.CaptionStyles); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("image-sizes", *imageSizes, converter.ImageSizeStyles); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("hard-breaks", *hardBreaks, converter.HardBreakStyles); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("list-numbering", *listNumbering, converter.ListNumberingStyles); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("table-header", *tableHeader, converter.TableHeaderStyles); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	var tableSort converter.TableSort
+	if *sortTables != "" {
+		ts, err := converter.ParseTableSort(*sortTables)
+		if err != nil {
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+		tableSort = ts
+	}
+	if err := validateChoice("table-cells", *tableCells, converter.TableCellStyles); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("single-cell-tables", *singleCellTables, converter.SingleCellTableStyles); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *maxBlankLines < 1 {
+		err := fmt.Errorf("--max-blank-lines must be at least 1, got %d", *maxBlankLines)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if (*maxBlankLines != converter.DefaultMaxBlankLines || *preserveBlockSpacing) && *to != string(converter.FormatMarkdown) {
+		err := fmt.Errorf("--max-blank-lines and --preserve-block-spacing require --to %s", converter.FormatMarkdown)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("nbsp", *nbsp, converter.NBSPStyles); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *nbsp != string(converter.NBSPKeep) && *to != string(converter.FormatMarkdown) {
+		err := fmt.Errorf("--nbsp %s requires --to %s", *nbsp, converter.FormatMarkdown)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("emoticon-fallback", *emoticonFallback, converter.EmoticonFallbacks); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("attachments-section", *attachmentsSection, converter.AttachmentsSectionStyles); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("engine", *engine, converter.Engines); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := converter.ValidateReaderExtensions(*readerExtensions); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("heading-ids", *headingIDs, converter.HeadingIDStyles); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *headingIDs != string(converter.HeadingIDsNone) && *to != string(converter.FormatMarkdown) {
+		err := fmt.Errorf("--heading-ids %s requires --to %s", *headingIDs, converter.FormatMarkdown)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	keepAttributes, err := converter.ParseAttributeSelectors(*keepAttributesFlag)
+	if err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if len(keepAttributes) > 0 && *to != string(converter.FormatMarkdown) {
+		err := fmt.Errorf("--keep-attributes requires --to %s", converter.FormatMarkdown)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("progress-format", *progress, progressFormats); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	var emitter *progressEmitter
+	if *progress == string(progressJSONL) {
+		emitter = newProgressEmitter(os.Stderr)
+	}
+	if err := validateChoice("stamp", *stamp, stampStyles); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *stamp != string(stampNone) && (converter.OutputFormat(*to).IsBinary() || converter.OutputFormat(*to).IsData()) {
+		err := fmt.Errorf("--stamp is not supported with --to %s", *to)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("source-link", *sourceLink, sourceLinkStyles); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *sourceLink != string(sourceLinkNone) && (converter.OutputFormat(*to).IsBinary() || converter.OutputFormat(*to).IsData()) {
+		err := fmt.Errorf("--source-link is not supported with --to %s", *to)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *sourceLink != string(sourceLinkNone) && *baseURL == "" && len(fc.LinkMappings) == 0 {
+		err := fmt.Errorf("--source-link requires --base-url or link mappings in --config")
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *sourceLink == string(sourceLinkFrontMatter) && *to != string(converter.FormatMarkdown) {
+		err := fmt.Errorf("--source-link %s requires --to %s", sourceLinkFrontMatter, converter.FormatMarkdown)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *stamp == string(stampFrontMatter) && *to != string(converter.FormatMarkdown) {
+		err := fmt.Errorf("--stamp %s requires --to %s", stampFrontMatter, converter.FormatMarkdown)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *pageIDs && *to != string(converter.FormatMarkdown) {
+		err := fmt.Errorf("--page-ids requires --to %s", converter.FormatMarkdown)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *detectLanguage && *to != string(converter.FormatMarkdown) {
+		err := fmt.Errorf("--detect-language requires --to %s", converter.FormatMarkdown)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if err := validateChoice("lang", *lang, converter.LabelLanguages); err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	var admonitionLabels map[converter.AdmonitionType]string
+	if *lang != "en" || len(fc.AdmonitionLabels) > 0 {
+		admonitionLabels, _ = converter.LocalizedAdmonitionLabels(*lang)
+		for typ, label := range fc.AdmonitionLabels {
+			admonitionLabels[typ] = label
+		}
+	}
+	if *pageTemplatePath != "" && (converter.OutputFormat(*to).IsBinary() || converter.OutputFormat(*to).IsData()) {
+		err := fmt.Errorf("--page-template is not supported with --to %s", *to)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *pageTemplatePath != "" && *chunk {
+		err := fmt.Errorf("--page-template is not supported with --chunk")
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	pageTemplate, err := loadPageTemplate(*pageTemplatePath)
+	if err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	var prependText, appendText string
+	for _, b := range []struct {
+		name string
+		path string
+		text *string
+	}{{"prepend", *prependPath, &prependText}, {"append", *appendPath, &appendText}} {
+		if b.path == "" {
+			continue
+		}
+		if *to != string(converter.FormatMarkdown) {
+			err := fmt.Errorf("--%s requires --to %s", b.name, converter.FormatMarkdown)
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+		text, err := loadBoilerplate(b.name, b.path)
+		if err != nil {
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+		*b.text = text
+	}
+	filter, err := newPageFilter(*skipDrafts, *skipTemplates, *labelFilter, *titleFilter)
+	if err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	filter.skipRestricted = *skipRestricted
+	if filter.active() && *dirMode == "" {
+		err := fmt.Errorf("--skip-drafts, --skip-templates, --skip-restricted, --label-filter, and --title-filter require --dir or --url")
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *gitCommitFlag && *dirMode == "" {
+		err := fmt.Errorf("--git-commit requires --dir")
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *gitCommitFlag {
+		if err := validateGitMessage(*gitMessage); err != nil {
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+	}
+	var tableCSV *tableCSVWriter
+	if *tablesToCSV != "" {
+		if *to != string(converter.FormatMarkdown) {
+			err := fmt.Errorf("--tables-to-csv requires --to %s", converter.FormatMarkdown)
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+		tableCSV = &tableCSVWriter{dir: *tablesToCSV, maxRows: *csvMaxRows}
+	}
+	if *csvMaxRows < 0 || (*csvMaxRows > 0 && tableCSV == nil) {
+		err := fmt.Errorf("--csv-max-rows requires --tables-to-csv and a positive number of rows")
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	var redaction []converter.RedactionRule
+	if *redact {
+		if converter.OutputFormat(*to).IsBinary() || converter.OutputFormat(*to).IsData() {
+			err := fmt.Errorf("--redact is not supported with --to %s", *to)
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+		rules, err := converter.RedactionRules(fc.Redactions)
+		if err != nil {
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+		redaction = rules
+	}
+	var altText *altTextCommand
+	if *altTextCmd != "" {
+		if *to != string(converter.FormatMarkdown) {
+			err := fmt.Errorf("--alt-text-command requires --to %s", converter.FormatMarkdown)
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+		cmd, err := newAltTextCommand(*altTextCmd, *timeout)
+		if err != nil {
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+		altText = cmd
+	}
+	if *chunk && *to != string(converter.FormatMarkdown) {
+		err := fmt.Errorf("--chunk requires --to %s", converter.FormatMarkdown)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *chunk && checkLinksOpt.mode != checkOff {
+		err := fmt.Errorf("--check-links is not supported with --chunk")
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *maxTokens <= 0 {
+		err := fmt.Errorf("invalid value %d for --max-tokens (must be positive)", *maxTokens)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *chunk && a11yCheckOpt.mode != checkOff {
+		err := fmt.Errorf("--a11y-check is not supported with --chunk")
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if a11yCheckOpt.mode != checkOff && *to != string(converter.FormatMarkdown) {
+		err := fmt.Errorf("--a11y-check requires --to %s", converter.FormatMarkdown)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if checkLinksOpt.mode != checkOff && *to != string(converter.FormatMarkdown) {
+		err := fmt.Errorf("--check-links requires --to %s", converter.FormatMarkdown)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *listIndent != 0 && *listIndent != 2 && *listIndent != 4 {
+		err := fmt.Errorf("invalid value %d for --list-indent (valid: 2, 4)", *listIndent)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+
+	inputLimit, err := parseByteSize(*maxInputSize)
+	if err != nil {
+		err = fmt.Errorf("--max-input-size: %w", err)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	htmlLimit, err := parseByteSize(*maxHTMLSize)
+	if err != nil {
+		err = fmt.Errorf("--max-html-size: %w", err)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	headerLimit, err := parsePositiveByteSize("--max-header-size", *maxHeaderSize)
+	if err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	partLimit, err := parsePositiveByteSize("--max-part-size", *maxPartSize)
+	if err != nil {
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *maxParts <= 0 {
+		err := fmt.Errorf("invalid value %d for --max-parts (must be positive)", *maxParts)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *timeout <= 0 {
+		err := fmt.Errorf("invalid value %s for --timeout (must be positive)", *timeout)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *batchSize < 1 {
+		err := fmt.Errorf("invalid value %d for --batch-size (must be at least 1)", *batchSize)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *jobs < 1 {
+		err := fmt.Errorf("invalid value %d for --jobs (must be at least 1)", *jobs)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	if *tempDir != "" {
+		if info, err := os.Stat(*tempDir); err != nil || !info.IsDir() {
+			err := fmt.Errorf("--temp-dir %s is not a directory", *tempDir)
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+	}
+
+	// Template flags override the config file
+	templateText := fc.TemplateText
+	templatePath := fc.Template
+	if *template != "" {
+		templatePath, templateText = *template, ""
+	}
+	refDoc := fc.ReferenceDoc
+	if *referenceDoc != "" {
+		refDoc = *referenceDoc
+	}
+	for _, p := range []string{*template, *referenceDoc} {
+		if p == "" {
+			continue
+		}
+		if err := checkFileExists(p); err != nil {
+			fmt.Fprintf(output, "Error: %v\n", err)
+			return nil, err
+		}
+	}
+	if *referenceDoc != "" && *to != string(converter.FormatDOCX) {
+		err := fmt.Errorf("--reference-doc requires --to %s", converter.FormatDOCX)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+
 	// Merge short and long flag variants
 	outPath := *outputPath
 	if *outputLong != "" && outPath == "" {
@@ -70,15 +785,105 @@ func parseFlags(args []string, output io.Writer) (*config, error) {
 	isVerbose := *verbose || *verboseLong
 
 	return &config{
-		outputPath:  outPath,
-		dirMode:     *dirMode,
-		verbose:     isVerbose,
-		dryRun:      *dryRun,
-		showVersion: *showVersion,
-		args:        fs.Args(),
+		outputPath:      outPath,
+		dirMode:         *dirMode,
+		fetch:           fetch,
+		fetchJobs:       *fetchJobs,
+		fetchComments:   *fetchComments,
+		fetchVersions:   *fetchVersions,
+		credentials:     creds,
+		verbose:         isVerbose,
+		dryRun:          *dryRun,
+		showVersion:     *showVersion,
+		args:            fs.Args(),
+		jekyllLayout:    *jekyllLayout,
+		gitbookSummary:  *gitbookSummary,
+		sitemap:         *sitemapJSON,
+		summaryPath:     *summaryPath,
+		searchIndex:     *searchIndex,
+		altText:         altText,
+		prependText:     prependText,
+		appendText:      appendText,
+		pageTemplate:    pageTemplate,
+		redaction:       redaction,
+		filter:          filter,
+		routes:          fc.Routes,
+		tree:            *tree,
+		tableCSV:        tableCSV,
+		extractAssets:   *extractAttachments,
+		gitCommit:       *gitCommitFlag,
+		gitMessage:      *gitMessage,
+		redactions:      newRedactionLog(),
+		chunk:           *chunk,
+		maxTokens:       *maxTokens,
+		report:          *report,
+		checkLinks:      checkLinksOpt.mode,
+		a11yCheck:       a11yCheckOpt.mode,
+		progress:        emitter,
+		stamp:           stampStyle(*stamp),
+		sourceLink:      sourceLinkStyle(*sourceLink),
+		maxInputSize:    inputLimit,
+		maxHTMLSize:     htmlLimit,
+		mimeLimits:      converter.MIMELimits{MaxHeaderBytes: int(headerLimit), MaxParts: *maxParts, MaxPartBytes: partLimit},
+		traceTransforms: *traceTransforms,
+		tempDir:         *tempDir,
+		batchSize:       *batchSize,
+		jobs:            *jobs,
+		options: converter.Options{
+			Flavor:                 converter.Flavor(*flavor),
+			Target:                 converter.Target(*target),
+			NormalizeHeadingLevels: *normalizeHeadingLevels,
+			NumberHeadings:         *numberHeadings,
+			TOCDepth:               toc.depth,
+			ImageCaptions:          converter.CaptionStyle(*imageCaptions),
+			ImageSizes:             converter.ImageSizeStyle(*imageSizes),
+			HardBreaks:             converter.HardBreakStyle(*hardBreaks),
+			ListIndent:             *listIndent,
+			ListNumbering:          converter.ListNumberingStyle(*listNumbering),
+			TableHeaders:           converter.TableHeaderStyle(*tableHeader),
+			TableSort:              tableSort,
+			SingleCellTables:       converter.SingleCellTableStyle(*singleCellTables),
+			TableCells:             converter.TableCellStyle(*tableCells),
+			BlankLines:             converter.BlankLines{Max: *maxBlankLines, PreserveAroundBlocks: *preserveBlockSpacing},
+			NBSP:                   converter.NBSPStyle(*nbsp),
+			EmoticonFallback:       converter.EmoticonFallback(*emoticonFallback),
+			BaseURL:                *baseURL,
+			LinkMappings:           fc.LinkMappings,
+			PanelColors:            fc.PanelColors,
+			AdmonitionLabels:       admonitionLabels,
+			Emoticons:              fc.Emoticons,
+			Replacements:           fc.Replacements,
+			Pipeline:               fc.pipeline,
+			To:                     converter.OutputFormat(*to),
+			Template:               templatePath,
+			TemplateText:           templateText,
+			ReferenceDoc:           refDoc,
+			Timeout:                *timeout,
+			Engine:                 converter.Engine(*engine),
+			ReaderExtensions:       *readerExtensions,
+			KeepAttributes:         keepAttributes,
+			HeadingIDs:             converter.HeadingIDStyle(*headingIDs),
+			MinimizeTempFiles:      *minimizeTempFiles,
+			PageIDs:                *pageIDs,
+			DetectLanguage:         *detectLanguage,
+			AttachmentsSection:     converter.AttachmentsSectionStyle(*attachmentsSection),
+			ExpandDetails:          *expandDetails,
+		},
 	}, nil
 }
 
+// validateChoice returns an error if value is not one of the allowed choices for a flag.
+func validateChoice[T ~string](flagName, value string, choices []T) error {
+	names := make([]string, len(choices))
+	for i, choice := range choices {
+		if string(choice) == value {
+			return nil
+		}
+		names[i] = string(choice)
+	}
+	return fmt.Errorf("invalid value %q for --%s (valid: %s)", value, flagName, strings.Join(names, ", "))
+}
+
 // run executes the main logic and returns an exit code.
 // This function is testable as it doesn't call os.Exit directly.
 func run(cfg *config) int {
@@ -92,16 +897,58 @@ func run(cfg *config) int {
 		return 0
 	}
 
-	// Check pandoc availability
-	if err := converter.CheckPandoc(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	// Summary mode reads pages without converting them, so needs no pandoc
+	if cfg.summaryPath != "" {
+		if err := runSummary(cfg); err != nil {
+			cfg.reportError(err)
+			return 1
+		}
+		return 0
+	}
+
+	if cfg.tempDir != "" {
+		converter.SetTempDir(cfg.tempDir)
+	}
+
+	// Check pandoc availability, pinning the engine for every file
+	engine, err := converter.ResolveEngine(cfg.options.Engine)
+	if err != nil {
+		cfg.reportError(err)
 		return 1
 	}
+	cfg.options.Engine = engine
+	if err := converter.CheckPandocCapabilities(cfg.options); err != nil {
+		cfg.reportError(err)
+		return 1
+	}
+	if cfg.options.To == converter.FormatPDF && !cfg.dryRun {
+		if _, err := converter.FindPDFEngine(); err != nil {
+			cfg.reportError(err)
+			return 1
+		}
+	}
+
+	// Sync mode fetches and converts what changed since the last sync
+	if cfg.fetch != nil && cfg.fetch.sync {
+		if err := syncDirectory(cfg.fetch, cfg.dirMode, cfg); err != nil {
+			cfg.reportError(err)
+			return 1
+		}
+		return 0
+	}
+
+	// Fetch mode downloads the pages into the directory converted below
+	if cfg.fetch != nil {
+		if err := fetchExports(cfg.fetch, cfg.dirMode, cfg); err != nil {
+			cfg.reportError(err)
+			return 1
+		}
+	}
 
 	// Directory mode
 	if cfg.dirMode != "" {
-		if err := convertDirectory(cfg.dirMode, cfg.verbose, cfg.dryRun); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if err := convertDirectory(cfg.dirMode, cfg); err != nil {
+			cfg.reportError(err)
 			return 1
 		}
 		if !cfg.dryRun {
@@ -115,7 +962,9 @@ func run(cfg *config) int {
 		fmt.Fprintf(os.Stderr, "confluence2md - Convert Confluence MIME exports to Markdown\n\n")
 		fmt.Fprintf(os.Stderr, "Usage:\n")
 		fmt.Fprintf(os.Stderr, "  confluence2md [flags] <input.doc>\n")
-		fmt.Fprintf(os.Stderr, "  confluence2md --dir <directory>\n\n")
+		fmt.Fprintf(os.Stderr, "  confluence2md --dir <directory>\n")
+		fmt.Fprintf(os.Stderr, "  confluence2md --url <page-url> [-o <directory>]\n")
+		fmt.Fprintf(os.Stderr, "  confluence2md sync --url <site-url> --space <KEY> --out <directory>\n\n")
 		fmt.Fprintf(os.Stderr, "Run 'confluence2md --help' for more information.\n")
 		return 1
 	}
@@ -123,13 +972,31 @@ func run(cfg *config) int {
 	inputPath := cfg.args[0]
 	output := cfg.outputPath
 	if output == "" {
-		output = generateOutputPath(inputPath)
+		output = outputPathFor(inputPath, cfg)
 	}
 
-	if err := convertFile(inputPath, output, cfg.verbose, cfg.dryRun); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if err := convertFile(inputPath, output, cfg); err != nil {
+		cfg.reportError(err)
 		return 1
 	}
+	if cfg.checkLinks != checkOff && !cfg.dryRun {
+		if err := checkLinks([]convertedPage{{outputPath: output}}, cfg.checkLinks, cfg.messages()); err != nil {
+			cfg.reportError(err)
+			return 1
+		}
+	}
+	if cfg.a11yCheck != checkOff && !cfg.dryRun {
+		if err := checkAccessibility([]convertedPage{{outputPath: output}}, cfg.a11yCheck, cfg.messages()); err != nil {
+			cfg.reportError(err)
+			return 1
+		}
+	}
+	if cfg.searchIndex != "" && !cfg.dryRun {
+		if err := writeSearchIndex(cfg.searchIndex, []convertedPage{newConvertedPage(inputPath, output)}); err != nil {
+			cfg.reportError(err)
+			return 1
+		}
+	}
 	if !cfg.dryRun {
 		printStarPrompt()
 	}
@@ -137,6 +1004,20 @@ func run(cfg *config) int {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == whyCommand {
+		os.Exit(runWhy(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == compatCommand {
+		os.Exit(runCompat(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == syncCommand {
+		cfg, err := parseSyncFlags(os.Args[2:], os.Stderr)
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Exit(run(cfg))
+	}
+
 	cfg, err := parseFlags(os.Args[1:], os.Stderr)
 	if err != nil {
 		os.Exit(1)
@@ -144,112 +1025,528 @@ func main() {
 	os.Exit(run(cfg))
 }
 
-// convertDirectory converts all .doc files in a directory.
-func convertDirectory(dir string, verbose, dryRun bool) error {
-	pattern := filepath.Join(dir, "*.doc")
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		return fmt.Errorf("failed to glob directory: %w", err)
+// convertDirectory converts all .doc and .xhtml files in a directory, and
+// with --url those in its blog post subdirectory too.
+func convertDirectory(dir string, cfg *config) error {
+	verbose := cfg.verbose
+	matches := cfg.inputs
+	if matches == nil {
+		patterns := []string{filepath.Join(dir, "*.doc"), filepath.Join(dir, "*"+converter.StorageExtension)}
+		if cfg.fetch != nil {
+			patterns = append(patterns, filepath.Join(dir, fetchBlogDir, "*.doc"))
+		}
+		for _, pattern := range patterns {
+			found, err := filepath.Glob(pattern)
+			if err != nil {
+				return fmt.Errorf("failed to glob directory: %w", err)
+			}
+			matches = append(matches, found...)
+		}
+		sort.Strings(matches)
 	}
 
 	if len(matches) == 0 {
-		fmt.Println("No .doc files found in directory")
+		fmt.Println("No .doc or .xhtml files found in directory")
 		return nil
 	}
 
+	report := newMigrationReport(dir)
+
 	// Filter to only Confluence MIME files
 	var confluenceFiles []string
 	for _, match := range matches {
-		isConfluence, err := converter.IsConfluenceMIME(match)
+		isConfluence, err := isConfluencePage(match)
 		if err != nil {
 			if verbose {
 				fmt.Printf("Skipping (error reading file): %s: %v\n", match, err)
 			}
+			report.addIssue(match, issueUnreadable, err)
+			cfg.progress.warning(match, fmt.Sprintf("skipped: %v", err))
 			continue
 		}
 		if isConfluence {
 			confluenceFiles = append(confluenceFiles, match)
-		} else if verbose {
-			fmt.Printf("Skipping (not Confluence MIME): %s\n", match)
+		} else {
+			if verbose {
+				fmt.Printf("Skipping (not Confluence MIME): %s\n", match)
+			}
+			report.addIssue(match, issueNotConfluence, errors.New("missing Confluence MIME headers"))
+			cfg.progress.warning(match, "skipped: not a Confluence MIME export")
 		}
 	}
 
+	found := len(confluenceFiles)
+	confluenceFiles = filterExports(confluenceFiles, cfg, report)
+
 	if len(confluenceFiles) == 0 {
-		fmt.Println("No Confluence MIME exports found in directory")
+		if found > 0 {
+			fmt.Printf("All %d Confluence export(s) were filtered out\n", found)
+		} else {
+			fmt.Println("No Confluence MIME exports found in directory")
+		}
+		if cfg.report && !cfg.dryRun {
+			return report.write(dir)
+		}
 		return nil
 	}
 
 	fmt.Printf("Found %d Confluence export(s) to convert\n", len(confluenceFiles))
+	cfg.progress.batchStarted(dir, len(confluenceFiles))
+
+	var converted []convertedPage
+	var skipped []string
+	batchSize := 1
+	if cfg.batchSize > 1 && !cfg.options.To.IsBinary() {
+		batchSize = cfg.batchSize
+	}
+	var batches [][]string
+	for start := 0; start < len(confluenceFiles); start += batchSize {
+		batches = append(batches, confluenceFiles[start:min(start+batchSize, len(confluenceFiles))])
+	}
+	outputPaths := make([][]string, len(batches))
+	for b, inputPaths := range batches {
+		outputPaths[b] = make([]string, len(inputPaths))
+		for i, inputPath := range inputPaths {
+			outputPaths[b][i] = outputPathFor(inputPath, cfg)
+		}
+	}
 
-	successCount := 0
-	for _, inputPath := range confluenceFiles {
-		outputPath := generateOutputPath(inputPath)
-		if err := convertFile(inputPath, outputPath, verbose, dryRun); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to convert %s: %v\n", inputPath, err)
+	// Convert the batches concurrently, then report their results in
+	// order so that the summary and migration report are deterministic
+	errs := make([][]error, len(batches))
+	output := newOrderedOutput(len(batches), cfg)
+	runJobs(len(batches), cfg.jobs, func(b int) {
+		jobCfg := cfg
+		if cfg.jobs > 1 {
+			jobCfg = output.config(b)
+			defer output.done(b)
+		}
+		if len(batches[b]) == 1 {
+			errs[b] = []error{convertFile(batches[b][0], outputPaths[b][0], jobCfg)}
 		} else {
-			successCount++
+			errs[b] = convertBatch(batches[b], outputPaths[b], jobCfg)
+		}
+	})
+
+	for b, inputPaths := range batches {
+		for i, inputPath := range inputPaths {
+			outputPath := outputPaths[b][i]
+			if err := errs[b][i]; err != nil {
+				report.addIssue(inputPath, conversionIssueCategory(err), err)
+				if isSkippable(err) {
+					fmt.Fprintf(cfg.messages(), "Warning: skipped %s: %v\n", inputPath, err)
+					skipped = append(skipped, fmt.Sprintf("%s: %v", inputPath, err))
+				} else {
+					fmt.Fprintf(cfg.messages(), "Warning: failed to convert %s: %v\n", inputPath, err)
+				}
+			} else {
+				if cfg.onConverted != nil {
+					cfg.onConverted(inputPath)
+				}
+				page := newConvertedPage(inputPath, outputPath)
+				page.redactions = cfg.redactions.get(outputPath)
+				converted = append(converted, page)
+				if cfg.report && !cfg.dryRun {
+					page.stats = readPageStats(inputPath, cfg.mimeLimits)
+					report.addPage(page, cfg.options.To == converter.FormatMarkdown && !cfg.chunk)
+				}
+			}
 		}
 	}
 
-	fmt.Printf("\nConverted %d/%d files\n", successCount, len(confluenceFiles))
-	return nil
+	fmt.Printf("\nConverted %d/%d files\n", len(converted), len(confluenceFiles))
+	cfg.progress.batchDone(dir, len(confluenceFiles), len(converted))
+	if len(skipped) > 0 {
+		fmt.Printf("Skipped %d file(s) exceeding per-file limits:\n", len(skipped))
+		for _, s := range skipped {
+			fmt.Printf("  %s\n", s)
+		}
+	}
+
+	var checkErr error
+	if cfg.checkLinks != checkOff && !cfg.dryRun {
+		linkErr := checkLinks(converted, cfg.checkLinks, cfg.messages())
+		if linkErr != nil && !errors.Is(linkErr, errBrokenLinks) {
+			return linkErr
+		}
+		checkErr = linkErr
+	}
+	if cfg.a11yCheck != checkOff && !cfg.dryRun {
+		a11yErr := checkAccessibility(converted, cfg.a11yCheck, cfg.messages())
+		if a11yErr != nil && !errors.Is(a11yErr, errA11yIssues) {
+			return a11yErr
+		}
+		checkErr = errors.Join(checkErr, a11yErr)
+	}
+
+	// written lists the files produced, for --git-commit
+	written := make([]string, 0, len(converted))
+	for _, page := range converted {
+		written = append(written, page.outputPath)
+	}
+
+	if cfg.report && !cfg.dryRun {
+		if err := report.write(dir); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s and %s\n", filepath.Join(dir, migrationReportFile), filepath.Join(dir, migrationReportJSONFile))
+		written = append(written, filepath.Join(dir, migrationReportFile), filepath.Join(dir, migrationReportJSONFile))
+	}
+
+	if cfg.gitbookSummary && !cfg.dryRun && len(converted) > 0 {
+		if err := writeGitBookSummary(dir, converted); err != nil {
+			return err
+		}
+		written = append(written, filepath.Join(dir, gitbookSummaryFile))
+		if verbose {
+			fmt.Printf("Wrote %s\n", filepath.Join(dir, gitbookSummaryFile))
+		}
+	}
+
+	if cfg.sitemap && !cfg.dryRun && len(converted) > 0 {
+		if err := writeSitemap(dir, converted); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\n", filepath.Join(dir, sitemapFile))
+		written = append(written, filepath.Join(dir, sitemapFile))
+	}
+
+	if cfg.searchIndex != "" && !cfg.dryRun && len(converted) > 0 {
+		if err := writeSearchIndex(cfg.searchIndex, converted); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\n", cfg.searchIndex)
+		written = append(written, cfg.searchIndex)
+	}
+
+	if cfg.gitCommit && len(converted) > 0 {
+		message := renderGitMessage(cfg.gitMessage, dir, len(converted), time.Now())
+		switch {
+		case cfg.dryRun:
+			fmt.Printf("[dry-run] Would commit converted files: %s\n", message)
+		case checkErr != nil:
+			fmt.Fprintf(cfg.messages(), "Warning: not committing: checks failed\n")
+		default:
+			committed, err := gitCommitFiles(dir, written, message)
+			if err != nil {
+				return err
+			}
+			if committed {
+				fmt.Printf("Committed %d file(s): %s\n", len(written), message)
+			} else {
+				fmt.Println("No changes to commit")
+			}
+		}
+	}
+	return checkErr
+}
+
+// convertFile converts a single file, reporting its progress events.
+func convertFile(inputPath, outputPath string, cfg *config) error {
+	started := time.Now()
+	cfg.progress.fileStarted(inputPath)
+
+	err := convertFileStages(inputPath, outputPath, cfg)
+	fileDone(inputPath, outputPath, err, started, cfg)
+	return err
 }
 
-// convertFile converts a single file.
-func convertFile(inputPath, outputPath string, verbose, dryRun bool) error {
+// fileDone reports the progress event for a file whose conversion started
+// at started and ended with err.
+func fileDone(inputPath, outputPath string, err error, started time.Time, cfg *config) {
+	status, output := statusConverted, outputPath
+	switch {
+	case err != nil && isSkippable(err):
+		status, output = statusSkipped, ""
+	case err != nil:
+		status, output = statusFailed, ""
+	case cfg.dryRun:
+		status = statusDryRun
+	}
+	cfg.progress.fileDone(inputPath, status, output, err, started)
+}
+
+// convertFileStages extracts, converts, and writes a single file.
+func convertFileStages(inputPath, outputPath string, cfg *config) error {
+	job, err := prepareFile(inputPath, outputPath, cfg)
+	if err != nil || job == nil {
+		return err
+	}
+
+	stageStarted := time.Now()
+	content, err := job.convert(cfg)
+	if err != nil {
+		return err
+	}
+	cfg.progress.stageCompleted(inputPath, stageConvert, stageStarted)
+
+	return job.write(content, cfg)
+}
+
+// fileJob is an export prepared for conversion: its extracted HTML and the
+// options converting it, with what is added to the output afterwards.
+type fileJob struct {
+	inputPath  string
+	outputPath string
+	html       string
+	opts       converter.Options
+
+	// prov is the provenance recorded by --stamp comment
+	prov provenance
+	// pageURL is the Confluence page linked by --source-link footer
+	pageURL string
+}
+
+// isConfluencePage reports whether the file at path is a Confluence MIME
+// export or a storage-format page.
+func isConfluencePage(path string) (bool, error) {
+	ok, err := converter.IsConfluenceMIME(path)
+	if err != nil || ok {
+		return ok, err
+	}
+	return converter.IsStorageFile(path)
+}
+
+// prepareFile checks and extracts a single file and builds its conversion
+// options. It returns a nil job in dry-run mode.
+func prepareFile(inputPath, outputPath string, cfg *config) (*fileJob, error) {
+	verbose := cfg.verbose
 	if verbose {
-		fmt.Printf("Converting: %s -> %s\n", inputPath, outputPath)
+		fmt.Fprintf(cfg.out(), "Converting: %s -> %s\n", inputPath, outputPath)
 	}
 
-	if dryRun {
-		fmt.Printf("[dry-run] Would convert: %s -> %s\n", inputPath, outputPath)
-		return nil
+	if cfg.dryRun {
+		fmt.Fprintf(cfg.out(), "[dry-run] Would convert: %s -> %s\n", inputPath, outputPath)
+		return nil, nil
 	}
 
 	// Check if input file exists
-	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
-		return fmt.Errorf("input file does not exist: %s", inputPath)
+	info, err := os.Stat(inputPath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("input file does not exist: %s", inputPath)
+	}
+	if err == nil {
+		if err := checkSizeLimit("input", info.Size(), cfg.maxInputSize); err != nil {
+			return nil, err
+		}
 	}
 
-	// Verify it's a Confluence MIME export
-	isConfluence, err := converter.IsConfluenceMIME(inputPath)
+	// Verify it's a Confluence MIME export or storage-format page
+	isConfluence, err := isConfluencePage(inputPath)
 	if err != nil {
-		return fmt.Errorf("failed to check file format: %w", err)
+		return nil, fmt.Errorf("failed to check file format: %w", err)
 	}
 	if !isConfluence {
-		return fmt.Errorf("file does not appear to be a Confluence MIME export: %s", inputPath)
+		return nil, fmt.Errorf("file does not appear to be a Confluence MIME export: %s (run 'confluence2md why %s' for details)", inputPath, inputPath)
 	}
 
 	// Extract HTML from MIME
 	if verbose {
-		fmt.Println("  Extracting HTML from MIME...")
+		fmt.Fprintln(cfg.out(), "  Extracting HTML from MIME...")
 	}
-	html, err := converter.ExtractHTMLFromMIME(inputPath)
+	stageStarted := time.Now()
+	html, err := converter.ReadPageHTML(inputPath, cfg.mimeLimits)
 	if err != nil {
-		return fmt.Errorf("failed to extract HTML: %w", err)
+		return nil, fmt.Errorf("failed to extract HTML: %w", err)
+	}
+	cfg.progress.stageCompleted(inputPath, stageExtract, stageStarted)
+	if err := checkSizeLimit("extracted HTML", int64(len(html)), cfg.maxHTMLSize); err != nil {
+		return nil, err
+	}
+	if charset := converter.UndecodedCharset(html); charset != "" {
+		msg := fmt.Sprintf("charset %s is not supported; converting the HTML undecoded", charset)
+		fmt.Fprintf(cfg.messages(), "Warning: %s: %s\n", inputPath, msg)
+		cfg.progress.warning(inputPath, msg)
+	}
+	html, removed := converter.SanitizeControlChars(html)
+	if len(removed) > 0 {
+		msg := "removed control characters: " + formatRemovedChars(removed)
+		fmt.Fprintf(cfg.messages(), "Warning: %s: %s\n", inputPath, msg)
+		cfg.progress.warning(inputPath, msg)
 	}
-
-	// Convert to Markdown
 	if verbose {
-		fmt.Println("  Converting HTML to Markdown...")
+		fmt.Fprintf(cfg.out(), "  Stats: %s\n", converter.ComputeStats(html))
 	}
-	markdown, err := converter.ConvertHTMLToMarkdown(html)
+
+	job := &fileJob{inputPath: inputPath, outputPath: outputPath, html: html}
+	opts := cfg.options
+	opts.Warn = func(msg string) {
+		fmt.Fprintf(cfg.messages(), "Warning: %s: %s\n", inputPath, msg)
+		cfg.progress.warning(inputPath, msg)
+	}
+	if cfg.traceTransforms {
+		opts.Trace = func(step converter.Transform, before, after int) {
+			fmt.Fprintf(cfg.messages(), "Trace: %s: %s\n", inputPath, formatTransformTrace(step, before, after))
+		}
+	}
+	if opts.To.IsBinary() {
+		job.opts = opts
+		return job, nil
+	}
+
+	if opts.Target == converter.TargetJekyll {
+		opts.FrontMatter = append(jekyllFrontMatter(inputPath, cfg.jekyllLayout), opts.FrontMatter...)
+	}
+	if cfg.stamp == stampComment || cfg.stamp == stampFrontMatter {
+		if job.prov, err = readProvenance(inputPath); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.stamp == stampFrontMatter {
+		// Copy so the shared options' backing array is never written
+		fields := append([]converter.FrontMatterField{}, opts.FrontMatter...)
+		opts.FrontMatter = append(fields, job.prov.frontMatter()...)
+	}
+	if cfg.sourceLink != sourceLinkNone {
+		meta, _ := converter.ReadExportMetadata(inputPath)
+		job.pageURL = sourcePageURL(opts, converter.ExtractPageInfo(html), pageTitle(inputPath, meta))
+		if job.pageURL == "" {
+			fmt.Fprintf(cfg.messages(), "Warning: no source link for %s: the export names no page ID or space\n", inputPath)
+			cfg.progress.warning(inputPath, "no source link: the export names no page ID or space")
+		}
+	}
+	if cfg.sourceLink == sourceLinkFrontMatter && job.pageURL != "" {
+		fields := append([]converter.FrontMatterField{}, opts.FrontMatter...)
+		opts.FrontMatter = append(fields, converter.FrontMatterField{Key: "confluence_url", Value: job.pageURL})
+	}
+	if opts.To == converter.FormatMarkdown {
+		// Exports fetched with --url record when a blog post was published,
+		// and the page's view restrictions, which publishing the output must
+		// not silently drop
+		info := converter.ExtractPageInfo(html)
+		var fields []converter.FrontMatterField
+		if opts.Target != converter.TargetJekyll {
+			// Jekyll posts have their date already
+			fields = info.BlogFrontMatter()
+		}
+		if fields = append(fields, info.RestrictionFrontMatter()...); len(fields) > 0 {
+			opts.FrontMatter = append(append([]converter.FrontMatterField{}, opts.FrontMatter...), fields...)
+		}
+	}
+	if cfg.prependText != "" || cfg.appendText != "" {
+		values := boilerplateValues(inputPath, html, time.Now())
+		opts.Prepend = renderBoilerplate(cfg.prependText, values)
+		opts.Append = renderBoilerplate(cfg.appendText, values)
+	}
+	if cfg.tableCSV != nil {
+		opts.Tables = func(index int, rows [][]string) string {
+			if cfg.redaction != nil {
+				// The CSV files are published alongside the page
+				for _, row := range rows {
+					for i := range row {
+						row[i], _, _ = converter.Redact(row[i], cfg.redaction)
+					}
+				}
+			}
+			link, err := cfg.tableCSV.write(outputPath, index, rows)
+			if err != nil {
+				fmt.Fprintf(cfg.messages(), "Warning: %v\n", err)
+				cfg.progress.warning(inputPath, err.Error())
+			}
+			return link
+		}
+	}
+	if cfg.extractAssets {
+		dir := filepath.Dir(outputPath)
+		attachments, err := converter.ExtractAttachmentsWithLimits(inputPath, filepath.Join(dir, assetsDir), cfg.mimeLimits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract attachments: %w", err)
+		}
+		if verbose {
+			fmt.Fprintf(cfg.out(), "  Extracted %d attachment(s) to %s\n", len(attachments), filepath.Join(dir, assetsDir))
+		}
+		opts.ImagePaths = converter.ImageLinks(attachments, dir)
+	}
+	if cfg.altText != nil {
+		dir := filepath.Dir(outputPath)
+		opts.AltText = func(src string) string {
+			alt, err := cfg.altText.describe(src, dir)
+			if err != nil {
+				fmt.Fprintf(cfg.messages(), "Warning: %v\n", err)
+				cfg.progress.warning(inputPath, err.Error())
+			}
+			return alt
+		}
+	}
+	job.opts = opts
+	return job, nil
+}
+
+// convert converts the job's HTML to its output format.
+func (job *fileJob) convert(cfg *config) ([]byte, error) {
+	opts := job.opts
+	if opts.To.IsBinary() {
+		if cfg.verbose {
+			fmt.Fprintf(cfg.out(), "  Converting HTML to %s with %s...\n", strings.ToUpper(string(opts.To)), opts.Engine.Describe())
+		}
+		content, err := converter.ConvertHTMLToDocument(job.html, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert to %s: %w", strings.ToUpper(string(opts.To)), err)
+		}
+		return content, nil
+	}
+
+	if cfg.verbose {
+		fmt.Fprintf(cfg.out(), "  Converting HTML to Markdown with %s...\n", opts.Engine.Describe())
+	}
+	markdown, err := converter.ConvertHTMLToMarkdownWithOptions(job.html, opts)
 	if err != nil {
-		return fmt.Errorf("failed to convert to Markdown: %w", err)
+		return nil, fmt.Errorf("failed to convert to Markdown: %w", err)
+	}
+	return job.finishMarkdown(markdown, cfg)
+}
+
+// finishMarkdown adds the source link footer and stamp comment to converted
+// text output, renders it through the page template, redacts it, and chunks
+// it when asked to.
+func (job *fileJob) finishMarkdown(markdown string, cfg *config) ([]byte, error) {
+	var err error
+	if cfg.sourceLink == sourceLinkFooter && job.pageURL != "" {
+		markdown += "\n" + sourceLinkLine(job.pageURL, job.opts.To)
+	}
+	if cfg.stamp == stampComment {
+		markdown += "\n" + job.prov.comment(job.opts.To)
+	}
+	if cfg.pageTemplate != nil {
+		if markdown, err = renderPageTemplate(cfg.pageTemplate, job, markdown, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.redaction != nil {
+		var found []converter.Redaction
+		if markdown, found, err = converter.Redact(markdown, cfg.redaction); err != nil {
+			return nil, err
+		}
+		cfg.redactions.record(job.outputPath, found)
+		if len(found) > 0 && cfg.verbose {
+			fmt.Fprintf(cfg.out(), "  Redacted %s\n", formatRedactions(found))
+		}
+	}
+	if cfg.chunk {
+		meta, _ := converter.ReadExportMetadata(job.inputPath)
+		return renderChunks(markdown, job.inputPath, pageTitle(job.inputPath, meta), converter.ExtractPageInfo(job.html), cfg.maxTokens)
 	}
+	return []byte(markdown), nil
+}
 
-	// Write output
-	if verbose {
-		fmt.Println("  Writing output...")
+// write writes the converted content to the job's output path.
+func (job *fileJob) write(content []byte, cfg *config) error {
+	if cfg.verbose {
+		fmt.Fprintln(cfg.out(), "  Writing output...")
+	}
+	stageStarted := time.Now()
+	if err := os.MkdirAll(filepath.Dir(job.outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
 	}
-	if err := os.WriteFile(outputPath, []byte(markdown), 0644); err != nil {
+	if err := os.WriteFile(job.outputPath, content, 0644); err != nil {
 		return fmt.Errorf("failed to write output: %w", err)
 	}
+	cfg.progress.stageCompleted(job.inputPath, stageWrite, stageStarted)
 
-	if !verbose {
-		fmt.Printf("Converted: %s -> %s\n", filepath.Base(inputPath), filepath.Base(outputPath))
+	if !cfg.verbose {
+		fmt.Fprintf(cfg.out(), "Converted: %s -> %s\n", filepath.Base(job.inputPath), filepath.Base(job.outputPath))
 	} else {
-		fmt.Printf("  Done: %s\n", outputPath)
+		fmt.Fprintf(cfg.out(), "  Done: %s\n", job.outputPath)
 	}
 
 	return nil
@@ -261,8 +1558,8 @@ func generateOutputPath(inputPath string) string {
 	dir := filepath.Dir(inputPath)
 	base := filepath.Base(inputPath)
 
-	// Remove .doc extension
-	name := strings.TrimSuffix(base, ".doc")
+	// Remove .doc or .xhtml extension
+	name := strings.TrimSuffix(strings.TrimSuffix(base, ".doc"), converter.StorageExtension)
 
 	// Replace + with - for cleaner filenames
 	name = strings.ReplaceAll(name, "+", "-")
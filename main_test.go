@@ -5,8 +5,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aqueeb/confluence2md/converter"
 )
@@ -586,6 +588,11 @@ func TestGenerateOutputPath(t *testing.T) {
 			input:    "my+file.doc",
 			expected: "my-file.md",
 		},
+		{
+			name:     "storage-format page",
+			input:    "my+file.xhtml",
+			expected: "my-file.md",
+		},
 		{
 			name:     "multiple plus signs",
 			input:    "my+complex+file+name.doc",
@@ -702,7 +709,7 @@ func TestConvertFile_DryRun(t *testing.T) {
 	outputPath := filepath.Join(tmpDir, "test.md")
 
 	// Run in dry-run mode
-	err := convertFile(inputPath, outputPath, false, true)
+	err := convertFile(inputPath, outputPath, &config{dryRun: true})
 	if err != nil {
 		t.Fatalf("convertFile dry-run failed: %v", err)
 	}
@@ -723,7 +730,7 @@ func TestConvertFile_Success(t *testing.T) {
 	outputPath := filepath.Join(tmpDir, "test.md")
 
 	// Run conversion
-	err := convertFile(inputPath, outputPath, false, false)
+	err := convertFile(inputPath, outputPath, &config{})
 	if err != nil {
 		t.Fatalf("convertFile failed: %v", err)
 	}
@@ -749,7 +756,7 @@ func TestConvertFile_NonExistentInput(t *testing.T) {
 	inputPath := filepath.Join(tmpDir, "nonexistent.doc")
 	outputPath := filepath.Join(tmpDir, "output.md")
 
-	err := convertFile(inputPath, outputPath, false, false)
+	err := convertFile(inputPath, outputPath, &config{})
 	if err == nil {
 		t.Error("Expected error for non-existent input file")
 	}
@@ -763,7 +770,7 @@ func TestConvertFile_InvalidMIME(t *testing.T) {
 	inputPath := createPlainTextFile(t, tmpDir, "invalid.doc", "This is just plain text, not MIME.")
 	outputPath := filepath.Join(tmpDir, "invalid.md")
 
-	err := convertFile(inputPath, outputPath, false, false)
+	err := convertFile(inputPath, outputPath, &config{})
 	if err == nil {
 		t.Error("Expected error for non-MIME file")
 	}
@@ -782,7 +789,7 @@ func TestConvertFile_VerboseMode(t *testing.T) {
 	outputPath := filepath.Join(tmpDir, "test.md")
 
 	// Verbose mode should not cause errors
-	err := convertFile(inputPath, outputPath, true, false)
+	err := convertFile(inputPath, outputPath, &config{verbose: true})
 	if err != nil {
 		t.Fatalf("convertFile with verbose failed: %v", err)
 	}
@@ -806,7 +813,7 @@ func TestConvertDirectory_MultipleFiles(t *testing.T) {
 	createTestConfluenceMIME(t, tmpDir, "doc3.doc", "<html><body><h1>Doc 3</h1></body></html>")
 
 	// Run directory conversion
-	err := convertDirectory(tmpDir, false, false)
+	err := convertDirectory(tmpDir, &config{})
 	if err != nil {
 		t.Fatalf("convertDirectory failed: %v", err)
 	}
@@ -824,11 +831,11 @@ func TestConvertDirectory_EmptyDirectory(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	// Run directory conversion on empty directory
-	err := convertDirectory(tmpDir, false, false)
+	err := convertDirectory(tmpDir, &config{})
 	if err != nil {
 		t.Fatalf("convertDirectory on empty dir failed: %v", err)
 	}
-	// Should complete without error, just print "No .doc files found"
+	// Should complete without error, just print "No .doc or .xhtml files found"
 }
 
 func TestConvertDirectory_MixedFiles(t *testing.T) {
@@ -849,7 +856,7 @@ func TestConvertDirectory_MixedFiles(t *testing.T) {
 	createPlainTextFile(t, tmpDir, "data.json", "{}")
 
 	// Run directory conversion
-	err := convertDirectory(tmpDir, false, false)
+	err := convertDirectory(tmpDir, &config{})
 	if err != nil {
 		t.Fatalf("convertDirectory failed: %v", err)
 	}
@@ -885,7 +892,7 @@ func TestConvertDirectory_DryRun(t *testing.T) {
 	createTestConfluenceMIME(t, tmpDir, "doc2.doc", "<html><body><h1>Doc 2</h1></body></html>")
 
 	// Run directory conversion in dry-run mode
-	err := convertDirectory(tmpDir, false, true)
+	err := convertDirectory(tmpDir, &config{dryRun: true})
 	if err != nil {
 		t.Fatalf("convertDirectory dry-run failed: %v", err)
 	}
@@ -907,7 +914,7 @@ func TestConvertDirectory_NoConfluenceFiles(t *testing.T) {
 	createPlainTextFile(t, tmpDir, "plain2.doc", "Plain text 2")
 
 	// Run directory conversion
-	err := convertDirectory(tmpDir, false, false)
+	err := convertDirectory(tmpDir, &config{})
 	if err != nil {
 		t.Fatalf("convertDirectory failed: %v", err)
 	}
@@ -926,7 +933,7 @@ func TestConvertDirectory_VerboseMode(t *testing.T) {
 	createPlainTextFile(t, tmpDir, "invalid.doc", "Not MIME")
 
 	// Verbose mode should not cause errors
-	err := convertDirectory(tmpDir, true, false)
+	err := convertDirectory(tmpDir, &config{verbose: true})
 	if err != nil {
 		t.Fatalf("convertDirectory with verbose failed: %v", err)
 	}
@@ -938,10 +945,10 @@ func TestConvertDirectory_VerboseMode(t *testing.T) {
 }
 
 func TestConvertDirectory_NonExistentDirectory(t *testing.T) {
-	err := convertDirectory("/nonexistent/directory/path", false, false)
+	err := convertDirectory("/nonexistent/directory/path", &config{})
 	if err != nil {
 		// filepath.Glob doesn't error on non-existent paths, it just returns empty
-		// So this should not error, but print "No .doc files found"
+		// So this should not error, but print "No .doc or .xhtml files found"
 		t.Logf("Got error (may be expected depending on implementation): %v", err)
 	}
 }
@@ -957,7 +964,7 @@ func TestConvertDirectory_WithPlusInFilename(t *testing.T) {
 	createTestConfluenceMIME(t, tmpDir, "my+doc+file.doc", "<html><body><h1>Plus Test</h1></body></html>")
 
 	// Run directory conversion
-	err := convertDirectory(tmpDir, false, false)
+	err := convertDirectory(tmpDir, &config{})
 	if err != nil {
 		t.Fatalf("convertDirectory failed: %v", err)
 	}
@@ -1016,7 +1023,7 @@ func TestConvertFile_VerboseMessages(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err := convertFile(inputPath, outputPath, true, false)
+	err := convertFile(inputPath, outputPath, &config{verbose: true})
 
 	w.Close()
 	os.Stdout = old
@@ -1058,7 +1065,7 @@ func TestConvertFile_OutputMessages(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err := convertFile(inputPath, outputPath, false, false)
+	err := convertFile(inputPath, outputPath, &config{})
 
 	w.Close()
 	os.Stdout = old
@@ -1097,7 +1104,7 @@ func TestConvertFile_DryRunMessages(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err := convertFile(inputPath, outputPath, false, true)
+	err := convertFile(inputPath, outputPath, &config{dryRun: true})
 
 	w.Close()
 	os.Stdout = old
@@ -1133,7 +1140,7 @@ func TestConvertDirectory_Messages(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err := convertDirectory(tmpDir, false, false)
+	err := convertDirectory(tmpDir, &config{})
 
 	w.Close()
 	os.Stdout = old
@@ -1169,7 +1176,7 @@ func TestConvertDirectory_VerboseSkipMessages(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err := convertDirectory(tmpDir, true, false)
+	err := convertDirectory(tmpDir, &config{verbose: true})
 
 	w.Close()
 	os.Stdout = old
@@ -1196,7 +1203,7 @@ func TestConvertDirectory_EmptyMessages(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err := convertDirectory(tmpDir, false, false)
+	err := convertDirectory(tmpDir, &config{})
 
 	w.Close()
 	os.Stdout = old
@@ -1210,8 +1217,8 @@ func TestConvertDirectory_EmptyMessages(t *testing.T) {
 	output := string(buf[:n])
 
 	// Verify empty directory message
-	if !strings.Contains(output, "No .doc files found") {
-		t.Errorf("Expected 'No .doc files found' message, got: %s", output)
+	if !strings.Contains(output, "No .doc or .xhtml files found") {
+		t.Errorf("Expected 'No .doc or .xhtml files found' message, got: %s", output)
 	}
 }
 
@@ -1224,7 +1231,7 @@ func TestConvertDirectory_NoConfluenceMessages(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err := convertDirectory(tmpDir, false, false)
+	err := convertDirectory(tmpDir, &config{})
 
 	w.Close()
 	os.Stdout = old
@@ -1295,3 +1302,312 @@ func TestGenerateOutputPath_MoreEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+// Tests for conversion option flags
+func TestParseFlags_ConversionOptions(t *testing.T) {
+	defaults := converter.Options{
+		Flavor:             converter.FlavorGFM,
+		Target:             converter.TargetNone,
+		ImageCaptions:      converter.CaptionItalic,
+		ImageSizes:         converter.ImageSizeNone,
+		HardBreaks:         converter.HardBreakBackslash,
+		ListNumbering:      converter.ListNumberingSequential,
+		TableHeaders:       converter.TableHeaderInfer,
+		SingleCellTables:   converter.SingleCellUnwrap,
+		TableCells:         converter.TableCellFlatten,
+		BlankLines:         converter.BlankLines{Max: converter.DefaultMaxBlankLines},
+		NBSP:               converter.NBSPKeep,
+		EmoticonFallback:   converter.EmoticonShortname,
+		To:                 converter.FormatMarkdown,
+		Timeout:            converter.DefaultTimeout,
+		Engine:             converter.EngineAuto,
+		AttachmentsSection: converter.AttachmentsKeep,
+		HeadingIDs:         converter.HeadingIDsNone,
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		modify func(o *converter.Options)
+	}{
+		{
+			name:   "defaults",
+			args:   []string{"input.doc"},
+			modify: func(o *converter.Options) {},
+		},
+		{
+			name:   "normalize heading levels",
+			args:   []string{"--normalize-heading-levels", "input.doc"},
+			modify: func(o *converter.Options) { o.NormalizeHeadingLevels = true },
+		},
+		{
+			name:   "number headings",
+			args:   []string{"--number-headings", "input.doc"},
+			modify: func(o *converter.Options) { o.NumberHeadings = true },
+		},
+		{
+			name:   "toc with default depth",
+			args:   []string{"--toc", "input.doc"},
+			modify: func(o *converter.Options) { o.TOCDepth = converter.DefaultTOCDepth },
+		},
+		{
+			name:   "sort tables",
+			args:   []string{"--sort-tables", "Owner:desc", "input.doc"},
+			modify: func(o *converter.Options) { o.TableSort = converter.TableSort{Column: "Owner", Descending: true} },
+		},
+		{
+			name:   "toc with explicit depth",
+			args:   []string{"--toc=2", "input.doc"},
+			modify: func(o *converter.Options) { o.TOCDepth = 2 },
+		},
+		{
+			name:   "image captions as alt text",
+			args:   []string{"--image-captions", "alt", "input.doc"},
+			modify: func(o *converter.Options) { o.ImageCaptions = converter.CaptionAlt },
+		},
+		{
+			name:   "image sizes as html",
+			args:   []string{"--image-sizes", "html", "input.doc"},
+			modify: func(o *converter.Options) { o.ImageSizes = converter.ImageSizeHTML },
+		},
+		{
+			name:   "gitlab flavor",
+			args:   []string{"--flavor", "gitlab", "input.doc"},
+			modify: func(o *converter.Options) { o.Flavor = converter.FlavorGitLab },
+		},
+		{
+			name:   "mkdocs flavor",
+			args:   []string{"--flavor", "mkdocs", "input.doc"},
+			modify: func(o *converter.Options) { o.Flavor = converter.FlavorMkDocs },
+		},
+		{
+			name:   "jekyll target",
+			args:   []string{"--target", "jekyll", "input.doc"},
+			modify: func(o *converter.Options) { o.Target = converter.TargetJekyll },
+		},
+		{
+			name:   "base URL",
+			args:   []string{"--base-url", "https://wiki.example.com", "input.doc"},
+			modify: func(o *converter.Options) { o.BaseURL = "https://wiki.example.com" },
+		},
+		{
+			name:   "org output",
+			args:   []string{"--to", "org", "input.doc"},
+			modify: func(o *converter.Options) { o.To = converter.FormatOrg },
+		},
+		{
+			name:   "plain output",
+			args:   []string{"--to", "plain", "input.doc"},
+			modify: func(o *converter.Options) { o.To = converter.FormatPlain },
+		},
+		{
+			name:   "JSON output",
+			args:   []string{"--to", "json", "input.doc"},
+			modify: func(o *converter.Options) { o.To = converter.FormatJSON },
+		},
+		{
+			name:   "docx output",
+			args:   []string{"--to", "docx", "input.doc"},
+			modify: func(o *converter.Options) { o.To = converter.FormatDOCX },
+		},
+		{
+			name:   "two-space hard breaks",
+			args:   []string{"--hard-breaks", "spaces", "input.doc"},
+			modify: func(o *converter.Options) { o.HardBreaks = converter.HardBreakSpaces },
+		},
+		{
+			name:   "four-space list indentation",
+			args:   []string{"--list-indent", "4", "input.doc"},
+			modify: func(o *converter.Options) { o.ListIndent = 4 },
+		},
+		{
+			name:   "lazy list numbering",
+			args:   []string{"--list-numbering", "lazy", "input.doc"},
+			modify: func(o *converter.Options) { o.ListNumbering = converter.ListNumberingLazy },
+		},
+		{
+			name:   "empty table headers",
+			args:   []string{"--table-header", "empty", "input.doc"},
+			modify: func(o *converter.Options) { o.TableHeaders = converter.TableHeaderEmpty },
+		},
+		{
+			name:   "keep single-cell tables",
+			args:   []string{"--single-cell-tables", "keep", "input.doc"},
+			modify: func(o *converter.Options) { o.SingleCellTables = converter.SingleCellKeep },
+		},
+		{
+			name:   "table cells as html",
+			args:   []string{"--table-cells", "html", "input.doc"},
+			modify: func(o *converter.Options) { o.TableCells = converter.TableCellHTML },
+		},
+		{
+			name:   "blank line compaction",
+			args:   []string{"--max-blank-lines", "2", "--preserve-block-spacing", "input.doc"},
+			modify: func(o *converter.Options) { o.BlankLines = converter.BlankLines{Max: 2, PreserveAroundBlocks: true} },
+		},
+		{
+			name:   "smart non-breaking spaces",
+			args:   []string{"--nbsp", "smart", "input.doc"},
+			modify: func(o *converter.Options) { o.NBSP = converter.NBSPSmart },
+		},
+		{
+			name:   "drop unknown emoticons",
+			args:   []string{"--emoticon-fallback", "drop", "input.doc"},
+			modify: func(o *converter.Options) { o.EmoticonFallback = converter.EmoticonDrop },
+		},
+		{
+			name:   "per-file timeout",
+			args:   []string{"--timeout", "30s", "input.doc"},
+			modify: func(o *converter.Options) { o.Timeout = 30 * time.Second },
+		},
+		{
+			name:   "system engine",
+			args:   []string{"--engine", "system", "input.doc"},
+			modify: func(o *converter.Options) { o.Engine = converter.EngineSystem },
+		},
+		{
+			name:   "reader extensions",
+			args:   []string{"--reader-extensions", "-native_divs-native_spans", "input.doc"},
+			modify: func(o *converter.Options) { o.ReaderExtensions = "-native_divs-native_spans" },
+		},
+		{
+			name:   "heading anchors",
+			args:   []string{"--heading-ids", "anchors", "input.doc"},
+			modify: func(o *converter.Options) { o.HeadingIDs = converter.HeadingIDsAnchors },
+		},
+		{
+			name:   "keep attributes",
+			args:   []string{"--keep-attributes", ".warning, #*", "input.doc"},
+			modify: func(o *converter.Options) { o.KeepAttributes = []string{".warning", "#*"} },
+		},
+		{
+			name:   "language detection",
+			args:   []string{"--detect-language", "input.doc"},
+			modify: func(o *converter.Options) { o.DetectLanguage = true },
+		},
+		{
+			name: "German labels",
+			args: []string{"--lang", "de", "input.doc"},
+			modify: func(o *converter.Options) {
+				o.AdmonitionLabels, _ = converter.LocalizedAdmonitionLabels("de")
+			},
+		},
+		{
+			name:   "expanded details",
+			args:   []string{"--expand-details", "input.doc"},
+			modify: func(o *converter.Options) { o.ExpandDetails = true },
+		},
+		{
+			name:   "page IDs",
+			args:   []string{"--page-ids", "input.doc"},
+			modify: func(o *converter.Options) { o.PageIDs = true },
+		},
+		{
+			name:   "attachments section",
+			args:   []string{"--attachments-section", "remove", "input.doc"},
+			modify: func(o *converter.Options) { o.AttachmentsSection = converter.AttachmentsRemove },
+		},
+		{
+			name:   "minimized temp files",
+			args:   []string{"--minimize-temp-files", "input.doc"},
+			modify: func(o *converter.Options) { o.MinimizeTempFiles = true },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cfg, err := parseFlags(tt.args, &buf)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			want := defaults
+			tt.modify(&want)
+			if !reflect.DeepEqual(cfg.options, want) {
+				t.Errorf("options = %+v, want %+v", cfg.options, want)
+			}
+		})
+	}
+}
+
+// Tests for rejected conversion option values
+func TestParseFlags_InvalidOptionValues(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"toc depth zero", []string{"--toc=0", "input.doc"}},
+		{"toc depth not a number", []string{"--toc=deep", "input.doc"}},
+		{"unknown caption style", []string{"--image-captions", "bold", "input.doc"}},
+		{"unknown target", []string{"--target", "hugo", "input.doc"}},
+		{"unknown flavor", []string{"--flavor", "bitbucket", "input.doc"}},
+		{"unknown label language", []string{"--lang", "ja", "input.doc"}},
+		{"unknown image size style", []string{"--image-sizes", "css", "input.doc"}},
+		{"unknown output format", []string{"--to", "rst", "input.doc"}},
+		{"unknown hard break style", []string{"--hard-breaks", "crlf", "input.doc"}},
+		{"unsupported list indentation", []string{"--list-indent", "3", "input.doc"}},
+		{"unknown list numbering", []string{"--list-numbering", "roman", "input.doc"}},
+		{"unknown table header style", []string{"--table-header", "none", "input.doc"}},
+		{"unknown single-cell table style", []string{"--single-cell-tables", "drop", "input.doc"}},
+		{"unknown table cell style", []string{"--table-cells", "merge", "input.doc"}},
+		{"zero max blank lines", []string{"--max-blank-lines", "0", "input.doc"}},
+		{"max blank lines with org output", []string{"--to", "org", "--max-blank-lines", "2", "input.doc"}},
+		{"unknown nbsp style", []string{"--nbsp", "strip", "input.doc"}},
+		{"nbsp with docx output", []string{"--to", "docx", "--nbsp", "space", "input.doc"}},
+		{"unknown emoticon fallback", []string{"--emoticon-fallback", "download", "input.doc"}},
+		{"jekyll target with org output", []string{"--to", "org", "--target", "jekyll", "input.doc"}},
+		{"zero jobs", []string{"--jobs", "0", "--dir", "exports"}},
+		{"extract attachments with docx output", []string{"--to", "docx", "--extract-attachments", "input.doc"}},
+		{"invalid max input size", []string{"--max-input-size", "lots", "input.doc"}},
+		{"invalid max html size", []string{"--max-html-size", "-1MB", "input.doc"}},
+		{"zero timeout", []string{"--timeout", "0s", "input.doc"}},
+		{"invalid engine", []string{"--engine", "native", "input.doc"}},
+		{"invalid reader extensions", []string{"--reader-extensions", "native_divs", "input.doc"}},
+		{"invalid heading ids", []string{"--heading-ids", "slugs", "input.doc"}},
+		{"heading ids with org", []string{"--heading-ids", "attributes", "--to", "org", "input.doc"}},
+		{"invalid attribute selector", []string{"--keep-attributes", "warning", "input.doc"}},
+		{"keep attributes with org", []string{"--keep-attributes", ".warning", "--to", "org", "input.doc"}},
+		{"language detection with org", []string{"--detect-language", "--to", "org", "input.doc"}},
+		{"page IDs with docx", []string{"--page-ids", "--to", "docx", "input.doc"}},
+		{"invalid attachments section", []string{"--attachments-section", "drop", "input.doc"}},
+		{"missing temp dir", []string{"--temp-dir", "/nonexistent/confluence2md-tmp", "input.doc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if _, err := parseFlags(tt.args, &buf); err == nil {
+				t.Errorf("Expected error for %v", tt.args)
+			}
+		})
+	}
+}
+
+func TestParseFlags_TempDir(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := parseFlags([]string{"--temp-dir", dir, "input.doc"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.tempDir != dir {
+		t.Errorf("tempDir = %q, want %q", cfg.tempDir, dir)
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseFlags([]string{"--temp-dir", file, "input.doc"}, &bytes.Buffer{}); err == nil {
+		t.Error("Expected error for a --temp-dir that is a file")
+	}
+}
+
+func TestParseFlags_ExtractAttachments(t *testing.T) {
+	cfg, err := parseFlags([]string{"--extract-attachments", "input.doc"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !cfg.extractAssets {
+		t.Error("Expected --extract-attachments to be set")
+	}
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// pageTemplateData is what a --page-template renders each page from.
+type pageTemplateData struct {
+	// Title is the page title.
+	Title string
+	// Body is the converted page, without its front matter.
+	Body string
+	// FrontMatter is the page's YAML front matter block, with its ---
+	// delimiters, or empty if it has none.
+	FrontMatter string
+	// Metadata holds the variables of --prepend and --append files
+	// (title, date, source, page_id, space, and version), by name.
+	Metadata map[string]string
+	// Labels are the page's Confluence labels.
+	Labels []string
+	// SourcePath and OutputPath are the paths of the export and of the
+	// file written.
+	SourcePath string
+	OutputPath string
+}
+
+// loadPageTemplate parses the Go template at path for --page-template, or
+// returns nil when path is empty. References to unknown fields and missing
+// metadata keys fail when the template is run.
+func loadPageTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --page-template file: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --page-template file: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderPageTemplate renders the converted Markdown of a job through tmpl.
+func renderPageTemplate(tmpl *template.Template, job *fileJob, markdown string, now time.Time) (string, error) {
+	frontMatter, body := converter.SplitFrontMatter(markdown)
+	values := boilerplateValues(job.inputPath, job.html, now)
+	data := pageTemplateData{
+		Title:       values["title"],
+		Body:        body,
+		FrontMatter: frontMatter,
+		Metadata:    values,
+		Labels:      converter.ExtractPageInfo(job.html).Labels,
+		SourcePath:  job.inputPath,
+		OutputPath:  job.outputPath,
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render page template: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderPageTemplate(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "Release+Notes.doc")
+	job := &fileJob{
+		inputPath:  inputPath,
+		outputPath: filepath.Join(dir, "Release-Notes.mdx"),
+		html:       `<meta name="ajs-page-id" content="42"><meta name="ajs-labels" content="release,docs">`,
+	}
+	markdown := "---\ntitle: \"Release Notes\"\n---\n\n# Changes\n"
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "MDX wrapper",
+			template: "{{.FrontMatter}}import Layout from '../Layout'\n\n<Layout title=\"{{.Title}}\" id=\"{{.Metadata.page_id}}\">\n\n{{.Body}}\n</Layout>\n",
+			want:     "---\ntitle: \"Release Notes\"\n---\n\nimport Layout from '../Layout'\n\n<Layout title=\"Release Notes\" id=\"42\">\n\n# Changes\n\n</Layout>\n",
+		},
+		{
+			name:     "undefined function",
+			template: "{{base .SourcePath}}",
+			wantErr:  true,
+		},
+		{
+			name:     "labels and date",
+			template: "{{range .Labels}}#{{.}} {{end}}{{.Metadata.date}}",
+			want:     "#release #docs 2026-10-17",
+		},
+		{
+			name:     "unknown metadata key",
+			template: "{{.Metadata.author}}",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown field",
+			template: "{{.Author}}",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "page.tmpl")
+			if err := os.WriteFile(path, []byte(tt.template), 0644); err != nil {
+				t.Fatal(err)
+			}
+			tmpl, err := loadPageTemplate(path)
+			if err == nil {
+				var got string
+				got, err = renderPageTemplate(tmpl, job, markdown, now)
+				if err == nil && got != tt.want {
+					t.Errorf("renderPageTemplate() =\n%s\nwant\n%s", got, tt.want)
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseFlags_PageTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.tmpl")
+	if err := os.WriteFile(path, []byte("{{.Body}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := parseFlags([]string{"--page-template", path, "input.doc"}, &bytes.Buffer{})
+	if err != nil || cfg.pageTemplate == nil {
+		t.Fatalf("parseFlags() = %v, want the page template loaded", err)
+	}
+
+	for _, args := range [][]string{
+		{"--page-template", path, "--to", "docx", "input.doc"},
+		{"--page-template", path, "--chunk", "input.doc"},
+		{"--page-template", filepath.Join(filepath.Dir(path), "missing.tmpl"), "input.doc"},
+	} {
+		var buf bytes.Buffer
+		if _, err := parseFlags(args, &buf); err == nil || !strings.Contains(buf.String(), "page-template") {
+			t.Errorf("parseFlags(%q) error = %v, output %q, want a --page-template error", args, err, buf.String())
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// profile is a named preset of flag values. Flags given explicitly on the
+// command line take precedence over the profile.
+type profile map[string]string
+
+// builtinProfiles are the presets available without a config file.
+var builtinProfiles = map[string]profile{
+	// github targets GitHub READMEs and wikis: GFM with sized images kept
+	// as HTML, which GitHub renders.
+	"github": {
+		"flavor":      "gfm",
+		"image-sizes": "html",
+		"hard-breaks": "backslash",
+	},
+	// mkdocs-material targets MkDocs (Python-Markdown), which needs four
+	// space list indentation, writes admonitions as "!!! note" blocks, and
+	// has no backslash hard breaks.
+	"mkdocs-material": {
+		"flavor":      "mkdocs",
+		"list-indent": "4",
+		"hard-breaks": "spaces",
+		"image-sizes": "html",
+	},
+	// minimal-html keeps raw HTML out of the output for renderers that
+	// strip or escape it; pandoc reads divs and spans as their content.
+	"minimal-html": {
+		"hard-breaks":       "spaces",
+		"image-sizes":       "none",
+		"image-captions":    "italic",
+		"table-header":      "empty",
+		"reader-extensions": "-native_divs-native_spans",
+	},
+}
+
+// profileExcludedFlags are flags a profile may not set: they select inputs,
+// outputs, or other profiles rather than conversion behavior.
+var profileExcludedFlags = map[string]bool{
+	"o": true, "output": true, "dir": true, "config": true, "profile": true,
+	"version": true, "v": true, "verbose": true, "dry-run": true,
+}
+
+// lookupProfile returns the named profile, preferring profiles defined in
+// the config file over built-in ones.
+func lookupProfile(name string, custom map[string]profile) (profile, error) {
+	if p, ok := custom[name]; ok {
+		return p, nil
+	}
+	if p, ok := builtinProfiles[name]; ok {
+		return p, nil
+	}
+
+	names := make([]string, 0, len(builtinProfiles)+len(custom))
+	for n := range builtinProfiles {
+		names = append(names, n)
+	}
+	for n := range custom {
+		if _, ok := builtinProfiles[n]; !ok {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// applyProfile sets the profile's flag values on fs, skipping flags that
+// were given explicitly on the command line.
+func applyProfile(fs *flag.FlagSet, name string, p profile) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if profileExcludedFlags[key] || fs.Lookup(key) == nil {
+			return fmt.Errorf("profile %s: %q is not a conversion flag", name, key)
+		}
+		if explicit[key] {
+			continue
+		}
+		if err := fs.Set(key, p[key]); err != nil {
+			return fmt.Errorf("profile %s: invalid value %q for --%s: %w", name, p[key], key, err)
+		}
+	}
+	return nil
+}
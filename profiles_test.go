@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestParseFlags_Profiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		check func(t *testing.T, cfg *config)
+	}{
+		{
+			name: "mkdocs-material",
+			args: []string{"--profile", "mkdocs-material", "input.doc"},
+			check: func(t *testing.T, cfg *config) {
+				if cfg.options.Flavor != converter.FlavorMkDocs || cfg.options.ListIndent != 4 || cfg.options.HardBreaks != converter.HardBreakSpaces || cfg.options.ImageSizes != converter.ImageSizeHTML {
+					t.Errorf("profile not applied: %+v", cfg.options)
+				}
+			},
+		},
+		{
+			name: "explicit flag wins over profile",
+			args: []string{"--hard-breaks", "html", "--profile", "mkdocs-material", "input.doc"},
+			check: func(t *testing.T, cfg *config) {
+				if cfg.options.HardBreaks != converter.HardBreakHTML {
+					t.Errorf("HardBreaks = %q, want html", cfg.options.HardBreaks)
+				}
+				if cfg.options.ListIndent != 4 {
+					t.Errorf("ListIndent = %d, want 4", cfg.options.ListIndent)
+				}
+			},
+		},
+		{
+			name: "minimal-html",
+			args: []string{"--profile", "minimal-html", "input.doc"},
+			check: func(t *testing.T, cfg *config) {
+				if cfg.options.ImageSizes != converter.ImageSizeNone || cfg.options.TableHeaders != converter.TableHeaderEmpty || cfg.options.ReaderExtensions != "-native_divs-native_spans" {
+					t.Errorf("profile not applied: %+v", cfg.options)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cfg, err := parseFlags(tt.args, &buf)
+			if err != nil {
+				t.Fatalf("parseFlags failed: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestParseFlags_ConfigProfile(t *testing.T) {
+	path := writeConfigFile(t, `{
+  "profiles": {
+    "wiki": {"flavor": "gitlab", "toc": "2", "number-headings": "true"},
+    "github": {"image-sizes": "suffix"}
+  }
+}`)
+
+	var buf bytes.Buffer
+	cfg, err := parseFlags([]string{"--config", path, "--profile", "wiki", "input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("parseFlags failed: %v", err)
+	}
+	if cfg.options.Flavor != converter.FlavorGitLab || cfg.options.TOCDepth != 2 || !cfg.options.NumberHeadings {
+		t.Errorf("config profile not applied: %+v", cfg.options)
+	}
+
+	cfg, err = parseFlags([]string{"--config", path, "--profile", "github", "input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("parseFlags failed: %v", err)
+	}
+	if cfg.options.ImageSizes != converter.ImageSizeSuffix || cfg.options.HardBreaks != converter.HardBreakBackslash {
+		t.Errorf("config profile should replace the built-in one: %+v", cfg.options)
+	}
+}
+
+func TestParseFlags_ProfileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		profile string
+		wantErr string
+	}{
+		{"unknown profile", `{}`, "hugo", "available: github, minimal-html, mkdocs-material"},
+		{"unknown flag", `{"profiles": {"p": {"colour": "red"}}}`, "p", `"colour" is not a conversion flag`},
+		{"excluded flag", `{"profiles": {"p": {"dir": "/tmp"}}}`, "p", `"dir" is not a conversion flag`},
+		{"bad value", `{"profiles": {"p": {"list-indent": "two"}}}`, "p", "invalid value"},
+		{"bad choice", `{"profiles": {"p": {"flavor": "bitbucket"}}}`, "p", "bitbucket"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfigFile(t, tt.config)
+			var buf bytes.Buffer
+			_, err := parseFlags([]string{"--config", path, "--profile", tt.profile, "input.doc"}, &buf)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// progressFormat selects how progress is reported.
+type progressFormat string
+
+const (
+	// progressText prints human-readable progress (the default).
+	progressText progressFormat = "text"
+	// progressJSONL additionally emits one JSON event per line on stderr.
+	progressJSONL progressFormat = "jsonl"
+)
+
+// progressFormats lists the valid --progress-format values.
+var progressFormats = []progressFormat{progressText, progressJSONL}
+
+// Progress event names.
+const (
+	eventBatchStarted   = "batch_started"
+	eventFileStarted    = "file_started"
+	eventStageCompleted = "stage_completed"
+	eventWarning        = "warning"
+	eventFileDone       = "file_done"
+	eventBatchDone      = "batch_done"
+	eventError          = "error"
+)
+
+// Conversion stages reported in stage_completed events.
+const (
+	stageExtract = "extract"
+	stageConvert = "convert"
+	stageWrite   = "write"
+)
+
+// File statuses reported in file_done events.
+const (
+	statusConverted = "converted"
+	statusSkipped   = "skipped"
+	statusFailed    = "failed"
+	statusDryRun    = "dry-run"
+)
+
+// progressEvent is a single JSON lines progress event.
+type progressEvent struct {
+	Time       time.Time `json:"time"`
+	Event      string    `json:"event"`
+	File       string    `json:"file,omitempty"`
+	Stage      string    `json:"stage,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	Output     string    `json:"output,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	Total      int       `json:"total,omitempty"`
+	Converted  int       `json:"converted,omitempty"`
+	DurationMS int64     `json:"durationMs,omitempty"`
+}
+
+// progressEmitter writes progress events as JSON lines. A nil emitter
+// discards events, so callers don't need to check whether reporting is on.
+type progressEmitter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	now func() time.Time
+}
+
+// newProgressEmitter returns an emitter writing to w.
+func newProgressEmitter(w io.Writer) *progressEmitter {
+	return &progressEmitter{enc: json.NewEncoder(w), now: time.Now}
+}
+
+// emit writes one event, stamping its time.
+func (p *progressEmitter) emit(e progressEvent) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e.Time = p.now()
+	_ = p.enc.Encode(e)
+}
+
+func (p *progressEmitter) batchStarted(dir string, total int) {
+	p.emit(progressEvent{Event: eventBatchStarted, File: dir, Total: total})
+}
+
+func (p *progressEmitter) fileStarted(file string) {
+	p.emit(progressEvent{Event: eventFileStarted, File: file})
+}
+
+func (p *progressEmitter) stageCompleted(file, stage string, started time.Time) {
+	p.emit(progressEvent{Event: eventStageCompleted, File: file, Stage: stage, DurationMS: time.Since(started).Milliseconds()})
+}
+
+func (p *progressEmitter) warning(file, message string) {
+	p.emit(progressEvent{Event: eventWarning, File: file, Message: message})
+}
+
+func (p *progressEmitter) fileDone(file, status, output string, err error, started time.Time) {
+	e := progressEvent{Event: eventFileDone, File: file, Status: status, Output: output, DurationMS: time.Since(started).Milliseconds()}
+	if err != nil {
+		e.Message = err.Error()
+	}
+	p.emit(e)
+}
+
+func (p *progressEmitter) batchDone(dir string, total, converted int) {
+	p.emit(progressEvent{Event: eventBatchDone, File: dir, Total: total, Converted: converted})
+}
+
+// out returns where regular output goes: stdout, unless replaced for a
+// file's conversion.
+func (c *config) out() io.Writer {
+	if c.stdout != nil {
+		return c.stdout
+	}
+	return os.Stdout
+}
+
+// messages returns where human-readable warnings go: stderr, or stdout when
+// stderr carries JSON progress events.
+func (c *config) messages() io.Writer {
+	if c.progress != nil {
+		return c.out()
+	}
+	if c.stderr != nil {
+		return c.stderr
+	}
+	return os.Stderr
+}
+
+// reportError reports a fatal error, as an error event when JSON progress is
+// enabled and as an "Error:" line on stderr otherwise.
+func (c *config) reportError(err error) {
+	if c.progress != nil {
+		c.progress.emit(progressEvent{Event: eventError, Message: err.Error()})
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// decodeEvents parses JSON lines progress output.
+func decodeEvents(t *testing.T, data string) []progressEvent {
+	t.Helper()
+	var events []progressEvent
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		if line == "" {
+			continue
+		}
+		var e progressEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestProgressEmitter(t *testing.T) {
+	var buf bytes.Buffer
+	p := newProgressEmitter(&buf)
+	fixed := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return fixed }
+
+	p.fileStarted("a.doc")
+	p.warning("b.doc", "skipped")
+	p.fileDone("a.doc", statusFailed, "", errors.New("boom"), time.Now())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	if want := `{"time":"2026-03-01T09:00:00Z","event":"file_started","file":"a.doc"}`; lines[0] != want {
+		t.Errorf("line 0 = %s, want %s", lines[0], want)
+	}
+	events := decodeEvents(t, buf.String())
+	if events[1].Event != eventWarning || events[1].Message != "skipped" {
+		t.Errorf("unexpected warning event: %+v", events[1])
+	}
+	if events[2].Status != statusFailed || events[2].Message != "boom" {
+		t.Errorf("unexpected file_done event: %+v", events[2])
+	}
+}
+
+func TestProgressEmitter_Nil(t *testing.T) {
+	var p *progressEmitter
+	p.fileStarted("a.doc") // must not panic
+	p.batchDone("dir", 1, 1)
+
+	if (&config{}).messages() != os.Stderr {
+		t.Error("messages should go to stderr without JSON progress")
+	}
+	if (&config{progress: newProgressEmitter(&bytes.Buffer{})}).messages() != os.Stdout {
+		t.Error("messages should go to stdout when stderr carries JSON progress")
+	}
+}
+
+func TestConvertFile_ProgressEvents(t *testing.T) {
+	tmpDir := t.TempDir()
+	plain := createPlainTextFile(t, tmpDir, "notes.doc", "Just some text")
+	export := createTestConfluenceMIME(t, tmpDir, "page.doc", "<html><body>"+strings.Repeat("<p>Lorem ipsum</p>\n", 50)+"</body></html>")
+
+	tests := []struct {
+		name       string
+		input      string
+		cfg        config
+		wantStatus string
+	}{
+		{"failed", plain, config{}, statusFailed},
+		{"skipped", export, config{maxHTMLSize: 64}, statusSkipped},
+		{"dry run", export, config{dryRun: true}, statusDryRun},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cfg := tt.cfg
+			cfg.progress = newProgressEmitter(&buf)
+			_ = convertFile(tt.input, tt.input+".md", &cfg)
+
+			events := decodeEvents(t, buf.String())
+			if len(events) < 2 {
+				t.Fatalf("expected at least 2 events, got %+v", events)
+			}
+			first, last := events[0], events[len(events)-1]
+			if first.Event != eventFileStarted || first.File != tt.input {
+				t.Errorf("unexpected first event: %+v", first)
+			}
+			if last.Event != eventFileDone || last.Status != tt.wantStatus {
+				t.Errorf("unexpected last event: %+v", last)
+			}
+		})
+	}
+}
+
+func TestConvertDirectory_ProgressWarnings(t *testing.T) {
+	tmpDir := t.TempDir()
+	createPlainTextFile(t, tmpDir, "notes.doc", "Just some text")
+
+	var buf bytes.Buffer
+	if err := convertDirectory(tmpDir, &config{progress: newProgressEmitter(&buf)}); err != nil {
+		t.Fatalf("convertDirectory failed: %v", err)
+	}
+	events := decodeEvents(t, buf.String())
+	if len(events) != 1 || events[0].Event != eventWarning || !strings.Contains(events[0].Message, "not a Confluence") {
+		t.Errorf("expected one warning event, got %+v", events)
+	}
+}
+
+func TestParseFlags_ProgressFormat(t *testing.T) {
+	var buf bytes.Buffer
+	cfg, err := parseFlags([]string{"input.doc"}, &buf)
+	if err != nil || cfg.progress != nil {
+		t.Errorf("progress should be off by default (err %v)", err)
+	}
+	cfg, err = parseFlags([]string{"--progress-format", "jsonl", "input.doc"}, &buf)
+	if err != nil || cfg.progress == nil {
+		t.Errorf("--progress-format jsonl should enable events (err %v)", err)
+	}
+	if _, err := parseFlags([]string{"--progress-format", "xml", "input.doc"}, &buf); err == nil {
+		t.Error("expected error for unknown progress format")
+	}
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// redactionLog records the redactions made in each output file, so the
+// migration report can list them. A nil log records nothing.
+type redactionLog struct {
+	mu    sync.Mutex
+	pages map[string][]converter.Redaction
+}
+
+// newRedactionLog returns an empty redaction log.
+func newRedactionLog() *redactionLog {
+	return &redactionLog{pages: make(map[string][]converter.Redaction)}
+}
+
+// record stores the redactions made in outputPath.
+func (l *redactionLog) record(outputPath string, redactions []converter.Redaction) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pages[outputPath] = redactions
+}
+
+// get returns the redactions recorded for outputPath.
+func (l *redactionLog) get(outputPath string) []converter.Redaction {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pages[outputPath]
+}
+
+// formatRedactions summarizes redaction counts as "email (2), ipv4 (1)".
+func formatRedactions(redactions []converter.Redaction) string {
+	parts := make([]string, len(redactions))
+	for i, r := range redactions {
+		parts[i] = fmt.Sprintf("%s (%d)", r.Rule, r.Count)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,213 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+const (
+	// migrationReportFile is the human-readable batch report.
+	migrationReportFile = "MIGRATION_REPORT.md"
+
+	// migrationReportJSONFile is the machine-readable batch report.
+	migrationReportJSONFile = "migration-report.json"
+)
+
+// Issue categories recorded in the migration report.
+const (
+	issueNotConfluence = "not a Confluence export"
+	issueUnreadable    = "unreadable"
+	issueFiltered      = "filtered out"
+	issueTooLarge      = "over size limit"
+	issueTimeout       = "timed out"
+	issueEmptyOutput   = "empty output"
+	issueFailed        = "conversion failed"
+	issueLinkCheck     = "link check failed"
+)
+
+// migrationReport describes the outcome of a directory conversion.
+type migrationReport struct {
+	Generated time.Time     `json:"generated"`
+	Generator string        `json:"generator"`
+	Source    string        `json:"source"`
+	Pages     []reportPage  `json:"pages"`
+	Issues    []reportIssue `json:"issues"`
+}
+
+// reportPage is a converted page in the migration report.
+type reportPage struct {
+	Title       string   `json:"title"`
+	Input       string   `json:"input"`
+	Output      string   `json:"output"`
+	Attachments int      `json:"attachments"`
+	BrokenLinks []string `json:"brokenLinks,omitempty"`
+
+	Stats converter.DocumentStats `json:"stats"`
+
+	Redactions []converter.Redaction `json:"redactions,omitempty"`
+}
+
+// reportIssue is a file that was skipped, failed, or produced a warning.
+type reportIssue struct {
+	File     string `json:"file"`
+	Category string `json:"category"`
+	Message  string `json:"message"`
+}
+
+// newMigrationReport starts an empty report for a directory conversion.
+func newMigrationReport(dir string) *migrationReport {
+	return &migrationReport{
+		Generated: time.Now(),
+		Generator: "confluence2md " + version,
+		Source:    dir,
+	}
+}
+
+// addIssue records a problem with a file.
+func (r *migrationReport) addIssue(file, category string, err error) {
+	r.Issues = append(r.Issues, reportIssue{File: file, Category: category, Message: err.Error()})
+}
+
+// addPage records a converted page. When checkLinks is set (for Markdown
+// output), links are checked to count attachments and broken links.
+func (r *migrationReport) addPage(page convertedPage, checkLinks bool) {
+	rp := reportPage{Title: page.title, Input: page.inputPath, Output: page.outputPath, Stats: page.stats, Redactions: page.redactions}
+	if checkLinks {
+		stats, err := checkPageLinks(page.outputPath)
+		if err != nil {
+			r.addIssue(page.outputPath, issueLinkCheck, err)
+		}
+		rp.Attachments = stats.attachments
+		rp.BrokenLinks = stats.broken
+	}
+	r.Pages = append(r.Pages, rp)
+}
+
+// readPageStats computes the stats of the export at inputPath, or returns
+// zero stats if it cannot be read within limits.
+func readPageStats(inputPath string, limits converter.MIMELimits) converter.DocumentStats {
+	html, err := converter.ReadPageHTML(inputPath, limits)
+	if err != nil {
+		return converter.DocumentStats{}
+	}
+	return converter.ComputeStats(html)
+}
+
+// conversionIssueCategory classifies an error returned by convertFile.
+func conversionIssueCategory(err error) string {
+	switch {
+	case errors.Is(err, converter.ErrTimeout):
+		return issueTimeout
+	case errors.Is(err, errLimitExceeded):
+		return issueTooLarge
+	case errors.Is(err, converter.ErrEmptyOutput):
+		return issueEmptyOutput
+	default:
+		return issueFailed
+	}
+}
+
+// write saves the report as Markdown and JSON into dir.
+func (r *migrationReport) write(dir string) error {
+	if err := os.WriteFile(filepath.Join(dir, migrationReportFile), []byte(r.markdown()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", migrationReportFile, err)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", migrationReportJSONFile, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, migrationReportJSONFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", migrationReportJSONFile, err)
+	}
+	return nil
+}
+
+// markdown renders the report for pasting into a migration ticket.
+func (r *migrationReport) markdown() string {
+	attachments, broken, redacted := 0, 0, 0
+	for _, p := range r.Pages {
+		attachments += p.Attachments
+		broken += len(p.BrokenLinks)
+		for _, red := range p.Redactions {
+			redacted += red.Count
+		}
+	}
+	byCategory := make(map[string]int)
+	for _, issue := range r.Issues {
+		byCategory[issue.Category]++
+	}
+
+	var b strings.Builder
+	b.WriteString("# Migration Report\n\n")
+	fmt.Fprintf(&b, "Generated %s by %s from `%s`.\n\n", r.Generated.Format("2006-01-02 15:04"), r.Generator, r.Source)
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString("| | Count |\n|---|---:|\n")
+	fmt.Fprintf(&b, "| Pages converted | %d |\n", len(r.Pages))
+	fmt.Fprintf(&b, "| Files skipped or failed | %d |\n", len(r.Issues)-byCategory[issueLinkCheck])
+	fmt.Fprintf(&b, "| Attachments referenced | %d |\n", attachments)
+	fmt.Fprintf(&b, "| Broken links | %d |\n", broken)
+	if redacted > 0 {
+		fmt.Fprintf(&b, "| Redactions | %d |\n", redacted)
+	}
+
+	if len(byCategory) > 0 {
+		b.WriteString("\n## Warnings by category\n\n")
+		b.WriteString("| Category | Files |\n|---|---:|\n")
+		categories := make([]string, 0, len(byCategory))
+		for category := range byCategory {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+		for _, category := range categories {
+			fmt.Fprintf(&b, "| %s | %d |\n", category, byCategory[category])
+		}
+
+		b.WriteString("\n## Skipped and failed files\n\n")
+		for _, issue := range r.Issues {
+			fmt.Fprintf(&b, "- `%s` (%s): %s\n", issue.File, issue.Category, issue.Message)
+		}
+	}
+
+	if len(r.Pages) > 0 {
+		b.WriteString("\n## Converted pages\n\n")
+		b.WriteString("| Page | Output | Attachments | Broken links |\n|---|---|---:|---:|\n")
+		for _, p := range r.Pages {
+			fmt.Fprintf(&b, "| %s | `%s` | %d | %d |\n", escapeTableCell(p.Title), p.Output, p.Attachments, len(p.BrokenLinks))
+		}
+	}
+
+	if broken > 0 {
+		b.WriteString("\n## Broken links\n\n")
+		for _, p := range r.Pages {
+			for _, target := range p.BrokenLinks {
+				fmt.Fprintf(&b, "- `%s`: `%s`\n", p.Output, target)
+			}
+		}
+	}
+
+	if redacted > 0 {
+		b.WriteString("\n## Redactions\n\n")
+		for _, p := range r.Pages {
+			if len(p.Redactions) > 0 {
+				fmt.Fprintf(&b, "- `%s`: %s\n", p.Output, formatRedactions(p.Redactions))
+			}
+		}
+	}
+	return b.String()
+}
+
+// escapeTableCell escapes pipes so text can be used in a Markdown table cell.
+func escapeTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestConversionIssueCategory(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("failed: %w", converter.ErrTimeout), issueTimeout},
+		{fmt.Errorf("%w: input is 2MB", errLimitExceeded), issueTooLarge},
+		{fmt.Errorf("failed to convert to Markdown: %w", converter.ErrEmptyOutput), issueEmptyOutput},
+		{errors.New("pandoc failed"), issueFailed},
+	}
+
+	for _, tt := range tests {
+		if got := conversionIssueCategory(tt.err); got != tt.want {
+			t.Errorf("conversionIssueCategory(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestMigrationReport_Markdown(t *testing.T) {
+	r := &migrationReport{
+		Generated: time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC),
+		Generator: "confluence2md 1.0.0",
+		Source:    "exports",
+		Pages: []reportPage{
+			{Title: "Setup | Guide", Input: "exports/a.doc", Output: "exports/a.md", Attachments: 2, BrokenLinks: []string{"gone.md"}},
+			{Title: "FAQ", Input: "exports/b.doc", Output: "exports/b.md"},
+		},
+	}
+	r.addIssue("exports/c.doc", issueNotConfluence, errors.New("missing Confluence MIME headers"))
+	r.addIssue("exports/d.doc", issueTooLarge, errors.New("input is 90MB, limit is 50MB"))
+
+	md := r.markdown()
+	for _, want := range []string{
+		"# Migration Report",
+		"Generated 2026-03-01 09:30 by confluence2md 1.0.0 from `exports`.",
+		"| Pages converted | 2 |",
+		"| Files skipped or failed | 2 |",
+		"| Attachments referenced | 2 |",
+		"| Broken links | 1 |",
+		"| not a Confluence export | 1 |",
+		"| over size limit | 1 |",
+		"- `exports/d.doc` (over size limit): input is 90MB, limit is 50MB",
+		"| Setup \\| Guide | `exports/a.md` | 2 | 1 |",
+		"- `exports/a.md`: `gone.md`",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("report missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestMigrationReport_Redactions(t *testing.T) {
+	r := newMigrationReport("exports")
+	r.addPage(convertedPage{title: "Ops", inputPath: "exports/ops.doc", outputPath: "exports/ops.md",
+		redactions: []converter.Redaction{{Rule: "email", Count: 2}, {Rule: "ipv4", Count: 1}}}, false)
+	r.addPage(convertedPage{title: "FAQ", inputPath: "exports/faq.doc", outputPath: "exports/faq.md"}, false)
+
+	md := r.markdown()
+	for _, want := range []string{
+		"| Redactions | 3 |",
+		"## Redactions",
+		"- `exports/ops.md`: email (2), ipv4 (1)",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("report missing %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "exports/faq.md`:") {
+		t.Errorf("page without redactions should not be listed:\n%s", md)
+	}
+
+	data, err := json.Marshal(r.Pages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"redactions":[{"rule":"email","count":2},{"rule":"ipv4","count":1}]`) {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+}
+
+func TestMigrationReport_Stats(t *testing.T) {
+	tmpDir := t.TempDir()
+	input := createTestConfluenceMIME(t, tmpDir, "Guide.doc", `<h1>Guide</h1><p>Two words</p><table><tr><td>x</td></tr></table><pre>code</pre><img src=3D"a.png">`)
+
+	r := newMigrationReport(tmpDir)
+	r.addPage(convertedPage{title: "Guide", inputPath: input, outputPath: "Guide.md", stats: readPageStats(input, converter.MIMELimits{})}, false)
+
+	want := converter.DocumentStats{Words: 5, Headings: 1, Tables: 1, Images: 1, CodeBlocks: 1}
+	if r.Pages[0].Stats != want {
+		t.Errorf("Stats = %+v, want %+v", r.Pages[0].Stats, want)
+	}
+	data, err := json.Marshal(r.Pages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"stats":{"words":5,"headings":1,"tables":1,"images":1,"codeBlocks":1}`) {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+
+	if got := readPageStats(filepath.Join(tmpDir, "missing.doc"), converter.MIMELimits{}); got != (converter.DocumentStats{}) {
+		t.Errorf("readPageStats(missing) = %+v, want zero stats", got)
+	}
+}
+
+func TestMigrationReport_MarkdownWithoutIssues(t *testing.T) {
+	r := newMigrationReport("exports")
+	md := r.markdown()
+	for _, unwanted := range []string{"## Warnings by category", "## Converted pages", "## Broken links", "## Redactions"} {
+		if strings.Contains(md, unwanted) {
+			t.Errorf("empty report should not contain %q:\n%s", unwanted, md)
+		}
+	}
+}
+
+func TestConvertDirectory_Report(t *testing.T) {
+	tmpDir := t.TempDir()
+	createPlainTextFile(t, tmpDir, "notes.doc", "Just some text")
+
+	if err := convertDirectory(tmpDir, &config{report: true}); err != nil {
+		t.Fatalf("convertDirectory failed: %v", err)
+	}
+
+	md, err := os.ReadFile(filepath.Join(tmpDir, migrationReportFile))
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	if !strings.Contains(string(md), "| not a Confluence export | 1 |") {
+		t.Errorf("report should list the skipped file:\n%s", md)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, migrationReportJSONFile))
+	if err != nil {
+		t.Fatalf("JSON report not written: %v", err)
+	}
+	var decoded migrationReport
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid JSON report: %v", err)
+	}
+	if len(decoded.Issues) != 1 || decoded.Issues[0].Category != issueNotConfluence {
+		t.Errorf("unexpected issues: %+v", decoded.Issues)
+	}
+}
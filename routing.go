@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// outputRoute places pages with a label, or pages from a space, into a
+// subdirectory of the directory their export is in, so that one run can
+// populate a structured docs repository.
+type outputRoute struct {
+	Label string `json:"label,omitempty"`
+	Space string `json:"space,omitempty"`
+	Dir   string `json:"dir"`
+}
+
+// validate reports whether the route is usable.
+func (r outputRoute) validate() error {
+	if (r.Label == "") == (r.Space == "") {
+		return fmt.Errorf("route to %q must set exactly one of label or space", r.Dir)
+	}
+	if r.Dir == "" {
+		return errors.New("route is missing dir")
+	}
+	dir := filepath.Clean(filepath.FromSlash(r.Dir))
+	if filepath.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("route dir %q must be a relative path inside the output directory", r.Dir)
+	}
+	return nil
+}
+
+// matches reports whether the route applies to a page.
+func (r outputRoute) matches(info converter.PageInfo) bool {
+	if r.Label != "" {
+		return info.HasLabel(r.Label)
+	}
+	return strings.EqualFold(r.Space, info.SpaceKey)
+}
+
+// routeDir returns the directory of the first route matching the export
+// at inputPath, or "" when none matches or the export cannot be read
+// within limits.
+func routeDir(inputPath string, routes []outputRoute, limits converter.MIMELimits) string {
+	if len(routes) == 0 {
+		return ""
+	}
+	html, err := converter.ReadPageHTML(inputPath, limits)
+	if err != nil {
+		return ""
+	}
+	info := converter.ExtractPageInfo(html)
+	for _, r := range routes {
+		if r.matches(info) {
+			return filepath.FromSlash(r.Dir)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestOutputRouteValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		route   outputRoute
+		wantErr string
+	}{
+		{"label route", outputRoute{Label: "runbook", Dir: "ops/runbooks"}, ""},
+		{"space route", outputRoute{Space: "ENG", Dir: "eng"}, ""},
+		{"label and space", outputRoute{Label: "a", Space: "B", Dir: "x"}, "exactly one of label or space"},
+		{"neither", outputRoute{Dir: "x"}, "exactly one of label or space"},
+		{"missing dir", outputRoute{Label: "a"}, "missing dir"},
+		{"absolute dir", outputRoute{Label: "a", Dir: "/srv/docs"}, "relative path"},
+		{"parent dir", outputRoute{Label: "a", Dir: "../docs"}, "relative path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.route.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOutputRouteMatches(t *testing.T) {
+	info := converter.PageInfo{SpaceKey: "ENG", Labels: []string{"runbook"}}
+	if !(outputRoute{Label: "Runbook"}).matches(info) {
+		t.Error("Expected label route to match")
+	}
+	if !(outputRoute{Space: "eng"}).matches(info) {
+		t.Error("Expected space route to match case-insensitively")
+	}
+	if (outputRoute{Label: "howto"}).matches(info) || (outputRoute{Space: "OPS"}).matches(info) {
+		t.Error("Unexpected route match")
+	}
+}
+
+func TestOutputPathFor_Routes(t *testing.T) {
+	tmpDir := t.TempDir()
+	runbook := createTestConfluenceMIME(t, tmpDir, "Deploys.doc", `<meta name=3D"ajs-labels" content=3D"runbook"><meta name=3D"ajs-space-key" content=3D"OPS"><p>x</p>`)
+	other := createTestConfluenceMIME(t, tmpDir, "Notes.doc", `<meta name=3D"ajs-space-key" content=3D"OPS"><p>x</p>`)
+	unrouted := createTestConfluenceMIME(t, tmpDir, "Misc.doc", `<p>x</p>`)
+
+	cfg := &config{routes: []outputRoute{
+		{Label: "runbook", Dir: "ops/runbooks"},
+		{Space: "OPS", Dir: "ops"},
+	}}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{runbook, filepath.Join(tmpDir, "ops", "runbooks", "Deploys.md")},
+		{other, filepath.Join(tmpDir, "ops", "Notes.md")},
+		{unrouted, filepath.Join(tmpDir, "Misc.md")},
+	}
+	for _, tt := range tests {
+		if got := outputPathFor(tt.input, cfg); got != tt.want {
+			t.Errorf("outputPathFor(%s) = %s, want %s", filepath.Base(tt.input), got, tt.want)
+		}
+	}
+}
+
+func TestParseFlags_Routes(t *testing.T) {
+	path := writeConfigFile(t, `{"routes": [{"label": "runbook", "dir": "ops/runbooks"}]}`)
+	cfg, err := parseFlags([]string{"--config", path, "--dir", "exports"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.routes) != 1 || cfg.routes[0].Dir != "ops/runbooks" {
+		t.Errorf("Expected routes from config file, got %+v", cfg.routes)
+	}
+
+	if _, err := loadConfigFile(writeConfigFile(t, `{"routes": [{"label": "a", "dir": "../up"}]}`)); err == nil {
+		t.Error("Expected error for a route leaving the output directory")
+	}
+}
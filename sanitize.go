@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// formatRemovedChars summarizes removed control characters as
+// "NUL (2), byte-order mark (1)".
+func formatRemovedChars(removed []converter.RemovedChars) string {
+	parts := make([]string, len(removed))
+	for i, r := range removed {
+		parts[i] = fmt.Sprintf("%s (%d)", r.Name, r.Count)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestFormatRemovedChars(t *testing.T) {
+	got := formatRemovedChars([]converter.RemovedChars{{Name: "NUL", Count: 2}, {Name: "byte-order mark", Count: 1}})
+	if want := "NUL (2), byte-order mark (1)"; got != want {
+		t.Errorf("formatRemovedChars() = %q, want %q", got, want)
+	}
+}
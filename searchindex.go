@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// searchIDInvalidChars matches characters Meilisearch does not allow in
+// document IDs.
+var searchIDInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// searchRecord is a page in the --search-index file. The flat layout loads
+// as-is into Meilisearch (with id as the primary key) and lunr.js (with id
+// as the ref and title, headings, and body as fields).
+type searchRecord struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Headings []string `json:"headings"`
+	Body     string   `json:"body"`
+	Path     string   `json:"path"`
+}
+
+// writeSearchIndex writes the search records of the converted pages to
+// indexPath. Record paths are relative to the index file's directory.
+func writeSearchIndex(indexPath string, pages []convertedPage) error {
+	base := filepath.Dir(indexPath)
+	records := make([]searchRecord, 0, len(pages))
+	seen := make(map[string]int)
+	for _, page := range pages {
+		html, err := converter.ExtractHTMLFromMIME(page.inputPath)
+		if err != nil {
+			return fmt.Errorf("failed to index %s: %w", page.inputPath, err)
+		}
+		record := newSearchRecord(base, page, html)
+
+		// Keep IDs unique, as both lunr and Meilisearch require
+		seen[record.ID]++
+		if n := seen[record.ID]; n > 1 {
+			record.ID = fmt.Sprintf("%s-%d", record.ID, n)
+		}
+		records = append(records, record)
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode search index: %w", err)
+	}
+	if err := os.WriteFile(indexPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	return nil
+}
+
+// newSearchRecord builds the search record of a page from its export HTML.
+// The ID is the Confluence page ID when known, and otherwise derived from
+// the output path.
+func newSearchRecord(base string, page convertedPage, html string) searchRecord {
+	rel, err := filepath.Rel(base, page.outputPath)
+	if err != nil {
+		rel = page.outputPath
+	}
+	rel = filepath.ToSlash(rel)
+
+	id := converter.ExtractPageInfo(html).PageID
+	if id == "" {
+		id = strings.Trim(searchIDInvalidChars.ReplaceAllString(strings.TrimSuffix(rel, filepath.Ext(rel)), "-"), "-")
+	}
+
+	outline := converter.ExtractOutline(html)
+	headings := make([]string, len(outline.Headings))
+	for i, h := range outline.Headings {
+		headings[i] = h.Text
+	}
+	return searchRecord{
+		ID:       id,
+		Title:    page.title,
+		Headings: headings,
+		Body:     converter.ExtractText(html),
+		Path:     rel,
+	}
+}
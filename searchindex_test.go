@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNewSearchRecord(t *testing.T) {
+	html := `<html><body><h1>Runbook</h1><p>Restart the <b>service</b>.</p><h2>Rollback</h2></body></html>`
+
+	tests := []struct {
+		name string
+		html string
+		want searchRecord
+	}{
+		{
+			name: "ID from path",
+			html: html,
+			want: searchRecord{ID: "ops-Run-book", Title: "Run book", Headings: []string{"Runbook", "Rollback"}, Body: "Runbook Restart the service. Rollback", Path: "ops/Run book.md"},
+		},
+		{
+			name: "ID from page ID",
+			html: `<html><head><meta name="ajs-page-id" content="4242"></head><body></body></html>`,
+			want: searchRecord{ID: "4242", Title: "Run book", Headings: []string{}, Body: "", Path: "ops/Run book.md"},
+		},
+	}
+
+	page := convertedPage{title: "Run book", inputPath: "/in/Run+book.doc", outputPath: "/out/ops/Run book.md"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newSearchRecord("/out", page, tt.html); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newSearchRecord() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteSearchIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := createTestConfluenceMIME(t, tmpDir, "A.doc", `<html><body><p>Alpha</p></body></html>`)
+	second := createTestConfluenceMIME(t, tmpDir, "B.doc", `<html><body><p>Beta</p></body></html>`)
+	pages := []convertedPage{
+		{title: "A", inputPath: first, outputPath: filepath.Join(tmpDir, "Page.md")},
+		{title: "B", inputPath: second, outputPath: filepath.Join(tmpDir, "Page.org")},
+	}
+
+	indexPath := filepath.Join(tmpDir, "index.json")
+	if err := writeSearchIndex(indexPath, pages); err != nil {
+		t.Fatalf("writeSearchIndex failed: %v", err)
+	}
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatalf("expected search index to be written: %v", err)
+	}
+	var records []searchRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatalf("search index is not valid JSON: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].ID != "Page" || records[1].ID != "Page-2" {
+		t.Errorf("expected unique IDs, got %q and %q", records[0].ID, records[1].ID)
+	}
+	if records[1].Body != "Beta" || records[1].Path != "Page.org" {
+		t.Errorf("unexpected record: %+v", records[1])
+	}
+
+	missing := []convertedPage{{title: "X", inputPath: filepath.Join(tmpDir, "missing.doc"), outputPath: "x.md"}}
+	if err := writeSearchIndex(indexPath, missing); err == nil {
+		t.Error("expected error for a missing export")
+	}
+}
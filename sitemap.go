@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// sitemapFile is the machine-readable navigation tree of a batch.
+const sitemapFile = "sitemap.json"
+
+// sitemap is the converted page hierarchy, for site generators and portals
+// that build navigation without re-scanning the output.
+type sitemap struct {
+	Generated time.Time      `json:"generated"`
+	Generator string         `json:"generator"`
+	Pages     []*sitemapNode `json:"pages"`
+}
+
+// sitemapNode is a page, or a directory without a page of its own, in the
+// sitemap. Paths are relative to the output root and use forward slashes.
+type sitemapNode struct {
+	Title    string         `json:"title"`
+	Path     string         `json:"path,omitempty"`
+	Source   string         `json:"source,omitempty"`
+	PageID   string         `json:"pageId,omitempty"`
+	Space    string         `json:"space,omitempty"`
+	Children []*sitemapNode `json:"children,omitempty"`
+}
+
+// writeSitemap writes sitemap.json describing the converted pages into root.
+// Page IDs and space keys are read from each export's HTML.
+func writeSitemap(root string, pages []convertedPage) error {
+	infos := make(map[string]converter.PageInfo, len(pages))
+	for _, page := range pages {
+		if html, err := converter.ExtractHTMLFromMIME(page.inputPath); err == nil {
+			infos[page.inputPath] = converter.ExtractPageInfo(html)
+		}
+	}
+
+	sm := sitemap{
+		Generated: time.Now(),
+		Generator: "confluence2md " + version,
+		Pages:     buildSitemap(root, pages, infos),
+	}
+	data, err := json.MarshalIndent(sm, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", sitemapFile, err)
+	}
+	if err := os.WriteFile(filepath.Join(root, sitemapFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", sitemapFile, err)
+	}
+	return nil
+}
+
+// buildSitemap nests pages by their directory below root, like the GitBook
+// summary: a page named after a directory (Parent.md next to Parent/) is the
+// parent of the pages inside it, and other directories become title-only
+// nodes.
+func buildSitemap(root string, pages []convertedPage, infos map[string]converter.PageInfo) []*sitemapNode {
+	type entry struct {
+		key  string // output path relative to root, without extension
+		page convertedPage
+		rel  string
+	}
+
+	entries := make([]entry, 0, len(pages))
+	for _, page := range pages {
+		rel, err := filepath.Rel(root, page.outputPath)
+		if err != nil {
+			rel = filepath.Base(page.outputPath)
+		}
+		rel = filepath.ToSlash(rel)
+		entries = append(entries, entry{key: strings.TrimSuffix(rel, path.Ext(rel)), page: page, rel: rel})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	var roots []*sitemapNode
+	nodes := make(map[string]*sitemapNode)
+	var nodeFor func(key string) *sitemapNode
+	nodeFor = func(key string) *sitemapNode {
+		if n, ok := nodes[key]; ok {
+			return n
+		}
+		n := &sitemapNode{Title: path.Base(key)}
+		nodes[key] = n
+		if parent := path.Dir(key); parent == "." {
+			roots = append(roots, n)
+		} else {
+			p := nodeFor(parent)
+			p.Children = append(p.Children, n)
+		}
+		return n
+	}
+
+	for _, e := range entries {
+		n := nodeFor(e.key)
+		info := infos[e.page.inputPath]
+		n.Title = e.page.title
+		n.Path = e.rel
+		n.Source = filepath.Base(e.page.inputPath)
+		n.PageID = info.PageID
+		n.Space = info.SpaceKey
+	}
+	return roots
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestBuildSitemap(t *testing.T) {
+	root := "/out"
+	pages := []convertedPage{
+		{title: "Child", inputPath: "/in/Child.doc", outputPath: "/out/Parent/Child.md"},
+		{title: "Parent", inputPath: "/in/Parent.doc", outputPath: "/out/Parent.md"},
+		{title: "Deep Page", inputPath: "/in/Deep+Page.doc", outputPath: "/out/Space/Deep Page.md"},
+	}
+	infos := map[string]converter.PageInfo{
+		"/in/Parent.doc": {PageID: "100", SpaceKey: "ENG"},
+	}
+
+	got := buildSitemap(root, pages, infos)
+	want := []*sitemapNode{
+		{
+			Title: "Parent", Path: "Parent.md", Source: "Parent.doc", PageID: "100", Space: "ENG",
+			Children: []*sitemapNode{
+				{Title: "Child", Path: "Parent/Child.md", Source: "Child.doc"},
+			},
+		},
+		{
+			Title: "Space",
+			Children: []*sitemapNode{
+				{Title: "Deep Page", Path: "Space/Deep Page.md", Source: "Deep+Page.doc"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.MarshalIndent(got, "", "  ")
+		wantJSON, _ := json.MarshalIndent(want, "", "  ")
+		t.Errorf("buildSitemap() =\n%s\nwant\n%s", gotJSON, wantJSON)
+	}
+}
+
+func TestWriteSitemap(t *testing.T) {
+	tmpDir := t.TempDir()
+	input := createTestConfluenceMIME(t, tmpDir, "Runbook.doc",
+		`<html><head><meta name="ajs-page-id" content="4242"><meta name="ajs-space-key" content="OPS"></head><body><p>Steps</p></body></html>`)
+	pages := []convertedPage{{title: "Runbook", inputPath: input, outputPath: filepath.Join(tmpDir, "Runbook.md")}}
+
+	if err := writeSitemap(tmpDir, pages); err != nil {
+		t.Fatalf("writeSitemap failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, sitemapFile))
+	if err != nil {
+		t.Fatalf("expected %s to be written: %v", sitemapFile, err)
+	}
+	var sm sitemap
+	if err := json.Unmarshal(data, &sm); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	want := []*sitemapNode{{Title: "Runbook", Path: "Runbook.md", Source: "Runbook.doc", PageID: "4242", Space: "OPS"}}
+	if !reflect.DeepEqual(sm.Pages, want) {
+		t.Errorf("pages = %+v, want %+v", sm.Pages[0], want[0])
+	}
+	if sm.Generator != "confluence2md "+version {
+		t.Errorf("generator = %q", sm.Generator)
+	}
+}
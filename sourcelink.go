@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// sourceLinkStyle selects how a link back to the original Confluence page
+// is added to each output file.
+type sourceLinkStyle string
+
+const (
+	// sourceLinkNone adds no link.
+	sourceLinkNone sourceLinkStyle = "none"
+	// sourceLinkFooter appends an "Originally from Confluence" line.
+	sourceLinkFooter sourceLinkStyle = "footer"
+	// sourceLinkFrontMatter adds a confluence_url front matter field.
+	sourceLinkFrontMatter sourceLinkStyle = "front-matter"
+)
+
+// sourceLinkStyles lists the valid --source-link values.
+var sourceLinkStyles = []sourceLinkStyle{sourceLinkNone, sourceLinkFooter, sourceLinkFrontMatter}
+
+// sourcePageURL returns the URL of the original Confluence page, built from
+// the page ID when the export names one, or else from the space key and
+// title. The base URL comes from the link mapping for the page's space,
+// falling back to opts.BaseURL. It returns an empty string when the page
+// cannot be located.
+func sourcePageURL(opts converter.Options, info converter.PageInfo, title string) string {
+	base := opts.BaseURL
+	for _, mapping := range opts.LinkMappings {
+		if mapping.Space != "" && strings.EqualFold(mapping.Space, info.SpaceKey) {
+			base = mapping.BaseURL
+			break
+		}
+	}
+	if base == "" {
+		return ""
+	}
+	base = strings.TrimSuffix(base, "/")
+
+	switch {
+	case info.PageID != "":
+		return base + "/pages/viewpage.action?pageId=" + info.PageID
+	case info.SpaceKey != "" && title != "":
+		return base + "/display/" + url.PathEscape(info.SpaceKey) + "/" + url.QueryEscape(title)
+	}
+	return ""
+}
+
+// sourceLinkLine renders the back-reference line appended to the output,
+// as a link in the syntax of the output format.
+func sourceLinkLine(pageURL string, format converter.OutputFormat) string {
+	switch format {
+	case converter.FormatOrg:
+		return fmt.Sprintf("Originally from Confluence: [[%s]]\n", pageURL)
+	case converter.FormatPlain:
+		return fmt.Sprintf("Originally from Confluence: %s\n", pageURL)
+	}
+	return fmt.Sprintf("Originally from Confluence: <%s>\n", pageURL)
+}
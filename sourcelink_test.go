@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestSourcePageURL(t *testing.T) {
+	mapped := converter.Options{
+		BaseURL: "https://wiki.example.com/",
+		LinkMappings: []converter.LinkMapping{
+			{Space: "OPS", BaseURL: "https://ops.example.com"},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		opts  converter.Options
+		info  converter.PageInfo
+		title string
+		want  string
+	}{
+		{
+			"page ID",
+			mapped, converter.PageInfo{PageID: "42", SpaceKey: "ENG"}, "Runbook",
+			"https://wiki.example.com/pages/viewpage.action?pageId=42",
+		},
+		{
+			"space and title",
+			mapped, converter.PageInfo{SpaceKey: "ENG"}, "Release Notes & FAQ",
+			"https://wiki.example.com/display/ENG/Release+Notes+%26+FAQ",
+		},
+		{
+			"space mapping",
+			mapped, converter.PageInfo{PageID: "7", SpaceKey: "ops"}, "",
+			"https://ops.example.com/pages/viewpage.action?pageId=7",
+		},
+		{
+			"unknown page",
+			mapped, converter.PageInfo{}, "Runbook",
+			"",
+		},
+		{
+			"no base URL",
+			converter.Options{}, converter.PageInfo{PageID: "42"}, "",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sourcePageURL(tt.opts, tt.info, tt.title); got != tt.want {
+				t.Errorf("sourcePageURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceLinkLine(t *testing.T) {
+	const pageURL = "https://wiki.example.com/pages/viewpage.action?pageId=42"
+	if got, want := sourceLinkLine(pageURL, converter.FormatMarkdown), "Originally from Confluence: <"+pageURL+">\n"; got != want {
+		t.Errorf("Markdown line = %q, want %q", got, want)
+	}
+	if got, want := sourceLinkLine(pageURL, converter.FormatOrg), "Originally from Confluence: [["+pageURL+"]]\n"; got != want {
+		t.Errorf("Org line = %q, want %q", got, want)
+	}
+	if got, want := sourceLinkLine(pageURL, converter.FormatPlain), "Originally from Confluence: "+pageURL+"\n"; got != want {
+		t.Errorf("plain line = %q, want %q", got, want)
+	}
+}
+
+func TestParseFlags_SourceLink(t *testing.T) {
+	base := "--base-url=https://wiki.example.com"
+	tests := []struct {
+		name    string
+		args    []string
+		want    sourceLinkStyle
+		wantErr bool
+	}{
+		{"default", []string{"input.doc"}, sourceLinkNone, false},
+		{"footer", []string{"--source-link", "footer", base, "input.doc"}, sourceLinkFooter, false},
+		{"front matter", []string{"--source-link", "front-matter", base, "input.doc"}, sourceLinkFrontMatter, false},
+		{"footer with org", []string{"--source-link", "footer", "--to", "org", base, "input.doc"}, sourceLinkFooter, false},
+		{"unknown style", []string{"--source-link", "header", base, "input.doc"}, "", true},
+		{"no base URL", []string{"--source-link", "footer", "input.doc"}, "", true},
+		{"front matter with org", []string{"--source-link", "front-matter", "--to", "org", base, "input.doc"}, "", true},
+		{"binary output", []string{"--source-link", "footer", "--to", "docx", base, "input.doc"}, "", true},
+		{"JSON output", []string{"--source-link", "footer", "--to", "json", base, "input.doc"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cfg, err := parseFlags(tt.args, &buf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlags(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if err == nil && cfg.sourceLink != tt.want {
+				t.Errorf("sourceLink = %q, want %q", cfg.sourceLink, tt.want)
+			}
+		})
+	}
+}
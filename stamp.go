@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// stampStyle selects how provenance information (source file, tool version,
+// and source content hash) is recorded in each output file.
+type stampStyle string
+
+const (
+	// stampNone records nothing.
+	stampNone stampStyle = "none"
+	// stampComment appends a comment line to the end of the output.
+	stampComment stampStyle = "comment"
+	// stampFrontMatter adds source, generator, and source_sha256 front matter fields.
+	stampFrontMatter stampStyle = "front-matter"
+)
+
+// stampStyles lists the valid --stamp values.
+var stampStyles = []stampStyle{stampNone, stampComment, stampFrontMatter}
+
+// provenance identifies the source of an output file for later audits.
+type provenance struct {
+	source    string // base name of the source export
+	generator string // tool name and version
+	sha256    string // hex SHA-256 of the source export
+}
+
+// readProvenance hashes the source export at inputPath.
+func readProvenance(inputPath string) (provenance, error) {
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return provenance{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return provenance{}, fmt.Errorf("failed to hash file: %w", err)
+	}
+	return provenance{
+		source:    filepath.Base(inputPath),
+		generator: "confluence2md " + version,
+		sha256:    hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+// frontMatter returns the provenance as front matter fields.
+func (p provenance) frontMatter() []converter.FrontMatterField {
+	return []converter.FrontMatterField{
+		{Key: "source", Value: p.source},
+		{Key: "generator", Value: p.generator},
+		{Key: "source_sha256", Value: p.sha256},
+	}
+}
+
+// comment renders the provenance as a comment in the syntax of the output
+// format: an HTML comment for Markdown, a "#" comment line for Org, and a
+// bare line for plain text, which has no comment syntax.
+func (p provenance) comment(format converter.OutputFormat) string {
+	text := fmt.Sprintf("source=%q generator=%q sha256=%s", p.source, p.generator, p.sha256)
+	switch format {
+	case converter.FormatOrg:
+		return "# " + text + "\n"
+	case converter.FormatPlain:
+		return text + "\n"
+	}
+	return "<!-- " + text + " -->\n"
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestReadProvenance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Team+Page.doc")
+	if err := os.WriteFile(path, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readProvenance(path)
+	if err != nil {
+		t.Fatalf("readProvenance failed: %v", err)
+	}
+	want := provenance{
+		source:    "Team+Page.doc",
+		generator: "confluence2md " + version,
+		sha256:    "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+	}
+	if got != want {
+		t.Errorf("readProvenance() = %+v, want %+v", got, want)
+	}
+
+	if _, err := readProvenance(filepath.Join(t.TempDir(), "missing.doc")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+func TestProvenanceComment(t *testing.T) {
+	p := provenance{source: "Page.doc", generator: "confluence2md 1.2.3", sha256: "abc123"}
+
+	tests := []struct {
+		format converter.OutputFormat
+		want   string
+	}{
+		{converter.FormatMarkdown, `<!-- source="Page.doc" generator="confluence2md 1.2.3" sha256=abc123 -->` + "\n"},
+		{converter.FormatOrg, `# source="Page.doc" generator="confluence2md 1.2.3" sha256=abc123` + "\n"},
+		{converter.FormatPlain, `source="Page.doc" generator="confluence2md 1.2.3" sha256=abc123` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			if got := p.comment(tt.format); got != tt.want {
+				t.Errorf("comment(%s) = %q, want %q", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvenanceFrontMatter(t *testing.T) {
+	p := provenance{source: "Page.doc", generator: "confluence2md 1.2.3", sha256: "abc123"}
+	want := []converter.FrontMatterField{
+		{Key: "source", Value: "Page.doc"},
+		{Key: "generator", Value: "confluence2md 1.2.3"},
+		{Key: "source_sha256", Value: "abc123"},
+	}
+	if got := p.frontMatter(); !reflect.DeepEqual(got, want) {
+		t.Errorf("frontMatter() = %v, want %v", got, want)
+	}
+}
+
+func TestParseFlags_Stamp(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    stampStyle
+		wantErr bool
+	}{
+		{"default", []string{"input.doc"}, stampNone, false},
+		{"comment", []string{"--stamp", "comment", "input.doc"}, stampComment, false},
+		{"front matter", []string{"--stamp", "front-matter", "input.doc"}, stampFrontMatter, false},
+		{"comment with org", []string{"--stamp", "comment", "--to", "org", "input.doc"}, stampComment, false},
+		{"unknown style", []string{"--stamp", "footer", "input.doc"}, "", true},
+		{"front matter with org", []string{"--stamp", "front-matter", "--to", "org", "input.doc"}, "", true},
+		{"binary output", []string{"--stamp", "comment", "--to", "docx", "input.doc"}, "", true},
+		{"JSON output", []string{"--stamp", "comment", "--to", "json", "input.doc"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cfg, err := parseFlags(tt.args, &buf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlags(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if err == nil && cfg.stamp != tt.want {
+				t.Errorf("stamp = %q, want %q", cfg.stamp, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// gitbookSummaryFile is the table of contents file GitBook and HonKit read.
+const gitbookSummaryFile = "SUMMARY.md"
+
+// convertedPage records a successfully converted page for batch outputs
+// such as the GitBook summary.
+type convertedPage struct {
+	title      string
+	inputPath  string
+	outputPath string
+
+	// redactions counts the matches --redact replaced in the output
+	redactions []converter.Redaction
+	// stats sizes up the page for the migration report
+	stats converter.DocumentStats
+}
+
+// newConvertedPage builds the record for a converted page, reading its title
+// from the export.
+func newConvertedPage(inputPath, outputPath string) convertedPage {
+	meta, _ := converter.ReadExportMetadata(inputPath)
+	return convertedPage{
+		title:      pageTitle(inputPath, meta),
+		inputPath:  inputPath,
+		outputPath: outputPath,
+	}
+}
+
+// writeGitBookSummary writes a SUMMARY.md listing the converted pages into root.
+func writeGitBookSummary(root string, pages []convertedPage) error {
+	summary := buildGitBookSummary(root, pages)
+	path := filepath.Join(root, gitbookSummaryFile)
+	if err := os.WriteFile(path, []byte(summary), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", gitbookSummaryFile, err)
+	}
+	return nil
+}
+
+// buildGitBookSummary renders a GitBook SUMMARY.md. Pages are nested by their
+// directory below root; a page named after a directory (Parent.md next to
+// Parent/) becomes the parent entry of the pages inside it.
+func buildGitBookSummary(root string, pages []convertedPage) string {
+	type entry struct {
+		rel   string
+		title string
+	}
+
+	entries := make([]entry, 0, len(pages))
+	byPath := make(map[string]string)
+	for _, page := range pages {
+		rel, err := filepath.Rel(root, page.outputPath)
+		if err != nil {
+			rel = filepath.Base(page.outputPath)
+		}
+		rel = filepath.ToSlash(rel)
+		entries = append(entries, entry{rel: rel, title: page.title})
+		byPath[rel] = page.title
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.TrimSuffix(entries[i].rel, ".md") < strings.TrimSuffix(entries[j].rel, ".md")
+	})
+
+	var b strings.Builder
+	b.WriteString("# Summary\n\n")
+	written := make(map[string]bool)
+	for _, e := range entries {
+		dirs := strings.Split(e.rel, "/")
+		dirs = dirs[:len(dirs)-1]
+
+		// Emit unlinked entries for parent directories without a page of their own
+		for depth := range dirs {
+			dir := strings.Join(dirs[:depth+1], "/")
+			if written[dir] {
+				continue
+			}
+			written[dir] = true
+			if _, ok := byPath[dir+".md"]; !ok {
+				fmt.Fprintf(&b, "%s* %s\n", strings.Repeat("  ", depth), dirs[depth])
+			}
+		}
+
+		fmt.Fprintf(&b, "%s* [%s](%s)\n", strings.Repeat("  ", len(dirs)), e.title, escapeSummaryTarget(e.rel))
+		written[strings.TrimSuffix(e.rel, ".md")] = true
+	}
+	return b.String()
+}
+
+// escapeSummaryTarget percent-encodes spaces so paths work as link targets.
+func escapeSummaryTarget(rel string) string {
+	return strings.ReplaceAll(rel, " ", "%20")
+}
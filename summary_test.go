@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestBuildGitBookSummary_Flat(t *testing.T) {
+	root := "/out"
+	pages := []convertedPage{
+		{title: "Setup Guide", outputPath: "/out/Setup-Guide.md"},
+		{title: "Architecture", outputPath: "/out/Architecture.md"},
+	}
+
+	got := buildGitBookSummary(root, pages)
+	want := "# Summary\n\n* [Architecture](Architecture.md)\n* [Setup Guide](Setup-Guide.md)\n"
+	if got != want {
+		t.Errorf("buildGitBookSummary() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBuildGitBookSummary_Nested(t *testing.T) {
+	root := "/out"
+	pages := []convertedPage{
+		{title: "Child", outputPath: "/out/Parent/Child.md"},
+		{title: "Parent", outputPath: "/out/Parent.md"},
+		{title: "Deep Page", outputPath: "/out/Space/Area/Deep Page.md"},
+	}
+
+	got := buildGitBookSummary(root, pages)
+	want := "# Summary\n\n" +
+		"* [Parent](Parent.md)\n" +
+		"  * [Child](Parent/Child.md)\n" +
+		"* Space\n" +
+		"  * Area\n" +
+		"    * [Deep Page](Space/Area/Deep%20Page.md)\n"
+	if got != want {
+		t.Errorf("buildGitBookSummary() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestConvertDirectory_GitBookSummary(t *testing.T) {
+	if err := converter.CheckPandoc(); err != nil {
+		t.Skipf("Pandoc not available, skipping test: %v", err)
+	}
+
+	tmpDir := t.TempDir()
+	createTestConfluenceMIME(t, tmpDir, "Team+Handbook.doc", "<html><body><h1>Handbook</h1></body></html>")
+
+	if err := convertDirectory(tmpDir, &config{gitbookSummary: true}); err != nil {
+		t.Fatalf("convertDirectory failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, gitbookSummaryFile))
+	if err != nil {
+		t.Fatalf("Expected SUMMARY.md to be written: %v", err)
+	}
+	if !strings.Contains(string(content), "* [Team Handbook](Team-Handbook.md)") {
+		t.Errorf("Unexpected SUMMARY.md content: %s", content)
+	}
+}
@@ -0,0 +1,334 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aqueeb/confluence2md/internal/confluence"
+)
+
+// syncCommand is the subcommand keeping a directory in sync with a space.
+const syncCommand = "sync"
+
+// syncStateFile is the file in the output directory recording the pages
+// the last sync, or --url --space fetch, fetched and converted.
+const syncStateFile = ".confluence2md-sync.json"
+
+// stateSaveInterval is how many pages are downloaded between saves of the
+// sync state.
+const stateSaveInterval = 25
+
+// syncState records the pages of a synced space by page ID, so that the
+// next sync fetches only pages with a newer version and removes the outputs
+// of pages deleted from the space.
+type syncState struct {
+	Site  string                `json:"site"`
+	Space string                `json:"space"`
+	Pages map[string]syncedPage `json:"pages"`
+}
+
+// syncedPage is a page as of the last sync.
+type syncedPage struct {
+	Title string `json:"title"`
+	// Export is the file name of the page's export in the output
+	// directory. It is kept when the page is renamed, so that links to
+	// the output stay valid.
+	Export string `json:"export"`
+	// Fetched and Converted are the versions of the page last downloaded
+	// and last converted successfully.
+	Fetched   int `json:"fetched"`
+	Converted int `json:"converted"`
+}
+
+// parseSyncFlags parses the arguments of the sync subcommand: the flags of
+// a --url --space fetch, with --out naming the output directory.
+func parseSyncFlags(args []string, output io.Writer) (*config, error) {
+	flagArgs := make([]string, len(args))
+	for i, arg := range args {
+		switch {
+		case arg == "--out" || arg == "-out":
+			arg = "-o"
+		case strings.HasPrefix(arg, "--out=") || strings.HasPrefix(arg, "-out="):
+			arg = "-o=" + arg[strings.Index(arg, "=")+1:]
+		}
+		flagArgs[i] = arg
+	}
+	cfg, err := parseFlags(flagArgs, output)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.fetch == nil || cfg.fetch.spaceKey == "" {
+		err := fmt.Errorf("%s requires --url and --space", syncCommand)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	// The files describing the whole space would only list the pages
+	// that changed
+	if cfg.gitbookSummary || cfg.sitemap || cfg.searchIndex != "" || cfg.gitCommit {
+		err := fmt.Errorf("--gitbook-summary, --sitemap, --search-index, and --git-commit are not supported with %s", syncCommand)
+		fmt.Fprintf(output, "Error: %v\n", err)
+		return nil, err
+	}
+	cfg.fetch.sync = true
+	return cfg, nil
+}
+
+// loadSyncState reads the sync state of dir, or returns an empty state for
+// src when dir was never synced. A directory synced from another space is
+// rejected.
+func loadSyncState(dir string, src *fetchSource) (*syncState, error) {
+	state := &syncState{Site: src.baseURL, Space: src.spaceKey, Pages: make(map[string]syncedPage)}
+	data, err := os.ReadFile(filepath.Join(dir, syncStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid sync state %s: %w", filepath.Join(dir, syncStateFile), err)
+	}
+	if !strings.EqualFold(state.Space, src.spaceKey) || state.Site != src.baseURL {
+		return nil, fmt.Errorf("%s was synced from space %s of %s, not %s of %s", dir, state.Space, state.Site, src.spaceKey, src.baseURL)
+	}
+	if state.Pages == nil {
+		state.Pages = make(map[string]syncedPage)
+	}
+	return state, nil
+}
+
+// save writes the state to dir, replacing the previous one only once it is
+// complete.
+func (s *syncState) save(dir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+	tmp := filepath.Join(dir, syncStateFile+".tmp")
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, syncStateFile)); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
+
+// syncDirectory brings dir up to date with the space of src: pages created
+// or edited since the last sync are fetched and converted, and the exports
+// and outputs of pages deleted from the space are removed. Pages whose
+// conversion failed are converted again by the next sync.
+func syncDirectory(src *fetchSource, dir string, cfg *config) error {
+	state, err := loadSyncState(dir, src)
+	if err != nil {
+		return err
+	}
+	client, err := newFetchClient(src, cfg.credentials)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	current, err := listSpace(ctx, client, src.spaceKey)
+	if err != nil {
+		return err
+	}
+	// A page restricted since the last sync is removed like a deleted one
+	current = withoutRestricted(current, cfg)
+	if !cfg.dryRun {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	// Removed pages go first, freeing their names for new pages
+	listed := make(map[string]bool, len(current))
+	for _, page := range current {
+		listed[page.ID] = true
+	}
+	var removed []string
+	for id := range state.Pages {
+		if !listed[id] {
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(removed)
+	for _, id := range removed {
+		if err := removeSyncedPage(dir, state.Pages[id], cfg); err != nil {
+			return err
+		}
+		delete(state.Pages, id)
+	}
+
+	changed, err := fetchChanged(ctx, client, current, dir, state, cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Sync of space %s: %d changed, %d removed, %d unchanged\n", src.spaceKey, len(changed), len(removed), len(current)-len(changed))
+	if cfg.dryRun {
+		return nil
+	}
+	if len(changed) == 0 {
+		return state.save(dir)
+	}
+
+	cfg.inputs = make([]string, len(changed))
+	pending := make(map[string]pendingExport, len(changed))
+	for i, export := range changed {
+		cfg.inputs[i] = export.path
+		pending[export.path] = export
+	}
+	cfg.onConverted = func(inputPath string) {
+		export := pending[inputPath]
+		synced := state.Pages[export.id]
+		synced.Converted = export.version
+		state.Pages[export.id] = synced
+	}
+	convertErr := convertDirectory(dir, cfg)
+	return errors.Join(convertErr, state.save(dir))
+}
+
+// listSpace lists the current version of each page of a space.
+func listSpace(ctx context.Context, client *confluence.Client, spaceKey string) ([]*confluence.Page, error) {
+	var pages []*confluence.Page
+	err := client.SpaceVersions(ctx, spaceKey, func(page *confluence.Page) error {
+		pages = append(pages, page)
+		return nil
+	})
+	if err != nil {
+		return nil, fetchError(err)
+	}
+	return pages, nil
+}
+
+// pendingExport is the export of a page not yet converted at its current
+// version.
+type pendingExport struct {
+	id      string
+	path    string
+	version int
+}
+
+// fetchChanged downloads the pages of current whose export in dir is
+// missing or older than their current version, up to cfg.fetchJobs at
+// once, and records them in state. The state is saved every
+// stateSaveInterval pages and when a download fails, so that an
+// interrupted fetch resumes where it stopped. It returns the exports of
+// the pages not yet converted at their current version, in the order of
+// current. In a dry run the pages are listed instead.
+func fetchChanged(ctx context.Context, client *confluence.Client, current []*confluence.Page, dir string, state *syncState, cfg *config) ([]pendingExport, error) {
+	used := make(map[string]bool, len(state.Pages))
+	for _, synced := range state.Pages {
+		used[strings.ToLower(strings.TrimSuffix(synced.Export, ".doc"))] = true
+	}
+
+	// Name the new pages in listing order so that names are stable
+	var changed []pendingExport
+	var downloads []int
+	for _, page := range current {
+		synced, known := state.Pages[page.ID]
+		if known && synced.Converted == page.Version {
+			continue
+		}
+		if !known {
+			synced.Export = fetchFileName(page, used)
+			synced.Title = page.Title
+			state.Pages[page.ID] = synced
+		}
+		path := filepath.Join(dir, synced.Export)
+		if synced.Fetched != page.Version || !fileExists(path) {
+			if cfg.dryRun {
+				fmt.Printf("[dry-run] Would fetch: %s -> %s\n", page.Title, path)
+			}
+			downloads = append(downloads, len(changed))
+		}
+		changed = append(changed, pendingExport{id: page.ID, path: path, version: page.Version})
+	}
+	if cfg.dryRun || len(downloads) == 0 {
+		return changed, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	var errs []error
+	fetched := 0
+	runJobs(len(downloads), cfg.fetchJobs, func(i int) {
+		export := &changed[downloads[i]]
+		if ctx.Err() != nil {
+			return
+		}
+		page, err := fetchPage(ctx, client, export.id, cfg)
+		if err == nil {
+			err = writeFetchedExport(export.path, page)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if ctx.Err() == nil {
+				errs = append(errs, fetchError(err))
+				cancel()
+			}
+			return
+		}
+		synced := state.Pages[page.ID]
+		synced.Title, synced.Fetched = page.Title, page.Version
+		state.Pages[page.ID] = synced
+		export.version = page.Version
+		if cfg.verbose {
+			fmt.Printf("Fetched: %s -> %s\n", page.Title, export.path)
+		}
+		if fetched++; fetched%stateSaveInterval == 0 {
+			if err := state.save(dir); err != nil {
+				errs = append(errs, err)
+				cancel()
+			}
+		}
+	})
+	if len(errs) > 0 {
+		// The pages fetched so far are not downloaded again
+		return nil, errors.Join(append(errs, state.save(dir))...)
+	}
+	return changed, nil
+}
+
+// removeSyncedPage removes the output and export of a page deleted from
+// the synced space.
+func removeSyncedPage(dir string, synced syncedPage, cfg *config) error {
+	exportPath := filepath.Join(dir, synced.Export)
+	var paths []string
+	if fileExists(exportPath) {
+		// The output path may depend on the export's content
+		paths = append(paths, outputPathFor(exportPath, cfg))
+	}
+	paths = append(paths, exportPath)
+	for _, path := range paths {
+		if cfg.dryRun {
+			fmt.Printf("[dry-run] Would remove: %s\n", path)
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		if cfg.verbose {
+			fmt.Printf("Removed: %s\n", path)
+		}
+	}
+	return nil
+}
+
+// fileExists reports whether path names an existing file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
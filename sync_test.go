@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestParseSyncFlags(t *testing.T) {
+	cfg, err := parseSyncFlags([]string{"--url", "https://example.atlassian.net/wiki", "--space", "DOCS", "--out", "docs"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("parseSyncFlags() error = %v", err)
+	}
+	if cfg.fetch == nil || !cfg.fetch.sync || cfg.fetch.spaceKey != "DOCS" || cfg.dirMode != "docs" {
+		t.Errorf("parseSyncFlags() = fetch %+v, dirMode %q, want a sync of DOCS into docs", cfg.fetch, cfg.dirMode)
+	}
+
+	for _, args := range [][]string{
+		{"--url", "https://example.atlassian.net/wiki/spaces/DOCS/pages/1/Home"},
+		{"--url", "https://example.atlassian.net/wiki", "--space", "DOCS", "--sitemap"},
+	} {
+		if _, err := parseSyncFlags(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("parseSyncFlags(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+// fakeSpace serves the pages of a space through the content endpoints of
+// the REST API, counting the page bodies fetched.
+type fakeSpace struct {
+	mu       sync.Mutex
+	versions map[string]int
+	fetched  []string
+}
+
+func (s *fakeSpace) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Path == "/rest/api/content" {
+		var results []string
+		for _, id := range []string{"1", "2", "3"} {
+			if v, ok := s.versions[id]; ok && r.URL.Query().Get("type") == "page" {
+				results = append(results, fmt.Sprintf(`{"id": %q, "title": "Page %s", "version": {"number": %d}}`, id, id, v))
+			}
+		}
+		fmt.Fprintf(w, `{"results": [%s]}`, strings.Join(results, ", "))
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/rest/api/content/")
+	s.fetched = append(s.fetched, id)
+	fmt.Fprintf(w, `{"id": %q, "title": "Page %s", "version": {"number": %d}, "body": {"export_view": {"value": "<p>Page %s version %d</p>"}}}`,
+		id, id, s.versions[id], id, s.versions[id])
+}
+
+// sync sets the versions of the space's pages and syncs dir with it,
+// returning the IDs of the pages fetched.
+func (s *fakeSpace) sync(t *testing.T, src *fetchSource, dir string, versions map[string]int) []string {
+	t.Helper()
+	s.mu.Lock()
+	s.versions, s.fetched = versions, nil
+	s.mu.Unlock()
+	cfg := &config{jobs: 1, credentials: credentials{Token: "secret"}, sourceLink: sourceLinkNone, redactions: newRedactionLog(), options: converter.Options{Engine: converter.EngineSystem}}
+	if err := syncDirectory(src, dir, cfg); err != nil {
+		t.Fatalf("syncDirectory() error = %v", err)
+	}
+	return s.fetched
+}
+
+func TestSyncDirectory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake pandoc scripts are not executable on Windows")
+	}
+	binDir := t.TempDir()
+	script := "#!/bin/sh\nsed -e 's#<[^>]*>##g'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "pandoc"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	space := &fakeSpace{}
+	server := httptest.NewServer(space)
+	defer server.Close()
+	src := &fetchSource{baseURL: server.URL, spaceKey: "DOCS", sync: true}
+	dir := filepath.Join(t.TempDir(), "docs")
+
+	readOutput := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	if got := space.sync(t, src, dir, map[string]int{"1": 1, "2": 1}); strings.Join(got, ",") != "1,2" {
+		t.Errorf("first sync fetched %q, want 1,2", got)
+	}
+	if !strings.Contains(readOutput("Page-1.md"), "Page 1 version 1") || !strings.Contains(readOutput("Page-2.md"), "Page 2 version 1") {
+		t.Fatalf("first sync did not convert both pages: %q, %q", readOutput("Page-1.md"), readOutput("Page-2.md"))
+	}
+
+	// Page 1 is edited, page 2 deleted, and page 3 created
+	if got := space.sync(t, src, dir, map[string]int{"1": 2, "3": 1}); strings.Join(got, ",") != "1,3" {
+		t.Errorf("second sync fetched %q, want 1,3", got)
+	}
+	if !strings.Contains(readOutput("Page-1.md"), "Page 1 version 2") || !strings.Contains(readOutput("Page-3.md"), "Page 3 version 1") {
+		t.Errorf("second sync did not convert the changed pages: %q, %q", readOutput("Page-1.md"), readOutput("Page-3.md"))
+	}
+	for _, name := range []string{"Page-2.md", "Page-2.doc"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s of the deleted page was not removed", name)
+		}
+	}
+
+	if got := space.sync(t, src, dir, map[string]int{"1": 2, "3": 1}); len(got) != 0 {
+		t.Errorf("sync without changes fetched %q", got)
+	}
+
+	other := &fetchSource{baseURL: server.URL, spaceKey: "ENG", sync: true}
+	if err := syncDirectory(other, dir, &config{}); err == nil || !strings.Contains(err.Error(), "DOCS") {
+		t.Errorf("syncDirectory() of another space error = %v, want a mismatch error", err)
+	}
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tableCSVWriter writes the tables of converted pages to CSV files in dir
+// for --tables-to-csv, named after the output file and the table's index.
+type tableCSVWriter struct {
+	dir string
+	// maxRows replaces tables with more body rows than this with a link to
+	// their CSV file (0 keeps every table)
+	maxRows int
+}
+
+// csvPath returns the CSV file for table index of the page written to
+// outputPath.
+func (w *tableCSVWriter) csvPath(outputPath string, index int) string {
+	name := strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+	return filepath.Join(w.dir, fmt.Sprintf("%s-table-%d.csv", name, index))
+}
+
+// write saves a table of the page written to outputPath and returns the
+// link target replacing the table, relative to the page, or "" to keep the
+// table.
+func (w *tableCSVWriter) write(outputPath string, index int, rows [][]string) (string, error) {
+	path := w.csvPath(outputPath, index)
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create CSV directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to write table CSV: %w", err)
+	}
+	cw := csv.NewWriter(f)
+	if err := cw.WriteAll(normalizeCSVRows(rows)); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if w.maxRows <= 0 || len(rows)-1 <= w.maxRows {
+		return "", nil
+	}
+	link, err := filepath.Rel(filepath.Dir(outputPath), path)
+	if err != nil {
+		return "", fmt.Errorf("failed to link %s: %w", path, err)
+	}
+	return filepath.ToSlash(link), nil
+}
+
+// normalizeCSVRows pads rows to the same number of fields, since
+// spreadsheet tools expect rectangular CSV.
+func normalizeCSVRows(rows [][]string) [][]string {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	padded := make([][]string, len(rows))
+	for i, row := range rows {
+		padded[i] = append(row[:len(row):len(row)], make([]string, width-len(row))...)
+	}
+	return padded
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTableCSVWriter(t *testing.T) {
+	root := t.TempDir()
+	w := &tableCSVWriter{dir: filepath.Join(root, "csv"), maxRows: 2}
+	outputPath := filepath.Join(root, "docs", "Status.md")
+
+	link, err := w.write(outputPath, 1, [][]string{{"Task", "Owner"}, {"Deploy, then verify", "Ana"}, {"Audit"}})
+	if err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if link != "" {
+		t.Errorf("table within --csv-max-rows should be kept, got link %q", link)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "csv", "Status-table-1.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Task,Owner\n\"Deploy, then verify\",Ana\nAudit,\n"; string(data) != want {
+		t.Errorf("CSV = %q, want %q", data, want)
+	}
+
+	link, err = w.write(outputPath, 2, [][]string{{"N"}, {"1"}, {"2"}, {"3"}})
+	if err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if link != "../csv/Status-table-2.csv" {
+		t.Errorf("link = %q, want ../csv/Status-table-2.csv", link)
+	}
+}
+
+func TestNormalizeCSVRows(t *testing.T) {
+	rows := [][]string{{"a", "b", "c"}, {"d"}}
+	got := normalizeCSVRows(rows)
+	if want := [][]string{{"a", "b", "c"}, {"d", "", ""}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeCSVRows() = %q, want %q", got, want)
+	}
+	if len(rows[1]) != 1 {
+		t.Error("normalizeCSVRows must not modify its input")
+	}
+}
+
+func TestParseFlags_TablesToCSV(t *testing.T) {
+	cfg, err := parseFlags([]string{"--tables-to-csv", "csv", "--csv-max-rows", "50", "input.doc"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.tableCSV == nil || cfg.tableCSV.dir != "csv" || cfg.tableCSV.maxRows != 50 {
+		t.Errorf("Unexpected table CSV writer: %+v", cfg.tableCSV)
+	}
+
+	for _, args := range [][]string{
+		{"--csv-max-rows", "10", "input.doc"},
+		{"--tables-to-csv", "csv", "--csv-max-rows", "-1", "input.doc"},
+		{"--tables-to-csv", "csv", "--to", "org", "input.doc"},
+	} {
+		if _, err := parseFlags(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("parseFlags(%v) succeeded, want error", args)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// outputPathFor returns the default output path for an input file, taking
+// the site generator target, output format, chunking, routes, and the
+// breadcrumb tree into account.
+func outputPathFor(inputPath string, cfg *config) string {
+	var path string
+	if cfg.options.Target == converter.TargetJekyll {
+		path = jekyllOutputPath(inputPath)
+	} else {
+		path = generateOutputPath(inputPath)
+	}
+	if dir := routeDir(inputPath, cfg.routes, cfg.mimeLimits); dir != "" {
+		path = filepath.Join(filepath.Dir(path), dir, filepath.Base(path))
+	}
+	if cfg.tree {
+		if dir := treeDir(inputPath, cfg.mimeLimits); dir != "" {
+			path = filepath.Join(filepath.Dir(path), dir, filepath.Base(path))
+		}
+	}
+	ext := cfg.options.To.Extension()
+	if cfg.chunk {
+		ext = chunkExtension
+	}
+	if ext != ".md" {
+		path = strings.TrimSuffix(path, ".md") + ext
+	}
+	return path
+}
+
+// pageTitle returns the title of an exported page. The MIME Subject is used
+// when it names the page; otherwise the title is derived from the file name,
+// where Confluence encodes spaces as "+".
+func pageTitle(inputPath string, meta converter.ExportMetadata) string {
+	if title := meta.PageTitle(); title != "" {
+		return title
+	}
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(inputPath), ".doc"), converter.StorageExtension)
+	return strings.ReplaceAll(name, "+", " ")
+}
+
+// pageDate returns the export date of a page, falling back to the file's
+// modification time when the export has no usable Date header.
+func pageDate(inputPath string, meta converter.ExportMetadata) time.Time {
+	if !meta.Date.IsZero() {
+		return meta.Date
+	}
+	if info, err := os.Stat(inputPath); err == nil {
+		return info.ModTime()
+	}
+	return time.Now()
+}
+
+// jekyllOutputPath returns a Jekyll post file name (YYYY-MM-DD-title.md) in
+// the input file's directory.
+func jekyllOutputPath(inputPath string) string {
+	meta, _ := converter.ReadExportMetadata(inputPath)
+	date := pageDate(inputPath, meta).Format("2006-01-02")
+	return filepath.Join(filepath.Dir(inputPath), date+"-"+slugify(pageTitle(inputPath, meta))+".md")
+}
+
+// jekyllFrontMatter returns the front matter fields Jekyll needs for a post.
+func jekyllFrontMatter(inputPath, layout string) []converter.FrontMatterField {
+	meta, _ := converter.ReadExportMetadata(inputPath)
+	return []converter.FrontMatterField{
+		{Key: "layout", Value: layout},
+		{Key: "title", Value: pageTitle(inputPath, meta)},
+		{Key: "date", Value: pageDate(inputPath, meta)},
+	}
+}
+
+// slugify converts a title to a lowercase, hyphen-separated file name.
+func slugify(title string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && b.Len() > 0 {
+			b.WriteRune('-')
+			hyphen = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		return "untitled"
+	}
+	return slug
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Release Notes", "release-notes"},
+		{"  API: v2 / Overview!  ", "api-v2-overview"},
+		{"Über Uns", "über-uns"},
+		{"!!!", "untitled"},
+	}
+
+	for _, tt := range tests {
+		if got := slugify(tt.input); got != tt.expected {
+			t.Errorf("slugify(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestPageTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		subject  string
+		expected string
+	}{
+		{"generic subject uses file name", "/docs/Release+Notes.doc", "Exported From Confluence", "Release Notes"},
+		{"specific subject wins", "/docs/export.doc", "Team Handbook", "Team Handbook"},
+		{"empty subject uses file name", "/docs/Runbook.doc", "", "Runbook"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pageTitle(tt.path, converter.ExportMetadata{Subject: tt.subject})
+			if got != tt.expected {
+				t.Errorf("pageTitle() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJekyllOutputPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := createTestConfluenceMIME(t, tmpDir, "Release+Notes.doc", "<html><body><h1>Notes</h1></body></html>")
+
+	got := jekyllOutputPath(inputPath)
+	want := filepath.Join(tmpDir, "2026-01-07-release-notes.md")
+	if got != want {
+		t.Errorf("jekyllOutputPath() = %q, want %q", got, want)
+	}
+}
+
+func TestJekyllOutputPath_HTMLTitle(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := createTestConfluenceMIME(t, tmpDir, "export-123.doc", "<html><head><title>Release Notes</title></head><body><h1>Notes</h1></body></html>")
+
+	got := jekyllOutputPath(inputPath)
+	want := filepath.Join(tmpDir, "2026-01-07-release-notes.md")
+	if got != want {
+		t.Errorf("jekyllOutputPath() = %q, want %q", got, want)
+	}
+}
+
+func TestJekyllOutputPath_FallsBackToModTime(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := createPlainTextFile(t, tmpDir, "Notes.doc", "not a MIME file")
+	modTime := time.Date(2025, 3, 14, 12, 0, 0, 0, time.Local)
+	if err := os.Chtimes(inputPath, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set mod time: %v", err)
+	}
+
+	got := filepath.Base(jekyllOutputPath(inputPath))
+	if got != "2025-03-14-notes.md" {
+		t.Errorf("Expected mod time date prefix, got %q", got)
+	}
+}
+
+func TestJekyllFrontMatter(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := createTestConfluenceMIME(t, tmpDir, "Release+Notes.doc", "<html><body></body></html>")
+
+	fields := jekyllFrontMatter(inputPath, "post")
+	if len(fields) != 3 {
+		t.Fatalf("Expected 3 front matter fields, got %d", len(fields))
+	}
+
+	keys := []string{fields[0].Key, fields[1].Key, fields[2].Key}
+	if strings.Join(keys, ",") != "layout,title,date" {
+		t.Errorf("Unexpected front matter keys: %v", keys)
+	}
+	if fields[1].Value != "Release Notes" {
+		t.Errorf("Expected title from file name, got %v", fields[1].Value)
+	}
+}
+
+func TestOutputPathFor(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := createTestConfluenceMIME(t, tmpDir, "My+Page.doc", "<html><body></body></html>")
+
+	if got := outputPathFor(inputPath, &config{}); got != filepath.Join(tmpDir, "My-Page.md") {
+		t.Errorf("Expected default output path, got %q", got)
+	}
+
+	jekyll := &config{options: converter.Options{Target: converter.TargetJekyll}}
+	if got := outputPathFor(inputPath, jekyll); got != filepath.Join(tmpDir, "2026-01-07-my-page.md") {
+		t.Errorf("Expected Jekyll output path, got %q", got)
+	}
+
+	org := &config{options: converter.Options{To: converter.FormatOrg}}
+	if got := outputPathFor(inputPath, org); got != filepath.Join(tmpDir, "My-Page.org") {
+		t.Errorf("Expected org output path, got %q", got)
+	}
+
+	pdf := &config{options: converter.Options{To: converter.FormatPDF}}
+	if got := outputPathFor(inputPath, pdf); got != filepath.Join(tmpDir, "My-Page.pdf") {
+		t.Errorf("Expected PDF output path, got %q", got)
+	}
+}
@@ -0,0 +1,15 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// formatTransformTrace describes a pipeline step that changed the document
+// as "markdown step nbsp: 1024 -> 1020 bytes (-4)".
+func formatTransformTrace(step converter.Transform, before, after int) string {
+	return fmt.Sprintf("%s step %s: %d -> %d bytes (%+d)", step.Stage, step.Name, before, after, after-before)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+func TestFormatTransformTrace(t *testing.T) {
+	tests := []struct {
+		name          string
+		step          converter.Transform
+		before, after int
+		want          string
+	}{
+		{"shrunk", converter.Transform{Name: "nbsp", Stage: converter.StageMarkdown}, 1024, 1020, "markdown step nbsp: 1024 -> 1020 bytes (-4)"},
+		{"grown", converter.Transform{Name: "emoticons", Stage: converter.StageHTML}, 10, 16, "html step emoticons: 10 -> 16 bytes (+6)"},
+		{"same size", converter.Transform{Name: "links", Stage: converter.StageMarkdown}, 8, 8, "markdown step links: 8 -> 8 bytes (+0)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatTransformTrace(tt.step, tt.before, tt.after); got != tt.want {
+				t.Errorf("formatTransformTrace() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFlags_TraceTransforms(t *testing.T) {
+	var buf bytes.Buffer
+	cfg, err := parseFlags([]string{"--trace-transforms", "input.doc"}, &buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !cfg.traceTransforms {
+		t.Error("Expected --trace-transforms to enable tracing")
+	}
+}
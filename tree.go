@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// treeDir returns the directory, relative to the export's own directory,
+// that --tree places the page at inputPath in: one directory per breadcrumb,
+// named like output files (spaces become "-"). The page's own crumb, which
+// some exports end the breadcrumbs with, is left out. It returns "" when
+// the export has no breadcrumbs or cannot be read within limits.
+func treeDir(inputPath string, limits converter.MIMELimits) string {
+	html, err := converter.ReadPageHTML(inputPath, limits)
+	if err != nil {
+		return ""
+	}
+	crumbs := converter.ExtractPageInfo(html).Breadcrumbs
+	if n := len(crumbs); n > 0 {
+		meta, _ := converter.ReadExportMetadata(inputPath)
+		if strings.EqualFold(crumbs[n-1], pageTitle(inputPath, meta)) {
+			crumbs = crumbs[:n-1]
+		}
+	}
+
+	var dirs []string
+	for _, crumb := range crumbs {
+		if name := treeDirName(crumb); name != "" {
+			dirs = append(dirs, name)
+		}
+	}
+	return filepath.Join(dirs...)
+}
+
+// treeDirName turns a breadcrumb title into a directory name: spaces become
+// "-" as in output file names, and path separators and characters invalid
+// in Windows file names are replaced too. It returns "" for titles that
+// leave no usable name, such as "..".
+func treeDirName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r), unicode.IsControl(r), strings.ContainsRune(`/\:*?"<>|`, r):
+			return '-'
+		}
+		return r
+	}, title)
+	return strings.Trim(name, "-.")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestTreeDirName(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Engineering", "Engineering"},
+		{"Release Notes", "Release-Notes"},
+		{"CI/CD: How-to?", "CI-CD--How-to"},
+		{"..", ""},
+		{"  ", ""},
+		{"Ünïcode Pages", "Ünïcode-Pages"},
+	}
+	for _, tt := range tests {
+		if got := treeDirName(tt.title); got != tt.want {
+			t.Errorf("treeDirName(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestOutputPathFor_Tree(t *testing.T) {
+	tmpDir := t.TempDir()
+	nested := createTestConfluenceMIME(t, tmpDir, "Deploys.doc", `<meta name=3D"ajs-space-key" content=3D"OPS"><ol id=3D"breadcrumbs"><li><a href=3D"/display/OPS">Operations</a></li><li><a>Run Books</a></li></ol><p>x</p>`)
+	self := createTestConfluenceMIME(t, tmpDir, "On+Call.doc", `<ol id=3D"breadcrumbs"><li><a>Operations</a></li><li><a>On Call</a></li></ol><p>x</p>`)
+	flat := createTestConfluenceMIME(t, tmpDir, "Misc.doc", `<p>x</p>`)
+
+	tests := []struct {
+		name  string
+		cfg   *config
+		input string
+		want  string
+	}{
+		{"breadcrumbs", &config{tree: true}, nested, filepath.Join(tmpDir, "Operations", "Run-Books", "Deploys.md")},
+		{"own crumb left out", &config{tree: true}, self, filepath.Join(tmpDir, "Operations", "On-Call.md")},
+		{"no breadcrumbs", &config{tree: true}, flat, filepath.Join(tmpDir, "Misc.md")},
+		{"tree off", &config{}, nested, filepath.Join(tmpDir, "Deploys.md")},
+		{"below route", &config{tree: true, routes: []outputRoute{{Space: "OPS", Dir: "ops"}}}, nested, filepath.Join(tmpDir, "ops", "Operations", "Run-Books", "Deploys.md")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputPathFor(tt.input, tt.cfg); got != tt.want {
+				t.Errorf("outputPathFor(%s) = %s, want %s", filepath.Base(tt.input), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFlags_Tree(t *testing.T) {
+	cfg, err := parseFlags([]string{"--tree", "--dir", "exports"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !cfg.tree {
+		t.Error("Expected --tree to be set")
+	}
+}
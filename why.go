@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/aqueeb/confluence2md/converter"
+)
+
+// whyCommand is the subcommand explaining how files are classified.
+const whyCommand = "why"
+
+// runWhy explains, for each file in args, why it was or wasn't treated as a
+// Confluence export: which header checks matched, which were missing, the
+// content type, and whether an HTML part could be extracted. It returns the
+// process exit code.
+func runWhy(args []string, w io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintf(w, "Usage: confluence2md %s <file.doc>...\n", whyCommand)
+		return 1
+	}
+
+	code := 0
+	for i, path := range args {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if err := explainDetection(path, w); err != nil {
+			fmt.Fprintf(w, "%s: %v\n", path, err)
+			code = 1
+		}
+	}
+	return code
+}
+
+// explainDetection writes the detection report for one file.
+func explainDetection(path string, w io.Writer) error {
+	d, err := converter.DetectConfluenceMIME(path)
+	if err != nil {
+		return err
+	}
+
+	if d.IsConfluence() {
+		fmt.Fprintf(w, "%s: treated as a Confluence export\n", path)
+	} else {
+		fmt.Fprintf(w, "%s: not treated as a Confluence export\n", path)
+	}
+
+	fmt.Fprintf(w, "  Header checks (%d line(s) scanned):\n", d.LinesScanned)
+	for _, check := range d.Checks {
+		if check.Found() {
+			fmt.Fprintf(w, "    found    %s (line %d: %s)\n", check.Name, check.Line, check.Text)
+		} else {
+			fmt.Fprintf(w, "    missing  %s\n", check.Name)
+		}
+	}
+
+	switch {
+	case d.ParseError != "":
+		fmt.Fprintf(w, "  Content-Type: unknown (not a MIME message: %s)\n", d.ParseError)
+	case d.ContentType == "":
+		fmt.Fprintf(w, "  Content-Type: missing\n")
+	default:
+		fmt.Fprintf(w, "  Content-Type: %s\n", d.ContentType)
+	}
+
+	if d.ParseError == "" {
+		if html, err := converter.ExtractHTMLFromMIME(path); err != nil {
+			fmt.Fprintf(w, "  HTML part: %v\n", err)
+		} else {
+			fmt.Fprintf(w, "  HTML part: found (%s)\n", formatByteSize(int64(len(html))))
+		}
+	}
+
+	if !d.IsConfluence() {
+		fmt.Fprintln(w, "  Confluence \"Export to Word\" files start with Date, \"Subject: Exported From Confluence\", and MIME-Version headers.")
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunWhy(t *testing.T) {
+	tmpDir := t.TempDir()
+	export := createTestConfluenceMIME(t, tmpDir, "page.doc", "<html><body><p>Hi</p></body></html>")
+	plain := createPlainTextFile(t, tmpDir, "notes.doc", "Just some text")
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		want     []string
+	}{
+		{
+			name:     "confluence export",
+			args:     []string{export},
+			wantCode: 0,
+			want: []string{
+				export + ": treated as a Confluence export",
+				"found    Date header (line 1: Date: Wed, 7 Jan 2026 01:29:00 +0000 (UTC))",
+				"found    MIME-Version header (line 4: MIME-Version: 1.0)",
+				`found    "Exported From Confluence" subject (line 3: Subject: Exported From Confluence)`,
+				"Content-Type: multipart/related;",
+				"HTML part: found",
+			},
+		},
+		{
+			name:     "plain text",
+			args:     []string{plain},
+			wantCode: 0,
+			want: []string{
+				plain + ": not treated as a Confluence export",
+				"missing  Date header",
+				"missing  MIME-Version header",
+				"Content-Type: unknown (not a MIME message",
+				"Export to Word",
+			},
+		},
+		{
+			name:     "missing file",
+			args:     []string{filepath.Join(tmpDir, "nope.doc")},
+			wantCode: 1,
+			want:     []string{"failed to open file"},
+		},
+		{
+			name:     "no arguments",
+			args:     nil,
+			wantCode: 1,
+			want:     []string{"Usage: confluence2md why"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if code := runWhy(tt.args, &buf); code != tt.wantCode {
+				t.Errorf("runWhy() = %d, want %d", code, tt.wantCode)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}